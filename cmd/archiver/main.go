@@ -125,7 +125,7 @@ var (
 		"Define the auth type used, default to NOOP").
 		Default("NOOP").
 		Envar("AUTH_TYPE").
-		Enum("NOOP", "BASIC")
+		Enum("NOOP", "BASIC", "TOKEN")
 
 	authConfigFile = app.Flag(
 		"auth-config-file",
@@ -20,10 +20,12 @@ import (
 	"time"
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	cronsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc"
 	statelesssvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc"
 	podsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod/svc"
 	watchsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/watch/svc"
 	"github.com/uber/peloton/.gen/peloton/private/jobmgrsvc"
+	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 	"github.com/uber/peloton/.gen/thrift/aurora/api/auroraschedulermanagerserver"
 	"github.com/uber/peloton/.gen/thrift/aurora/api/readonlyschedulerserver"
 	auth_impl "github.com/uber/peloton/pkg/auth/impl"
@@ -103,7 +105,7 @@ var (
 		"Define the auth type used, default to NOOP").
 		Default("NOOP").
 		Envar("AUTH_TYPE").
-		Enum("NOOP", "BASIC")
+		Enum("NOOP", "BASIC", "TOKEN")
 
 	authConfigFile = app.Flag(
 		"auth-config-file",
@@ -260,9 +262,15 @@ func main() {
 	podClient := podsvc.NewPodServiceYARPCClient(
 		dispatcher.ClientConfig(common.PelotonJobManager))
 
+	cronClient := cronsvc.NewCronJobServiceYARPCClient(
+		dispatcher.ClientConfig(common.PelotonJobManager))
+
 	respoolClient := respool.NewResourceManagerYARPCClient(
 		dispatcher.ClientConfig(common.PelotonResourceManager))
 
+	resmgrClient := resmgrsvc.NewResourceManagerServiceYARPCClient(
+		dispatcher.ClientConfig(common.PelotonResourceManager))
+
 	watchClient := watchsvc.NewWatchServiceYARPCClient(
 		dispatcher.ClientConfig(common.PelotonJobManager))
 
@@ -309,6 +317,9 @@ func main() {
 		jobClient,
 		jobmgrClient,
 		podClient,
+		cronClient,
+		resmgrClient,
+		respoolClient,
 		respoolLoader,
 		bridgecommon.RandomImpl{},
 	)
@@ -178,3 +178,18 @@ func TestHostmgrHosts(t *testing.T) {
 	cmd, err = app.Parse([]string{"hostmgr", "hosts", "--cpu", "-1.0", "--gpu", "-5.0"})
 	assert.Error(t, err)
 }
+
+func TestParseApply(t *testing.T) {
+	dir := "../../example/stateless"
+	cmd, err := app.Parse([]string{"apply", "-f", dir, "-r", "/infra/compute",
+		"--prune", "--dry-run", "--no-wait"})
+	assert.Nil(t, err)
+	assert.Equal(t, apply.FullCommand(), cmd)
+	assert.Equal(t, dir, *applyPath)
+	assert.Equal(t, "/infra/compute", *applyResPoolPath)
+	assert.Equal(t, uint32(1), *applyBatchSize)
+	assert.Equal(t, "default", *applySetName)
+	assert.True(t, *applyPrune)
+	assert.True(t, *applyDryRun)
+	assert.False(t, *applyWait)
+}
@@ -23,6 +23,7 @@ import (
 
 	pt "github.com/uber/peloton/.gen/peloton/api/v0/task"
 
+	"github.com/uber/peloton/pkg/archiver/sink"
 	pc "github.com/uber/peloton/pkg/cli"
 	"github.com/uber/peloton/pkg/cli/config"
 	"github.com/uber/peloton/pkg/cli/middleware"
@@ -106,6 +107,18 @@ var (
 		Envar("BASIC_AUTH_CONFIG").
 		String()
 
+	authToken = app.Flag(
+		"authToken",
+		"signed bearer token for token auth feature, takes precedence over basicAuthConfig").
+		Envar("AUTH_TOKEN").
+		String()
+
+	authTokenFile = app.Flag(
+		"authTokenFile",
+		"file path containing the signed bearer token for token auth feature").
+		Envar("AUTH_TOKEN_FILE").
+		String()
+
 	timeout = app.Flag(
 		"timeout",
 		"default RPC timeout (set $TIMEOUT to override)").
@@ -137,8 +150,13 @@ var (
 	jobStopLabels = jobStop.Flag("labels", "job labels").Default("").Short('l').String()
 	jobStopForce  = jobStop.Flag("force", "force stop").Default("false").Short('f').Bool()
 
-	jobGet     = job.Command("get", "get a job")
-	jobGetName = jobGet.Arg("job", "job identifier").Required().String()
+	jobGet         = job.Command("get", "get a job")
+	jobGetName     = jobGet.Arg("job", "job identifier").Required().String()
+	jobGetArchived = jobGet.Flag("archived",
+		"get the job from the archive written by archiver, which is on the local "+
+			"filesystem of the archiver host, so this must run on that host").Default("false").Bool()
+	jobGetArchiveDir = jobGet.Flag("archive-dir",
+		"local directory of the archive, the archive_dir of archiver").Default(sink.DefaultLocalDir).String()
 
 	jobRefresh     = job.Command("refresh", "load runtime state of job and re-refresh corresponding action (debug only)")
 	jobRefreshName = jobRefresh.Arg("job", "job identifier").Required().String()
@@ -209,10 +227,12 @@ var (
 
 	watch = app.Command("watch", "watch job / pod runtime changes")
 
-	watchPod         = watch.Command("pod", "watch pod runtime changes")
-	watchPodJobID    = watchPod.Arg("job", "job identifier").String()
-	watchPodPodNames = watchPod.Arg("pod", "pod name").Strings()
-	watchLabels      = watchPod.Flag("labels", "filter on labels (key:value pairs)").Strings()
+	watchPod              = watch.Command("pod", "watch pod runtime changes")
+	watchPodJobID         = watchPod.Arg("job", "job identifier").String()
+	watchPodPodNames      = watchPod.Arg("pod", "pod name").Strings()
+	watchLabels           = watchPod.Flag("labels", "filter on labels (key:value pairs)").Strings()
+	watchPodStartRevision = watchPod.Flag("start-revision",
+		"resume the watch from the given revision, e.g. the last revision received plus one").Default("0").Uint64()
 
 	watchCancel        = watch.Command("cancel", "cancel watch")
 	watchCancelWatchID = watchCancel.Arg("id", "watch id").Required().String()
@@ -364,6 +384,38 @@ var (
 		"and the job cannot be re-created (with same uuid) till the delete is complete. "+
 		"USE WITH CAUTION!").Default("false").Short('f').Bool()
 
+	// Top level job command for cron jobs
+	cron = job.Command("cron", "manage cron jobs which create batch jobs on a schedule")
+
+	cronCreate            = cron.Command("create", "create a cron job")
+	cronCreateName        = cronCreate.Arg("name", "unique name of the cron job").Required().String()
+	cronCreateSchedule    = cronCreate.Arg("schedule", "schedule in cron format, e.g. \"*/15 * * * *\" (UTC)").Required().String()
+	cronCreateResPoolPath = cronCreate.Arg("respool", "complete path of the "+
+		"resource pool starting from the root").Required().String()
+	cronCreateSpec            = cronCreate.Arg("spec", "YAML job specification of the template").Required().ExistingFile()
+	cronCreateCollisionPolicy = cronCreate.Flag("collision-policy",
+		"policy when previous runs are still active (kill_existing, skip, run_overlap)").Default("kill_existing").String()
+
+	cronReplace            = cron.Command("replace", "replace the schedule, collision policy and template of a cron job")
+	cronReplaceName        = cronReplace.Arg("name", "name of the cron job").Required().String()
+	cronReplaceSchedule    = cronReplace.Arg("schedule", "schedule in cron format, e.g. \"*/15 * * * *\" (UTC)").Required().String()
+	cronReplaceResPoolPath = cronReplace.Arg("respool", "complete path of the "+
+		"resource pool starting from the root").Required().String()
+	cronReplaceSpec            = cronReplace.Arg("spec", "YAML job specification of the template").Required().ExistingFile()
+	cronReplaceCollisionPolicy = cronReplace.Flag("collision-policy",
+		"policy when previous runs are still active (kill_existing, skip, run_overlap)").Default("kill_existing").String()
+
+	cronGet     = cron.Command("get", "get the spec and status of a cron job")
+	cronGetName = cronGet.Arg("name", "name of the cron job").Required().String()
+
+	cronList = cron.Command("list", "list all cron jobs")
+
+	cronDelete     = cron.Command("delete", "delete a cron job, the jobs it created are not affected")
+	cronDeleteName = cronDelete.Arg("name", "name of the cron job").Required().String()
+
+	cronStart     = cron.Command("start", "start a run of a cron job now")
+	cronStartName = cronStart.Arg("name", "name of the cron job").Required().String()
+
 	// Top level pod command
 	pod = app.Command("pod", "CLI reflects pod(s) actions, such as get pod details, create/restart/update a pod...")
 
@@ -376,6 +428,9 @@ var (
 	podGetCache        = pod.Command("cache", "get pod status from cache")
 	podGetCachePodName = podGetCache.Arg("name", "pod name").Required().String()
 
+	podWhy        = pod.Command("why", "show why a pod is not running yet")
+	podWhyPodName = podWhy.Arg("name", "pod name").Required().String()
+
 	podGetEventsV1Alpha        = pod.Command("events-v1alpha", "get pod events")
 	podGetEventsV1AlphaPodName = podGetEventsV1Alpha.Arg("name", "pod name").Required().String()
 	podGetEventsV1AlphaPodID   = podGetEventsV1Alpha.Flag("id", "pod identifier").Short('p').String()
@@ -437,6 +492,10 @@ var (
 	taskGetCacheName       = taskGetCache.Arg("job", "job identifier").Required().String()
 	taskGetCacheInstanceID = taskGetCache.Arg("instance", "job instance id").Required().Uint32()
 
+	taskWhy           = task.Command("why", "show why a task is not running yet")
+	taskWhyJobName    = taskWhy.Arg("job", "job identifier").Required().String()
+	taskWhyInstanceID = taskWhy.Arg("instance", "job instance id").Required().Uint32()
+
 	taskGetEvents           = task.Command("events", "show task events")
 	taskGetEventsJobName    = taskGetEvents.Arg("job", "job identifier").Required().String()
 	taskGetEventsInstanceID = taskGetEvents.Arg("instance", "job instance id").Required().Uint32()
@@ -539,6 +598,18 @@ var (
 	hostMaintenanceComplete          = hostMaintenance.Command("complete", "complete host maintenance on a list of hosts")
 	hostMaintenanceCompleteHostnames = hostMaintenanceComplete.Arg("hostnames", "comma separated hostnames").Required().String()
 
+	hostMaintenanceSchedule          = hostMaintenance.Command("schedule", "schedule a maintenance window on a list of hosts")
+	hostMaintenanceScheduleHostnames = hostMaintenanceSchedule.Arg("hostnames", "comma separated hostnames").Required().String()
+	hostMaintenanceScheduleStart     = hostMaintenanceSchedule.Flag("start", "start time of the window in RFC3339 format").Required().String()
+	hostMaintenanceScheduleEnd       = hostMaintenanceSchedule.Flag("end", "end time of the window in RFC3339 format").Default("").String()
+	hostMaintenanceScheduleReason    = hostMaintenanceSchedule.Flag("reason", "reason of the maintenance").Default("").String()
+
+	hostMaintenanceList       = hostMaintenance.Command("list", "list maintenance windows by state(s)")
+	hostMaintenanceListStates = hostMaintenanceList.Flag("states", "maintenance window state(s) to filter").Default("").Short('s').String()
+
+	hostMaintenanceCancel         = hostMaintenance.Command("cancel", "cancel a scheduled maintenance window")
+	hostMaintenanceCancelWindowID = hostMaintenanceCancel.Arg("window", "maintenance window identifier").Required().String()
+
 	hostQuery       = host.Command("query", "query hosts by state(s)")
 	hostQueryStates = hostQuery.Flag("states", "host state(s) to filter").Default("").Short('s').String()
 
@@ -608,6 +679,56 @@ var (
 	updateResumeOpaqueData = updateResume.Flag("opaque-data",
 		"opaque data provided by the user").Default("").String()
 
+	// Top level pipeline command
+	pipeline = app.Command("pipeline", "manage pipelines which run DAGs of batch jobs")
+
+	pipelineCreate            = pipeline.Command("create", "create a pipeline and start its root batch jobs")
+	pipelineCreateResPoolPath = pipelineCreate.Arg("respool", "complete path of the "+
+		"resource pool of the batch jobs starting from the root").Required().String()
+	pipelineCreateSpec = pipelineCreate.Arg("spec", "YAML pipeline specification").Required().ExistingFile()
+
+	pipelineGet   = pipeline.Command("get", "get the spec and status of a pipeline")
+	pipelineGetID = pipelineGet.Arg("id", "pipeline identifier").Required().String()
+
+	pipelineList = pipeline.Command("list", "list the pipelines which have not terminated yet")
+
+	pipelineKill   = pipeline.Command("kill", "kill the batch jobs of a pipeline")
+	pipelineKillID = pipelineKill.Arg("id", "pipeline identifier").Required().String()
+
+	// Top level audit command
+	audit = app.Command("audit", "inspect the audit log of the mutating calls")
+
+	auditQuery          = audit.Command("query", "query the audit events, most recent first")
+	auditQueryUser      = auditQuery.Flag("user", "name of the user who made the calls").Default("").String()
+	auditQueryProcedure = auditQuery.Flag("procedure", "substring of the called procedures").Default("").String()
+	auditQueryJobID     = auditQuery.Flag("job", "job identifier targeted by the calls").Default("").String()
+	auditQueryPodName   = auditQuery.Flag("pod", "pod name targeted by the calls").Default("").String()
+	auditQueryRespoolID = auditQuery.Flag("respool", "resource pool identifier targeted by the calls").Default("").String()
+	auditQueryStart     = auditQuery.Flag("start", "RFC3339 start of the time range, "+
+		"defaults to one day before the end").Default("").String()
+	auditQueryEnd = auditQuery.Flag("end", "RFC3339 end of the time range, "+
+		"defaults to now").Default("").String()
+	auditQueryLimit = auditQuery.Flag("limit", "maximum number of events to return").Default("100").Uint32()
+
+	// Top level apply command
+	apply = app.Command("apply", "create or replace stateless jobs "+
+		"by name from YAML job specs")
+	applyPath = apply.Flag("filename", "YAML job spec, or directory "+
+		"of YAML job specs").Short('f').Required().ExistingFileOrDir()
+	applyResPoolPath = apply.Flag("respool", "complete path of the "+
+		"resource pool of the job specs without one").Short('r').Default("").String()
+	applyBatchSize = apply.Flag("batch-size", "batch size of the create "+
+		"and replace workflows").Default("1").Uint32()
+	applySetName = apply.Flag("set", "name of the set of jobs managed "+
+		"together, used to find the jobs to prune").Default("default").String()
+	applyDryRun = apply.Flag("dry-run", "only show what would be "+
+		"created, replaced and deleted").Default("false").Bool()
+	applyPrune = apply.Flag("prune", "delete the jobs of the set which "+
+		"are not in the job specs anymore, running jobs are stopped "+
+		"and deleted by force").Default("false").Bool()
+	applyWait = apply.Flag("wait", "wait for the workflows to "+
+		"complete").Default("true").Bool()
+
 	// Top level hostmgr command
 	hostmgr = app.Command("hostmgr", "top level command for hostmgr")
 
@@ -769,7 +890,23 @@ func main() {
 		basicAuthConfigPtr = &basicAuthConfig
 	}
 
-	client, err := pc.New(discovery, *timeout, basicAuthConfigPtr, *jsonFormat)
+	var tokenAuthConfigPtr *middleware.TokenAuthConfig
+	if len(*authToken) != 0 {
+		tokenAuthConfigPtr = &middleware.TokenAuthConfig{Token: *authToken}
+	} else if len(*authTokenFile) != 0 {
+		tokenAuthConfigPtr, err = middleware.LoadTokenAuthConfig(*authTokenFile)
+		if err != nil {
+			app.FatalIfError(err, "Fail to load auth token file")
+		}
+	}
+
+	client, err := pc.New(
+		discovery,
+		*timeout,
+		basicAuthConfigPtr,
+		tokenAuthConfigPtr,
+		*jsonFormat,
+	)
 	if err != nil {
 		app.FatalIfError(err, "Fail to initialize client")
 	}
@@ -790,7 +927,11 @@ func main() {
 			*jobStopForce,
 		)
 	case jobGet.FullCommand():
-		err = client.JobGetAction(*jobGetName)
+		if *jobGetArchived {
+			err = client.JobGetArchivedAction(*jobGetName, *jobGetArchiveDir)
+		} else {
+			err = client.JobGetAction(*jobGetName)
+		}
 	case jobRefresh.FullCommand():
 		err = client.JobRefreshAction(*jobRefreshName)
 	case jobStatus.FullCommand():
@@ -814,6 +955,8 @@ func main() {
 		err = client.TaskGetAction(*taskGetJobName, *taskGetInstanceID)
 	case taskGetCache.FullCommand():
 		err = client.TaskGetCacheAction(*taskGetCacheName, *taskGetCacheInstanceID)
+	case taskWhy.FullCommand():
+		err = client.TaskWhyAction(*taskWhyJobName, *taskWhyInstanceID)
 	case taskGetEvents.FullCommand():
 		err = client.TaskGetEventsAction(*taskGetEventsJobName, *taskGetEventsInstanceID)
 	case taskLogsGet.FullCommand():
@@ -835,6 +978,16 @@ func main() {
 		err = client.HostMaintenanceStartAction(*hostMaintenanceStartHostnames)
 	case hostMaintenanceComplete.FullCommand():
 		err = client.HostMaintenanceCompleteAction(*hostMaintenanceCompleteHostnames)
+	case hostMaintenanceSchedule.FullCommand():
+		err = client.HostMaintenanceScheduleAction(
+			*hostMaintenanceScheduleHostnames,
+			*hostMaintenanceScheduleStart,
+			*hostMaintenanceScheduleEnd,
+			*hostMaintenanceScheduleReason)
+	case hostMaintenanceList.FullCommand():
+		err = client.HostMaintenanceListAction(*hostMaintenanceListStates)
+	case hostMaintenanceCancel.FullCommand():
+		err = client.HostMaintenanceCancelAction(*hostMaintenanceCancelWindowID)
 	case hostQuery.FullCommand():
 		err = client.HostQueryAction(*hostQueryStates)
 	case jobMgrThrottledPods.FullCommand():
@@ -885,6 +1038,35 @@ func main() {
 		err = client.UpdatePauseAction(*updatePauseID, *updatePauseOpaqueData)
 	case updateResume.FullCommand():
 		err = client.UpdateResumeAction(*updateResumeID, *updateResumeOpaqueData)
+	case pipelineCreate.FullCommand():
+		err = client.PipelineCreateAction(*pipelineCreateResPoolPath, *pipelineCreateSpec)
+	case pipelineGet.FullCommand():
+		err = client.PipelineGetAction(*pipelineGetID)
+	case pipelineList.FullCommand():
+		err = client.PipelineListAction()
+	case pipelineKill.FullCommand():
+		err = client.PipelineKillAction(*pipelineKillID)
+	case auditQuery.FullCommand():
+		err = client.AuditQueryAction(
+			*auditQueryUser,
+			*auditQueryProcedure,
+			*auditQueryJobID,
+			*auditQueryPodName,
+			*auditQueryRespoolID,
+			*auditQueryStart,
+			*auditQueryEnd,
+			*auditQueryLimit,
+		)
+	case apply.FullCommand():
+		err = client.ApplyAction(
+			*applyPath,
+			*applyResPoolPath,
+			*applyBatchSize,
+			*applySetName,
+			*applyDryRun,
+			*applyPrune,
+			*applyWait,
+		)
 	case offers.FullCommand():
 		err = client.OffersGetAction()
 	case getHosts.FullCommand():
@@ -895,6 +1077,8 @@ func main() {
 		err = client.PodGetEventsAction(*podGetEventsJobName, *podGetEventsInstanceID, *podGetEventsRunID, *podGetEventsLimit)
 	case podGetCache.FullCommand():
 		err = client.PodGetCacheAction(*podGetCachePodName)
+	case podWhy.FullCommand():
+		err = client.PodWhyAction(*podWhyPodName)
 	case podGetEventsV1Alpha.FullCommand():
 		err = client.PodGetEventsV1AlphaAction(*podGetEventsV1AlphaPodName, *podGetEventsV1AlphaPodID)
 	case podRefresh.FullCommand():
@@ -1018,8 +1202,32 @@ func main() {
 			*statelessDeleteEntityVersion,
 			*statelessDeleteForce,
 		)
+	case cronCreate.FullCommand():
+		err = client.CronCreateAction(
+			*cronCreateName,
+			*cronCreateSchedule,
+			*cronCreateCollisionPolicy,
+			*cronCreateResPoolPath,
+			*cronCreateSpec,
+		)
+	case cronReplace.FullCommand():
+		err = client.CronReplaceAction(
+			*cronReplaceName,
+			*cronReplaceSchedule,
+			*cronReplaceCollisionPolicy,
+			*cronReplaceResPoolPath,
+			*cronReplaceSpec,
+		)
+	case cronGet.FullCommand():
+		err = client.CronGetAction(*cronGetName)
+	case cronList.FullCommand():
+		err = client.CronListAction()
+	case cronDelete.FullCommand():
+		err = client.CronDeleteAction(*cronDeleteName)
+	case cronStart.FullCommand():
+		err = client.CronStartAction(*cronStartName)
 	case watchPod.FullCommand():
-		err = client.WatchPod(*watchPodJobID, *watchPodPodNames, *watchLabels)
+		err = client.WatchPod(*watchPodJobID, *watchPodPodNames, *watchLabels, *watchPodStartRevision)
 	case watchCancel.FullCommand():
 		err = client.CancelWatch(*watchCancelWatchID)
 	default:
@@ -34,6 +34,7 @@ import (
 	"github.com/uber/peloton/pkg/common/logging"
 	"github.com/uber/peloton/pkg/common/metrics"
 	"github.com/uber/peloton/pkg/common/rpc"
+	"github.com/uber/peloton/pkg/common/tracing"
 	"github.com/uber/peloton/pkg/hostmgr"
 	bin_packing "github.com/uber/peloton/pkg/hostmgr/binpacking"
 	"github.com/uber/peloton/pkg/hostmgr/host"
@@ -50,6 +51,7 @@ import (
 	"github.com/uber/peloton/pkg/hostmgr/watchevent"
 	"github.com/uber/peloton/pkg/middleware/inbound"
 	"github.com/uber/peloton/pkg/middleware/outbound"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 	"github.com/uber/peloton/pkg/storage/stores"
 
 	log "github.com/sirupsen/logrus"
@@ -188,7 +190,7 @@ var (
 		"Define the auth type used, default to NOOP").
 		Default("NOOP").
 		Envar("AUTH_TYPE").
-		Enum("NOOP", "BASIC")
+		Enum("NOOP", "BASIC", "TOKEN")
 
 	authConfigFile = app.Flag(
 		"auth-config-file",
@@ -320,6 +322,17 @@ func main() {
 	)
 	defer scopeCloser.Close()
 
+	tracerCloser, err := tracing.InitGlobalTracer(
+		cfg.Tracing,
+		common.PelotonHostManager,
+		rootScope.SubScope("tracing"),
+	)
+	if err != nil {
+		log.WithError(err).
+			Fatal("Could not initialize tracing")
+	}
+	defer tracerCloser.Close()
+
 	mux.HandleFunc(
 		logging.LevelOverwrite,
 		logging.LevelOverwriteHandler(initialLevel))
@@ -344,6 +357,7 @@ func main() {
 	rootScope.Counter("boot").Inc(1)
 
 	store := stores.MustCreateStore(&cfg.Storage, rootScope)
+	ormStore := stores.MustCreateORMStore(&cfg.Storage, rootScope)
 
 	authHeader, err := mesos.GetAuthHeader(&cfg.Mesos, *mesosSecretFile)
 	if err != nil {
@@ -421,6 +435,7 @@ func main() {
 	}
 
 	authInboundMiddleware := inbound.NewAuthInboundMiddleware(securityManager)
+	tracingMiddleware := &inbound.TracingInboundMiddleware{}
 
 	securityClient, err := auth_impl.CreateNewSecurityClient(&cfg.Auth)
 	if err != nil {
@@ -438,9 +453,9 @@ func main() {
 			Tally: rootScope,
 		},
 		InboundMiddleware: yarpc.InboundMiddleware{
-			Unary:  authInboundMiddleware,
-			Oneway: authInboundMiddleware,
-			Stream: authInboundMiddleware,
+			Unary:  yarpc.UnaryInboundMiddleware(authInboundMiddleware, tracingMiddleware),
+			Oneway: yarpc.OnewayInboundMiddleware(authInboundMiddleware, tracingMiddleware),
+			Stream: yarpc.StreamInboundMiddleware(authInboundMiddleware, tracingMiddleware),
 		},
 		OutboundMiddleware: yarpc.OutboundMiddleware{
 			Unary:  authOutboundMiddleware,
@@ -535,6 +550,9 @@ func main() {
 	}
 
 	bin_packing.Init()
+	bin_packing.InitWeighted(
+		cfg.HostManager.BinPackingWeights,
+		cfg.HostManager.ScarceResourceTypes)
 	log.WithField("ranker_name", cfg.HostManager.BinPacking).
 		Info("Bin packing is enabled")
 	defaultRanker := bin_packing.GetRankerByName(cfg.HostManager.BinPacking)
@@ -604,6 +622,9 @@ func main() {
 		masterOperatorClient,
 		maintenanceQueue,
 		maintenanceHostInfoMap,
+		ormobjects.NewMaintenanceWindowOps(ormStore),
+		backgroundManager,
+		cfg.HostManager.MaintenanceSchedulerPeriod,
 	)
 
 	// Register background worker to start mesos task status update counter.
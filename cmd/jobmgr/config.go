@@ -20,6 +20,7 @@ import (
 	"github.com/uber/peloton/pkg/common/leader"
 	"github.com/uber/peloton/pkg/common/logging"
 	"github.com/uber/peloton/pkg/common/metrics"
+	"github.com/uber/peloton/pkg/common/tracing"
 	"github.com/uber/peloton/pkg/jobmgr"
 	"github.com/uber/peloton/pkg/middleware/inbound"
 	storage "github.com/uber/peloton/pkg/storage/config"
@@ -34,5 +35,7 @@ type Config struct {
 	Health       health.Config           `yaml:"health"`
 	SentryConfig logging.SentryConfig    `yaml:"sentry"`
 	Auth         auth.Config             `yaml:"auth"`
+	Tracing      tracing.Config          `yaml:"tracing"`
 	RateLimit    inbound.RateLimitConfig `yaml:"rate_limit"`
+	Audit        inbound.AuditConfig     `yaml:"audit"`
 }
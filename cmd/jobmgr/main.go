@@ -19,6 +19,7 @@ import (
 	"os"
 	"time"
 
+	host_svc "github.com/uber/peloton/.gen/peloton/api/v0/host/svc"
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
 
@@ -33,14 +34,21 @@ import (
 	"github.com/uber/peloton/pkg/common/logging"
 	"github.com/uber/peloton/pkg/common/metrics"
 	"github.com/uber/peloton/pkg/common/rpc"
+	"github.com/uber/peloton/pkg/common/tracing"
 	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/peer"
 	"github.com/uber/peloton/pkg/jobmgr"
+	"github.com/uber/peloton/pkg/jobmgr/autoscaler"
 	"github.com/uber/peloton/pkg/jobmgr/cached"
+	"github.com/uber/peloton/pkg/jobmgr/cron"
+	"github.com/uber/peloton/pkg/jobmgr/cronsvc"
+	"github.com/uber/peloton/pkg/jobmgr/daemon"
 	"github.com/uber/peloton/pkg/jobmgr/goalstate"
 	"github.com/uber/peloton/pkg/jobmgr/jobsvc"
 	"github.com/uber/peloton/pkg/jobmgr/jobsvc/private"
 	"github.com/uber/peloton/pkg/jobmgr/jobsvc/stateless"
 	"github.com/uber/peloton/pkg/jobmgr/logmanager"
+	"github.com/uber/peloton/pkg/jobmgr/pipeline"
+	"github.com/uber/peloton/pkg/jobmgr/pipelinesvc"
 	"github.com/uber/peloton/pkg/jobmgr/podsvc"
 	"github.com/uber/peloton/pkg/jobmgr/task/activermtask"
 	"github.com/uber/peloton/pkg/jobmgr/task/deadline"
@@ -203,7 +211,7 @@ var (
 		"Define the auth type used, default to NOOP").
 		Default("NOOP").
 		Envar("AUTH_TYPE").
-		Enum("NOOP", "BASIC")
+		Enum("NOOP", "BASIC", "TOKEN")
 
 	authConfigFile = app.Flag(
 		"auth-config-file",
@@ -371,6 +379,17 @@ func main() {
 	)
 	defer scopeCloser.Close()
 
+	tracerCloser, err := tracing.InitGlobalTracer(
+		cfg.Tracing,
+		common.PelotonJobManager,
+		rootScope.SubScope("tracing"),
+	)
+	if err != nil {
+		log.WithError(err).
+			Fatal("Could not initialize tracing")
+	}
+	defer tracerCloser.Close()
+
 	mux.HandleFunc(
 		logging.LevelOverwrite,
 		logging.LevelOverwriteHandler(initialLevel),
@@ -381,12 +400,7 @@ func main() {
 	// store implements JobStore, TaskStore, VolumeStore, UpdateStore
 	// and FrameworkInfoStore
 	store := stores.MustCreateStore(&cfg.Storage, rootScope)
-	ormStore, ormErr := ormobjects.NewCassandraStore(
-		&cfg.Storage.Cassandra,
-		rootScope)
-	if ormErr != nil {
-		log.WithError(ormErr).Fatal("Failed to create ORM store for Cassandra")
-	}
+	ormStore := stores.MustCreateORMStore(&cfg.Storage, rootScope)
 
 	// Create both HTTP and GRPC inbounds
 	inbounds := rpc.NewInbounds(
@@ -446,12 +460,25 @@ func main() {
 			Fatal("Could not enable security feature")
 	}
 
-	rateLimitMiddleware, err := inbound.NewRateLimitInboundMiddleware(cfg.RateLimit)
+	preAuthRateLimitMiddleware, rateLimitMiddleware, err := inbound.NewRateLimitInboundMiddlewares(
+		cfg.RateLimit,
+		rootScope.SubScope("rate_limit"),
+	)
 	if err != nil {
 		log.WithError(err).
 			Fatal("Could not create rate limit middleware")
 	}
 	authInboundMiddleware := inbound.NewAuthInboundMiddleware(securityManager)
+	tracingMiddleware := &inbound.TracingInboundMiddleware{}
+	auditMiddleware, err := inbound.NewAuditInboundMiddleware(
+		cfg.Audit,
+		ormobjects.NewAuditEventOps(ormStore),
+		rootScope.SubScope("audit"),
+	)
+	if err != nil {
+		log.WithError(err).
+			Fatal("Could not create audit middleware")
+	}
 	yarpcMetricsMiddleware := &inbound.YAPRCMetricsInboundMiddleware{Scope: rootScope.SubScope("yarpc")}
 
 	securityClient, err := auth_impl.CreateNewSecurityClient(&cfg.Auth)
@@ -469,10 +496,12 @@ func main() {
 		Metrics: yarpc.MetricsConfig{
 			Tally: rootScope,
 		},
+		// the calls are rate limited before authentication, and
+		// after authentication if they are limited per authenticated user
 		InboundMiddleware: yarpc.InboundMiddleware{
-			Unary:  yarpc.UnaryInboundMiddleware(rateLimitMiddleware, authInboundMiddleware, yarpcMetricsMiddleware),
-			Stream: yarpc.StreamInboundMiddleware(rateLimitMiddleware, authInboundMiddleware, yarpcMetricsMiddleware),
-			Oneway: yarpc.OnewayInboundMiddleware(rateLimitMiddleware, authInboundMiddleware, yarpcMetricsMiddleware),
+			Unary:  yarpc.UnaryInboundMiddleware(preAuthRateLimitMiddleware, authInboundMiddleware, tracingMiddleware, rateLimitMiddleware, auditMiddleware, yarpcMetricsMiddleware),
+			Stream: yarpc.StreamInboundMiddleware(preAuthRateLimitMiddleware, authInboundMiddleware, tracingMiddleware, rateLimitMiddleware, auditMiddleware, yarpcMetricsMiddleware),
+			Oneway: yarpc.OnewayInboundMiddleware(preAuthRateLimitMiddleware, authInboundMiddleware, tracingMiddleware, rateLimitMiddleware, auditMiddleware, yarpcMetricsMiddleware),
 		},
 		OutboundMiddleware: yarpc.OutboundMiddleware{
 			Unary:  authOutboundMiddleware,
@@ -549,6 +578,47 @@ func main() {
 		cfg.JobManager.JobRuntimeCalculationViaCache,
 	)
 
+	// Register the daemon job reconciler
+	daemonReconciler := &daemon.Reconciler{
+		JobFactory:      jobFactory,
+		JobConfigOps:    ormobjects.NewJobConfigOps(ormStore),
+		GoalStateDriver: goalStateDriver,
+		HostMgrClient: hostsvc.NewInternalHostServiceYARPCClient(
+			dispatcher.ClientConfig(common.PelotonHostManager)),
+		HostClient: host_svc.NewHostServiceYARPCClient(
+			dispatcher.ClientConfig(common.PelotonHostManager)),
+		Metrics: daemon.NewMetrics(rootScope),
+		Config:  &cfg.JobManager.Daemon,
+	}
+	if err := daemonReconciler.Register(backgroundManager); err != nil {
+		log.WithError(err).
+			Fatal("fail to register daemonReconciler in backgroundManager")
+	}
+
+	// Register the autoscaler of stateless jobs
+	if cfg.JobManager.Autoscaler.Enabled {
+		metricsSource, err := autoscaler.NewMetricsSource(
+			&cfg.JobManager.Autoscaler.MetricsSource)
+		if err != nil {
+			log.WithError(err).
+				Fatal("fail to create the metrics source of the autoscaler")
+		}
+
+		jobAutoscaler := &autoscaler.Autoscaler{
+			JobFactory:      jobFactory,
+			JobConfigOps:    ormobjects.NewJobConfigOps(ormStore),
+			UpdateStore:     store,
+			GoalStateDriver: goalStateDriver,
+			Source:          metricsSource,
+			Metrics:         autoscaler.NewMetrics(rootScope),
+			Config:          &cfg.JobManager.Autoscaler,
+		}
+		if err := jobAutoscaler.Register(backgroundManager); err != nil {
+			log.WithError(err).
+				Fatal("fail to register autoscaler in backgroundManager")
+		}
+	}
+
 	// Init placement processor
 	placementProcessor := placement.InitProcessor(
 		dispatcher,
@@ -618,7 +688,7 @@ func main() {
 		log.Fatalf("Unable to create leader candidate: %v", err)
 	}
 
-	jobsvc.InitServiceHandler(
+	jobHandler := jobsvc.InitServiceHandler(
 		dispatcher,
 		rootScope,
 		store, // store implements JobStore
@@ -631,6 +701,51 @@ func main() {
 		cfg.JobManager.JobSvcCfg,
 	)
 
+	// Register the cron job scheduler, which creates the runs of the
+	// cron jobs through the job service handler
+	cronScheduler := cron.NewScheduler(
+		ormobjects.NewCronJobOps(ormStore),
+		jobFactory,
+		goalStateDriver,
+		jobHandler,
+		rootScope,
+		&cfg.JobManager.Cron,
+	)
+	if err := cronScheduler.Register(backgroundManager); err != nil {
+		log.WithError(err).
+			Fatal("fail to register cronScheduler in backgroundManager")
+	}
+
+	cronsvc.InitV1AlphaCronJobServiceHandler(
+		dispatcher,
+		ormStore,
+		cronScheduler,
+		candidate,
+	)
+
+	// Register the pipeline engine, which creates the batch jobs of the
+	// nodes of the pipelines through the job service handler
+	pipelineEngine := pipeline.NewEngine(
+		ormobjects.NewPipelineOps(ormStore),
+		ormobjects.NewJobRuntimeOps(ormStore),
+		jobFactory,
+		goalStateDriver,
+		jobHandler,
+		rootScope,
+		&cfg.JobManager.Pipeline,
+	)
+	if err := pipelineEngine.Register(backgroundManager); err != nil {
+		log.WithError(err).
+			Fatal("fail to register pipelineEngine in backgroundManager")
+	}
+
+	pipelinesvc.InitServiceHandler(
+		dispatcher,
+		ormStore,
+		pipelineEngine,
+		candidate,
+	)
+
 	private.InitPrivateJobServiceHandler(
 		dispatcher,
 		store,
@@ -28,6 +28,7 @@ import (
 	"github.com/uber/peloton/pkg/common/logging"
 	"github.com/uber/peloton/pkg/common/metrics"
 	"github.com/uber/peloton/pkg/common/rpc"
+	"github.com/uber/peloton/pkg/common/tracing"
 	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/peer"
 	"github.com/uber/peloton/pkg/middleware/inbound"
 	"github.com/uber/peloton/pkg/middleware/outbound"
@@ -155,7 +156,7 @@ var (
 		"Define the auth type used, default to NOOP").
 		Default("NOOP").
 		Envar("AUTH_TYPE").
-		Enum("NOOP", "BASIC")
+		Enum("NOOP", "BASIC", "TOKEN")
 
 	authConfigFile = app.Flag(
 		"auth-config-file",
@@ -265,6 +266,17 @@ func main() {
 	)
 	defer scopeCloser.Close()
 
+	tracerCloser, err := tracing.InitGlobalTracer(
+		cfg.Tracing,
+		common.PelotonPlacement,
+		rootScope.SubScope("tracing"),
+	)
+	if err != nil {
+		log.WithError(err).
+			Fatal("Could not initialize tracing")
+	}
+	defer tracerCloser.Close()
+
 	mux.HandleFunc(logging.LevelOverwrite, logging.LevelOverwriteHandler(initialLevel))
 	mux.HandleFunc(buildversion.Get, buildversion.Handler(version))
 
@@ -323,6 +335,7 @@ func main() {
 	}
 
 	authInboundMiddleware := inbound.NewAuthInboundMiddleware(securityManager)
+	tracingMiddleware := &inbound.TracingInboundMiddleware{}
 
 	securityClient, err := auth_impl.CreateNewSecurityClient(&cfg.Auth)
 	if err != nil {
@@ -348,9 +361,9 @@ func main() {
 			Tally: rootScope,
 		},
 		InboundMiddleware: yarpc.InboundMiddleware{
-			Unary:  authInboundMiddleware,
-			Oneway: authInboundMiddleware,
-			Stream: authInboundMiddleware,
+			Unary:  yarpc.UnaryInboundMiddleware(authInboundMiddleware, tracingMiddleware),
+			Oneway: yarpc.OnewayInboundMiddleware(authInboundMiddleware, tracingMiddleware),
+			Stream: yarpc.StreamInboundMiddleware(authInboundMiddleware, tracingMiddleware),
 		},
 		OutboundMiddleware: yarpc.OutboundMiddleware{
 			Unary:  authOutboundMiddleware,
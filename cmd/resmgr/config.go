@@ -20,6 +20,8 @@ import (
 	"github.com/uber/peloton/pkg/common/leader"
 	"github.com/uber/peloton/pkg/common/logging"
 	"github.com/uber/peloton/pkg/common/metrics"
+	"github.com/uber/peloton/pkg/common/tracing"
+	"github.com/uber/peloton/pkg/middleware/inbound"
 	"github.com/uber/peloton/pkg/resmgr"
 	storage "github.com/uber/peloton/pkg/storage/config"
 )
@@ -33,4 +35,6 @@ type Config struct {
 	Health       health.Config         `yaml:"health"`
 	SentryConfig logging.SentryConfig  `yaml:"sentry"`
 	Auth         auth.Config           `yaml:"auth"`
+	Tracing      tracing.Config        `yaml:"tracing"`
+	Audit        inbound.AuditConfig   `yaml:"audit"`
 }
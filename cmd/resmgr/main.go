@@ -29,6 +29,7 @@ import (
 	"github.com/uber/peloton/pkg/common/logging"
 	"github.com/uber/peloton/pkg/common/metrics"
 	"github.com/uber/peloton/pkg/common/rpc"
+	"github.com/uber/peloton/pkg/common/tracing"
 	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/peer"
 	"github.com/uber/peloton/pkg/middleware/inbound"
 	"github.com/uber/peloton/pkg/middleware/outbound"
@@ -39,6 +40,7 @@ import (
 	"github.com/uber/peloton/pkg/resmgr/respool"
 	"github.com/uber/peloton/pkg/resmgr/respool/respoolsvc"
 	"github.com/uber/peloton/pkg/resmgr/task"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 	"github.com/uber/peloton/pkg/storage/stores"
 
 	log "github.com/sirupsen/logrus"
@@ -150,7 +152,7 @@ var (
 		"Define the auth type used, default to NOOP").
 		Default("NOOP").
 		Envar("AUTH_TYPE").
-		Enum("NOOP", "BASIC")
+		Enum("NOOP", "BASIC", "TOKEN")
 
 	authConfigFile = app.Flag(
 		"auth-config-file",
@@ -264,12 +266,24 @@ func main() {
 		metrics.TallyFlushInterval,
 	)
 	defer scopeCloser.Close()
+
+	tracerCloser, err := tracing.InitGlobalTracer(
+		cfg.Tracing,
+		common.PelotonResourceManager,
+		rootScope.SubScope("tracing"),
+	)
+	if err != nil {
+		log.WithError(err).
+			Fatal("Could not initialize tracing")
+	}
+	defer tracerCloser.Close()
 	rootScope.Counter("boot").Inc(1)
 
 	mux.HandleFunc(logging.LevelOverwrite, logging.LevelOverwriteHandler(initialLevel))
 	mux.HandleFunc(buildversion.Get, buildversion.Handler(version))
 
 	store := stores.MustCreateStore(&cfg.Storage, rootScope)
+	ormStore := stores.MustCreateORMStore(&cfg.Storage, rootScope)
 
 	// Create both HTTP and GRPC inbounds
 	inbounds := rpc.NewInbounds(
@@ -313,6 +327,16 @@ func main() {
 	}
 
 	authInboundMiddleware := inbound.NewAuthInboundMiddleware(securityManager)
+	tracingMiddleware := &inbound.TracingInboundMiddleware{}
+	auditMiddleware, err := inbound.NewAuditInboundMiddleware(
+		cfg.Audit,
+		ormobjects.NewAuditEventOps(ormStore),
+		rootScope.SubScope("audit"),
+	)
+	if err != nil {
+		log.WithError(err).
+			Fatal("Could not create audit middleware")
+	}
 	yarpcMetricsMiddleware := &inbound.YAPRCMetricsInboundMiddleware{Scope: rootScope.SubScope("yarpc")}
 
 	securityClient, err := auth_impl.CreateNewSecurityClient(&cfg.Auth)
@@ -332,9 +356,9 @@ func main() {
 			Tally: rootScope,
 		},
 		InboundMiddleware: yarpc.InboundMiddleware{
-			Unary:  yarpc.UnaryInboundMiddleware(authInboundMiddleware, leaderCheckMiddleware, yarpcMetricsMiddleware),
-			Oneway: yarpc.OnewayInboundMiddleware(authInboundMiddleware, leaderCheckMiddleware, yarpcMetricsMiddleware),
-			Stream: yarpc.StreamInboundMiddleware(authInboundMiddleware, leaderCheckMiddleware, yarpcMetricsMiddleware),
+			Unary:  yarpc.UnaryInboundMiddleware(authInboundMiddleware, tracingMiddleware, leaderCheckMiddleware, auditMiddleware, yarpcMetricsMiddleware),
+			Oneway: yarpc.OnewayInboundMiddleware(authInboundMiddleware, tracingMiddleware, leaderCheckMiddleware, auditMiddleware, yarpcMetricsMiddleware),
+			Stream: yarpc.StreamInboundMiddleware(authInboundMiddleware, tracingMiddleware, leaderCheckMiddleware, auditMiddleware, yarpcMetricsMiddleware),
 		},
 		OutboundMiddleware: yarpc.OutboundMiddleware{
 			Unary:  authOutboundMiddleware,
@@ -408,6 +432,7 @@ func main() {
 		hostmgrClient,
 		cfg.ResManager.HostDrainerPeriod,
 		task.GetTracker(),
+		ormobjects.NewJobIndexOps(ormStore),
 		preemptor)
 
 	// Initialize resource manager service handlers
@@ -0,0 +1,132 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/config"
+	"github.com/uber/peloton/pkg/common/logging"
+	storage_config "github.com/uber/peloton/pkg/storage/config"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+	"github.com/uber/peloton/pkg/storage/stores"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/tally"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Config holds the parts of the job manager config used by this tool
+type Config struct {
+	// Storage configuration
+	Storage storage_config.Config `yaml:"storage"`
+}
+
+var (
+	version string
+	app     = kingpin.New(
+		"peloton-secrets", "Maintenance of the secrets stored by Peloton")
+
+	cfgFiles = app.Flag(
+		"config",
+		"Job manager YAML config files (can be provided multiple times to merge configs)").
+		Short('c').
+		Required().
+		ExistingFiles()
+
+	keyFile = app.Flag(
+		"key-file",
+		"Key file with the key encryption keys "+
+			"(storage.secret_encryption.key_file override) "+
+			"(set $SECRET_KEY_FILE to override)").
+		Envar("SECRET_KEY_FILE").
+		String()
+
+	pelotonSecretFile = app.Flag(
+		"peloton-secret-file",
+		"Secret file containing all Peloton secrets").
+		Default("").
+		Envar("PELOTON_SECRET_FILE").
+		String()
+
+	rewrap = app.Command(
+		"rewrap",
+		"Encrypt the secrets stored unencrypted, and re-wrap the data keys "+
+			"of encrypted secrets with the current key. Run it after "+
+			"enabling encryption and after every key rotation.")
+)
+
+func main() {
+	var cfg Config
+
+	app.Version(version)
+	app.HelpFlag.Short('h')
+	cmd := kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	log.SetFormatter(
+		&logging.LogFieldFormatter{
+			Formatter: &log.JSONFormatter{},
+			Fields: log.Fields{
+				common.AppLogField: app.Name,
+			},
+		},
+	)
+
+	if err := config.Parse(&cfg, *cfgFiles...); err != nil {
+		log.WithError(err).Fatal("Cannot parse yaml config")
+	}
+
+	if *keyFile != "" {
+		cfg.Storage.SecretEncryption.KeyFile = *keyFile
+	}
+	if cfg.Storage.SecretEncryption.KeyFile == "" {
+		log.Fatal("Secret encryption is not enabled, set a key file")
+	}
+
+	if *pelotonSecretFile != "" {
+		var secretsCfg config.PelotonSecretsConfig
+		if err := config.Parse(&secretsCfg, *pelotonSecretFile); err != nil {
+			log.WithError(err).
+				WithField("peloton_secret_file", *pelotonSecretFile).
+				Fatal("Cannot parse secret config")
+		}
+		cfg.Storage.Cassandra.CassandraConn.Username =
+			secretsCfg.CassandraUsername
+		cfg.Storage.Cassandra.CassandraConn.Password =
+			secretsCfg.CassandraPassword
+	}
+
+	// the schema is owned by the job manager
+	cfg.Storage.AutoMigrate = false
+	store := stores.MustCreateStore(&cfg.Storage, tally.NoopScope)
+	ormStore := stores.MustCreateORMStore(&cfg.Storage, tally.NoopScope)
+
+	switch cmd {
+	case rewrap.FullCommand():
+		rewrapped, err := rewrapSecrets(
+			context.Background(),
+			store,
+			ormobjects.NewSecretInfoOps(ormStore),
+		)
+		if err != nil {
+			log.WithError(err).
+				WithField("rewrapped", rewrapped).
+				Fatal("Failed to rewrap secrets")
+		}
+		log.WithField("rewrapped", rewrapped).Info("Secrets rewrapped")
+	}
+}
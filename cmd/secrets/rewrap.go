@@ -0,0 +1,83 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/storage"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	log "github.com/sirupsen/logrus"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// rewrapSecrets encrypts or re-wraps the secrets of all jobs. The secrets
+// are found through the secret volumes of the job configurations, as the
+// secret_info table can only be read by secret id. Failures are logged
+// and the remaining secrets are still processed. It returns the number
+// of secrets which were changed.
+func rewrapSecrets(
+	ctx context.Context,
+	jobStore storage.JobStore,
+	secretInfoOps ormobjects.SecretInfoOps,
+) (int, error) {
+	summaries, err := jobStore.GetAllJobsInJobIndex(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var rewrapped, failed int
+	for _, summary := range summaries {
+		jobID := summary.GetId().GetValue()
+		jobConfig, _, err := jobStore.GetJobConfig(ctx, jobID)
+		if yarpcerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID).
+				Error("Failed to get job config")
+			failed++
+			continue
+		}
+
+		for _, volume := range util.RemoveSecretVolumesFromJobConfig(jobConfig) {
+			secretID := string(
+				volume.GetSource().GetSecret().GetValue().GetData())
+			changed, err := secretInfoOps.RewrapSecret(ctx, secretID)
+			if err != nil {
+				log.WithError(err).
+					WithFields(log.Fields{
+						"job_id":    jobID,
+						"secret_id": secretID,
+					}).Error("Failed to rewrap secret")
+				failed++
+				continue
+			}
+			if changed {
+				rewrapped++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return rewrapped, fmt.Errorf(
+			"%d failures while rewrapping secrets, see the logs", failed)
+	}
+	return rewrapped, nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+
+	"github.com/uber/peloton/pkg/common/util"
+	storemocks "github.com/uber/peloton/pkg/storage/mocks"
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type RewrapTestSuite struct {
+	suite.Suite
+
+	ctrl          *gomock.Controller
+	jobStore      *storemocks.MockJobStore
+	secretInfoOps *objectmocks.MockSecretInfoOps
+}
+
+func (suite *RewrapTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.jobStore = storemocks.NewMockJobStore(suite.ctrl)
+	suite.secretInfoOps = objectmocks.NewMockSecretInfoOps(suite.ctrl)
+}
+
+func (suite *RewrapTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestRewrapTestSuite(t *testing.T) {
+	suite.Run(t, new(RewrapTestSuite))
+}
+
+// jobConfigWithSecrets returns a job config with secret volumes for the
+// given secret ids
+func jobConfigWithSecrets(secretIDs ...string) *job.JobConfig {
+	var volumes []*mesos.Volume
+	for _, id := range secretIDs {
+		volumes = append(volumes, util.CreateSecretVolume("/tmp/secret", id))
+	}
+	mesosType := mesos.ContainerInfo_MESOS
+	return &job.JobConfig{
+		DefaultConfig: &task.TaskConfig{
+			Container: &mesos.ContainerInfo{
+				Type:    &mesosType,
+				Volumes: volumes,
+			},
+		},
+	}
+}
+
+// TestRewrapSecrets tests that the secrets of all jobs are rewrapped
+func (suite *RewrapTestSuite) TestRewrapSecrets() {
+	ctx := context.Background()
+	suite.jobStore.EXPECT().GetAllJobsInJobIndex(ctx).Return(
+		[]*job.JobSummary{
+			{Id: &peloton.JobID{Value: "job1"}},
+			{Id: &peloton.JobID{Value: "job2"}},
+			{Id: &peloton.JobID{Value: "deleted"}},
+		}, nil)
+	suite.jobStore.EXPECT().GetJobConfig(ctx, "job1").
+		Return(jobConfigWithSecrets("secret1", "secret2"), nil, nil)
+	suite.jobStore.EXPECT().GetJobConfig(ctx, "job2").
+		Return(jobConfigWithSecrets(), nil, nil)
+	suite.jobStore.EXPECT().GetJobConfig(ctx, "deleted").
+		Return(nil, nil, yarpcerrors.NotFoundErrorf("job not found"))
+	suite.secretInfoOps.EXPECT().RewrapSecret(ctx, "secret1").Return(true, nil)
+	suite.secretInfoOps.EXPECT().RewrapSecret(ctx, "secret2").Return(false, nil)
+
+	rewrapped, err := rewrapSecrets(ctx, suite.jobStore, suite.secretInfoOps)
+	suite.NoError(err)
+	suite.Equal(1, rewrapped)
+}
+
+// TestRewrapSecretsFailure tests that a failure to rewrap a secret does
+// not stop the remaining secrets from being rewrapped
+func (suite *RewrapTestSuite) TestRewrapSecretsFailure() {
+	ctx := context.Background()
+	suite.jobStore.EXPECT().GetAllJobsInJobIndex(ctx).Return(
+		[]*job.JobSummary{{Id: &peloton.JobID{Value: "job1"}}}, nil)
+	suite.jobStore.EXPECT().GetJobConfig(ctx, "job1").
+		Return(jobConfigWithSecrets("secret1", "secret2"), nil, nil)
+	suite.secretInfoOps.EXPECT().RewrapSecret(ctx, "secret1").
+		Return(false, errors.New("unwrap failed"))
+	suite.secretInfoOps.EXPECT().RewrapSecret(ctx, "secret2").Return(true, nil)
+
+	rewrapped, err := rewrapSecrets(ctx, suite.jobStore, suite.secretInfoOps)
+	suite.Error(err)
+	suite.Equal(1, rewrapped)
+}
+
+// TestRewrapSecretsGetJobsFailure tests failing to list the jobs
+func (suite *RewrapTestSuite) TestRewrapSecretsGetJobsFailure() {
+	ctx := context.Background()
+	suite.jobStore.EXPECT().GetAllJobsInJobIndex(ctx).
+		Return(nil, errors.New("db error"))
+
+	_, err := rewrapSecrets(ctx, suite.jobStore, suite.secretInfoOps)
+	suite.Error(err)
+}
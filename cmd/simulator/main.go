@@ -0,0 +1,113 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/config"
+	"github.com/uber/peloton/pkg/common/logging"
+	"github.com/uber/peloton/pkg/simulator"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	version string
+	app     = kingpin.New(
+		"peloton-sim",
+		"Offline simulator of the Peloton scheduling which replays a "+
+			"workload trace against a virtual cluster")
+
+	cfgFiles = app.Flag(
+		"config",
+		"Simulator YAML config files (can be provided multiple times to merge configs)").
+		Short('c').
+		Required().
+		ExistingFiles()
+
+	traceFile = app.Flag(
+		"trace",
+		"YAML trace with the resource pools, the hosts and the jobs to replay").
+		Short('t').
+		Required().
+		ExistingFile()
+
+	output = app.Flag(
+		"output",
+		"Format of the report").
+		Short('o').
+		Default("table").
+		Enum("table", "json")
+
+	debug = app.Flag(
+		"debug", "enable the logs of the simulated components").
+		Short('d').
+		Default("false").
+		Bool()
+)
+
+func main() {
+	var cfg simulator.Config
+
+	app.Version(version)
+	app.HelpFlag.Short('h')
+	kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	log.SetFormatter(
+		&logging.LogFieldFormatter{
+			Formatter: &log.JSONFormatter{},
+			Fields: log.Fields{
+				common.AppLogField: app.Name,
+			},
+		},
+	)
+	// the simulated components log every scheduling cycle
+	log.SetLevel(log.WarnLevel)
+	if *debug {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	if err := config.Parse(&cfg, *cfgFiles...); err != nil {
+		log.WithError(err).Fatal("Cannot parse yaml config")
+	}
+
+	trace, err := simulator.LoadTrace(*traceFile)
+	if err != nil {
+		log.WithError(err).Fatal("Cannot load trace")
+	}
+
+	sim, err := simulator.New(cfg, trace)
+	if err != nil {
+		log.WithError(err).Fatal("Cannot create simulator")
+	}
+
+	report, err := sim.Run(context.Background())
+	if err != nil {
+		log.WithError(err).Fatal("Simulation failed")
+	}
+
+	if *output == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteTable(os.Stdout)
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Cannot write report")
+	}
+}
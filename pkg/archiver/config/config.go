@@ -105,6 +105,13 @@ type ArchiverConfig struct {
 
 	// Kafka topic used by archiver to stream jobs via filebeat
 	KafkaTopic string `yaml:"kafka_topic"`
+
+	// Directory to which the config, tasks, pod events and updates of
+	// jobs are archived before the jobs are deleted. Jobs are not
+	// archived if this is not set. The directory is on the local
+	// filesystem of the archiver host, where `peloton job get --archived`
+	// has to run to read the archived jobs.
+	ArchiveDir string `yaml:"archive_dir"`
 }
 
 // Normalize configuration by setting unassigned fields to default values.
@@ -29,7 +29,10 @@ By default, the archiver engine will function like this:
 	2. Archiver thread uses peloton client to make JobQuery API request
 	   to jobmgr that queries for jobs that have been completed 30 days ago or earlier.
 	3. The job config for these jobs will be sent out as json data to Kafka upstream.
-	4. Once the jobconfig is sent to secondary storage via Kafka, the archiver will
+	4. If archive_dir is set, the job info, config of every version, tasks,
+	   pod events and updates of these jobs are written as a compressed bundle
+	   to the archive directory, and can be read back by the peloton CLI.
+	5. Once the jobconfig is sent to secondary storage via Kafka, the archiver will
 	   call the JobDelete API for this job_id
 	Outside the scope of this code, the data streamed to kafka will be ingested by
 	secondary storage like ELK or query builder.
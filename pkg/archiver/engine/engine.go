@@ -19,18 +19,24 @@ import (
 	"fmt"
 	"math/rand"
 	nethttp "net/http"
+	"sort"
 	"time"
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/query"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	updatesvc "github.com/uber/peloton/.gen/peloton/api/v0/update/svc"
+	statelesssvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
 	"github.com/uber/peloton/pkg/archiver/config"
+	"github.com/uber/peloton/pkg/archiver/sink"
 	auth_impl "github.com/uber/peloton/pkg/auth/impl"
 	"github.com/uber/peloton/pkg/common"
 	"github.com/uber/peloton/pkg/common/backoff"
 	"github.com/uber/peloton/pkg/common/leader"
 	"github.com/uber/peloton/pkg/common/util"
+	versionutil "github.com/uber/peloton/pkg/common/util/entityversion"
 	"github.com/uber/peloton/pkg/middleware/inbound"
 	"github.com/uber/peloton/pkg/middleware/outbound"
 
@@ -85,6 +91,13 @@ type engine struct {
 	jobClient job.JobManagerYARPCClient
 	// Task Manager Client to query task events.
 	taskClient task.TaskManagerYARPCClient
+	// Stateless Job Client to query job configuration versions.
+	statelessClient statelesssvc.JobServiceYARPCClient
+	// Update Client to query job updates.
+	updateClient updatesvc.UpdateServiceYARPCClient
+	// Sink to archive jobs to before they are deleted,
+	// nil if jobs are not archived.
+	sink sink.Sink
 	// Yarpc dispatcher
 	dispatcher *yarpc.Dispatcher
 	// Archiver config
@@ -149,6 +162,11 @@ func New(
 		return nil, fmt.Errorf("Unable to start dispatcher: %v", err)
 	}
 
+	var archiveSink sink.Sink
+	if len(cfg.Archiver.ArchiveDir) > 0 {
+		archiveSink = sink.NewLocalSink(cfg.Archiver.ArchiveDir)
+	}
+
 	return &engine{
 		jobClient: job.NewJobManagerYARPCClient(
 			dispatcher.ClientConfig(common.PelotonJobManager),
@@ -156,6 +174,13 @@ func New(
 		taskClient: task.NewTaskManagerYARPCClient(
 			dispatcher.ClientConfig(common.PelotonJobManager),
 		),
+		statelessClient: statelesssvc.NewJobServiceYARPCClient(
+			dispatcher.ClientConfig(common.PelotonJobManager),
+		),
+		updateClient: updatesvc.NewUpdateServiceYARPCClient(
+			dispatcher.ClientConfig(common.PelotonJobManager),
+		),
+		sink:       archiveSink,
 		dispatcher: dispatcher,
 		config:     cfg,
 		metrics:    NewMetrics(scope),
@@ -300,6 +325,20 @@ func (e *engine) archiveJobs(
 				completedJobTag: summary,
 			}).Info("completed job")
 
+			// Archive the full record of the job to the sink, and keep
+			// the job if it could not be archived.
+			if e.sink != nil {
+				if err := e.archiveJob(ctx, summary.GetId()); err != nil {
+					log.WithError(err).
+						WithField("job_id", summary.GetId().GetValue()).
+						Error("job archive failed")
+					e.metrics.ArchiverJobSinkFail.Inc(1)
+					archiveSummary[archiverFailureKey]++
+					continue
+				}
+				e.metrics.ArchiverJobSinkSuccess.Inc(1)
+			}
+
 			if e.config.Archiver.StreamOnlyMode {
 				continue
 			}
@@ -347,6 +386,104 @@ func (e *engine) archiveJobs(
 	}
 }
 
+// archiveJob writes the configuration of every version, tasks, pod events
+// and updates of the job to the sink.
+func (e *engine) archiveJob(ctx context.Context, jobID *peloton.JobID) error {
+	bundle, err := e.getJobBundle(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	return e.sink.Write(ctx, bundle)
+}
+
+// getJobBundle queries jobmgr for the full record of the job, every
+// query has its own PelotonClientTimeout.
+func (e *engine) getJobBundle(
+	ctx context.Context,
+	jobID *peloton.JobID) (*sink.JobBundle, error) {
+	timeout := e.config.Archiver.PelotonClientTimeout
+
+	rpcCtx, cancel := context.WithTimeout(ctx, timeout)
+	jobResp, err := e.jobClient.Get(rpcCtx, &job.GetRequest{Id: jobID})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	if jobResp.GetJobInfo() == nil {
+		return nil, fmt.Errorf("job info not found")
+	}
+	bundle := &sink.JobBundle{JobInfo: jobResp.GetJobInfo()}
+
+	// batch jobs are archived in their v0 form only, the config in the
+	// job info has the instance configs of every version since the
+	// updates of batch jobs only add instances
+	if jobResp.GetJobInfo().GetConfig().GetType() != job.JobType_BATCH {
+		configVersion := jobResp.GetJobInfo().GetRuntime().GetConfigurationVersion()
+		for v := uint64(1); v <= configVersion; v++ {
+			rpcCtx, cancel := context.WithTimeout(ctx, timeout)
+			specResp, err := e.statelessClient.GetJob(rpcCtx, &statelesssvc.GetJobRequest{
+				JobId:   &v1alphapeloton.JobID{Value: jobID.GetValue()},
+				Version: versionutil.GetJobEntityVersion(v, 0, 0),
+			})
+			cancel()
+			if err != nil {
+				return nil, err
+			}
+			bundle.JobSpecs = append(bundle.JobSpecs, specResp.GetJobInfo().GetSpec())
+		}
+	}
+
+	rpcCtx, cancel = context.WithTimeout(ctx, timeout)
+	taskResp, err := e.taskClient.List(rpcCtx, &task.ListRequest{JobId: jobID})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	for _, taskInfo := range taskResp.GetResult().GetValue() {
+		bundle.Tasks = append(bundle.Tasks, taskInfo)
+	}
+	sort.Slice(bundle.Tasks, func(i, j int) bool {
+		return bundle.Tasks[i].GetInstanceId() < bundle.Tasks[j].GetInstanceId()
+	})
+
+	for _, taskInfo := range bundle.Tasks {
+		// Fetch the pod events of every run of the task
+		runID, err := util.ParseRunID(
+			taskInfo.GetRuntime().GetMesosTaskId().GetValue())
+		if err != nil || runID == 0 {
+			runID = 1
+		}
+		rpcCtx, cancel := context.WithTimeout(ctx, timeout)
+		eventsResp, err := e.taskClient.GetPodEvents(
+			rpcCtx,
+			&task.GetPodEventsRequest{
+				JobId:      jobID,
+				InstanceId: taskInfo.GetInstanceId(),
+				Limit:      runID,
+			})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		if eventsResp.GetError() != nil {
+			return nil, fmt.Errorf(
+				"get pod events: %s", eventsResp.GetError().GetMessage())
+		}
+		bundle.PodEvents = append(bundle.PodEvents, eventsResp.GetResult()...)
+	}
+
+	rpcCtx, cancel = context.WithTimeout(ctx, timeout)
+	updateResp, err := e.updateClient.ListUpdates(
+		rpcCtx,
+		&updatesvc.ListUpdatesRequest{JobID: jobID})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	bundle.Updates = updateResp.GetUpdateInfo()
+	return bundle, nil
+}
+
 // deletePodEvents reads RUNNING service jobs and deletes,
 // runs (monotonically increasing counter) if more than 100.
 // This action is to constraint #runs in DB, to prevent large partitions
@@ -17,8 +17,10 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	nethttp "net/http"
 	"net/url"
+	"os"
 	"testing"
 	"time"
 
@@ -29,7 +31,15 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/query"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	task_mocks "github.com/uber/peloton/.gen/peloton/api/v0/task/mocks"
+	"github.com/uber/peloton/.gen/peloton/api/v0/update"
+	updatesvc "github.com/uber/peloton/.gen/peloton/api/v0/update/svc"
+	updatesvc_mocks "github.com/uber/peloton/.gen/peloton/api/v0/update/svc/mocks"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	statelesssvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc"
+	statelesssvc_mocks "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc/mocks"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
 	"github.com/uber/peloton/pkg/archiver/config"
+	"github.com/uber/peloton/pkg/archiver/sink"
 	"github.com/uber/peloton/pkg/common/backoff"
 	"github.com/uber/peloton/pkg/common/leader"
 	"go.uber.org/yarpc"
@@ -349,3 +359,189 @@ func (suite *archiverEngineTestSuite) TestArchiveJobsStreamOnly() {
 		context.Background(),
 		summaryList)
 }
+
+// TestArchiveJobsSink tests that the full record of a job is archived
+// to the sink before the job is deleted
+func (suite *archiverEngineTestSuite) TestArchiveJobsSink() {
+	dir, err := ioutil.TempDir("", "archive")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	mockCtrl := gomock.NewController(suite.T())
+	defer mockCtrl.Finish()
+	mockJobClient := job_mocks.NewMockJobManagerYARPCClient(mockCtrl)
+	mockTaskClient := task_mocks.NewMockTaskManagerYARPCClient(mockCtrl)
+	mockStatelessClient := statelesssvc_mocks.NewMockJobServiceYARPCClient(mockCtrl)
+	mockUpdateClient := updatesvc_mocks.NewMockUpdateServiceYARPCClient(mockCtrl)
+	archiveSink := sink.NewLocalSink(dir)
+	e := &engine{
+		jobClient:       mockJobClient,
+		taskClient:      mockTaskClient,
+		statelessClient: mockStatelessClient,
+		updateClient:    mockUpdateClient,
+		sink:            archiveSink,
+		metrics:         NewMetrics(tally.NoopScope),
+	}
+
+	jobID := &peloton.JobID{Value: "7ac74273-4ef0-4ca4-8fd2-34bc52aeac06"}
+	mesosTaskID := "7ac74273-4ef0-4ca4-8fd2-34bc52aeac06-0-2"
+	jobInfo := &job.JobInfo{
+		Id:     jobID,
+		Config: &job.JobConfig{Type: job.JobType_SERVICE},
+		Runtime: &job.RuntimeInfo{
+			State:                job.JobState_SUCCEEDED,
+			ConfigurationVersion: 2,
+		},
+	}
+	specs := []*stateless.JobSpec{
+		{Name: "my-job-v1"},
+		{Name: "my-job-v2"},
+	}
+	taskInfo := &task.TaskInfo{
+		JobId:      jobID,
+		InstanceId: 0,
+		Runtime: &task.RuntimeInfo{
+			MesosTaskId: &mesos.TaskID{Value: &mesosTaskID},
+		},
+	}
+	events := []*task.PodEvent{
+		{ActualState: task.TaskState_RUNNING.String()},
+		{ActualState: task.TaskState_SUCCEEDED.String()},
+	}
+	updates := []*update.UpdateInfo{
+		{UpdateId: &peloton.UpdateID{Value: "my-update"}},
+	}
+
+	mockJobClient.EXPECT().
+		Get(gomock.Any(), &job.GetRequest{Id: jobID}).
+		Return(&job.GetResponse{JobInfo: jobInfo}, nil)
+	for i, spec := range specs {
+		mockStatelessClient.EXPECT().
+			GetJob(gomock.Any(), &statelesssvc.GetJobRequest{
+				JobId:   &v1alphapeloton.JobID{Value: jobID.GetValue()},
+				Version: &v1alphapeloton.EntityVersion{Value: fmt.Sprintf("%d-0-0", i+1)},
+			}).
+			Return(&statelesssvc.GetJobResponse{
+				JobInfo: &stateless.JobInfo{Spec: spec},
+			}, nil)
+	}
+	mockTaskClient.EXPECT().
+		List(gomock.Any(), &task.ListRequest{JobId: jobID}).
+		Return(&task.ListResponse{
+			Result: &task.ListResponse_Result{
+				Value: map[uint32]*task.TaskInfo{0: taskInfo},
+			},
+		}, nil)
+	mockTaskClient.EXPECT().
+		GetPodEvents(gomock.Any(), &task.GetPodEventsRequest{
+			JobId:      jobID,
+			InstanceId: 0,
+			Limit:      2,
+		}).
+		Return(&task.GetPodEventsResponse{Result: events}, nil)
+	mockUpdateClient.EXPECT().
+		ListUpdates(gomock.Any(), &updatesvc.ListUpdatesRequest{JobID: jobID}).
+		Return(&updatesvc.ListUpdatesResponse{UpdateInfo: updates}, nil)
+	mockJobClient.EXPECT().
+		Delete(gomock.Any(), &job.DeleteRequest{Id: jobID}).
+		Return(&job.DeleteResponse{}, nil)
+
+	e.archiveJobs(
+		context.Background(),
+		[]*job.JobSummary{{Id: jobID}})
+
+	bundle, err := archiveSink.Read(context.Background(), jobID)
+	suite.NoError(err)
+	suite.Equal(&sink.JobBundle{
+		JobInfo:   jobInfo,
+		JobSpecs:  specs,
+		Tasks:     []*task.TaskInfo{taskInfo},
+		PodEvents: events,
+		Updates:   updates,
+	}, bundle)
+}
+
+// TestArchiveBatchJobSink tests that the configs of a batch job are
+// archived in their v0 form only
+func (suite *archiverEngineTestSuite) TestArchiveBatchJobSink() {
+	dir, err := ioutil.TempDir("", "archive")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	mockCtrl := gomock.NewController(suite.T())
+	defer mockCtrl.Finish()
+	mockJobClient := job_mocks.NewMockJobManagerYARPCClient(mockCtrl)
+	mockTaskClient := task_mocks.NewMockTaskManagerYARPCClient(mockCtrl)
+	mockUpdateClient := updatesvc_mocks.NewMockUpdateServiceYARPCClient(mockCtrl)
+	archiveSink := sink.NewLocalSink(dir)
+	e := &engine{
+		jobClient:    mockJobClient,
+		taskClient:   mockTaskClient,
+		updateClient: mockUpdateClient,
+		sink:         archiveSink,
+		metrics:      NewMetrics(tally.NoopScope),
+	}
+
+	jobID := &peloton.JobID{Value: "7ac74273-4ef0-4ca4-8fd2-34bc52aeac06"}
+	jobInfo := &job.JobInfo{
+		Id: jobID,
+		Config: &job.JobConfig{
+			Name:          "my-batch-job",
+			Type:          job.JobType_BATCH,
+			InstanceCount: 2,
+			InstanceConfig: map[uint32]*task.TaskConfig{
+				1: {Name: "added-by-update"},
+			},
+		},
+		Runtime: &job.RuntimeInfo{
+			State:                job.JobState_SUCCEEDED,
+			ConfigurationVersion: 2,
+		},
+	}
+
+	mockJobClient.EXPECT().
+		Get(gomock.Any(), &job.GetRequest{Id: jobID}).
+		Return(&job.GetResponse{JobInfo: jobInfo}, nil)
+	mockTaskClient.EXPECT().
+		List(gomock.Any(), &task.ListRequest{JobId: jobID}).
+		Return(&task.ListResponse{}, nil)
+	mockUpdateClient.EXPECT().
+		ListUpdates(gomock.Any(), &updatesvc.ListUpdatesRequest{JobID: jobID}).
+		Return(&updatesvc.ListUpdatesResponse{}, nil)
+	mockJobClient.EXPECT().
+		Delete(gomock.Any(), &job.DeleteRequest{Id: jobID}).
+		Return(&job.DeleteResponse{}, nil)
+
+	e.archiveJobs(
+		context.Background(),
+		[]*job.JobSummary{{Id: jobID}})
+
+	bundle, err := archiveSink.Read(context.Background(), jobID)
+	suite.NoError(err)
+	suite.Equal(&sink.JobBundle{JobInfo: jobInfo}, bundle)
+}
+
+// TestArchiveJobsSinkFailure tests that a job which fails to be archived
+// to the sink is not deleted
+func (suite *archiverEngineTestSuite) TestArchiveJobsSinkFailure() {
+	dir, err := ioutil.TempDir("", "archive")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	mockCtrl := gomock.NewController(suite.T())
+	defer mockCtrl.Finish()
+	mockJobClient := job_mocks.NewMockJobManagerYARPCClient(mockCtrl)
+	e := &engine{
+		jobClient: mockJobClient,
+		sink:      sink.NewLocalSink(dir),
+		metrics:   NewMetrics(tally.NoopScope),
+	}
+
+	mockJobClient.EXPECT().
+		Get(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("Job Get failed"))
+
+	e.archiveJobs(
+		context.Background(),
+		[]*job.JobSummary{{Id: &peloton.JobID{Value: "my-job-0"}}})
+}
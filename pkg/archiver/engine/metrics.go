@@ -26,6 +26,8 @@ type Metrics struct {
 	ArchiverJobQueryFail      tally.Counter
 	ArchiverJobDeleteSuccess  tally.Counter
 	ArchiverJobDeleteFail     tally.Counter
+	ArchiverJobSinkSuccess    tally.Counter
+	ArchiverJobSinkFail       tally.Counter
 	ArchiverNoJobsInTimerange tally.Counter
 
 	PodDeleteEventsFail    tally.Counter
@@ -43,6 +45,8 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		ArchiverJobQueryFail:      scope.Counter("archiver_job_query_fail"),
 		ArchiverJobDeleteSuccess:  scope.Counter("archiver_job_delete_success"),
 		ArchiverJobDeleteFail:     scope.Counter("archiver_job_delete_fail"),
+		ArchiverJobSinkSuccess:    scope.Counter("archiver_job_sink_success"),
+		ArchiverJobSinkFail:       scope.Counter("archiver_job_sink_fail"),
 		ArchiverNoJobsInTimerange: scope.Counter("archiver_no_jobs_in_timerange"),
 		PodDeleteEventsSuccess:    scope.Counter("pod_delete_events_success"),
 		PodDeleteEventsFail:       scope.Counter("pod_delete_events_fail"),
@@ -0,0 +1,230 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	// DefaultLocalDir is the default directory of the local sink
+	DefaultLocalDir = "/var/lib/peloton/archive"
+
+	// file extension of a job bundle, which is gzip compressed
+	// newline-delimited json
+	_bundleExt = ".ndjson.gz"
+
+	// kinds of the records in a job bundle
+	_kindJobInfo    = "job_info"
+	_kindJobSpec    = "job_spec"
+	_kindTaskInfo   = "task_info"
+	_kindPodEvent   = "pod_event"
+	_kindUpdateInfo = "update_info"
+)
+
+// record is a single line of a job bundle
+type record struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// localSink implements Sink by writing one bundle file per job
+// to a directory on the local filesystem.
+type localSink struct {
+	dir       string
+	marshaler jsonpb.Marshaler
+}
+
+// NewLocalSink returns a Sink which archives jobs to the given directory.
+func NewLocalSink(dir string) Sink {
+	return &localSink{
+		dir: dir,
+		marshaler: jsonpb.Marshaler{
+			OrigName: true,
+		},
+	}
+}
+
+// Write writes the bundle to a temporary file first, and renames it once
+// complete, such that a partially written bundle is never read back.
+func (s *localSink) Write(ctx context.Context, bundle *JobBundle) error {
+	jobID := bundle.JobInfo.GetId()
+	if len(jobID.GetValue()) == 0 {
+		return yarpcerrors.InvalidArgumentErrorf("job id not set in bundle")
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(s.dir, jobID.GetValue())
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	if err := s.writeRecords(zw, bundle); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(jobID))
+}
+
+func (s *localSink) writeRecords(w io.Writer, bundle *JobBundle) error {
+	encoder := json.NewEncoder(w)
+	write := func(kind string, pb proto.Message) error {
+		data, err := s.marshaler.MarshalToString(pb)
+		if err != nil {
+			return err
+		}
+		return encoder.Encode(&record{
+			Kind: kind,
+			Data: json.RawMessage(data),
+		})
+	}
+
+	if err := write(_kindJobInfo, bundle.JobInfo); err != nil {
+		return err
+	}
+	for _, spec := range bundle.JobSpecs {
+		if err := write(_kindJobSpec, spec); err != nil {
+			return err
+		}
+	}
+	for _, taskInfo := range bundle.Tasks {
+		if err := write(_kindTaskInfo, taskInfo); err != nil {
+			return err
+		}
+	}
+	for _, event := range bundle.PodEvents {
+		if err := write(_kindPodEvent, event); err != nil {
+			return err
+		}
+	}
+	for _, updateInfo := range bundle.Updates {
+		if err := write(_kindUpdateInfo, updateInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read reads the bundle of the job back from its file.
+func (s *localSink) Read(
+	ctx context.Context,
+	jobID *peloton.JobID,
+) (*JobBundle, error) {
+	f, err := os.Open(s.path(jobID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, yarpcerrors.NotFoundErrorf(
+				"job %s not found in archive", jobID.GetValue())
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	bundle := &JobBundle{}
+	decoder := json.NewDecoder(bufio.NewReader(zr))
+	for {
+		var r record
+		if err := decoder.Decode(&r); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		pb, err := addRecord(bundle, r.Kind)
+		if err != nil {
+			return nil, err
+		}
+		if err := jsonpb.UnmarshalString(string(r.Data), pb); err != nil {
+			return nil, err
+		}
+	}
+
+	if bundle.JobInfo == nil {
+		return nil, fmt.Errorf("job info not found in bundle of job %s",
+			jobID.GetValue())
+	}
+	return bundle, nil
+}
+
+// addRecord adds an empty message of the given kind to the bundle, and
+// returns it to be filled in.
+func addRecord(bundle *JobBundle, kind string) (proto.Message, error) {
+	switch kind {
+	case _kindJobInfo:
+		bundle.JobInfo = &job.JobInfo{}
+		return bundle.JobInfo, nil
+	case _kindJobSpec:
+		spec := &stateless.JobSpec{}
+		bundle.JobSpecs = append(bundle.JobSpecs, spec)
+		return spec, nil
+	case _kindTaskInfo:
+		taskInfo := &task.TaskInfo{}
+		bundle.Tasks = append(bundle.Tasks, taskInfo)
+		return taskInfo, nil
+	case _kindPodEvent:
+		event := &task.PodEvent{}
+		bundle.PodEvents = append(bundle.PodEvents, event)
+		return event, nil
+	case _kindUpdateInfo:
+		updateInfo := &update.UpdateInfo{}
+		bundle.Updates = append(bundle.Updates, updateInfo)
+		return updateInfo, nil
+	}
+	return nil, fmt.Errorf("unknown record kind %s", kind)
+}
+
+// path returns the path of the bundle file of the job
+func (s *localSink) path(jobID *peloton.JobID) string {
+	return filepath.Join(s.dir, jobID.GetValue()+_bundleExt)
+}
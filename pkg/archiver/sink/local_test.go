@@ -0,0 +1,189 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const _testJobID = "7ac74273-4ef0-4ca4-8fd2-34bc52aeac06"
+
+type localSinkTestSuite struct {
+	suite.Suite
+
+	dir  string
+	sink Sink
+}
+
+func (suite *localSinkTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "archive")
+	suite.NoError(err)
+	suite.dir = dir
+	suite.sink = NewLocalSink(dir)
+}
+
+func (suite *localSinkTestSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+}
+
+func TestLocalSink(t *testing.T) {
+	suite.Run(t, new(localSinkTestSuite))
+}
+
+func newTestBundle() *JobBundle {
+	jobID := &peloton.JobID{Value: _testJobID}
+	return &JobBundle{
+		JobInfo: &job.JobInfo{
+			Id: jobID,
+			Config: &job.JobConfig{
+				Name:          "test-job",
+				Type:          job.JobType_BATCH,
+				InstanceCount: 2,
+			},
+			Runtime: &job.RuntimeInfo{
+				State:                job.JobState_SUCCEEDED,
+				ConfigurationVersion: 2,
+			},
+		},
+		JobSpecs: []*stateless.JobSpec{
+			{
+				Revision: &v1alphapeloton.Revision{Version: 1},
+				Name:     "test-job",
+			},
+			{
+				Revision: &v1alphapeloton.Revision{Version: 2},
+				Name:     "test-job",
+			},
+		},
+		Tasks: []*task.TaskInfo{
+			{
+				JobId:      jobID,
+				InstanceId: 0,
+				Runtime:    &task.RuntimeInfo{State: task.TaskState_SUCCEEDED},
+			},
+			{
+				JobId:      jobID,
+				InstanceId: 1,
+				Runtime:    &task.RuntimeInfo{State: task.TaskState_FAILED},
+			},
+		},
+		PodEvents: []*task.PodEvent{
+			{
+				ActualState: task.TaskState_RUNNING.String(),
+				Hostname:    "host1",
+			},
+			{
+				ActualState: task.TaskState_SUCCEEDED.String(),
+				Hostname:    "host1",
+			},
+		},
+		Updates: []*update.UpdateInfo{
+			{
+				UpdateId: &peloton.UpdateID{Value: "update-1"},
+				JobId:    jobID,
+			},
+		},
+	}
+}
+
+// TestWriteRead tests that a job bundle written to the local sink is
+// read back unchanged
+func (suite *localSinkTestSuite) TestWriteRead() {
+	bundle := newTestBundle()
+	suite.NoError(suite.sink.Write(context.Background(), bundle))
+
+	// the temporary file is renamed to the bundle file
+	files, err := ioutil.ReadDir(suite.dir)
+	suite.NoError(err)
+	suite.Len(files, 1)
+	suite.Equal(_testJobID+_bundleExt, files[0].Name())
+
+	// the bundle file is compressed
+	f, err := os.Open(filepath.Join(suite.dir, files[0].Name()))
+	suite.NoError(err)
+	defer f.Close()
+	_, err = gzip.NewReader(f)
+	suite.NoError(err)
+
+	result, err := suite.sink.Read(
+		context.Background(),
+		&peloton.JobID{Value: _testJobID},
+	)
+	suite.NoError(err)
+	suite.Equal(bundle, result)
+}
+
+// TestWriteOverwrite tests that writing a job bundle again replaces the
+// previous one
+func (suite *localSinkTestSuite) TestWriteOverwrite() {
+	bundle := newTestBundle()
+	suite.NoError(suite.sink.Write(context.Background(), bundle))
+
+	bundle.Updates = nil
+	suite.NoError(suite.sink.Write(context.Background(), bundle))
+
+	result, err := suite.sink.Read(
+		context.Background(),
+		&peloton.JobID{Value: _testJobID},
+	)
+	suite.NoError(err)
+	suite.Empty(result.Updates)
+}
+
+// TestWriteNoJobID tests that a bundle without job id is not written
+func (suite *localSinkTestSuite) TestWriteNoJobID() {
+	err := suite.sink.Write(context.Background(), &JobBundle{})
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestReadNotFound tests reading a job which is not archived
+func (suite *localSinkTestSuite) TestReadNotFound() {
+	_, err := suite.sink.Read(
+		context.Background(),
+		&peloton.JobID{Value: _testJobID},
+	)
+	suite.True(yarpcerrors.IsNotFound(err))
+}
+
+// TestReadCorruptBundle tests reading a bundle file which is not
+// compressed json
+func (suite *localSinkTestSuite) TestReadCorruptBundle() {
+	suite.NoError(ioutil.WriteFile(
+		filepath.Join(suite.dir, _testJobID+_bundleExt),
+		[]byte("corrupt"),
+		0644,
+	))
+
+	_, err := suite.sink.Read(
+		context.Background(),
+		&peloton.JobID{Value: _testJobID},
+	)
+	suite.Error(err)
+}
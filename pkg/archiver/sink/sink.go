@@ -0,0 +1,50 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+)
+
+// Sink defines the interface used by the archiver to persist the full
+// record of a job to secondary storage before the job is deleted, and to
+// load it back afterwards.
+type Sink interface {
+	// Write archives the bundle of a job.
+	Write(ctx context.Context, bundle *JobBundle) error
+	// Read loads the archived bundle of a job.
+	Read(ctx context.Context, jobID *peloton.JobID) (*JobBundle, error)
+}
+
+// JobBundle is the self-contained record of an archived job.
+type JobBundle struct {
+	// Configuration and runtime of the job
+	JobInfo *job.JobInfo `json:"job_info"`
+	// Configuration of the job at every version, ordered by version.
+	// Not set for batch jobs, whose config is only archived in JobInfo.
+	JobSpecs []*stateless.JobSpec `json:"job_specs"`
+	// Configuration and runtime of every task, ordered by instance id
+	Tasks []*task.TaskInfo `json:"tasks"`
+	// Pod events of every task
+	PodEvents []*task.PodEvent `json:"pod_events"`
+	// Updates of the job
+	Updates []*update.UpdateInfo `json:"updates"`
+}
@@ -39,12 +39,13 @@ func NewConstraint(
 			r := newHostLimitConstraint(jobKeyLabel, c.GetConstraint().GetLimit().GetLimit())
 			result = append(result, r)
 		} else if c.GetConstraint().IsSetValue() {
+			r := newHostConstraint(c.GetName(), c.GetConstraint().GetValue().GetValues())
 			if c.GetConstraint().GetValue().GetNegated() {
-				return nil, fmt.Errorf(
-					"constraint %s: negated value constraints not supported", c.GetName())
+				r = newNotConstraint(r)
+			}
+			if r != nil {
+				result = append(result, r)
 			}
-			r := newHostConstraint(c.GetName(), c.GetConstraint().GetValue().GetValues())
-			result = append(result, r)
 		}
 	}
 	return joinConstraints(result, _andOp), nil
@@ -84,6 +85,20 @@ func newHostConstraint(name string, values map[string]struct{}) *pod.Constraint
 	return joinConstraints(result, _orOp)
 }
 
+// newNotConstraint negates c, such that a negated Aurora value constraint
+// restricts pods to hosts which do not match any of its values.
+func newNotConstraint(c *pod.Constraint) *pod.Constraint {
+	if c == nil {
+		return nil
+	}
+	return &pod.Constraint{
+		Type: pod.Constraint_CONSTRAINT_TYPE_NOT,
+		NotConstraint: &pod.NotConstraint{
+			Constraint: c,
+		},
+	}
+}
+
 // joinOp is an enum for describing how to join a list of constraints.
 type joinOp int
 
@@ -119,3 +119,48 @@ func TestNewPodSpec_ValueConstraints(t *testing.T) {
 		},
 	}, c.GetOrConstraint().GetConstraints())
 }
+
+// Ensures that PodSpec negated host constraints are translated from Aurora
+// negated value constraints.
+func TestNewPodSpec_NegatedValueConstraint(t *testing.T) {
+	var (
+		name = "sku"
+		v    = "abc123"
+	)
+
+	p, err := NewPodSpec(
+		&api.TaskConfig{
+			Constraints: []*api.Constraint{{
+				Name: &name,
+				Constraint: &api.TaskConstraint{
+					Value: &api.ValueConstraint{
+						Negated: ptr.Bool(true),
+						Values: map[string]struct{}{
+							v: {},
+						},
+					},
+				},
+			}},
+		},
+		ThermosExecutorConfig{},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, &pod.Constraint{
+		Type: pod.Constraint_CONSTRAINT_TYPE_NOT,
+		NotConstraint: &pod.NotConstraint{
+			Constraint: &pod.Constraint{
+				Type: pod.Constraint_CONSTRAINT_TYPE_LABEL,
+				LabelConstraint: &pod.LabelConstraint{
+					Kind:      pod.LabelConstraint_LABEL_CONSTRAINT_KIND_HOST,
+					Condition: pod.LabelConstraint_LABEL_CONSTRAINT_CONDITION_EQUAL,
+					Label: &peloton.Label{
+						Key:   name,
+						Value: v,
+					},
+					Requirement: 1,
+				},
+			},
+		},
+	}, p.GetConstraint())
+}
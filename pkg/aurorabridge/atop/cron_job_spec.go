@@ -0,0 +1,99 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atop
+
+import (
+	"fmt"
+
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+	"github.com/uber/peloton/.gen/thrift/aurora/api"
+
+	"github.com/uber/peloton/pkg/aurorabridge/common"
+	"github.com/uber/peloton/pkg/aurorabridge/label"
+)
+
+// NewCronJobSpec creates a new CronJobSpec from the configuration of an
+// Aurora cron job.
+func NewCronJobSpec(
+	config *api.JobConfiguration,
+	respoolID *peloton.ResourcePoolID,
+	c ThermosExecutorConfig,
+) (*cron.CronJobSpec, error) {
+
+	if !config.IsSetTaskConfig() {
+		return nil, fmt.Errorf("task config is not set in job configuration")
+	}
+
+	if len(config.GetCronSchedule()) == 0 {
+		return nil, fmt.Errorf("cron schedule is not set in job configuration")
+	}
+
+	key := NewCronJobKey(config)
+
+	p, err := NewPodSpec(config.GetTaskConfig(), c)
+	if err != nil {
+		return nil, fmt.Errorf("new pod spec: %s", err)
+	}
+
+	l := []*peloton.Label{
+		label.NewAuroraJobKeyRole(key.GetRole()),
+		label.NewAuroraJobKeyEnvironment(key.GetEnvironment()),
+		label.NewAuroraJobKeyName(key.GetName()),
+		common.BridgeJobLabel,
+	}
+
+	return &cron.CronJobSpec{
+		Name:            NewJobName(key),
+		Schedule:        config.GetCronSchedule(),
+		CollisionPolicy: NewCollisionPolicy(config.GetCronCollisionPolicy()),
+		Template: &stateless.JobSpec{
+			Name:          NewJobName(key),
+			Owner:         config.GetTaskConfig().GetOwner().GetUser(),
+			OwningTeam:    config.GetTaskConfig().GetOwner().GetUser(),
+			Labels:        l,
+			InstanceCount: uint32(config.GetInstanceCount()),
+			Sla:           newSLASpec(config.GetTaskConfig(), 0),
+			DefaultSpec:   p,
+			RespoolId:     respoolID,
+		},
+	}, nil
+}
+
+// NewCronJobKey returns the key of an Aurora cron job, which defaults to
+// the job of its task config when not set.
+func NewCronJobKey(config *api.JobConfiguration) *api.JobKey {
+	if config.IsSetKey() {
+		return config.GetKey()
+	}
+	return config.GetTaskConfig().GetJob()
+}
+
+// NewCollisionPolicy converts an Aurora cron collision policy into a
+// Peloton one.
+func NewCollisionPolicy(p api.CronCollisionPolicy) cron.CollisionPolicy {
+	switch p {
+	case api.CronCollisionPolicyKillExisting:
+		return cron.CollisionPolicy_COLLISION_POLICY_KILL_EXISTING
+	case api.CronCollisionPolicyCancelNew:
+		return cron.CollisionPolicy_COLLISION_POLICY_SKIP
+	case api.CronCollisionPolicyRunOverlap:
+		// Deprecated in Aurora, which treats it the same as CANCEL_NEW.
+		return cron.CollisionPolicy_COLLISION_POLICY_SKIP
+	default:
+		return cron.CollisionPolicy_COLLISION_POLICY_KILL_EXISTING
+	}
+}
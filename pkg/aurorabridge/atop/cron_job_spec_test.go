@@ -0,0 +1,106 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atop
+
+import (
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+	"github.com/uber/peloton/.gen/thrift/aurora/api"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/thriftrw/ptr"
+)
+
+func newTestCronJobConfiguration() *api.JobConfiguration {
+	return &api.JobConfiguration{
+		Key: &api.JobKey{
+			Role:        ptr.String("role"),
+			Environment: ptr.String("prod"),
+			Name:        ptr.String("report"),
+		},
+		CronSchedule:        ptr.String("0 2 * * *"),
+		CronCollisionPolicy: api.CronCollisionPolicyCancelNew.Ptr(),
+		TaskConfig: &api.TaskConfig{
+			Owner: &api.Identity{User: ptr.String("owner")},
+		},
+		InstanceCount: ptr.Int32(3),
+	}
+}
+
+// Ensures that the cron job spec is built from the Aurora configuration.
+func TestNewCronJobSpec(t *testing.T) {
+	respoolID := &peloton.ResourcePoolID{Value: "respool"}
+
+	spec, err := NewCronJobSpec(
+		newTestCronJobConfiguration(),
+		respoolID,
+		ThermosExecutorConfig{},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "role/prod/report", spec.GetName())
+	assert.Equal(t, "0 2 * * *", spec.GetSchedule())
+	assert.Equal(t,
+		cron.CollisionPolicy_COLLISION_POLICY_SKIP,
+		spec.GetCollisionPolicy())
+
+	template := spec.GetTemplate()
+	assert.Equal(t, "role/prod/report", template.GetName())
+	assert.Equal(t, "owner", template.GetOwner())
+	assert.Equal(t, uint32(3), template.GetInstanceCount())
+	assert.Equal(t, respoolID, template.GetRespoolId())
+	assert.NotNil(t, template.GetDefaultSpec())
+	assert.Len(t, template.GetLabels(), 4)
+}
+
+// Ensures that the job of the task config is used when the key of the
+// job configuration is not set.
+func TestNewCronJobSpec_KeyFromTaskConfig(t *testing.T) {
+	config := newTestCronJobConfiguration()
+	config.TaskConfig.Job = config.Key
+	config.Key = nil
+
+	spec, err := NewCronJobSpec(config, nil, ThermosExecutorConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, "role/prod/report", spec.GetName())
+}
+
+// Ensures that invalid cron job configurations are rejected.
+func TestNewCronJobSpec_Invalid(t *testing.T) {
+	config := newTestCronJobConfiguration()
+	config.TaskConfig = nil
+	_, err := NewCronJobSpec(config, nil, ThermosExecutorConfig{})
+	assert.Error(t, err)
+
+	config = newTestCronJobConfiguration()
+	config.CronSchedule = nil
+	_, err = NewCronJobSpec(config, nil, ThermosExecutorConfig{})
+	assert.Error(t, err)
+}
+
+// Ensures that Aurora collision policies are mapped to Peloton ones.
+func TestNewCollisionPolicy(t *testing.T) {
+	assert.Equal(t,
+		cron.CollisionPolicy_COLLISION_POLICY_KILL_EXISTING,
+		NewCollisionPolicy(api.CronCollisionPolicyKillExisting))
+	assert.Equal(t,
+		cron.CollisionPolicy_COLLISION_POLICY_SKIP,
+		NewCollisionPolicy(api.CronCollisionPolicyCancelNew))
+	assert.Equal(t,
+		cron.CollisionPolicy_COLLISION_POLICY_SKIP,
+		NewCollisionPolicy(api.CronCollisionPolicyRunOverlap))
+}
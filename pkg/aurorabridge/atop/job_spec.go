@@ -61,7 +61,7 @@ func NewJobSpecFromJobUpdateRequest(
 		InstanceCount: uint32(r.GetInstanceCount()),
 		Sla:           newSLASpec(r.GetTaskConfig(), r.GetSettings().GetMaxFailedInstances()),
 		DefaultSpec:   p,
-		InstanceSpec:  nil, // TODO(codyg): Pinned instance support.
+		InstanceSpec:  nil, // Pinned instances are resolved by StartJobUpdate.
 		RespoolId:     respoolID,
 	}, nil
 }
@@ -19,6 +19,8 @@ import (
 	"math/rand"
 	"time"
 
+	v0peloton "github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
 	"github.com/uber/peloton/.gen/thrift/aurora/api"
@@ -49,6 +51,36 @@ func PelotonResourcePoolID() *peloton.ResourcePoolID {
 	}
 }
 
+// PelotonResourcePoolInfo returns a random v0 ResourcePoolInfo.
+func PelotonResourcePoolInfo() *respool.ResourcePoolInfo {
+	var resources []*respool.ResourceConfig
+	var usage []*respool.ResourceUsage
+	for _, kind := range []string{"cpu", "memory", "disk", "gpu"} {
+		reservation := randutil.Range(100, 1000)
+		resources = append(resources, &respool.ResourceConfig{
+			Kind:        kind,
+			Reservation: float64(reservation),
+			Limit:       float64(reservation + randutil.Range(0, 1000)),
+			Share:       1,
+		})
+		usage = append(usage, &respool.ResourceUsage{
+			Kind:       kind,
+			Allocation: float64(randutil.Range(0, reservation)),
+			Slack:      float64(randutil.Range(0, 100)),
+		})
+	}
+	return &respool.ResourcePoolInfo{
+		Id: &v0peloton.ResourcePoolID{
+			Value: fmt.Sprintf("respool-%s", randutil.Text(8)),
+		},
+		Config: &respool.ResourcePoolConfig{
+			Name:      fmt.Sprintf("respool-%s", randutil.Text(8)),
+			Resources: resources,
+		},
+		Usage: usage,
+	}
+}
+
 // PelotonUpdateSpec returns a random UpdateSpec.
 func PelotonUpdateSpec() *stateless.UpdateSpec {
 	return &stateless.UpdateSpec{
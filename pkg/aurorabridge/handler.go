@@ -23,6 +23,8 @@ import (
 	"time"
 
 	v0peloton "github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	cronsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc"
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
 	statelesssvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc"
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
@@ -30,6 +32,7 @@ import (
 	podsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod/svc"
 	pbquery "github.com/uber/peloton/.gen/peloton/api/v1alpha/query"
 	"github.com/uber/peloton/.gen/peloton/private/jobmgrsvc"
+	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 	"github.com/uber/peloton/.gen/thrift/aurora/api"
 	"github.com/uber/peloton/pkg/common/util"
 
@@ -64,6 +67,9 @@ type ServiceHandler struct {
 	jobClient     statelesssvc.JobServiceYARPCClient
 	jobmgrClient  jobmgrsvc.JobManagerServiceYARPCClient
 	podClient     podsvc.PodServiceYARPCClient
+	cronClient    cronsvc.CronJobServiceYARPCClient
+	resmgrClient  resmgrsvc.ResourceManagerServiceYARPCClient
+	respoolClient respool.ResourceManagerYARPCClient
 	respoolLoader RespoolLoader
 	random        common.Random
 }
@@ -75,6 +81,9 @@ func NewServiceHandler(
 	jobClient statelesssvc.JobServiceYARPCClient,
 	jobmgrClient jobmgrsvc.JobManagerServiceYARPCClient,
 	podClient podsvc.PodServiceYARPCClient,
+	cronClient cronsvc.CronJobServiceYARPCClient,
+	resmgrClient resmgrsvc.ResourceManagerServiceYARPCClient,
+	respoolClient respool.ResourceManagerYARPCClient,
 	respoolLoader RespoolLoader,
 	random common.Random,
 ) (*ServiceHandler, error) {
@@ -90,6 +99,9 @@ func NewServiceHandler(
 		jobClient:     jobClient,
 		jobmgrClient:  jobmgrClient,
 		podClient:     podClient,
+		cronClient:    cronClient,
+		resmgrClient:  resmgrClient,
+		respoolClient: respoolClient,
 		respoolLoader: respoolLoader,
 		random:        random,
 	}, nil
@@ -327,6 +339,233 @@ func (h *ServiceHandler) getTasksWithoutConfigs(
 	}, nil
 }
 
+// GetPendingReason returns the reasons why the tasks matching the query
+// are still pending.
+func (h *ServiceHandler) GetPendingReason(
+	ctx context.Context,
+	query *api.TaskQuery,
+) (*api.Response, error) {
+
+	startTime := time.Now()
+	result, err := h.getPendingReason(ctx, query)
+	resp := newResponse(result, err)
+
+	defer func() {
+		h.metrics.
+			Procedures[ProcedureGetPendingReason].
+			ResponseCode.
+			ResponseCodes[resp.GetResponseCode()].
+			Inc(1)
+
+		h.metrics.
+			Procedures[ProcedureGetPendingReason].
+			ResponseCodeLatency.
+			ResponseCodes[resp.GetResponseCode()].
+			Record(time.Since(startTime))
+
+		if err != nil {
+			log.WithFields(log.Fields{
+				"params": log.Fields{
+					"query": query,
+				},
+				"code":  err.responseCode,
+				"error": err.msg,
+			}).Error("GetPendingReason error")
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"params": log.Fields{
+				"query": query,
+			},
+			"result": result,
+		}).Debug("GetPendingReason success")
+	}()
+
+	return resp, nil
+}
+
+func (h *ServiceHandler) getPendingReason(
+	ctx context.Context,
+	query *api.TaskQuery,
+) (*api.Result, *auroraError) {
+
+	// Aurora only returns reasons for PENDING tasks, so the
+	// statuses cannot be provided in the query
+	if len(query.GetStatuses()) > 0 {
+		return nil, auroraErrorf(
+			"statuses is not supported in a query: %v", query.GetStatuses()).
+			code(api.ResponseCodeInvalidRequest)
+	}
+
+	jobIDs, err := h.getJobIDsFromTaskQuery(ctx, query)
+	if err != nil {
+		return nil, auroraErrorf("get job ids from task query: %s", err)
+	}
+
+	// map from peloton task id to aurora task id of the pending tasks
+	auroraTaskIDs := make(map[string]string)
+	var taskIDs []*v0peloton.TaskID
+
+	for _, jobID := range jobIDs {
+		jobSummary, err := h.getJobInfoSummary(ctx, jobID)
+		if err != nil {
+			if yarpcerrors.IsNotFound(err) {
+				continue
+			}
+			return nil, auroraErrorf("get job info for job id %q: %s",
+				jobID.GetValue(), err)
+		}
+
+		pods, err := h.queryPods(
+			ctx,
+			jobID,
+			jobSummary.GetInstanceCount(),
+		)
+		if err != nil {
+			return nil, auroraErrorf(
+				"query pods for job id %q: %s", jobID.GetValue(), err)
+		}
+
+		for _, p := range pods {
+			s, err := ptoa.NewScheduleStatus(p.GetStatus().GetState())
+			if err != nil {
+				return nil, auroraErrorf("new schedule status: %s", err)
+			}
+			if *s != api.ScheduleStatusPending {
+				continue
+			}
+
+			podName := p.GetSpec().GetPodName().GetValue()
+			auroraTaskIDs[podName] = p.GetStatus().GetPodId().GetValue()
+			taskIDs = append(taskIDs, &v0peloton.TaskID{Value: podName})
+		}
+	}
+
+	reasons := make([]*api.PendingReason, 0, len(taskIDs))
+	if len(taskIDs) > 0 {
+		resp, err := h.resmgrClient.GetPendingReasons(
+			ctx,
+			&resmgrsvc.GetPendingReasonsRequest{Tasks: taskIDs},
+		)
+		if err != nil {
+			return nil, auroraErrorf("get pending reasons: %s", err)
+		}
+
+		// tasks which are not found in resource manager are not pending
+		// anymore, so they are left out of the result
+		for _, r := range resp.GetReasons() {
+			reasons = append(reasons, &api.PendingReason{
+				TaskId: ptr.String(auroraTaskIDs[r.GetTask().GetValue()]),
+				Reason: ptr.String(newPendingReasonMessage(r)),
+			})
+		}
+	}
+
+	return &api.Result{
+		GetPendingReasonResult: &api.GetPendingReasonResult{
+			Reasons: reasons,
+		},
+	}, nil
+}
+
+// GetQuota returns the quota and consumption of the resource
+// pool which the jobs of a role are placed into.
+func (h *ServiceHandler) GetQuota(
+	ctx context.Context,
+	ownerRole *string,
+) (*api.Response, error) {
+
+	startTime := time.Now()
+	result, err := h.getQuota(ctx, ownerRole)
+	resp := newResponse(result, err)
+
+	defer func() {
+		h.metrics.
+			Procedures[ProcedureGetQuota].
+			ResponseCode.
+			ResponseCodes[resp.GetResponseCode()].
+			Inc(1)
+
+		h.metrics.
+			Procedures[ProcedureGetQuota].
+			ResponseCodeLatency.
+			ResponseCodes[resp.GetResponseCode()].
+			Record(time.Since(startTime))
+
+		if err != nil {
+			log.WithFields(log.Fields{
+				"params": log.Fields{
+					"role": ownerRole,
+				},
+				"code":  err.responseCode,
+				"error": err.msg,
+			}).Error("GetQuota error")
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"params": log.Fields{
+				"role": ownerRole,
+			},
+			"result": result,
+		}).Debug("GetQuota success")
+	}()
+
+	return resp, nil
+}
+
+func (h *ServiceHandler) getQuota(
+	ctx context.Context,
+	ownerRole *string,
+) (*api.Result, *auroraError) {
+
+	if ownerRole == nil || *ownerRole == "" {
+		return nil, auroraErrorf("role must be provided").
+			code(api.ResponseCodeInvalidRequest)
+	}
+
+	// all roles are placed into the same resource pool
+	respoolID, err := h.respoolLoader.Load(ctx)
+	if err != nil {
+		return nil, auroraErrorf("load respool: %s", err)
+	}
+
+	resp, err := h.respoolClient.GetResourcePool(
+		ctx,
+		&respool.GetRequest{
+			Id: &v0peloton.ResourcePoolID{Value: respoolID.GetValue()},
+		},
+	)
+	if err != nil {
+		return nil, auroraErrorf("get respool %q: %s", respoolID.GetValue(), err)
+	}
+	if resp.GetError() != nil {
+		return nil, auroraErrorf(
+			"get respool %q: %s", respoolID.GetValue(), resp.GetError().String())
+	}
+
+	return &api.Result{
+		GetQuotaResult: ptoa.NewGetQuotaResult(resp.GetPoolinfo()),
+	}, nil
+}
+
+// newPendingReasonMessage converts the reasons returned by resource manager
+// to a user-friendly message.
+func newPendingReasonMessage(r *resmgrsvc.PendingReason) string {
+	var msgs []string
+	if r.GetAdmissionReason() != "" {
+		msgs = append(msgs, "admission: "+r.GetAdmissionReason())
+	}
+	if r.GetPlacementReason() != "" {
+		msgs = append(msgs, "placement: "+r.GetPlacementReason())
+	}
+	if len(msgs) == 0 {
+		return r.GetStateReason()
+	}
+	return strings.Join(msgs, "; ")
+}
+
 type taskFilter struct {
 	statuses map[api.ScheduleStatus]struct{}
 }
@@ -0,0 +1,292 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aurorabridge
+
+import (
+	"context"
+	"time"
+
+	cronsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc"
+	"github.com/uber/peloton/.gen/thrift/aurora/api"
+
+	"github.com/uber/peloton/pkg/aurorabridge/atop"
+
+	log "github.com/sirupsen/logrus"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// ScheduleCronJob creates a cron job, or replaces the cron job if one
+// already exists with the same job key.
+func (h *ServiceHandler) ScheduleCronJob(
+	ctx context.Context,
+	description *api.JobConfiguration,
+) (*api.Response, error) {
+
+	startTime := time.Now()
+	result, err := h.scheduleCronJob(ctx, description)
+	resp := newResponse(result, err)
+
+	defer func() {
+		h.recordCronMetrics(ProcedureScheduleCronJob, resp, startTime)
+
+		if err != nil {
+			log.WithFields(log.Fields{
+				"params": log.Fields{
+					"description": description,
+				},
+				"code":  err.responseCode,
+				"error": err.msg,
+			}).Error("ScheduleCronJob error")
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"params": log.Fields{
+				"description": description,
+			},
+		}).Info("ScheduleCronJob success")
+	}()
+	return resp, nil
+}
+
+func (h *ServiceHandler) scheduleCronJob(
+	ctx context.Context,
+	description *api.JobConfiguration,
+) (*api.Result, *auroraError) {
+
+	respoolID, err := h.respoolLoader.Load(ctx)
+	if err != nil {
+		return nil, auroraErrorf("load respool: %s", err)
+	}
+
+	spec, err := atop.NewCronJobSpec(
+		description,
+		respoolID,
+		h.config.ThermosExecutor,
+	)
+	if err != nil {
+		return nil, auroraErrorf("new cron job spec: %s", err).
+			code(api.ResponseCodeInvalidRequest)
+	}
+
+	_, err = h.cronClient.CreateCronJob(
+		ctx,
+		&cronsvc.CreateCronJobRequest{Spec: spec},
+	)
+	if err == nil {
+		return dummyResult(), nil
+	}
+	if !yarpcerrors.IsAlreadyExists(err) {
+		return nil, auroraErrorf("create cron job: %s", err)
+	}
+
+	// Aurora replaces the existing cron job on schedule.
+	if _, err := h.cronClient.ReplaceCronJob(
+		ctx,
+		&cronsvc.ReplaceCronJobRequest{Spec: spec},
+	); err != nil {
+		return nil, auroraErrorf("replace cron job: %s", err)
+	}
+	return dummyResult(), nil
+}
+
+// DescheduleCronJob removes a cron job. Runs which have already been
+// created by the cron job are not affected.
+func (h *ServiceHandler) DescheduleCronJob(
+	ctx context.Context,
+	job *api.JobKey,
+) (*api.Response, error) {
+
+	startTime := time.Now()
+	result, err := h.descheduleCronJob(ctx, job)
+	resp := newResponse(result, err)
+
+	defer func() {
+		h.recordCronMetrics(ProcedureDescheduleCronJob, resp, startTime)
+
+		if err != nil {
+			log.WithFields(log.Fields{
+				"params": log.Fields{
+					"job": job,
+				},
+				"code":  err.responseCode,
+				"error": err.msg,
+			}).Error("DescheduleCronJob error")
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"params": log.Fields{
+				"job": job,
+			},
+		}).Info("DescheduleCronJob success")
+	}()
+	return resp, nil
+}
+
+func (h *ServiceHandler) descheduleCronJob(
+	ctx context.Context,
+	job *api.JobKey,
+) (*api.Result, *auroraError) {
+
+	if _, err := h.cronClient.DeleteCronJob(
+		ctx,
+		&cronsvc.DeleteCronJobRequest{Name: atop.NewJobName(job)},
+	); err != nil {
+		aerr := auroraErrorf("delete cron job: %s", err)
+		if yarpcerrors.IsNotFound(err) {
+			aerr.code(api.ResponseCodeInvalidRequest)
+		}
+		return nil, aerr
+	}
+	return dummyResult(), nil
+}
+
+// StartCronJob starts a run of a cron job immediately.
+func (h *ServiceHandler) StartCronJob(
+	ctx context.Context,
+	job *api.JobKey,
+) (*api.Response, error) {
+
+	startTime := time.Now()
+	result, err := h.startCronJob(ctx, job)
+	resp := newResponse(result, err)
+
+	defer func() {
+		h.recordCronMetrics(ProcedureStartCronJob, resp, startTime)
+
+		if err != nil {
+			log.WithFields(log.Fields{
+				"params": log.Fields{
+					"job": job,
+				},
+				"code":  err.responseCode,
+				"error": err.msg,
+			}).Error("StartCronJob error")
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"params": log.Fields{
+				"job": job,
+			},
+		}).Info("StartCronJob success")
+	}()
+	return resp, nil
+}
+
+func (h *ServiceHandler) startCronJob(
+	ctx context.Context,
+	job *api.JobKey,
+) (*api.Result, *auroraError) {
+
+	if _, err := h.cronClient.StartCronJob(
+		ctx,
+		&cronsvc.StartCronJobRequest{Name: atop.NewJobName(job)},
+	); err != nil {
+		aerr := auroraErrorf("start cron job: %s", err)
+		if yarpcerrors.IsNotFound(err) {
+			aerr.code(api.ResponseCodeInvalidRequest)
+		}
+		return nil, aerr
+	}
+	return dummyResult(), nil
+}
+
+// ReplaceCronTemplate replaces the configuration of an existing cron job.
+func (h *ServiceHandler) ReplaceCronTemplate(
+	ctx context.Context,
+	config *api.JobConfiguration,
+) (*api.Response, error) {
+
+	startTime := time.Now()
+	result, err := h.replaceCronTemplate(ctx, config)
+	resp := newResponse(result, err)
+
+	defer func() {
+		h.recordCronMetrics(ProcedureReplaceCronTemplate, resp, startTime)
+
+		if err != nil {
+			log.WithFields(log.Fields{
+				"params": log.Fields{
+					"config": config,
+				},
+				"code":  err.responseCode,
+				"error": err.msg,
+			}).Error("ReplaceCronTemplate error")
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"params": log.Fields{
+				"config": config,
+			},
+		}).Info("ReplaceCronTemplate success")
+	}()
+	return resp, nil
+}
+
+func (h *ServiceHandler) replaceCronTemplate(
+	ctx context.Context,
+	config *api.JobConfiguration,
+) (*api.Result, *auroraError) {
+
+	respoolID, err := h.respoolLoader.Load(ctx)
+	if err != nil {
+		return nil, auroraErrorf("load respool: %s", err)
+	}
+
+	spec, err := atop.NewCronJobSpec(
+		config,
+		respoolID,
+		h.config.ThermosExecutor,
+	)
+	if err != nil {
+		return nil, auroraErrorf("new cron job spec: %s", err).
+			code(api.ResponseCodeInvalidRequest)
+	}
+
+	if _, err := h.cronClient.ReplaceCronJob(
+		ctx,
+		&cronsvc.ReplaceCronJobRequest{Spec: spec},
+	); err != nil {
+		aerr := auroraErrorf("replace cron job: %s", err)
+		if yarpcerrors.IsNotFound(err) {
+			aerr.code(api.ResponseCodeInvalidRequest)
+		}
+		return nil, aerr
+	}
+	return dummyResult(), nil
+}
+
+// recordCronMetrics records the response code and the latency of a cron
+// job procedure.
+func (h *ServiceHandler) recordCronMetrics(
+	procedure string,
+	resp *api.Response,
+	startTime time.Time,
+) {
+	h.metrics.
+		Procedures[procedure].
+		ResponseCode.
+		ResponseCodes[resp.GetResponseCode()].
+		Inc(1)
+
+	h.metrics.
+		Procedures[procedure].
+		ResponseCodeLatency.
+		ResponseCodes[resp.GetResponseCode()].
+		Record(time.Since(startTime))
+}
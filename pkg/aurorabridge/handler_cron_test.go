@@ -0,0 +1,200 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aurorabridge
+
+import (
+	"errors"
+
+	cronsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc"
+	"github.com/uber/peloton/.gen/thrift/aurora/api"
+
+	"github.com/uber/peloton/pkg/aurorabridge/atop"
+	"github.com/uber/peloton/pkg/aurorabridge/fixture"
+
+	"github.com/golang/mock/gomock"
+	"go.uber.org/thriftrw/ptr"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func (suite *ServiceHandlerTestSuite) newCronJobConfiguration() *api.JobConfiguration {
+	tc := fixture.AuroraTaskConfig()
+	return &api.JobConfiguration{
+		Key:                 tc.GetJob(),
+		TaskConfig:          tc,
+		InstanceCount:       ptr.Int32(2),
+		CronSchedule:        ptr.String("*/5 * * * *"),
+		CronCollisionPolicy: api.CronCollisionPolicyCancelNew.Ptr(),
+	}
+}
+
+// TestScheduleCronJob tests creating a new cron job
+func (suite *ServiceHandlerTestSuite) TestScheduleCronJob() {
+	respoolID := fixture.PelotonResourcePoolID()
+	config := suite.newCronJobConfiguration()
+	spec, err := atop.NewCronJobSpec(
+		config, respoolID, suite.config.ThermosExecutor)
+	suite.NoError(err)
+
+	suite.respoolLoader.EXPECT().Load(gomock.Any()).Return(respoolID, nil)
+	suite.cronClient.EXPECT().
+		CreateCronJob(gomock.Any(), &cronsvc.CreateCronJobRequest{Spec: spec}).
+		Return(&cronsvc.CreateCronJobResponse{}, nil)
+
+	resp, err := suite.handler.ScheduleCronJob(suite.ctx, config)
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeOk, resp.GetResponseCode())
+}
+
+// TestScheduleCronJobReplace tests that scheduling an existing cron job
+// replaces it
+func (suite *ServiceHandlerTestSuite) TestScheduleCronJobReplace() {
+	respoolID := fixture.PelotonResourcePoolID()
+	config := suite.newCronJobConfiguration()
+	spec, err := atop.NewCronJobSpec(
+		config, respoolID, suite.config.ThermosExecutor)
+	suite.NoError(err)
+
+	suite.respoolLoader.EXPECT().Load(gomock.Any()).Return(respoolID, nil)
+	suite.cronClient.EXPECT().
+		CreateCronJob(gomock.Any(), &cronsvc.CreateCronJobRequest{Spec: spec}).
+		Return(nil, yarpcerrors.AlreadyExistsErrorf("cron job exists"))
+	suite.cronClient.EXPECT().
+		ReplaceCronJob(gomock.Any(), &cronsvc.ReplaceCronJobRequest{Spec: spec}).
+		Return(&cronsvc.ReplaceCronJobResponse{}, nil)
+
+	resp, err := suite.handler.ScheduleCronJob(suite.ctx, config)
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeOk, resp.GetResponseCode())
+}
+
+// TestScheduleCronJobInvalidConfig tests scheduling a cron job without
+// a cron schedule
+func (suite *ServiceHandlerTestSuite) TestScheduleCronJobInvalidConfig() {
+	config := suite.newCronJobConfiguration()
+	config.CronSchedule = nil
+
+	suite.respoolLoader.EXPECT().
+		Load(gomock.Any()).
+		Return(fixture.PelotonResourcePoolID(), nil)
+
+	resp, err := suite.handler.ScheduleCronJob(suite.ctx, config)
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeInvalidRequest, resp.GetResponseCode())
+}
+
+// TestScheduleCronJobCreateFailure tests failing to create a cron job
+func (suite *ServiceHandlerTestSuite) TestScheduleCronJobCreateFailure() {
+	suite.respoolLoader.EXPECT().
+		Load(gomock.Any()).
+		Return(fixture.PelotonResourcePoolID(), nil)
+	suite.cronClient.EXPECT().
+		CreateCronJob(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("some error"))
+
+	resp, err := suite.handler.ScheduleCronJob(
+		suite.ctx, suite.newCronJobConfiguration())
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeError, resp.GetResponseCode())
+}
+
+// TestDescheduleCronJob tests removing a cron job
+func (suite *ServiceHandlerTestSuite) TestDescheduleCronJob() {
+	k := fixture.AuroraJobKey()
+
+	suite.cronClient.EXPECT().
+		DeleteCronJob(gomock.Any(), &cronsvc.DeleteCronJobRequest{
+			Name: atop.NewJobName(k),
+		}).
+		Return(&cronsvc.DeleteCronJobResponse{}, nil)
+
+	resp, err := suite.handler.DescheduleCronJob(suite.ctx, k)
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeOk, resp.GetResponseCode())
+}
+
+// TestDescheduleCronJobNotFound tests removing a cron job which
+// does not exist
+func (suite *ServiceHandlerTestSuite) TestDescheduleCronJobNotFound() {
+	suite.cronClient.EXPECT().
+		DeleteCronJob(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.NotFoundErrorf("cron job not found"))
+
+	resp, err := suite.handler.DescheduleCronJob(
+		suite.ctx, fixture.AuroraJobKey())
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeInvalidRequest, resp.GetResponseCode())
+}
+
+// TestStartCronJob tests starting a run of a cron job
+func (suite *ServiceHandlerTestSuite) TestStartCronJob() {
+	k := fixture.AuroraJobKey()
+
+	suite.cronClient.EXPECT().
+		StartCronJob(gomock.Any(), &cronsvc.StartCronJobRequest{
+			Name: atop.NewJobName(k),
+		}).
+		Return(&cronsvc.StartCronJobResponse{
+			JobId: fixture.PelotonJobID(),
+		}, nil)
+
+	resp, err := suite.handler.StartCronJob(suite.ctx, k)
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeOk, resp.GetResponseCode())
+}
+
+// TestStartCronJobFailure tests failing to start a run of a cron job
+func (suite *ServiceHandlerTestSuite) TestStartCronJobFailure() {
+	suite.cronClient.EXPECT().
+		StartCronJob(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("some error"))
+
+	resp, err := suite.handler.StartCronJob(suite.ctx, fixture.AuroraJobKey())
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeError, resp.GetResponseCode())
+}
+
+// TestReplaceCronTemplate tests replacing the configuration of a cron job
+func (suite *ServiceHandlerTestSuite) TestReplaceCronTemplate() {
+	respoolID := fixture.PelotonResourcePoolID()
+	config := suite.newCronJobConfiguration()
+	spec, err := atop.NewCronJobSpec(
+		config, respoolID, suite.config.ThermosExecutor)
+	suite.NoError(err)
+
+	suite.respoolLoader.EXPECT().Load(gomock.Any()).Return(respoolID, nil)
+	suite.cronClient.EXPECT().
+		ReplaceCronJob(gomock.Any(), &cronsvc.ReplaceCronJobRequest{Spec: spec}).
+		Return(&cronsvc.ReplaceCronJobResponse{}, nil)
+
+	resp, err := suite.handler.ReplaceCronTemplate(suite.ctx, config)
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeOk, resp.GetResponseCode())
+}
+
+// TestReplaceCronTemplateNotFound tests replacing the configuration of
+// a cron job which does not exist
+func (suite *ServiceHandlerTestSuite) TestReplaceCronTemplateNotFound() {
+	suite.respoolLoader.EXPECT().
+		Load(gomock.Any()).
+		Return(fixture.PelotonResourcePoolID(), nil)
+	suite.cronClient.EXPECT().
+		ReplaceCronJob(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.NotFoundErrorf("cron job not found"))
+
+	resp, err := suite.handler.ReplaceCronTemplate(
+		suite.ctx, suite.newCronJobConfiguration())
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeInvalidRequest, resp.GetResponseCode())
+}
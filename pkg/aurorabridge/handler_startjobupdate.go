@@ -352,17 +352,23 @@ func getTerminalInstances(
 // getUpdateInstances returns a map of instance ids that are expected to
 // be updated, based on UpdateOnlyTheseInstances field from JobUpdateRequest.
 func getUpdateInstances(req *api.JobUpdateRequest) map[uint32]struct{} {
-	// Populate update instances based on UpdateOnlyTheseInstances field
+	// Populate update instances based on UpdateOnlyTheseInstances field.
+	// Instance ids beyond the instance count are ignored, otherwise they
+	// would be counted towards an update covering all instances and
+	// wipe out the instance specs of pinned instances.
 	updateInstances := make(map[uint32]struct{})
 	for _, r := range req.GetSettings().GetUpdateOnlyTheseInstances() {
 		for i := uint32(r.GetFirst()); i <= uint32(r.GetLast()); i++ {
+			if i >= uint32(req.GetInstanceCount()) {
+				break
+			}
 			updateInstances[i] = struct{}{}
 		}
 	}
 
 	// Expect to update all instances if UpdateOnlyTheseInstances field
 	// is not provided
-	if len(updateInstances) == 0 {
+	if len(req.GetSettings().GetUpdateOnlyTheseInstances()) == 0 {
 		for i := uint32(0); i < uint32(req.GetInstanceCount()); i++ {
 			updateInstances[i] = struct{}{}
 		}
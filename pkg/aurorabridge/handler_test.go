@@ -23,6 +23,10 @@ import (
 	"testing"
 
 	"github.com/pborman/uuid"
+	v0peloton "github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	respoolmocks "github.com/uber/peloton/.gen/peloton/api/v0/respool/mocks"
+	cronmocks "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc/mocks"
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
 	statelesssvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc"
 	jobmocks "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc/mocks"
@@ -33,6 +37,8 @@ import (
 	pbquery "github.com/uber/peloton/.gen/peloton/api/v1alpha/query"
 	"github.com/uber/peloton/.gen/peloton/private/jobmgrsvc"
 	jobmgrmocks "github.com/uber/peloton/.gen/peloton/private/jobmgrsvc/mocks"
+	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
+	resmgrmocks "github.com/uber/peloton/.gen/peloton/private/resmgrsvc/mocks"
 	"github.com/uber/peloton/.gen/thrift/aurora/api"
 	commonmocks "github.com/uber/peloton/pkg/aurorabridge/common/mocks"
 	aurorabridgemocks "github.com/uber/peloton/pkg/aurorabridge/mocks"
@@ -44,6 +50,7 @@ import (
 	"github.com/uber/peloton/pkg/aurorabridge/label"
 	"github.com/uber/peloton/pkg/aurorabridge/mockutil"
 	"github.com/uber/peloton/pkg/aurorabridge/opaquedata"
+	"github.com/uber/peloton/pkg/aurorabridge/ptoa"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -66,6 +73,9 @@ type ServiceHandlerTestSuite struct {
 	jobmgrClient   *jobmgrmocks.MockJobManagerServiceYARPCClient
 	listPodsStream *jobmocks.MockJobServiceServiceListPodsYARPCClient
 	podClient      *podmocks.MockPodServiceYARPCClient
+	cronClient     *cronmocks.MockCronJobServiceYARPCClient
+	resmgrClient   *resmgrmocks.MockResourceManagerServiceYARPCClient
+	respoolClient  *respoolmocks.MockResourceManagerYARPCClient
 	respoolLoader  *aurorabridgemocks.MockRespoolLoader
 	random         *commonmocks.MockRandom
 
@@ -83,6 +93,9 @@ func (suite *ServiceHandlerTestSuite) SetupTest() {
 	suite.jobmgrClient = jobmgrmocks.NewMockJobManagerServiceYARPCClient(suite.ctrl)
 	suite.listPodsStream = jobmocks.NewMockJobServiceServiceListPodsYARPCClient(suite.ctrl)
 	suite.podClient = podmocks.NewMockPodServiceYARPCClient(suite.ctrl)
+	suite.cronClient = cronmocks.NewMockCronJobServiceYARPCClient(suite.ctrl)
+	suite.resmgrClient = resmgrmocks.NewMockResourceManagerServiceYARPCClient(suite.ctrl)
+	suite.respoolClient = respoolmocks.NewMockResourceManagerYARPCClient(suite.ctrl)
 	suite.respoolLoader = aurorabridgemocks.NewMockRespoolLoader(suite.ctrl)
 	suite.random = commonmocks.NewMockRandom(suite.ctrl)
 
@@ -107,6 +120,9 @@ func (suite *ServiceHandlerTestSuite) SetupTest() {
 		suite.jobClient,
 		suite.jobmgrClient,
 		suite.podClient,
+		suite.cronClient,
+		suite.resmgrClient,
+		suite.respoolClient,
 		suite.respoolLoader,
 		suite.random,
 	)
@@ -1483,6 +1499,136 @@ func (suite *ServiceHandlerTestSuite) expectQueryPods(
 		Return(&statelesssvc.QueryPodsResponse{Pods: pods}, nil)
 }
 
+// TestGetPendingReason tests that the reasons of the pending tasks are
+// fetched from resource manager
+func (suite *ServiceHandlerTestSuite) TestGetPendingReason() {
+	query := fixture.AuroraTaskQuery()
+	jobKey := query.GetJobKeys()[0]
+	jobID := fixture.PelotonJobID()
+
+	suite.expectGetJobSummary(jobKey, jobID, 3)
+
+	var pods []*pod.PodInfo
+	for i, state := range []pod.PodState{
+		pod.PodState_POD_STATE_PENDING,
+		pod.PodState_POD_STATE_PLACING,
+		pod.PodState_POD_STATE_RUNNING,
+	} {
+		podName := util.CreatePelotonTaskID(jobID.GetValue(), uint32(i))
+		pods = append(pods, &pod.PodInfo{
+			Spec: &pod.PodSpec{
+				PodName: &peloton.PodName{Value: podName},
+			},
+			Status: &pod.PodStatus{
+				PodId: &peloton.PodID{Value: podName + "-1"},
+				State: state,
+			},
+		})
+	}
+	suite.jobClient.EXPECT().
+		QueryPods(gomock.Any(), gomock.Any()).
+		Return(&statelesssvc.QueryPodsResponse{Pods: pods}, nil)
+
+	pendingTask := &v0peloton.TaskID{
+		Value: pods[0].GetSpec().GetPodName().GetValue(),
+	}
+	placingTask := &v0peloton.TaskID{
+		Value: pods[1].GetSpec().GetPodName().GetValue(),
+	}
+	suite.resmgrClient.EXPECT().
+		GetPendingReasons(gomock.Any(), &resmgrsvc.GetPendingReasonsRequest{
+			Tasks: []*v0peloton.TaskID{pendingTask, placingTask},
+		}).
+		Return(&resmgrsvc.GetPendingReasonsResponse{
+			Error: []*resmgrsvc.GetPendingReasonsResponse_Error{
+				{NotFound: &resmgrsvc.TasksNotFound{Task: placingTask}},
+			},
+			Reasons: []*resmgrsvc.PendingReason{
+				{
+					Task:            pendingTask,
+					AdmissionReason: "entitlement exceeded",
+					PlacementReason: "no offers from the cluster",
+				},
+			},
+		}, nil)
+
+	resp, err := suite.handler.GetPendingReason(suite.ctx, query)
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeOk, resp.GetResponseCode())
+
+	reasons := resp.GetResult().GetGetPendingReasonResult().GetReasons()
+	suite.Len(reasons, 1)
+	suite.Equal(pods[0].GetStatus().GetPodId().GetValue(), reasons[0].GetTaskId())
+	suite.Equal(
+		"admission: entitlement exceeded; placement: no offers from the cluster",
+		reasons[0].GetReason())
+}
+
+// TestGetPendingReason_StatusesNotSupported tests that statuses can not
+// be provided in the query of GetPendingReason
+func (suite *ServiceHandlerTestSuite) TestGetPendingReason_StatusesNotSupported() {
+	query := fixture.AuroraTaskQuery()
+	query.Statuses = map[api.ScheduleStatus]struct{}{
+		api.ScheduleStatusRunning: {},
+	}
+
+	resp, err := suite.handler.GetPendingReason(suite.ctx, query)
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeInvalidRequest, resp.GetResponseCode())
+}
+
+// TestGetQuota tests GetQuota returns the quota of the respool
+// which the jobs of the role are placed into
+func (suite *ServiceHandlerTestSuite) TestGetQuota() {
+	respoolID := fixture.PelotonResourcePoolID()
+	poolInfo := fixture.PelotonResourcePoolInfo()
+
+	suite.respoolLoader.EXPECT().Load(gomock.Any()).Return(respoolID, nil)
+
+	suite.respoolClient.EXPECT().
+		GetResourcePool(gomock.Any(), &respool.GetRequest{
+			Id: &v0peloton.ResourcePoolID{Value: respoolID.GetValue()},
+		}).
+		Return(&respool.GetResponse{Poolinfo: poolInfo}, nil)
+
+	resp, err := suite.handler.GetQuota(suite.ctx, ptr.String("some-role"))
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeOk, resp.GetResponseCode())
+	suite.Equal(
+		ptoa.NewGetQuotaResult(poolInfo),
+		resp.GetResult().GetGetQuotaResult(),
+	)
+}
+
+// TestGetQuota_NoRole tests GetQuota fails when the role is not provided
+func (suite *ServiceHandlerTestSuite) TestGetQuota_NoRole() {
+	resp, err := suite.handler.GetQuota(suite.ctx, nil)
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeInvalidRequest, resp.GetResponseCode())
+}
+
+// TestGetQuota_GetResourcePoolError tests GetQuota fails when
+// the respool cannot be read
+func (suite *ServiceHandlerTestSuite) TestGetQuota_GetResourcePoolError() {
+	respoolID := fixture.PelotonResourcePoolID()
+
+	suite.respoolLoader.EXPECT().Load(gomock.Any()).Return(respoolID, nil)
+
+	suite.respoolClient.EXPECT().
+		GetResourcePool(gomock.Any(), gomock.Any()).
+		Return(&respool.GetResponse{
+			Error: &respool.GetResponse_Error{
+				NotFound: &respool.ResourcePoolNotFound{
+					Id: &v0peloton.ResourcePoolID{Value: respoolID.GetValue()},
+				},
+			},
+		}, nil)
+
+	resp, err := suite.handler.GetQuota(suite.ctx, ptr.String("some-role"))
+	suite.NoError(err)
+	suite.Equal(api.ResponseCodeError, resp.GetResponseCode())
+}
+
 // TestGetTasksWithoutConfigs_ParallelismSuccess tests parallelism for
 // GetTasksWithoutConfig success scenario
 func (suite *ServiceHandlerTestSuite) TestGetTasksWithoutConfigs_ParallelismSuccess() {
@@ -3940,6 +4086,30 @@ func (suite *ServiceHandlerTestSuite) TestGetUpdateInstances_PinnedInstances() {
 	}, ui)
 }
 
+// TestGetUpdateInstances_PinnedInstancesOutOfRange checks that instance ids
+// in UpdateOnlyTheseInstances beyond the instance count are ignored by
+// getUpdateInstances, such that the update does not appear to cover all
+// instances.
+func (suite *ServiceHandlerTestSuite) TestGetUpdateInstances_PinnedInstancesOutOfRange() {
+	defer goleak.VerifyNoLeaks(suite.T())
+
+	req := &api.JobUpdateRequest{
+		InstanceCount: ptr.Int32(3),
+		Settings: &api.JobUpdateSettings{
+			UpdateOnlyTheseInstances: []*api.Range{
+				{First: ptr.Int32(1), Last: ptr.Int32(3)},
+				{First: ptr.Int32(5), Last: ptr.Int32(6)},
+			},
+		},
+	}
+
+	ui := getUpdateInstances(req)
+	suite.Equal(map[uint32]struct{}{
+		1: {},
+		2: {},
+	}, ui)
+}
+
 // TestGetSpecChangedInstances tests getSpecChangedInstances util function.
 func (suite *ServiceHandlerTestSuite) TestGetSpecChangedInstances() {
 	defer goleak.VerifyNoLeaks(suite.T())
@@ -37,20 +37,6 @@ func (h *ServiceHandler) GetTasksStatus(
 	return nil, errUnimplemented
 }
 
-// GetPendingReason will remain unimplemented.
-func (h *ServiceHandler) GetPendingReason(
-	ctx context.Context,
-	query *api.TaskQuery) (*api.Response, error) {
-	return nil, errUnimplemented
-}
-
-// GetQuota will remain unimplemented.
-func (h *ServiceHandler) GetQuota(
-	ctx context.Context,
-	ownerRole *string) (*api.Response, error) {
-	return nil, errUnimplemented
-}
-
 // PopulateJobConfig will remain unimplemented.
 func (h *ServiceHandler) PopulateJobConfig(
 	ctx context.Context,
@@ -66,27 +52,6 @@ func (h *ServiceHandler) CreateJob(
 	return nil, errUnimplemented
 }
 
-// ScheduleCronJob will remain unimplemented.
-func (h *ServiceHandler) ScheduleCronJob(
-	ctx context.Context,
-	description *api.JobConfiguration) (*api.Response, error) {
-	return nil, errUnimplemented
-}
-
-// DescheduleCronJob will remain unimplemented.
-func (h *ServiceHandler) DescheduleCronJob(
-	ctx context.Context,
-	job *api.JobKey) (*api.Response, error) {
-	return nil, errUnimplemented
-}
-
-// StartCronJob will remain unimplemented.
-func (h *ServiceHandler) StartCronJob(
-	ctx context.Context,
-	job *api.JobKey) (*api.Response, error) {
-	return nil, errUnimplemented
-}
-
 // RestartShards will remain unimplemented.
 func (h *ServiceHandler) RestartShards(
 	ctx context.Context,
@@ -103,10 +68,3 @@ func (h *ServiceHandler) AddInstances(
 	count *int32) (*api.Response, error) {
 	return nil, errUnimplemented
 }
-
-// ReplaceCronTemplate will remain unimplemented.
-func (h *ServiceHandler) ReplaceCronTemplate(
-	ctx context.Context,
-	config *api.JobConfiguration) (*api.Response, error) {
-	return nil, errUnimplemented
-}
@@ -21,37 +21,49 @@ import (
 
 const (
 	ProcedureAbortJobUpdate         = "auroraschedulermanager__abortjobupdate"
+	ProcedureDescheduleCronJob      = "auroraschedulermanager__deschedulecronjob"
 	ProcedureGetConfigSummary       = "readonlyscheduler__getconfigsummary"
 	ProcedureGetJobSummary          = "readonlyscheduler__getjobsummary"
 	ProcedureGetJobUpdateDetails    = "readonlyscheduler__getjobupdatedetails"
 	ProcedureGetJobUpdateDiff       = "readonlyscheduler__getjobupdatediff"
 	ProcedureGetJobUpdateSummaries  = "readonlyscheduler__getjobupdatesummaries"
 	ProcedureGetJobs                = "readonlyscheduler__getjobs"
+	ProcedureGetPendingReason       = "readonlyscheduler__getpendingreason"
+	ProcedureGetQuota               = "readonlyscheduler__getquota"
 	ProcedureGetTasksWithoutConfigs = "readonlyscheduler__gettaskswithoutconfigs"
 	ProcedureGetTierConfigs         = "readonlyscheduler__gettierconfigs"
 	ProcedureKillTasks              = "auroraschedulermanager__killtasks"
 	ProcedurePauseJobUpdate         = "auroraschedulermanager__pausejobupdate"
 	ProcedurePulseJobUpdate         = "auroraschedulermanager__pulsejobupdate"
+	ProcedureReplaceCronTemplate    = "auroraschedulermanager__replacecrontemplate"
 	ProcedureResumeJobUpdate        = "auroraschedulermanager__resumejobupdate"
 	ProcedureRollbackJobUpdate      = "auroraschedulermanager__rollbackjobupdate"
+	ProcedureScheduleCronJob        = "auroraschedulermanager__schedulecronjob"
+	ProcedureStartCronJob           = "auroraschedulermanager__startcronjob"
 	ProcedureStartJobUpdate         = "auroraschedulermanager__startjobupdate"
 )
 
 var _procedures = []string{
 	ProcedureAbortJobUpdate,
+	ProcedureDescheduleCronJob,
 	ProcedureGetConfigSummary,
 	ProcedureGetJobSummary,
 	ProcedureGetJobUpdateDetails,
 	ProcedureGetJobUpdateDiff,
 	ProcedureGetJobUpdateSummaries,
 	ProcedureGetJobs,
+	ProcedureGetPendingReason,
+	ProcedureGetQuota,
 	ProcedureGetTasksWithoutConfigs,
 	ProcedureGetTierConfigs,
 	ProcedureKillTasks,
 	ProcedurePauseJobUpdate,
 	ProcedurePulseJobUpdate,
+	ProcedureReplaceCronTemplate,
 	ProcedureResumeJobUpdate,
 	ProcedureRollbackJobUpdate,
+	ProcedureScheduleCronJob,
+	ProcedureStartCronJob,
 	ProcedureStartJobUpdate,
 }
 
@@ -145,5 +145,20 @@ func newConstraint(constraint *pod.Constraint) (*api.Constraint, error) {
 		}, nil
 	}
 
+	// If see a "Not" Constraint, assume it's created from a negated
+	// ValueConstraint. Throw an error if the negated Constraint is not
+	// a ValueConstraint.
+	if constraint.GetType() == pod.Constraint_CONSTRAINT_TYPE_NOT {
+		ac, err := newConstraint(constraint.GetNotConstraint().GetConstraint())
+		if err != nil {
+			return nil, err
+		}
+		if !ac.GetConstraint().IsSetValue() {
+			return nil, fmt.Errorf("expect negated value constraint")
+		}
+		ac.Constraint.Value.Negated = ptr.Bool(true)
+		return ac, nil
+	}
+
 	return nil, fmt.Errorf("unexpected constraint type %d", constraint.GetType())
 }
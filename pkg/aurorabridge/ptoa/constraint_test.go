@@ -151,6 +151,36 @@ func TestNewConstraints_ValueConstraintMultiple(t *testing.T) {
 	assert.Equal(t, constraints, cc)
 }
 
+// TestNewConstraints_NegatedValueConstraint tests that NewConstraints
+// returns aurora negated ValueConstraint correctly based on input
+// generated by atop.NewConstraint()
+func TestNewConstraints_NegatedValueConstraint(t *testing.T) {
+	jobKey := fixture.AuroraJobKey()
+	jobKeyLabel := label.NewAuroraJobKey(jobKey)
+	constraints := []*api.Constraint{
+		{
+			Name: ptr.String(common.MesosHostAttr),
+			Constraint: &api.TaskConstraint{
+				Value: &api.ValueConstraint{
+					Negated: ptr.Bool(true),
+					Values: map[string]struct{}{
+						"host-1": {},
+						"host-2": {},
+					},
+				},
+			},
+		},
+	}
+
+	c, err := atop.NewConstraint(jobKeyLabel, constraints)
+	assert.NoError(t, err)
+
+	cc, err := NewConstraints(c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, constraints, cc)
+}
+
 // TestNewConstraints_MultipleConstraints tests that NewConstraints
 // returns multiple aurora Constraints correctly based on input generated
 // by atop.NewConstraint()
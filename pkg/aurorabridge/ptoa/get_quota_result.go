@@ -0,0 +1,78 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptoa
+
+import (
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	"github.com/uber/peloton/.gen/thrift/aurora/api"
+
+	"github.com/uber/peloton/pkg/common"
+
+	"go.uber.org/thriftrw/ptr"
+)
+
+// NewGetQuotaResult returns aurora quota from the peloton resource pool
+// which the jobs of a role are placed into. The reservation of the pool
+// is the quota since non-preemptible jobs are admitted within it, the
+// non-revocable allocation is the prod consumption and the revocable
+// allocation (slack) is the non-prod consumption. Resource pools are
+// shared between roles, so the dedicated consumption is always empty.
+func NewGetQuotaResult(info *respool.ResourcePoolInfo) *api.GetQuotaResult {
+	reservation := make(map[string]float64)
+	for _, r := range info.GetConfig().GetResources() {
+		reservation[r.GetKind()] = r.GetReservation()
+	}
+
+	allocation := make(map[string]float64)
+	slack := make(map[string]float64)
+	for _, u := range info.GetUsage() {
+		allocation[u.GetKind()] = u.GetAllocation()
+		slack[u.GetKind()] = u.GetSlack()
+	}
+
+	return &api.GetQuotaResult{
+		Quota:                       newResourceAggregate(reservation),
+		ProdSharedConsumption:       newResourceAggregate(allocation),
+		NonProdSharedConsumption:    newResourceAggregate(slack),
+		ProdDedicatedConsumption:    newResourceAggregate(nil),
+		NonProdDedicatedConsumption: newResourceAggregate(nil),
+	}
+}
+
+// newResourceAggregate converts the amount of each peloton resource kind
+// to aurora ResourceAggregate. Memory and disk are in MB in both.
+func newResourceAggregate(resources map[string]float64) *api.ResourceAggregate {
+	numCpus := resources[common.CPU]
+	ramMb := int64(resources[common.MEMORY])
+	diskMb := int64(resources[common.DISK])
+
+	auroraResources := []*api.Resource{
+		{NumCpus: ptr.Float64(numCpus)},
+		{RamMb: ptr.Int64(ramMb)},
+		{DiskMb: ptr.Int64(diskMb)},
+	}
+	if numGpus := int64(resources[common.GPU]); numGpus > 0 {
+		auroraResources = append(auroraResources, &api.Resource{
+			NumGpus: ptr.Int64(numGpus),
+		})
+	}
+
+	return &api.ResourceAggregate{
+		NumCpus:   ptr.Float64(numCpus),
+		RamMb:     ptr.Int64(ramMb),
+		DiskMb:    ptr.Int64(diskMb),
+		Resources: auroraResources,
+	}
+}
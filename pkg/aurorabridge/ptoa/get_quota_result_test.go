@@ -0,0 +1,86 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptoa
+
+import (
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	"github.com/uber/peloton/.gen/thrift/aurora/api"
+
+	"github.com/uber/peloton/pkg/aurorabridge/fixture"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/thriftrw/ptr"
+)
+
+// TestNewGetQuotaResult checks NewGetQuotaResult converts the reservation,
+// allocation and slack of a resource pool to aurora quota and consumption.
+func TestNewGetQuotaResult(t *testing.T) {
+	info := &respool.ResourcePoolInfo{
+		Config: &respool.ResourcePoolConfig{
+			Resources: []*respool.ResourceConfig{
+				{Kind: "cpu", Reservation: 100, Limit: 200},
+				{Kind: "memory", Reservation: 1024, Limit: 2048},
+				{Kind: "disk", Reservation: 4096, Limit: 4096},
+				{Kind: "gpu", Reservation: 2, Limit: 2},
+			},
+		},
+		Usage: []*respool.ResourceUsage{
+			{Kind: "cpu", Allocation: 10.5, Slack: 2},
+			{Kind: "memory", Allocation: 512, Slack: 128},
+			{Kind: "disk", Allocation: 1024, Slack: 0},
+			{Kind: "gpu", Allocation: 0, Slack: 0},
+		},
+	}
+
+	r := NewGetQuotaResult(info)
+
+	assert.Equal(t, &api.ResourceAggregate{
+		NumCpus: ptr.Float64(100),
+		RamMb:   ptr.Int64(1024),
+		DiskMb:  ptr.Int64(4096),
+		Resources: []*api.Resource{
+			{NumCpus: ptr.Float64(100)},
+			{RamMb: ptr.Int64(1024)},
+			{DiskMb: ptr.Int64(4096)},
+			{NumGpus: ptr.Int64(2)},
+		},
+	}, r.GetQuota())
+
+	assert.Equal(t, 10.5, r.GetProdSharedConsumption().GetNumCpus())
+	assert.Equal(t, int64(512), r.GetProdSharedConsumption().GetRamMb())
+	assert.Equal(t, int64(1024), r.GetProdSharedConsumption().GetDiskMb())
+	assert.Len(t, r.GetProdSharedConsumption().GetResources(), 3)
+
+	assert.Equal(t, float64(2), r.GetNonProdSharedConsumption().GetNumCpus())
+	assert.Equal(t, int64(128), r.GetNonProdSharedConsumption().GetRamMb())
+
+	assert.Equal(t, float64(0), r.GetProdDedicatedConsumption().GetNumCpus())
+	assert.Equal(t, int64(0), r.GetNonProdDedicatedConsumption().GetRamMb())
+}
+
+// TestNewGetQuotaResultFixture checks NewGetQuotaResult sets all of the
+// aggregates for a random resource pool.
+func TestNewGetQuotaResultFixture(t *testing.T) {
+	r := NewGetQuotaResult(fixture.PelotonResourcePoolInfo())
+
+	assert.NotNil(t, r.GetQuota())
+	assert.NotNil(t, r.GetProdSharedConsumption())
+	assert.NotNil(t, r.GetNonProdSharedConsumption())
+	assert.NotNil(t, r.GetProdDedicatedConsumption())
+	assert.NotNil(t, r.GetNonProdDedicatedConsumption())
+	assert.True(t, r.GetQuota().GetNumCpus() >= r.GetProdSharedConsumption().GetNumCpus())
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type userContextKey struct{}
+
+// ContextWithUser returns a copy of ctx which carries
+// the user authenticated for the request
+func ContextWithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext returns the user authenticated for the request,
+// it returns false if the request did not go through auth
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userContextKey{}).(User)
+	return user, ok
+}
+
+// AuthorizeResource returns a PermissionDenied error if the user
+// authenticated for the request is not permitted to call the
+// procedure of the request on the resource. Requests which did not
+// go through auth, such as internal calls, are permitted.
+func AuthorizeResource(ctx context.Context, resource *Resource) error {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	procedure := yarpc.CallFromContext(ctx).Procedure()
+	if !user.IsPermittedOnResource(procedure, resource) {
+		return yarpcerrors.PermissionDeniedErrorf(
+			"not permitted to call %s on %s", procedure, resource)
+	}
+	return nil
+}
+
+// String returns the description of the resource used in errors and logs
+func (r *Resource) String() string {
+	if r == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf(
+		"resource{respool:%s owner:%s owning_team:%s ldap_groups:%v}",
+		r.RespoolPath, r.Owner, r.OwningTeam, r.LdapGroups)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/uber/peloton/pkg/auth"
 	"github.com/uber/peloton/pkg/auth/impl/basic"
 	"github.com/uber/peloton/pkg/auth/impl/noop"
+	"github.com/uber/peloton/pkg/auth/impl/token"
 
 	"go.uber.org/yarpc/yarpcerrors"
 )
@@ -29,6 +30,8 @@ func CreateNewSecurityManager(config *auth.Config) (auth.SecurityManager, error)
 		return noop.NewNoopSecurityManager(), nil
 	case auth.BASIC:
 		return basic.NewBasicSecurityManager(config.Path)
+	case auth.TOKEN:
+		return token.NewTokenSecurityManager(config.Path)
 	default:
 		return nil,
 			yarpcerrors.InvalidArgumentErrorf("unknown security type provided: %s", config.AuthType)
@@ -42,6 +45,8 @@ func CreateNewSecurityClient(config *auth.Config) (auth.SecurityClient, error) {
 		return noop.NewNoopSecurityClient(), nil
 	case auth.BASIC:
 		return basic.NewBasicSecurityClient(config.Path)
+	case auth.TOKEN:
+		return token.NewTokenSecurityClient(config.Path)
 	default:
 		return nil,
 			yarpcerrors.InvalidArgumentErrorf("unknown security type provided: %s", config.AuthType)
@@ -16,7 +16,7 @@ package basic
 
 type authConfig struct {
 	Users        []*userConfig
-	Roles        []*roleConfig
+	Roles        []*RoleConfig
 	InternalUser string `yaml:"internal_user"`
 }
 
@@ -26,8 +26,30 @@ type userConfig struct {
 	Password string
 }
 
-type roleConfig struct {
+// RoleConfig defines the procedures a role is permitted to call.
+// It is shared with other auth types which map their identities onto roles.
+type RoleConfig struct {
 	Role   string
 	Accept []string
 	Reject []string
+	// Scopes limit some of the accepted procedures
+	// to the resources matched by the scopes
+	Scopes []*ScopeConfig
+}
+
+// ScopeConfig limits procedures to the resources it matches,
+// a resource is matched if any of its respool path, owner,
+// owning team or ldap groups is matched by the scope.
+// A procedure limited by several scopes is permitted if
+// any of them matches the resource.
+type ScopeConfig struct {
+	// Procedures limited by the scope, in the same format as Accept
+	Procedures []string
+	// RespoolPaths matches resource pool paths, a path ending
+	// with '*' matches all the resource pools with the prefix
+	RespoolPaths []string `yaml:"respool_paths"`
+	// Owners matches the owner or owning team of the resource
+	Owners []string
+	// LdapGroups matches resources with any of the ldap groups
+	LdapGroups []string `yaml:"ldap_groups"`
 }
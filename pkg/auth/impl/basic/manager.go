@@ -29,6 +29,8 @@ const (
 	// rule that matches all methods under all services
 	_matchAllRule       = "*"
 	_procedureSeparator = "::"
+	// resource pool paths in scopes must be absolute
+	_respoolPathSeparator = "/"
 
 	// expected fields passed by token
 	_usernameHeaderKey = "username"
@@ -59,6 +61,18 @@ type role struct {
 	accepts map[string][]string
 	// service -> methods
 	rejects map[string][]string
+	scopes  []*scope
+}
+
+// all fields are immutable after init,
+// need lock protection if the assumption breaks
+type scope struct {
+	// service -> methods
+	procedures   map[string][]string
+	respoolPaths []string
+	// owners and owning teams
+	owners     map[string]bool
+	ldapGroups map[string]bool
 }
 
 var _ auth.SecurityManager = &SecurityManager{}
@@ -104,6 +118,11 @@ func (m *SecurityManager) RedactToken(token auth.Token) {
 	token.Del(_passwordHeaderKey)
 }
 
+// Name returns the username of the user
+func (u *user) Name() string {
+	return u.username
+}
+
 // IsPermitted returns if a procedure is permitted for user
 func (u *user) IsPermitted(procedure string) bool {
 	// procedure is permitted if it is accepted by
@@ -120,6 +139,64 @@ func (u *user) IsPermitted(procedure string) bool {
 	return false
 }
 
+// IsPermittedOnResource returns if a procedure is permitted
+// for user on the resource
+func (u *user) IsPermittedOnResource(
+	procedure string,
+	resource *auth.Resource,
+) bool {
+	if !u.IsPermitted(procedure) {
+		return false
+	}
+
+	// procedure is permitted on the resource if it is
+	// not limited by any scope, or any of the scopes
+	// limiting it matches the resource
+	results := strings.Split(procedure, _procedureSeparator)
+	service := results[0]
+	method := results[1]
+
+	limited := false
+	for _, s := range u.role.scopes {
+		if !matchRules(service, method, s.procedures) {
+			continue
+		}
+		if s.matchResource(resource) {
+			return true
+		}
+		limited = true
+	}
+
+	return !limited
+}
+
+func (s *scope) matchResource(resource *auth.Resource) bool {
+	if resource == nil {
+		return false
+	}
+
+	if len(resource.RespoolPath) != 0 {
+		for _, p := range s.respoolPaths {
+			if matchRule(resource.RespoolPath, p) {
+				return true
+			}
+		}
+	}
+
+	if (len(resource.Owner) != 0 && s.owners[resource.Owner]) ||
+		(len(resource.OwningTeam) != 0 && s.owners[resource.OwningTeam]) {
+		return true
+	}
+
+	for _, g := range resource.LdapGroups {
+		if s.ldapGroups[g] {
+			return true
+		}
+	}
+
+	return false
+}
+
 func matchRules(service, method string, rules map[string][]string) bool {
 	// _matchAllRule is set, all services and methods are matched
 	if _, ok := rules[_matchAllRule]; ok {
@@ -167,7 +244,7 @@ func newBasicSecurityManager(mConfig *authConfig) (*SecurityManager, error) {
 		return nil, err
 	}
 
-	defaultUser, users, err := constructUsers(mConfig, constructRoles(mConfig))
+	defaultUser, users, err := constructUsers(mConfig, constructRoles(mConfig.Roles))
 	if err != nil {
 		return nil, err
 	}
@@ -187,27 +264,9 @@ func parseConfig(configPath string) (*authConfig, error) {
 }
 
 func validateConfig(config *authConfig) error {
-	roleConfigs := make(map[string]*roleConfig)
-	// check if rules are valid
-	for _, roleConfig := range config.Roles {
-		for _, acceptRule := range roleConfig.Accept {
-			if err := validateRule(acceptRule); err != nil {
-				return err
-			}
-		}
-		for _, rejectRule := range roleConfig.Reject {
-			if err := validateRule(rejectRule); err != nil {
-				return err
-			}
-		}
-
-		if _, ok := roleConfigs[roleConfig.Role]; ok {
-			return yarpcerrors.InvalidArgumentErrorf(
-				"same Role defined more than once. Role:%s",
-				roleConfig.Role,
-			)
-		}
-		roleConfigs[roleConfig.Role] = roleConfig
+	roleConfigs, err := ValidateRoleConfigs(config.Roles)
+	if err != nil {
+		return err
 	}
 
 	var defaultUserCount int
@@ -263,7 +322,7 @@ func validateConfig(config *authConfig) error {
 		return yarpcerrors.InvalidArgumentErrorf("undefined role for internal user")
 	}
 
-	if !isRootRole(internalUserRoleConfig) {
+	if !IsRootRole(internalUserRoleConfig) {
 		return yarpcerrors.InvalidArgumentErrorf(
 			"role for internal user must accept * and reject no method")
 	}
@@ -271,7 +330,43 @@ func validateConfig(config *authConfig) error {
 	return nil
 }
 
-func isRootRole(config *roleConfig) bool {
+// ValidateRoleConfigs checks the accept and reject rules of the roles,
+// and returns the role configs keyed by role name
+func ValidateRoleConfigs(configs []*RoleConfig) (map[string]*RoleConfig, error) {
+	roleConfigs := make(map[string]*RoleConfig)
+	// check if rules are valid
+	for _, roleConfig := range configs {
+		for _, acceptRule := range roleConfig.Accept {
+			if err := validateRule(acceptRule); err != nil {
+				return nil, err
+			}
+		}
+		for _, rejectRule := range roleConfig.Reject {
+			if err := validateRule(rejectRule); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, scopeConfig := range roleConfig.Scopes {
+			if err := validateScope(scopeConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, ok := roleConfigs[roleConfig.Role]; ok {
+			return nil, yarpcerrors.InvalidArgumentErrorf(
+				"same Role defined more than once. Role:%s",
+				roleConfig.Role,
+			)
+		}
+		roleConfigs[roleConfig.Role] = roleConfig
+	}
+	return roleConfigs, nil
+}
+
+// IsRootRole returns whether the role accepts all the
+// procedures and rejects none of them
+func IsRootRole(config *RoleConfig) bool {
 	if !(len(config.Accept) == 1 && config.Accept[0] == _matchAllRule) {
 		return false
 	}
@@ -280,9 +375,47 @@ func isRootRole(config *roleConfig) bool {
 		return false
 	}
 
+	if len(config.Scopes) != 0 {
+		return false
+	}
+
 	return true
 }
 
+// check if the scope is valid
+func validateScope(config *ScopeConfig) error {
+	if len(config.Procedures) == 0 {
+		return yarpcerrors.InvalidArgumentErrorf("no procedure specified for scope")
+	}
+
+	for _, procedure := range config.Procedures {
+		if err := validateRule(procedure); err != nil {
+			return err
+		}
+	}
+
+	if len(config.RespoolPaths) == 0 &&
+		len(config.Owners) == 0 &&
+		len(config.LdapGroups) == 0 {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"no respool path, owner or ldap group specified for scope")
+	}
+
+	for _, path := range config.RespoolPaths {
+		if !strings.HasPrefix(path, _respoolPathSeparator) ||
+			strings.Count(path, _matchAllRule) > 1 ||
+			(strings.Contains(path, _matchAllRule) &&
+				!strings.HasSuffix(path, _matchAllRule)) {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"respool path: %s has unexpected format",
+				path,
+			)
+		}
+	}
+
+	return nil
+}
+
 // check if the rule is valid,
 func validateRule(rule string) error {
 	error := yarpcerrors.InvalidArgumentErrorf(
@@ -347,9 +480,21 @@ func isValidMethod(method string) bool {
 	return true
 }
 
-func constructRoles(mConfig *authConfig) map[string]*role {
+// NewRoleUsers returns an anonymous user for each of the roles, keyed by
+// role name. It is used by auth types which authenticate the identity
+// elsewhere and only need the role rules for authorization.
+// The role configs are expected to be validated by ValidateRoleConfigs.
+func NewRoleUsers(configs []*RoleConfig) map[string]auth.User {
+	result := make(map[string]auth.User)
+	for name, role := range constructRoles(configs) {
+		result[name] = &user{role: role}
+	}
+	return result
+}
+
+func constructRoles(configs []*RoleConfig) map[string]*role {
 	result := make(map[string]*role)
-	for _, roleConfig := range mConfig.Roles {
+	for _, roleConfig := range configs {
 		accepts := make(map[string][]string)
 		rejects := make(map[string][]string)
 
@@ -372,16 +517,51 @@ func constructRoles(mConfig *authConfig) map[string]*role {
 
 		}
 
+		var scopes []*scope
+		for _, scopeConfig := range roleConfig.Scopes {
+			scopes = append(scopes, constructScope(scopeConfig))
+		}
+
 		result[roleConfig.Role] = &role{
 			role:    roleConfig.Role,
 			accepts: accepts,
 			rejects: rejects,
+			scopes:  scopes,
 		}
 	}
 
 	return result
 }
 
+func constructScope(config *ScopeConfig) *scope {
+	procedures := make(map[string][]string)
+	for _, procedure := range config.Procedures {
+		if procedure == _matchAllRule {
+			procedures[_matchAllRule] = []string{_matchAllRule}
+			continue
+		}
+		results := strings.Split(procedure, _ruleSeparator)
+		procedures[results[0]] = append(procedures[results[0]], results[1])
+	}
+
+	owners := make(map[string]bool)
+	for _, owner := range config.Owners {
+		owners[owner] = true
+	}
+
+	ldapGroups := make(map[string]bool)
+	for _, group := range config.LdapGroups {
+		ldapGroups[group] = true
+	}
+
+	return &scope{
+		procedures:   procedures,
+		respoolPaths: config.RespoolPaths,
+		owners:       owners,
+		ldapGroups:   ldapGroups,
+	}
+}
+
 func constructUsers(mConfig *authConfig, roles map[string]*role) (
 	defaultUser *user,
 	users map[string]*user,
@@ -17,6 +17,8 @@ package basic
 import (
 	"testing"
 
+	"github.com/uber/peloton/pkg/auth"
+
 	"github.com/stretchr/testify/suite"
 )
 
@@ -35,11 +37,11 @@ func (suite *SecurityManagerTestSuite) SetupTest() {
 }
 
 func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerSuccess() {
-	role1 := &roleConfig{
+	role1 := &RoleConfig{
 		Role:   "admin",
 		Accept: []string{_matchAllRule},
 	}
-	role2 := &roleConfig{
+	role2 := &RoleConfig{
 		Role: "default",
 	}
 
@@ -59,7 +61,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerSuccess() {
 
 	config := &authConfig{
 		Users:        []*userConfig{user1, user2, user3},
-		Roles:        []*roleConfig{role1, role2},
+		Roles:        []*RoleConfig{role1, role2},
 		InternalUser: user1.Username,
 	}
 
@@ -69,7 +71,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerSuccess() {
 }
 
 func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerMultiDefaultUserErr() {
-	role1 := &roleConfig{
+	role1 := &RoleConfig{
 		Role: "default",
 	}
 
@@ -82,7 +84,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerMultiDefaul
 
 	config := &authConfig{
 		Users: []*userConfig{user1, user2},
-		Roles: []*roleConfig{role1},
+		Roles: []*RoleConfig{role1},
 	}
 
 	m, err := newBasicSecurityManager(config)
@@ -91,10 +93,10 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerMultiDefaul
 }
 
 func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerDuplicatedRolesErr() {
-	role1 := &roleConfig{
+	role1 := &RoleConfig{
 		Role: "admin",
 	}
-	role2 := &roleConfig{
+	role2 := &RoleConfig{
 		Role: "admin",
 	}
 
@@ -109,7 +111,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerDuplicatedR
 
 	config := &authConfig{
 		Users: []*userConfig{user1, user2},
-		Roles: []*roleConfig{role1, role2},
+		Roles: []*RoleConfig{role1, role2},
 	}
 
 	m, err := newBasicSecurityManager(config)
@@ -118,7 +120,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerDuplicatedR
 }
 
 func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerDuplicatedUsersErr() {
-	role1 := &roleConfig{
+	role1 := &RoleConfig{
 		Role: "admin",
 	}
 
@@ -135,7 +137,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerDuplicatedU
 
 	config := &authConfig{
 		Users: []*userConfig{user1, user2},
-		Roles: []*roleConfig{role1},
+		Roles: []*RoleConfig{role1},
 	}
 
 	m, err := newBasicSecurityManager(config)
@@ -144,7 +146,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerDuplicatedU
 }
 
 func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerUndefinedRoleErr() {
-	role1 := &roleConfig{
+	role1 := &RoleConfig{
 		Role: "admin",
 	}
 
@@ -161,7 +163,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerUndefinedRo
 
 	config := &authConfig{
 		Users: []*userConfig{user1, user2},
-		Roles: []*roleConfig{role1},
+		Roles: []*RoleConfig{role1},
 	}
 
 	m, err := newBasicSecurityManager(config)
@@ -170,7 +172,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerUndefinedRo
 }
 
 func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerMissingUserInfoErr() {
-	role := &roleConfig{
+	role := &RoleConfig{
 		Role: "admin",
 	}
 
@@ -181,7 +183,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerMissingUser
 	}
 	config := &authConfig{
 		Users: []*userConfig{user},
-		Roles: []*roleConfig{role},
+		Roles: []*RoleConfig{role},
 	}
 
 	m, err := newBasicSecurityManager(config)
@@ -195,7 +197,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerMissingUser
 	}
 	config = &authConfig{
 		Users: []*userConfig{user},
-		Roles: []*roleConfig{role},
+		Roles: []*RoleConfig{role},
 	}
 
 	m, err = newBasicSecurityManager(config)
@@ -209,7 +211,7 @@ func (suite *SecurityManagerTestSuite) TestCreateBasicSecurityManagerMissingUser
 	}
 	config = &authConfig{
 		Users: []*userConfig{user},
-		Roles: []*roleConfig{role},
+		Roles: []*RoleConfig{role},
 	}
 
 	m, err = newBasicSecurityManager(config)
@@ -259,12 +261,13 @@ func (suite *SecurityManagerTestSuite) TestAuthenticateUser() {
 		} else {
 			suite.NotNil(u)
 			suite.NoError(err)
+			suite.Equal(test.username, u.Name())
 		}
 	}
 }
 
 func (suite *SecurityManagerTestSuite) TestAuthenticateDefaultUserWhenNonDefinedErr() {
-	role1 := &roleConfig{
+	role1 := &RoleConfig{
 		Role:   "admin",
 		Accept: []string{_matchAllRule},
 	}
@@ -282,7 +285,7 @@ func (suite *SecurityManagerTestSuite) TestAuthenticateDefaultUserWhenNonDefined
 
 	config := &authConfig{
 		Users:        []*userConfig{user1, user2},
-		Roles:        []*roleConfig{role1},
+		Roles:        []*RoleConfig{role1},
 		InternalUser: user1.Username,
 	}
 
@@ -346,6 +349,139 @@ func (suite *SecurityManagerTestSuite) TestNormalUserPermission() {
 	}
 }
 
+func (suite *SecurityManagerTestSuite) TestScopedUserPermission() {
+	const (
+		createJob = "peloton.api.v1alpha.job.stateless.svc.JobService::CreateJob"
+		stopJob   = "peloton.api.v1alpha.job.stateless.svc.JobService::StopJob"
+		getJob    = "peloton.api.v1alpha.job.stateless.svc.JobService::GetJob"
+		stopPod   = "peloton.api.v1alpha.pod.svc.PodService::StopPod"
+		getVolume = "peloton.api.v1alpha.job.volume.svc.VolumeService::GetVolume"
+	)
+
+	infraPool := &auth.Resource{RespoolPath: "/infra/compute"}
+	infraTeam := &auth.Resource{RespoolPath: "/shared", OwningTeam: "infra-team"}
+	infraOwner := &auth.Resource{RespoolPath: "/shared", Owner: "infra-team"}
+	oncall := &auth.Resource{RespoolPath: "/shared", LdapGroups: []string{"web", "infra-oncall"}}
+	other := &auth.Resource{RespoolPath: "/web", OwningTeam: "web", LdapGroups: []string{"web"}}
+
+	tests := []struct {
+		procedureName string
+		resource      *auth.Resource
+		isPermitted   bool
+	}{
+		{procedureName: createJob, resource: infraPool, isPermitted: true},
+		{procedureName: createJob, resource: infraTeam, isPermitted: true},
+		{procedureName: createJob, resource: infraOwner, isPermitted: true},
+		{procedureName: createJob, resource: oncall, isPermitted: false},
+		{procedureName: createJob, resource: other, isPermitted: false},
+		{procedureName: createJob, resource: &auth.Resource{RespoolPath: "/infra"}, isPermitted: false},
+		{procedureName: createJob, resource: nil, isPermitted: false},
+		// limited by both scopes
+		{procedureName: stopJob, resource: infraPool, isPermitted: true},
+		{procedureName: stopJob, resource: oncall, isPermitted: true},
+		{procedureName: stopJob, resource: other, isPermitted: false},
+		{procedureName: stopPod, resource: oncall, isPermitted: true},
+		{procedureName: stopPod, resource: infraPool, isPermitted: false},
+		// not limited by any scope
+		{procedureName: getJob, resource: other, isPermitted: true},
+		{procedureName: getJob, resource: nil, isPermitted: true},
+		// not accepted by the role
+		{procedureName: getVolume, resource: infraPool, isPermitted: false},
+	}
+
+	u, err := suite.m.Authenticate(
+		&testToken{username: "user3", password: "password3"},
+	)
+	suite.NoError(err)
+
+	for _, test := range tests {
+		suite.Equal(
+			test.isPermitted,
+			u.IsPermittedOnResource(test.procedureName, test.resource),
+			"%s on %s", test.procedureName, test.resource,
+		)
+	}
+
+	// users without scopes are permitted on all resources
+	u, err = suite.m.Authenticate(
+		&testToken{username: "user2", password: "password2"},
+	)
+	suite.NoError(err)
+	suite.True(u.IsPermittedOnResource(createJob, other))
+}
+
+func (suite *SecurityManagerTestSuite) TestValidateScope() {
+	const procedure = "peloton.api.v1alpha.job.stateless.svc.JobService:Stop*"
+
+	tests := []struct {
+		scope     *ScopeConfig
+		expectErr bool
+	}{
+		{
+			scope: &ScopeConfig{
+				Procedures:   []string{procedure},
+				RespoolPaths: []string{"/infra/*", "/web"},
+			},
+			expectErr: false,
+		},
+		{
+			scope: &ScopeConfig{
+				Procedures: []string{procedure},
+				LdapGroups: []string{"infra"},
+			},
+			expectErr: false,
+		},
+		{
+			scope: &ScopeConfig{
+				Owners: []string{"infra"},
+			},
+			expectErr: true,
+		},
+		{
+			scope: &ScopeConfig{
+				Procedures: []string{procedure},
+			},
+			expectErr: true,
+		},
+		{
+			scope: &ScopeConfig{
+				Procedures: []string{"JobService"},
+				Owners:     []string{"infra"},
+			},
+			expectErr: true,
+		},
+		{
+			scope: &ScopeConfig{
+				Procedures:   []string{procedure},
+				RespoolPaths: []string{"infra/*"},
+			},
+			expectErr: true,
+		},
+		{
+			scope: &ScopeConfig{
+				Procedures:   []string{procedure},
+				RespoolPaths: []string{"/infra/*/compute"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		if test.expectErr {
+			suite.Error(validateScope(test.scope))
+		} else {
+			suite.NoError(validateScope(test.scope))
+		}
+	}
+
+	// a role with scopes cannot be the root role
+	suite.False(IsRootRole(&RoleConfig{
+		Role:   "root",
+		Accept: []string{_matchAllRule},
+		Scopes: []*ScopeConfig{tests[0].scope},
+	}))
+}
+
 func (suite *SecurityManagerTestSuite) TestValidateRule() {
 	tests := []struct {
 		rule      string
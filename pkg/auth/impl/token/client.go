@@ -0,0 +1,147 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"sync"
+	"time"
+
+	"github.com/uber/peloton/pkg/auth"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SecurityClient returns token which authenticates internal
+// communication when token auth is enabled. The token is
+// signed for the internal subject and is renewed before it expires.
+type SecurityClient struct {
+	sync.Mutex
+
+	keys     *keys
+	subject  string
+	role     string
+	issuer   string
+	audience string
+	ttl      time.Duration
+
+	token     *bearerToken
+	refreshAt time.Time
+
+	now func() time.Time
+}
+
+// GetToken returns a token for token auth
+func (c *SecurityClient) GetToken() auth.Token {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.now().Before(c.refreshAt) {
+		return c.token
+	}
+
+	if err := c.refresh(); err != nil {
+		// keep the previous token, the receiver would reject it
+		// once it expires
+		log.WithError(err).Error("failed to sign internal token")
+	}
+	return c.token
+}
+
+// refresh signs a new token, the token is renewed once half of its
+// lifetime has passed, so that it is still valid when it arrives
+// at the receiver
+func (c *SecurityClient) refresh() error {
+	now := c.now()
+	signed, err := c.keys.encode(&claims{
+		Subject:   c.subject,
+		Role:      c.role,
+		Issuer:    c.issuer,
+		Audience:  c.audience,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(c.ttl).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	c.token = &bearerToken{
+		items: map[string]string{
+			_authorizationHeaderKey: _bearerPrefix + signed,
+		},
+	}
+	c.refreshAt = now.Add(c.ttl / 2)
+	return nil
+}
+
+type bearerToken struct {
+	items map[string]string
+}
+
+func (t *bearerToken) Get(k string) (string, bool) {
+	result, ok := t.items[k]
+	return result, ok
+}
+
+func (t *bearerToken) Items() map[string]string {
+	return t.items
+}
+
+func (t *bearerToken) Del(k string) {
+	delete(t.items, k)
+}
+
+// NewTokenSecurityClient returns SecurityClient
+func NewTokenSecurityClient(configPath string) (*SecurityClient, error) {
+	cConfig, err := parseConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenSecurityClient(cConfig)
+}
+
+// helper method to create SecurityClient which makes test easier
+func newTokenSecurityClient(cConfig *authConfig) (*SecurityClient, error) {
+	if err := validateConfig(cConfig); err != nil {
+		return nil, err
+	}
+
+	k, err := newKeys(cConfig, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := cConfig.TTL
+	if ttl == 0 {
+		ttl = _defaultTTL
+	}
+
+	c := &SecurityClient{
+		keys:     k,
+		subject:  cConfig.InternalSubject,
+		role:     cConfig.InternalRole,
+		issuer:   cConfig.Issuer,
+		audience: cConfig.Audience,
+		ttl:      ttl,
+		now:      time.Now,
+	}
+
+	// sign the first token eagerly, so that a bad key
+	// fails the creation of the client
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SecurityClientTestSuite struct {
+	suite.Suite
+
+	now time.Time
+	c   *SecurityClient
+	m   *SecurityManager
+}
+
+func (suite *SecurityClientTestSuite) SetupTest() {
+	suite.now = time.Unix(1500000000, 0)
+	now := func() time.Time { return suite.now }
+
+	c, err := NewTokenSecurityClient(_testConfigPath)
+	suite.NoError(err)
+	c.now = now
+	// drop the token signed on creation with the real clock
+	c.refreshAt = time.Time{}
+	suite.c = c
+
+	m, err := NewTokenSecurityManager(_testConfigPath)
+	suite.NoError(err)
+	m.now = now
+	suite.m = m
+}
+
+func (suite *SecurityClientTestSuite) TestTokenSecurityClientGetToken() {
+	t := suite.c.GetToken()
+	suite.Len(t.Items(), 1)
+
+	u, err := suite.m.Authenticate(t)
+	suite.NoError(err)
+	suite.True(u.IsPermitted("peloton.api.v1alpha.pod.svc.PodService::GetPodCache"))
+}
+
+func (suite *SecurityClientTestSuite) TestTokenSecurityClientRefreshToken() {
+	t1 := suite.c.GetToken()
+
+	// token is reused within the first half of its lifetime
+	suite.now = suite.now.Add(4 * time.Minute)
+	suite.Equal(t1, suite.c.GetToken())
+
+	suite.now = suite.now.Add(2 * time.Minute)
+	t2 := suite.c.GetToken()
+	suite.NotEqual(t1, t2)
+
+	// the old token expires while the new one is still valid
+	suite.now = suite.now.Add(6 * time.Minute)
+	_, err := suite.m.Authenticate(t1)
+	suite.Error(err)
+	_, err = suite.m.Authenticate(t2)
+	suite.NoError(err)
+}
+
+func (suite *SecurityClientTestSuite) TestCreateTokenSecurityClientInvalidRoleFailure() {
+	config, err := parseConfig(_testConfigPath)
+	suite.NoError(err)
+
+	// change role to one which has no root privilege
+	config.InternalRole = "role1"
+
+	c, err := newTokenSecurityClient(config)
+	suite.Nil(c)
+	suite.Error(err)
+}
+
+func TestSecurityClientTestSuite(t *testing.T) {
+	suite.Run(t, new(SecurityClientTestSuite))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"time"
+
+	"github.com/uber/peloton/pkg/auth/impl/basic"
+)
+
+type authConfig struct {
+	// Algorithm used to sign the tokens, HS256 or RS256
+	Algorithm string
+	// Secret is the shared key used to sign and verify HS256 tokens
+	Secret string
+	// PublicKeyFile is the path to the PEM encoded RSA public key
+	// used to verify RS256 tokens
+	PublicKeyFile string `yaml:"public_key_file"`
+	// PrivateKeyFile is the path to the PEM encoded RSA private key
+	// used by peloton components to sign RS256 tokens
+	PrivateKeyFile string `yaml:"private_key_file"`
+	// Issuer is the expected iss claim, not checked if empty
+	Issuer string
+	// Audience is the expected aud claim, not checked if empty
+	Audience string
+	// TTL is the lifetime of the tokens signed for internal communication
+	TTL time.Duration `yaml:"ttl"`
+	// ClockSkew is the leeway allowed when checking the token lifetime
+	ClockSkew time.Duration `yaml:"clock_skew"`
+	// DefaultRole is the role of the callers which do not present a token,
+	// such callers are rejected if it is not set
+	DefaultRole string `yaml:"default_role"`
+	Roles       []*basic.RoleConfig
+	// InternalSubject is the sub claim of the tokens signed
+	// for internal communication
+	InternalSubject string `yaml:"internal_subject"`
+	// InternalRole is the role claim of the tokens signed
+	// for internal communication
+	InternalRole string `yaml:"internal_role"`
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_algHS256 = "HS256"
+	_algRS256 = "RS256"
+
+	_tokenType     = "JWT"
+	_partSeparator = "."
+)
+
+var (
+	errMalformedToken    = errors.New("malformed token")
+	errInvalidSignature  = errors.New("invalid token signature")
+	errMissingExpiration = errors.New("token has no expiration")
+	errTokenExpired      = errors.New("token is expired")
+	errTokenNotValidYet  = errors.New("token is not valid yet")
+	errInvalidIssuer     = errors.New("token has unexpected issuer")
+	errInvalidAudience   = errors.New("token has unexpected audience")
+)
+
+var _encoding = base64.RawURLEncoding
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// claims carried by a token
+type claims struct {
+	Subject   string `json:"sub"`
+	Role      string `json:"role"`
+	Issuer    string `json:"iss,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// keys signs and verifies tokens with a single algorithm,
+// all fields are immutable after init
+type keys struct {
+	algorithm  string
+	secret     []byte
+	publicKey  *rsa.PublicKey
+	privateKey *rsa.PrivateKey
+}
+
+// encode signs the claims and returns the compact serialized token
+func (k *keys) encode(c *claims) (string, error) {
+	h, err := json.Marshal(&header{Algorithm: k.algorithm, Type: _tokenType})
+	if err != nil {
+		return "", err
+	}
+	p, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := _encoding.EncodeToString(h) +
+		_partSeparator +
+		_encoding.EncodeToString(p)
+	sig, err := k.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + _partSeparator + _encoding.EncodeToString(sig), nil
+}
+
+// decode verifies the signature of the token and returns its claims.
+// The lifetime and the issuer of the claims are not checked.
+func (k *keys) decode(token string) (*claims, error) {
+	parts := strings.Split(token, _partSeparator)
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+
+	h := &header{}
+	if err := decodePart(parts[0], h); err != nil {
+		return nil, err
+	}
+	// only accept the configured algorithm, so that a token cannot
+	// pick a weaker one such as "none" or HS256 keyed by the public key
+	if h.Algorithm != k.algorithm {
+		return nil, fmt.Errorf("unexpected signing algorithm %q", h.Algorithm)
+	}
+
+	sig, err := _encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	if err := k.verify([]byte(parts[0]+_partSeparator+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	c := &claims{}
+	if err := decodePart(parts[1], c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (k *keys) sign(signingInput []byte) ([]byte, error) {
+	switch k.algorithm {
+	case _algHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(signingInput)
+		return mac.Sum(nil), nil
+	case _algRS256:
+		if k.privateKey == nil {
+			return nil, errors.New("no private key to sign token")
+		}
+		digest := sha256.Sum256(signingInput)
+		return rsa.SignPKCS1v15(rand.Reader, k.privateKey, crypto.SHA256, digest[:])
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", k.algorithm)
+	}
+}
+
+func (k *keys) verify(signingInput []byte, sig []byte) error {
+	switch k.algorithm {
+	case _algHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(signingInput)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errInvalidSignature
+		}
+		return nil
+	case _algRS256:
+		digest := sha256.Sum256(signingInput)
+		if err := rsa.VerifyPKCS1v15(
+			k.publicKey, crypto.SHA256, digest[:], sig); err != nil {
+			return errInvalidSignature
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", k.algorithm)
+	}
+}
+
+// validate checks the lifetime, issuer and audience of the claims
+func (c *claims) validate(
+	now time.Time,
+	clockSkew time.Duration,
+	issuer string,
+	audience string,
+) error {
+	if c.ExpiresAt == 0 {
+		return errMissingExpiration
+	}
+	if now.Add(-clockSkew).After(time.Unix(c.ExpiresAt, 0)) {
+		return errTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(c.NotBefore, 0)) {
+		return errTokenNotValidYet
+	}
+	if len(issuer) != 0 && c.Issuer != issuer {
+		return errInvalidIssuer
+	}
+	if len(audience) != 0 && c.Audience != audience {
+		return errInvalidAudience
+	}
+	return nil
+}
+
+func decodePart(part string, v interface{}) error {
+	b, err := _encoding.DecodeString(part)
+	if err != nil {
+		return errMalformedToken
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return errMalformedToken
+	}
+	return nil
+}
+
+// newKeys creates the keys for the configured algorithm, the private key
+// is only loaded if withPrivateKey is set
+func newKeys(config *authConfig, withPrivateKey bool) (*keys, error) {
+	k := &keys{algorithm: config.Algorithm}
+
+	switch config.Algorithm {
+	case _algHS256:
+		if len(config.Secret) == 0 {
+			return nil, yarpcerrors.InvalidArgumentErrorf("no secret specified for HS256")
+		}
+		k.secret = []byte(config.Secret)
+	case _algRS256:
+		if withPrivateKey {
+			privateKey, err := loadPrivateKey(config.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			k.privateKey = privateKey
+			k.publicKey = &privateKey.PublicKey
+		}
+		if len(config.PublicKeyFile) != 0 {
+			publicKey, err := loadPublicKey(config.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			k.publicKey = publicKey
+		}
+		if k.publicKey == nil {
+			return nil, yarpcerrors.InvalidArgumentErrorf("no public key specified for RS256")
+		}
+	default:
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"unsupported signing algorithm %q", config.Algorithm)
+	}
+
+	return k, nil
+}
+
+func loadPEMBlock(path string) (*pem.Block, error) {
+	if len(path) == 0 {
+		return nil, yarpcerrors.InvalidArgumentErrorf("no key file specified")
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, yarpcerrors.InvalidArgumentErrorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
+
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := loadPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, yarpcerrors.InvalidArgumentErrorf("%s is not a RSA public key", path)
+	}
+	return rsaKey, nil
+}
+
+func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := loadPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, yarpcerrors.InvalidArgumentErrorf("%s is not a RSA private key", path)
+	}
+	return rsaKey, nil
+}
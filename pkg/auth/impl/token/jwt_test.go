@@ -0,0 +1,135 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type KeysTestSuite struct {
+	suite.Suite
+
+	dir            string
+	privateKeyFile string
+	publicKeyFile  string
+}
+
+func (suite *KeysTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "token_keys")
+	suite.NoError(err)
+	suite.dir = dir
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	suite.NoError(err)
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	suite.NoError(err)
+
+	suite.privateKeyFile = filepath.Join(dir, "private.pem")
+	suite.writePEM(suite.privateKeyFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	suite.publicKeyFile = filepath.Join(dir, "public.pem")
+	suite.writePEM(suite.publicKeyFile, "PUBLIC KEY", publicKey)
+}
+
+func (suite *KeysTestSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *KeysTestSuite) writePEM(path string, blockType string, data []byte) {
+	suite.NoError(ioutil.WriteFile(
+		path,
+		pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}),
+		0600,
+	))
+}
+
+func (suite *KeysTestSuite) TestRS256EncodeDecode() {
+	signer, err := newKeys(&authConfig{
+		Algorithm:      _algRS256,
+		PrivateKeyFile: suite.privateKeyFile,
+	}, true)
+	suite.NoError(err)
+
+	verifier, err := newKeys(&authConfig{
+		Algorithm:     _algRS256,
+		PublicKeyFile: suite.publicKeyFile,
+	}, false)
+	suite.NoError(err)
+
+	c := &claims{
+		Subject:   "user",
+		Role:      "role1",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	}
+	signed, err := signer.encode(c)
+	suite.NoError(err)
+
+	decoded, err := verifier.decode(signed)
+	suite.NoError(err)
+	suite.Equal(c, decoded)
+
+	// verifier has no private key to sign
+	_, err = verifier.encode(c)
+	suite.Error(err)
+}
+
+func (suite *KeysTestSuite) TestDecodeAlgorithmMismatch() {
+	verifier, err := newKeys(&authConfig{
+		Algorithm:     _algRS256,
+		PublicKeyFile: suite.publicKeyFile,
+	}, false)
+	suite.NoError(err)
+
+	// a HS256 token keyed by the public key must not be accepted
+	publicKey, err := ioutil.ReadFile(suite.publicKeyFile)
+	suite.NoError(err)
+	forger := &keys{algorithm: _algHS256, secret: publicKey}
+	signed, err := forger.encode(&claims{
+		Role:      "role1",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	})
+	suite.NoError(err)
+
+	_, err = verifier.decode(signed)
+	suite.Error(err)
+}
+
+func (suite *KeysTestSuite) TestNewKeysFailure() {
+	_, err := newKeys(&authConfig{
+		Algorithm:     _algRS256,
+		PublicKeyFile: filepath.Join(suite.dir, "missing.pem"),
+	}, false)
+	suite.Error(err)
+
+	// a public key cannot be used as private key
+	_, err = newKeys(&authConfig{
+		Algorithm:      _algRS256,
+		PrivateKeyFile: suite.publicKeyFile,
+	}, true)
+	suite.Error(err)
+}
+
+func TestKeysTestSuite(t *testing.T) {
+	suite.Run(t, new(KeysTestSuite))
+}
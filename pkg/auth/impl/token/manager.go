@@ -0,0 +1,191 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"strings"
+	"time"
+
+	"github.com/uber/peloton/pkg/auth"
+	"github.com/uber/peloton/pkg/auth/impl/basic"
+	"github.com/uber/peloton/pkg/common/config"
+
+	log "github.com/sirupsen/logrus"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	// expected field passed by token, the value is
+	// the signed token prefixed by _bearerPrefix
+	_authorizationHeaderKey = "authorization"
+	_bearerPrefix           = "Bearer "
+
+	// _defaultTTL is used for internal tokens if no ttl is configured
+	_defaultTTL = 10 * time.Minute
+)
+
+// SecurityManager uses signed bearer tokens for auth,
+// the role claim of a token is mapped to the configured roles
+type SecurityManager struct {
+	keys      *keys
+	issuer    string
+	audience  string
+	clockSkew time.Duration
+
+	defaultUser auth.User
+	// role name -> user
+	users map[string]auth.User
+
+	now func() time.Time
+}
+
+var _ auth.SecurityManager = &SecurityManager{}
+
+// tokenUser is the user authenticated by a token, the permissions
+// come from the role claim and the name from the subject claim
+type tokenUser struct {
+	auth.User
+	subject string
+}
+
+// Name returns the subject of the token
+func (u *tokenUser) Name() string {
+	return u.subject
+}
+
+// Authenticate authenticates a user,
+// it expects a bearer token signed by the configured key
+func (m *SecurityManager) Authenticate(token auth.Token) (auth.User, error) {
+	authErr := yarpcerrors.UnauthenticatedErrorf("invalid or expired token")
+
+	value, _ := token.Get(_authorizationHeaderKey)
+
+	// no token provided, return default user
+	if len(value) == 0 {
+		if m.defaultUser == nil {
+			return nil, authErr
+		}
+		return m.defaultUser, nil
+	}
+
+	if !strings.HasPrefix(value, _bearerPrefix) {
+		return nil, authErr
+	}
+
+	c, err := m.keys.decode(strings.TrimPrefix(value, _bearerPrefix))
+	if err == nil {
+		err = c.validate(m.now(), m.clockSkew, m.issuer, m.audience)
+	}
+	if err != nil {
+		log.WithError(err).Debug("failed to validate token")
+		return nil, authErr
+	}
+
+	user, ok := m.users[c.Role]
+	if !ok {
+		log.WithFields(log.Fields{
+			"subject": c.Subject,
+			"role":    c.Role,
+		}).Debug("token has undefined role")
+		return nil, authErr
+	}
+
+	return &tokenUser{User: user, subject: c.Subject}, nil
+}
+
+// RedactToken removes the signed token from the token
+func (m *SecurityManager) RedactToken(token auth.Token) {
+	token.Del(_authorizationHeaderKey)
+}
+
+// NewTokenSecurityManager returns SecurityManager
+func NewTokenSecurityManager(configPath string) (*SecurityManager, error) {
+	mConfig, err := parseConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTokenSecurityManager(mConfig)
+}
+
+// helper method to create SecurityManager which makes test easier
+func newTokenSecurityManager(mConfig *authConfig) (*SecurityManager, error) {
+	if err := validateConfig(mConfig); err != nil {
+		return nil, err
+	}
+
+	k, err := newKeys(mConfig, false)
+	if err != nil {
+		return nil, err
+	}
+
+	users := basic.NewRoleUsers(mConfig.Roles)
+
+	return &SecurityManager{
+		keys:        k,
+		issuer:      mConfig.Issuer,
+		audience:    mConfig.Audience,
+		clockSkew:   mConfig.ClockSkew,
+		defaultUser: users[mConfig.DefaultRole],
+		users:       users,
+		now:         time.Now,
+	}, nil
+}
+
+func parseConfig(configPath string) (*authConfig, error) {
+	mConfig := &authConfig{}
+	if err := config.Parse(mConfig, configPath); err != nil {
+		return nil, err
+	}
+	return mConfig, nil
+}
+
+func validateConfig(config *authConfig) error {
+	roleConfigs, err := basic.ValidateRoleConfigs(config.Roles)
+	if err != nil {
+		return err
+	}
+
+	if len(config.DefaultRole) != 0 {
+		if _, ok := roleConfigs[config.DefaultRole]; !ok {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"undefined default role: %s",
+				config.DefaultRole,
+			)
+		}
+	}
+
+	if config.TTL < 0 || config.ClockSkew < 0 {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"ttl and clock_skew cannot be negative")
+	}
+
+	// validate internal user configs
+	if len(config.InternalSubject) == 0 {
+		return yarpcerrors.InvalidArgumentErrorf("undefined internal subject")
+	}
+
+	internalRoleConfig, ok := roleConfigs[config.InternalRole]
+	if !ok {
+		return yarpcerrors.InvalidArgumentErrorf("undefined role for internal subject")
+	}
+
+	if !basic.IsRootRole(internalRoleConfig) {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"role for internal subject must accept * and reject no method")
+	}
+
+	return nil
+}
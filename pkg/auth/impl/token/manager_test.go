@@ -0,0 +1,260 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uber/peloton/pkg/auth/impl/basic"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const _testConfigPath = "testdata/test_token_auth_config.yaml"
+
+type SecurityManagerTestSuite struct {
+	suite.Suite
+
+	now    time.Time
+	config *authConfig
+	keys   *keys
+	m      *SecurityManager
+}
+
+func (suite *SecurityManagerTestSuite) SetupTest() {
+	m, err := NewTokenSecurityManager(_testConfigPath)
+	suite.NoError(err)
+	suite.now = time.Unix(1500000000, 0)
+	m.now = func() time.Time { return suite.now }
+	suite.m = m
+
+	suite.config, err = parseConfig(_testConfigPath)
+	suite.NoError(err)
+	suite.keys, err = newKeys(suite.config, false)
+	suite.NoError(err)
+}
+
+// newToken returns a bearer token for role which expires after ttl
+func (suite *SecurityManagerTestSuite) newToken(role string, ttl time.Duration) *bearerToken {
+	return suite.newTokenWithClaims(&claims{
+		Subject:   "user",
+		Role:      role,
+		Issuer:    suite.config.Issuer,
+		IssuedAt:  suite.now.Unix(),
+		ExpiresAt: suite.now.Add(ttl).Unix(),
+	})
+}
+
+func (suite *SecurityManagerTestSuite) newTokenWithClaims(c *claims) *bearerToken {
+	signed, err := suite.keys.encode(c)
+	suite.NoError(err)
+	return &bearerToken{
+		items: map[string]string{
+			_authorizationHeaderKey: _bearerPrefix + signed,
+		},
+	}
+}
+
+func (suite *SecurityManagerTestSuite) TestAuthenticateSuccess() {
+	u, err := suite.m.Authenticate(suite.newToken("role1", time.Minute))
+	suite.NoError(err)
+	suite.Equal("user", u.Name())
+	suite.True(u.IsPermitted("peloton.api.v1alpha.job.stateless.svc.JobService::GetJob"))
+	suite.True(u.IsPermitted("peloton.api.v1alpha.pod.svc.PodService::GetPod"))
+	suite.False(u.IsPermitted("peloton.api.v1alpha.job.stateless.svc.JobService::GetJobCache"))
+	suite.False(u.IsPermitted("peloton.api.v1alpha.job.stateless.svc.JobService::CreateJob"))
+
+	u, err = suite.m.Authenticate(suite.newToken("role2", time.Minute))
+	suite.NoError(err)
+	suite.True(u.IsPermitted("peloton.api.v1alpha.job.stateless.svc.JobService::GetJobCache"))
+}
+
+func (suite *SecurityManagerTestSuite) TestAuthenticateDefaultUser() {
+	u, err := suite.m.Authenticate(&bearerToken{items: map[string]string{}})
+	suite.NoError(err)
+	suite.Empty(u.Name())
+	suite.True(u.IsPermitted("peloton.api.v1alpha.job.stateless.svc.JobService::CreateJob"))
+	suite.False(u.IsPermitted("peloton.api.v1alpha.pod.svc.PodService::GetPod"))
+
+	suite.m.defaultUser = nil
+	u, err = suite.m.Authenticate(&bearerToken{items: map[string]string{}})
+	suite.Nil(u)
+	suite.Error(err)
+}
+
+func (suite *SecurityManagerTestSuite) TestAuthenticateWithClockSkew() {
+	// expired, but within the allowed clock skew
+	u, err := suite.m.Authenticate(suite.newToken("role1", -10*time.Second))
+	suite.NoError(err)
+	suite.NotNil(u)
+
+	// not valid yet, but within the allowed clock skew
+	u, err = suite.m.Authenticate(suite.newTokenWithClaims(&claims{
+		Role:      "role1",
+		Issuer:    suite.config.Issuer,
+		NotBefore: suite.now.Add(10 * time.Second).Unix(),
+		ExpiresAt: suite.now.Add(time.Minute).Unix(),
+	}))
+	suite.NoError(err)
+	suite.NotNil(u)
+}
+
+func (suite *SecurityManagerTestSuite) TestAuthenticateFailure() {
+	otherKeys := &keys{algorithm: _algHS256, secret: []byte("other-secret")}
+	otherSigned, err := otherKeys.encode(&claims{
+		Role:      "role2",
+		Issuer:    suite.config.Issuer,
+		ExpiresAt: suite.now.Add(time.Minute).Unix(),
+	})
+	suite.NoError(err)
+
+	valid := suite.newToken("role2", time.Minute).items[_authorizationHeaderKey]
+
+	tests := []struct {
+		msg   string
+		token *bearerToken
+	}{
+		{
+			msg:   "expired token",
+			token: suite.newToken("role2", -time.Minute),
+		},
+		{
+			msg: "token not valid yet",
+			token: suite.newTokenWithClaims(&claims{
+				Role:      "role2",
+				Issuer:    suite.config.Issuer,
+				NotBefore: suite.now.Add(time.Minute).Unix(),
+				ExpiresAt: suite.now.Add(2 * time.Minute).Unix(),
+			}),
+		},
+		{
+			msg: "token without expiration",
+			token: suite.newTokenWithClaims(&claims{
+				Role:   "role2",
+				Issuer: suite.config.Issuer,
+			}),
+		},
+		{
+			msg: "token with unexpected issuer",
+			token: suite.newTokenWithClaims(&claims{
+				Role:      "role2",
+				Issuer:    "other",
+				ExpiresAt: suite.now.Add(time.Minute).Unix(),
+			}),
+		},
+		{
+			msg:   "token with undefined role",
+			token: suite.newToken("role4", time.Minute),
+		},
+		{
+			msg: "token signed by other key",
+			token: &bearerToken{items: map[string]string{
+				_authorizationHeaderKey: _bearerPrefix + otherSigned,
+			}},
+		},
+		{
+			msg: "token without bearer prefix",
+			token: &bearerToken{items: map[string]string{
+				_authorizationHeaderKey: valid[len(_bearerPrefix):],
+			}},
+		},
+		{
+			msg: "malformed token",
+			token: &bearerToken{items: map[string]string{
+				_authorizationHeaderKey: _bearerPrefix + "abc.def",
+			}},
+		},
+		{
+			// header {"alg":"none"} with the payload and no signature
+			msg: "unsigned token",
+			token: &bearerToken{items: map[string]string{
+				_authorizationHeaderKey: _bearerPrefix + "eyJhbGciOiJub25lIn0." +
+					strings.Split(valid, _partSeparator)[1] + ".",
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		u, err := suite.m.Authenticate(test.token)
+		suite.Nil(u, test.msg)
+		suite.Error(err, test.msg)
+	}
+}
+
+func (suite *SecurityManagerTestSuite) TestRedactToken() {
+	token := suite.newToken("role1", time.Minute)
+	suite.m.RedactToken(token)
+	_, ok := token.Get(_authorizationHeaderKey)
+	suite.False(ok)
+}
+
+func (suite *SecurityManagerTestSuite) TestCreateTokenSecurityManagerFailure() {
+	tests := []struct {
+		msg    string
+		modify func(config *authConfig)
+	}{
+		{
+			msg:    "unsupported algorithm",
+			modify: func(config *authConfig) { config.Algorithm = "none" },
+		},
+		{
+			msg:    "no secret",
+			modify: func(config *authConfig) { config.Secret = "" },
+		},
+		{
+			msg: "no public key",
+			modify: func(config *authConfig) {
+				config.Algorithm = _algRS256
+			},
+		},
+		{
+			msg:    "undefined default role",
+			modify: func(config *authConfig) { config.DefaultRole = "role4" },
+		},
+		{
+			msg:    "no internal subject",
+			modify: func(config *authConfig) { config.InternalSubject = "" },
+		},
+		{
+			msg:    "internal role without root privilege",
+			modify: func(config *authConfig) { config.InternalRole = "role1" },
+		},
+		{
+			msg: "invalid rule",
+			modify: func(config *authConfig) {
+				config.Roles = append(config.Roles, &basic.RoleConfig{
+					Role:   "role4",
+					Accept: []string{"JobService"},
+				})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		config, err := parseConfig(_testConfigPath)
+		suite.NoError(err)
+		test.modify(config)
+
+		m, err := newTokenSecurityManager(config)
+		suite.Nil(m, test.msg)
+		suite.Error(err, test.msg)
+	}
+}
+
+func TestSecurityManagerTestSuite(t *testing.T) {
+	suite.Run(t, new(SecurityManagerTestSuite))
+}
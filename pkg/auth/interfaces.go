@@ -24,6 +24,8 @@ const (
 	NOOP = Type("NOOP")
 	// BASIC would use username and password for auth
 	BASIC = Type("BASIC")
+	// TOKEN would use signed bearer tokens for auth
+	TOKEN = Type("TOKEN")
 )
 
 // Token is used by SecurityManager to authenticate a user
@@ -52,9 +54,29 @@ type SecurityManager interface {
 
 // User includes authorization related methods
 type User interface {
+	// Name returns the name of the user,
+	// it is empty for anonymous users
+	Name() string
 	// IsPermitted returns whether user can
 	// access the specified procedure
 	IsPermitted(procedure string) bool
+	// IsPermittedOnResource returns whether user can
+	// access the specified procedure on the resource
+	IsPermittedOnResource(procedure string, resource *Resource) bool
+}
+
+// Resource is the target of a procedure call,
+// used for resource-scoped authorization
+type Resource struct {
+	// RespoolPath is the path of the resource pool
+	// the resource belongs to, such as /infra/compute
+	RespoolPath string
+	// Owner of the job
+	Owner string
+	// OwningTeam of the job or resource pool
+	OwningTeam string
+	// LdapGroups of the job or resource pool
+	LdapGroups []string
 }
 
 // SecurityClient is the internal client used by each of
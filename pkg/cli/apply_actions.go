@@ -0,0 +1,515 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	statelesssvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+	v1alphapod "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
+	v1alphaquery "github.com/uber/peloton/.gen/peloton/api/v1alpha/query"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/yarpc/yarpcerrors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// applySetLabelKey is the key of the label set by apply on the jobs
+	// it manages, its value is the name of the apply set
+	applySetLabelKey = "peloton.apply.set"
+
+	// maximum number of jobs of an apply set looked up to be pruned
+	applyPruneQueryLimit = 1000
+)
+
+var (
+	// interval and timeout of the polling of the workflows started by apply
+	applyProgressRefresh = 5 * time.Second
+	applyProgressTimeout = 30 * time.Minute
+)
+
+// applyAction is what apply does for a job spec
+type applyAction string
+
+const (
+	applyCreate    applyAction = "create"
+	applyReplace   applyAction = "replace"
+	applyUnchanged applyAction = "unchanged"
+	applyDelete    applyAction = "delete"
+)
+
+// appliedJob is a job spec read from a file and its state in peloton
+type appliedJob struct {
+	file    string
+	spec    *stateless.JobSpec
+	jobID   *v1alphapeloton.JobID
+	version *v1alphapeloton.EntityVersion
+	action  applyAction
+}
+
+// ApplyAction creates or replaces the stateless jobs of the specs in a
+// file or in the YAML files of a directory, the jobs are looked up by
+// name. Jobs whose spec is the same and without added, removed or updated
+// instances are left unchanged. With prune, the jobs of the apply set
+// which are not in the specs anymore are deleted by force, stopping the
+// running ones, unless some job failed to be applied. Unless it is a dry
+// run, it waits for the workflows it started to complete.
+func (c *Client) ApplyAction(
+	path string,
+	respoolPath string,
+	batchSize uint32,
+	applySet string,
+	dryRun bool,
+	prune bool,
+	wait bool,
+) error {
+	jobs, err := readApplySpecs(path)
+	if err != nil {
+		return err
+	}
+
+	if err := c.setApplyRespool(jobs, respoolPath); err != nil {
+		return err
+	}
+
+	for _, j := range jobs {
+		setApplySetLabel(j.spec, applySet)
+		if err := c.planApply(j); err != nil {
+			return fmt.Errorf("unable to plan job %s: %v", j.spec.GetName(), err)
+		}
+	}
+
+	var pruned []*stateless.JobSummary
+	if prune {
+		if pruned, err = c.getPrunedJobs(jobs, applySet); err != nil {
+			return err
+		}
+		for _, s := range pruned {
+			fmt.Printf("%s: %s %s\n", s.GetName(), applyDelete, s.GetJobId().GetValue())
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	var started []*appliedJob
+	var failed []string
+	for _, j := range jobs {
+		if err := c.apply(j, batchSize); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s failed: %v\n", j.spec.GetName(), j.action, err)
+			failed = append(failed, j.spec.GetName())
+			continue
+		}
+		if j.action != applyUnchanged {
+			started = append(started, j)
+		}
+	}
+
+	// the pruned jobs may be replaced by the jobs which failed to be
+	// applied, so they are kept until the apply succeeds
+	if len(failed) > 0 && len(pruned) > 0 {
+		fmt.Fprintf(os.Stderr, "not pruning %d jobs since some jobs "+
+			"failed to be applied\n", len(pruned))
+		pruned = nil
+	}
+
+	for _, s := range pruned {
+		_, err := c.statelessClient.DeleteJob(
+			c.ctx,
+			&statelesssvc.DeleteJobRequest{
+				JobId:   s.GetJobId(),
+				Version: s.GetStatus().GetVersion(),
+				Force:   true,
+			},
+		)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s failed: %v\n", s.GetName(), applyDelete, err)
+			failed = append(failed, s.GetName())
+		}
+	}
+
+	if wait && len(started) > 0 {
+		failed = append(failed, c.waitApply(started)...)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("unable to apply jobs: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// readApplySpecs reads the job specs of a file, or of the YAML files of
+// a directory ordered by file name
+func readApplySpecs(path string) ([]*appliedJob, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no job spec found in %s", path)
+	}
+
+	var jobs []*appliedJob
+	names := make(map[string]string)
+	for _, file := range files {
+		var spec stateless.JobSpec
+		buffer, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open file %s: %v", file, err)
+		}
+		if err := yaml.Unmarshal(buffer, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse file %s: %v", file, err)
+		}
+		if len(spec.GetName()) == 0 {
+			return nil, fmt.Errorf("job spec in file %s has no name", file)
+		}
+		if other, ok := names[spec.GetName()]; ok {
+			return nil, fmt.Errorf("job %s is in files %s and %s",
+				spec.GetName(), other, file)
+		}
+		names[spec.GetName()] = file
+		jobs = append(jobs, &appliedJob{file: file, spec: &spec})
+	}
+	return jobs, nil
+}
+
+// setApplyRespool sets the resource pool of the job specs without one
+func (c *Client) setApplyRespool(jobs []*appliedJob, respoolPath string) error {
+	var respoolID *v1alphapeloton.ResourcePoolID
+	for _, j := range jobs {
+		if len(j.spec.GetRespoolId().GetValue()) != 0 {
+			continue
+		}
+
+		if respoolID == nil {
+			if len(respoolPath) == 0 {
+				return fmt.Errorf("job spec in file %s has no resource pool, "+
+					"use --respool", j.file)
+			}
+			id, err := c.LookupResourcePoolID(respoolPath)
+			if err != nil {
+				return err
+			}
+			if id == nil {
+				return fmt.Errorf("unable to find resource pool ID for "+
+					":%s", respoolPath)
+			}
+			respoolID = &v1alphapeloton.ResourcePoolID{Value: id.GetValue()}
+		}
+		j.spec.RespoolId = respoolID
+	}
+	return nil
+}
+
+// setApplySetLabel sets the label of the apply set on a job spec
+func setApplySetLabel(spec *stateless.JobSpec, applySet string) {
+	for _, l := range spec.GetLabels() {
+		if l.GetKey() == applySetLabelKey {
+			l.Value = applySet
+			return
+		}
+	}
+	spec.Labels = append(spec.Labels, &v1alphapeloton.Label{
+		Key:   applySetLabelKey,
+		Value: applySet,
+	})
+}
+
+// planApply looks up the job of a spec by name, and prints what apply
+// does for it. The entity version used for the diff is the one used to
+// replace the job, so that the replace fails if the job changed since.
+func (c *Client) planApply(j *appliedJob) error {
+	idResp, err := c.statelessClient.GetJobIDFromJobName(
+		c.ctx,
+		&statelesssvc.GetJobIDFromJobNameRequest{
+			JobName: j.spec.GetName(),
+		},
+	)
+	if yarpcerrors.IsNotFound(err) || (err == nil && len(idResp.GetJobId()) == 0) {
+		c.planApplyCreate(j)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the job ids are sorted by descending creation time, and the
+	// name of a deleted job is not removed from them
+	jobID := idResp.GetJobId()[0]
+	getResp, err := c.statelessClient.GetJob(
+		c.ctx,
+		&statelesssvc.GetJobRequest{
+			JobId: jobID,
+		},
+	)
+	if yarpcerrors.IsNotFound(err) ||
+		(err == nil && getResp.GetJobInfo().GetStatus().GetState() ==
+			stateless.JobState_JOB_STATE_DELETED) {
+		c.planApplyCreate(j)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	j.jobID = jobID
+	j.version = getResp.GetJobInfo().GetStatus().GetVersion()
+
+	diff, err := c.statelessClient.GetReplaceJobDiff(
+		c.ctx,
+		&statelesssvc.GetReplaceJobDiffRequest{
+			JobId:   j.jobID,
+			Version: j.version,
+			Spec:    j.spec,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	j.action = applyReplace
+	if len(diff.GetInstancesAdded()) == 0 &&
+		len(diff.GetInstancesRemoved()) == 0 &&
+		len(diff.GetInstancesUpdated()) == 0 &&
+		isSameJobSpec(getResp.GetJobInfo().GetSpec(), j.spec) {
+		j.action = applyUnchanged
+	}
+	fmt.Printf("%s: %s %s at version %s, instances added: %s, "+
+		"removed: %s, updated: %s, unchanged: %s\n",
+		j.spec.GetName(),
+		j.action,
+		j.jobID.GetValue(),
+		j.version.GetValue(),
+		formatInstanceRanges(diff.GetInstancesAdded()),
+		formatInstanceRanges(diff.GetInstancesRemoved()),
+		formatInstanceRanges(diff.GetInstancesUpdated()),
+		formatInstanceRanges(diff.GetInstancesUnchanged()))
+	return nil
+}
+
+// planApplyCreate prints that apply creates the job of a spec
+func (c *Client) planApplyCreate(j *appliedJob) {
+	j.action = applyCreate
+	fmt.Printf("%s: %s with %d instances\n",
+		j.spec.GetName(), j.action, j.spec.GetInstanceCount())
+}
+
+// isSameJobSpec returns whether the job level fields of two job specs,
+// such as the SLA, labels, owner and resource pool, are the same. The
+// pod specs are compared by the diff of the instances.
+func isSameJobSpec(current *stateless.JobSpec, spec *stateless.JobSpec) bool {
+	jobLevelSpec := func(s *stateless.JobSpec) *stateless.JobSpec {
+		if s == nil {
+			return &stateless.JobSpec{}
+		}
+		result := proto.Clone(s).(*stateless.JobSpec)
+		result.Revision = nil
+		result.InstanceCount = 0
+		result.DefaultSpec = nil
+		result.InstanceSpec = nil
+		return result
+	}
+	return proto.Equal(jobLevelSpec(current), jobLevelSpec(spec))
+}
+
+// apply creates or replaces the job of a spec
+func (c *Client) apply(j *appliedJob, batchSize uint32) error {
+	switch j.action {
+	case applyCreate:
+		resp, err := c.statelessClient.CreateJob(
+			c.ctx,
+			&statelesssvc.CreateJobRequest{
+				Spec: j.spec,
+				CreateSpec: &stateless.CreateSpec{
+					BatchSize: batchSize,
+				},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		j.jobID = resp.GetJobId()
+		j.version = resp.GetVersion()
+
+	case applyReplace:
+		resp, err := c.statelessClient.ReplaceJob(
+			c.ctx,
+			&statelesssvc.ReplaceJobRequest{
+				JobId:   j.jobID,
+				Version: j.version,
+				Spec:    j.spec,
+				UpdateSpec: &stateless.UpdateSpec{
+					BatchSize: batchSize,
+				},
+			},
+		)
+		if yarpcerrors.IsAborted(err) {
+			return fmt.Errorf("job changed since version %s, "+
+				"apply again to see the new diff: %v", j.version.GetValue(), err)
+		}
+		if err != nil {
+			return err
+		}
+		j.version = resp.GetVersion()
+	}
+	return nil
+}
+
+// getPrunedJobs returns the jobs of the apply set which are not in the
+// applied specs
+func (c *Client) getPrunedJobs(
+	jobs []*appliedJob,
+	applySet string,
+) ([]*stateless.JobSummary, error) {
+	names := make(map[string]bool)
+	for _, j := range jobs {
+		names[j.spec.GetName()] = true
+	}
+
+	resp, err := c.statelessClient.QueryJobs(
+		c.ctx,
+		&statelesssvc.QueryJobsRequest{
+			Spec: &stateless.QuerySpec{
+				Pagination: &v1alphaquery.PaginationSpec{
+					Limit:    applyPruneQueryLimit,
+					MaxLimit: applyPruneQueryLimit,
+				},
+				Labels: []*v1alphapeloton.Label{{
+					Key:   applySetLabelKey,
+					Value: applySet,
+				}},
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []*stateless.JobSummary
+	for _, s := range resp.GetRecords() {
+		if names[s.GetName()] ||
+			s.GetStatus().GetState() == stateless.JobState_JOB_STATE_DELETED {
+			continue
+		}
+		pruned = append(pruned, s)
+	}
+	return pruned, nil
+}
+
+// waitApply prints the progress of the workflows of the applied jobs
+// until they complete, and returns the names of the jobs whose workflow
+// did not succeed
+func (c *Client) waitApply(jobs []*appliedJob) []string {
+	var failed []string
+	pending := jobs
+	progress := make(map[*appliedJob]string)
+	timeout := time.After(applyProgressTimeout)
+	refresh := time.NewTicker(applyProgressRefresh)
+	defer refresh.Stop()
+
+	for len(pending) > 0 {
+		var next []*appliedJob
+		for _, j := range pending {
+			resp, err := c.statelessClient.GetJob(
+				c.ctx,
+				&statelesssvc.GetJobRequest{
+					JobId:       j.jobID,
+					SummaryOnly: true,
+				},
+			)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: unable to get workflow: %v\n",
+					j.spec.GetName(), err)
+				next = append(next, j)
+				continue
+			}
+
+			status := resp.GetSummary().GetStatus().GetWorkflowStatus()
+			line := fmt.Sprintf("%s: %s completed: %d remaining: %d failed: %d",
+				j.spec.GetName(),
+				status.GetState().String(),
+				status.GetNumInstancesCompleted(),
+				status.GetNumInstancesRemaining(),
+				status.GetNumInstancesFailed())
+			if progress[j] != line {
+				fmt.Println(line)
+				progress[j] = line
+			}
+
+			switch status.GetState() {
+			case stateless.WorkflowState_WORKFLOW_STATE_SUCCEEDED:
+			case stateless.WorkflowState_WORKFLOW_STATE_ABORTED,
+				stateless.WorkflowState_WORKFLOW_STATE_FAILED,
+				stateless.WorkflowState_WORKFLOW_STATE_ROLLED_BACK:
+				failed = append(failed, j.spec.GetName())
+			default:
+				next = append(next, j)
+			}
+		}
+
+		pending = next
+		if len(pending) == 0 {
+			break
+		}
+		select {
+		case <-timeout:
+			fmt.Fprint(os.Stderr, "Timed out waiting for workflows to complete\n")
+			for _, j := range pending {
+				failed = append(failed, j.spec.GetName())
+			}
+			return failed
+		case <-refresh.C:
+		}
+	}
+	return failed
+}
+
+// formatInstanceRanges formats instance ranges as from-to lists
+func formatInstanceRanges(ranges []*v1alphapod.InstanceIDRange) string {
+	if len(ranges) == 0 {
+		return "-"
+	}
+	var result []string
+	for _, r := range ranges {
+		if r.GetFrom() == r.GetTo() {
+			result = append(result, fmt.Sprintf("%d", r.GetFrom()))
+			continue
+		}
+		result = append(result, fmt.Sprintf("%d-%d", r.GetFrom(), r.GetTo()))
+	}
+	return strings.Join(result, ",")
+}
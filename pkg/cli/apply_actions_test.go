@@ -0,0 +1,556 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	respoolmocks "github.com/uber/peloton/.gen/peloton/api/v0/respool/mocks"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc/mocks"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+	v1alphapod "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	testApplySet = "test-set"
+)
+
+type applyActionsTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	client Client
+	dir    string
+
+	ctrl            *gomock.Controller
+	statelessClient *mocks.MockJobServiceYARPCClient
+	resClient       *respoolmocks.MockResourceManagerYARPCClient
+	respoolID       string
+}
+
+func TestApplyActions(t *testing.T) {
+	suite.Run(t, new(applyActionsTestSuite))
+}
+
+func (suite *applyActionsTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.statelessClient = mocks.NewMockJobServiceYARPCClient(suite.ctrl)
+	suite.resClient = respoolmocks.NewMockResourceManagerYARPCClient(suite.ctrl)
+	suite.ctx = context.Background()
+	suite.respoolID = uuid.New()
+	suite.client = Client{
+		Debug:           false,
+		statelessClient: suite.statelessClient,
+		resClient:       suite.resClient,
+		dispatcher:      nil,
+		ctx:             suite.ctx,
+	}
+
+	dir, err := ioutil.TempDir("", "apply")
+	suite.NoError(err)
+	suite.dir = dir
+
+	applyProgressRefresh = time.Millisecond
+}
+
+func (suite *applyActionsTestSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+	suite.ctrl.Finish()
+}
+
+// writeSpec writes a job spec with the given name in the test directory
+func (suite *applyActionsTestSuite) writeSpec(file string, name string) string {
+	path := filepath.Join(suite.dir, file)
+	suite.NoError(ioutil.WriteFile(
+		path, []byte("name: "+name+"\ninstancecount: 2\n"), 0644))
+	return path
+}
+
+func (suite *applyActionsTestSuite) expectLookupRespool() {
+	suite.resClient.EXPECT().
+		LookupResourcePoolID(gomock.Any(), &respool.LookupRequest{
+			Path: &respool.ResourcePoolPath{Value: testRespoolPath},
+		}).
+		Return(&respool.LookupResponse{
+			Id: &peloton.ResourcePoolID{Value: suite.respoolID},
+		}, nil)
+}
+
+func (suite *applyActionsTestSuite) expectNotFound(name string) {
+	suite.statelessClient.EXPECT().
+		GetJobIDFromJobName(gomock.Any(), &svc.GetJobIDFromJobNameRequest{
+			JobName: name,
+		}).
+		Return(nil, yarpcerrors.NotFoundErrorf("job not found"))
+}
+
+// appliedSpec returns the spec of a job written by writeSpec, as it is
+// applied to the test apply set
+func (suite *applyActionsTestSuite) appliedSpec(name string) *stateless.JobSpec {
+	return &stateless.JobSpec{
+		Name:          name,
+		InstanceCount: 2,
+		RespoolId:     &v1alphapeloton.ResourcePoolID{Value: suite.respoolID},
+		Labels: []*v1alphapeloton.Label{{
+			Key:   applySetLabelKey,
+			Value: testApplySet,
+		}},
+	}
+}
+
+// expectGetJob expects the lookup of the most recent job of a name, and
+// of its spec and status
+func (suite *applyActionsTestSuite) expectGetJob(
+	name string,
+	jobID string,
+	jobInfo *stateless.JobInfo,
+	err error) {
+	suite.statelessClient.EXPECT().
+		GetJobIDFromJobName(gomock.Any(), &svc.GetJobIDFromJobNameRequest{
+			JobName: name,
+		}).
+		Return(&svc.GetJobIDFromJobNameResponse{
+			JobId: []*v1alphapeloton.JobID{{Value: jobID}, {Value: uuid.New()}},
+		}, nil)
+	suite.statelessClient.EXPECT().
+		GetJob(gomock.Any(), &svc.GetJobRequest{
+			JobId: &v1alphapeloton.JobID{Value: jobID},
+		}).
+		Return(&svc.GetJobResponse{JobInfo: jobInfo}, err)
+}
+
+// expectDiff expects the lookup of an existing job with the applied spec
+// and of its diff
+func (suite *applyActionsTestSuite) expectDiff(
+	name string,
+	jobID string,
+	diff *svc.GetReplaceJobDiffResponse) {
+	suite.expectSpecDiff(name, jobID, suite.appliedSpec(name), diff)
+}
+
+// expectSpecDiff expects the lookup of an existing job with the given
+// spec and of its diff
+func (suite *applyActionsTestSuite) expectSpecDiff(
+	name string,
+	jobID string,
+	spec *stateless.JobSpec,
+	diff *svc.GetReplaceJobDiffResponse) {
+	suite.expectGetJob(name, jobID, &stateless.JobInfo{
+		Spec: spec,
+		Status: &stateless.JobStatus{
+			State:   stateless.JobState_JOB_STATE_RUNNING,
+			Version: &v1alphapeloton.EntityVersion{Value: testEntityVersion},
+		},
+	}, nil)
+	suite.statelessClient.EXPECT().
+		GetReplaceJobDiff(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.GetReplaceJobDiffRequest) {
+			suite.Equal(jobID, req.GetJobId().GetValue())
+			suite.Equal(testEntityVersion, req.GetVersion().GetValue())
+			suite.Equal(name, req.GetSpec().GetName())
+		}).
+		Return(diff, nil)
+}
+
+// expectWorkflow expects the polling of the workflow of a job until it
+// reaches the given state
+func (suite *applyActionsTestSuite) expectWorkflow(
+	jobID string,
+	state stateless.WorkflowState) {
+	getJob := func(state stateless.WorkflowState) *gomock.Call {
+		return suite.statelessClient.EXPECT().
+			GetJob(gomock.Any(), &svc.GetJobRequest{
+				JobId:       &v1alphapeloton.JobID{Value: jobID},
+				SummaryOnly: true,
+			}).
+			Return(&svc.GetJobResponse{
+				Summary: &stateless.JobSummary{
+					Status: &stateless.JobStatus{
+						WorkflowStatus: &stateless.WorkflowStatus{
+							State:                 state,
+							NumInstancesCompleted: 1,
+							NumInstancesRemaining: 1,
+						},
+					},
+				},
+			}, nil)
+	}
+	gomock.InOrder(
+		getJob(stateless.WorkflowState_WORKFLOW_STATE_ROLLING_FORWARD),
+		getJob(state),
+	)
+}
+
+// TestApplyActionCreate tests creating a job which does not exist
+func (suite *applyActionsTestSuite) TestApplyActionCreate() {
+	jobID := uuid.New()
+	suite.expectLookupRespool()
+	suite.expectNotFound("TestSpec")
+	suite.statelessClient.EXPECT().
+		CreateJob(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.CreateJobRequest) {
+			suite.Nil(req.GetJobId())
+			suite.Equal(uint32(2), req.GetCreateSpec().GetBatchSize())
+			suite.Equal(suite.respoolID, req.GetSpec().GetRespoolId().GetValue())
+			suite.Contains(req.GetSpec().GetLabels(), &v1alphapeloton.Label{
+				Key:   applySetLabelKey,
+				Value: testApplySet,
+			})
+		}).
+		Return(&svc.CreateJobResponse{
+			JobId:   &v1alphapeloton.JobID{Value: jobID},
+			Version: &v1alphapeloton.EntityVersion{Value: testEntityVersion},
+		}, nil)
+	suite.expectWorkflow(jobID, stateless.WorkflowState_WORKFLOW_STATE_SUCCEEDED)
+
+	suite.NoError(suite.client.ApplyAction(
+		testStatelessSpecConfig, testRespoolPath, 2, testApplySet,
+		false, false, true))
+}
+
+// TestApplyActionReplace tests replacing the most recent job of a name
+// at the version of the diff
+func (suite *applyActionsTestSuite) TestApplyActionReplace() {
+	jobID := uuid.New()
+	path := suite.writeSpec("job.yaml", "job")
+	suite.expectLookupRespool()
+	suite.expectDiff("job", jobID, &svc.GetReplaceJobDiffResponse{
+		InstancesUpdated: []*v1alphapod.InstanceIDRange{{From: 0, To: 1}},
+	})
+	suite.statelessClient.EXPECT().
+		ReplaceJob(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.ReplaceJobRequest) {
+			suite.Equal(jobID, req.GetJobId().GetValue())
+			suite.Equal(testEntityVersion, req.GetVersion().GetValue())
+			suite.Equal(uint32(1), req.GetUpdateSpec().GetBatchSize())
+		}).
+		Return(&svc.ReplaceJobResponse{
+			Version: &v1alphapeloton.EntityVersion{Value: "2-1-1"},
+		}, nil)
+	suite.expectWorkflow(jobID, stateless.WorkflowState_WORKFLOW_STATE_SUCCEEDED)
+
+	suite.NoError(suite.client.ApplyAction(
+		path, testRespoolPath, 1, testApplySet, false, false, true))
+}
+
+// TestApplyActionReplaceVersionChanged tests that the replace fails
+// when the job changed since the diff
+func (suite *applyActionsTestSuite) TestApplyActionReplaceVersionChanged() {
+	jobID := uuid.New()
+	path := suite.writeSpec("job.yaml", "job")
+	suite.expectLookupRespool()
+	suite.expectDiff("job", jobID, &svc.GetReplaceJobDiffResponse{
+		InstancesAdded: []*v1alphapod.InstanceIDRange{{From: 2, To: 2}},
+	})
+	suite.statelessClient.EXPECT().
+		ReplaceJob(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.AbortedErrorf("unexpected entity version"))
+
+	suite.Error(suite.client.ApplyAction(
+		path, testRespoolPath, 1, testApplySet, false, false, true))
+}
+
+// TestApplyActionUnchanged tests that a job without instance changes is
+// not replaced
+func (suite *applyActionsTestSuite) TestApplyActionUnchanged() {
+	path := suite.writeSpec("job.yaml", "job")
+	suite.expectLookupRespool()
+	suite.expectDiff("job", uuid.New(), &svc.GetReplaceJobDiffResponse{
+		InstancesUnchanged: []*v1alphapod.InstanceIDRange{{From: 0, To: 1}},
+	})
+
+	suite.NoError(suite.client.ApplyAction(
+		path, testRespoolPath, 1, testApplySet, false, false, true))
+}
+
+// TestApplyActionReplaceSpecChanged tests that a job without instance
+// changes is replaced when its job level spec changed
+func (suite *applyActionsTestSuite) TestApplyActionReplaceSpecChanged() {
+	path := suite.writeSpec("job.yaml", "job")
+
+	specs := []*stateless.JobSpec{
+		suite.appliedSpec("job"),
+		suite.appliedSpec("job"),
+		suite.appliedSpec("job"),
+		suite.appliedSpec("job"),
+	}
+	// not in the apply set yet
+	specs[0].Labels = nil
+	specs[1].Sla = &stateless.SlaSpec{MaximumUnavailableInstances: 1}
+	specs[2].Owner = "owner"
+	specs[3].RespoolId = &v1alphapeloton.ResourcePoolID{Value: uuid.New()}
+
+	for _, spec := range specs {
+		jobID := uuid.New()
+		suite.expectLookupRespool()
+		suite.expectSpecDiff("job", jobID, spec, &svc.GetReplaceJobDiffResponse{
+			InstancesUnchanged: []*v1alphapod.InstanceIDRange{{From: 0, To: 1}},
+		})
+		suite.statelessClient.EXPECT().
+			ReplaceJob(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, req *svc.ReplaceJobRequest) {
+				suite.Equal(jobID, req.GetJobId().GetValue())
+				suite.Equal(suite.appliedSpec("job"), req.GetSpec())
+			}).
+			Return(&svc.ReplaceJobResponse{
+				Version: &v1alphapeloton.EntityVersion{Value: "2-1-1"},
+			}, nil)
+
+		suite.NoError(suite.client.ApplyAction(
+			path, testRespoolPath, 1, testApplySet, false, false, false))
+	}
+}
+
+// TestApplyActionCreateDeleted tests creating a job when the most recent
+// job of its name is deleted
+func (suite *applyActionsTestSuite) TestApplyActionCreateDeleted() {
+	path := suite.writeSpec("job.yaml", "job")
+
+	for _, expectGetJob := range []func(jobID string){
+		func(jobID string) {
+			suite.expectGetJob("job", jobID, &stateless.JobInfo{
+				Spec: suite.appliedSpec("job"),
+				Status: &stateless.JobStatus{
+					State: stateless.JobState_JOB_STATE_DELETED,
+				},
+			}, nil)
+		},
+		func(jobID string) {
+			suite.expectGetJob("job", jobID, nil,
+				yarpcerrors.NotFoundErrorf("job not found"))
+		},
+	} {
+		suite.expectLookupRespool()
+		expectGetJob(uuid.New())
+		suite.statelessClient.EXPECT().
+			CreateJob(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, req *svc.CreateJobRequest) {
+				suite.Nil(req.GetJobId())
+				suite.Equal("job", req.GetSpec().GetName())
+			}).
+			Return(&svc.CreateJobResponse{
+				JobId: &v1alphapeloton.JobID{Value: uuid.New()},
+			}, nil)
+
+		suite.NoError(suite.client.ApplyAction(
+			path, testRespoolPath, 1, testApplySet, false, false, false))
+	}
+}
+
+// TestApplyActionWorkflowFailed tests that apply fails when a workflow
+// does not succeed
+func (suite *applyActionsTestSuite) TestApplyActionWorkflowFailed() {
+	jobID := uuid.New()
+	suite.expectLookupRespool()
+	suite.expectNotFound("TestSpec")
+	suite.statelessClient.EXPECT().
+		CreateJob(gomock.Any(), gomock.Any()).
+		Return(&svc.CreateJobResponse{
+			JobId: &v1alphapeloton.JobID{Value: jobID},
+		}, nil)
+	suite.expectWorkflow(jobID, stateless.WorkflowState_WORKFLOW_STATE_ROLLED_BACK)
+
+	suite.Error(suite.client.ApplyAction(
+		testStatelessSpecConfig, testRespoolPath, 1, testApplySet,
+		false, false, true))
+}
+
+// TestApplyActionNoWait tests that apply does not poll the workflows
+// without wait
+func (suite *applyActionsTestSuite) TestApplyActionNoWait() {
+	suite.expectLookupRespool()
+	suite.expectNotFound("TestSpec")
+	suite.statelessClient.EXPECT().
+		CreateJob(gomock.Any(), gomock.Any()).
+		Return(&svc.CreateJobResponse{
+			JobId: &v1alphapeloton.JobID{Value: uuid.New()},
+		}, nil)
+
+	suite.NoError(suite.client.ApplyAction(
+		testStatelessSpecConfig, testRespoolPath, 1, testApplySet,
+		false, false, false))
+}
+
+// expectPruneQuery expects the query of the jobs of the test apply set
+func (suite *applyActionsTestSuite) expectPruneQuery(
+	records ...*stateless.JobSummary) {
+	suite.statelessClient.EXPECT().
+		QueryJobs(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.QueryJobsRequest) {
+			suite.Equal([]*v1alphapeloton.Label{{
+				Key:   applySetLabelKey,
+				Value: testApplySet,
+			}}, req.GetSpec().GetLabels())
+		}).
+		Return(&svc.QueryJobsResponse{Records: records}, nil)
+}
+
+// TestApplyActionDryRunPrune tests that a dry run of a directory does
+// not change any job
+func (suite *applyActionsTestSuite) TestApplyActionDryRunPrune() {
+	suite.writeSpec("a.yaml", "a")
+	suite.writeSpec("b.yml", "b")
+	suite.NoError(ioutil.WriteFile(
+		filepath.Join(suite.dir, "README"), []byte("not a spec"), 0644))
+
+	suite.expectLookupRespool()
+	suite.expectNotFound("a")
+	suite.expectDiff("b", uuid.New(), &svc.GetReplaceJobDiffResponse{
+		InstancesRemoved: []*v1alphapod.InstanceIDRange{{From: 2, To: 3}},
+	})
+	suite.expectPruneQuery(
+		&stateless.JobSummary{
+			JobId: &v1alphapeloton.JobID{Value: uuid.New()},
+			Name:  "b",
+		},
+		&stateless.JobSummary{
+			JobId: &v1alphapeloton.JobID{Value: uuid.New()},
+			Name:  "old",
+		},
+	)
+
+	suite.NoError(suite.client.ApplyAction(
+		suite.dir, testRespoolPath, 1, testApplySet, true, true, true))
+}
+
+// TestApplyActionPrune tests deleting the jobs of the apply set which
+// are not in the directory anymore
+func (suite *applyActionsTestSuite) TestApplyActionPrune() {
+	suite.writeSpec("a.yaml", "a")
+	oldJobID := uuid.New()
+
+	suite.expectLookupRespool()
+	suite.expectDiff("a", uuid.New(), &svc.GetReplaceJobDiffResponse{})
+	suite.expectPruneQuery(
+		&stateless.JobSummary{
+			JobId: &v1alphapeloton.JobID{Value: uuid.New()},
+			Name:  "a",
+		},
+		&stateless.JobSummary{
+			JobId: &v1alphapeloton.JobID{Value: oldJobID},
+			Name:  "old",
+			Status: &stateless.JobStatus{
+				Version: &v1alphapeloton.EntityVersion{Value: testEntityVersion},
+			},
+		},
+		&stateless.JobSummary{
+			JobId: &v1alphapeloton.JobID{Value: uuid.New()},
+			Name:  "deleted",
+			Status: &stateless.JobStatus{
+				State: stateless.JobState_JOB_STATE_DELETED,
+			},
+		},
+	)
+	suite.statelessClient.EXPECT().
+		DeleteJob(gomock.Any(), &svc.DeleteJobRequest{
+			JobId:   &v1alphapeloton.JobID{Value: oldJobID},
+			Version: &v1alphapeloton.EntityVersion{Value: testEntityVersion},
+			Force:   true,
+		}).
+		Return(&svc.DeleteJobResponse{}, nil)
+
+	suite.NoError(suite.client.ApplyAction(
+		suite.dir, testRespoolPath, 1, testApplySet, false, true, true))
+}
+
+// TestApplyActionPruneApplyFailed tests that no job is deleted when a
+// job of the directory fails to be applied
+func (suite *applyActionsTestSuite) TestApplyActionPruneApplyFailed() {
+	suite.writeSpec("a.yaml", "a")
+
+	suite.expectLookupRespool()
+	suite.expectNotFound("a")
+	suite.expectPruneQuery(
+		&stateless.JobSummary{
+			JobId: &v1alphapeloton.JobID{Value: uuid.New()},
+			Name:  "old",
+			Status: &stateless.JobStatus{
+				Version: &v1alphapeloton.EntityVersion{Value: testEntityVersion},
+			},
+		},
+	)
+	suite.statelessClient.EXPECT().
+		CreateJob(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.InternalErrorf("test error"))
+
+	suite.Error(suite.client.ApplyAction(
+		suite.dir, testRespoolPath, 1, testApplySet, false, true, true))
+}
+
+// TestApplyActionRespoolInSpec tests that the resource pool of a spec
+// is kept
+func (suite *applyActionsTestSuite) TestApplyActionRespoolInSpec() {
+	path := filepath.Join(suite.dir, "job.yaml")
+	suite.NoError(ioutil.WriteFile(path, []byte(
+		"name: job\ninstancecount: 1\nrespoolid:\n  value: "+suite.respoolID+"\n"),
+		0644))
+
+	suite.expectNotFound("job")
+	suite.statelessClient.EXPECT().
+		CreateJob(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.CreateJobRequest) {
+			suite.Equal(suite.respoolID, req.GetSpec().GetRespoolId().GetValue())
+		}).
+		Return(&svc.CreateJobResponse{
+			JobId: &v1alphapeloton.JobID{Value: uuid.New()},
+		}, nil)
+
+	suite.NoError(suite.client.ApplyAction(
+		path, "", 1, testApplySet, false, false, false))
+}
+
+// TestApplyActionInvalidSpecs tests that no job is changed when the
+// specs are invalid
+func (suite *applyActionsTestSuite) TestApplyActionInvalidSpecs() {
+	// no spec
+	suite.Error(suite.client.ApplyAction(
+		suite.dir, testRespoolPath, 1, testApplySet, false, false, true))
+
+	// no resource pool
+	path := suite.writeSpec("a.yaml", "a")
+	suite.Error(suite.client.ApplyAction(
+		path, "", 1, testApplySet, false, false, true))
+
+	// same name in two files
+	suite.writeSpec("b.yaml", "a")
+	suite.Error(suite.client.ApplyAction(
+		suite.dir, testRespoolPath, 1, testApplySet, false, false, true))
+
+	// no name
+	suite.NoError(ioutil.WriteFile(path, []byte("instancecount: 1\n"), 0644))
+	suite.Error(suite.client.ApplyAction(
+		suite.dir, testRespoolPath, 1, testApplySet, false, false, true))
+}
+
+func (suite *applyActionsTestSuite) TestFormatInstanceRanges() {
+	suite.Equal("-", formatInstanceRanges(nil))
+	suite.Equal("0-2,5", formatInstanceRanges([]*v1alphapod.InstanceIDRange{
+		{From: 0, To: 2},
+		{From: 5, To: 5},
+	}))
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uber/peloton/.gen/peloton/private/jobmgrsvc"
+)
+
+const (
+	auditQueryFormatHeader = "Time\tUser\tCaller\tProcedure\tTargets\t" +
+		"Outcome\tLatency(ms)\t\n"
+	auditQueryFormatBody = "%s\t%s\t%s\t%s\t%s\t%s\t%d\t\n"
+)
+
+// AuditQueryAction is the action for querying the audit log of the
+// mutating calls, start and end are RFC3339 timestamps
+func (c *Client) AuditQueryAction(
+	user string,
+	procedure string,
+	jobID string,
+	podName string,
+	respoolID string,
+	start string,
+	end string,
+	limit uint32,
+) error {
+	defer tabWriter.Flush()
+
+	resp, err := c.jobmgrClient.QueryAuditEvents(
+		c.ctx,
+		&jobmgrsvc.QueryAuditEventsRequest{
+			User:      user,
+			Procedure: procedure,
+			JobId:     jobID,
+			PodName:   podName,
+			RespoolId: respoolID,
+			StartTime: start,
+			EndTime:   end,
+			Limit:     limit,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.GetEvents()) == 0 {
+		fmt.Fprintf(tabWriter, "No audit events found\n")
+		return nil
+	}
+
+	fmt.Fprint(tabWriter, auditQueryFormatHeader)
+	for _, e := range resp.GetEvents() {
+		var targets []string
+		targets = append(targets, e.GetJobIds()...)
+		targets = append(targets, e.GetPodNames()...)
+		targets = append(targets, e.GetRespoolIds()...)
+
+		fmt.Fprintf(
+			tabWriter,
+			auditQueryFormatBody,
+			e.GetTime(),
+			e.GetUser(),
+			e.GetCaller(),
+			e.GetProcedure(),
+			strings.Join(targets, ","),
+			e.GetOutcome(),
+			e.GetLatencyMs(),
+		)
+	}
+	return nil
+}
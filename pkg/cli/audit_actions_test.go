@@ -0,0 +1,101 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/private/jobmgrsvc"
+	jobmgrsvcmocks "github.com/uber/peloton/.gen/peloton/private/jobmgrsvc/mocks"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type auditActionsTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	client Client
+
+	ctrl         *gomock.Controller
+	jobmgrClient *jobmgrsvcmocks.MockJobManagerServiceYARPCClient
+}
+
+func (suite *auditActionsTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.jobmgrClient = jobmgrsvcmocks.NewMockJobManagerServiceYARPCClient(suite.ctrl)
+	suite.ctx = context.Background()
+	suite.client = Client{
+		Debug:        false,
+		jobmgrClient: suite.jobmgrClient,
+		dispatcher:   nil,
+		ctx:          suite.ctx,
+	}
+}
+
+func (suite *auditActionsTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestAuditActions(t *testing.T) {
+	suite.Run(t, new(auditActionsTestSuite))
+}
+
+// TestAuditQueryAction tests querying the audit log with filters
+func (suite *auditActionsTestSuite) TestAuditQueryAction() {
+	suite.jobmgrClient.EXPECT().
+		QueryAuditEvents(gomock.Any(), &jobmgrsvc.QueryAuditEventsRequest{
+			User:      "alice",
+			JobId:     "job1",
+			StartTime: "2019-01-01T00:00:00Z",
+			Limit:     10,
+		}).
+		Return(&jobmgrsvc.QueryAuditEventsResponse{
+			Events: []*models.AuditEvent{
+				{
+					Time:      "2019-01-01T01:00:00Z",
+					User:      "alice",
+					Procedure: "peloton.api.v1alpha.job.stateless.svc.JobService::ReplaceJob",
+					JobIds:    []string{"job1"},
+					Outcome:   "ok",
+					LatencyMs: 12,
+				},
+			},
+		}, nil)
+	suite.NoError(suite.client.AuditQueryAction(
+		"alice", "", "job1", "", "", "2019-01-01T00:00:00Z", "", 10))
+}
+
+// TestAuditQueryActionNoEvents tests querying the audit log when no
+// event matches the filters
+func (suite *auditActionsTestSuite) TestAuditQueryActionNoEvents() {
+	suite.jobmgrClient.EXPECT().
+		QueryAuditEvents(gomock.Any(), gomock.Any()).
+		Return(&jobmgrsvc.QueryAuditEventsResponse{}, nil)
+	suite.NoError(suite.client.AuditQueryAction(
+		"", "", "", "", "", "", "", 0))
+}
+
+// TestAuditQueryActionFailure tests a failure to query the audit log
+func (suite *auditActionsTestSuite) TestAuditQueryActionFailure() {
+	suite.jobmgrClient.EXPECT().
+		QueryAuditEvents(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.InvalidArgumentErrorf("bad time range"))
+	suite.Error(suite.client.AuditQueryAction(
+		"", "", "", "", "", "", "", 0))
+}
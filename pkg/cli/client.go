@@ -25,10 +25,12 @@ import (
 
 	hostsvc "github.com/uber/peloton/.gen/peloton/api/v0/host/svc"
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	pipelinesvc "github.com/uber/peloton/.gen/peloton/api/v0/pipeline/svc"
 	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	updatesvc "github.com/uber/peloton/.gen/peloton/api/v0/update/svc"
 	volume_svc "github.com/uber/peloton/.gen/peloton/api/v0/volume/svc"
+	cronsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc"
 	statelesssvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless/svc"
 	podsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod/svc"
 	watchsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/watch/svc"
@@ -47,6 +49,8 @@ type Client struct {
 	taskClient      task.TaskManagerYARPCClient
 	podClient       podsvc.PodServiceYARPCClient
 	statelessClient statelesssvc.JobServiceYARPCClient
+	cronClient      cronsvc.CronJobServiceYARPCClient
+	pipelineClient  pipelinesvc.PipelineServiceYARPCClient
 	watchClient     watchsvc.WatchServiceYARPCClient
 	resClient       respool.ResourceManagerYARPCClient
 	resMgrClient    resmgrsvc.ResourceManagerServiceYARPCClient
@@ -67,6 +71,7 @@ func New(
 	discovery leader.Discovery,
 	timeout time.Duration,
 	authConfig *middleware.BasicAuthConfig,
+	tokenAuthConfig *middleware.TokenAuthConfig,
 	debug bool) (*Client, error) {
 
 	jobmgrURL, err := discovery.GetAppURL(common.JobManagerRole)
@@ -86,7 +91,7 @@ func New(
 
 	t := grpc.NewTransport()
 
-	authMiddleware := middleware.NewBasicAuthOutboundMiddleware(authConfig)
+	authMiddleware := middleware.NewAuthOutboundMiddleware(authConfig, tokenAuthConfig)
 
 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
 		Name: common.PelotonCLI,
@@ -146,6 +151,12 @@ func New(
 		statelessClient: statelesssvc.NewJobServiceYARPCClient(
 			dispatcher.ClientConfig(common.PelotonJobManager),
 		),
+		cronClient: cronsvc.NewCronJobServiceYARPCClient(
+			dispatcher.ClientConfig(common.PelotonJobManager),
+		),
+		pipelineClient: pipelinesvc.NewPipelineServiceYARPCClient(
+			dispatcher.ClientConfig(common.PelotonJobManager),
+		),
 		watchClient: watchsvc.NewWatchServiceYARPCClient(
 			dispatcher.ClientConfig(common.PelotonJobManager),
 		),
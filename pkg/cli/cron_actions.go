@@ -0,0 +1,238 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+	cronsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	cronJobListFormatHeader = "Name\tSchedule\tCollision Policy\tLast Run\t" +
+		"Next Run\tActive Runs\tRuns\tSkipped\t\n"
+	cronJobListFormatBody = "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t\n"
+
+	collisionPolicyPrefix = "COLLISION_POLICY_"
+)
+
+// CronCreateAction is the action for creating a cron job which creates a
+// batch job from the template in cfg on every activation of the schedule
+func (c *Client) CronCreateAction(
+	name string,
+	schedule string,
+	collisionPolicy string,
+	respoolPath string,
+	cfg string,
+) error {
+	spec, err := c.buildCronJobSpec(
+		name, schedule, collisionPolicy, respoolPath, cfg)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.cronClient.CreateCronJob(
+		c.ctx,
+		&cronsvc.CreateCronJobRequest{Spec: spec},
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cron job %s created\n", name)
+	return nil
+}
+
+// CronReplaceAction is the action for replacing the schedule, the
+// collision policy and the template of a cron job
+func (c *Client) CronReplaceAction(
+	name string,
+	schedule string,
+	collisionPolicy string,
+	respoolPath string,
+	cfg string,
+) error {
+	spec, err := c.buildCronJobSpec(
+		name, schedule, collisionPolicy, respoolPath, cfg)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.cronClient.ReplaceCronJob(
+		c.ctx,
+		&cronsvc.ReplaceCronJobRequest{Spec: spec},
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cron job %s replaced\n", name)
+	return nil
+}
+
+// CronGetAction is the action for getting the spec and the status
+// of a cron job
+func (c *Client) CronGetAction(name string) error {
+	resp, err := c.cronClient.GetCronJob(
+		c.ctx,
+		&cronsvc.GetCronJobRequest{Name: name},
+	)
+	if err != nil {
+		return err
+	}
+
+	out, err := marshallResponse(defaultResponseFormat, resp)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%v\n", string(out))
+
+	return nil
+}
+
+// CronListAction is the action for listing all the cron jobs
+func (c *Client) CronListAction() error {
+	defer tabWriter.Flush()
+
+	resp, err := c.cronClient.ListCronJobs(
+		c.ctx,
+		&cronsvc.ListCronJobsRequest{},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.GetCronJobs()) == 0 {
+		fmt.Fprintf(tabWriter, "No cron jobs found\n")
+		return nil
+	}
+
+	fmt.Fprint(tabWriter, cronJobListFormatHeader)
+	for _, cronJob := range resp.GetCronJobs() {
+		fmt.Fprintf(
+			tabWriter,
+			cronJobListFormatBody,
+			cronJob.GetSpec().GetName(),
+			cronJob.GetSpec().GetSchedule(),
+			strings.TrimPrefix(
+				cronJob.GetSpec().GetCollisionPolicy().String(),
+				collisionPolicyPrefix),
+			cronJob.GetStatus().GetLastRunTime(),
+			cronJob.GetStatus().GetNextRunTime(),
+			len(cronJob.GetStatus().GetActiveRuns()),
+			cronJob.GetStatus().GetRunCount(),
+			cronJob.GetStatus().GetSkippedCount(),
+		)
+	}
+	return nil
+}
+
+// CronDeleteAction is the action for deleting a cron job, the batch jobs
+// it has created are not affected
+func (c *Client) CronDeleteAction(name string) error {
+	_, err := c.cronClient.DeleteCronJob(
+		c.ctx,
+		&cronsvc.DeleteCronJobRequest{Name: name},
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cron job %s deleted\n", name)
+	return nil
+}
+
+// CronStartAction is the action for starting a run of a cron job
+// immediately, irrespective of its schedule
+func (c *Client) CronStartAction(name string) error {
+	resp, err := c.cronClient.StartCronJob(
+		c.ctx,
+		&cronsvc.StartCronJobRequest{Name: name},
+	)
+	if err != nil {
+		return err
+	}
+
+	if resp.GetJobId() == nil {
+		fmt.Printf("Run of cron job %s skipped due to its collision "+
+			"policy\n", name)
+		return nil
+	}
+
+	fmt.Printf("Cron job %s started job %s\n",
+		name, resp.GetJobId().GetValue())
+	return nil
+}
+
+// buildCronJobSpec builds the spec of a cron job with the template read
+// from the given file
+func (c *Client) buildCronJobSpec(
+	name string,
+	schedule string,
+	collisionPolicy string,
+	respoolPath string,
+	cfg string,
+) (*cron.CronJobSpec, error) {
+	policy, err := parseCollisionPolicy(collisionPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	respoolID, err := c.LookupResourcePoolID(respoolPath)
+	if err != nil {
+		return nil, err
+	}
+	if respoolID == nil {
+		return nil, fmt.Errorf("unable to find resource pool ID for "+
+			":%s", respoolPath)
+	}
+
+	var template stateless.JobSpec
+	buffer, err := ioutil.ReadFile(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file %s: %v", cfg, err)
+	}
+	if err := yaml.Unmarshal(buffer, &template); err != nil {
+		return nil, fmt.Errorf("unable to parse file %s: %v", cfg, err)
+	}
+	template.RespoolId = &v1alphapeloton.ResourcePoolID{
+		Value: respoolID.GetValue(),
+	}
+
+	return &cron.CronJobSpec{
+		Name:            name,
+		Schedule:        schedule,
+		CollisionPolicy: policy,
+		Template:        &template,
+	}, nil
+}
+
+// parseCollisionPolicy parses a collision policy such as "kill_existing"
+func parseCollisionPolicy(policy string) (cron.CollisionPolicy, error) {
+	value, ok := cron.CollisionPolicy_value[collisionPolicyPrefix+
+		strings.ToUpper(policy)]
+	if !ok || value == int32(cron.CollisionPolicy_COLLISION_POLICY_INVALID) {
+		return cron.CollisionPolicy_COLLISION_POLICY_INVALID,
+			fmt.Errorf("invalid collision policy %s", policy)
+	}
+	return cron.CollisionPolicy(value), nil
+}
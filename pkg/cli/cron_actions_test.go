@@ -0,0 +1,258 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	respoolmocks "github.com/uber/peloton/.gen/peloton/api/v0/respool/mocks"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+	cronsvc "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc"
+	cronmocks "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc/mocks"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	testCronJobName  = "test-cron"
+	testCronSchedule = "*/5 * * * *"
+)
+
+type cronActionsTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	client    Client
+	respoolID string
+
+	ctrl       *gomock.Controller
+	cronClient *cronmocks.MockCronJobServiceYARPCClient
+	resClient  *respoolmocks.MockResourceManagerYARPCClient
+}
+
+func (suite *cronActionsTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.cronClient = cronmocks.NewMockCronJobServiceYARPCClient(suite.ctrl)
+	suite.resClient = respoolmocks.NewMockResourceManagerYARPCClient(suite.ctrl)
+	suite.ctx = context.Background()
+	suite.respoolID = uuid.New()
+	suite.client = Client{
+		Debug:      false,
+		cronClient: suite.cronClient,
+		resClient:  suite.resClient,
+		dispatcher: nil,
+		ctx:        suite.ctx,
+	}
+}
+
+func (suite *cronActionsTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestCronActions(t *testing.T) {
+	suite.Run(t, new(cronActionsTestSuite))
+}
+
+func (suite *cronActionsTestSuite) expectLookupRespool() {
+	suite.resClient.EXPECT().
+		LookupResourcePoolID(gomock.Any(), &respool.LookupRequest{
+			Path: &respool.ResourcePoolPath{Value: testRespoolPath},
+		}).
+		Return(&respool.LookupResponse{
+			Id: &peloton.ResourcePoolID{Value: suite.respoolID},
+		}, nil)
+}
+
+func (suite *cronActionsTestSuite) getSpec() *cron.CronJobSpec {
+	var template stateless.JobSpec
+	buffer, err := ioutil.ReadFile(testStatelessSpecConfig)
+	suite.NoError(err)
+	suite.NoError(yaml.Unmarshal(buffer, &template))
+	template.RespoolId = &v1alphapeloton.ResourcePoolID{Value: suite.respoolID}
+
+	return &cron.CronJobSpec{
+		Name:            testCronJobName,
+		Schedule:        testCronSchedule,
+		CollisionPolicy: cron.CollisionPolicy_COLLISION_POLICY_SKIP,
+		Template:        &template,
+	}
+}
+
+// TestCronCreateAction tests creating a cron job
+func (suite *cronActionsTestSuite) TestCronCreateAction() {
+	suite.expectLookupRespool()
+	suite.cronClient.EXPECT().
+		CreateCronJob(gomock.Any(), &cronsvc.CreateCronJobRequest{
+			Spec: suite.getSpec(),
+		}).
+		Return(&cronsvc.CreateCronJobResponse{}, nil)
+
+	suite.NoError(suite.client.CronCreateAction(
+		testCronJobName,
+		testCronSchedule,
+		"skip",
+		testRespoolPath,
+		testStatelessSpecConfig,
+	))
+}
+
+// TestCronCreateActionInvalidPolicy tests creating a cron job with an
+// invalid collision policy
+func (suite *cronActionsTestSuite) TestCronCreateActionInvalidPolicy() {
+	for _, policy := range []string{"invalid", "never"} {
+		suite.Error(suite.client.CronCreateAction(
+			testCronJobName,
+			testCronSchedule,
+			policy,
+			testRespoolPath,
+			testStatelessSpecConfig,
+		))
+	}
+}
+
+// TestCronCreateActionLookupRespoolFailure tests creating a cron job
+// when the resource pool lookup fails
+func (suite *cronActionsTestSuite) TestCronCreateActionLookupRespoolFailure() {
+	suite.resClient.EXPECT().
+		LookupResourcePoolID(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.InternalErrorf("test error"))
+
+	suite.Error(suite.client.CronCreateAction(
+		testCronJobName,
+		testCronSchedule,
+		"skip",
+		testRespoolPath,
+		testStatelessSpecConfig,
+	))
+}
+
+// TestCronCreateActionBadConfig tests creating a cron job with a
+// template file which does not exist
+func (suite *cronActionsTestSuite) TestCronCreateActionBadConfig() {
+	suite.expectLookupRespool()
+
+	suite.Error(suite.client.CronCreateAction(
+		testCronJobName,
+		testCronSchedule,
+		"skip",
+		testRespoolPath,
+		"not-exist.yaml",
+	))
+}
+
+// TestCronReplaceAction tests replacing a cron job
+func (suite *cronActionsTestSuite) TestCronReplaceAction() {
+	spec := suite.getSpec()
+	spec.CollisionPolicy = cron.CollisionPolicy_COLLISION_POLICY_KILL_EXISTING
+
+	suite.expectLookupRespool()
+	suite.cronClient.EXPECT().
+		ReplaceCronJob(gomock.Any(), &cronsvc.ReplaceCronJobRequest{
+			Spec: spec,
+		}).
+		Return(nil, yarpcerrors.NotFoundErrorf("test error"))
+
+	suite.Error(suite.client.CronReplaceAction(
+		testCronJobName,
+		testCronSchedule,
+		"kill_existing",
+		testRespoolPath,
+		testStatelessSpecConfig,
+	))
+}
+
+// TestCronGetAction tests getting a cron job
+func (suite *cronActionsTestSuite) TestCronGetAction() {
+	suite.cronClient.EXPECT().
+		GetCronJob(gomock.Any(), &cronsvc.GetCronJobRequest{
+			Name: testCronJobName,
+		}).
+		Return(&cronsvc.GetCronJobResponse{
+			CronJob: &cron.CronJobInfo{Spec: suite.getSpec()},
+		}, nil)
+
+	suite.NoError(suite.client.CronGetAction(testCronJobName))
+}
+
+// TestCronListAction tests listing the cron jobs
+func (suite *cronActionsTestSuite) TestCronListAction() {
+	suite.cronClient.EXPECT().
+		ListCronJobs(gomock.Any(), &cronsvc.ListCronJobsRequest{}).
+		Return(&cronsvc.ListCronJobsResponse{
+			CronJobs: []*cron.CronJobInfo{
+				{
+					Spec: suite.getSpec(),
+					Status: &cron.CronJobStatus{
+						LastRunTime: "2019-01-01T00:05:00Z",
+						NextRunTime: "2019-01-01T00:10:00Z",
+						ActiveRuns: []*v1alphapeloton.JobID{
+							{Value: uuid.New()},
+						},
+						RunCount: 2,
+					},
+				},
+			},
+		}, nil)
+	suite.NoError(suite.client.CronListAction())
+
+	suite.cronClient.EXPECT().
+		ListCronJobs(gomock.Any(), &cronsvc.ListCronJobsRequest{}).
+		Return(&cronsvc.ListCronJobsResponse{}, nil)
+	suite.NoError(suite.client.CronListAction())
+
+	suite.cronClient.EXPECT().
+		ListCronJobs(gomock.Any(), &cronsvc.ListCronJobsRequest{}).
+		Return(nil, yarpcerrors.InternalErrorf("test error"))
+	suite.Error(suite.client.CronListAction())
+}
+
+// TestCronDeleteAction tests deleting a cron job
+func (suite *cronActionsTestSuite) TestCronDeleteAction() {
+	suite.cronClient.EXPECT().
+		DeleteCronJob(gomock.Any(), &cronsvc.DeleteCronJobRequest{
+			Name: testCronJobName,
+		}).
+		Return(&cronsvc.DeleteCronJobResponse{}, nil)
+
+	suite.NoError(suite.client.CronDeleteAction(testCronJobName))
+}
+
+// TestCronStartAction tests starting a run of a cron job
+func (suite *cronActionsTestSuite) TestCronStartAction() {
+	suite.cronClient.EXPECT().
+		StartCronJob(gomock.Any(), &cronsvc.StartCronJobRequest{
+			Name: testCronJobName,
+		}).
+		Return(&cronsvc.StartCronJobResponse{
+			JobId: &v1alphapeloton.JobID{Value: uuid.New()},
+		}, nil)
+	suite.NoError(suite.client.CronStartAction(testCronJobName))
+
+	suite.cronClient.EXPECT().
+		StartCronJob(gomock.Any(), &cronsvc.StartCronJobRequest{
+			Name: testCronJobName,
+		}).
+		Return(&cronsvc.StartCronJobResponse{}, nil)
+	suite.NoError(suite.client.CronStartAction(testCronJobName))
+}
@@ -28,11 +28,13 @@ import (
 )
 
 const (
-	hostQueryFormatHeader = "Hostname\tIP\tState\n"
-	hostQueryFormatBody   = "%s\t%s\t%s\n"
-	hostSeparator         = ","
-	getHostsFormatHeader  = "Hostname\tCPU\tGPU\tMEM\tDisk\tState\t Task Hold\n"
-	getHostsFormatBody    = "%s\t%.2f\t%.2f\t%.2f MB\t%.2f MB\t%s\t%s\n"
+	hostQueryFormatHeader         = "Hostname\tIP\tState\tDrain Blocked Reason\n"
+	hostQueryFormatBody           = "%s\t%s\t%s\t%s\n"
+	hostSeparator                 = ","
+	getHostsFormatHeader          = "Hostname\tCPU\tGPU\tMEM\tDisk\tState\t Task Hold\n"
+	getHostsFormatBody            = "%s\t%.2f\t%.2f\t%.2f MB\t%.2f MB\t%s\t%s\n"
+	maintenanceWindowFormatHeader = "ID\tHosts\tStart\tEnd\tState\tReason\n"
+	maintenanceWindowFormatBody   = "%s\t%s\t%s\t%s\t%s\t%s\n"
 )
 
 // HostMaintenanceStartAction is the action for starting host maintenance. StartMaintenance puts the host(s)
@@ -82,6 +84,108 @@ func (c *Client) HostMaintenanceCompleteAction(hosts string) error {
 	return nil
 }
 
+// HostMaintenanceScheduleAction is the action for scheduling a maintenance
+// window on a list of hosts. The maintenance of the hosts is started at the
+// start time of the window and completed at its end time, the times are in
+// RFC3339 format and the end time is optional.
+func (c *Client) HostMaintenanceScheduleAction(
+	hosts string,
+	startTime string,
+	endTime string,
+	reason string) error {
+	hostnames, err := c.ExtractHostnames(hosts, hostSeparator)
+	if err != nil {
+		return err
+	}
+
+	request := &host_svc.ScheduleMaintenanceWindowRequest{
+		Window: &host.MaintenanceWindow{
+			Hostnames: hostnames,
+			StartTime: startTime,
+			EndTime:   endTime,
+			Reason:    reason,
+		},
+	}
+	response, err := c.hostClient.ScheduleMaintenanceWindow(c.ctx, request)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(tabWriter, "Scheduled maintenance window %s\n",
+		response.GetWindowId())
+	tabWriter.Flush()
+	return nil
+}
+
+// HostMaintenanceListAction is the action for listing the maintenance
+// windows in one of the given states, all the windows are listed when
+// no state is given.
+func (c *Client) HostMaintenanceListAction(states string) error {
+	var windowStates []host.MaintenanceWindowState
+	for _, state := range strings.Split(states, hostSeparator) {
+		if state != "" {
+			windowStates = append(
+				windowStates,
+				host.MaintenanceWindowState(
+					host.MaintenanceWindowState_value[state]))
+		}
+	}
+
+	request := &host_svc.ListMaintenanceWindowsRequest{
+		States: windowStates,
+	}
+	response, err := c.hostClient.ListMaintenanceWindows(c.ctx, request)
+	if err != nil {
+		return err
+	}
+
+	printMaintenanceWindows(response, c.Debug)
+	return nil
+}
+
+func printMaintenanceWindows(
+	r *host_svc.ListMaintenanceWindowsResponse,
+	debug bool) {
+	if debug {
+		printResponseJSON(r)
+	} else {
+		if len(r.GetWindows()) == 0 {
+			fmt.Fprintf(tabWriter, "No maintenance windows found\n")
+			return
+		}
+		fmt.Fprintf(tabWriter, maintenanceWindowFormatHeader)
+		for _, w := range r.GetWindows() {
+			fmt.Fprintf(
+				tabWriter,
+				maintenanceWindowFormatBody,
+				w.GetId(),
+				strings.Join(w.GetHostnames(), hostSeparator),
+				w.GetStartTime(),
+				w.GetEndTime(),
+				w.GetState(),
+				w.GetReason(),
+			)
+		}
+	}
+	tabWriter.Flush()
+}
+
+// HostMaintenanceCancelAction is the action for cancelling a maintenance
+// window which has not started yet.
+func (c *Client) HostMaintenanceCancelAction(windowID string) error {
+	request := &host_svc.CancelMaintenanceWindowRequest{
+		WindowId: windowID,
+	}
+	_, err := c.hostClient.CancelMaintenanceWindow(c.ctx, request)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(tabWriter, "Cancelled maintenance window %s\n", windowID)
+	tabWriter.Flush()
+	return nil
+}
+
 // HostQueryAction is the action for querying hosts by states. This can be to used to monitor the state of the host(s)
 // Eg. When a list of hosts are put into maintenance (`host maintenance start`).
 // A host, at any given time, will be in one of the following states
@@ -126,6 +230,7 @@ func printHostQueryResponse(r *host_svc.QueryHostsResponse, debug bool) {
 				h.GetHostname(),
 				h.GetIp(),
 				h.GetState(),
+				h.GetDrainBlockedReason(),
 			)
 		}
 	}
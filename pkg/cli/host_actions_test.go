@@ -128,6 +128,128 @@ func (suite *hostmgrActionsTestSuite) TestClientHostMaintenanceCompleteAction()
 	suite.Error(err)
 }
 
+func (suite *hostmgrActionsTestSuite) TestClientHostMaintenanceScheduleAction() {
+	c := Client{
+		Debug:      false,
+		hostClient: suite.mockHostmgr,
+		dispatcher: nil,
+		ctx:        suite.ctx,
+	}
+
+	suite.mockHostmgr.EXPECT().
+		ScheduleMaintenanceWindow(
+			gomock.Any(),
+			&hostsvc.ScheduleMaintenanceWindowRequest{
+				Window: &host.MaintenanceWindow{
+					Hostnames: []string{"hostname"},
+					StartTime: "2019-01-01T00:00:00Z",
+					EndTime:   "2019-01-01T04:00:00Z",
+					Reason:    "kernel upgrade",
+				},
+			}).
+		Return(&hostsvc.ScheduleMaintenanceWindowResponse{
+			WindowId: "window",
+		}, nil)
+	suite.NoError(c.HostMaintenanceScheduleAction(
+		"hostname",
+		"2019-01-01T00:00:00Z",
+		"2019-01-01T04:00:00Z",
+		"kernel upgrade"))
+
+	// Test ScheduleMaintenanceWindow error
+	suite.mockHostmgr.EXPECT().
+		ScheduleMaintenanceWindow(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("fake ScheduleMaintenanceWindow error"))
+	suite.Error(c.HostMaintenanceScheduleAction(
+		"hostname", "2019-01-01T00:00:00Z", "", ""))
+
+	// Test empty hostname error
+	suite.Error(c.HostMaintenanceScheduleAction(
+		"", "2019-01-01T00:00:00Z", "", ""))
+}
+
+func (suite *hostmgrActionsTestSuite) TestClientHostMaintenanceListAction() {
+	c := Client{
+		Debug:      false,
+		hostClient: suite.mockHostmgr,
+		dispatcher: nil,
+		ctx:        suite.ctx,
+	}
+
+	tt := []struct {
+		debug bool
+		resp  *hostsvc.ListMaintenanceWindowsResponse
+		err   error
+	}{
+		{
+			resp: &hostsvc.ListMaintenanceWindowsResponse{
+				Windows: []*host.MaintenanceWindow{
+					{
+						Id:        "window",
+						Hostnames: []string{"hostname"},
+						StartTime: "2019-01-01T00:00:00Z",
+						State:     host.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+					},
+				},
+			},
+		},
+		{
+			debug: true,
+			resp: &hostsvc.ListMaintenanceWindowsResponse{
+				Windows: make([]*host.MaintenanceWindow, 1),
+			},
+		},
+		{
+			resp: &hostsvc.ListMaintenanceWindowsResponse{},
+		},
+		{
+			err: fmt.Errorf("fake ListMaintenanceWindows error"),
+		},
+	}
+
+	for _, t := range tt {
+		c.Debug = t.debug
+		suite.mockHostmgr.EXPECT().
+			ListMaintenanceWindows(
+				gomock.Any(),
+				&hostsvc.ListMaintenanceWindowsRequest{
+					States: []host.MaintenanceWindowState{
+						host.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+					},
+				}).
+			Return(t.resp, t.err)
+		err := c.HostMaintenanceListAction(
+			"MAINTENANCE_WINDOW_STATE_SCHEDULED")
+		if t.err != nil {
+			suite.Error(err)
+		} else {
+			suite.NoError(err)
+		}
+	}
+}
+
+func (suite *hostmgrActionsTestSuite) TestClientHostMaintenanceCancelAction() {
+	c := Client{
+		Debug:      false,
+		hostClient: suite.mockHostmgr,
+		dispatcher: nil,
+		ctx:        suite.ctx,
+	}
+
+	suite.mockHostmgr.EXPECT().
+		CancelMaintenanceWindow(
+			gomock.Any(),
+			&hostsvc.CancelMaintenanceWindowRequest{WindowId: "window"}).
+		Return(&hostsvc.CancelMaintenanceWindowResponse{}, nil)
+	suite.NoError(c.HostMaintenanceCancelAction("window"))
+
+	// Test CancelMaintenanceWindow error
+	suite.mockHostmgr.EXPECT().
+		CancelMaintenanceWindow(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("fake CancelMaintenanceWindow error"))
+	suite.Error(c.HostMaintenanceCancelAction("window"))
+}
+
 func (suite *hostmgrActionsTestSuite) TestClientHostQueryAction() {
 	c := Client{
 		Debug:      false,
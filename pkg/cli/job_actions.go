@@ -28,6 +28,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/query"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 
+	"github.com/uber/peloton/pkg/archiver/sink"
 	"github.com/uber/peloton/pkg/common/stringset"
 	"github.com/uber/peloton/pkg/common/util"
 	jobmgrtask "github.com/uber/peloton/pkg/jobmgr/task"
@@ -137,6 +138,28 @@ func (c *Client) jobGet(jobID string) (*job.GetResponse, error) {
 	return c.jobClient.Get(c.ctx, request)
 }
 
+// JobGetArchivedAction is the action for getting a job archived by
+// archiver, along with its tasks, pod events and updates. The archive is
+// read from the local filesystem, so this only finds the job when run on
+// the host of archiver, or on a host where its archive dir is mounted.
+func (c *Client) JobGetArchivedAction(jobID string, archiveDir string) error {
+	bundle, err := sink.NewLocalSink(archiveDir).Read(
+		c.ctx,
+		&peloton.JobID{Value: jobID},
+	)
+	if err != nil {
+		if yarpcerrors.IsNotFound(err) {
+			return fmt.Errorf("%s in %s, the archive is only on the host "+
+				"of archiver", err.Error(), archiveDir)
+		}
+		return err
+	}
+
+	printResponseJSON(bundle)
+	tabWriter.Flush()
+	return nil
+}
+
 // JobGetCacheAction is the action for getting a job cache
 func (c *Client) JobGetCacheAction(jobID string) error {
 	r, err := c.jobClient.GetCache(c.ctx, &job.GetCacheRequest{
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	taskmocks "github.com/uber/peloton/.gen/peloton/api/v0/task/mocks"
 
+	"github.com/uber/peloton/pkg/archiver/sink"
 	jobmgrtask "github.com/uber/peloton/pkg/jobmgr/task"
 
 	"github.com/golang/mock/gomock"
@@ -986,6 +988,28 @@ func (suite *jobActionsTestSuite) TestClientJobStatusAction() {
 	}
 }
 
+// TestClientJobGetArchivedAction tests getting a job from the archive
+func (suite *jobActionsTestSuite) TestClientJobGetArchivedAction() {
+	dir, err := ioutil.TempDir("", "archive")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// job is not archived, or not on this host
+	err = suite.client.JobGetArchivedAction(testJobID, dir)
+	suite.Error(err)
+	suite.Contains(err.Error(), "host of archiver")
+
+	suite.NoError(sink.NewLocalSink(dir).Write(
+		context.Background(),
+		&sink.JobBundle{
+			JobInfo: &job.JobInfo{
+				Id: &peloton.JobID{Value: testJobID},
+			},
+		},
+	))
+	suite.NoError(suite.client.JobGetArchivedAction(testJobID, dir))
+}
+
 // TestClientJobGetCacheAction tests fetching job in cache
 func (suite *jobActionsTestSuite) TestClientJobGetCacheAction() {
 	tt := []struct {
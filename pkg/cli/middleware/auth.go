@@ -16,23 +16,42 @@ package middleware
 
 import (
 	"context"
+	"io/ioutil"
+	"strings"
 
 	"go.uber.org/yarpc/api/middleware"
 	"go.uber.org/yarpc/api/transport"
 )
 
 const (
-	_usernameHeader = "username"
-	_passwordHeader = "password"
+	_usernameHeader      = "username"
+	_passwordHeader      = "password"
+	_authorizationHeader = "authorization"
+	_bearerPrefix        = "Bearer "
 )
 
-type outboundMiddleware interface {
+// OutboundMiddleware is the middleware applied to all
+// outbound requests of the CLI
+type OutboundMiddleware interface {
 	middleware.UnaryOutbound
 	middleware.OnewayOutbound
 	middleware.StreamOutbound
 }
 
-var _ outboundMiddleware = &BasicAuthOutboundMiddleware{}
+var _ OutboundMiddleware = &BasicAuthOutboundMiddleware{}
+var _ OutboundMiddleware = &TokenAuthOutboundMiddleware{}
+
+// NewAuthOutboundMiddleware creates the auth middleware for the CLI,
+// token auth is used if a token is provided and basic auth otherwise
+func NewAuthOutboundMiddleware(
+	basicConfig *BasicAuthConfig,
+	tokenConfig *TokenAuthConfig,
+) OutboundMiddleware {
+	if tokenConfig != nil && len(tokenConfig.Token) != 0 {
+		return NewTokenAuthOutboundMiddleware(tokenConfig)
+	}
+	return NewBasicAuthOutboundMiddleware(basicConfig)
+}
 
 // BasicAuthConfig is the config for basic auth
 type BasicAuthConfig struct {
@@ -85,3 +104,62 @@ func (m *BasicAuthOutboundMiddleware) addAuthToHeader(headers transport.Headers)
 
 	return headers
 }
+
+// TokenAuthConfig is the config for token auth
+type TokenAuthConfig struct {
+	// Token is the signed token issued for the caller
+	Token string
+}
+
+// LoadTokenAuthConfig reads the token from a file,
+// surrounding whitespace is ignored
+func LoadTokenAuthConfig(path string) (*TokenAuthConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenAuthConfig{Token: strings.TrimSpace(string(data))}, nil
+}
+
+// TokenAuthOutboundMiddleware provides token auth
+// support for all outbound requests
+type TokenAuthOutboundMiddleware struct {
+	config *TokenAuthConfig
+}
+
+// NewTokenAuthOutboundMiddleware creates TokenAuthOutboundMiddleware
+func NewTokenAuthOutboundMiddleware(config *TokenAuthConfig) *TokenAuthOutboundMiddleware {
+	if config != nil && len(config.Token) == 0 {
+		config = nil
+	}
+
+	return &TokenAuthOutboundMiddleware{
+		config: config,
+	}
+}
+
+// Call adds auth info to yarpc request header and relay the request
+func (m *TokenAuthOutboundMiddleware) Call(ctx context.Context, request *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	request.Headers = m.addAuthToHeader(request.Headers)
+	return out.Call(ctx, request)
+}
+
+// CallOneway adds auth info to yarpc request header and relay the request
+func (m *TokenAuthOutboundMiddleware) CallOneway(ctx context.Context, request *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	request.Headers = m.addAuthToHeader(request.Headers)
+	return out.CallOneway(ctx, request)
+}
+
+// CallStream adds auth info to yarpc request header and relay the request
+func (m *TokenAuthOutboundMiddleware) CallStream(ctx context.Context, request *transport.StreamRequest, out transport.StreamOutbound) (*transport.ClientStream, error) {
+	request.Meta.Headers = m.addAuthToHeader(request.Meta.Headers)
+	return out.CallStream(ctx, request)
+}
+
+func (m *TokenAuthOutboundMiddleware) addAuthToHeader(headers transport.Headers) transport.Headers {
+	if m.config == nil {
+		return headers
+	}
+
+	return headers.With(_authorizationHeader, _bearerPrefix+m.config.Token)
+}
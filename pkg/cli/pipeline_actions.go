@@ -0,0 +1,163 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+	pipelinesvc "github.com/uber/peloton/.gen/peloton/api/v0/pipeline/svc"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	pipelineListFormatHeader = "ID\tName\tState\tCreation Time\tRunning\t" +
+		"Succeeded\tFailed\tTotal\t\n"
+	pipelineListFormatBody = "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t\n"
+
+	pipelineStatePrefix = "PIPELINE_STATE_"
+)
+
+// PipelineCreateAction is the action for creating a pipeline from the
+// spec in cfg, the batch jobs of the pipeline are created in the given
+// resource pool
+func (c *Client) PipelineCreateAction(respoolPath string, cfg string) error {
+	respoolID, err := c.LookupResourcePoolID(respoolPath)
+	if err != nil {
+		return err
+	}
+	if respoolID == nil {
+		return fmt.Errorf("unable to find resource pool ID for "+
+			":%s", respoolPath)
+	}
+
+	var spec pipeline.PipelineSpec
+	buffer, err := ioutil.ReadFile(cfg)
+	if err != nil {
+		return fmt.Errorf("unable to open file %s: %v", cfg, err)
+	}
+	if err := yaml.Unmarshal(buffer, &spec); err != nil {
+		return fmt.Errorf("unable to parse file %s: %v", cfg, err)
+	}
+	for _, node := range spec.GetNodes() {
+		if node.GetConfig() != nil {
+			node.Config.RespoolID = respoolID
+		}
+	}
+
+	resp, err := c.pipelineClient.CreatePipeline(
+		c.ctx,
+		&pipelinesvc.CreatePipelineRequest{Spec: &spec},
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pipeline %s created\n", resp.GetPipelineId().GetValue())
+	return nil
+}
+
+// PipelineGetAction is the action for getting the spec and the status
+// of a pipeline
+func (c *Client) PipelineGetAction(id string) error {
+	resp, err := c.pipelineClient.GetPipeline(
+		c.ctx,
+		&pipelinesvc.GetPipelineRequest{
+			PipelineId: &peloton.PipelineID{Value: id},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	out, err := marshallResponse(defaultResponseFormat, resp)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%v\n", string(out))
+
+	return nil
+}
+
+// PipelineListAction is the action for listing the pipelines which have
+// not terminated yet
+func (c *Client) PipelineListAction() error {
+	defer tabWriter.Flush()
+
+	resp, err := c.pipelineClient.ListPipelines(
+		c.ctx,
+		&pipelinesvc.ListPipelinesRequest{},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.GetPipelines()) == 0 {
+		fmt.Fprintf(tabWriter, "No pipelines found\n")
+		return nil
+	}
+
+	fmt.Fprint(tabWriter, pipelineListFormatHeader)
+	for _, p := range resp.GetPipelines() {
+		var running, succeeded, failed int
+		for _, n := range p.GetStatus().GetNodes() {
+			switch n.GetState() {
+			case pipeline.NodeState_NODE_STATE_RUNNING:
+				running++
+			case pipeline.NodeState_NODE_STATE_SUCCEEDED:
+				succeeded++
+			case pipeline.NodeState_NODE_STATE_FAILED:
+				failed++
+			}
+		}
+
+		fmt.Fprintf(
+			tabWriter,
+			pipelineListFormatBody,
+			p.GetId().GetValue(),
+			p.GetSpec().GetName(),
+			strings.TrimPrefix(
+				p.GetStatus().GetState().String(),
+				pipelineStatePrefix),
+			p.GetStatus().GetCreationTime(),
+			running,
+			succeeded,
+			failed,
+			len(p.GetStatus().GetNodes()),
+		)
+	}
+	return nil
+}
+
+// PipelineKillAction is the action for killing a pipeline, the batch
+// jobs which have not been started yet are never started
+func (c *Client) PipelineKillAction(id string) error {
+	_, err := c.pipelineClient.KillPipeline(
+		c.ctx,
+		&pipelinesvc.KillPipelineRequest{
+			PipelineId: &peloton.PipelineID{Value: id},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pipeline %s killed\n", id)
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+	pipelinesvc "github.com/uber/peloton/.gen/peloton/api/v0/pipeline/svc"
+	pipelinemocks "github.com/uber/peloton/.gen/peloton/api/v0/pipeline/svc/mocks"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	respoolmocks "github.com/uber/peloton/.gen/peloton/api/v0/respool/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+	"gopkg.in/yaml.v2"
+)
+
+const testPipelineSpec = "../../example/pipeline/testpipeline.yaml"
+
+type pipelineActionsTestSuite struct {
+	suite.Suite
+	ctx        context.Context
+	client     Client
+	respoolID  string
+	pipelineID *peloton.PipelineID
+
+	ctrl           *gomock.Controller
+	pipelineClient *pipelinemocks.MockPipelineServiceYARPCClient
+	resClient      *respoolmocks.MockResourceManagerYARPCClient
+}
+
+func (suite *pipelineActionsTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.pipelineClient = pipelinemocks.NewMockPipelineServiceYARPCClient(suite.ctrl)
+	suite.resClient = respoolmocks.NewMockResourceManagerYARPCClient(suite.ctrl)
+	suite.ctx = context.Background()
+	suite.respoolID = uuid.New()
+	suite.pipelineID = &peloton.PipelineID{Value: uuid.New()}
+	suite.client = Client{
+		Debug:          false,
+		pipelineClient: suite.pipelineClient,
+		resClient:      suite.resClient,
+		dispatcher:     nil,
+		ctx:            suite.ctx,
+	}
+}
+
+func (suite *pipelineActionsTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestPipelineActions(t *testing.T) {
+	suite.Run(t, new(pipelineActionsTestSuite))
+}
+
+func (suite *pipelineActionsTestSuite) getSpec() *pipeline.PipelineSpec {
+	var spec pipeline.PipelineSpec
+	buffer, err := ioutil.ReadFile(testPipelineSpec)
+	suite.NoError(err)
+	suite.NoError(yaml.Unmarshal(buffer, &spec))
+	for _, node := range spec.GetNodes() {
+		node.Config.RespoolID = &peloton.ResourcePoolID{Value: suite.respoolID}
+	}
+	return &spec
+}
+
+// TestPipelineCreateAction tests creating a pipeline
+func (suite *pipelineActionsTestSuite) TestPipelineCreateAction() {
+	suite.resClient.EXPECT().
+		LookupResourcePoolID(gomock.Any(), &respool.LookupRequest{
+			Path: &respool.ResourcePoolPath{Value: testRespoolPath},
+		}).
+		Return(&respool.LookupResponse{
+			Id: &peloton.ResourcePoolID{Value: suite.respoolID},
+		}, nil)
+	suite.pipelineClient.EXPECT().
+		CreatePipeline(gomock.Any(), &pipelinesvc.CreatePipelineRequest{
+			Spec: suite.getSpec(),
+		}).
+		Return(&pipelinesvc.CreatePipelineResponse{
+			PipelineId: suite.pipelineID,
+		}, nil)
+
+	suite.NoError(suite.client.PipelineCreateAction(
+		testRespoolPath,
+		testPipelineSpec,
+	))
+}
+
+// TestPipelineCreateActionRespoolNotFound tests creating a pipeline in a
+// resource pool which does not exist
+func (suite *pipelineActionsTestSuite) TestPipelineCreateActionRespoolNotFound() {
+	suite.resClient.EXPECT().
+		LookupResourcePoolID(gomock.Any(), gomock.Any()).
+		Return(&respool.LookupResponse{}, nil)
+
+	suite.Error(suite.client.PipelineCreateAction(
+		testRespoolPath,
+		testPipelineSpec,
+	))
+}
+
+// TestPipelineGetAction tests getting a pipeline
+func (suite *pipelineActionsTestSuite) TestPipelineGetAction() {
+	suite.pipelineClient.EXPECT().
+		GetPipeline(gomock.Any(), &pipelinesvc.GetPipelineRequest{
+			PipelineId: suite.pipelineID,
+		}).
+		Return(&pipelinesvc.GetPipelineResponse{
+			Pipeline: &pipeline.PipelineInfo{
+				Id:   suite.pipelineID,
+				Spec: suite.getSpec(),
+			},
+		}, nil)
+	suite.NoError(suite.client.PipelineGetAction(suite.pipelineID.GetValue()))
+
+	suite.pipelineClient.EXPECT().
+		GetPipeline(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.NotFoundErrorf("pipeline not found"))
+	suite.Error(suite.client.PipelineGetAction(suite.pipelineID.GetValue()))
+}
+
+// TestPipelineListAction tests listing the active pipelines
+func (suite *pipelineActionsTestSuite) TestPipelineListAction() {
+	suite.pipelineClient.EXPECT().
+		ListPipelines(gomock.Any(), gomock.Any()).
+		Return(&pipelinesvc.ListPipelinesResponse{
+			Pipelines: []*pipeline.PipelineInfo{
+				{
+					Id:   suite.pipelineID,
+					Spec: suite.getSpec(),
+					Status: &pipeline.PipelineStatus{
+						State: pipeline.PipelineState_PIPELINE_STATE_RUNNING,
+						Nodes: []*pipeline.NodeStatus{
+							{
+								Name:  "extract",
+								State: pipeline.NodeState_NODE_STATE_SUCCEEDED,
+							},
+							{
+								Name:  "transform",
+								State: pipeline.NodeState_NODE_STATE_RUNNING,
+							},
+						},
+					},
+				},
+			},
+		}, nil)
+	suite.NoError(suite.client.PipelineListAction())
+
+	suite.pipelineClient.EXPECT().
+		ListPipelines(gomock.Any(), gomock.Any()).
+		Return(&pipelinesvc.ListPipelinesResponse{}, nil)
+	suite.NoError(suite.client.PipelineListAction())
+
+	suite.pipelineClient.EXPECT().
+		ListPipelines(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.InternalErrorf("test error"))
+	suite.Error(suite.client.PipelineListAction())
+}
+
+// TestPipelineKillAction tests killing a pipeline
+func (suite *pipelineActionsTestSuite) TestPipelineKillAction() {
+	suite.pipelineClient.EXPECT().
+		KillPipeline(gomock.Any(), &pipelinesvc.KillPipelineRequest{
+			PipelineId: suite.pipelineID,
+		}).
+		Return(&pipelinesvc.KillPipelineResponse{}, nil)
+	suite.NoError(suite.client.PipelineKillAction(suite.pipelineID.GetValue()))
+
+	suite.pipelineClient.EXPECT().
+		KillPipeline(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.NotFoundErrorf("pipeline not found"))
+	suite.Error(suite.client.PipelineKillAction(suite.pipelineID.GetValue()))
+}
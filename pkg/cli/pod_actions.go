@@ -50,6 +50,12 @@ func (c *Client) PodGetCacheAction(podName string) error {
 	return nil
 }
 
+// PodWhyAction is the action to show why a pod is not running yet
+func (c *Client) PodWhyAction(podName string) error {
+	// the pod name is the same as the peloton task id in resource manager
+	return c.getPendingReason(podName)
+}
+
 // PodGetEventsV1AlphaAction is the action to get the events of a given pod
 func (c *Client) PodGetEventsV1AlphaAction(podName string, podID string) error {
 	var request = &podsvc.GetPodEventsRequest{
@@ -25,6 +25,8 @@ import (
 const (
 	activeTaskListFormatHeader = "TaskID\tState\tHostname\tReason\tLast Update Time\n"
 	activeTaskListFormatBody   = "%s\t%s\t%s\t%s\t%s\n"
+
+	pendingReasonFormat = "%s:\t%s\n"
 )
 
 // ResMgrGetActiveTasks fetches the active tasks from resource manager.
@@ -64,6 +66,20 @@ func (c *Client) ResMgrGetPendingTasks(respoolID string, limit uint32) error {
 	return nil
 }
 
+// getPendingReason fetches from resource manager the reasons why the task
+// is not running yet.
+func (c *Client) getPendingReason(taskID string) error {
+	var request = &resmgrsvc.GetPendingReasonsRequest{
+		Tasks: []*peloton.TaskID{{Value: taskID}},
+	}
+	resp, err := c.resMgrClient.GetPendingReasons(c.ctx, request)
+	if err != nil {
+		return err
+	}
+	printPendingReasonsResponse(resp, c.Debug)
+	return nil
+}
+
 func printActiveTasksResponse(r *resmgrsvc.GetActiveTasksResponse, debug bool) {
 	if debug {
 		printResponseJSON(r)
@@ -103,3 +119,41 @@ func printPendingTasksResponse(r *resmgrsvc.GetPendingTasksResponse, debug bool)
 	}
 	tabWriter.Flush()
 }
+
+func printPendingReasonsResponse(
+	r *resmgrsvc.GetPendingReasonsResponse,
+	debug bool) {
+	if debug {
+		printResponseJSON(r)
+		return
+	}
+
+	for _, e := range r.GetError() {
+		fmt.Fprintf(
+			tabWriter,
+			"Task %s: %s\n",
+			e.GetNotFound().GetTask().GetValue(),
+			e.GetNotFound().GetMessage(),
+		)
+	}
+	for _, reason := range r.GetReasons() {
+		fmt.Fprintf(tabWriter, pendingReasonFormat,
+			"Task", reason.GetTask().GetValue())
+		fmt.Fprintf(tabWriter, pendingReasonFormat,
+			"State", reason.GetState().String())
+		fmt.Fprintf(tabWriter, pendingReasonFormat,
+			"State Reason", reason.GetStateReason())
+		if reason.GetAdmissionReason() != "" {
+			fmt.Fprintf(tabWriter, pendingReasonFormat,
+				"Admission Rejection", reason.GetAdmissionReason())
+		}
+		if reason.GetPlacementReason() != "" {
+			fmt.Fprintf(tabWriter, pendingReasonFormat,
+				"Last Placement Failure", reason.GetPlacementReason())
+			fmt.Fprintf(tabWriter, pendingReasonFormat,
+				"Last Placement Failure Time",
+				reason.GetLastPlacementFailureTime())
+		}
+	}
+	tabWriter.Flush()
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 	res_mocks "github.com/uber/peloton/.gen/peloton/private/resmgrsvc/mocks"
@@ -169,3 +170,54 @@ func (suite *resmgrActionsTestSuite) TestClientGetPendingTasks() {
 	err = c.ResMgrGetPendingTasks("respool-1", 10)
 	suite.NoError(err)
 }
+
+func (suite *resmgrActionsTestSuite) TestClientWhyActions() {
+	c := Client{
+		Debug:        false,
+		resMgrClient: suite.mockRes,
+		dispatcher:   nil,
+		ctx:          suite.ctx,
+	}
+
+	taskID := &peloton.TaskID{Value: "job-1-0"}
+	resp := &resmgrsvc.GetPendingReasonsResponse{
+		Error: []*resmgrsvc.GetPendingReasonsResponse_Error{
+			{
+				NotFound: &resmgrsvc.TasksNotFound{
+					Task:    &peloton.TaskID{Value: "job-2-0"},
+					Message: "Task Not Found",
+				},
+			},
+		},
+		Reasons: []*resmgrsvc.PendingReason{
+			{
+				Task:                     taskID,
+				State:                    task.TaskState_PENDING,
+				StateReason:              "enqueued",
+				AdmissionReason:          "entitlement exceeded",
+				PlacementReason:          "no offers from the cluster",
+				LastPlacementFailureTime: "2019-01-01T00:00:00Z",
+			},
+		},
+	}
+
+	suite.mockRes.EXPECT().
+		GetPendingReasons(gomock.Any(), &resmgrsvc.GetPendingReasonsRequest{
+			Tasks: []*peloton.TaskID{taskID},
+		}).
+		Return(resp, nil).
+		Times(2)
+	suite.NoError(c.TaskWhyAction("job-1", 0))
+	suite.NoError(c.PodWhyAction("job-1-0"))
+
+	c.Debug = true
+	suite.mockRes.EXPECT().
+		GetPendingReasons(gomock.Any(), gomock.Any()).
+		Return(resp, nil)
+	suite.NoError(c.PodWhyAction("job-1-0"))
+
+	suite.mockRes.EXPECT().
+		GetPendingReasons(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("fake res error"))
+	suite.Error(c.TaskWhyAction("job-1", 0))
+}
@@ -47,8 +47,8 @@ const (
 	statelessUpdateEventsFormatHeader = "Type\tTimestamp\tState\t\n"
 	statelessUpdateEventsFormatBody   = "%s\t%s\t%s\t\n"
 
-	workflowEventsV1AlphaFormatHeader = "Workflow State\tWorkflow Type\tTimestamp\n"
-	workflowEventsV1AlphaFormatBody   = "%s\t%s\t%s\n"
+	workflowEventsV1AlphaFormatHeader = "Workflow State\tWorkflow Type\tTimestamp\tMessage\n"
+	workflowEventsV1AlphaFormatBody   = "%s\t%s\t%s\t%s\n"
 
 	queryPodsFormatHeader = "Pod ID\tName\tState\tContainer Name\tContainer State\tHealthy\tStart Time\tRun Time\t" +
 		"Host\tMessage\tReason\tTermination Status\t\n"
@@ -1011,6 +1011,7 @@ func printWorkflowEventsV1AlphaResponse(r *statelesssvc.GetWorkflowEventsRespons
 			event.GetState(),
 			event.GetType(),
 			event.GetTimestamp(),
+			event.GetMessage(),
 		)
 	}
 }
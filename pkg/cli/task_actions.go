@@ -26,6 +26,8 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/query"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+
+	"github.com/uber/peloton/pkg/common/util"
 )
 
 const (
@@ -59,6 +61,11 @@ func (c *Client) TaskGetAction(jobID string, instanceID uint32) error {
 	return nil
 }
 
+// TaskWhyAction is the action to show why a task instance is not running yet
+func (c *Client) TaskWhyAction(jobID string, instanceID uint32) error {
+	return c.getPendingReason(util.CreatePelotonTaskID(jobID, instanceID))
+}
+
 // TaskGetCacheAction is the acion to get a task cache
 func (c *Client) TaskGetCacheAction(jobID string, instanceID uint32) error {
 	requst := &task.GetCacheRequest{
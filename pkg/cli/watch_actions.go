@@ -58,8 +58,14 @@ func (c *Client) WatchHostManagerEvents() error {
 }
 
 // WatchPod is the action for starting a watch stream for pod, specified
-// by job id and pod names.
-func (c *Client) WatchPod(jobID string, podNames []string, labels []string) error {
+// by job id and pod names. The changes since the start revision are
+// streamed first if it is set.
+func (c *Client) WatchPod(
+	jobID string,
+	podNames []string,
+	labels []string,
+	startRevision uint64,
+) error {
 	var j *peloton.JobID
 	if jobID != "" {
 		j = &peloton.JobID{
@@ -89,6 +95,7 @@ func (c *Client) WatchPod(jobID string, podNames []string, labels []string) erro
 	stream, err := c.watchClient.Watch(
 		c.ctx,
 		&watchsvc.WatchRequest{
+			StartRevision: startRevision,
 			PodFilter: &watch.PodFilter{
 				JobId:    j,
 				PodNames: ps,
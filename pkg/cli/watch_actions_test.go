@@ -81,6 +81,9 @@ func (suite *watchActionsTestSuite) TestWatchPod() {
 
 	suite.watchClient.EXPECT().
 		Watch(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *watchsvc.WatchRequest) {
+			suite.Equal(uint64(10), req.GetStartRevision())
+		}).
 		Return(stream, nil)
 
 	var calls []*gomock.Call
@@ -91,7 +94,7 @@ func (suite *watchActionsTestSuite) TestWatchPod() {
 
 	gomock.InOrder(calls...)
 
-	suite.NoError(suite.client.WatchPod(jobID, podNames, labels))
+	suite.NoError(suite.client.WatchPod(jobID, podNames, labels, 10))
 }
 
 func (suite *watchActionsTestSuite) TestWatchPodLabelError() {
@@ -102,7 +105,7 @@ func (suite *watchActionsTestSuite) TestWatchPodLabelError() {
 	label1 := "key1:value1:value2"
 	labels = append(labels, label1)
 
-	suite.Error(suite.client.WatchPod(jobID, podNames, labels))
+	suite.Error(suite.client.WatchPod(jobID, podNames, labels, 0))
 }
 
 func (suite *watchActionsTestSuite) TestCancelWatch() {
@@ -0,0 +1,103 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+type targetsContextKey struct{}
+
+// Targets are the identifiers of the entities targeted by a call to
+// a mutating procedure, they are added by the handler of the procedure
+// and recorded in the audit log once the call completes.
+type Targets struct {
+	sync.Mutex
+
+	jobIDs     []string
+	podNames   []string
+	respoolIDs []string
+}
+
+// NewContext returns a copy of ctx which collects the
+// targets added by the handler of the call
+func NewContext(ctx context.Context) (context.Context, *Targets) {
+	t := &Targets{}
+	return context.WithValue(ctx, targetsContextKey{}, t), t
+}
+
+// AddJobID adds a job targeted by the call, it is a no-op
+// if the call is not audited
+func AddJobID(ctx context.Context, id string) {
+	if t, ok := ctx.Value(targetsContextKey{}).(*Targets); ok {
+		t.add(&t.jobIDs, id)
+	}
+}
+
+// AddPodName adds a pod targeted by the call, it is a no-op
+// if the call is not audited
+func AddPodName(ctx context.Context, name string) {
+	if t, ok := ctx.Value(targetsContextKey{}).(*Targets); ok {
+		t.add(&t.podNames, name)
+	}
+}
+
+// AddRespoolID adds a resource pool targeted by the call, it is
+// a no-op if the call is not audited
+func AddRespoolID(ctx context.Context, id string) {
+	if t, ok := ctx.Value(targetsContextKey{}).(*Targets); ok {
+		t.add(&t.respoolIDs, id)
+	}
+}
+
+// JobIDs returns the jobs targeted by the call
+func (t *Targets) JobIDs() []string {
+	return t.get(&t.jobIDs)
+}
+
+// PodNames returns the pods targeted by the call
+func (t *Targets) PodNames() []string {
+	return t.get(&t.podNames)
+}
+
+// RespoolIDs returns the resource pools targeted by the call
+func (t *Targets) RespoolIDs() []string {
+	return t.get(&t.respoolIDs)
+}
+
+// add appends the id to ids unless it is empty or already present
+func (t *Targets) add(ids *[]string, id string) {
+	if len(id) == 0 {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	for _, existing := range *ids {
+		if existing == id {
+			return
+		}
+	}
+	*ids = append(*ids, id)
+}
+
+func (t *Targets) get(ids *[]string) []string {
+	t.Lock()
+	defer t.Unlock()
+
+	return append([]string(nil), *ids...)
+}
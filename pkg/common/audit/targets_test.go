@@ -0,0 +1,45 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddTargets(t *testing.T) {
+	ctx, targets := NewContext(context.Background())
+
+	AddJobID(ctx, "job1")
+	AddJobID(ctx, "job2")
+	AddJobID(ctx, "job1")
+	AddJobID(ctx, "")
+	AddPodName(ctx, "job1-0")
+	AddRespoolID(ctx, "respool1")
+
+	assert.Equal(t, []string{"job1", "job2"}, targets.JobIDs())
+	assert.Equal(t, []string{"job1-0"}, targets.PodNames())
+	assert.Equal(t, []string{"respool1"}, targets.RespoolIDs())
+}
+
+func TestAddTargetsNotAudited(t *testing.T) {
+	// adding targets to a call which is not audited is a no-op
+	ctx := context.Background()
+	AddJobID(ctx, "job1")
+	AddPodName(ctx, "job1-0")
+	AddRespoolID(ctx, "respool1")
+}
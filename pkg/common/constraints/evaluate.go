@@ -85,6 +85,9 @@ func (e evaluator) Evaluate(
 	case task.Constraint_OR_CONSTRAINT:
 		return e.evaluateOrConstraint(
 			constraint.GetOrConstraint(), labelValues)
+	case task.Constraint_NOT_CONSTRAINT:
+		return e.evaluateNotConstraint(
+			constraint.GetNotConstraint(), labelValues)
 	case task.Constraint_LABEL_CONSTRAINT:
 		return e.evaluateLabelConstraint(
 			constraint.GetLabelConstraint(), labelValues)
@@ -139,6 +142,27 @@ func (e evaluator) evaluateOrConstraint(
 	return result, nil
 }
 
+func (e evaluator) evaluateNotConstraint(
+	notConstraint *task.NotConstraint,
+	labelValues LabelValues,
+) (EvaluateResult, error) {
+
+	result, err := e.Evaluate(notConstraint.GetConstraint(), labelValues)
+	if err != nil {
+		return EvaluateResultNotApplicable, err
+	}
+
+	// A constraint which is not applicable stays not applicable when
+	// negated, so that it does not short-circuit any And/Or evaluation.
+	switch result {
+	case EvaluateResultMatch:
+		return EvaluateResultMismatch, nil
+	case EvaluateResultMismatch:
+		return EvaluateResultMatch, nil
+	}
+	return EvaluateResultNotApplicable, nil
+}
+
 func (e evaluator) evaluateLabelConstraint(
 	labelConstraint *task.LabelConstraint,
 	labelValues LabelValues,
@@ -191,6 +215,9 @@ func IsNonExclusiveConstraint(constraint *task.Constraint) bool {
 		toEval = constraint.GetAndConstraint().GetConstraints()
 	case task.Constraint_OR_CONSTRAINT:
 		toEval = constraint.GetOrConstraint().GetConstraints()
+	case task.Constraint_NOT_CONSTRAINT:
+		// A negated constraint can never require an exclusive host.
+		return true
 	case task.Constraint_LABEL_CONSTRAINT:
 		lc := constraint.GetLabelConstraint()
 		if lc.GetKind() == task.LabelConstraint_HOST &&
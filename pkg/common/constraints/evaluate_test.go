@@ -260,6 +260,43 @@ func (suite *EvaluatorTestSuite) TestKindEvaluator() {
 			},
 		},
 	}
+
+	notMatch := testCase{
+		expected:    EvaluateResultMatch,
+		msg:         "NotConstraint of mismatch expects match",
+		labelValues: hostLabels2,
+		constraint: &task.Constraint{
+			Type: task.Constraint_NOT_CONSTRAINT,
+			NotConstraint: &task.NotConstraint{
+				Constraint: hostAffinityMatch.constraint,
+			},
+		},
+	}
+
+	notMismatch := testCase{
+		expected:    EvaluateResultMismatch,
+		msg:         "NotConstraint of match expects mismatch",
+		labelValues: hostLabels1,
+		constraint: &task.Constraint{
+			Type: task.Constraint_NOT_CONSTRAINT,
+			NotConstraint: &task.NotConstraint{
+				Constraint: hostAffinityMatch.constraint,
+			},
+		},
+	}
+
+	notNotApplicable := testCase{
+		expected:    EvaluateResultNotApplicable,
+		msg:         "NotConstraint not applicable",
+		labelValues: hostLabels1,
+		constraint: &task.Constraint{
+			Type: task.Constraint_NOT_CONSTRAINT,
+			NotConstraint: &task.NotConstraint{
+				Constraint: kindMismatch.constraint,
+			},
+		},
+	}
+
 	unknownConditionEnum := testCase{
 		expected:    EvaluateResultNotApplicable,
 		expectedErr: ErrUnknownLabelCondition,
@@ -299,6 +336,9 @@ func (suite *EvaluatorTestSuite) TestKindEvaluator() {
 		orMatch,
 		orMismatch,
 		orNotApplicable,
+		notMatch,
+		notMismatch,
+		notNotApplicable,
 		unknownConditionEnum,
 		unknownConstraintTypeEnum,
 	}
@@ -415,6 +455,16 @@ func (suite *EvaluatorTestSuite) TestIsNonExclusiveConstraint() {
 			},
 			expected: true,
 		},
+		{
+			msg: "Not constraint with exclusive",
+			constraint: &task.Constraint{
+				Type: task.Constraint_NOT_CONSTRAINT,
+				NotConstraint: &task.NotConstraint{
+					Constraint: labelExcl,
+				},
+			},
+			expected: true,
+		},
 	}
 
 	for _, tc := range testTable {
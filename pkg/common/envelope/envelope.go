@@ -0,0 +1,226 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package envelope implements envelope encryption of data at rest. Each
+// piece of data is encrypted with a data key of its own, and the data key
+// is stored alongside the data after being wrapped (encrypted) by a key
+// encryption key held by a KeyProvider. Rotating the key encryption key
+// only requires re-wrapping the data keys, the data is left untouched.
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// prefix marks encrypted data, so that it can be told apart from
+	// data stored before encryption was enabled
+	prefix = "envelope:v1:"
+
+	// dataKeySize is the size of the AES-256 data keys
+	dataKeySize = 32
+)
+
+var errMalformed = errors.New("malformed envelope")
+
+// KeyProvider wraps and unwraps data keys with key encryption keys which
+// never leave the provider, such as keys held by a KMS.
+type KeyProvider interface {
+	// CurrentKeyID returns the id of the key used to wrap new data keys
+	CurrentKeyID() string
+
+	// WrapKey wraps a data key with the current key encryption key and
+	// returns the id of that key along with the wrapped data key
+	WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error)
+
+	// UnwrapKey unwraps a data key wrapped by the key with the given id
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// envelope is the decoded form of encrypted data
+type envelope struct {
+	// id of the key encryption key which wrapped the data key
+	keyID string
+	// the wrapped data key
+	wrappedKey []byte
+	// the data encrypted with the data key, prefixed by the nonce
+	ciphertext []byte
+}
+
+func (e *envelope) String() string {
+	return prefix + strings.Join([]string{
+		e.keyID,
+		base64.StdEncoding.EncodeToString(e.wrappedKey),
+		base64.StdEncoding.EncodeToString(e.ciphertext),
+	}, ":")
+}
+
+func parseEnvelope(data string) (*envelope, error) {
+	parts := strings.Split(strings.TrimPrefix(data, prefix), ":")
+	if len(parts) != 3 {
+		return nil, errMalformed
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errMalformed
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformed
+	}
+	return &envelope{
+		keyID:      parts[0],
+		wrappedKey: wrappedKey,
+		ciphertext: ciphertext,
+	}, nil
+}
+
+// IsEncrypted returns true if the data was encrypted by Encrypt
+func IsEncrypted(data string) bool {
+	return strings.HasPrefix(data, prefix)
+}
+
+// Encrypt encrypts the plaintext with a new data key wrapped by the
+// provider. The additional data is authenticated but not encrypted, and
+// must be given again to decrypt, which binds the ciphertext to e.g. the
+// id of the row it is stored in.
+func Encrypt(
+	ctx context.Context,
+	provider KeyProvider,
+	plaintext, additionalData []byte,
+) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, plaintext, additionalData)
+	if err != nil {
+		return "", err
+	}
+	keyID, wrappedKey, err := provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", err
+	}
+	return (&envelope{
+		keyID:      keyID,
+		wrappedKey: wrappedKey,
+		ciphertext: ciphertext,
+	}).String(), nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt. Data which is not encrypted
+// is returned as is.
+func Decrypt(
+	ctx context.Context,
+	provider KeyProvider,
+	data string,
+	additionalData []byte,
+) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return []byte(data), nil
+	}
+	e, err := parseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := provider.UnwrapKey(ctx, e.keyID, e.wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(dataKey, e.ciphertext, additionalData)
+}
+
+// Rewrap re-wraps the data key of encrypted data with the current key of
+// the provider, leaving the encrypted data untouched. Data which is not
+// encrypted yet is encrypted. It returns false if the data is already
+// wrapped by the current key and so was left unchanged.
+func Rewrap(
+	ctx context.Context,
+	provider KeyProvider,
+	data string,
+	additionalData []byte,
+) (string, bool, error) {
+	if !IsEncrypted(data) {
+		encrypted, err := Encrypt(ctx, provider, []byte(data), additionalData)
+		return encrypted, err == nil, err
+	}
+	e, err := parseEnvelope(data)
+	if err != nil {
+		return "", false, err
+	}
+	if e.keyID == provider.CurrentKeyID() {
+		return data, false, nil
+	}
+	dataKey, err := provider.UnwrapKey(ctx, e.keyID, e.wrappedKey)
+	if err != nil {
+		return "", false, err
+	}
+	// make sure the data key opens the data before dropping the old key
+	if _, err := open(dataKey, e.ciphertext, additionalData); err != nil {
+		return "", false, err
+	}
+	e.keyID, e.wrappedKey, err = provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", false, err
+	}
+	return e.String(), true, nil
+}
+
+// seal encrypts the plaintext with AES-GCM, and prefixes the result with
+// the random nonce used
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the result of seal
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errMalformed
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+	plaintext, err := aead.Open(
+		nil, nonce, ciphertext[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %v", err)
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
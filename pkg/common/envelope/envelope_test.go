@@ -0,0 +1,158 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EnvelopeTestSuite struct {
+	suite.Suite
+
+	ctx     context.Context
+	keyFile string
+}
+
+func (suite *EnvelopeTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	f, err := ioutil.TempFile("", "keyfile")
+	suite.NoError(err)
+	f.Close()
+	suite.keyFile = f.Name()
+}
+
+func (suite *EnvelopeTestSuite) TearDownTest() {
+	os.Remove(suite.keyFile)
+}
+
+func TestEnvelopeTestSuite(t *testing.T) {
+	suite.Run(t, new(EnvelopeTestSuite))
+}
+
+// writeKeyFile writes a key file with the given keys, and returns a
+// provider reading it
+func (suite *EnvelopeTestSuite) writeKeyFile(
+	current string,
+	ids ...string,
+) KeyProvider {
+	content := fmt.Sprintf("current_key: %q\nkeys:\n", current)
+	for _, id := range ids {
+		key := base64.StdEncoding.EncodeToString(
+			[]byte(strings.Repeat(id, dataKeySize)[:dataKeySize]))
+		content += fmt.Sprintf("  %q: %s\n", id, key)
+	}
+	suite.NoError(ioutil.WriteFile(suite.keyFile, []byte(content), 0600))
+
+	provider, err := NewKeyProvider(&Config{KeyFile: suite.keyFile})
+	suite.NoError(err)
+	return provider
+}
+
+// TestEncryptDecrypt tests that encrypted data decrypts to the plaintext
+func (suite *EnvelopeTestSuite) TestEncryptDecrypt() {
+	provider := suite.writeKeyFile("a", "a")
+
+	encrypted, err := Encrypt(
+		suite.ctx, provider, []byte("secret"), []byte("id"))
+	suite.NoError(err)
+	suite.True(IsEncrypted(encrypted))
+	suite.NotContains(encrypted, "secret")
+
+	plaintext, err := Decrypt(suite.ctx, provider, encrypted, []byte("id"))
+	suite.NoError(err)
+	suite.Equal("secret", string(plaintext))
+
+	// the ciphertext is bound to the additional data
+	_, err = Decrypt(suite.ctx, provider, encrypted, []byte("other"))
+	suite.Error(err)
+
+	// data stored before encryption is returned as is
+	plaintext, err = Decrypt(suite.ctx, provider, "c2VjcmV0", []byte("id"))
+	suite.NoError(err)
+	suite.Equal("c2VjcmV0", string(plaintext))
+
+	_, err = Decrypt(suite.ctx, provider, prefix+"a:b", nil)
+	suite.Error(err)
+}
+
+// TestRewrap tests re-wrapping data keys after a key rotation
+func (suite *EnvelopeTestSuite) TestRewrap() {
+	oldProvider := suite.writeKeyFile("a", "a")
+	encrypted, err := Encrypt(
+		suite.ctx, oldProvider, []byte("secret"), []byte("id"))
+	suite.NoError(err)
+
+	_, changed, err := Rewrap(
+		suite.ctx, oldProvider, encrypted, []byte("id"))
+	suite.NoError(err)
+	suite.False(changed)
+
+	newProvider := suite.writeKeyFile("b", "a", "b")
+	rewrapped, changed, err := Rewrap(
+		suite.ctx, newProvider, encrypted, []byte("id"))
+	suite.NoError(err)
+	suite.True(changed)
+	suite.True(strings.HasPrefix(rewrapped, prefix+"b:"))
+
+	// the old key is no longer needed once the data key is re-wrapped
+	onlyNewProvider := suite.writeKeyFile("b", "b")
+	plaintext, err := Decrypt(
+		suite.ctx, onlyNewProvider, rewrapped, []byte("id"))
+	suite.NoError(err)
+	suite.Equal("secret", string(plaintext))
+	_, err = Decrypt(suite.ctx, onlyNewProvider, encrypted, []byte("id"))
+	suite.Error(err)
+
+	// data stored before encryption gets encrypted
+	migrated, changed, err := Rewrap(
+		suite.ctx, onlyNewProvider, "plain", []byte("id"))
+	suite.NoError(err)
+	suite.True(changed)
+	plaintext, err = Decrypt(
+		suite.ctx, onlyNewProvider, migrated, []byte("id"))
+	suite.NoError(err)
+	suite.Equal("plain", string(plaintext))
+}
+
+// TestNewKeyProvider tests validation of key files
+func (suite *EnvelopeTestSuite) TestNewKeyProvider() {
+	provider, err := NewKeyProvider(&Config{})
+	suite.NoError(err)
+	suite.Nil(provider)
+
+	for _, content := range []string{
+		"current_key: a\nkeys:\n  b: " +
+			base64.StdEncoding.EncodeToString(make([]byte, dataKeySize)),
+		"current_key: a\nkeys:\n  a: c2hvcnQ=",
+		"current_key: a\nkeys:\n  a: '!!'",
+		"current_key: 'a:b'\nkeys:\n  'a:b': " +
+			base64.StdEncoding.EncodeToString(make([]byte, dataKeySize)),
+	} {
+		suite.NoError(ioutil.WriteFile(suite.keyFile, []byte(content), 0600))
+		_, err := NewKeyFileProvider(suite.keyFile)
+		suite.Error(err, content)
+	}
+
+	_, err = NewKeyFileProvider("/does/not/exist")
+	suite.Error(err)
+}
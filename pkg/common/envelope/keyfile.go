@@ -0,0 +1,128 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the config of secret encryption
+type Config struct {
+	// KeyFile is the path of the file with the key encryption keys.
+	// Encryption is disabled if it is not set.
+	KeyFile string `yaml:"key_file"`
+}
+
+// NewKeyProvider creates the key provider described by the config,
+// or returns nil if encryption is disabled
+func NewKeyProvider(cfg *Config) (KeyProvider, error) {
+	if cfg.KeyFile == "" {
+		return nil, nil
+	}
+	return NewKeyFileProvider(cfg.KeyFile)
+}
+
+// keyFile is the content of a key file, for example:
+//
+//	current_key: "2019-06"
+//	keys:
+//	  "2019-05": <base64 encoded 32 byte key>
+//	  "2019-06": <base64 encoded 32 byte key>
+//
+// To rotate keys, add a new key and make it current. The previous keys
+// must be kept until all data keys have been re-wrapped with the new key.
+type keyFile struct {
+	CurrentKey string            `yaml:"current_key"`
+	Keys       map[string]string `yaml:"keys"`
+}
+
+// keyFileProvider is a KeyProvider with the key encryption keys read from
+// a local file. It is meant for development and testing, production
+// deployments should use a provider backed by a KMS.
+type keyFileProvider struct {
+	currentKeyID string
+	keys         map[string][]byte
+}
+
+// NewKeyFileProvider creates a KeyProvider with the keys of a key file
+func NewKeyFileProvider(path string) (KeyProvider, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var kf keyFile
+	if err := yaml.Unmarshal(data, &kf); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]byte)
+	for id, encoded := range kf.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s is not base64 encoded", id)
+		}
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf(
+				"key %s must be %d bytes long", id, dataKeySize)
+		}
+		keys[id] = key
+	}
+	if _, ok := keys[kf.CurrentKey]; !ok {
+		return nil, fmt.Errorf("current key %q not found", kf.CurrentKey)
+	}
+	return &keyFileProvider{
+		currentKeyID: kf.CurrentKey,
+		keys:         keys,
+	}, nil
+}
+
+// CurrentKeyID returns the id of the key used to wrap new data keys
+func (p *keyFileProvider) CurrentKeyID() string {
+	return p.currentKeyID
+}
+
+// WrapKey wraps a data key with the current key
+func (p *keyFileProvider) WrapKey(
+	ctx context.Context,
+	dataKey []byte,
+) (string, []byte, error) {
+	wrapped, err := seal(p.keys[p.currentKeyID], dataKey, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return p.currentKeyID, wrapped, nil
+}
+
+// UnwrapKey unwraps a data key wrapped by the key with the given id
+func (p *keyFileProvider) UnwrapKey(
+	ctx context.Context,
+	keyID string,
+	wrapped []byte,
+) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found", keyID)
+	}
+	return open(key, wrapped, nil)
+}
@@ -31,6 +31,15 @@ type Metrics struct {
 	// counter to track recovery failure case when the job is terminal but
 	// still present in active_jobs table
 	terminalRecoveredJob tally.Counter
+
+	// total active pipelines from active_pipelines table
+	activePipelines tally.Gauge
+	// counter to track recovery failure case when the pipeline is present
+	// in active_pipelines but not in pipelines
+	missingPipeline tally.Counter
+	// counter to track recovery of pipelines which are terminal but still
+	// present in active_pipelines table
+	terminalRecoveredPipeline tally.Counter
 }
 
 // NewMetrics returns a new Metrics struct.
@@ -40,5 +49,9 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		missingJobRuntime:    scope.Counter("missing_job_runtime"),
 		missingJobConfig:     scope.Counter("missing_job_config"),
 		terminalRecoveredJob: scope.Counter("terminal_recovered_job"),
+
+		activePipelines:           scope.Gauge("active_pipelines"),
+		missingPipeline:           scope.Counter("missing_pipeline"),
+		terminalRecoveredPipeline: scope.Counter("terminal_recovered_pipeline"),
 	}
 }
@@ -0,0 +1,108 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recovery
+
+import (
+	"context"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+
+	"github.com/uber/peloton/pkg/common/util"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	"github.com/gocql/gocql"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// RecoverPipeline is a function type which is used to recover a pipeline
+// which has not terminated yet.
+type RecoverPipeline func(
+	ctx context.Context,
+	info *pipeline.PipelineInfo) error
+
+// RecoverActivePipelines is the handler to start a pipeline recovery.
+// Pipelines which are missing or which have already terminated are
+// removed from active_pipelines table instead of being recovered.
+func RecoverActivePipelines(
+	ctx context.Context,
+	parentScope tally.Scope,
+	pipelineOps ormobjects.PipelineOps,
+	f RecoverPipeline,
+) error {
+
+	mtx := NewMetrics(parentScope.SubScope("recovery"))
+
+	activePipelineIDs, err := pipelineOps.GetActive(ctx)
+	if err != nil {
+		log.WithError(err).
+			Error("GetActive pipelines failed")
+		return err
+	}
+
+	mtx.activePipelines.Update(float64(len(activePipelineIDs)))
+
+	log.WithField("total_active_pipelines", len(activePipelineIDs)).
+		Info("pipelines to recover")
+
+	for _, id := range activePipelineIDs {
+		info, err := pipelineOps.Get(ctx, id)
+		if err != nil {
+			log.WithField("pipeline_id", id.GetValue()).
+				WithError(err).
+				Info("failed to load pipeline")
+			// similar to jobs, skip to the next pipeline instead of
+			// bailing out of the recovery, and only remove the pipeline
+			// from active_pipelines if it does not exist
+			mtx.missingPipeline.Inc(1)
+			if err == gocql.ErrNotFound || yarpcerrors.IsNotFound(err) {
+				deleteFromActivePipelines(ctx, id, pipelineOps)
+			}
+			continue
+		}
+
+		if util.IsPipelineStateTerminal(info.GetStatus().GetState()) {
+			mtx.terminalRecoveredPipeline.Inc(1)
+			log.WithField("pipeline_id", id.GetValue()).
+				Info("delete terminal pipeline from active_pipelines")
+			deleteFromActivePipelines(ctx, id, pipelineOps)
+			continue
+		}
+
+		if err := f(ctx, info); err != nil {
+			log.WithError(err).
+				WithField("pipeline_id", id.GetValue()).
+				Error("Failed to recover pipeline")
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteFromActivePipelines best effort deletes a pipeline from the
+// active_pipelines, the pipeline is not recovered anyway
+func deleteFromActivePipelines(
+	ctx context.Context,
+	id *peloton.PipelineID,
+	pipelineOps ormobjects.PipelineOps,
+) {
+	if err := pipelineOps.DeleteActive(ctx, id); err != nil {
+		log.WithError(err).
+			WithField("pipeline_id", id.GetValue()).
+			Info("DeleteActive pipeline failed")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func newPipelineInfo(
+	id *peloton.PipelineID,
+	state pipeline.PipelineState,
+) *pipeline.PipelineInfo {
+	return &pipeline.PipelineInfo{
+		Id:     id,
+		Spec:   &pipeline.PipelineSpec{Name: "pipeline"},
+		Status: &pipeline.PipelineStatus{State: state},
+	}
+}
+
+// TestPipelineRecovery tests that only the pipelines which have not
+// terminated are recovered, and that the other ones are removed from
+// active_pipelines table
+func TestPipelineRecovery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockPipelineOps := objectmocks.NewMockPipelineOps(ctrl)
+
+	runningID := &peloton.PipelineID{Value: uuid.New()}
+	terminalID := &peloton.PipelineID{Value: uuid.New()}
+	missingID := &peloton.PipelineID{Value: uuid.New()}
+	errorID := &peloton.PipelineID{Value: uuid.New()}
+
+	mockPipelineOps.EXPECT().
+		GetActive(ctx).
+		Return([]*peloton.PipelineID{
+			runningID, terminalID, missingID, errorID}, nil)
+	mockPipelineOps.EXPECT().
+		Get(ctx, runningID).
+		Return(newPipelineInfo(
+			runningID, pipeline.PipelineState_PIPELINE_STATE_RUNNING), nil)
+	mockPipelineOps.EXPECT().
+		Get(ctx, terminalID).
+		Return(newPipelineInfo(
+			terminalID, pipeline.PipelineState_PIPELINE_STATE_FAILED), nil)
+	mockPipelineOps.EXPECT().
+		Get(ctx, missingID).
+		Return(nil, gocql.ErrNotFound)
+	mockPipelineOps.EXPECT().
+		Get(ctx, errorID).
+		Return(nil, yarpcerrors.InternalErrorf("db error"))
+
+	// only the terminal and the missing pipelines are deleted, the
+	// delete failure does not fail the recovery
+	mockPipelineOps.EXPECT().
+		DeleteActive(ctx, terminalID).
+		Return(nil)
+	mockPipelineOps.EXPECT().
+		DeleteActive(ctx, missingID).
+		Return(fmt.Errorf("DeleteActive error"))
+
+	var recovered []string
+	err := RecoverActivePipelines(
+		ctx,
+		scope,
+		mockPipelineOps,
+		func(ctx context.Context, info *pipeline.PipelineInfo) error {
+			recovered = append(recovered, info.GetId().GetValue())
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{runningID.GetValue()}, recovered)
+}
+
+// TestPipelineRecoveryErrors tests the failures of pipeline recovery
+func TestPipelineRecoveryErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockPipelineOps := objectmocks.NewMockPipelineOps(ctrl)
+	id := &peloton.PipelineID{Value: uuid.New()}
+
+	recoverPipeline := func(
+		ctx context.Context,
+		info *pipeline.PipelineInfo,
+	) error {
+		return fmt.Errorf("recover error")
+	}
+
+	// GetActive error
+	mockPipelineOps.EXPECT().
+		GetActive(ctx).
+		Return(nil, fmt.Errorf("GetActive error"))
+	assert.Error(t, RecoverActivePipelines(
+		ctx, scope, mockPipelineOps, recoverPipeline))
+
+	// recover function error
+	mockPipelineOps.EXPECT().
+		GetActive(ctx).
+		Return([]*peloton.PipelineID{id}, nil)
+	mockPipelineOps.EXPECT().
+		Get(ctx, id).
+		Return(newPipelineInfo(
+			id, pipeline.PipelineState_PIPELINE_STATE_RUNNING), nil)
+	assert.Error(t, RecoverActivePipelines(
+		ctx, scope, mockPipelineOps, recoverPipeline))
+}
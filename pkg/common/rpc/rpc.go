@@ -24,6 +24,7 @@ import (
 	"go.uber.org/yarpc/transport/grpc"
 	"go.uber.org/yarpc/transport/http"
 
+	"github.com/opentracing/opentracing-go"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -33,10 +34,13 @@ const (
 )
 
 // NewTransport returns a new transport, using the default transport layer.
+// The transport propagates the span context of the calls with the global
+// tracer, which has to be initialized before.
 func NewTransport() *grpc.Transport {
 	return grpc.NewTransport(
 		grpc.ClientMaxRecvMsgSize(MaxRecvMsgSize),
 		grpc.ServerMaxRecvMsgSize(MaxRecvMsgSize),
+		grpc.Tracer(opentracing.GlobalTracer()),
 	)
 }
 
@@ -47,7 +51,7 @@ func NewAuroraBridgeInbounds(
 	mux *nethttp.ServeMux) []transport.Inbound {
 
 	// Create both HTTP and gRPC transport
-	ht := http.NewTransport()
+	ht := http.NewTransport(http.Tracer(opentracing.GlobalTracer()))
 	gt := NewTransport()
 
 	gl, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
@@ -73,7 +77,7 @@ func NewInbounds(
 	mux *nethttp.ServeMux) []transport.Inbound {
 
 	// Create both HTTP and gRPC transport
-	ht := http.NewTransport()
+	ht := http.NewTransport(http.Tracer(opentracing.GlobalTracer()))
 	gt := NewTransport()
 
 	gl, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
@@ -0,0 +1,28 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+// Config is the config of the distributed tracing of the daemons
+type Config struct {
+	// Enabled turns on the tracing, spans are dropped otherwise
+	Enabled bool `yaml:"enabled"`
+
+	// SamplingRate is the probability of sampling a new trace
+	SamplingRate float64 `yaml:"sampling_rate"`
+
+	// AgentAddress is the host:port of the local jaeger agent
+	// which exports the spans to the collector
+	AgentAddress string `yaml:"agent_address"`
+}
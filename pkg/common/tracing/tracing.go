@@ -0,0 +1,164 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/url"
+
+	"github.com/opentracing/opentracing-go"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/tally"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+	jaegertally "github.com/uber/jaeger-lib/metrics/tally"
+)
+
+const (
+	// _defaultSamplingRate is the sampling rate if none is configured
+	_defaultSamplingRate = 0.01
+	// _defaultAgentAddress is the address of the jaeger agent
+	// running on the same host
+	_defaultAgentAddress = "localhost:6831"
+)
+
+// nopCloser is the closer of the global tracer if tracing is disabled
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// InitGlobalTracer sets the global tracer which is used by the YARPC
+// transports and the storage to create spans, it has to be called
+// before creating the transports. The closer flushes the spans.
+func InitGlobalTracer(
+	config Config,
+	serviceName string,
+	scope tally.Scope,
+) (io.Closer, error) {
+	if !config.Enabled {
+		return nopCloser{}, nil
+	}
+
+	samplingRate := config.SamplingRate
+	if samplingRate <= 0 {
+		samplingRate = _defaultSamplingRate
+	}
+	agentAddress := config.AgentAddress
+	if len(agentAddress) == 0 {
+		agentAddress = _defaultAgentAddress
+	}
+
+	cfg := jaegercfg.Configuration{
+		ServiceName: serviceName,
+		Sampler: &jaegercfg.SamplerConfig{
+			Type:  "probabilistic",
+			Param: samplingRate,
+		},
+		Reporter: &jaegercfg.ReporterConfig{
+			LocalAgentHostPort: agentAddress,
+		},
+	}
+	tracer, closer, err := cfg.NewTracer(
+		jaegercfg.Metrics(jaegertally.Wrap(scope)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	opentracing.SetGlobalTracer(tracer)
+	log.WithFields(log.Fields{
+		"sampling_rate": samplingRate,
+		"agent_address": agentAddress,
+	}).Info("Distributed tracing enabled")
+	return closer, nil
+}
+
+// EncodeSpanContext returns the trace ID which carries the span
+// context across the queues of the daemons, it is empty if the
+// span is not traced.
+func EncodeSpanContext(spanContext opentracing.SpanContext) string {
+	carrier := opentracing.TextMapCarrier{}
+	if err := opentracing.GlobalTracer().Inject(
+		spanContext,
+		opentracing.TextMap,
+		carrier,
+	); err != nil {
+		return ""
+	}
+
+	values := url.Values{}
+	for k, v := range carrier {
+		values.Set(k, v)
+	}
+	return values.Encode()
+}
+
+// DecodeSpanContext returns the span context carried by a trace ID
+func DecodeSpanContext(traceID string) (opentracing.SpanContext, error) {
+	values, err := url.ParseQuery(traceID)
+	if err != nil {
+		return nil, err
+	}
+
+	carrier := opentracing.TextMapCarrier{}
+	for k := range values {
+		carrier.Set(k, values.Get(k))
+	}
+	return opentracing.GlobalTracer().Extract(opentracing.TextMap, carrier)
+}
+
+// TraceIDFromContext returns the trace ID of the span in ctx,
+// it is empty if there is no span.
+func TraceIDFromContext(ctx context.Context) string {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	return EncodeSpanContext(span.Context())
+}
+
+// StartSpanFromTraceIDs starts a span which continues the traces of the
+// trace IDs carried by a batch of tasks. The span is a child of the
+// first trace and follows from the other ones, it starts a new trace
+// if no trace ID can be decoded.
+func StartSpanFromTraceIDs(
+	ctx context.Context,
+	operationName string,
+	traceIDs []string,
+) (opentracing.Span, context.Context) {
+	var refs []opentracing.StartSpanOption
+	seen := make(map[string]bool)
+	for _, traceID := range traceIDs {
+		if len(traceID) == 0 || seen[traceID] {
+			continue
+		}
+		seen[traceID] = true
+
+		spanContext, err := DecodeSpanContext(traceID)
+		if err != nil {
+			continue
+		}
+		if len(refs) == 0 {
+			refs = append(refs, opentracing.ChildOf(spanContext))
+		} else {
+			refs = append(refs, opentracing.FollowsFrom(spanContext))
+		}
+	}
+
+	span := opentracing.GlobalTracer().StartSpan(operationName, refs...)
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+)
+
+type TracingTestSuite struct {
+	suite.Suite
+
+	tracer       *mocktracer.MockTracer
+	globalTracer opentracing.Tracer
+}
+
+func (suite *TracingTestSuite) SetupTest() {
+	suite.globalTracer = opentracing.GlobalTracer()
+	suite.tracer = mocktracer.New()
+	opentracing.SetGlobalTracer(suite.tracer)
+}
+
+func (suite *TracingTestSuite) TearDownTest() {
+	opentracing.SetGlobalTracer(suite.globalTracer)
+}
+
+func TestTracing(t *testing.T) {
+	suite.Run(t, new(TracingTestSuite))
+}
+
+// TestInitGlobalTracerDisabled tests that the global
+// tracer is not replaced if tracing is disabled
+func (suite *TracingTestSuite) TestInitGlobalTracerDisabled() {
+	closer, err := InitGlobalTracer(Config{}, "test", tally.NoopScope)
+	suite.NoError(err)
+	suite.NoError(closer.Close())
+	suite.Equal(suite.tracer, opentracing.GlobalTracer())
+}
+
+// TestEncodeDecodeSpanContext tests carrying a span context in a trace ID
+func (suite *TracingTestSuite) TestEncodeDecodeSpanContext() {
+	span := suite.tracer.StartSpan("test")
+	traceID := EncodeSpanContext(span.Context())
+	suite.NotEmpty(traceID)
+
+	spanContext, err := DecodeSpanContext(traceID)
+	suite.NoError(err)
+	suite.Equal(
+		span.Context().(mocktracer.MockSpanContext).TraceID,
+		spanContext.(mocktracer.MockSpanContext).TraceID)
+	suite.Equal(
+		span.Context().(mocktracer.MockSpanContext).SpanID,
+		spanContext.(mocktracer.MockSpanContext).SpanID)
+
+	_, err = DecodeSpanContext("")
+	suite.Error(err)
+}
+
+// TestTraceIDFromContext tests getting the trace ID of the span in ctx
+func (suite *TracingTestSuite) TestTraceIDFromContext() {
+	suite.Empty(TraceIDFromContext(context.Background()))
+
+	span := suite.tracer.StartSpan("test")
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	suite.Equal(EncodeSpanContext(span.Context()), TraceIDFromContext(ctx))
+}
+
+// TestStartSpanFromTraceIDs tests that the span continues the first trace
+func (suite *TracingTestSuite) TestStartSpanFromTraceIDs() {
+	first := suite.tracer.StartSpan("first")
+	second := suite.tracer.StartSpan("second")
+	traceIDs := []string{
+		"",
+		EncodeSpanContext(first.Context()),
+		EncodeSpanContext(first.Context()),
+		EncodeSpanContext(second.Context()),
+	}
+
+	span, ctx := StartSpanFromTraceIDs(context.Background(), "test", traceIDs)
+	suite.Equal(span, opentracing.SpanFromContext(ctx))
+	span.Finish()
+
+	finished := suite.tracer.FinishedSpans()
+	suite.Len(finished, 1)
+	firstContext := first.Context().(mocktracer.MockSpanContext)
+	suite.Equal(firstContext.TraceID, finished[0].SpanContext.TraceID)
+	suite.Equal(firstContext.SpanID, finished[0].ParentID)
+}
+
+// TestStartSpanFromTraceIDsNewTrace tests that a new trace is
+// started if there is no trace ID
+func (suite *TracingTestSuite) TestStartSpanFromTraceIDsNewTrace() {
+	span, _ := StartSpanFromTraceIDs(context.Background(), "test", []string{""})
+	span.Finish()
+
+	finished := suite.tracer.FinishedSpans()
+	suite.Len(finished, 1)
+	suite.Equal(0, finished[0].ParentID)
+}
@@ -122,6 +122,8 @@ func ConvertTaskToResMgrTask(
 		Revocable:         taskInfo.GetConfig().GetRevocable(),
 		DesiredHost:       taskInfo.GetRuntime().GetDesiredHost(),
 		PlacementStrategy: jobConfig.GetPlacementStrategy(),
+
+		MaximumUnavailableInstances: slaConfig.GetMaximumUnavailableInstances(),
 	}
 
 	taskState := taskInfo.GetRuntime().GetState()
@@ -145,8 +147,11 @@ func getTaskType(cfg *task.TaskConfig, jobType job.JobType) resmgr.TaskType {
 		return resmgr.TaskType_STATEFUL
 	}
 
-	if jobType == job.JobType_SERVICE {
+	switch jobType {
+	case job.JobType_SERVICE:
 		return resmgr.TaskType_STATELESS
+	case job.JobType_DAEMON:
+		return resmgr.TaskType_DAEMON
 	}
 	// By default task type is batch.
 	return resmgr.TaskType_BATCH
@@ -49,6 +49,11 @@ func TestGetTaskType(t *testing.T) {
 			jobType:  job.JobType_SERVICE,
 			taskType: resmgr.TaskType_STATELESS,
 		},
+		{
+			cfg:      &task.TaskConfig{},
+			jobType:  job.JobType_DAEMON,
+			taskType: resmgr.TaskType_DAEMON,
+		},
 	}
 
 	for _, test := range tt {
@@ -125,7 +130,9 @@ func TestConvertTaskToResMgrTask(t *testing.T) {
 	}
 
 	jobConfig := &job.JobConfig{
-		SLA:               &job.SlaConfig{},
+		SLA: &job.SlaConfig{
+			MaximumUnavailableInstances: 2,
+		},
 		PlacementStrategy: job.PlacementStrategy_PLACEMENT_STRATEGY_SPREAD_JOB,
 	}
 	for _, taskInfo := range taskInfos {
@@ -144,6 +151,7 @@ func TestConvertTaskToResMgrTask(t *testing.T) {
 			t,
 			job.PlacementStrategy_PLACEMENT_STRATEGY_SPREAD_JOB,
 			rmTask.GetPlacementStrategy())
+		assert.Equal(t, uint32(2), rmTask.GetMaximumUnavailableInstances())
 	}
 }
 
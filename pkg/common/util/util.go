@@ -31,6 +31,7 @@ import (
 	mesos "github.com/uber/peloton/.gen/mesos/v1"
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
@@ -348,6 +349,19 @@ func IsPelotonJobStateTerminal(state job.JobState) bool {
 	}
 }
 
+// IsPipelineStateTerminal returns true if pipeline state is terminal
+// otherwise false
+func IsPipelineStateTerminal(state pipeline.PipelineState) bool {
+	switch state {
+	case pipeline.PipelineState_PIPELINE_STATE_SUCCEEDED,
+		pipeline.PipelineState_PIPELINE_STATE_FAILED,
+		pipeline.PipelineState_PIPELINE_STATE_KILLED:
+		return true
+	default:
+		return false
+	}
+}
+
 // IsTaskHasValidVolume returns true if a task is stateful and has a valid volume
 func IsTaskHasValidVolume(taskInfo *task.TaskInfo) bool {
 	if taskInfo.GetConfig().GetVolume() != nil &&
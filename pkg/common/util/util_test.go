@@ -27,6 +27,7 @@ import (
 	mesos "github.com/uber/peloton/.gen/mesos/v1"
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 
@@ -506,6 +507,20 @@ func TestJobTerminalState(t *testing.T) {
 	}
 }
 
+// Test check for pipeline state being terminal
+func TestPipelineTerminalState(t *testing.T) {
+	pipelineTerminalStates := map[pipeline.PipelineState]bool{
+		pipeline.PipelineState_PIPELINE_STATE_SUCCEEDED: true,
+		pipeline.PipelineState_PIPELINE_STATE_FAILED:    true,
+		pipeline.PipelineState_PIPELINE_STATE_KILLED:    true,
+	}
+	for s := range pipeline.PipelineState_name {
+		_, isTerm := pipelineTerminalStates[pipeline.PipelineState(s)]
+		assert.Equal(t, isTerm,
+			IsPipelineStateTerminal(pipeline.PipelineState(s)))
+	}
+}
+
 // Test check for task state being terminal
 func TestTaskTerminalState(t *testing.T) {
 	taskTerminalStates := map[task.TaskState]bool{
@@ -24,6 +24,14 @@ const (
 
 	// FirstFit is the name of the First Fit policy
 	FirstFit = "FIRST_FIT"
+
+	// Weighted is the name of the weighted scoring policy which packs
+	// the tasks
+	Weighted = "WEIGHTED"
+
+	// LeastAllocated is the name of the weighted scoring policy which
+	// spreads the tasks
+	LeastAllocated = "LEAST_ALLOCATED"
 )
 
 // map of ranker name to Ranker. Not thread-safe -> should be
@@ -57,6 +65,17 @@ func Init() {
 	register(FirstFit, NewFirstFitRanker)
 }
 
+// InitWeighted registers the weighted rankers with the given weights,
+// the scarce resource types are the ones of the host manager config
+func InitWeighted(config WeightedConfig, scarceResourceTypes []string) {
+	register(Weighted, func() Ranker {
+		return NewWeightedRanker(config, scarceResourceTypes)
+	})
+	register(LeastAllocated, func() Ranker {
+		return NewLeastAllocatedRanker(config, scarceResourceTypes)
+	})
+}
+
 // GetRankerByName returns a ranker with specified name
 func GetRankerByName(name string) Ranker {
 	return rankers[name]
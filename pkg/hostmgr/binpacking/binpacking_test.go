@@ -71,3 +71,18 @@ func (suite *BinPackingTestSuite) TestGetRankers() {
 	suite.Contains(expectedNames, result[0].Name())
 	suite.Contains(expectedNames, result[1].Name())
 }
+
+// TestInitWeighted tests the InitWeighted() function
+func (suite *BinPackingTestSuite) TestInitWeighted() {
+	defer func() {
+		delete(rankers, Weighted)
+		delete(rankers, LeastAllocated)
+	}()
+
+	InitWeighted(WeightedConfig{CPU: 1}, []string{"GPU"})
+	suite.Equal(4, len(rankers))
+	suite.NotNil(rankers[Weighted])
+	suite.Equal(rankers[Weighted].Name(), Weighted)
+	suite.NotNil(rankers[LeastAllocated])
+	suite.Equal(rankers[LeastAllocated].Name(), LeastAllocated)
+}
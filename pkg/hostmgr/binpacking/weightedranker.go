@@ -0,0 +1,294 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binpacking
+
+import (
+	"math"
+	"strconv"
+	"sync"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+
+	"github.com/uber/peloton/pkg/common/sorter"
+	"github.com/uber/peloton/pkg/hostmgr/host"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
+	"github.com/uber/peloton/pkg/hostmgr/summary"
+	"github.com/uber/peloton/pkg/hostmgr/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WeightedConfig is the config of the weighted rankers, which rank the
+// hosts by a weighted sum of scores computed from their host summary.
+// Every score is between 0 and 1, so the weights are comparable.
+type WeightedConfig struct {
+	// Weights of the free resources of a host, relative to the total
+	// resources of the host. The Weighted ranker prefers the most
+	// allocated hosts to pack the tasks, the LeastAllocated ranker
+	// prefers the least allocated hosts to spread the tasks.
+	CPU  float64 `yaml:"cpu"`
+	Mem  float64 `yaml:"mem"`
+	Disk float64 `yaml:"disk"`
+	GPU  float64 `yaml:"gpu"`
+
+	// ScarceResource is the penalty of the hosts offering any of the
+	// scarce resource types, to keep them for the tasks which need them.
+	ScarceResource float64 `yaml:"scarce_resource"`
+
+	// HeldTasks is the penalty of the hosts held for tasks, relative to
+	// the host held for the most tasks.
+	HeldTasks float64 `yaml:"held_tasks"`
+
+	// Attributes are the weights of the hosts with given attributes,
+	// a negative weight avoids the hosts.
+	Attributes []AttributeWeight `yaml:"attributes"`
+}
+
+// AttributeWeight is the weight of the hosts with an attribute.
+type AttributeWeight struct {
+	// Name of the attribute.
+	Name string `yaml:"name"`
+
+	// Value of the text or scalar attribute, any value matches if empty.
+	Value string `yaml:"value"`
+
+	// Weight of the hosts with the attribute.
+	Weight float64 `yaml:"weight"`
+}
+
+// DefaultWeightedConfig returns the config used by the weighted rankers
+// when none is configured.
+func DefaultWeightedConfig() WeightedConfig {
+	return WeightedConfig{
+		CPU:            1,
+		Mem:            1,
+		ScarceResource: 1,
+		HeldTasks:      1,
+	}
+}
+
+// isZero returns true if no weight is configured.
+func (c WeightedConfig) isZero() bool {
+	return c.CPU == 0 && c.Mem == 0 && c.Disk == 0 && c.GPU == 0 &&
+		c.ScarceResource == 0 && c.HeldTasks == 0 && len(c.Attributes) == 0
+}
+
+// weightedRanker is the struct for implementation of
+// the Weighted and LeastAllocated Rankers
+type weightedRanker struct {
+	mu                  sync.RWMutex
+	name                string
+	spread              bool
+	config              WeightedConfig
+	scarceResourceTypes []string
+	summaryList         []interface{}
+}
+
+// NewWeightedRanker returns the Weighted Ranker, which packs the tasks
+// on the most allocated hosts.
+func NewWeightedRanker(
+	config WeightedConfig,
+	scarceResourceTypes []string) Ranker {
+	return newWeightedRanker(Weighted, false, config, scarceResourceTypes)
+}
+
+// NewLeastAllocatedRanker returns the LeastAllocated Ranker, which
+// spreads the tasks on the least allocated hosts.
+func NewLeastAllocatedRanker(
+	config WeightedConfig,
+	scarceResourceTypes []string) Ranker {
+	return newWeightedRanker(LeastAllocated, true, config, scarceResourceTypes)
+}
+
+func newWeightedRanker(
+	name string,
+	spread bool,
+	config WeightedConfig,
+	scarceResourceTypes []string) *weightedRanker {
+	if config.isZero() {
+		config = DefaultWeightedConfig()
+	}
+	return &weightedRanker{
+		name:                name,
+		spread:              spread,
+		config:              config,
+		scarceResourceTypes: scarceResourceTypes,
+	}
+}
+
+// Name is the implementation for Ranker interface.Name method
+// returns the name
+func (w *weightedRanker) Name() string {
+	return w.name
+}
+
+// GetRankedHostList returns the hosts ordered by descending score.
+// This checks if there is already a list present pass that
+// and it depends on RefreshRanking to refresh the list
+func (w *weightedRanker) GetRankedHostList(
+	offerIndex map[string]summary.HostSummary) []interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	log.Debugf(" %s ranker GetRankedHostList is been called", w.Name())
+	if len(w.summaryList) == 0 {
+		w.summaryList = w.getRankedHostList(offerIndex)
+	}
+	return w.summaryList
+}
+
+// RefreshRanking refreshes the hostlist based on new host summary index
+// This function has to be called periodically to refresh the list
+func (w *weightedRanker) RefreshRanking(
+	offerIndex map[string]summary.HostSummary) {
+	summaryList := w.getRankedHostList(offerIndex)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.summaryList = summaryList
+}
+
+// hostScore is the input of the score of a host.
+type hostScore struct {
+	summary    summary.HostSummary
+	hostname   string
+	free       scalar.Resources
+	total      scalar.Resources
+	attributes []*mesos.Attribute
+	heldTasks  int
+	score      float64
+}
+
+// getRankedHostList this is the unprotected method for scoring and
+// sorting the offer index
+func (w *weightedRanker) getRankedHostList(
+	offerIndex map[string]summary.HostSummary) []interface{} {
+	hosts := make([]*hostScore, 0, len(offerIndex))
+	var maxFree scalar.Resources
+	maxHeldTasks := 0
+	for hostname, s := range offerIndex {
+		h := &hostScore{
+			summary:   s,
+			hostname:  hostname,
+			heldTasks: len(s.GetHeldTask()),
+		}
+		offers := s.GetOffers(summary.All)
+		h.free = util.GetResourcesFromOffers(offers)
+		h.total = scalar.FromMesosResources(
+			host.GetAgentInfo(hostname).GetResources())
+		for _, offer := range offers {
+			h.attributes = offer.GetAttributes()
+			break
+		}
+		maxFree = scalar.Resources{
+			CPU:  math.Max(maxFree.CPU, h.free.CPU),
+			Mem:  math.Max(maxFree.Mem, h.free.Mem),
+			Disk: math.Max(maxFree.Disk, h.free.Disk),
+			GPU:  math.Max(maxFree.GPU, h.free.GPU),
+		}
+		if h.heldTasks > maxHeldTasks {
+			maxHeldTasks = h.heldTasks
+		}
+		hosts = append(hosts, h)
+	}
+
+	summaryList := make([]interface{}, 0, len(hosts))
+	for _, h := range hosts {
+		h.score = w.score(h, maxFree, maxHeldTasks)
+		summaryList = append(summaryList, h)
+	}
+
+	score := func(c1, c2 interface{}) bool {
+		return c1.(*hostScore).score > c2.(*hostScore).score
+	}
+	hostname := func(c1, c2 interface{}) bool {
+		return c1.(*hostScore).hostname < c2.(*hostScore).hostname
+	}
+	sorter.OrderedBy(score, hostname).Sort(summaryList)
+
+	for i, h := range summaryList {
+		summaryList[i] = h.(*hostScore).summary
+	}
+	return summaryList
+}
+
+// score returns the weighted score of a host.
+func (w *weightedRanker) score(
+	h *hostScore,
+	maxFree scalar.Resources,
+	maxHeldTasks int) float64 {
+	c := w.config
+	score := c.CPU*w.resourceScore(h.free.CPU, h.total.CPU, maxFree.CPU) +
+		c.Mem*w.resourceScore(h.free.Mem, h.total.Mem, maxFree.Mem) +
+		c.Disk*w.resourceScore(h.free.Disk, h.total.Disk, maxFree.Disk) +
+		c.GPU*w.resourceScore(h.free.GPU, h.total.GPU, maxFree.GPU)
+
+	for _, resourceType := range w.scarceResourceTypes {
+		if scalar.HasResourceType(h.free, scalar.Resources{}, resourceType) {
+			score -= c.ScarceResource
+			break
+		}
+	}
+
+	if maxHeldTasks > 0 {
+		score -= c.HeldTasks * float64(h.heldTasks) / float64(maxHeldTasks)
+	}
+
+	for _, a := range c.Attributes {
+		if hasAttribute(h.attributes, a) {
+			score += a.Weight
+		}
+	}
+	return score
+}
+
+// resourceScore returns the score of the free amount of a resource
+// relative to the total amount of the resource on the host. The most free
+// amount on any host is used instead for the hosts whose agent is not in
+// the host map yet. The score is higher for less free resources unless
+// the ranker spreads.
+func (w *weightedRanker) resourceScore(free, total, maxFree float64) float64 {
+	if total <= 0 {
+		total = maxFree
+	}
+	if total <= 0 {
+		return 0
+	}
+	ratio := math.Min(free/total, 1)
+	if w.spread {
+		return ratio
+	}
+	return 1 - ratio
+}
+
+// hasAttribute returns true if the attributes match the attribute of
+// the weight.
+func hasAttribute(attributes []*mesos.Attribute, a AttributeWeight) bool {
+	for _, attr := range attributes {
+		if attr.GetName() != a.Name {
+			continue
+		}
+		if a.Value == "" {
+			return true
+		}
+		switch attr.GetType() {
+		case mesos.Value_TEXT:
+			return attr.GetText().GetValue() == a.Value
+		case mesos.Value_SCALAR:
+			return strconv.FormatFloat(
+				attr.GetScalar().GetValue(), 'f', -1, 64) == a.Value
+		}
+		return false
+	}
+	return false
+}
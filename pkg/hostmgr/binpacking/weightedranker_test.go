@@ -0,0 +1,246 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binpacking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	mesosmaster "github.com/uber/peloton/.gen/mesos/v1/master"
+	hpb "github.com/uber/peloton/.gen/peloton/api/v0/host"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/pkg/hostmgr/host"
+	hostmocks "github.com/uber/peloton/pkg/hostmgr/host/mocks"
+	mpbmocks "github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb/mocks"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
+	"github.com/uber/peloton/pkg/hostmgr/summary"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+)
+
+type WeightedRankerTestSuite struct {
+	suite.Suite
+	offerIndex map[string]summary.HostSummary
+}
+
+func TestWeightedRankerTestSuite(t *testing.T) {
+	suite.Run(t, new(WeightedRankerTestSuite))
+}
+
+func (suite *WeightedRankerTestSuite) SetupTest() {
+	suite.offerIndex = CreateOfferIndex()
+}
+
+// hostnames returns the hostnames of a ranked host list
+func (suite *WeightedRankerTestSuite) hostnames(
+	summaryList []interface{}) []string {
+	var hostnames []string
+	for _, s := range summaryList {
+		hostnames = append(hostnames, s.(summary.HostSummary).GetHostname())
+	}
+	return hostnames
+}
+
+// addHost adds a host with the given resources and attributes to the
+// offer index
+func (suite *WeightedRankerTestSuite) addHost(
+	hostname string,
+	resources scalar.Resources,
+	attributes ...*mesos.Attribute) summary.HostSummary {
+	offer := CreateOffer(hostname, resources)
+	offer.Attributes = attributes
+	s := summary.New(nil, nil, hostname, nil, 30*time.Second)
+	s.AddMesosOffers(context.Background(), []*mesos.Offer{offer})
+	suite.offerIndex[hostname] = s
+	return s
+}
+
+func (suite *WeightedRankerTestSuite) TestName() {
+	suite.Equal(Weighted, NewWeightedRanker(WeightedConfig{}, nil).Name())
+	suite.Equal(LeastAllocated,
+		NewLeastAllocatedRanker(WeightedConfig{}, nil).Name())
+}
+
+func (suite *WeightedRankerTestSuite) TestDefaultConfig() {
+	ranker := NewWeightedRanker(WeightedConfig{}, nil).(*weightedRanker)
+	suite.Equal(DefaultWeightedConfig(), ranker.config)
+
+	config := WeightedConfig{Disk: 1}
+	ranker = NewWeightedRanker(config, nil).(*weightedRanker)
+	suite.Equal(config, ranker.config)
+}
+
+func (suite *WeightedRankerTestSuite) TestPack() {
+	ranker := NewWeightedRanker(WeightedConfig{CPU: 1}, nil)
+	suite.Equal(
+		[]string{"hostname0", "hostname1", "hostname2", "hostname3", "hostname4"},
+		suite.hostnames(ranker.GetRankedHostList(suite.offerIndex)))
+}
+
+func (suite *WeightedRankerTestSuite) TestSpread() {
+	ranker := NewLeastAllocatedRanker(WeightedConfig{CPU: 1}, nil)
+	suite.Equal(
+		[]string{"hostname3", "hostname4", "hostname2", "hostname0", "hostname1"},
+		suite.hostnames(ranker.GetRankedHostList(suite.offerIndex)))
+}
+
+func (suite *WeightedRankerTestSuite) TestResourceWeights() {
+	// hostname1 and hostname2 have the most gpus, which outweighs the
+	// cpus, memory and disk
+	ranker := NewLeastAllocatedRanker(
+		WeightedConfig{CPU: 1, Mem: 1, Disk: 1, GPU: 4}, nil)
+	suite.Equal(
+		[]string{"hostname2", "hostname1", "hostname3", "hostname4", "hostname0"},
+		suite.hostnames(ranker.GetRankedHostList(suite.offerIndex)))
+}
+
+func (suite *WeightedRankerTestSuite) TestScarceResource() {
+	suite.addHost("hostname5", scalar.Resources{CPU: 1, Mem: 1, Disk: 1})
+
+	config := WeightedConfig{CPU: 1, ScarceResource: 1}
+	ranker := NewWeightedRanker(config, []string{"GPU"})
+	suite.Equal("hostname5",
+		suite.hostnames(ranker.GetRankedHostList(suite.offerIndex))[0])
+
+	// the hosts with gpus are not penalized without scarce resource types
+	ranker = NewWeightedRanker(config, nil)
+	suite.Equal("hostname0",
+		suite.hostnames(ranker.GetRankedHostList(suite.offerIndex))[0])
+}
+
+func (suite *WeightedRankerTestSuite) TestHeldTasks() {
+	s := suite.offerIndex["hostname0"]
+	suite.NoError(s.HoldForTask(&peloton.TaskID{Value: "task0"}))
+	suite.NoError(s.HoldForTask(&peloton.TaskID{Value: "task1"}))
+	suite.NoError(suite.offerIndex["hostname1"].HoldForTask(
+		&peloton.TaskID{Value: "task2"}))
+
+	ranker := NewWeightedRanker(WeightedConfig{CPU: 1, HeldTasks: 1}, nil)
+	suite.Equal(
+		[]string{"hostname2", "hostname1", "hostname3", "hostname4", "hostname0"},
+		suite.hostnames(ranker.GetRankedHostList(suite.offerIndex)))
+}
+
+func (suite *WeightedRankerTestSuite) TestAttributes() {
+	text := func(name, value string) *mesos.Attribute {
+		return &mesos.Attribute{
+			Name: proto.String(name),
+			Type: mesos.Value_TEXT.Enum(),
+			Text: &mesos.Value_Text{Value: proto.String(value)},
+		}
+	}
+	scalarAttr := func(name string, value float64) *mesos.Attribute {
+		return &mesos.Attribute{
+			Name:   proto.String(name),
+			Type:   mesos.Value_SCALAR.Enum(),
+			Scalar: &mesos.Value_Scalar{Value: proto.Float64(value)},
+		}
+	}
+	resources := scalar.Resources{CPU: 3, Mem: 3, Disk: 3, GPU: 2}
+	suite.addHost("hostname5", resources, text("rack", "r1"))
+	suite.addHost("hostname6", resources, text("rack", "r2"))
+	suite.addHost("hostname7", resources, scalarAttr("generation", 2))
+	suite.addHost("hostname8", resources, text("ssd", "true"))
+
+	ranker := NewWeightedRanker(WeightedConfig{
+		CPU: 1,
+		Attributes: []AttributeWeight{
+			{Name: "rack", Value: "r2", Weight: 2},
+			{Name: "generation", Value: "2", Weight: 3},
+			{Name: "ssd", Weight: 4},
+			{Name: "rack", Value: "r1", Weight: -1},
+		},
+	}, nil)
+	hostnames := suite.hostnames(ranker.GetRankedHostList(suite.offerIndex))
+	suite.Equal([]string{"hostname8", "hostname7", "hostname6"}, hostnames[:3])
+	suite.Equal("hostname5", hostnames[len(hostnames)-1])
+}
+
+func (suite *WeightedRankerTestSuite) TestGetRankedHostListWithRefresh() {
+	ranker := NewLeastAllocatedRanker(WeightedConfig{CPU: 1}, nil)
+	suite.Len(ranker.GetRankedHostList(suite.offerIndex), 5)
+
+	// the new host is ranked only after a refresh
+	AddHostToIndex(5, suite.offerIndex)
+	suite.Len(ranker.GetRankedHostList(suite.offerIndex), 5)
+
+	ranker.RefreshRanking(suite.offerIndex)
+	sortedList := ranker.GetRankedHostList(suite.offerIndex)
+	suite.Len(sortedList, 6)
+	suite.Equal("hostname5", suite.hostnames(sortedList)[0])
+}
+
+// loadAgents loads the agents with the given total resources into the
+// host map
+func (suite *WeightedRankerTestSuite) loadAgents(
+	totals map[string]scalar.Resources) {
+	mockCtrl := gomock.NewController(suite.T())
+	defer mockCtrl.Finish()
+
+	operatorClient := mpbmocks.NewMockMasterOperatorClient(mockCtrl)
+	maintenanceHostInfoMap := hostmocks.NewMockMaintenanceHostInfoMap(mockCtrl)
+
+	response := &mesosmaster.Response_GetAgents{}
+	for hostname, total := range totals {
+		resources := CreateOffer(hostname, total).GetResources()
+		response.Agents = append(response.Agents,
+			&mesosmaster.Response_GetAgents_Agent{
+				AgentInfo: &mesos.AgentInfo{
+					Hostname:  proto.String(hostname),
+					Resources: resources,
+				},
+				TotalResources: resources,
+			})
+	}
+	operatorClient.EXPECT().Agents().Return(response, nil)
+	maintenanceHostInfoMap.EXPECT().
+		GetDrainingHostInfos(gomock.Any()).
+		Return([]*hpb.HostInfo{}).
+		Times(len(totals))
+
+	loader := &host.Loader{
+		OperatorClient:         operatorClient,
+		MaintenanceHostInfoMap: maintenanceHostInfoMap,
+		Scope:                  tally.NoopScope,
+	}
+	loader.Load(nil)
+}
+
+// TestTotalResources tests that the free resources of the hosts are
+// relative to their total resources rather than to the host with the
+// most free resources
+func (suite *WeightedRankerTestSuite) TestTotalResources() {
+	suite.loadAgents(map[string]scalar.Resources{
+		"agent0": {CPU: 8, Mem: 8},
+		"agent1": {CPU: 2, Mem: 2},
+	})
+	suite.offerIndex = make(map[string]summary.HostSummary)
+	// agent0 is half allocated, agent1 is not allocated
+	suite.addHost("agent0", scalar.Resources{CPU: 4, Mem: 4})
+	suite.addHost("agent1", scalar.Resources{CPU: 2, Mem: 2})
+
+	ranker := NewLeastAllocatedRanker(WeightedConfig{CPU: 1, Mem: 1}, nil)
+	suite.Equal([]string{"agent1", "agent0"},
+		suite.hostnames(ranker.GetRankedHostList(suite.offerIndex)))
+
+	ranker = NewWeightedRanker(WeightedConfig{CPU: 1, Mem: 1}, nil)
+	suite.Equal([]string{"agent0", "agent1"},
+		suite.hostnames(ranker.GetRankedHostList(suite.offerIndex)))
+}
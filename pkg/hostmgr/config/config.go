@@ -17,6 +17,7 @@ package config
 import (
 	"time"
 
+	"github.com/uber/peloton/pkg/hostmgr/binpacking"
 	"github.com/uber/peloton/pkg/hostmgr/reconcile"
 	"github.com/uber/peloton/pkg/hostmgr/watchevent"
 )
@@ -69,6 +70,9 @@ type Config struct {
 	// Host Drainer Period
 	HostDrainerPeriod time.Duration `yaml:"host_drainer_period"`
 
+	// Period to start and complete the scheduled maintenance windows
+	MaintenanceSchedulerPeriod time.Duration `yaml:"maintenance_scheduler_period"`
+
 	// Represents scarce resource types such as GPU.
 	ScarceResourceTypes []string `yaml:"scarce_resource_types"`
 
@@ -79,6 +83,8 @@ type Config struct {
 	BinPacking string `yaml:"bin_packing"`
 	// Bin Packing Refresh Interval
 	BinPackingRefreshIntervalSec time.Duration `yaml:"bin_packing_refresh_interval"`
+	// Weights of the WEIGHTED and LEAST_ALLOCATED bin packing rankers
+	BinPackingWeights binpacking.WeightedConfig `yaml:"bin_packing_weights"`
 
 	// Watch API specific configuration
 	Watch watchevent.Config `yaml:"watch"`
@@ -1665,6 +1665,24 @@ func (h *ServiceHandler) MarkHostsDrained(
 	}, errs
 }
 
+// SetDrainBlockedHosts implements InternalHostService.SetDrainBlockedHosts
+// Record why the tasks of the DRAINING hosts cannot be evicted yet. This
+// method is called by Resource Manager Drainer, the reasons are returned
+// by HostService.QueryHosts.
+func (h *ServiceHandler) SetDrainBlockedHosts(
+	ctx context.Context,
+	request *hostsvc.SetDrainBlockedHostsRequest,
+) (*hostsvc.SetDrainBlockedHostsResponse, error) {
+	reasons := make(map[string]string)
+	for _, blockedHost := range request.GetBlockedHosts() {
+		reasons[blockedHost.GetHostname()] = blockedHost.GetReason()
+	}
+	h.maintenanceHostInfoMap.SetDrainBlockedReasons(reasons)
+
+	h.metrics.SetDrainBlockedHosts.Inc(1)
+	return &hostsvc.SetDrainBlockedHostsResponse{}, nil
+}
+
 // GetMesosAgentInfo implements InternalHostService.GetMesosAgentInfo
 // Returns Mesos agent info for a single agent or all agents.
 func (h *ServiceHandler) GetMesosAgentInfo(
@@ -2201,6 +2201,28 @@ func (suite *HostMgrHandlerTestSuite) TestServiceHandlerMarkHostsDrained() {
 	suite.Nil(resp.GetMarkedHosts())
 }
 
+func (suite *HostMgrHandlerTestSuite) TestServiceHandlerSetDrainBlockedHosts() {
+	defer suite.ctrl.Finish()
+
+	suite.maintenanceHostInfoMap.EXPECT().
+		SetDrainBlockedReasons(map[string]string{
+			"host1": "job sla violation",
+		})
+
+	resp, err := suite.handler.SetDrainBlockedHosts(
+		context.Background(),
+		&hostsvc.SetDrainBlockedHostsRequest{
+			BlockedHosts: []*hostsvc.DrainBlockedHost{
+				{
+					Hostname: "host1",
+					Reason:   "job sla violation",
+				},
+			},
+		})
+	suite.NoError(err)
+	suite.NotNil(resp)
+}
+
 func getAcquireHostOffersRequest() *hostsvc.AcquireHostOffersRequest {
 	return &hostsvc.AcquireHostOffersRequest{
 		Filter: &hostsvc.HostFilter{
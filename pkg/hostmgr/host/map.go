@@ -178,6 +178,9 @@ type MaintenanceHostInfoMap interface {
 	// ClearAndFillMap clears the content of the
 	// map and fills the map with the given host infos
 	ClearAndFillMap(hostInfos []*host.HostInfo)
+	// SetDrainBlockedReasons replaces the reasons why the tasks of the
+	// DRAINING hosts cannot be evicted yet, keyed by hostname.
+	SetDrainBlockedReasons(reasons map[string]string)
 }
 
 // maintenanceHostInfoMap implements MaintenanceHostInfoMap interface
@@ -186,14 +189,18 @@ type maintenanceHostInfoMap struct {
 	metrics       *Metrics
 	drainingHosts map[string]*host.HostInfo
 	downHosts     map[string]*host.HostInfo
+	// reasons why the DRAINING hosts are not drained yet, reported by
+	// the resource manager which evicts the tasks of the hosts
+	drainBlockedReasons map[string]string
 }
 
 // NewMaintenanceHostInfoMap returns a new MaintenanceHostInfoMap
 func NewMaintenanceHostInfoMap(scope tally.Scope) MaintenanceHostInfoMap {
 	return &maintenanceHostInfoMap{
-		metrics:       NewMetrics(scope.SubScope("maintenance_map")),
-		drainingHosts: make(map[string]*host.HostInfo),
-		downHosts:     make(map[string]*host.HostInfo),
+		metrics:             NewMetrics(scope.SubScope("maintenance_map")),
+		drainingHosts:       make(map[string]*host.HostInfo),
+		downHosts:           make(map[string]*host.HostInfo),
+		drainBlockedReasons: make(map[string]string),
 	}
 }
 
@@ -206,19 +213,35 @@ func (m *maintenanceHostInfoMap) GetDrainingHostInfos(hostFilter []string) []*ho
 	var hostInfos []*host.HostInfo
 	if len(hostFilter) == 0 {
 		for _, hostInfo := range m.drainingHosts {
-			hostInfos = append(hostInfos, hostInfo)
+			hostInfos = append(hostInfos, m.withDrainBlockedReason(hostInfo))
 		}
 		return hostInfos
 	}
 
 	for _, host := range hostFilter {
 		if hostInfo, ok := m.drainingHosts[host]; ok {
-			hostInfos = append(hostInfos, hostInfo)
+			hostInfos = append(hostInfos, m.withDrainBlockedReason(hostInfo))
 		}
 	}
 	return hostInfos
 }
 
+// withDrainBlockedReason returns a copy of the HostInfo of a DRAINING host
+// with the reason why the host is not drained yet, if there is one
+func (m *maintenanceHostInfoMap) withDrainBlockedReason(
+	hostInfo *host.HostInfo) *host.HostInfo {
+	reason, ok := m.drainBlockedReasons[hostInfo.GetHostname()]
+	if !ok {
+		return hostInfo
+	}
+	return &host.HostInfo{
+		Hostname:           hostInfo.GetHostname(),
+		Ip:                 hostInfo.GetIp(),
+		State:              hostInfo.GetState(),
+		DrainBlockedReason: reason,
+	}
+}
+
 // GetDownHosts returns HostInfo of the specified DOWN hosts
 // If the hostFilter is empty then HostInfos of all DOWN hosts is returned
 func (m *maintenanceHostInfoMap) GetDownHostInfos(hostFilter []string) []*host.HostInfo {
@@ -352,3 +375,18 @@ func (m *maintenanceHostInfoMap) ClearAndFillMap(hostInfos []*host.HostInfo) {
 	m.metrics.DrainingHosts.Update(float64(len(m.drainingHosts)))
 	m.metrics.DownHosts.Update(float64(len(m.downHosts)))
 }
+
+// SetDrainBlockedReasons replaces the reasons why the tasks of the
+// DRAINING hosts cannot be evicted yet
+func (m *maintenanceHostInfoMap) SetDrainBlockedReasons(
+	reasons map[string]string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.drainBlockedReasons = make(map[string]string)
+	for hostname, reason := range reasons {
+		m.drainBlockedReasons[hostname] = reason
+	}
+
+	m.metrics.DrainBlockedHosts.Update(float64(len(m.drainBlockedReasons)))
+}
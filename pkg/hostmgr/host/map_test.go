@@ -299,6 +299,23 @@ func (suite *HostMapTestSuite) TestMaintenanceHostInfoMap() {
 	maintenanceHostInfoMap.ClearAndFillMap(downHostInfos)
 	suite.NotEmpty(maintenanceHostInfoMap.GetDownHostInfos([]string{}))
 	suite.Empty(maintenanceHostInfoMap.GetDrainingHostInfos([]string{}))
+
+	// Test SetDrainBlockedReasons
+	maintenanceHostInfoMap.AddHostInfos(drainingHostInfos)
+	maintenanceHostInfoMap.SetDrainBlockedReasons(map[string]string{
+		drainingHosts[0]: "job sla violation",
+	})
+	hostInfos := maintenanceHostInfoMap.GetDrainingHostInfos(drainingHosts)
+	suite.Len(hostInfos, 1)
+	suite.Equal("job sla violation", hostInfos[0].GetDrainBlockedReason())
+	suite.Equal(host.HostState_HOST_STATE_DRAINING, hostInfos[0].GetState())
+	// the stored HostInfo is not modified
+	suite.Empty(drainingHostInfos[0].GetDrainBlockedReason())
+
+	maintenanceHostInfoMap.SetDrainBlockedReasons(nil)
+	hostInfos = maintenanceHostInfoMap.GetDrainingHostInfos([]string{})
+	suite.Len(hostInfos, 1)
+	suite.Empty(hostInfos[0].GetDrainBlockedReason())
 }
 
 func TestHostMapTestSuite(t *testing.T) {
@@ -22,8 +22,9 @@ import (
 type Metrics struct {
 	scope tally.Scope
 
-	DrainingHosts tally.Gauge
-	DownHosts     tally.Gauge
+	DrainingHosts     tally.Gauge
+	DownHosts         tally.Gauge
+	DrainBlockedHosts tally.Gauge
 }
 
 // NewMetrics returns a new Metrics struct, with all metrics
@@ -32,7 +33,8 @@ func NewMetrics(scope tally.Scope) *Metrics {
 	return &Metrics{
 		scope: scope,
 
-		DrainingHosts: scope.Gauge("draining_hosts"),
-		DownHosts:     scope.Gauge("down_hosts"),
+		DrainingHosts:     scope.Gauge("draining_hosts"),
+		DownHosts:         scope.Gauge("down_hosts"),
+		DrainBlockedHosts: scope.Gauge("drain_blocked_hosts"),
 	}
 }
@@ -24,11 +24,13 @@ import (
 	hpb "github.com/uber/peloton/.gen/peloton/api/v0/host"
 	host_svc "github.com/uber/peloton/.gen/peloton/api/v0/host/svc"
 
+	"github.com/uber/peloton/pkg/common/background"
 	"github.com/uber/peloton/pkg/common/stringset"
 	"github.com/uber/peloton/pkg/common/util"
 	"github.com/uber/peloton/pkg/hostmgr/host"
 	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb"
 	"github.com/uber/peloton/pkg/hostmgr/queue"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/uber-go/tally"
@@ -41,22 +43,40 @@ type serviceHandler struct {
 	metrics                *Metrics
 	operatorMasterClient   mpb.MasterOperatorClient
 	maintenanceHostInfoMap host.MaintenanceHostInfoMap
+	maintenanceWindowOps   ormobjects.MaintenanceWindowOps
 }
 
-// InitServiceHandler initializes the HostService
+// InitServiceHandler initializes the HostService, and registers the
+// background work which starts and completes the maintenance of the
+// hosts of the scheduled maintenance windows
 func InitServiceHandler(
 	d *yarpc.Dispatcher,
 	parent tally.Scope,
 	operatorMasterClient mpb.MasterOperatorClient,
 	maintenanceQueue queue.MaintenanceQueue,
-	hostInfoMap host.MaintenanceHostInfoMap) {
+	hostInfoMap host.MaintenanceHostInfoMap,
+	maintenanceWindowOps ormobjects.MaintenanceWindowOps,
+	backgroundMgr background.Manager,
+	maintenanceSchedulerPeriod time.Duration) {
 	handler := &serviceHandler{
 		maintenanceQueue:       maintenanceQueue,
 		metrics:                NewMetrics(parent.SubScope("hostsvc")),
 		operatorMasterClient:   operatorMasterClient,
 		maintenanceHostInfoMap: hostInfoMap,
+		maintenanceWindowOps:   maintenanceWindowOps,
 	}
 	d.Register(host_svc.BuildHostServiceYARPCProcedures(handler))
+
+	if maintenanceSchedulerPeriod <= 0 {
+		maintenanceSchedulerPeriod = _defaultMaintenanceSchedulerPeriod
+	}
+	backgroundMgr.RegisterWorks(
+		background.Work{
+			Name:   _maintenanceSchedulerName,
+			Func:   handler.runMaintenanceWindows,
+			Period: maintenanceSchedulerPeriod,
+		},
+	)
 	log.Info("Hostsvc handler initialized")
 }
 
@@ -137,17 +157,26 @@ func (m *serviceHandler) StartMaintenance(
 ) (*host_svc.StartMaintenanceResponse, error) {
 	m.metrics.StartMaintenanceAPI.Inc(1)
 
-	machineIds, err := buildMachineIDsForHosts(request.GetHostnames())
-	if err != nil {
+	if err := m.startMaintenance(request.GetHostnames()); err != nil {
 		m.metrics.StartMaintenanceFail.Inc(1)
 		return nil, err
 	}
 
+	m.metrics.StartMaintenanceSuccess.Inc(1)
+	return &host_svc.StartMaintenanceResponse{}, nil
+}
+
+// startMaintenance puts the hosts into DRAINING state
+func (m *serviceHandler) startMaintenance(hostnames []string) error {
+	machineIds, err := buildMachineIDsForHosts(hostnames)
+	if err != nil {
+		return err
+	}
+
 	// Get current maintenance schedule
 	response, err := m.operatorMasterClient.GetMaintenanceSchedule()
 	if err != nil {
-		m.metrics.StartMaintenanceFail.Inc(1)
-		return nil, err
+		return err
 	}
 	schedule := response.GetSchedule()
 	// Set current time as the `start` of maintenance window
@@ -171,8 +200,7 @@ func (m *serviceHandler) StartMaintenance(
 
 	err = m.operatorMasterClient.UpdateMaintenanceSchedule(schedule)
 	if err != nil {
-		m.metrics.StartMaintenanceFail.Inc(1)
-		return nil, err
+		return err
 	}
 	log.WithField("maintenance_schedule", schedule).
 		Info("Maintenance Schedule posted to Mesos Master")
@@ -189,13 +217,7 @@ func (m *serviceHandler) StartMaintenance(
 	m.maintenanceHostInfoMap.AddHostInfos(hostInfos)
 	// Enqueue hostnames into maintenance queue to initiate
 	// the rescheduling of tasks running on these hosts
-	err = m.maintenanceQueue.Enqueue(request.GetHostnames())
-	if err != nil {
-		return nil, err
-	}
-
-	m.metrics.StartMaintenanceSuccess.Inc(1)
-	return &host_svc.StartMaintenanceResponse{}, nil
+	return m.maintenanceQueue.Enqueue(hostnames)
 }
 
 // CompleteMaintenance completes maintenance on the specified hosts. It brings
@@ -208,18 +230,27 @@ func (m *serviceHandler) CompleteMaintenance(
 ) (*host_svc.CompleteMaintenanceResponse, error) {
 	m.metrics.CompleteMaintenanceAPI.Inc(1)
 
+	if err := m.completeMaintenance(request.GetHostnames()); err != nil {
+		m.metrics.CompleteMaintenanceFail.Inc(1)
+		return nil, err
+	}
+
+	m.metrics.CompleteMaintenanceSuccess.Inc(1)
+	return &host_svc.CompleteMaintenanceResponse{}, nil
+}
+
+// completeMaintenance brings UP the hosts which are DOWN
+func (m *serviceHandler) completeMaintenance(hostnames []string) error {
 	downHostInfoMap := make(map[string]*hpb.HostInfo)
 	for _, hostInfo := range m.maintenanceHostInfoMap.GetDownHostInfos([]string{}) {
 		downHostInfoMap[hostInfo.GetHostname()] = hostInfo
 	}
 
 	var machineIds []*mesos.MachineID
-	hostnames := request.GetHostnames()
 	for _, hostname := range hostnames {
 		hostInfo, ok := downHostInfoMap[hostname]
 		if !ok {
-			m.metrics.CompleteMaintenanceFail.Inc(1)
-			return nil, fmt.Errorf("invalid request. Host %s is not DOWN", hostname)
+			return fmt.Errorf("invalid request. Host %s is not DOWN", hostname)
 		}
 		machineID := &mesos.MachineID{
 			Hostname: &hostInfo.Hostname,
@@ -230,14 +261,11 @@ func (m *serviceHandler) CompleteMaintenance(
 
 	err := m.operatorMasterClient.StopMaintenance(machineIds)
 	if err != nil {
-		m.metrics.CompleteMaintenanceFail.Inc(1)
-		return nil, err
+		return err
 	}
 
 	m.maintenanceHostInfoMap.RemoveHostInfos(hostnames)
-
-	m.metrics.CompleteMaintenanceSuccess.Inc(1)
-	return &host_svc.CompleteMaintenanceResponse{}, nil
+	return nil
 }
 
 // Build host info for registered agents
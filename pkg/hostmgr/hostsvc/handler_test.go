@@ -30,6 +30,7 @@ import (
 	hm "github.com/uber/peloton/pkg/hostmgr/host/mocks"
 	ym "github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb/mocks"
 	qm "github.com/uber/peloton/pkg/hostmgr/queue/mocks"
+	ormmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
 
 	"github.com/golang/mock/gomock"
 	log "github.com/sirupsen/logrus"
@@ -50,6 +51,7 @@ type HostSvcHandlerTestSuite struct {
 	mockMasterOperatorClient *ym.MockMasterOperatorClient
 	mockMaintenanceQueue     *qm.MockMaintenanceQueue
 	mockMaintenanceMap       *hm.MockMaintenanceHostInfoMap
+	mockWindowOps            *ormmocks.MockMaintenanceWindowOps
 }
 
 func (suite *HostSvcHandlerTestSuite) SetupSuite() {
@@ -116,9 +118,11 @@ func (suite *HostSvcHandlerTestSuite) SetupTest() {
 	suite.mockMasterOperatorClient = ym.NewMockMasterOperatorClient(suite.mockCtrl)
 	suite.mockMaintenanceQueue = qm.NewMockMaintenanceQueue(suite.mockCtrl)
 	suite.mockMaintenanceMap = hm.NewMockMaintenanceHostInfoMap(suite.mockCtrl)
+	suite.mockWindowOps = ormmocks.NewMockMaintenanceWindowOps(suite.mockCtrl)
 	suite.handler.operatorMasterClient = suite.mockMasterOperatorClient
 	suite.handler.maintenanceQueue = suite.mockMaintenanceQueue
 	suite.handler.maintenanceHostInfoMap = suite.mockMaintenanceMap
+	suite.handler.maintenanceWindowOps = suite.mockWindowOps
 
 	response := suite.makeAgentsResponse()
 	loader := &host.Loader{
@@ -0,0 +1,372 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostsvc
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	hpb "github.com/uber/peloton/.gen/peloton/api/v0/host"
+	host_svc "github.com/uber/peloton/.gen/peloton/api/v0/host/svc"
+
+	"github.com/gocql/gocql"
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/atomic"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_maintenanceSchedulerName = "maintenanceScheduler"
+
+	// period of the maintenance scheduler when it is not configured
+	_defaultMaintenanceSchedulerPeriod = 60 * time.Second
+
+	// timeout of a run of the maintenance scheduler
+	_maintenanceSchedulerTimeout = 30 * time.Second
+)
+
+var (
+	errNullWindow     = yarpcerrors.InvalidArgumentErrorf("maintenance window is null")
+	errEmptyHostnames = yarpcerrors.InvalidArgumentErrorf("maintenance window has no hosts")
+)
+
+// ScheduleMaintenanceWindow stores a maintenance window, the hosts of the
+// window are put into maintenance by the maintenance scheduler at the start
+// time of the window, and the maintenance of the hosts which are DOWN is
+// completed at its end time.
+func (m *serviceHandler) ScheduleMaintenanceWindow(
+	ctx context.Context,
+	request *host_svc.ScheduleMaintenanceWindowRequest,
+) (*host_svc.ScheduleMaintenanceWindowResponse, error) {
+	m.metrics.ScheduleMaintenanceWindowAPI.Inc(1)
+
+	window, err := m.validateMaintenanceWindow(ctx, request.GetWindow())
+	if err != nil {
+		m.metrics.ScheduleMaintenanceWindowFail.Inc(1)
+		return nil, err
+	}
+
+	if err := m.maintenanceWindowOps.Create(ctx, window); err != nil {
+		m.metrics.ScheduleMaintenanceWindowFail.Inc(1)
+		return nil, err
+	}
+
+	log.WithField("maintenance_window", window).
+		Info("Maintenance window scheduled")
+	m.metrics.ScheduleMaintenanceWindowSuccess.Inc(1)
+	return &host_svc.ScheduleMaintenanceWindowResponse{
+		WindowId: window.GetId(),
+	}, nil
+}
+
+// ListMaintenanceWindows returns the maintenance windows which are in one
+// of the specified states, sorted by start time.
+func (m *serviceHandler) ListMaintenanceWindows(
+	ctx context.Context,
+	request *host_svc.ListMaintenanceWindowsRequest,
+) (*host_svc.ListMaintenanceWindowsResponse, error) {
+	m.metrics.ListMaintenanceWindowsAPI.Inc(1)
+
+	windows, err := m.maintenanceWindowOps.GetAll(ctx)
+	if err != nil {
+		m.metrics.ListMaintenanceWindowsFail.Inc(1)
+		return nil, err
+	}
+
+	states := make(map[hpb.MaintenanceWindowState]bool)
+	for _, state := range request.GetStates() {
+		states[state] = true
+	}
+
+	var result []*hpb.MaintenanceWindow
+	for _, window := range windows {
+		if len(states) == 0 || states[window.GetState()] {
+			result = append(result, window)
+		}
+	}
+	// the times are normalized to UTC when the windows are scheduled
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].GetStartTime() < result[j].GetStartTime()
+	})
+
+	m.metrics.ListMaintenanceWindowsSuccess.Inc(1)
+	return &host_svc.ListMaintenanceWindowsResponse{
+		Windows: result,
+	}, nil
+}
+
+// CancelMaintenanceWindow cancels a maintenance window which has not
+// started yet. The maintenance of the hosts of an active window is
+// completed by calling CompleteMaintenance.
+func (m *serviceHandler) CancelMaintenanceWindow(
+	ctx context.Context,
+	request *host_svc.CancelMaintenanceWindowRequest,
+) (*host_svc.CancelMaintenanceWindowResponse, error) {
+	m.metrics.CancelMaintenanceWindowAPI.Inc(1)
+
+	window, err := m.maintenanceWindowOps.Get(ctx, request.GetWindowId())
+	if err != nil {
+		m.metrics.CancelMaintenanceWindowFail.Inc(1)
+		if err == gocql.ErrNotFound {
+			return nil, yarpcerrors.NotFoundErrorf(
+				"maintenance window %s not found", request.GetWindowId())
+		}
+		return nil, err
+	}
+
+	if window.GetState() != hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED {
+		m.metrics.CancelMaintenanceWindowFail.Inc(1)
+		return nil, yarpcerrors.FailedPreconditionErrorf(
+			"maintenance window %s is %s", window.GetId(), window.GetState())
+	}
+
+	window.State = hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_CANCELLED
+	if err := m.maintenanceWindowOps.Update(ctx, window); err != nil {
+		m.metrics.CancelMaintenanceWindowFail.Inc(1)
+		return nil, err
+	}
+
+	log.WithField("window_id", window.GetId()).
+		Info("Maintenance window cancelled")
+	m.metrics.CancelMaintenanceWindowSuccess.Inc(1)
+	return &host_svc.CancelMaintenanceWindowResponse{}, nil
+}
+
+// validateMaintenanceWindow validates a new maintenance window, and returns
+// a copy of the window with its id, state and normalized times
+func (m *serviceHandler) validateMaintenanceWindow(
+	ctx context.Context,
+	window *hpb.MaintenanceWindow,
+) (*hpb.MaintenanceWindow, error) {
+	if window == nil {
+		return nil, errNullWindow
+	}
+	if len(window.GetHostnames()) == 0 {
+		return nil, errEmptyHostnames
+	}
+	if _, err := buildMachineIDsForHosts(window.GetHostnames()); err != nil {
+		return nil, yarpcerrors.InvalidArgumentErrorf("%v", err)
+	}
+
+	start, end, err := parseMaintenanceWindowTimes(window)
+	if err != nil {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"invalid maintenance window time: %v", err)
+	}
+	if !end.IsZero() && !end.After(start) {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"maintenance window ends before it starts")
+	}
+
+	result := &hpb.MaintenanceWindow{
+		Id:        uuid.New(),
+		Hostnames: window.GetHostnames(),
+		StartTime: start.UTC().Format(time.RFC3339),
+		Reason:    window.GetReason(),
+		State:     hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+	}
+	if !end.IsZero() {
+		result.EndTime = end.UTC().Format(time.RFC3339)
+	}
+
+	// a host can only be in one pending maintenance window at a time
+	windows, err := m.maintenanceWindowOps.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hostnames := make(map[string]bool)
+	for _, hostname := range window.GetHostnames() {
+		hostnames[hostname] = true
+	}
+	for _, other := range windows {
+		if other.GetState() != hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED &&
+			other.GetState() != hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_ACTIVE {
+			continue
+		}
+		otherStart, otherEnd, err := parseMaintenanceWindowTimes(other)
+		if err != nil {
+			return nil, err
+		}
+		if (!otherEnd.IsZero() && !start.Before(otherEnd)) ||
+			(!end.IsZero() && !otherStart.Before(end)) {
+			continue
+		}
+		for _, hostname := range other.GetHostnames() {
+			if hostnames[hostname] {
+				return nil, yarpcerrors.AlreadyExistsErrorf(
+					"host %s is in maintenance window %s",
+					hostname, other.GetId())
+			}
+		}
+	}
+	return result, nil
+}
+
+// runMaintenanceWindows starts the maintenance of the hosts of the
+// scheduled windows whose start time has passed, and completes the
+// maintenance of the hosts of the active windows whose end time has passed
+func (m *serviceHandler) runMaintenanceWindows(_ *atomic.Bool) {
+	ctx, cancel := context.WithTimeout(
+		context.Background(), _maintenanceSchedulerTimeout)
+	defer cancel()
+
+	windows, err := m.maintenanceWindowOps.GetAll(ctx)
+	if err != nil {
+		m.metrics.MaintenanceWindowFail.Inc(1)
+		log.WithError(err).Warn("failed to get maintenance windows")
+		return
+	}
+
+	now := time.Now()
+	for _, window := range windows {
+		if err := m.runMaintenanceWindow(ctx, window, now); err != nil {
+			m.metrics.MaintenanceWindowFail.Inc(1)
+			log.WithError(err).
+				WithField("window_id", window.GetId()).
+				Warn("failed to run maintenance window")
+		}
+	}
+}
+
+// runMaintenanceWindow moves a maintenance window to its next state
+func (m *serviceHandler) runMaintenanceWindow(
+	ctx context.Context,
+	window *hpb.MaintenanceWindow,
+	now time.Time,
+) error {
+	start, end, err := parseMaintenanceWindowTimes(window)
+	if err != nil {
+		return err
+	}
+
+	switch window.GetState() {
+	case hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED:
+		if now.Before(start) {
+			return nil
+		}
+		return m.startMaintenanceWindow(ctx, window)
+	case hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_ACTIVE:
+		if end.IsZero() || now.Before(end) {
+			return nil
+		}
+		return m.completeMaintenanceWindow(ctx, window)
+	}
+	return nil
+}
+
+// startMaintenanceWindow puts the hosts of the window which are not in
+// maintenance yet into DRAINING state
+func (m *serviceHandler) startMaintenanceWindow(
+	ctx context.Context,
+	window *hpb.MaintenanceWindow,
+) error {
+	inMaintenance := make(map[string]bool)
+	for _, hostInfo := range m.maintenanceHostInfoMap.GetDrainingHostInfos(
+		window.GetHostnames()) {
+		inMaintenance[hostInfo.GetHostname()] = true
+	}
+	for _, hostInfo := range m.maintenanceHostInfoMap.GetDownHostInfos(
+		window.GetHostnames()) {
+		inMaintenance[hostInfo.GetHostname()] = true
+	}
+
+	var hostnames []string
+	for _, hostname := range window.GetHostnames() {
+		if !inMaintenance[hostname] {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+
+	if len(hostnames) != 0 {
+		if err := m.startMaintenance(hostnames); err != nil {
+			return err
+		}
+	}
+
+	window.State = hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_ACTIVE
+	if err := m.maintenanceWindowOps.Update(ctx, window); err != nil {
+		return err
+	}
+
+	log.WithField("window_id", window.GetId()).
+		WithField("hosts", hostnames).
+		Info("Maintenance window started")
+	m.metrics.MaintenanceWindowStarted.Inc(1)
+	return nil
+}
+
+// completeMaintenanceWindow brings UP the hosts of the window which are
+// DOWN at its end time and completes the window. The hosts which are still
+// DRAINING, because their tasks cannot be evicted without violating the
+// SLA of their jobs, stay in maintenance once they are DOWN: they are
+// brought UP by CompleteMaintenance or at the end of a later window which
+// contains them.
+func (m *serviceHandler) completeMaintenanceWindow(
+	ctx context.Context,
+	window *hpb.MaintenanceWindow,
+) error {
+	var downHosts []string
+	for _, hostInfo := range m.maintenanceHostInfoMap.GetDownHostInfos(
+		window.GetHostnames()) {
+		downHosts = append(downHosts, hostInfo.GetHostname())
+	}
+
+	if len(downHosts) != 0 {
+		if err := m.completeMaintenance(downHosts); err != nil {
+			return err
+		}
+	}
+
+	var drainingHosts []string
+	for _, hostInfo := range m.maintenanceHostInfoMap.GetDrainingHostInfos(
+		window.GetHostnames()) {
+		drainingHosts = append(drainingHosts, hostInfo.GetHostname())
+	}
+	if len(drainingHosts) != 0 {
+		m.metrics.MaintenanceWindowOverdue.Inc(1)
+		log.WithField("window_id", window.GetId()).
+			WithField("draining_hosts", drainingHosts).
+			Warn("Maintenance window ended before its hosts were drained")
+	}
+
+	window.State = hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_COMPLETED
+	if err := m.maintenanceWindowOps.Update(ctx, window); err != nil {
+		return err
+	}
+
+	log.WithField("window_id", window.GetId()).
+		WithField("hosts", downHosts).
+		WithField("draining_hosts", drainingHosts).
+		Info("Maintenance window completed")
+	m.metrics.MaintenanceWindowCompleted.Inc(1)
+	return nil
+}
+
+// parseMaintenanceWindowTimes returns the start and end times of the
+// window, the end time is zero if the window has no end time
+func parseMaintenanceWindowTimes(
+	window *hpb.MaintenanceWindow,
+) (start time.Time, end time.Time, err error) {
+	start, err = time.Parse(time.RFC3339, window.GetStartTime())
+	if err != nil {
+		return
+	}
+	if len(window.GetEndTime()) != 0 {
+		end, err = time.Parse(time.RFC3339, window.GetEndTime())
+	}
+	return
+}
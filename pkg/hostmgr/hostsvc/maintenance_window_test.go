@@ -0,0 +1,423 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostsvc
+
+import (
+	"fmt"
+	"time"
+
+	mesosmaintenance "github.com/uber/peloton/.gen/mesos/v1/maintenance"
+	mesosmaster "github.com/uber/peloton/.gen/mesos/v1/master"
+	hpb "github.com/uber/peloton/.gen/peloton/api/v0/host"
+	"github.com/uber/peloton/.gen/peloton/api/v0/host/svc"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// newMaintenanceWindow returns a maintenance window of the given hosts
+// which starts and ends at the given offsets from now
+func newMaintenanceWindow(
+	state hpb.MaintenanceWindowState,
+	start time.Duration,
+	end time.Duration,
+	hostnames ...string,
+) *hpb.MaintenanceWindow {
+	now := time.Now().UTC()
+	return &hpb.MaintenanceWindow{
+		Id:        uuid.New(),
+		Hostnames: hostnames,
+		StartTime: now.Add(start).Format(time.RFC3339),
+		EndTime:   now.Add(end).Format(time.RFC3339),
+		State:     state,
+	}
+}
+
+func (suite *HostSvcHandlerTestSuite) TestScheduleMaintenanceWindow() {
+	hostname := suite.upMachines[0].GetHostname()
+	start := time.Now().Add(time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	// the other window of the host ends before this window starts
+	other := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+		-time.Hour,
+		30*time.Minute,
+		hostname)
+
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*hpb.MaintenanceWindow{other}, nil)
+	suite.mockWindowOps.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Do(func(_ interface{}, window *hpb.MaintenanceWindow) {
+			suite.NotEmpty(window.GetId())
+			suite.Equal([]string{hostname}, window.GetHostnames())
+			suite.Equal(start.UTC().Format(time.RFC3339), window.GetStartTime())
+			suite.Equal(end.UTC().Format(time.RFC3339), window.GetEndTime())
+			suite.Equal("kernel upgrade", window.GetReason())
+			suite.Equal(
+				hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+				window.GetState())
+		}).
+		Return(nil)
+
+	resp, err := suite.handler.ScheduleMaintenanceWindow(suite.ctx,
+		&svcpb.ScheduleMaintenanceWindowRequest{
+			Window: &hpb.MaintenanceWindow{
+				Hostnames: []string{hostname},
+				StartTime: start.Format(time.RFC3339),
+				EndTime:   end.Format(time.RFC3339),
+				Reason:    "kernel upgrade",
+			},
+		})
+	suite.NoError(err)
+	suite.NotEmpty(resp.GetWindowId())
+}
+
+func (suite *HostSvcHandlerTestSuite) TestScheduleMaintenanceWindowError() {
+	hostname := suite.upMachines[0].GetHostname()
+	start := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		msg    string
+		window *hpb.MaintenanceWindow
+	}{
+		{
+			msg: "null window",
+		},
+		{
+			msg:    "no hosts",
+			window: &hpb.MaintenanceWindow{StartTime: start},
+		},
+		{
+			msg: "unknown host",
+			window: &hpb.MaintenanceWindow{
+				Hostnames: []string{"unknown"},
+				StartTime: start,
+			},
+		},
+		{
+			msg: "invalid start time",
+			window: &hpb.MaintenanceWindow{
+				Hostnames: []string{hostname},
+				StartTime: "tomorrow",
+			},
+		},
+		{
+			msg: "end before start",
+			window: &hpb.MaintenanceWindow{
+				Hostnames: []string{hostname},
+				StartTime: start,
+				EndTime:   time.Now().Format(time.RFC3339),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		resp, err := suite.handler.ScheduleMaintenanceWindow(suite.ctx,
+			&svcpb.ScheduleMaintenanceWindowRequest{Window: test.window})
+		suite.True(yarpcerrors.IsInvalidArgument(err), test.msg)
+		suite.Nil(resp, test.msg)
+	}
+
+	// the host is in another window which has not ended yet
+	other := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_ACTIVE,
+		-time.Hour,
+		2*time.Hour,
+		hostname)
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*hpb.MaintenanceWindow{other}, nil)
+	resp, err := suite.handler.ScheduleMaintenanceWindow(suite.ctx,
+		&svcpb.ScheduleMaintenanceWindowRequest{
+			Window: &hpb.MaintenanceWindow{
+				Hostnames: []string{hostname},
+				StartTime: start,
+			},
+		})
+	suite.True(yarpcerrors.IsAlreadyExists(err))
+	suite.Nil(resp)
+
+	// Test DB error
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return(nil, nil)
+	suite.mockWindowOps.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(fmt.Errorf("fake Create error"))
+	resp, err = suite.handler.ScheduleMaintenanceWindow(suite.ctx,
+		&svcpb.ScheduleMaintenanceWindowRequest{
+			Window: &hpb.MaintenanceWindow{
+				Hostnames: []string{hostname},
+				StartTime: start,
+			},
+		})
+	suite.Error(err)
+	suite.Nil(resp)
+}
+
+func (suite *HostSvcHandlerTestSuite) TestListMaintenanceWindows() {
+	later := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+		2*time.Hour,
+		3*time.Hour,
+		"host1")
+	sooner := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+		time.Hour,
+		2*time.Hour,
+		"host2")
+	completed := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_COMPLETED,
+		-2*time.Hour,
+		-time.Hour,
+		"host3")
+
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*hpb.MaintenanceWindow{later, completed, sooner}, nil).
+		Times(2)
+
+	resp, err := suite.handler.ListMaintenanceWindows(suite.ctx,
+		&svcpb.ListMaintenanceWindowsRequest{})
+	suite.NoError(err)
+	suite.Equal(
+		[]*hpb.MaintenanceWindow{completed, sooner, later},
+		resp.GetWindows())
+
+	resp, err = suite.handler.ListMaintenanceWindows(suite.ctx,
+		&svcpb.ListMaintenanceWindowsRequest{
+			States: []hpb.MaintenanceWindowState{
+				hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+			},
+		})
+	suite.NoError(err)
+	suite.Equal([]*hpb.MaintenanceWindow{sooner, later}, resp.GetWindows())
+
+	// Test DB error
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return(nil, fmt.Errorf("fake GetAll error"))
+	resp, err = suite.handler.ListMaintenanceWindows(suite.ctx,
+		&svcpb.ListMaintenanceWindowsRequest{})
+	suite.Error(err)
+	suite.Nil(resp)
+}
+
+func (suite *HostSvcHandlerTestSuite) TestCancelMaintenanceWindow() {
+	window := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+		time.Hour,
+		2*time.Hour,
+		"host1")
+
+	suite.mockWindowOps.EXPECT().
+		Get(gomock.Any(), window.GetId()).
+		Return(window, nil)
+	suite.mockWindowOps.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		Do(func(_ interface{}, window *hpb.MaintenanceWindow) {
+			suite.Equal(
+				hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_CANCELLED,
+				window.GetState())
+		}).
+		Return(nil)
+
+	resp, err := suite.handler.CancelMaintenanceWindow(suite.ctx,
+		&svcpb.CancelMaintenanceWindowRequest{WindowId: window.GetId()})
+	suite.NoError(err)
+	suite.NotNil(resp)
+}
+
+func (suite *HostSvcHandlerTestSuite) TestCancelMaintenanceWindowError() {
+	window := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_ACTIVE,
+		-time.Hour,
+		time.Hour,
+		"host1")
+
+	// Test window not found
+	suite.mockWindowOps.EXPECT().
+		Get(gomock.Any(), window.GetId()).
+		Return(nil, gocql.ErrNotFound)
+	resp, err := suite.handler.CancelMaintenanceWindow(suite.ctx,
+		&svcpb.CancelMaintenanceWindowRequest{WindowId: window.GetId()})
+	suite.True(yarpcerrors.IsNotFound(err))
+	suite.Nil(resp)
+
+	// Test window already started
+	suite.mockWindowOps.EXPECT().
+		Get(gomock.Any(), window.GetId()).
+		Return(window, nil)
+	resp, err = suite.handler.CancelMaintenanceWindow(suite.ctx,
+		&svcpb.CancelMaintenanceWindowRequest{WindowId: window.GetId()})
+	suite.True(yarpcerrors.IsFailedPrecondition(err))
+	suite.Nil(resp)
+}
+
+// TestRunMaintenanceWindowsStart tests starting the maintenance of the
+// hosts of a window whose start time has passed
+func (suite *HostSvcHandlerTestSuite) TestRunMaintenanceWindowsStart() {
+	upHost := suite.upMachines[0].GetHostname()
+	drainingHost := suite.drainingMachines[0].GetHostname()
+	started := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+		-time.Minute,
+		time.Hour,
+		upHost, drainingHost)
+	pending := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+		time.Hour,
+		2*time.Hour,
+		upHost)
+
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*hpb.MaintenanceWindow{started, pending}, nil)
+
+	// the hosts which are already in maintenance are skipped
+	suite.mockMaintenanceMap.EXPECT().
+		GetDrainingHostInfos(started.GetHostnames()).
+		Return([]*hpb.HostInfo{{
+			Hostname: drainingHost,
+			State:    hpb.HostState_HOST_STATE_DRAINING,
+		}})
+	suite.mockMaintenanceMap.EXPECT().
+		GetDownHostInfos(started.GetHostnames()).
+		Return(nil)
+
+	gomock.InOrder(
+		suite.mockMasterOperatorClient.EXPECT().GetMaintenanceSchedule().
+			Return(&mesosmaster.Response_GetMaintenanceSchedule{
+				Schedule: &mesosmaintenance.Schedule{},
+			}, nil),
+		suite.mockMasterOperatorClient.EXPECT().
+			UpdateMaintenanceSchedule(gomock.Any()).Return(nil),
+		suite.mockMaintenanceMap.EXPECT().
+			AddHostInfos(gomock.Any()),
+		suite.mockMaintenanceQueue.EXPECT().
+			Enqueue([]string{upHost}).Return(nil),
+		suite.mockWindowOps.EXPECT().
+			Update(gomock.Any(), started).
+			Return(nil),
+	)
+
+	suite.handler.runMaintenanceWindows(nil)
+	suite.Equal(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_ACTIVE,
+		started.GetState())
+	suite.Equal(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+		pending.GetState())
+}
+
+// TestRunMaintenanceWindowsComplete tests completing the maintenance of
+// the hosts of a window whose end time has passed
+func (suite *HostSvcHandlerTestSuite) TestRunMaintenanceWindowsComplete() {
+	var (
+		hosts     []string
+		hostInfos []*hpb.HostInfo
+	)
+	for _, machine := range suite.downMachines {
+		hosts = append(hosts, machine.GetHostname())
+		hostInfos = append(hostInfos, &hpb.HostInfo{
+			Hostname: machine.GetHostname(),
+			Ip:       machine.GetIp(),
+			State:    hpb.HostState_HOST_STATE_DOWN,
+		})
+	}
+	window := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_ACTIVE,
+		-2*time.Hour,
+		-time.Minute,
+		hosts...)
+
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*hpb.MaintenanceWindow{window}, nil)
+	gomock.InOrder(
+		suite.mockMaintenanceMap.EXPECT().
+			GetDownHostInfos(hosts).
+			Return(hostInfos),
+		suite.mockMaintenanceMap.EXPECT().
+			GetDownHostInfos([]string{}).
+			Return(hostInfos),
+		suite.mockMasterOperatorClient.EXPECT().
+			StopMaintenance(suite.downMachines).Return(nil),
+		suite.mockMaintenanceMap.EXPECT().
+			RemoveHostInfos(hosts),
+		suite.mockMaintenanceMap.EXPECT().
+			GetDrainingHostInfos(hosts).
+			Return(nil),
+		suite.mockWindowOps.EXPECT().
+			Update(gomock.Any(), window).
+			Return(nil),
+	)
+
+	suite.handler.runMaintenanceWindows(nil)
+	suite.Equal(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_COMPLETED,
+		window.GetState())
+}
+
+// TestRunMaintenanceWindowsOverdue tests that a window is completed at
+// its end time while some of its hosts are still DRAINING, and that these
+// hosts are not brought UP by the window once they are DOWN
+func (suite *HostSvcHandlerTestSuite) TestRunMaintenanceWindowsOverdue() {
+	hostname := suite.drainingMachines[0].GetHostname()
+	window := newMaintenanceWindow(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_ACTIVE,
+		-2*time.Hour,
+		-time.Minute,
+		hostname)
+
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*hpb.MaintenanceWindow{window}, nil)
+	suite.mockMaintenanceMap.EXPECT().
+		GetDownHostInfos(window.GetHostnames()).
+		Return(nil)
+	suite.mockMaintenanceMap.EXPECT().
+		GetDrainingHostInfos(window.GetHostnames()).
+		Return([]*hpb.HostInfo{{
+			Hostname:           hostname,
+			State:              hpb.HostState_HOST_STATE_DRAINING,
+			DrainBlockedReason: "job sla violation",
+		}})
+	suite.mockWindowOps.EXPECT().
+		Update(gomock.Any(), window).
+		Return(nil)
+
+	suite.handler.runMaintenanceWindows(nil)
+	suite.Equal(
+		hpb.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_COMPLETED,
+		window.GetState())
+
+	// the host is DOWN in the next run, and stays in maintenance
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*hpb.MaintenanceWindow{window}, nil)
+	suite.handler.runMaintenanceWindows(nil)
+
+	// Test DB error
+	suite.mockWindowOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return(nil, fmt.Errorf("fake GetAll error"))
+	suite.handler.runMaintenanceWindows(nil)
+}
@@ -29,6 +29,24 @@ type Metrics struct {
 	QueryHostsAPI     tally.Counter
 	QueryHostsSuccess tally.Counter
 	QueryHostsFail    tally.Counter
+
+	ScheduleMaintenanceWindowAPI     tally.Counter
+	ScheduleMaintenanceWindowSuccess tally.Counter
+	ScheduleMaintenanceWindowFail    tally.Counter
+
+	ListMaintenanceWindowsAPI     tally.Counter
+	ListMaintenanceWindowsSuccess tally.Counter
+	ListMaintenanceWindowsFail    tally.Counter
+
+	CancelMaintenanceWindowAPI     tally.Counter
+	CancelMaintenanceWindowSuccess tally.Counter
+	CancelMaintenanceWindowFail    tally.Counter
+
+	// Maintenance windows processed by the maintenance scheduler
+	MaintenanceWindowStarted   tally.Counter
+	MaintenanceWindowCompleted tally.Counter
+	MaintenanceWindowOverdue   tally.Counter
+	MaintenanceWindowFail      tally.Counter
 }
 
 // NewMetrics returns a new instance of host.svc.Metrics
@@ -48,5 +66,22 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		QueryHostsAPI:     apiScope.Counter("query_hosts"),
 		QueryHostsSuccess: successScope.Counter("query_hosts"),
 		QueryHostsFail:    failScope.Counter("query_hosts"),
+
+		ScheduleMaintenanceWindowAPI:     apiScope.Counter("schedule_maintenance_window"),
+		ScheduleMaintenanceWindowSuccess: successScope.Counter("schedule_maintenance_window"),
+		ScheduleMaintenanceWindowFail:    failScope.Counter("schedule_maintenance_window"),
+
+		ListMaintenanceWindowsAPI:     apiScope.Counter("list_maintenance_windows"),
+		ListMaintenanceWindowsSuccess: successScope.Counter("list_maintenance_windows"),
+		ListMaintenanceWindowsFail:    failScope.Counter("list_maintenance_windows"),
+
+		CancelMaintenanceWindowAPI:     apiScope.Counter("cancel_maintenance_window"),
+		CancelMaintenanceWindowSuccess: successScope.Counter("cancel_maintenance_window"),
+		CancelMaintenanceWindowFail:    failScope.Counter("cancel_maintenance_window"),
+
+		MaintenanceWindowStarted:   scope.Counter("maintenance_window_started"),
+		MaintenanceWindowCompleted: scope.Counter("maintenance_window_completed"),
+		MaintenanceWindowOverdue:   scope.Counter("maintenance_window_overdue"),
+		MaintenanceWindowFail:      scope.Counter("maintenance_window_fail"),
 	}
 }
@@ -0,0 +1,303 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakemaster
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	sched "github.com/uber/peloton/.gen/mesos/v1/scheduler"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
+)
+
+const (
+	_defaultAgentIP   = "127.0.0.1"
+	_defaultAgentPort = 5051
+)
+
+// AgentSpec describes an agent simulated by the fake master.
+type AgentSpec struct {
+	Hostname string
+	// IP of the agent, 127.0.0.1 by default
+	IP string
+
+	CPU  float64
+	Mem  float64
+	Disk float64
+	GPU  float64
+
+	// PortBegin and PortEnd are the first and the last port of the
+	// agent, the agent has no ports when PortEnd is 0
+	PortBegin uint32
+	PortEnd   uint32
+
+	// Attributes are the text attributes of the agent
+	Attributes map[string]string
+}
+
+// agent is an agent simulated by the fake master.
+type agent struct {
+	id         string
+	hostname   string
+	ip         string
+	attributes []*mesos.Attribute
+
+	total     scalar.Resources
+	used      scalar.Resources
+	ports     map[uint32]bool
+	usedPorts map[uint32]bool
+
+	// outstanding offer of the unused resources of the agent
+	offer *mesos.Offer
+	// resources of the agent are not offered before this time
+	refusedUntil time.Time
+	// down is set when the machine of the agent is in maintenance
+	down bool
+
+	registeredTime time.Time
+}
+
+// AddAgent adds an agent, its resources are offered to the framework by
+// the next allocation. It returns the id of the agent.
+func (m *Master) AddAgent(spec AgentSpec) (string, error) {
+	if spec.Hostname == "" {
+		return "", errors.New("agent has no hostname")
+	}
+	if spec.PortEnd < spec.PortBegin {
+		return "", fmt.Errorf(
+			"invalid port range %d-%d", spec.PortBegin, spec.PortEnd)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.agents[spec.Hostname]; ok {
+		return "", fmt.Errorf("agent %s already exists", spec.Hostname)
+	}
+
+	m.lastAgentID++
+	a := &agent{
+		id:       fmt.Sprintf("%s-S%d", m.id, m.lastAgentID),
+		hostname: spec.Hostname,
+		ip:       spec.IP,
+		total: scalar.Resources{
+			CPU:  spec.CPU,
+			Mem:  spec.Mem,
+			Disk: spec.Disk,
+			GPU:  spec.GPU,
+		},
+		ports:          make(map[uint32]bool),
+		usedPorts:      make(map[uint32]bool),
+		down:           m.downMachines[spec.Hostname] != nil,
+		registeredTime: time.Now(),
+	}
+	if a.ip == "" {
+		a.ip = _defaultAgentIP
+	}
+	if spec.PortEnd > 0 {
+		for port := spec.PortBegin; port <= spec.PortEnd; port++ {
+			a.ports[port] = true
+		}
+	}
+	var names []string
+	for name := range spec.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		name, value := name, spec.Attributes[name]
+		a.attributes = append(a.attributes, &mesos.Attribute{
+			Name: &name,
+			Type: mesos.Value_TEXT.Enum(),
+			Text: &mesos.Value_Text{Value: &value},
+		})
+	}
+	m.agents[a.hostname] = a
+	return a.id, nil
+}
+
+// FailAgent simulates the loss of an agent. The outstanding offer of the
+// agent is rescinded, its tasks are lost and the agent is removed.
+func (m *Master) FailAgent(hostname string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	a, ok := m.agents[hostname]
+	if !ok {
+		return fmt.Errorf("unknown agent %s", hostname)
+	}
+
+	m.rescindOffer(a)
+	state := mesos.TaskState_TASK_LOST
+	if m.framework.partitionAware() {
+		state = mesos.TaskState_TASK_GONE
+	}
+	m.removeAgentTasks(
+		a,
+		state,
+		mesos.TaskStatus_REASON_AGENT_REMOVED,
+		"Agent was removed")
+	delete(m.agents, hostname)
+
+	m.framework.send(&sched.Event{
+		Type:    sched.Event_FAILURE.Enum(),
+		Failure: &sched.Event_Failure{AgentId: a.agentID()},
+	})
+	return nil
+}
+
+// AgentID returns the id of the agent with the given hostname.
+func (m *Master) AgentID(hostname string) string {
+	m.Lock()
+	defer m.Unlock()
+
+	if a, ok := m.agents[hostname]; ok {
+		return a.id
+	}
+	return ""
+}
+
+// removeAgentTasks moves all the tasks of an agent to a terminal state.
+func (m *Master) removeAgentTasks(
+	a *agent,
+	state mesos.TaskState,
+	reason mesos.TaskStatus_Reason,
+	message string) {
+	for _, t := range m.sortedTasks() {
+		if t.agent == a {
+			m.updateTask(
+				t,
+				state,
+				mesos.TaskStatus_SOURCE_MASTER,
+				reason.Enum(),
+				message)
+		}
+	}
+}
+
+func (a *agent) agentID() *mesos.AgentID {
+	return &mesos.AgentID{Value: &a.id}
+}
+
+// pid returns the libprocess pid of the agent.
+func (a *agent) pid() string {
+	return fmt.Sprintf("slave(1)@%s:%d", a.ip, _defaultAgentPort)
+}
+
+// info returns the AgentInfo of the agent.
+func (a *agent) info() *mesos.AgentInfo {
+	port := int32(_defaultAgentPort)
+	return &mesos.AgentInfo{
+		Hostname:   &a.hostname,
+		Port:       &port,
+		Resources:  a.totalResources(),
+		Attributes: a.attributes,
+		Id:         a.agentID(),
+	}
+}
+
+// totalResources returns all the resources of the agent.
+func (a *agent) totalResources() []*mesos.Resource {
+	return newResources(a.total, a.ports)
+}
+
+// usedResources returns the resources used by the tasks of the agent.
+func (a *agent) usedResources() []*mesos.Resource {
+	return newResources(a.used, a.usedPorts)
+}
+
+// unusedResources returns the resources not used by any task.
+func (a *agent) unusedResources() []*mesos.Resource {
+	return newResources(a.total.Subtract(a.used), a.unusedPorts())
+}
+
+// unusedPorts returns the ports not used by any task.
+func (a *agent) unusedPorts() map[uint32]bool {
+	ports := make(map[uint32]bool)
+	for port := range a.ports {
+		if !a.usedPorts[port] {
+			ports[port] = true
+		}
+	}
+	return ports
+}
+
+// fits returns whether the unused resources of the agent contain the
+// given resources and ports.
+func (a *agent) fits(r scalar.Resources, ports map[uint32]bool) bool {
+	if !a.total.Subtract(a.used).Contains(r) {
+		return false
+	}
+	for port := range ports {
+		if !a.ports[port] || a.usedPorts[port] {
+			return false
+		}
+	}
+	return true
+}
+
+// allocate marks resources and ports as used by a task.
+func (a *agent) allocate(r scalar.Resources, ports map[uint32]bool) {
+	a.used = a.used.Add(r)
+	for port := range ports {
+		a.usedPorts[port] = true
+	}
+}
+
+// release marks resources and ports as not used anymore.
+func (a *agent) release(r scalar.Resources, ports map[uint32]bool) {
+	a.used = a.used.Subtract(r)
+	for port := range ports {
+		delete(a.usedPorts, port)
+	}
+}
+
+// newResources builds the Mesos resources of scalar resources and ports.
+func newResources(
+	r scalar.Resources,
+	ports map[uint32]bool) []*mesos.Resource {
+	var resources []*mesos.Resource
+	for _, s := range []struct {
+		name  string
+		value float64
+	}{
+		{common.MesosCPU, r.GetCPU()},
+		{common.MesosMem, r.GetMem()},
+		{common.MesosDisk, r.GetDisk()},
+		{common.MesosGPU, r.GetGPU()},
+	} {
+		if s.value < util.ResourceEpsilon {
+			continue
+		}
+		resources = append(resources, util.NewMesosResourceBuilder().
+			WithName(s.name).
+			WithValue(s.value).
+			Build())
+	}
+	if len(ports) > 0 {
+		resources = append(resources, util.NewMesosResourceBuilder().
+			WithName(common.MesosPorts).
+			WithType(mesos.Value_RANGES).
+			WithRanges(util.CreatePortRanges(ports)).
+			Build())
+	}
+	return resources
+}
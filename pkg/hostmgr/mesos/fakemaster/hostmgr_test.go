@@ -0,0 +1,286 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakemaster
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	sched "github.com/uber/peloton/.gen/mesos/v1/scheduler"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/util"
+	hostmgrmesos "github.com/uber/peloton/pkg/hostmgr/mesos"
+	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb"
+	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/transport/mhttp"
+	storage_mocks "github.com/uber/peloton/pkg/storage/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc"
+)
+
+const _testFrameworkName = "peloton"
+
+// HostMgrTestSuite runs the Mesos inbound, outbounds and clients of the
+// host manager against the fake master.
+type HostMgrTestSuite struct {
+	suite.Suite
+
+	ctrl       *gomock.Controller
+	store      *storage_mocks.MockFrameworkInfoStore
+	master     *Master
+	dispatcher *yarpc.Dispatcher
+	inbound    mhttp.Inbound
+	driver     hostmgrmesos.SchedulerDriver
+
+	schedulerClient mpb.SchedulerClient
+	operatorClient  mpb.MasterOperatorClient
+
+	streamID    string
+	frameworkID chan string
+	offers      chan *mesos.Offer
+	updates     chan *mesos.TaskStatus
+}
+
+func (suite *HostMgrTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.store = storage_mocks.NewMockFrameworkInfoStore(suite.ctrl)
+	suite.frameworkID = make(chan string, 1)
+	suite.offers = make(chan *mesos.Offer, 10)
+	suite.updates = make(chan *mesos.TaskStatus, 10)
+
+	suite.master = New(
+		WithOfferInterval(0),
+		WithHeartbeatInterval(time.Hour))
+	suite.NoError(suite.master.Start())
+	_, err := suite.master.AddAgent(AgentSpec{
+		Hostname:  _testHostname,
+		CPU:       4,
+		Mem:       1024,
+		Disk:      2048,
+		PortBegin: 31000,
+		PortEnd:   31009,
+	})
+	suite.NoError(err)
+
+	// wired the same way as by the host manager, with the fake master as
+	// the detector of the Mesos leader
+	config := &hostmgrmesos.Config{
+		Framework: &hostmgrmesos.FrameworkConfig{
+			User: "peloton",
+			Name: _testFrameworkName,
+			Role: "peloton",
+		},
+		Encoding: mpb.ContentTypeProtobuf,
+	}
+	suite.driver = hostmgrmesos.InitSchedulerDriver(
+		config, suite.store, http.Header{})
+	suite.inbound = mhttp.NewInbound(tally.NoopScope, suite.driver)
+	suite.dispatcher = yarpc.NewDispatcher(yarpc.Config{
+		Name:     common.PelotonHostManager,
+		Inbounds: yarpc.Inbounds{suite.inbound},
+		Outbounds: yarpc.Outbounds{
+			common.MesosMasterScheduler: mhttp.NewOutbound(
+				tally.NoopScope,
+				suite.master,
+				suite.driver.Endpoint(),
+				http.Header{},
+			),
+			common.MesosMasterOperator: mhttp.NewOutbound(
+				tally.NoopScope,
+				suite.master,
+				url.URL{
+					Scheme: "http",
+					Path:   common.MesosMasterOperatorEndPoint,
+				},
+				http.Header{},
+			),
+		},
+	})
+	suite.schedulerClient = mpb.NewSchedulerClient(
+		suite.dispatcher.ClientConfig(common.MesosMasterScheduler),
+		config.Encoding,
+	)
+	suite.operatorClient = mpb.NewMasterOperatorClient(
+		suite.dispatcher.ClientConfig(common.MesosMasterOperator),
+		config.Encoding,
+	)
+
+	hostmgrmesos.InitManager(suite.dispatcher, config, suite.store)
+	mpb.Register(suite.dispatcher, hostmgrmesos.ServiceName, mpb.Procedure(
+		sched.Event_OFFERS.String(),
+		func(_ context.Context, event *sched.Event) error {
+			for _, offer := range event.GetOffers().GetOffers() {
+				suite.offers <- offer
+			}
+			return nil
+		}))
+	mpb.Register(suite.dispatcher, hostmgrmesos.ServiceName, mpb.Procedure(
+		sched.Event_UPDATE.String(),
+		func(_ context.Context, event *sched.Event) error {
+			suite.updates <- event.GetUpdate().GetStatus()
+			return nil
+		}))
+	suite.NoError(suite.dispatcher.Start())
+}
+
+func (suite *HostMgrTestSuite) TearDownTest() {
+	// stopping the master ends the event stream of the inbound first
+	suite.NoError(suite.master.Stop())
+	suite.NoError(suite.dispatcher.Stop())
+	suite.ctrl.Finish()
+}
+
+func TestHostMgr(t *testing.T) {
+	suite.Run(t, new(HostMgrTestSuite))
+}
+
+// subscribe starts the Mesos loop of the inbound, and returns the id of
+// the subscribed framework.
+func (suite *HostMgrTestSuite) subscribe() *mesos.FrameworkID {
+	suite.store.EXPECT().
+		GetFrameworkID(gomock.Any(), _testFrameworkName).
+		Return("", nil).
+		AnyTimes()
+	suite.store.EXPECT().
+		SetMesosStreamID(gomock.Any(), _testFrameworkName, gomock.Any()).
+		Do(func(_ context.Context, _ string, streamID string) {
+			suite.streamID = streamID
+		}).
+		Return(nil)
+	suite.store.EXPECT().
+		SetMesosFrameworkID(gomock.Any(), _testFrameworkName, gomock.Any()).
+		Do(func(_ context.Context, _ string, frameworkID string) {
+			suite.frameworkID <- frameworkID
+		}).
+		Return(nil)
+
+	_, err := suite.inbound.StartMesosLoop(
+		context.Background(), suite.master.HostPort())
+	suite.Require().NoError(err)
+	suite.NotEmpty(suite.streamID)
+
+	select {
+	case frameworkID := <-suite.frameworkID:
+		return &mesos.FrameworkID{Value: &frameworkID}
+	case <-time.After(_eventTimeout):
+		suite.FailNow("timed out waiting for subscribed event")
+	}
+	return nil
+}
+
+// nextOffer returns the next offer received by the inbound.
+func (suite *HostMgrTestSuite) nextOffer() *mesos.Offer {
+	select {
+	case offer := <-suite.offers:
+		return offer
+	case <-time.After(_eventTimeout):
+		suite.FailNow("timed out waiting for offer")
+	}
+	return nil
+}
+
+// nextUpdate returns the status of the next update received by the
+// inbound, which must be of the given task and state.
+func (suite *HostMgrTestSuite) nextUpdate(
+	taskID string,
+	state mesos.TaskState) *mesos.TaskStatus {
+	select {
+	case status := <-suite.updates:
+		suite.Equal(taskID, status.GetTaskId().GetValue())
+		suite.Equal(state, status.GetState())
+		return status
+	case <-time.After(_eventTimeout):
+		suite.FailNow("timed out waiting for update", state.String())
+	}
+	return nil
+}
+
+// TestLaunchTask tests launching a task on an offer received by the
+// inbound of the host manager through its scheduler client, and reading
+// the agent of the task through its operator client.
+func (suite *HostMgrTestSuite) TestLaunchTask() {
+	frameworkID := suite.subscribe()
+
+	suite.master.SendOffers()
+	offer := suite.nextOffer()
+	suite.Equal(_testHostname, offer.GetHostname())
+
+	taskID := "task-1"
+	suite.NoError(suite.schedulerClient.Call(suite.streamID, &sched.Call{
+		FrameworkId: frameworkID,
+		Type:        sched.Call_ACCEPT.Enum(),
+		Accept: &sched.Call_Accept{
+			OfferIds: []*mesos.OfferID{offer.GetId()},
+			Operations: []*mesos.Offer_Operation{{
+				Type: mesos.Offer_Operation_LAUNCH.Enum(),
+				Launch: &mesos.Offer_Operation_Launch{
+					TaskInfos: []*mesos.TaskInfo{{
+						Name:    proto.String(taskID),
+						TaskId:  &mesos.TaskID{Value: proto.String(taskID)},
+						AgentId: offer.GetAgentId(),
+						Resources: []*mesos.Resource{
+							util.NewMesosResourceBuilder().
+								WithName(common.MesosCPU).
+								WithValue(1).
+								Build(),
+						},
+					}},
+				},
+			}},
+		},
+	}))
+
+	for _, state := range []mesos.TaskState{
+		mesos.TaskState_TASK_STARTING,
+		mesos.TaskState_TASK_RUNNING,
+	} {
+		status := suite.nextUpdate(taskID, state)
+		suite.NoError(suite.schedulerClient.Call(suite.streamID, &sched.Call{
+			FrameworkId: frameworkID,
+			Type:        sched.Call_ACKNOWLEDGE.Enum(),
+			Acknowledge: &sched.Call_Acknowledge{
+				AgentId: status.GetAgentId(),
+				TaskId:  status.GetTaskId(),
+				Uuid:    status.GetUuid(),
+			},
+		}))
+	}
+	suite.Equal(0, suite.master.PendingAcknowledgements())
+	suite.Equal(
+		map[string]mesos.TaskState{taskID: mesos.TaskState_TASK_RUNNING},
+		suite.master.TaskStates())
+
+	agents, err := suite.operatorClient.Agents()
+	suite.NoError(err)
+	suite.Len(agents.GetAgents(), 1)
+	suite.Equal(
+		_testHostname,
+		agents.GetAgents()[0].GetAgentInfo().GetHostname())
+
+	allocated, err := suite.operatorClient.AllocatedResources(
+		frameworkID.GetValue())
+	suite.NoError(err)
+	suite.Len(allocated, 1)
+	suite.Equal(common.MesosCPU, allocated[0].GetName())
+	suite.Equal(1.0, allocated[0].GetScalar().GetValue())
+}
@@ -0,0 +1,426 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakemaster implements an in-process fake of a Mesos master which
+// speaks the v1 scheduler HTTP streaming API and the v1 operator API, so
+// that the Peloton components can be run against simulated agents in
+// `go test` instead of the docker minicluster.
+package fakemaster
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	mesos_maintenance "github.com/uber/peloton/.gen/mesos/v1/maintenance"
+	mesos_quota "github.com/uber/peloton/.gen/mesos/v1/quota"
+	sched "github.com/uber/peloton/.gen/mesos/v1/scheduler"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// path of the scheduler API
+	_schedulerPath = "/api/v1/scheduler"
+
+	// header carrying the id of the event stream of the framework
+	_streamIDHeader = "Mesos-Stream-Id"
+
+	// version reported for the simulated agents
+	_agentVersion = "1.7.1"
+
+	_defaultOfferInterval     = time.Second
+	_defaultHeartbeatInterval = 15 * time.Second
+)
+
+var (
+	errNotStarted = errors.New("fake master is not started")
+)
+
+// Option is an option for the fake master.
+type Option func(*Master)
+
+// WithOfferInterval sets the interval at which the unused resources of the
+// agents are offered to the framework. Offers are only sent by SendOffers
+// when the interval is not positive.
+func WithOfferInterval(interval time.Duration) Option {
+	return func(m *Master) {
+		m.offerInterval = interval
+	}
+}
+
+// WithHeartbeatInterval sets the interval of the HEARTBEAT events.
+func WithHeartbeatInterval(interval time.Duration) Option {
+	return func(m *Master) {
+		m.heartbeatInterval = interval
+	}
+}
+
+// WithLaunchStates sets the states the launched tasks are moved through
+// by their simulated executors, TASK_STARTING and TASK_RUNNING by default.
+func WithLaunchStates(states ...mesos.TaskState) Option {
+	return func(m *Master) {
+		m.launchStates = states
+	}
+}
+
+// WithRefuseSeconds sets how long the resources of declined or accepted
+// offers are not offered again when the call sets no filters.
+func WithRefuseSeconds(seconds float64) Option {
+	return func(m *Master) {
+		m.refuseSeconds = seconds
+	}
+}
+
+// WithQuota sets the quota guarantee of a role returned by GET_QUOTA.
+func WithQuota(role string, guarantee []*mesos.Resource) Option {
+	return func(m *Master) {
+		m.quotas = append(m.quotas, &mesos_quota.QuotaInfo{
+			Role:      &role,
+			Guarantee: guarantee,
+		})
+	}
+}
+
+// Master is an in-process fake of a Mesos master. It accepts a single
+// framework subscribing through the scheduler API, offers the resources of
+// the simulated agents to it, launches and kills tasks on the agents and
+// sends their status updates. Tests use it to inject task failures, agent
+// losses and maintenance.
+type Master struct {
+	sync.Mutex
+
+	id string
+
+	offerInterval     time.Duration
+	heartbeatInterval time.Duration
+	launchStates      []mesos.TaskState
+	refuseSeconds     float64
+	quotas            []*mesos_quota.QuotaInfo
+
+	listener net.Listener
+	server   *http.Server
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	framework *framework
+
+	// agents by hostname
+	agents map[string]*agent
+	// agents by id of their outstanding offer
+	offers map[string]*agent
+	// tasks by task id
+	tasks map[string]*task
+	// uuids of the status updates not acknowledged yet
+	pendingAcks map[string]bool
+
+	schedule *mesos_maintenance.Schedule
+	// machines in maintenance by hostname
+	downMachines map[string]*mesos.MachineID
+
+	lastAgentID int
+	lastOfferID int
+}
+
+// New creates a fake Mesos master.
+func New(opts ...Option) *Master {
+	m := &Master{
+		id:                uuid.New(),
+		offerInterval:     _defaultOfferInterval,
+		heartbeatInterval: _defaultHeartbeatInterval,
+		launchStates: []mesos.TaskState{
+			mesos.TaskState_TASK_STARTING,
+			mesos.TaskState_TASK_RUNNING,
+		},
+		refuseSeconds: mesos.Default_Filters_RefuseSeconds,
+		agents:        make(map[string]*agent),
+		offers:        make(map[string]*agent),
+		tasks:         make(map[string]*task),
+		pendingAcks:   make(map[string]bool),
+		schedule:      &mesos_maintenance.Schedule{},
+		downMachines:  make(map[string]*mesos.MachineID),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Start starts serving the scheduler and operator APIs on a random
+// local port.
+func (m *Master) Start() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(_schedulerPath, m.handleScheduler)
+	mux.HandleFunc(common.MesosMasterOperatorEndPoint, m.handleOperator)
+
+	server := &http.Server{Handler: mux}
+	m.Lock()
+	m.listener = listener
+	m.server = server
+	m.stopChan = make(chan struct{})
+	m.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			log.WithError(err).Error("fake mesos master stopped serving")
+		}
+	}()
+
+	if m.offerInterval > 0 {
+		m.wg.Add(1)
+		go m.runAllocator()
+	}
+
+	log.WithField("hostport", listener.Addr().String()).
+		Info("Fake mesos master started")
+	return nil
+}
+
+// Stop closes the event stream of the framework and stops serving.
+func (m *Master) Stop() error {
+	m.Lock()
+	if m.server == nil {
+		m.Unlock()
+		return errNotStarted
+	}
+	close(m.stopChan)
+	if m.framework != nil {
+		m.framework.stream.close()
+	}
+	server := m.server
+	m.server = nil
+	m.Unlock()
+
+	err := server.Close()
+	m.wg.Wait()
+	return err
+}
+
+// HostPort returns the address the master is listening on. It implements
+// mhttp.LeaderDetector so that the Mesos outbounds can be pointed at the
+// fake master.
+func (m *Master) HostPort() string {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.listener == nil {
+		return ""
+	}
+	return m.listener.Addr().String()
+}
+
+// runAllocator periodically offers the unused resources of the agents.
+func (m *Master) runAllocator() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.offerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			m.SendOffers()
+		}
+	}
+}
+
+// SendOffers offers the unused resources of the agents to the framework.
+// Agents which have an outstanding offer, are in maintenance or whose
+// resources are filtered by a previous decline are skipped.
+func (m *Master) SendOffers() {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.framework == nil || m.framework.suppressed {
+		return
+	}
+
+	now := time.Now()
+	var offers []*mesos.Offer
+	for _, a := range m.sortedAgents() {
+		if a.down || a.offer != nil || now.Before(a.refusedUntil) {
+			continue
+		}
+		resources := a.unusedResources()
+		if len(resources) == 0 {
+			continue
+		}
+
+		m.lastOfferID++
+		offerID := fmt.Sprintf("%s-O%d", m.id, m.lastOfferID)
+		a.offer = &mesos.Offer{
+			Id:             &mesos.OfferID{Value: &offerID},
+			FrameworkId:    m.framework.id(),
+			AgentId:        a.agentID(),
+			Hostname:       &a.hostname,
+			Resources:      resources,
+			Attributes:     a.attributes,
+			Unavailability: m.unavailability(a.hostname),
+		}
+		m.offers[offerID] = a
+		offers = append(offers, a.offer)
+	}
+
+	if len(offers) == 0 {
+		return
+	}
+	m.framework.send(&sched.Event{
+		Type:   sched.Event_OFFERS.Enum(),
+		Offers: &sched.Event_Offers{Offers: offers},
+	})
+}
+
+// RescindOffer rescinds the outstanding offer of an agent.
+func (m *Master) RescindOffer(hostname string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	a, ok := m.agents[hostname]
+	if !ok || a.offer == nil {
+		return fmt.Errorf("agent %s has no outstanding offer", hostname)
+	}
+	m.rescindOffer(a)
+	return nil
+}
+
+// DisconnectFramework closes the event stream of the framework, as a
+// master failover would, the framework is expected to subscribe again.
+func (m *Master) DisconnectFramework() error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.framework == nil {
+		return errors.New("no framework is subscribed")
+	}
+	m.framework.stream.close()
+	return nil
+}
+
+// PendingAcknowledgements returns the number of status updates which have
+// not been acknowledged by the framework.
+func (m *Master) PendingAcknowledgements() int {
+	m.Lock()
+	defer m.Unlock()
+
+	return len(m.pendingAcks)
+}
+
+// rescindOffer rescinds the outstanding offer of an agent, it must be
+// called with the lock held.
+func (m *Master) rescindOffer(a *agent) {
+	if a.offer == nil {
+		return
+	}
+	offerID := a.offer.GetId()
+	m.removeOffer(offerID.GetValue(), 0)
+	m.framework.send(&sched.Event{
+		Type:    sched.Event_RESCIND.Enum(),
+		Rescind: &sched.Event_Rescind{OfferId: offerID},
+	})
+}
+
+// removeOffer removes an outstanding offer and filters the resources of
+// its agent for the given duration, it returns the agent of the offer.
+func (m *Master) removeOffer(offerID string, refuse time.Duration) *agent {
+	a, ok := m.offers[offerID]
+	if !ok {
+		return nil
+	}
+	delete(m.offers, offerID)
+	a.offer = nil
+	if refuse > 0 {
+		a.refusedUntil = time.Now().Add(refuse)
+	}
+	return a
+}
+
+// removeOffers removes all the outstanding offers.
+func (m *Master) removeOffers() {
+	for offerID := range m.offers {
+		m.removeOffer(offerID, 0)
+	}
+}
+
+// refuseDuration returns how long resources are filtered for the filters
+// of an accept or a decline call.
+func (m *Master) refuseDuration(filters *mesos.Filters) time.Duration {
+	seconds := m.refuseSeconds
+	if filters != nil && filters.RefuseSeconds != nil {
+		seconds = filters.GetRefuseSeconds()
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// sortedAgents returns the agents ordered by hostname.
+func (m *Master) sortedAgents() []*agent {
+	var agents []*agent
+	for _, a := range m.agents {
+		agents = append(agents, a)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].hostname < agents[j].hostname
+	})
+	return agents
+}
+
+// contentType returns the encoding of a Mesos media type such as
+// application/x-protobuf.
+func contentType(mediaType string) (string, error) {
+	t, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return "", err
+	}
+	switch t {
+	case "application/" + mpb.ContentTypeJSON:
+		return mpb.ContentTypeJSON, nil
+	case "application/" + mpb.ContentTypeProtobuf:
+		return mpb.ContentTypeProtobuf, nil
+	}
+	return "", fmt.Errorf("unsupported media type %s", mediaType)
+}
+
+// decode decodes a call encoded in the given content type.
+func decode(r io.Reader, encoding string, msg proto.Message) error {
+	if encoding == mpb.ContentTypeJSON {
+		return jsonpb.Unmarshal(r, msg)
+	}
+
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(body, msg)
+}
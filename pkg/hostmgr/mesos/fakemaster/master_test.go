@@ -0,0 +1,736 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakemaster
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	mesos_maintenance "github.com/uber/peloton/.gen/mesos/v1/maintenance"
+	mesos_master "github.com/uber/peloton/.gen/mesos/v1/master"
+	sched "github.com/uber/peloton/.gen/mesos/v1/scheduler"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	_testHostname = "agent-1"
+	_eventTimeout = 5 * time.Second
+)
+
+type FakeMasterTestSuite struct {
+	suite.Suite
+
+	master      *Master
+	encoding    string
+	body        io.ReadCloser
+	events      chan *sched.Event
+	streamID    string
+	frameworkID *mesos.FrameworkID
+}
+
+func (suite *FakeMasterTestSuite) SetupTest() {
+	suite.master = New(
+		WithOfferInterval(0),
+		WithHeartbeatInterval(time.Hour))
+	suite.encoding = mpb.ContentTypeProtobuf
+	suite.NoError(suite.master.Start())
+
+	_, err := suite.master.AddAgent(AgentSpec{
+		Hostname:   _testHostname,
+		CPU:        4,
+		Mem:        1024,
+		Disk:       2048,
+		PortBegin:  31000,
+		PortEnd:    31009,
+		Attributes: map[string]string{"rack": "r1"},
+	})
+	suite.NoError(err)
+}
+
+func (suite *FakeMasterTestSuite) TearDownTest() {
+	suite.NoError(suite.master.Stop())
+	if suite.body != nil {
+		suite.body.Close()
+		suite.body = nil
+	}
+}
+
+func TestFakeMaster(t *testing.T) {
+	suite.Run(t, new(FakeMasterTestSuite))
+}
+
+// post sends a call to the fake master in the encoding of the suite.
+func (suite *FakeMasterTestSuite) post(
+	path string,
+	call proto.Message) *http.Response {
+	body, err := mpb.MarshalPbMessage(call, suite.encoding)
+	suite.Require().NoError(err)
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		fmt.Sprintf("http://%s%s", suite.master.HostPort(), path),
+		bytes.NewReader([]byte(body)))
+	suite.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/"+suite.encoding)
+	req.Header.Set("Accept", "application/"+suite.encoding)
+	if suite.streamID != "" {
+		req.Header.Set(_streamIDHeader, suite.streamID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+// subscribe subscribes a framework with the given capabilities and reads
+// its events in the background.
+func (suite *FakeMasterTestSuite) subscribe(
+	capabilities ...mesos.FrameworkInfo_Capability_Type) {
+	info := &mesos.FrameworkInfo{
+		User: proto.String("peloton"),
+		Name: proto.String("peloton"),
+		Role: proto.String("peloton"),
+		Id:   suite.frameworkID,
+	}
+	for _, c := range capabilities {
+		info.Capabilities = append(
+			info.Capabilities,
+			&mesos.FrameworkInfo_Capability{Type: c.Enum()})
+	}
+
+	suite.streamID = ""
+	resp := suite.post(_schedulerPath, &sched.Call{
+		Type:      sched.Call_SUBSCRIBE.Enum(),
+		Subscribe: &sched.Call_Subscribe{FrameworkInfo: info},
+	})
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	suite.Require().Len(resp.Header[_streamIDHeader], 1)
+	suite.streamID = resp.Header.Get(_streamIDHeader)
+	suite.body = resp.Body
+
+	events := make(chan *sched.Event, 100)
+	suite.events = events
+	go func() {
+		defer close(events)
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, _, err := reader.ReadLine()
+			if err != nil {
+				return
+			}
+			length, err := strconv.Atoi(string(line))
+			if err != nil {
+				return
+			}
+			buf := make([]byte, length)
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				return
+			}
+			event := &sched.Event{}
+			if err := mpb.UnmarshalPbMessage(
+				buf, reflect.ValueOf(event), suite.encoding); err != nil {
+				return
+			}
+			events <- event
+		}
+	}()
+
+	event := suite.nextEvent(sched.Event_SUBSCRIBED)
+	suite.frameworkID = event.GetSubscribed().GetFrameworkId()
+	suite.NotEmpty(suite.frameworkID.GetValue())
+}
+
+// nextEvent returns the next event, which must be of the given type.
+func (suite *FakeMasterTestSuite) nextEvent(
+	eventType sched.Event_Type) *sched.Event {
+	select {
+	case event, ok := <-suite.events:
+		suite.Require().True(ok, "event stream is closed")
+		suite.Require().Equal(eventType, event.GetType())
+		return event
+	case <-time.After(_eventTimeout):
+		suite.FailNow("timed out waiting for event", eventType.String())
+	}
+	return nil
+}
+
+// nextUpdate returns the status of the next event, which must be an update
+// of the given task to the given state.
+func (suite *FakeMasterTestSuite) nextUpdate(
+	taskID string,
+	state mesos.TaskState) *mesos.TaskStatus {
+	status := suite.nextEvent(sched.Event_UPDATE).GetUpdate().GetStatus()
+	suite.Equal(taskID, status.GetTaskId().GetValue())
+	suite.Equal(state, status.GetState())
+	return status
+}
+
+// noEvent verifies that no event is received for a while.
+func (suite *FakeMasterTestSuite) noEvent() {
+	select {
+	case event := <-suite.events:
+		suite.Failf("unexpected event", "%v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// call sends a scheduler call and returns the response status code.
+func (suite *FakeMasterTestSuite) call(call *sched.Call) int {
+	call.FrameworkId = suite.frameworkID
+	resp := suite.post(_schedulerPath, call)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+// operator sends an operator call and returns its response.
+func (suite *FakeMasterTestSuite) operator(
+	call *mesos_master.Call) (*mesos_master.Response, int) {
+	resp := suite.post(common.MesosMasterOperatorEndPoint, call)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	response := &mesos_master.Response{}
+	if resp.StatusCode == http.StatusOK {
+		suite.Require().NoError(proto.Unmarshal(body, response))
+	}
+	return response, resp.StatusCode
+}
+
+// offer sends the offers and returns the offer of the test agent.
+func (suite *FakeMasterTestSuite) offer() *mesos.Offer {
+	suite.master.SendOffers()
+	offers := suite.nextEvent(sched.Event_OFFERS).GetOffers().GetOffers()
+	suite.Require().Len(offers, 1)
+	return offers[0]
+}
+
+// launch launches a task using the given offer.
+func (suite *FakeMasterTestSuite) launch(
+	offer *mesos.Offer,
+	taskID string,
+	cpu float64) int {
+	return suite.call(&sched.Call{
+		Type: sched.Call_ACCEPT.Enum(),
+		Accept: &sched.Call_Accept{
+			OfferIds: []*mesos.OfferID{offer.GetId()},
+			Operations: []*mesos.Offer_Operation{{
+				Type: mesos.Offer_Operation_LAUNCH.Enum(),
+				Launch: &mesos.Offer_Operation_Launch{
+					TaskInfos: []*mesos.TaskInfo{{
+						Name:    proto.String(taskID),
+						TaskId:  &mesos.TaskID{Value: proto.String(taskID)},
+						AgentId: offer.GetAgentId(),
+						Resources: []*mesos.Resource{
+							util.NewMesosResourceBuilder().
+								WithName(common.MesosCPU).
+								WithValue(cpu).
+								Build(),
+						},
+					}},
+				},
+			}},
+		},
+	})
+}
+
+// launchRunning launches a task and waits until it is running.
+func (suite *FakeMasterTestSuite) launchRunning(taskID string) {
+	suite.Equal(http.StatusAccepted, suite.launch(suite.offer(), taskID, 1))
+	suite.nextUpdate(taskID, mesos.TaskState_TASK_STARTING)
+	suite.nextUpdate(taskID, mesos.TaskState_TASK_RUNNING)
+}
+
+// scheduleMaintenance adds the test agent to the maintenance schedule.
+func (suite *FakeMasterTestSuite) scheduleMaintenance() *mesos.MachineID {
+	machine := &mesos.MachineID{
+		Hostname: proto.String(_testHostname),
+		Ip:       proto.String("127.0.0.1"),
+	}
+	_, code := suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_UPDATE_MAINTENANCE_SCHEDULE.Enum(),
+		UpdateMaintenanceSchedule: &mesos_master.Call_UpdateMaintenanceSchedule{
+			Schedule: &mesos_maintenance.Schedule{
+				Windows: []*mesos_maintenance.Window{{
+					MachineIds: []*mesos.MachineID{machine},
+					Unavailability: &mesos.Unavailability{
+						Start: &mesos.TimeInfo{
+							Nanoseconds: proto.Int64(time.Now().UnixNano()),
+						},
+					},
+				}},
+			},
+		},
+	})
+	suite.Equal(http.StatusOK, code)
+	return machine
+}
+
+// TestSubscribeJSON tests subscribing with the JSON encoding.
+func (suite *FakeMasterTestSuite) TestSubscribeJSON() {
+	suite.encoding = mpb.ContentTypeJSON
+	suite.subscribe()
+	suite.launchRunning("task-1")
+}
+
+// TestResubscribe tests that a framework subscribing again gets a new
+// stream and that the calls with the old stream id are rejected.
+func (suite *FakeMasterTestSuite) TestResubscribe() {
+	suite.subscribe()
+	oldStreamID := suite.streamID
+	frameworkID := suite.frameworkID.GetValue()
+
+	suite.NoError(suite.master.DisconnectFramework())
+	_, ok := <-suite.events
+	suite.False(ok)
+
+	suite.subscribe()
+	suite.Equal(frameworkID, suite.frameworkID.GetValue())
+	suite.NotEqual(oldStreamID, suite.streamID)
+
+	suite.streamID = oldStreamID
+	suite.Equal(
+		http.StatusBadRequest,
+		suite.call(&sched.Call{Type: sched.Call_REVIVE.Enum()}))
+}
+
+// TestSubscribeOtherFramework tests that a second framework cannot
+// subscribe.
+func (suite *FakeMasterTestSuite) TestSubscribeOtherFramework() {
+	suite.subscribe()
+	suite.streamID = ""
+	resp := suite.post(_schedulerPath, &sched.Call{
+		Type: sched.Call_SUBSCRIBE.Enum(),
+		Subscribe: &sched.Call_Subscribe{
+			FrameworkInfo: &mesos.FrameworkInfo{
+				User: proto.String("other"),
+				Name: proto.String("other"),
+			},
+		},
+	})
+	defer resp.Body.Close()
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+// TestOffers tests the resources and attributes of the offers.
+func (suite *FakeMasterTestSuite) TestOffers() {
+	suite.subscribe()
+	offer := suite.offer()
+
+	suite.Equal(_testHostname, offer.GetHostname())
+	suite.Equal(
+		suite.master.AgentID(_testHostname),
+		offer.GetAgentId().GetValue())
+	suite.Equal(suite.frameworkID.GetValue(), offer.GetFrameworkId().GetValue())
+	suite.Len(offer.GetAttributes(), 1)
+	suite.Equal("r1", offer.GetAttributes()[0].GetText().GetValue())
+
+	resources := make(map[string]float64)
+	for _, r := range offer.GetResources() {
+		resources[r.GetName()] = r.GetScalar().GetValue()
+	}
+	suite.Equal(4.0, resources[common.MesosCPU])
+	suite.Equal(1024.0, resources[common.MesosMem])
+	suite.Equal(2048.0, resources[common.MesosDisk])
+	suite.Len(util.GetPortsSetFromResources(offer.GetResources()), 10)
+
+	// the agent has an outstanding offer
+	suite.master.SendOffers()
+	suite.noEvent()
+
+	suite.NoError(suite.master.RescindOffer(_testHostname))
+	rescind := suite.nextEvent(sched.Event_RESCIND)
+	suite.Equal(offer.GetId().GetValue(), rescind.GetRescind().GetOfferId().GetValue())
+	suite.Error(suite.master.RescindOffer(_testHostname))
+}
+
+// TestDeclineAndRevive tests that declined resources are filtered until
+// the framework revives offers.
+func (suite *FakeMasterTestSuite) TestDeclineAndRevive() {
+	suite.subscribe()
+	offer := suite.offer()
+
+	suite.Equal(http.StatusAccepted, suite.call(&sched.Call{
+		Type: sched.Call_DECLINE.Enum(),
+		Decline: &sched.Call_Decline{
+			OfferIds: []*mesos.OfferID{offer.GetId()},
+			Filters:  &mesos.Filters{RefuseSeconds: proto.Float64(60)},
+		},
+	}))
+	suite.master.SendOffers()
+	suite.noEvent()
+
+	suite.Equal(
+		http.StatusAccepted,
+		suite.call(&sched.Call{Type: sched.Call_REVIVE.Enum()}))
+	suite.offer()
+}
+
+// TestSuppress tests that a suppressed framework receives no offers.
+func (suite *FakeMasterTestSuite) TestSuppress() {
+	suite.subscribe()
+	suite.Equal(
+		http.StatusAccepted,
+		suite.call(&sched.Call{Type: sched.Call_SUPPRESS.Enum()}))
+	suite.master.SendOffers()
+	suite.noEvent()
+}
+
+// TestLaunchAndAcknowledge tests launching a task and acknowledging its
+// status updates.
+func (suite *FakeMasterTestSuite) TestLaunchAndAcknowledge() {
+	suite.subscribe()
+	suite.Equal(http.StatusAccepted, suite.launch(suite.offer(), "task-1", 1))
+
+	starting := suite.nextUpdate("task-1", mesos.TaskState_TASK_STARTING)
+	running := suite.nextUpdate("task-1", mesos.TaskState_TASK_RUNNING)
+	suite.Equal(mesos.TaskStatus_SOURCE_EXECUTOR, running.GetSource())
+	suite.Equal(2, suite.master.PendingAcknowledgements())
+	suite.Equal(
+		map[string]mesos.TaskState{"task-1": mesos.TaskState_TASK_RUNNING},
+		suite.master.TaskStates())
+
+	for _, status := range []*mesos.TaskStatus{starting, running} {
+		suite.Equal(http.StatusAccepted, suite.call(&sched.Call{
+			Type: sched.Call_ACKNOWLEDGE.Enum(),
+			Acknowledge: &sched.Call_Acknowledge{
+				AgentId: status.GetAgentId(),
+				TaskId:  status.GetTaskId(),
+				Uuid:    status.GetUuid(),
+			},
+		}))
+	}
+	suite.Equal(0, suite.master.PendingAcknowledgements())
+
+	// the offer of the remaining resources is filtered by the accept
+	suite.master.SendOffers()
+	suite.noEvent()
+}
+
+// TestLaunchErrors tests launching tasks with invalid offers, with
+// duplicate task ids and with more resources than offered.
+func (suite *FakeMasterTestSuite) TestLaunchErrors() {
+	suite.subscribe(mesos.FrameworkInfo_Capability_PARTITION_AWARE)
+	suite.launchRunning("task-1")
+
+	suite.Equal(
+		http.StatusAccepted,
+		suite.call(&sched.Call{Type: sched.Call_REVIVE.Enum()}))
+	offer := suite.offer()
+	suite.Equal(http.StatusAccepted, suite.launch(offer, "task-2", 8))
+	status := suite.nextUpdate("task-2", mesos.TaskState_TASK_ERROR)
+	suite.Equal(mesos.TaskStatus_REASON_TASK_INVALID, status.GetReason())
+
+	// the offer is used up by the accept
+	suite.Equal(http.StatusAccepted, suite.launch(offer, "task-3", 1))
+	status = suite.nextUpdate("task-3", mesos.TaskState_TASK_DROPPED)
+	suite.Equal(mesos.TaskStatus_REASON_INVALID_OFFERS, status.GetReason())
+
+	suite.Equal(
+		http.StatusAccepted,
+		suite.call(&sched.Call{Type: sched.Call_REVIVE.Enum()}))
+	suite.Equal(http.StatusAccepted, suite.launch(suite.offer(), "task-1", 1))
+	suite.nextUpdate("task-1", mesos.TaskState_TASK_ERROR)
+}
+
+// TestKill tests killing a running task and an unknown task.
+func (suite *FakeMasterTestSuite) TestKill() {
+	suite.subscribe(mesos.FrameworkInfo_Capability_TASK_KILLING_STATE)
+	suite.launchRunning("task-1")
+
+	suite.Equal(http.StatusAccepted, suite.call(&sched.Call{
+		Type: sched.Call_KILL.Enum(),
+		Kill: &sched.Call_Kill{
+			TaskId: &mesos.TaskID{Value: proto.String("task-1")},
+		},
+	}))
+	suite.nextUpdate("task-1", mesos.TaskState_TASK_KILLING)
+	suite.nextUpdate("task-1", mesos.TaskState_TASK_KILLED)
+	suite.Empty(suite.master.TaskStates())
+
+	suite.Equal(http.StatusAccepted, suite.call(&sched.Call{
+		Type: sched.Call_KILL.Enum(),
+		Kill: &sched.Call_Kill{
+			TaskId: &mesos.TaskID{Value: proto.String("task-1")},
+		},
+	}))
+	status := suite.nextUpdate("task-1", mesos.TaskState_TASK_LOST)
+	suite.Empty(status.GetUuid())
+}
+
+// TestUpdateTaskState tests injecting a task failure, the resources of
+// the failed task are offered again.
+func (suite *FakeMasterTestSuite) TestUpdateTaskState() {
+	suite.master.refuseSeconds = 0
+	suite.subscribe()
+	suite.launchRunning("task-1")
+
+	suite.NoError(suite.master.UpdateTaskState(
+		"task-1",
+		mesos.TaskState_TASK_FAILED,
+		mesos.TaskStatus_REASON_COMMAND_EXECUTOR_FAILED,
+		"Command exited with status 1"))
+	status := suite.nextUpdate("task-1", mesos.TaskState_TASK_FAILED)
+	suite.Equal("Command exited with status 1", status.GetMessage())
+	suite.Empty(suite.master.TaskStates())
+
+	for _, r := range suite.offer().GetResources() {
+		if r.GetName() == common.MesosCPU {
+			suite.Equal(4.0, r.GetScalar().GetValue())
+		}
+	}
+
+	suite.Error(suite.master.UpdateTaskState(
+		"task-1",
+		mesos.TaskState_TASK_RUNNING,
+		mesos.TaskStatus_REASON_RECONCILIATION,
+		""))
+}
+
+// TestReconcile tests the explicit and implicit reconciliation.
+func (suite *FakeMasterTestSuite) TestReconcile() {
+	suite.subscribe()
+	suite.launchRunning("task-1")
+
+	suite.Equal(http.StatusAccepted, suite.call(&sched.Call{
+		Type:      sched.Call_RECONCILE.Enum(),
+		Reconcile: &sched.Call_Reconcile{},
+	}))
+	status := suite.nextUpdate("task-1", mesos.TaskState_TASK_RUNNING)
+	suite.Equal(mesos.TaskStatus_REASON_RECONCILIATION, status.GetReason())
+
+	suite.Equal(http.StatusAccepted, suite.call(&sched.Call{
+		Type: sched.Call_RECONCILE.Enum(),
+		Reconcile: &sched.Call_Reconcile{
+			Tasks: []*sched.Call_Reconcile_Task{
+				{TaskId: &mesos.TaskID{Value: proto.String("task-1")}},
+				{TaskId: &mesos.TaskID{Value: proto.String("task-2")}},
+			},
+		},
+	}))
+	suite.nextUpdate("task-1", mesos.TaskState_TASK_RUNNING)
+	suite.nextUpdate("task-2", mesos.TaskState_TASK_LOST)
+}
+
+// TestFailAgent tests that the tasks of a failed agent are lost.
+func (suite *FakeMasterTestSuite) TestFailAgent() {
+	suite.subscribe()
+	suite.launchRunning("task-1")
+	agentID := suite.master.AgentID(_testHostname)
+
+	suite.NoError(suite.master.FailAgent(_testHostname))
+	status := suite.nextUpdate("task-1", mesos.TaskState_TASK_LOST)
+	suite.Equal(mesos.TaskStatus_REASON_AGENT_REMOVED, status.GetReason())
+	failure := suite.nextEvent(sched.Event_FAILURE)
+	suite.Equal(agentID, failure.GetFailure().GetAgentId().GetValue())
+
+	suite.Empty(suite.master.AgentID(_testHostname))
+	suite.Error(suite.master.FailAgent(_testHostname))
+}
+
+// TestOperatorAgents tests the agents and frameworks of the operator API.
+func (suite *FakeMasterTestSuite) TestOperatorAgents() {
+	suite.subscribe()
+	suite.launchRunning("task-1")
+
+	response, code := suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_GET_AGENTS.Enum(),
+	})
+	suite.Equal(http.StatusOK, code)
+	agents := response.GetGetAgents().GetAgents()
+	suite.Len(agents, 1)
+	suite.Equal(_testHostname, agents[0].GetAgentInfo().GetHostname())
+	ip, port, err := util.ExtractIPAndPortFromMesosAgentPID(agents[0].GetPid())
+	suite.NoError(err)
+	suite.Equal("127.0.0.1", ip)
+	suite.Equal("5051", port)
+	suite.Equal(
+		1.0,
+		agents[0].GetAllocatedResources()[0].GetScalar().GetValue())
+
+	response, code = suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_GET_FRAMEWORKS.Enum(),
+	})
+	suite.Equal(http.StatusOK, code)
+	frameworks := response.GetGetFrameworks().GetFrameworks()
+	suite.Len(frameworks, 1)
+	suite.Equal(
+		suite.frameworkID.GetValue(),
+		frameworks[0].GetFrameworkInfo().GetId().GetValue())
+	suite.True(frameworks[0].GetConnected())
+}
+
+// TestMaintenance tests draining a machine, bringing it down and up.
+func (suite *FakeMasterTestSuite) TestMaintenance() {
+	suite.subscribe()
+	suite.launchRunning("task-1")
+	suite.Equal(
+		http.StatusAccepted,
+		suite.call(&sched.Call{Type: sched.Call_REVIVE.Enum()}))
+	offer := suite.offer()
+	suite.Nil(offer.GetUnavailability())
+
+	machine := suite.scheduleMaintenance()
+	rescind := suite.nextEvent(sched.Event_RESCIND)
+	suite.Equal(offer.GetId().GetValue(), rescind.GetRescind().GetOfferId().GetValue())
+	suite.NotNil(suite.offer().GetUnavailability())
+
+	response, code := suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_GET_MAINTENANCE_STATUS.Enum(),
+	})
+	suite.Equal(http.StatusOK, code)
+	status := response.GetGetMaintenanceStatus().GetStatus()
+	suite.Len(status.GetDrainingMachines(), 1)
+	suite.Empty(status.GetDownMachines())
+
+	_, code = suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_START_MAINTENANCE.Enum(),
+		StartMaintenance: &mesos_master.Call_StartMaintenance{
+			Machines: []*mesos.MachineID{machine},
+		},
+	})
+	suite.Equal(http.StatusOK, code)
+	suite.nextEvent(sched.Event_RESCIND)
+	taskStatus := suite.nextUpdate("task-1", mesos.TaskState_TASK_LOST)
+	suite.Equal(
+		mesos.TaskStatus_REASON_AGENT_REMOVED_BY_OPERATOR,
+		taskStatus.GetReason())
+
+	response, _ = suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_GET_MAINTENANCE_STATUS.Enum(),
+	})
+	status = response.GetGetMaintenanceStatus().GetStatus()
+	suite.Empty(status.GetDrainingMachines())
+	suite.Len(status.GetDownMachines(), 1)
+
+	response, _ = suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_GET_AGENTS.Enum(),
+	})
+	suite.Empty(response.GetGetAgents().GetAgents())
+	suite.master.SendOffers()
+	suite.noEvent()
+
+	_, code = suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_STOP_MAINTENANCE.Enum(),
+		StopMaintenance: &mesos_master.Call_StopMaintenance{
+			Machines: []*mesos.MachineID{machine},
+		},
+	})
+	suite.Equal(http.StatusOK, code)
+
+	response, _ = suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_GET_MAINTENANCE_SCHEDULE.Enum(),
+	})
+	suite.Empty(response.GetGetMaintenanceSchedule().GetSchedule().GetWindows())
+	suite.Nil(suite.offer().GetUnavailability())
+}
+
+// TestMaintenanceErrors tests the invalid maintenance calls.
+func (suite *FakeMasterTestSuite) TestMaintenanceErrors() {
+	machine := &mesos.MachineID{Hostname: proto.String(_testHostname)}
+	_, code := suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_START_MAINTENANCE.Enum(),
+		StartMaintenance: &mesos_master.Call_StartMaintenance{
+			Machines: []*mesos.MachineID{machine},
+		},
+	})
+	suite.Equal(http.StatusBadRequest, code)
+
+	_, code = suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_STOP_MAINTENANCE.Enum(),
+		StopMaintenance: &mesos_master.Call_StopMaintenance{
+			Machines: []*mesos.MachineID{machine},
+		},
+	})
+	suite.Equal(http.StatusBadRequest, code)
+
+	_, code = suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_UPDATE_MAINTENANCE_SCHEDULE.Enum(),
+		UpdateMaintenanceSchedule: &mesos_master.Call_UpdateMaintenanceSchedule{
+			Schedule: &mesos_maintenance.Schedule{
+				Windows: []*mesos_maintenance.Window{{
+					MachineIds: []*mesos.MachineID{
+						{Ip: proto.String("127.0.0.1")},
+					},
+					Unavailability: &mesos.Unavailability{
+						Start: &mesos.TimeInfo{Nanoseconds: proto.Int64(0)},
+					},
+				}},
+			},
+		},
+	})
+	suite.Equal(http.StatusBadRequest, code)
+}
+
+// TestQuota tests the quota of the operator API.
+func (suite *FakeMasterTestSuite) TestQuota() {
+	suite.NoError(suite.master.Stop())
+	suite.master = New(WithQuota(
+		"peloton",
+		[]*mesos.Resource{
+			util.NewMesosResourceBuilder().
+				WithName(common.MesosCPU).
+				WithValue(10).
+				Build(),
+		}))
+	suite.NoError(suite.master.Start())
+
+	response, code := suite.operator(&mesos_master.Call{
+		Type: mesos_master.Call_GET_QUOTA.Enum(),
+	})
+	suite.Equal(http.StatusOK, code)
+	infos := response.GetGetQuota().GetStatus().GetInfos()
+	suite.Len(infos, 1)
+	suite.Equal("peloton", infos[0].GetRole())
+}
+
+// TestAgentErrors tests adding invalid agents.
+func (suite *FakeMasterTestSuite) TestAgentErrors() {
+	_, err := suite.master.AddAgent(AgentSpec{})
+	suite.Error(err)
+	_, err = suite.master.AddAgent(AgentSpec{Hostname: _testHostname})
+	suite.Error(err)
+	_, err = suite.master.AddAgent(AgentSpec{
+		Hostname:  "agent-2",
+		PortBegin: 2,
+		PortEnd:   1,
+	})
+	suite.Error(err)
+}
+
+// TestStop tests that a stopped master closes the event stream.
+func (suite *FakeMasterTestSuite) TestStop() {
+	suite.subscribe()
+	suite.NoError(suite.master.Stop())
+	_, ok := <-suite.events
+	suite.False(ok)
+	suite.Error(suite.master.Stop())
+	suite.NoError(suite.master.Start())
+}
@@ -0,0 +1,378 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakemaster
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	mesos_maintenance "github.com/uber/peloton/.gen/mesos/v1/maintenance"
+	mesos_master "github.com/uber/peloton/.gen/mesos/v1/master"
+	mesos_quota "github.com/uber/peloton/.gen/mesos/v1/quota"
+
+	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// handleOperator serves the operator API, responses are encoded in the
+// media type of the Accept header.
+func (m *Master) handleOperator(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "expecting a POST request", http.StatusMethodNotAllowed)
+		return
+	}
+
+	encoding, err := contentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	accept := encoding
+	if a := r.Header.Get("Accept"); a != "" {
+		if accept, err = contentType(a); err != nil {
+			http.Error(w, err.Error(), http.StatusNotAcceptable)
+			return
+		}
+	}
+
+	call := &mesos_master.Call{}
+	if err := decode(r.Body, encoding, call); err != nil {
+		http.Error(
+			w,
+			fmt.Sprintf("failed to decode call: %v", err),
+			http.StatusBadRequest)
+		return
+	}
+
+	response, err := m.handleOperatorCall(call)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if response == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	body, err := mpb.MarshalPbMessage(response, accept)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/"+accept)
+	w.Write([]byte(body))
+}
+
+// handleOperatorCall applies an operator call, calls which have no
+// response return nil.
+func (m *Master) handleOperatorCall(
+	call *mesos_master.Call) (*mesos_master.Response, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	switch call.GetType() {
+	case mesos_master.Call_GET_AGENTS:
+		return &mesos_master.Response{
+			Type:      mesos_master.Response_GET_AGENTS.Enum(),
+			GetAgents: m.getAgents(),
+		}, nil
+	case mesos_master.Call_GET_FRAMEWORKS:
+		return &mesos_master.Response{
+			Type:          mesos_master.Response_GET_FRAMEWORKS.Enum(),
+			GetFrameworks: m.getFrameworks(),
+		}, nil
+	case mesos_master.Call_GET_ROLES:
+		return &mesos_master.Response{
+			Type:     mesos_master.Response_GET_ROLES.Enum(),
+			GetRoles: m.getRoles(),
+		}, nil
+	case mesos_master.Call_GET_QUOTA:
+		return &mesos_master.Response{
+			Type: mesos_master.Response_GET_QUOTA.Enum(),
+			GetQuota: &mesos_master.Response_GetQuota{
+				Status: &mesos_quota.QuotaStatus{Infos: m.quotas},
+			},
+		}, nil
+	case mesos_master.Call_GET_MAINTENANCE_SCHEDULE:
+		return &mesos_master.Response{
+			Type: mesos_master.Response_GET_MAINTENANCE_SCHEDULE.Enum(),
+			GetMaintenanceSchedule: &mesos_master.Response_GetMaintenanceSchedule{
+				Schedule: m.schedule,
+			},
+		}, nil
+	case mesos_master.Call_GET_MAINTENANCE_STATUS:
+		return &mesos_master.Response{
+			Type: mesos_master.Response_GET_MAINTENANCE_STATUS.Enum(),
+			GetMaintenanceStatus: &mesos_master.Response_GetMaintenanceStatus{
+				Status: m.maintenanceStatus(),
+			},
+		}, nil
+	case mesos_master.Call_UPDATE_MAINTENANCE_SCHEDULE:
+		return nil, m.updateMaintenanceSchedule(
+			call.GetUpdateMaintenanceSchedule().GetSchedule())
+	case mesos_master.Call_START_MAINTENANCE:
+		return nil, m.startMaintenance(
+			call.GetStartMaintenance().GetMachines())
+	case mesos_master.Call_STOP_MAINTENANCE:
+		return nil, m.stopMaintenance(
+			call.GetStopMaintenance().GetMachines())
+	}
+	return nil, fmt.Errorf("unsupported call type %s", call.GetType())
+}
+
+// getAgents returns the agents which are not in maintenance.
+func (m *Master) getAgents() *mesos_master.Response_GetAgents {
+	response := &mesos_master.Response_GetAgents{}
+	for _, a := range m.sortedAgents() {
+		if a.down {
+			continue
+		}
+		registeredTime := a.registeredTime.UnixNano()
+		agent := &mesos_master.Response_GetAgents_Agent{
+			AgentInfo:          a.info(),
+			Active:             proto.Bool(true),
+			Version:            proto.String(_agentVersion),
+			Pid:                proto.String(a.pid()),
+			RegisteredTime:     &mesos.TimeInfo{Nanoseconds: &registeredTime},
+			TotalResources:     a.totalResources(),
+			AllocatedResources: a.usedResources(),
+		}
+		if a.offer != nil {
+			agent.OfferedResources = a.offer.GetResources()
+		}
+		response.Agents = append(response.Agents, agent)
+	}
+	return response
+}
+
+// getFrameworks returns the subscribed framework, the resources offered
+// to the framework are part of its allocated resources.
+func (m *Master) getFrameworks() *mesos_master.Response_GetFrameworks {
+	response := &mesos_master.Response_GetFrameworks{}
+	if m.framework == nil {
+		return response
+	}
+
+	registeredTime := m.framework.registeredTime.UnixNano()
+	framework := &mesos_master.Response_GetFrameworks_Framework{
+		FrameworkInfo:  m.framework.info,
+		Active:         proto.Bool(!m.framework.suppressed),
+		Connected:      proto.Bool(!m.framework.stream.isClosed()),
+		Recovered:      proto.Bool(false),
+		RegisteredTime: &mesos.TimeInfo{Nanoseconds: &registeredTime},
+	}
+	for _, a := range m.sortedAgents() {
+		framework.AllocatedResources = append(
+			framework.AllocatedResources,
+			a.usedResources()...)
+		if a.offer != nil {
+			framework.Offers = append(framework.Offers, a.offer)
+			framework.AllocatedResources = append(
+				framework.AllocatedResources,
+				a.offer.GetResources()...)
+			framework.OfferedResources = append(
+				framework.OfferedResources,
+				a.offer.GetResources()...)
+		}
+	}
+	response.Frameworks = append(response.Frameworks, framework)
+	return response
+}
+
+// getRoles returns the role of the subscribed framework with the resources
+// allocated to the framework.
+func (m *Master) getRoles() *mesos_master.Response_GetRoles {
+	response := &mesos_master.Response_GetRoles{}
+	if m.framework == nil {
+		return response
+	}
+
+	role := m.framework.info.GetRole()
+	if role == "" {
+		role = "*"
+	}
+	response.Roles = append(response.Roles, &mesos.Role{
+		Name:       &role,
+		Weight:     proto.Float64(1),
+		Frameworks: []*mesos.FrameworkID{m.framework.id()},
+		Resources: m.getFrameworks().GetFrameworks()[0].
+			GetAllocatedResources(),
+	})
+	return response
+}
+
+// maintenanceStatus returns the machines which are draining, that is in
+// the maintenance schedule but not down, and the machines which are down.
+func (m *Master) maintenanceStatus() *mesos_maintenance.ClusterStatus {
+	status := &mesos_maintenance.ClusterStatus{}
+	for _, window := range m.schedule.GetWindows() {
+		for _, machine := range window.GetMachineIds() {
+			if _, ok := m.downMachines[machine.GetHostname()]; ok {
+				continue
+			}
+			status.DrainingMachines = append(
+				status.DrainingMachines,
+				&mesos_maintenance.ClusterStatus_DrainingMachine{Id: machine})
+		}
+	}
+
+	var hostnames []string
+	for hostname := range m.downMachines {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	for _, hostname := range hostnames {
+		status.DownMachines = append(
+			status.DownMachines,
+			m.downMachines[hostname])
+	}
+	return status
+}
+
+// updateMaintenanceSchedule replaces the maintenance schedule, the machines
+// of the schedule are draining. The outstanding offers of their agents are
+// rescinded so that they are offered again with their unavailability.
+func (m *Master) updateMaintenanceSchedule(
+	schedule *mesos_maintenance.Schedule) error {
+	if schedule == nil {
+		return fmt.Errorf("missing maintenance schedule")
+	}
+
+	scheduled := make(map[string]bool)
+	for _, window := range schedule.GetWindows() {
+		if window.GetUnavailability() == nil {
+			return fmt.Errorf("maintenance window has no unavailability")
+		}
+		for _, machine := range window.GetMachineIds() {
+			hostname := machine.GetHostname()
+			if hostname == "" {
+				return fmt.Errorf("machine %v has no hostname", machine)
+			}
+			if scheduled[hostname] {
+				return fmt.Errorf(
+					"machine %s is in more than one maintenance window",
+					hostname)
+			}
+			scheduled[hostname] = true
+		}
+	}
+	for hostname := range m.downMachines {
+		if !scheduled[hostname] {
+			return fmt.Errorf(
+				"machine %s is down and cannot be removed from the schedule",
+				hostname)
+		}
+	}
+
+	m.schedule = proto.Clone(schedule).(*mesos_maintenance.Schedule)
+	for hostname := range scheduled {
+		if a, ok := m.agents[hostname]; ok {
+			m.rescindOffer(a)
+		}
+	}
+	return nil
+}
+
+// startMaintenance brings machines of the schedule down. The agents on the
+// machines are removed, their tasks are gone and their offers rescinded.
+func (m *Master) startMaintenance(machines []*mesos.MachineID) error {
+	for _, machine := range machines {
+		hostname := machine.GetHostname()
+		if m.unavailability(hostname) == nil {
+			return fmt.Errorf(
+				"machine %s is not in the maintenance schedule", hostname)
+		}
+		if _, ok := m.downMachines[hostname]; ok {
+			return fmt.Errorf("machine %s is already down", hostname)
+		}
+	}
+
+	state := mesos.TaskState_TASK_LOST
+	if m.framework.partitionAware() {
+		state = mesos.TaskState_TASK_GONE_BY_OPERATOR
+	}
+	for _, machine := range machines {
+		hostname := machine.GetHostname()
+		m.downMachines[hostname] = machine
+		a, ok := m.agents[hostname]
+		if !ok {
+			continue
+		}
+		m.rescindOffer(a)
+		m.removeAgentTasks(
+			a,
+			state,
+			mesos.TaskStatus_REASON_AGENT_REMOVED_BY_OPERATOR,
+			"Agent was removed for maintenance")
+		a.down = true
+	}
+	return nil
+}
+
+// stopMaintenance brings machines which are down back up and removes them
+// from the schedule, their agents register again.
+func (m *Master) stopMaintenance(machines []*mesos.MachineID) error {
+	down := make(map[string]bool)
+	for _, machine := range machines {
+		hostname := machine.GetHostname()
+		if _, ok := m.downMachines[hostname]; !ok {
+			return fmt.Errorf("machine %s is not down", hostname)
+		}
+		down[hostname] = true
+	}
+
+	var windows []*mesos_maintenance.Window
+	for _, window := range m.schedule.GetWindows() {
+		var machineIDs []*mesos.MachineID
+		for _, machine := range window.GetMachineIds() {
+			if !down[machine.GetHostname()] {
+				machineIDs = append(machineIDs, machine)
+			}
+		}
+		if len(machineIDs) > 0 {
+			windows = append(windows, &mesos_maintenance.Window{
+				MachineIds:     machineIDs,
+				Unavailability: window.GetUnavailability(),
+			})
+		}
+	}
+	m.schedule = &mesos_maintenance.Schedule{Windows: windows}
+
+	for hostname := range down {
+		delete(m.downMachines, hostname)
+		if a, ok := m.agents[hostname]; ok {
+			a.down = false
+			a.registeredTime = time.Now()
+		}
+	}
+	return nil
+}
+
+// unavailability returns the unavailability of a machine of the
+// maintenance schedule, or nil when the machine is not scheduled.
+func (m *Master) unavailability(hostname string) *mesos.Unavailability {
+	for _, window := range m.schedule.GetWindows() {
+		for _, machine := range window.GetMachineIds() {
+			if machine.GetHostname() == hostname {
+				return window.GetUnavailability()
+			}
+		}
+	}
+	return nil
+}
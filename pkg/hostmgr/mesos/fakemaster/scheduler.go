@@ -0,0 +1,575 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakemaster
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	sched "github.com/uber/peloton/.gen/mesos/v1/scheduler"
+
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// task is a task launched on an agent.
+type task struct {
+	info      *mesos.TaskInfo
+	agent     *agent
+	state     mesos.TaskState
+	resources scalar.Resources
+	ports     map[uint32]bool
+}
+
+// handleScheduler serves the scheduler API. A SUBSCRIBE call opens the
+// event stream of the framework, the other calls are accepted once they
+// are applied.
+func (m *Master) handleScheduler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "expecting a POST request", http.StatusMethodNotAllowed)
+		return
+	}
+
+	encoding, err := contentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	call := &sched.Call{}
+	if err := decode(r.Body, encoding, call); err != nil {
+		http.Error(
+			w,
+			fmt.Sprintf("failed to decode call: %v", err),
+			http.StatusBadRequest)
+		return
+	}
+
+	if call.GetType() == sched.Call_SUBSCRIBE {
+		m.subscribe(w, r, call.GetSubscribe(), encoding)
+		return
+	}
+
+	if err := m.handleCall(r.Header.Get(_streamIDHeader), call); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// subscribe registers the framework and streams its events until the
+// stream is closed or the framework disconnects.
+func (m *Master) subscribe(
+	w http.ResponseWriter,
+	r *http.Request,
+	subscribe *sched.Call_Subscribe,
+	encoding string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	s, err := m.addFramework(subscribe.GetFrameworkInfo(), encoding)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/"+encoding)
+	w.Header().Set(_streamIDHeader, s.id)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(m.heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		for _, event := range s.pop() {
+			if err := s.write(w, event); err != nil {
+				log.WithError(err).Info("Framework event stream broken")
+				s.close()
+				return
+			}
+		}
+		flusher.Flush()
+
+		select {
+		case <-s.notify:
+		case <-heartbeat.C:
+			s.push(&sched.Event{Type: sched.Event_HEARTBEAT.Enum()})
+		case <-s.done:
+			return
+		case <-r.Context().Done():
+			s.close()
+			return
+		}
+	}
+}
+
+// addFramework subscribes a framework, a subscription of the framework
+// which is already subscribed replaces its event stream and invalidates
+// its outstanding offers.
+func (m *Master) addFramework(
+	info *mesos.FrameworkInfo,
+	encoding string) (*stream, error) {
+	if info == nil {
+		return nil, errors.New("subscribe call has no framework info")
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	info = proto.Clone(info).(*mesos.FrameworkInfo)
+	if m.framework != nil {
+		if info.GetId().GetValue() != m.framework.id().GetValue() {
+			return nil, fmt.Errorf(
+				"framework %s is already subscribed",
+				m.framework.id().GetValue())
+		}
+		m.framework.stream.close()
+		m.removeOffers()
+	}
+	if info.GetId().GetValue() == "" {
+		id := m.id + "-0000"
+		info.Id = &mesos.FrameworkID{Value: &id}
+	}
+
+	m.framework = &framework{
+		info:           info,
+		stream:         newStream(encoding),
+		registeredTime: time.Now(),
+	}
+	for _, a := range m.agents {
+		a.refusedUntil = time.Time{}
+	}
+
+	heartbeatInterval := m.heartbeatInterval.Seconds()
+	m.framework.send(&sched.Event{
+		Type: sched.Event_SUBSCRIBED.Enum(),
+		Subscribed: &sched.Event_Subscribed{
+			FrameworkId:              m.framework.id(),
+			HeartbeatIntervalSeconds: &heartbeatInterval,
+		},
+	})
+
+	log.WithField("framework_id", info.GetId().GetValue()).
+		Info("Framework subscribed to fake mesos master")
+	return m.framework.stream, nil
+}
+
+// handleCall applies a scheduler call of the subscribed framework.
+func (m *Master) handleCall(streamID string, call *sched.Call) error {
+	m.Lock()
+	defer m.Unlock()
+
+	f := m.framework
+	if f == nil {
+		return errors.New("framework is not subscribed")
+	}
+	if call.GetFrameworkId().GetValue() != f.id().GetValue() {
+		return fmt.Errorf(
+			"call of unknown framework %s",
+			call.GetFrameworkId().GetValue())
+	}
+	if streamID != f.stream.id {
+		return fmt.Errorf("stream id %s does not match", streamID)
+	}
+
+	switch call.GetType() {
+	case sched.Call_TEARDOWN:
+		m.teardown()
+	case sched.Call_ACCEPT:
+		m.accept(call.GetAccept())
+	case sched.Call_DECLINE:
+		refuse := m.refuseDuration(call.GetDecline().GetFilters())
+		for _, offerID := range call.GetDecline().GetOfferIds() {
+			m.removeOffer(offerID.GetValue(), refuse)
+		}
+	case sched.Call_REVIVE:
+		f.suppressed = false
+		for _, a := range m.agents {
+			a.refusedUntil = time.Time{}
+		}
+	case sched.Call_SUPPRESS:
+		f.suppressed = true
+	case sched.Call_KILL:
+		m.kill(call.GetKill())
+	case sched.Call_SHUTDOWN:
+		m.shutdown(call.GetShutdown())
+	case sched.Call_ACKNOWLEDGE:
+		delete(m.pendingAcks, string(call.GetAcknowledge().GetUuid()))
+	case sched.Call_RECONCILE:
+		m.reconcile(call.GetReconcile().GetTasks())
+	case sched.Call_ACCEPT_INVERSE_OFFERS,
+		sched.Call_DECLINE_INVERSE_OFFERS,
+		sched.Call_MESSAGE,
+		sched.Call_REQUEST:
+		// inverse offers and executor messages are not simulated
+	default:
+		return fmt.Errorf("unsupported call type %s", call.GetType())
+	}
+	return nil
+}
+
+// teardown removes the framework along with its tasks and offers.
+func (m *Master) teardown() {
+	for _, t := range m.sortedTasks() {
+		m.removeTask(t)
+	}
+	m.removeOffers()
+	m.framework.stream.close()
+	m.framework = nil
+}
+
+// accept launches the tasks of the operations on the agent of the offers.
+// Reservations and persistent volumes are not simulated, those operations
+// are accepted without effect.
+func (m *Master) accept(accept *sched.Call_Accept) {
+	refuse := m.refuseDuration(accept.GetFilters())
+
+	var a *agent
+	valid := len(accept.GetOfferIds()) > 0
+	for _, offerID := range accept.GetOfferIds() {
+		offerAgent := m.removeOffer(offerID.GetValue(), refuse)
+		if offerAgent == nil || (a != nil && a != offerAgent) {
+			valid = false
+			continue
+		}
+		a = offerAgent
+	}
+
+	for _, op := range accept.GetOperations() {
+		switch op.GetType() {
+		case mesos.Offer_Operation_LAUNCH:
+			m.launch(a, valid, op.GetLaunch().GetTaskInfos(), nil)
+		case mesos.Offer_Operation_LAUNCH_GROUP:
+			m.launch(
+				a,
+				valid,
+				op.GetLaunchGroup().GetTaskGroup().GetTasks(),
+				op.GetLaunchGroup().GetExecutor())
+		}
+	}
+}
+
+// launch launches tasks on an agent, the tasks are dropped when the offers
+// are not valid and fail when they do not fit on the agent.
+func (m *Master) launch(
+	a *agent,
+	valid bool,
+	infos []*mesos.TaskInfo,
+	executor *mesos.ExecutorInfo) {
+	for _, info := range infos {
+		taskID := info.GetTaskId().GetValue()
+		if !valid {
+			state := mesos.TaskState_TASK_LOST
+			if m.framework.partitionAware() {
+				state = mesos.TaskState_TASK_DROPPED
+			}
+			m.sendUpdate(m.newStatus(
+				info.GetTaskId(),
+				info.GetAgentId(),
+				state,
+				mesos.TaskStatus_SOURCE_MASTER,
+				mesos.TaskStatus_REASON_INVALID_OFFERS.Enum(),
+				"Task launched with invalid offers"), false)
+			continue
+		}
+
+		resources := scalar.FromMesosResources(info.GetResources())
+		ports := util.GetPortsSetFromResources(info.GetResources())
+		if e := info.GetExecutor(); e != nil {
+			resources = resources.Add(scalar.FromMesosResources(e.GetResources()))
+		} else if executor != nil {
+			resources = resources.Add(
+				scalar.FromMesosResources(executor.GetResources()))
+			executor = nil
+		}
+
+		var message string
+		if _, ok := m.tasks[taskID]; ok || taskID == "" {
+			message = fmt.Sprintf("Task id '%s' is invalid or in use", taskID)
+		} else if !a.fits(resources, ports) {
+			message = fmt.Sprintf(
+				"Task uses more resources %v than available on agent %s",
+				resources,
+				a.hostname)
+		}
+		if message != "" {
+			m.sendUpdate(m.newStatus(
+				info.GetTaskId(),
+				a.agentID(),
+				mesos.TaskState_TASK_ERROR,
+				mesos.TaskStatus_SOURCE_MASTER,
+				mesos.TaskStatus_REASON_TASK_INVALID.Enum(),
+				message), false)
+			continue
+		}
+
+		a.allocate(resources, ports)
+		t := &task{
+			info:      info,
+			agent:     a,
+			state:     mesos.TaskState_TASK_STAGING,
+			resources: resources,
+			ports:     ports,
+		}
+		m.tasks[taskID] = t
+		for _, state := range m.launchStates {
+			m.updateTask(t, state, mesos.TaskStatus_SOURCE_EXECUTOR, nil, "")
+		}
+	}
+}
+
+// kill kills a task, the task goes through TASK_KILLING when the
+// framework has the TASK_KILLING_STATE capability.
+func (m *Master) kill(kill *sched.Call_Kill) {
+	t, ok := m.tasks[kill.GetTaskId().GetValue()]
+	if !ok {
+		m.sendUpdate(m.newStatus(
+			kill.GetTaskId(),
+			kill.GetAgentId(),
+			m.unknownTaskState(),
+			mesos.TaskStatus_SOURCE_MASTER,
+			mesos.TaskStatus_REASON_RECONCILIATION.Enum(),
+			"Attempted to kill an unknown task"), false)
+		return
+	}
+
+	if m.framework.hasCapability(
+		mesos.FrameworkInfo_Capability_TASK_KILLING_STATE) {
+		m.updateTask(
+			t,
+			mesos.TaskState_TASK_KILLING,
+			mesos.TaskStatus_SOURCE_EXECUTOR,
+			nil,
+			"")
+	}
+	m.updateTask(
+		t,
+		mesos.TaskState_TASK_KILLED,
+		mesos.TaskStatus_SOURCE_EXECUTOR,
+		nil,
+		"Task killed")
+}
+
+// shutdown kills the tasks of an executor.
+func (m *Master) shutdown(shutdown *sched.Call_Shutdown) {
+	for _, t := range m.sortedTasks() {
+		if t.agent.id == shutdown.GetAgentId().GetValue() &&
+			t.info.GetExecutor().GetExecutorId().GetValue() ==
+				shutdown.GetExecutorId().GetValue() {
+			m.updateTask(
+				t,
+				mesos.TaskState_TASK_KILLED,
+				mesos.TaskStatus_SOURCE_AGENT,
+				mesos.TaskStatus_REASON_EXECUTOR_TERMINATED.Enum(),
+				"Executor was shut down")
+		}
+	}
+}
+
+// reconcile sends the latest state of the given tasks, or of all the
+// tasks for an implicit reconciliation.
+func (m *Master) reconcile(tasks []*sched.Call_Reconcile_Task) {
+	if len(tasks) == 0 {
+		for _, t := range m.sortedTasks() {
+			m.sendUpdate(m.newTaskStatus(
+				t,
+				t.state,
+				mesos.TaskStatus_SOURCE_MASTER,
+				mesos.TaskStatus_REASON_RECONCILIATION.Enum(),
+				"Reconciliation: latest task state"), false)
+		}
+		return
+	}
+
+	for _, rt := range tasks {
+		if t, ok := m.tasks[rt.GetTaskId().GetValue()]; ok {
+			m.sendUpdate(m.newTaskStatus(
+				t,
+				t.state,
+				mesos.TaskStatus_SOURCE_MASTER,
+				mesos.TaskStatus_REASON_RECONCILIATION.Enum(),
+				"Reconciliation: latest task state"), false)
+			continue
+		}
+		m.sendUpdate(m.newStatus(
+			rt.GetTaskId(),
+			rt.GetAgentId(),
+			m.unknownTaskState(),
+			mesos.TaskStatus_SOURCE_MASTER,
+			mesos.TaskStatus_REASON_RECONCILIATION.Enum(),
+			"Reconciliation: task is unknown"), false)
+	}
+}
+
+// UpdateTaskState moves a task to a new state as its executor would, e.g.
+// to TASK_FAILED to inject a task failure or to TASK_FINISHED to complete
+// it. The resources of the task are released once it is terminal.
+func (m *Master) UpdateTaskState(
+	taskID string,
+	state mesos.TaskState,
+	reason mesos.TaskStatus_Reason,
+	message string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	t, ok := m.tasks[taskID]
+	if !ok {
+		return fmt.Errorf("unknown task %s", taskID)
+	}
+	m.updateTask(
+		t,
+		state,
+		mesos.TaskStatus_SOURCE_EXECUTOR,
+		reason.Enum(),
+		message)
+	return nil
+}
+
+// TaskStates returns the states of the non terminal tasks by task id.
+func (m *Master) TaskStates() map[string]mesos.TaskState {
+	m.Lock()
+	defer m.Unlock()
+
+	states := make(map[string]mesos.TaskState)
+	for taskID, t := range m.tasks {
+		states[taskID] = t.state
+	}
+	return states
+}
+
+// updateTask moves a task to a new state and sends its status update.
+func (m *Master) updateTask(
+	t *task,
+	state mesos.TaskState,
+	source mesos.TaskStatus_Source,
+	reason *mesos.TaskStatus_Reason,
+	message string) {
+	t.state = state
+	m.sendUpdate(m.newTaskStatus(t, state, source, reason, message), true)
+	if isTerminal(state) {
+		m.removeTask(t)
+	}
+}
+
+// removeTask releases the resources of a task and forgets it.
+func (m *Master) removeTask(t *task) {
+	t.agent.release(t.resources, t.ports)
+	delete(m.tasks, t.info.GetTaskId().GetValue())
+}
+
+// sortedTasks returns the tasks ordered by task id.
+func (m *Master) sortedTasks() []*task {
+	var tasks []*task
+	for _, t := range m.tasks {
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].info.GetTaskId().GetValue() <
+			tasks[j].info.GetTaskId().GetValue()
+	})
+	return tasks
+}
+
+// unknownTaskState returns the state reported for unknown tasks.
+func (m *Master) unknownTaskState() mesos.TaskState {
+	if m.framework.partitionAware() {
+		return mesos.TaskState_TASK_UNKNOWN
+	}
+	return mesos.TaskState_TASK_LOST
+}
+
+// newTaskStatus builds a status update of a launched task.
+func (m *Master) newTaskStatus(
+	t *task,
+	state mesos.TaskState,
+	source mesos.TaskStatus_Source,
+	reason *mesos.TaskStatus_Reason,
+	message string) *mesos.TaskStatus {
+	status := m.newStatus(
+		t.info.GetTaskId(),
+		t.agent.agentID(),
+		state,
+		source,
+		reason,
+		message)
+	status.ExecutorId = t.info.GetExecutor().GetExecutorId()
+	return status
+}
+
+// newStatus builds a status update of a task.
+func (m *Master) newStatus(
+	taskID *mesos.TaskID,
+	agentID *mesos.AgentID,
+	state mesos.TaskState,
+	source mesos.TaskStatus_Source,
+	reason *mesos.TaskStatus_Reason,
+	message string) *mesos.TaskStatus {
+	timestamp := float64(time.Now().UnixNano()) / float64(time.Second)
+	status := &mesos.TaskStatus{
+		TaskId:    taskID,
+		State:     state.Enum(),
+		Source:    source.Enum(),
+		Reason:    reason,
+		AgentId:   agentID,
+		Timestamp: &timestamp,
+	}
+	if message != "" {
+		status.Message = &message
+	}
+	return status
+}
+
+// sendUpdate sends a status update to the framework. Reliable updates
+// carry a uuid and are expected to be acknowledged.
+func (m *Master) sendUpdate(status *mesos.TaskStatus, reliable bool) {
+	if m.framework == nil {
+		return
+	}
+	if reliable {
+		status.Uuid = []byte(uuid.NewRandom())
+		m.pendingAcks[string(status.Uuid)] = true
+	}
+	m.framework.send(&sched.Event{
+		Type:   sched.Event_UPDATE.Enum(),
+		Update: &sched.Event_Update{Status: status},
+	})
+}
+
+// isTerminal returns whether a task state is terminal.
+func isTerminal(state mesos.TaskState) bool {
+	switch state {
+	case mesos.TaskState_TASK_FINISHED,
+		mesos.TaskState_TASK_FAILED,
+		mesos.TaskState_TASK_KILLED,
+		mesos.TaskState_TASK_ERROR,
+		mesos.TaskState_TASK_LOST,
+		mesos.TaskState_TASK_DROPPED,
+		mesos.TaskState_TASK_GONE,
+		mesos.TaskState_TASK_GONE_BY_OPERATOR:
+		return true
+	}
+	return false
+}
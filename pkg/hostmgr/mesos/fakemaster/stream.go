@@ -0,0 +1,162 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakemaster
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	sched "github.com/uber/peloton/.gen/mesos/v1/scheduler"
+
+	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pborman/uuid"
+)
+
+// framework is the framework subscribed to the fake master.
+type framework struct {
+	info           *mesos.FrameworkInfo
+	stream         *stream
+	suppressed     bool
+	registeredTime time.Time
+}
+
+// id returns the id of the framework.
+func (f *framework) id() *mesos.FrameworkID {
+	return f.info.GetId()
+}
+
+// send queues an event on the stream of the framework, the event is
+// dropped when no framework is subscribed.
+func (f *framework) send(event *sched.Event) {
+	if f == nil {
+		return
+	}
+	f.stream.push(event)
+}
+
+// hasCapability returns whether the framework has a capability.
+func (f *framework) hasCapability(
+	capability mesos.FrameworkInfo_Capability_Type) bool {
+	if f == nil {
+		return false
+	}
+	for _, c := range f.info.GetCapabilities() {
+		if c.GetType() == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionAware returns whether the framework has the PARTITION_AWARE
+// capability, which changes the states of the lost tasks.
+func (f *framework) partitionAware() bool {
+	return f.hasCapability(mesos.FrameworkInfo_Capability_PARTITION_AWARE)
+}
+
+// stream is the event stream of a subscription. Events are queued without
+// bound so that the master never blocks on a slow subscriber.
+type stream struct {
+	sync.Mutex
+
+	id       string
+	encoding string
+	events   []*sched.Event
+	closed   bool
+
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func newStream(encoding string) *stream {
+	return &stream{
+		id:       uuid.New(),
+		encoding: encoding,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// push queues an event.
+func (s *stream) push(event *sched.Event) {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return
+	}
+	s.events = append(s.events, event)
+	s.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the queued events.
+func (s *stream) pop() []*sched.Event {
+	s.Lock()
+	defer s.Unlock()
+
+	events := s.events
+	s.events = nil
+	return events
+}
+
+// isClosed returns whether the stream is closed.
+func (s *stream) isClosed() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.closed
+}
+
+// close closes the stream, the subscription connection is ended.
+func (s *stream) close() {
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+}
+
+// write writes an event as a RecordIO frame. Events are encoded in JSON
+// with the field names and integer values the Mesos master produces.
+func (s *stream) write(w io.Writer, event *sched.Event) error {
+	var data []byte
+	var err error
+	if s.encoding == mpb.ContentTypeJSON {
+		data, err = json.Marshal(event)
+	} else {
+		data, err = proto.Marshal(event)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "%d\n", len(data)); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
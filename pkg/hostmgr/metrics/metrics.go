@@ -72,6 +72,8 @@ type Metrics struct {
 	MarkHostsDrained     tally.Counter
 	MarkHostsDrainedFail tally.Counter
 
+	SetDrainBlockedHosts tally.Counter
+
 	WatchEventCancel   tally.Counter
 	WatchEventOverflow tally.Counter
 
@@ -137,6 +139,8 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		MarkHostsDrained:     scope.Counter("mark_hosts_drained"),
 		MarkHostsDrainedFail: scope.Counter("mark_hosts_drained_fail"),
 
+		SetDrainBlockedHosts: scope.Counter("set_drain_blocked_hosts"),
+
 		WatchEventCancel:           watchEventScope.Counter("watch_event_cancel"),
 		WatchEventOverflow:         watchEventScope.Counter("watch_event_overflow"),
 		WatchCancelNotFound:        watchEventScope.Counter("watch_cancel_not_found"),
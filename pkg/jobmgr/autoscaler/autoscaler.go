@@ -0,0 +1,350 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaler
+
+import (
+	"context"
+	"math"
+	"time"
+
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbupdate "github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/common/background"
+	"github.com/uber/peloton/pkg/common/util"
+	versionutil "github.com/uber/peloton/pkg/common/util/entityversion"
+	"github.com/uber/peloton/pkg/jobmgr/cached"
+	"github.com/uber/peloton/pkg/jobmgr/goalstate"
+	"github.com/uber/peloton/pkg/storage"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	"github.com/gogo/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/atomic"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const _autoscalerName = "autoscaler"
+
+// Autoscaler scales the instance count of the stateless jobs which have
+// an autoscaling policy, so that the value of the metric of the policy
+// averaged over the instances of a job stays close to its target value.
+// The jobs are scaled by update workflows, so that the instances are
+// added and removed in batches and the scaling shows up in the workflows
+// of the job. A job is not scaled while it has an active workflow.
+type Autoscaler struct {
+	JobFactory      cached.JobFactory
+	JobConfigOps    ormobjects.JobConfigOps
+	UpdateStore     storage.UpdateStore
+	GoalStateDriver goalstate.Driver
+	Source          MetricsSource
+	Metrics         *Metrics
+	Config          *Config
+
+	// lastScaleTimes is the time at which each autoscaled job was last
+	// scaled. It is not persisted, so the cooldowns of a job are counted
+	// from the first time the job is seen by a newly elected leader.
+	lastScaleTimes map[string]time.Time
+
+	// configs is the config of each service job at its current
+	// configuration version, so that the config is only read from DB
+	// when the job is updated.
+	configs map[string]*jobConfig
+}
+
+// jobConfig is the config of a job at a configuration version
+type jobConfig struct {
+	version     uint64
+	config      *pbjob.JobConfig
+	configAddOn *models.ConfigAddOn
+}
+
+// Register register the autoscaler in background.Manager
+func (a *Autoscaler) Register(manager background.Manager) error {
+	if a.Config == nil {
+		a.Config = &Config{}
+	}
+
+	a.Config.normalize()
+	return manager.RegisterWorks(
+		background.Work{
+			Name: _autoscalerName,
+			Func: func(_ *atomic.Bool) {
+				a.Autoscale()
+			},
+			Period: a.Config.AutoscalePeriod,
+		},
+	)
+}
+
+// Autoscale evaluates the autoscaling policies of all stateless jobs and
+// scales the jobs whose instance count is off target
+func (a *Autoscaler) Autoscale() {
+	stopWatch := a.Metrics.AutoscaleDuration.Start()
+	defer stopWatch.Stop()
+
+	now := time.Now()
+	lastScaleTimes := make(map[string]time.Time)
+	jobIDs := make(map[string]bool)
+	for _, cachedJob := range a.JobFactory.GetAllJobs() {
+		if cachedJob.GetJobType() != pbjob.JobType_SERVICE {
+			continue
+		}
+
+		// each job has its own timeout, so that a slow metrics source
+		// does not prevent the other jobs from being scaled
+		jobID := cachedJob.ID().GetValue()
+		jobIDs[jobID] = true
+		ctx, cancel := context.WithTimeout(
+			context.Background(), a.Config.AutoscaleTimeout)
+		lastScaleTime, autoscaled, err := a.autoscaleJob(
+			ctx, cachedJob, a.lastScaleTimes[jobID], now)
+		cancel()
+		if err != nil {
+			a.Metrics.AutoscaleJobFail.Inc(1)
+			log.WithError(err).
+				WithField("job_id", jobID).
+				Warn("failed to autoscale job")
+		}
+		if autoscaled {
+			lastScaleTimes[jobID] = lastScaleTime
+		}
+	}
+
+	// forget the jobs which are gone or no longer autoscaled
+	a.lastScaleTimes = lastScaleTimes
+	for jobID := range a.configs {
+		if !jobIDs[jobID] {
+			delete(a.configs, jobID)
+		}
+	}
+	a.Metrics.AutoscaledJobs.Update(float64(len(lastScaleTimes)))
+}
+
+// autoscaleJob scales a single job according to its autoscaling policy.
+// It returns the time at which the job was last scaled, and whether the
+// job has an autoscaling policy.
+func (a *Autoscaler) autoscaleJob(
+	ctx context.Context,
+	cachedJob cached.Job,
+	lastScaleTime time.Time,
+	now time.Time) (time.Time, bool, error) {
+	runtime, err := cachedJob.GetRuntime(ctx)
+	if err != nil {
+		return lastScaleTime, false, err
+	}
+
+	// the job is being stopped or deleted
+	if util.IsPelotonJobStateTerminal(runtime.GetGoalState()) ||
+		util.IsPelotonJobStateTerminal(runtime.GetState()) {
+		return lastScaleTime, false, nil
+	}
+
+	config, configAddOn, err := a.getConfig(
+		ctx, cachedJob, runtime.GetConfigurationVersion())
+	if err != nil {
+		return lastScaleTime, false, err
+	}
+
+	policy := config.GetAutoscalingPolicy()
+	if policy == nil {
+		return lastScaleTime, false, nil
+	}
+
+	if lastScaleTime.IsZero() {
+		lastScaleTime = now
+	}
+
+	// do not interfere with a workflow in progress, be it started by the
+	// autoscaler or by the user
+	active, err := a.hasActiveWorkflow(ctx, cachedJob, runtime.GetUpdateID())
+	if err != nil {
+		return lastScaleTime, true, err
+	}
+	if active {
+		return lastScaleTime, true, nil
+	}
+
+	current := config.GetInstanceCount()
+	value, err := a.Source.GetMetric(
+		ctx, cachedJob.ID(), config.GetName(), policy.GetMetric())
+	if err != nil {
+		// keep the job within the bounds of the policy even without metric
+		a.Metrics.GetMetricFail.Inc(1)
+		log.WithError(err).
+			WithField("job_id", cachedJob.ID().GetValue()).
+			WithField("metric", policy.GetMetric()).
+			Warn("failed to get metric of autoscaled job")
+		value = policy.GetTargetValue()
+	}
+
+	desired := DesiredInstanceCount(
+		policy, current, value, a.Config.Tolerance)
+	// the cooldowns do not apply to a job outside the bounds of its policy
+	var cooldown time.Duration
+	switch {
+	case desired > current && current >= policy.GetMinInstances():
+		cooldown = time.Duration(policy.GetScaleUpCooldownSecs()) * time.Second
+	case desired < current && current <= policy.GetMaxInstances():
+		cooldown = time.Duration(policy.GetScaleDownCooldownSecs()) * time.Second
+	case desired == current:
+		return lastScaleTime, true, nil
+	}
+	if now.Sub(lastScaleTime) < cooldown {
+		return lastScaleTime, true, nil
+	}
+
+	if err := a.scale(
+		ctx, cachedJob, runtime, config, configAddOn, desired); err != nil {
+		return lastScaleTime, true, err
+	}
+
+	if desired > current {
+		a.Metrics.ScaleUp.Inc(1)
+		a.Metrics.InstancesAdded.Inc(int64(desired - current))
+	} else {
+		a.Metrics.ScaleDown.Inc(1)
+		a.Metrics.InstancesRemoved.Inc(int64(current - desired))
+	}
+
+	log.WithField("job_id", cachedJob.ID().GetValue()).
+		WithField("metric", policy.GetMetric()).
+		WithField("value", value).
+		WithField("target", policy.GetTargetValue()).
+		WithField("instance_count", current).
+		WithField("desired_instance_count", desired).
+		Info("autoscaled job")
+	return now, true, nil
+}
+
+// getConfig returns the config of a job at a configuration version. The
+// config of a version never changes, so it is only read from DB when the
+// configuration version of the job changes.
+func (a *Autoscaler) getConfig(
+	ctx context.Context,
+	cachedJob cached.Job,
+	version uint64) (*pbjob.JobConfig, *models.ConfigAddOn, error) {
+	jobID := cachedJob.ID().GetValue()
+	if c, ok := a.configs[jobID]; ok && c.version == version {
+		return c.config, c.configAddOn, nil
+	}
+
+	config, configAddOn, err := a.JobConfigOps.Get(
+		ctx, cachedJob.ID(), version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if a.configs == nil {
+		a.configs = make(map[string]*jobConfig)
+	}
+	a.configs[jobID] = &jobConfig{
+		version:     version,
+		config:      config,
+		configAddOn: configAddOn,
+	}
+	return config, configAddOn, nil
+}
+
+// hasActiveWorkflow returns true if the workflow of the job has not
+// terminated yet. The workflow is read from DB if it is not in the cache,
+// e.g. before the goal state engine of a newly elected leader recovers it.
+func (a *Autoscaler) hasActiveWorkflow(
+	ctx context.Context,
+	cachedJob cached.Job,
+	updateID *peloton.UpdateID) (bool, error) {
+	if workflow := cachedJob.GetWorkflow(updateID); workflow != nil {
+		return !cached.IsUpdateStateTerminal(workflow.GetState().State), nil
+	}
+
+	if len(updateID.GetValue()) == 0 {
+		return false, nil
+	}
+
+	updateModel, err := a.UpdateStore.GetUpdate(ctx, updateID)
+	if yarpcerrors.IsNotFound(err) {
+		// the workflow has been deleted
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !cached.IsUpdateStateTerminal(updateModel.GetState()), nil
+}
+
+// scale creates an update workflow which changes the instance count of
+// the job, the update is run by the goal state engine
+func (a *Autoscaler) scale(
+	ctx context.Context,
+	cachedJob cached.Job,
+	runtime *pbjob.RuntimeInfo,
+	config *pbjob.JobConfig,
+	configAddOn *models.ConfigAddOn,
+	instanceCount uint32) error {
+	newConfig := proto.Clone(config).(*pbjob.JobConfig)
+	newConfig.InstanceCount = instanceCount
+
+	// concurrency control is done by the entity version, like for
+	// the replace of a job
+	newConfig.ChangeLog = nil
+
+	updateID, _, err := cachedJob.CreateWorkflow(
+		ctx,
+		models.WorkflowType_UPDATE,
+		&pbupdate.UpdateConfig{
+			BatchSize: config.GetAutoscalingPolicy().GetBatchSize(),
+		},
+		versionutil.GetJobEntityVersion(
+			runtime.GetConfigurationVersion(),
+			runtime.GetDesiredStateVersion(),
+			runtime.GetWorkflowVersion(),
+		),
+		cached.WithConfig(newConfig, config, configAddOn),
+	)
+
+	// enqueue the update even on error, so that it is either run or
+	// aborted if it has been persisted
+	if len(updateID.GetValue()) > 0 {
+		a.GoalStateDriver.EnqueueUpdate(cachedJob.ID(), updateID, time.Now())
+	}
+	return err
+}
+
+// DesiredInstanceCount returns the instance count of a job with the given
+// autoscaling policy, current instance count and current value of the
+// metric of the policy. The instance count is scaled proportionally to
+// the ratio of the value to the target value of the policy, unless the
+// ratio is within the tolerance, and is bounded by the policy.
+func DesiredInstanceCount(
+	policy *pbjob.AutoscalingPolicy,
+	current uint32,
+	value float64,
+	tolerance float64) uint32 {
+	desired := float64(current)
+	ratio := value / policy.GetTargetValue()
+	if math.Abs(ratio-1) > tolerance {
+		desired = math.Ceil(desired * ratio)
+	}
+
+	if desired < float64(policy.GetMinInstances()) {
+		return policy.GetMinInstances()
+	}
+	if desired > float64(policy.GetMaxInstances()) {
+		return policy.GetMaxInstances()
+	}
+	return uint32(desired)
+}
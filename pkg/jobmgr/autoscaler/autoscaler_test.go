@@ -0,0 +1,470 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaler
+
+import (
+	"testing"
+	"time"
+
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbupdate "github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	backgroundmocks "github.com/uber/peloton/pkg/common/background/mocks"
+	versionutil "github.com/uber/peloton/pkg/common/util/entityversion"
+	sourcemocks "github.com/uber/peloton/pkg/jobmgr/autoscaler/mocks"
+	"github.com/uber/peloton/pkg/jobmgr/cached"
+	cachedmocks "github.com/uber/peloton/pkg/jobmgr/cached/mocks"
+	goalstatemocks "github.com/uber/peloton/pkg/jobmgr/goalstate/mocks"
+	storagemocks "github.com/uber/peloton/pkg/storage/mocks"
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+)
+
+const (
+	_testJobName = "test-service"
+	_testMetric  = "cpu_utilization"
+)
+
+type AutoscalerTestSuite struct {
+	suite.Suite
+	mockCtrl *gomock.Controller
+
+	testScope       tally.TestScope
+	jobFactory      *cachedmocks.MockJobFactory
+	cachedJob       *cachedmocks.MockJob
+	jobConfigOps    *objectmocks.MockJobConfigOps
+	updateStore     *storagemocks.MockUpdateStore
+	goalStateDriver *goalstatemocks.MockDriver
+	source          *sourcemocks.MockMetricsSource
+	autoscaler      *Autoscaler
+
+	jobID   *peloton.JobID
+	runtime *pbjob.RuntimeInfo
+}
+
+func (s *AutoscalerTestSuite) SetupTest() {
+	s.mockCtrl = gomock.NewController(s.T())
+
+	s.testScope = tally.NewTestScope("", nil)
+	s.jobFactory = cachedmocks.NewMockJobFactory(s.mockCtrl)
+	s.cachedJob = cachedmocks.NewMockJob(s.mockCtrl)
+	s.jobConfigOps = objectmocks.NewMockJobConfigOps(s.mockCtrl)
+	s.updateStore = storagemocks.NewMockUpdateStore(s.mockCtrl)
+	s.goalStateDriver = goalstatemocks.NewMockDriver(s.mockCtrl)
+	s.source = sourcemocks.NewMockMetricsSource(s.mockCtrl)
+	s.jobID = &peloton.JobID{Value: "service-job"}
+	s.runtime = &pbjob.RuntimeInfo{
+		State:                pbjob.JobState_RUNNING,
+		GoalState:            pbjob.JobState_RUNNING,
+		ConfigurationVersion: 3,
+		DesiredStateVersion:  1,
+		WorkflowVersion:      2,
+	}
+
+	config := &Config{}
+	config.normalize()
+
+	s.autoscaler = &Autoscaler{
+		JobFactory:      s.jobFactory,
+		JobConfigOps:    s.jobConfigOps,
+		UpdateStore:     s.updateStore,
+		GoalStateDriver: s.goalStateDriver,
+		Source:          s.source,
+		Metrics:         NewMetrics(s.testScope),
+		Config:          config,
+	}
+}
+
+func (s *AutoscalerTestSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestAutoscalerTestSuite(t *testing.T) {
+	suite.Run(t, new(AutoscalerTestSuite))
+}
+
+// serviceConfig returns the config of a service job with the given
+// instance count and an autoscaling policy between 2 and 10 instances
+func serviceConfig(instanceCount uint32) *pbjob.JobConfig {
+	return &pbjob.JobConfig{
+		Name:          _testJobName,
+		Type:          pbjob.JobType_SERVICE,
+		InstanceCount: instanceCount,
+		ChangeLog:     &peloton.ChangeLog{Version: 3},
+		AutoscalingPolicy: &pbjob.AutoscalingPolicy{
+			MinInstances: 2,
+			MaxInstances: 10,
+			Metric:       _testMetric,
+			TargetValue:  0.5,
+			BatchSize:    2,
+		},
+	}
+}
+
+// expectCachedServiceJob sets the expectations to get the runtime of the
+// service job whose config has already been read
+func (s *AutoscalerTestSuite) expectCachedServiceJob() {
+	s.jobFactory.EXPECT().
+		GetAllJobs().
+		Return(map[string]cached.Job{s.jobID.GetValue(): s.cachedJob})
+	s.cachedJob.EXPECT().GetJobType().Return(pbjob.JobType_SERVICE)
+	s.cachedJob.EXPECT().ID().Return(s.jobID).AnyTimes()
+	s.cachedJob.EXPECT().GetRuntime(gomock.Any()).Return(s.runtime, nil)
+}
+
+// expectServiceJob sets the expectations to get the runtime and the
+// config of the service job
+func (s *AutoscalerTestSuite) expectServiceJob(config *pbjob.JobConfig) {
+	s.expectCachedServiceJob()
+	s.jobConfigOps.EXPECT().
+		Get(gomock.Any(), s.jobID, uint64(3)).
+		Return(config, &models.ConfigAddOn{}, nil)
+}
+
+// expectScale sets the expectations to create and enqueue the update
+// which scales the service job
+func (s *AutoscalerTestSuite) expectScale(batchSize uint32) {
+	updateID := &peloton.UpdateID{Value: "update-id"}
+	s.cachedJob.EXPECT().GetWorkflow(nil).Return(nil)
+	s.cachedJob.EXPECT().
+		CreateWorkflow(
+			gomock.Any(),
+			models.WorkflowType_UPDATE,
+			&pbupdate.UpdateConfig{BatchSize: batchSize},
+			versionutil.GetJobEntityVersion(3, 1, 2),
+			gomock.Any(),
+		).
+		Return(updateID, nil, nil)
+	s.goalStateDriver.EXPECT().EnqueueUpdate(s.jobID, updateID, gomock.Any())
+}
+
+// TestAutoscalerRegister tests that the autoscaler registers with the
+// background manager
+func (s *AutoscalerTestSuite) TestAutoscalerRegister() {
+	mockBackgroundManager := backgroundmocks.NewMockManager(s.mockCtrl)
+	mockBackgroundManager.EXPECT().RegisterWorks(gomock.Any()).Return(nil)
+	s.NoError(s.autoscaler.Register(mockBackgroundManager))
+}
+
+// TestAutoscaleScaleUp tests that a job whose metric is above its target
+// is scaled up by an update with the batch size of its policy
+func (s *AutoscalerTestSuite) TestAutoscaleScaleUp() {
+	s.expectServiceJob(serviceConfig(4))
+	s.expectScale(2)
+	s.source.EXPECT().
+		GetMetric(gomock.Any(), s.jobID, _testJobName, _testMetric).
+		Return(0.75, nil)
+
+	s.autoscaler.Autoscale()
+	s.Equal(int64(2), s.testScope.Snapshot().
+		Counters()["autoscaler.instance.added+"].Value())
+	s.Equal(int64(1), s.testScope.Snapshot().
+		Counters()["autoscaler.scale_up+"].Value())
+}
+
+// TestAutoscaleScaleDown tests that a job whose metric is below its target
+// is scaled down, not below the minimum instances of its policy
+func (s *AutoscalerTestSuite) TestAutoscaleScaleDown() {
+	s.expectServiceJob(serviceConfig(4))
+	s.expectScale(2)
+	s.source.EXPECT().
+		GetMetric(gomock.Any(), s.jobID, _testJobName, _testMetric).
+		Return(0.1, nil)
+
+	s.autoscaler.Autoscale()
+	s.Equal(int64(2), s.testScope.Snapshot().
+		Counters()["autoscaler.instance.removed+"].Value())
+}
+
+// TestAutoscaleWithinTolerance tests that a job whose metric is close to
+// its target is not scaled
+func (s *AutoscalerTestSuite) TestAutoscaleWithinTolerance() {
+	s.expectServiceJob(serviceConfig(4))
+	s.cachedJob.EXPECT().GetWorkflow(nil).Return(nil)
+	s.source.EXPECT().
+		GetMetric(gomock.Any(), s.jobID, _testJobName, _testMetric).
+		Return(0.52, nil)
+
+	s.autoscaler.Autoscale()
+	s.Contains(s.autoscaler.lastScaleTimes, s.jobID.GetValue())
+}
+
+// TestAutoscaleCooldown tests that a job is not scaled again before the
+// cooldown of its policy has elapsed since it was last scaled
+func (s *AutoscalerTestSuite) TestAutoscaleCooldown() {
+	config := serviceConfig(4)
+	config.AutoscalingPolicy.ScaleUpCooldownSecs = 60
+
+	// the cooldown is counted from the first time the job is seen
+	s.expectServiceJob(config)
+	s.cachedJob.EXPECT().GetWorkflow(nil).Return(nil)
+	s.source.EXPECT().
+		GetMetric(gomock.Any(), s.jobID, _testJobName, _testMetric).
+		Return(0.75, nil)
+	s.autoscaler.Autoscale()
+
+	s.autoscaler.lastScaleTimes[s.jobID.GetValue()] =
+		time.Now().Add(-2 * time.Minute)
+	s.expectCachedServiceJob()
+	s.expectScale(2)
+	s.source.EXPECT().
+		GetMetric(gomock.Any(), s.jobID, _testJobName, _testMetric).
+		Return(0.75, nil)
+	s.autoscaler.Autoscale()
+
+	s.Equal(int64(1), s.testScope.Snapshot().
+		Counters()["autoscaler.scale_up+"].Value())
+	s.WithinDuration(
+		time.Now(),
+		s.autoscaler.lastScaleTimes[s.jobID.GetValue()],
+		time.Minute)
+}
+
+// TestAutoscaleMetricFailure tests that a job above the maximum instances
+// of its policy is scaled down irrespective of its metric and cooldown
+func (s *AutoscalerTestSuite) TestAutoscaleMetricFailure() {
+	config := serviceConfig(12)
+	config.AutoscalingPolicy.ScaleDownCooldownSecs = 60
+	s.expectServiceJob(config)
+	s.expectScale(2)
+	s.source.EXPECT().
+		GetMetric(gomock.Any(), s.jobID, _testJobName, _testMetric).
+		Return(float64(0), errors.New("metric not found"))
+
+	s.autoscaler.Autoscale()
+	s.Equal(int64(1), s.testScope.Snapshot().
+		Counters()["autoscaler.get_metric_fail+"].Value())
+	s.Equal(int64(2), s.testScope.Snapshot().
+		Counters()["autoscaler.instance.removed+"].Value())
+}
+
+// TestAutoscaleActiveWorkflow tests that a job with an active workflow
+// is not scaled
+func (s *AutoscalerTestSuite) TestAutoscaleActiveWorkflow() {
+	updateID := &peloton.UpdateID{Value: "user-update"}
+	s.runtime.UpdateID = updateID
+	cachedUpdate := cachedmocks.NewMockUpdate(s.mockCtrl)
+
+	s.expectServiceJob(serviceConfig(4))
+	s.cachedJob.EXPECT().GetWorkflow(updateID).Return(cachedUpdate)
+	cachedUpdate.EXPECT().
+		GetState().
+		Return(&cached.UpdateStateVector{
+			State: pbupdate.State_ROLLING_FORWARD,
+		})
+
+	s.autoscaler.Autoscale()
+}
+
+// TestAutoscaleConfigCached tests that the config of a job is only read
+// from DB when its configuration version changes
+func (s *AutoscalerTestSuite) TestAutoscaleConfigCached() {
+	s.expectServiceJob(serviceConfig(4))
+	s.cachedJob.EXPECT().GetWorkflow(nil).Return(nil).Times(2)
+	s.source.EXPECT().
+		GetMetric(gomock.Any(), s.jobID, _testJobName, _testMetric).
+		Return(0.5, nil).
+		Times(2)
+	s.autoscaler.Autoscale()
+
+	s.expectCachedServiceJob()
+	s.autoscaler.Autoscale()
+
+	s.runtime.ConfigurationVersion = 4
+	s.expectCachedServiceJob()
+	s.jobConfigOps.EXPECT().
+		Get(gomock.Any(), s.jobID, uint64(4)).
+		Return(&pbjob.JobConfig{
+			Type:          pbjob.JobType_SERVICE,
+			InstanceCount: 4,
+		}, &models.ConfigAddOn{}, nil)
+	s.autoscaler.Autoscale()
+	s.Empty(s.autoscaler.lastScaleTimes)
+}
+
+// TestAutoscaleActiveWorkflowNotCached tests that a job is not scaled
+// while its workflow, which is not in the cache yet, is active
+func (s *AutoscalerTestSuite) TestAutoscaleActiveWorkflowNotCached() {
+	updateID := &peloton.UpdateID{Value: "user-update"}
+	s.runtime.UpdateID = updateID
+
+	s.expectServiceJob(serviceConfig(4))
+	s.cachedJob.EXPECT().GetWorkflow(updateID).Return(nil)
+	s.updateStore.EXPECT().
+		GetUpdate(gomock.Any(), updateID).
+		Return(&models.UpdateModel{
+			UpdateID: updateID,
+			State:    pbupdate.State_ROLLING_FORWARD,
+		}, nil)
+
+	s.autoscaler.Autoscale()
+	s.Contains(s.autoscaler.lastScaleTimes, s.jobID.GetValue())
+}
+
+// TestAutoscaleTerminatedWorkflowNotCached tests that a job is scaled
+// once its workflow, which is not in the cache, has terminated
+func (s *AutoscalerTestSuite) TestAutoscaleTerminatedWorkflowNotCached() {
+	updateID := &peloton.UpdateID{Value: "user-update"}
+	s.runtime.UpdateID = updateID
+
+	s.expectServiceJob(serviceConfig(4))
+	s.cachedJob.EXPECT().GetWorkflow(updateID).Return(nil)
+	s.updateStore.EXPECT().
+		GetUpdate(gomock.Any(), updateID).
+		Return(&models.UpdateModel{
+			UpdateID: updateID,
+			State:    pbupdate.State_SUCCEEDED,
+		}, nil)
+	s.source.EXPECT().
+		GetMetric(gomock.Any(), s.jobID, _testJobName, _testMetric).
+		Return(0.75, nil)
+	s.cachedJob.EXPECT().
+		CreateWorkflow(
+			gomock.Any(),
+			models.WorkflowType_UPDATE,
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(&peloton.UpdateID{Value: "update-id"}, nil, nil)
+	s.goalStateDriver.EXPECT().EnqueueUpdate(s.jobID, gomock.Any(), gomock.Any())
+
+	s.autoscaler.Autoscale()
+	s.Equal(int64(1), s.testScope.Snapshot().
+		Counters()["autoscaler.scale_up+"].Value())
+}
+
+// TestAutoscaleGetUpdateFailure tests that a job is not scaled if its
+// workflow, which is not in the cache, fails to be read
+func (s *AutoscalerTestSuite) TestAutoscaleGetUpdateFailure() {
+	updateID := &peloton.UpdateID{Value: "user-update"}
+	s.runtime.UpdateID = updateID
+
+	s.expectServiceJob(serviceConfig(4))
+	s.cachedJob.EXPECT().GetWorkflow(updateID).Return(nil)
+	s.updateStore.EXPECT().
+		GetUpdate(gomock.Any(), updateID).
+		Return(nil, errors.New("db error"))
+
+	s.autoscaler.Autoscale()
+	s.Equal(int64(1), s.testScope.Snapshot().
+		Counters()["autoscaler.autoscale_job_fail+"].Value())
+}
+
+// TestAutoscaleCreateWorkflowFailure tests that the update is enqueued
+// even if the creation of the workflow fails
+func (s *AutoscalerTestSuite) TestAutoscaleCreateWorkflowFailure() {
+	updateID := &peloton.UpdateID{Value: "update-id"}
+	s.expectServiceJob(serviceConfig(4))
+	s.cachedJob.EXPECT().GetWorkflow(nil).Return(nil)
+	s.source.EXPECT().
+		GetMetric(gomock.Any(), s.jobID, _testJobName, _testMetric).
+		Return(0.75, nil)
+	s.cachedJob.EXPECT().
+		CreateWorkflow(
+			gomock.Any(),
+			models.WorkflowType_UPDATE,
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(updateID, nil, errors.New("db error"))
+	s.goalStateDriver.EXPECT().EnqueueUpdate(s.jobID, updateID, gomock.Any())
+
+	s.autoscaler.Autoscale()
+	s.Equal(int64(1), s.testScope.Snapshot().
+		Counters()["autoscaler.autoscale_job_fail+"].Value())
+}
+
+// TestAutoscaleSkipJobs tests that the jobs which are not service jobs,
+// are being stopped or have no autoscaling policy are not scaled
+func (s *AutoscalerTestSuite) TestAutoscaleSkipJobs() {
+	batchJob := cachedmocks.NewMockJob(s.mockCtrl)
+	stoppedJob := cachedmocks.NewMockJob(s.mockCtrl)
+	stoppedJobID := &peloton.JobID{Value: "stopped-job"}
+
+	s.jobFactory.EXPECT().
+		GetAllJobs().
+		Return(map[string]cached.Job{
+			"batch-job":             batchJob,
+			stoppedJobID.GetValue(): stoppedJob,
+			s.jobID.GetValue():      s.cachedJob,
+		})
+	batchJob.EXPECT().GetJobType().Return(pbjob.JobType_BATCH)
+
+	stoppedJob.EXPECT().GetJobType().Return(pbjob.JobType_SERVICE)
+	stoppedJob.EXPECT().ID().Return(stoppedJobID).AnyTimes()
+	stoppedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{
+			State:     pbjob.JobState_RUNNING,
+			GoalState: pbjob.JobState_KILLED,
+		}, nil)
+
+	s.cachedJob.EXPECT().GetJobType().Return(pbjob.JobType_SERVICE)
+	s.cachedJob.EXPECT().ID().Return(s.jobID).AnyTimes()
+	s.cachedJob.EXPECT().GetRuntime(gomock.Any()).Return(s.runtime, nil)
+	s.jobConfigOps.EXPECT().
+		Get(gomock.Any(), s.jobID, uint64(3)).
+		Return(&pbjob.JobConfig{
+			Type:          pbjob.JobType_SERVICE,
+			InstanceCount: 4,
+		}, &models.ConfigAddOn{}, nil)
+
+	s.autoscaler.Autoscale()
+	s.Empty(s.autoscaler.lastScaleTimes)
+}
+
+// TestDesiredInstanceCount tests the instance count computed from the
+// metric of an autoscaling policy
+func TestDesiredInstanceCount(t *testing.T) {
+	policy := &pbjob.AutoscalingPolicy{
+		MinInstances: 2,
+		MaxInstances: 10,
+		TargetValue:  0.5,
+	}
+
+	tests := []struct {
+		current  uint32
+		value    float64
+		expected uint32
+	}{
+		// within tolerance
+		{4, 0.5, 4},
+		{4, 0.54, 4},
+		// proportional to the ratio of the value to the target
+		{4, 1, 8},
+		{4, 0.3, 3},
+		// bounded by the policy
+		{4, 100, 10},
+		{4, 0, 2},
+		{0, 0.5, 2},
+		{20, 0.5, 10},
+	}
+
+	for _, test := range tests {
+		if actual := DesiredInstanceCount(
+			policy, test.current, test.value, 0.1); actual != test.expected {
+			t.Errorf("current %d, value %v: expected %d, got %d",
+				test.current, test.value, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaler
+
+import "time"
+
+const (
+	_defaultAutoscalePeriod  = 30 * time.Second
+	_defaultAutoscaleTimeout = 20 * time.Second
+	_defaultTolerance        = 0.1
+	_defaultSourceTimeout    = 5 * time.Second
+)
+
+// Config for the autoscaler of stateless jobs
+type Config struct {
+	// Enabled determines if the autoscaler is running
+	Enabled bool `yaml:"enabled"`
+
+	// Period at which the autoscaling policies of the jobs are evaluated
+	AutoscalePeriod time.Duration `yaml:"autoscale_period"`
+
+	// Timeout of the evaluation of a single autoscaled job
+	AutoscaleTimeout time.Duration `yaml:"autoscale_timeout"`
+
+	// Tolerance is the relative difference between the value of the
+	// metric of a job and its target value below which the job is not
+	// scaled, so that small fluctuations do not cause updates
+	Tolerance float64 `yaml:"tolerance"`
+
+	// Source of the metrics of the jobs
+	MetricsSource MetricsSourceConfig `yaml:"metrics_source"`
+}
+
+// MetricsSourceConfig is the config of the source of the metrics of
+// the autoscaled jobs
+type MetricsSourceConfig struct {
+	// Type of the metrics source, file or http
+	Type string `yaml:"type"`
+
+	// Path of the metrics file of the file source
+	Path string `yaml:"path"`
+
+	// URL queried by the http source
+	URL string `yaml:"url"`
+
+	// Timeout of a query of the http source
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func (c *Config) normalize() {
+	if c.AutoscalePeriod == time.Duration(0) {
+		c.AutoscalePeriod = _defaultAutoscalePeriod
+	}
+
+	if c.AutoscaleTimeout == time.Duration(0) {
+		c.AutoscaleTimeout = _defaultAutoscaleTimeout
+	}
+
+	if c.Tolerance == 0 {
+		c.Tolerance = _defaultTolerance
+	}
+
+	c.MetricsSource.normalize()
+}
+
+func (c *MetricsSourceConfig) normalize() {
+	if c.Timeout == time.Duration(0) {
+		c.Timeout = _defaultSourceTimeout
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaler
+
+import "github.com/uber-go/tally"
+
+// Metrics is the struct containing all metrics relevant for
+// the autoscaler of stateless jobs
+type Metrics struct {
+	AutoscaledJobs    tally.Gauge
+	ScaleUp           tally.Counter
+	ScaleDown         tally.Counter
+	InstancesAdded    tally.Counter
+	InstancesRemoved  tally.Counter
+	AutoscaleDuration tally.Timer
+
+	GetMetricFail    tally.Counter
+	AutoscaleJobFail tally.Counter
+}
+
+// NewMetrics returns a new Metrics struct, with all metrics
+// initialized and rooted at the given tally.Scope
+func NewMetrics(scope tally.Scope) *Metrics {
+	autoscalerScope := scope.SubScope("autoscaler")
+	instanceScope := autoscalerScope.SubScope("instance")
+	return &Metrics{
+		AutoscaledJobs:    autoscalerScope.Gauge("jobs"),
+		ScaleUp:           autoscalerScope.Counter("scale_up"),
+		ScaleDown:         autoscalerScope.Counter("scale_down"),
+		InstancesAdded:    instanceScope.Counter("added"),
+		InstancesRemoved:  instanceScope.Counter("removed"),
+		AutoscaleDuration: autoscalerScope.Timer("duration"),
+
+		GetMetricFail:    autoscalerScope.Counter("get_metric_fail"),
+		AutoscaleJobFail: autoscalerScope.Counter("autoscale_job_fail"),
+	}
+}
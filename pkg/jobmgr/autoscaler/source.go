@@ -0,0 +1,164 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaler
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// MetricsSourceFile is the type of the source reading the metrics
+	// from a local file
+	MetricsSourceFile = "file"
+
+	// MetricsSourceHTTP is the type of the source querying the metrics
+	// from an HTTP endpoint
+	MetricsSourceHTTP = "http"
+)
+
+// MetricsSource returns the current value of the metrics of the jobs
+type MetricsSource interface {
+	// GetMetric returns the value of the metric of the job averaged over
+	// its instances.
+	GetMetric(
+		ctx context.Context,
+		jobID *peloton.JobID,
+		jobName string,
+		metric string,
+	) (float64, error)
+}
+
+// NewMetricsSource returns the metrics source of the config
+func NewMetricsSource(config *MetricsSourceConfig) (MetricsSource, error) {
+	config.normalize()
+	switch config.Type {
+	case MetricsSourceFile:
+		if len(config.Path) == 0 {
+			return nil, errors.New("path of the metrics file is not set")
+		}
+		return &fileSource{path: config.Path}, nil
+	case MetricsSourceHTTP:
+		if _, err := url.Parse(config.URL); err != nil ||
+			len(config.URL) == 0 {
+			return nil, errors.Errorf("invalid metrics url %q", config.URL)
+		}
+		return &httpSource{
+			url:    config.URL,
+			client: &http.Client{Timeout: config.Timeout},
+		}, nil
+	}
+	return nil, errors.Errorf("unknown metrics source type %q", config.Type)
+}
+
+// fileSource reads the metrics from a YAML file mapping the ID or the
+// name of the jobs to the values of their metrics, e.g.
+//
+//	my-service:
+//	  cpu_utilization: 0.75
+//
+// The file is read on every call, so that it can be rewritten by another
+// process, or by tests, while the autoscaler is running.
+type fileSource struct {
+	path string
+}
+
+// GetMetric implements MetricsSource
+func (s *fileSource) GetMetric(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	jobName string,
+	metric string,
+) (float64, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read metrics file")
+	}
+
+	var jobMetrics map[string]map[string]float64
+	if err := yaml.Unmarshal(data, &jobMetrics); err != nil {
+		return 0, errors.Wrap(err, "failed to parse metrics file")
+	}
+
+	for _, key := range []string{jobID.GetValue(), jobName} {
+		if value, ok := jobMetrics[key][metric]; ok {
+			return value, nil
+		}
+	}
+	return 0, errors.Errorf(
+		"metric %s of job %s not found", metric, jobID.GetValue())
+}
+
+// httpSource queries the metrics from an HTTP endpoint. The job ID, job
+// name and metric are passed as the job_id, job_name and metric query
+// parameters, and the endpoint responds with a JSON object holding the
+// value of the metric, e.g. {"value": 0.75}.
+type httpSource struct {
+	url    string
+	client *http.Client
+}
+
+// metricResponse is the response of the endpoint of the http source
+type metricResponse struct {
+	Value *float64 `json:"value"`
+}
+
+// GetMetric implements MetricsSource
+func (s *httpSource) GetMetric(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	jobName string,
+	metric string,
+) (float64, error) {
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	query := req.URL.Query()
+	query.Set("job_id", jobID.GetValue())
+	query.Set("job_name", jobName)
+	query.Set("metric", metric)
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to query metric")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.Errorf(
+			"failed to query metric %s of job %s: %s",
+			metric, jobID.GetValue(), resp.Status)
+	}
+
+	var body metricResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, errors.Wrap(err, "failed to decode metric")
+	}
+	if body.Value == nil {
+		return 0, errors.Errorf(
+			"metric %s of job %s not found", metric, jobID.GetValue())
+	}
+	return *body.Value, nil
+}
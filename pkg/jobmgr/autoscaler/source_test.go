@@ -0,0 +1,155 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewMetricsSource tests the creation of the metrics sources
+func TestNewMetricsSource(t *testing.T) {
+	_, err := NewMetricsSource(&MetricsSourceConfig{Type: "prometheus"})
+	assert.Error(t, err)
+
+	_, err = NewMetricsSource(&MetricsSourceConfig{Type: MetricsSourceFile})
+	assert.Error(t, err)
+
+	_, err = NewMetricsSource(&MetricsSourceConfig{Type: MetricsSourceHTTP})
+	assert.Error(t, err)
+
+	source, err := NewMetricsSource(&MetricsSourceConfig{
+		Type: MetricsSourceFile,
+		Path: "/tmp/metrics.yaml",
+	})
+	assert.NoError(t, err)
+	assert.IsType(t, &fileSource{}, source)
+
+	// the http client has the default timeout of an unset timeout
+	source, err = NewMetricsSource(&MetricsSourceConfig{
+		Type: MetricsSourceHTTP,
+		URL:  "http://localhost:8080/metrics",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, _defaultSourceTimeout,
+		source.(*httpSource).client.Timeout)
+}
+
+// TestFileSource tests reading the metrics of the jobs by ID and by name
+// from a metrics file
+func TestFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "autoscaler")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "metrics.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+job-id:
+  cpu_utilization: 0.25
+test-service:
+  cpu_utilization: 0.75
+`), 0644))
+
+	source, err := NewMetricsSource(&MetricsSourceConfig{
+		Type: MetricsSourceFile,
+		Path: path,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	value, err := source.GetMetric(
+		ctx, &peloton.JobID{Value: "job-id"}, "test-service", _testMetric)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, value)
+
+	value, err = source.GetMetric(
+		ctx, &peloton.JobID{Value: "other-id"}, "test-service", _testMetric)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, value)
+
+	_, err = source.GetMetric(
+		ctx, &peloton.JobID{Value: "other-id"}, "test-service", "qps")
+	assert.Error(t, err)
+
+	// the file is read again on every call
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+test-service:
+  qps: 100
+`), 0644))
+	value, err = source.GetMetric(
+		ctx, &peloton.JobID{Value: "other-id"}, "test-service", "qps")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(100), value)
+
+	require.NoError(t, os.Remove(path))
+	_, err = source.GetMetric(
+		ctx, &peloton.JobID{Value: "job-id"}, "test-service", _testMetric)
+	assert.Error(t, err)
+}
+
+// TestHTTPSource tests querying the metrics of the jobs from an HTTP
+// endpoint
+func TestHTTPSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			switch {
+			case query.Get("metric") == "error":
+				w.WriteHeader(http.StatusInternalServerError)
+			case query.Get("metric") == "missing":
+				fmt.Fprint(w, `{}`)
+			case query.Get("job_id") == "job-id" &&
+				query.Get("job_name") == "test-service":
+				fmt.Fprint(w, `{"value": 0.75}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	defer server.Close()
+
+	source, err := NewMetricsSource(&MetricsSourceConfig{
+		Type:    MetricsSourceHTTP,
+		URL:     server.URL,
+		Timeout: time.Second,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: "job-id"}
+	value, err := source.GetMetric(ctx, jobID, "test-service", _testMetric)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, value)
+
+	_, err = source.GetMetric(ctx, jobID, "other-service", _testMetric)
+	assert.Error(t, err)
+
+	_, err = source.GetMetric(ctx, jobID, "test-service", "error")
+	assert.Error(t, err)
+
+	_, err = source.GetMetric(ctx, jobID, "test-service", "missing")
+	assert.Error(t, err)
+}
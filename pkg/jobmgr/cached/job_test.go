@@ -2291,7 +2291,8 @@ func (suite *JobTestSuite) TestJobCreateWorkflowSuccess() {
 				gomock.Any(),
 				i,
 				workflowType,
-				pbupdate.State_INITIALIZED).Return(nil)
+				pbupdate.State_INITIALIZED,
+				gomock.Any()).Return(nil)
 	}
 
 	gomock.InOrder(
@@ -2416,7 +2417,8 @@ func (suite *JobTestSuite) TestJobCreateWorkflowWithStartTasksForStoppedJobSucce
 				gomock.Any(),
 				i,
 				workflowType,
-				pbupdate.State_INITIALIZED).Return(nil)
+				pbupdate.State_INITIALIZED,
+				gomock.Any()).Return(nil)
 	}
 
 	gomock.InOrder(
@@ -2608,7 +2610,8 @@ func (suite *JobTestSuite) TestJobCreateWorkflowWorkflowCreationFailure() {
 				gomock.Any(),
 				uint32(i),
 				models.WorkflowType_START,
-				pbupdate.State_INITIALIZED).
+				pbupdate.State_INITIALIZED,
+				gomock.Any()).
 			Return(nil)
 	}
 
@@ -2714,7 +2717,8 @@ func (suite *JobTestSuite) TestJobCreateWorkflowUpdateRuntimeFailure() {
 				gomock.Any(),
 				i,
 				workflowType,
-				pbupdate.State_INITIALIZED).Return(nil)
+				pbupdate.State_INITIALIZED,
+				gomock.Any()).Return(nil)
 	}
 
 	gomock.InOrder(
@@ -2828,7 +2832,8 @@ func (suite *JobTestSuite) TestJobCreateWorkflowOnDeletedJobError() {
 				gomock.Any(),
 				i,
 				workflowType,
-				pbupdate.State_INITIALIZED).Return(nil)
+				pbupdate.State_INITIALIZED,
+				gomock.Any()).Return(nil)
 	}
 
 	gomock.InOrder(
@@ -3602,7 +3607,8 @@ func (suite *JobTestSuite) TestRollbackWorkflowSuccess() {
 				gomock.Any(),
 				i,
 				models.WorkflowType_UPDATE,
-				pbupdate.State_ROLLING_BACKWARD).
+				pbupdate.State_ROLLING_BACKWARD,
+				gomock.Any()).
 			Return(nil)
 	}
 
@@ -3999,7 +4005,8 @@ func (suite *JobTestSuite) TestRollbackWorkflowSuccessAfterModifyUpdateFails() {
 				gomock.Any(),
 				uint32(i),
 				models.WorkflowType_UPDATE,
-				pbupdate.State_ROLLING_BACKWARD).
+				pbupdate.State_ROLLING_BACKWARD,
+				gomock.Any()).
 			Return(nil)
 	}
 
@@ -4033,7 +4040,8 @@ func (suite *JobTestSuite) TestRollbackWorkflowSuccessAfterModifyUpdateFails() {
 				gomock.Any(),
 				uint32(i),
 				models.WorkflowType_UPDATE,
-				pbupdate.State_ROLLING_BACKWARD).
+				pbupdate.State_ROLLING_BACKWARD,
+				gomock.Any()).
 			Return(nil)
 	}
 
@@ -4227,7 +4235,8 @@ func (suite *JobTestSuite) TestRollbackWorkflowSuccessAfterJobRuntimeUpdateDBWri
 				gomock.Any(),
 				uint32(i),
 				models.WorkflowType_UPDATE,
-				pbupdate.State_ROLLING_BACKWARD).
+				pbupdate.State_ROLLING_BACKWARD,
+				gomock.Any()).
 			Return(nil).AnyTimes()
 	}
 
@@ -4381,7 +4390,8 @@ func (suite *JobTestSuite) TestRollbackWorkflowSuccessAfterJobRuntimeDBWriteSucc
 				gomock.Any(),
 				uint32(i),
 				models.WorkflowType_UPDATE,
-				pbupdate.State_ROLLING_BACKWARD).
+				pbupdate.State_ROLLING_BACKWARD,
+				gomock.Any()).
 			Return(nil).AnyTimes()
 	}
 
@@ -4753,7 +4763,8 @@ func (suite *JobTestSuite) TestJobRollingCreateSuccess() {
 	suite.updateStore.EXPECT().
 		AddWorkflowEvent(
 			gomock.Any(), gomock.Any(),
-			gomock.Any(), gomock.Any(), gomock.Any()).
+			gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any()).
 		Return(nil).
 		Times(int(jobConfig.InstanceCount))
 
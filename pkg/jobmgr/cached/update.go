@@ -1191,7 +1191,8 @@ func (u *update) addWorkflowEventForInstances(
 			updateID,
 			id,
 			workflowType,
-			workflowState)
+			workflowState,
+			"")
 	}
 
 	// add workflow events for provided instances in parallel batches.
@@ -118,7 +118,8 @@ func (suite *UpdateTestSuite) TestModifyValid() {
 			suite.updateID,
 			gomock.Any(),
 			suite.update.workflowType,
-			suite.update.state).
+			suite.update.state,
+			gomock.Any()).
 		Return(nil).
 		Times(6)
 
@@ -157,7 +158,8 @@ func (suite *UpdateTestSuite) TestModifyDBError() {
 			gomock.Any(),
 			gomock.Any(),
 			gomock.Any(),
-			pbupdate.State_ROLLING_FORWARD).
+			pbupdate.State_ROLLING_FORWARD,
+			gomock.Any()).
 		Return(nil).Times(6)
 	suite.updateStore.EXPECT().
 		AddJobUpdateEvent(
@@ -219,6 +221,7 @@ func (suite *UpdateTestSuite) TestValidWriteProgress() {
 			suite.updateID,
 			gomock.Any(),
 			gomock.Any(),
+			gomock.Any(),
 			gomock.Any()).Return(nil).Times(4)
 
 	err := suite.update.WriteProgress(
@@ -399,7 +402,8 @@ func (suite *UpdateTestSuite) TestConsecutiveWriteProgressPrevState() {
 			suite.updateID,
 			uint32(0),
 			models.WorkflowType_UPDATE,
-			pbupdate.State_ROLLING_FORWARD).
+			pbupdate.State_ROLLING_FORWARD,
+			gomock.Any()).
 		Return(nil)
 
 	suite.updateStore.EXPECT().
@@ -408,7 +412,8 @@ func (suite *UpdateTestSuite) TestConsecutiveWriteProgressPrevState() {
 			suite.updateID,
 			uint32(1),
 			models.WorkflowType_UPDATE,
-			pbupdate.State_ROLLING_FORWARD).
+			pbupdate.State_ROLLING_FORWARD,
+			gomock.Any()).
 		Return(nil)
 
 	// prev state should still be INITIALIZED after we write
@@ -435,7 +440,8 @@ func (suite *UpdateTestSuite) TestConsecutiveWriteProgressPrevState() {
 			suite.updateID,
 			uint32(0),
 			models.WorkflowType_UPDATE,
-			pbupdate.State_SUCCEEDED).
+			pbupdate.State_SUCCEEDED,
+			gomock.Any()).
 		Return(nil)
 
 	suite.updateStore.EXPECT().
@@ -444,7 +450,8 @@ func (suite *UpdateTestSuite) TestConsecutiveWriteProgressPrevState() {
 			suite.updateID,
 			uint32(1),
 			models.WorkflowType_UPDATE,
-			pbupdate.State_SUCCEEDED).
+			pbupdate.State_SUCCEEDED,
+			gomock.Any()).
 		Return(nil)
 
 	suite.updateStore.EXPECT().
@@ -453,7 +460,8 @@ func (suite *UpdateTestSuite) TestConsecutiveWriteProgressPrevState() {
 			suite.updateID,
 			uint32(2),
 			models.WorkflowType_UPDATE,
-			pbupdate.State_ROLLING_FORWARD).
+			pbupdate.State_ROLLING_FORWARD,
+			gomock.Any()).
 		Return(nil)
 
 	suite.updateStore.EXPECT().
@@ -462,7 +470,8 @@ func (suite *UpdateTestSuite) TestConsecutiveWriteProgressPrevState() {
 			suite.updateID,
 			uint32(3),
 			models.WorkflowType_UPDATE,
-			pbupdate.State_ROLLING_FORWARD).
+			pbupdate.State_ROLLING_FORWARD,
+			gomock.Any()).
 		Return(nil)
 
 	suite.NoError(suite.update.WriteProgress(
@@ -486,7 +495,8 @@ func (suite *UpdateTestSuite) TestConsecutiveWriteProgressPrevState() {
 			suite.updateID,
 			uint32(2),
 			models.WorkflowType_UPDATE,
-			pbupdate.State_ROLLING_BACKWARD).
+			pbupdate.State_ROLLING_BACKWARD,
+			gomock.Any()).
 		Return(nil)
 
 	suite.updateStore.EXPECT().
@@ -495,7 +505,8 @@ func (suite *UpdateTestSuite) TestConsecutiveWriteProgressPrevState() {
 			suite.updateID,
 			uint32(3),
 			models.WorkflowType_UPDATE,
-			pbupdate.State_ROLLING_BACKWARD).
+			pbupdate.State_ROLLING_BACKWARD,
+			gomock.Any()).
 		Return(nil)
 
 	suite.updateStore.EXPECT().
@@ -684,7 +695,8 @@ func (suite *UpdateTestSuite) TestCancelValid() {
 				suite.updateID,
 				i,
 				suite.update.workflowType,
-				pbupdate.State_ABORTED)
+				pbupdate.State_ABORTED,
+				gomock.Any())
 	}
 
 	suite.updateStore.EXPECT().
@@ -1764,7 +1776,8 @@ func (suite *UpdateTestSuite) TestUpdateCreateSuccess() {
 				gomock.Any(),
 				instance,
 				workflowType,
-				pbupdate.State_INITIALIZED).Return(nil)
+				pbupdate.State_INITIALIZED,
+				gomock.Any()).Return(nil)
 	}
 
 	suite.NoError(suite.update.Create(
@@ -1833,7 +1846,8 @@ func (suite *UpdateTestSuite) TestUpdateCreatePausedSuccess() {
 				gomock.Any(),
 				instance,
 				workflowType,
-				pbupdate.State_PAUSED).Return(nil)
+				pbupdate.State_PAUSED,
+				gomock.Any()).Return(nil)
 	}
 
 	suite.NoError(suite.update.Create(
@@ -17,8 +17,12 @@ package jobmgr
 import (
 	"time"
 
+	"github.com/uber/peloton/pkg/jobmgr/autoscaler"
+	"github.com/uber/peloton/pkg/jobmgr/cron"
+	"github.com/uber/peloton/pkg/jobmgr/daemon"
 	"github.com/uber/peloton/pkg/jobmgr/goalstate"
 	"github.com/uber/peloton/pkg/jobmgr/jobsvc"
+	"github.com/uber/peloton/pkg/jobmgr/pipeline"
 	"github.com/uber/peloton/pkg/jobmgr/task/deadline"
 	"github.com/uber/peloton/pkg/jobmgr/task/placement"
 	"github.com/uber/peloton/pkg/jobmgr/task/preemptor"
@@ -60,6 +64,18 @@ type Config struct {
 	// WorkflowProgressCheck specific configuration
 	WorkflowProgressCheck progress.Config `yaml:"workflow_progress_check"`
 
+	// Daemon job reconciler specific configuration
+	Daemon daemon.Config `yaml:"daemon"`
+
+	// Cron job scheduler specific configuration
+	Cron cron.Config `yaml:"cron"`
+
+	// Pipeline engine specific configuration
+	Pipeline pipeline.Config `yaml:"pipeline"`
+
+	// Autoscaler of stateless jobs specific configuration
+	Autoscaler autoscaler.Config `yaml:"autoscaler"`
+
 	// Period in sec for updating active cache
 	ActiveTaskUpdatePeriod time.Duration `yaml:"active_task_update_period"`
 
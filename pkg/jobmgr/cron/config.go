@@ -0,0 +1,42 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import "time"
+
+const (
+	_defaultSchedulePeriod  = 10 * time.Second
+	_defaultScheduleTimeout = 30 * time.Second
+)
+
+// Config for the cron job scheduler
+type Config struct {
+	// Period at which the schedules of the cron jobs are evaluated,
+	// it bounds how late a run can be created after its scheduled time
+	SchedulePeriod time.Duration `yaml:"schedule_period"`
+
+	// Timeout of a single evaluation of all cron jobs
+	ScheduleTimeout time.Duration `yaml:"schedule_timeout"`
+}
+
+func (c *Config) normalize() {
+	if c.SchedulePeriod == time.Duration(0) {
+		c.SchedulePeriod = _defaultSchedulePeriod
+	}
+
+	if c.ScheduleTimeout == time.Duration(0) {
+		c.ScheduleTimeout = _defaultScheduleTimeout
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import "github.com/uber-go/tally"
+
+// Metrics is the struct containing all metrics relevant for
+// the cron job scheduler
+type Metrics struct {
+	CronJobs         tally.Gauge
+	ActiveRuns       tally.Gauge
+	RunsCreated      tally.Counter
+	RunsSkipped      tally.Counter
+	RunsKilled       tally.Counter
+	ScheduleDuration tally.Timer
+
+	GetCronJobsFail tally.Counter
+	CreateRunFail   tally.Counter
+	KillRunFail     tally.Counter
+	UpdateFail      tally.Counter
+}
+
+// NewMetrics returns a new Metrics struct, with all metrics
+// initialized and rooted at the given tally.Scope
+func NewMetrics(scope tally.Scope) *Metrics {
+	cronScope := scope.SubScope("cron")
+	runScope := cronScope.SubScope("run")
+	return &Metrics{
+		CronJobs:         cronScope.Gauge("jobs"),
+		ActiveRuns:       runScope.Gauge("active"),
+		RunsCreated:      runScope.Counter("created"),
+		RunsSkipped:      runScope.Counter("skipped"),
+		RunsKilled:       runScope.Counter("killed"),
+		ScheduleDuration: cronScope.Timer("duration"),
+
+		GetCronJobsFail: cronScope.Counter("get_jobs_fail"),
+		CreateRunFail:   runScope.Counter("create_fail"),
+		KillRunFail:     runScope.Counter("kill_fail"),
+		UpdateFail:      cronScope.Counter("update_fail"),
+	}
+}
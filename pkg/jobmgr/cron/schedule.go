@@ -0,0 +1,229 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// _maxScheduleSearch bounds the search for the next activation of a
+// schedule, schedules such as "0 0 30 2 *" never fire.
+const _maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// descriptors are the predefined schedules which can be used in place
+// of the five fields of a schedule
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field describes the valid values of one field of a schedule
+type field struct {
+	name     string
+	min, max uint
+}
+
+var (
+	minuteField     = field{"minute", 0, 59}
+	hourField       = field{"hour", 0, 23}
+	dayOfMonthField = field{"day of month", 1, 31}
+	monthField      = field{"month", 1, 12}
+	// 7 is accepted as Sunday in addition to 0
+	dayOfWeekField = field{"day of week", 0, 7}
+)
+
+// Schedule is a parsed cron schedule in the standard five field format.
+// Each field is stored as a bit set of the values at which it matches.
+type Schedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+
+	// set when the corresponding field is "*", used to decide how the
+	// day of month and the day of week are combined
+	dayOfMonthStar bool
+	dayOfWeekStar  bool
+}
+
+// ParseSchedule parses a cron schedule with the fields minute, hour,
+// day of month, month and day of week. Each field is either "*", a
+// value, a range "a-b" or a comma separated list of those, optionally
+// followed by a step "/n". The descriptors "@hourly", "@daily",
+// "@weekly", "@monthly" and "@yearly" are accepted as well.
+func ParseSchedule(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[expr]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf(
+			"schedule %q should have 5 fields, found %d", expr, len(fields))
+	}
+
+	s := &Schedule{}
+	var err error
+	if s.minute, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.dayOfMonth, err = parseField(fields[2], dayOfMonthField); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.dayOfWeek, err = parseField(fields[4], dayOfWeekField); err != nil {
+		return nil, err
+	}
+
+	// fold Sunday as 7 into Sunday as 0
+	if s.dayOfWeek&(1<<7) != 0 {
+		s.dayOfWeek |= 1
+	}
+	s.dayOfMonthStar = strings.HasPrefix(fields[2], "*")
+	s.dayOfWeekStar = strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// parseField parses a comma separated list of ranges of a field into
+// a bit set.
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for _, r := range strings.Split(expr, ",") {
+		b, err := parseRange(r, f)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+// parseRange parses one range of a field, i.e. "*", "a" or "a-b",
+// optionally followed by "/step", into a bit set.
+func parseRange(expr string, f field) (uint64, error) {
+	rangeExpr := expr
+	step := uint(1)
+	if i := strings.Index(expr, "/"); i >= 0 {
+		s, err := strconv.ParseUint(expr[i+1:], 10, 8)
+		if err != nil || s == 0 {
+			return 0, fmt.Errorf("invalid step in %s %q", f.name, expr)
+		}
+		rangeExpr = expr[:i]
+		step = uint(s)
+	}
+
+	var start, end uint
+	switch {
+	case rangeExpr == "*":
+		start, end = f.min, f.max
+	case strings.Contains(rangeExpr, "-"):
+		parts := strings.SplitN(rangeExpr, "-", 2)
+		var err error
+		if start, err = parseValue(parts[0], f); err != nil {
+			return 0, err
+		}
+		if end, err = parseValue(parts[1], f); err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range in %s %q", f.name, expr)
+		}
+	default:
+		v, err := parseValue(rangeExpr, f)
+		if err != nil {
+			return 0, err
+		}
+		start, end = v, v
+		// "a/n" is a shorthand for "a-max/n"
+		if step > 1 {
+			end = f.max
+		}
+	}
+
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << v
+	}
+	return bits, nil
+}
+
+// parseValue parses a single value of a field and checks its bounds.
+func parseValue(expr string, f field) (uint, error) {
+	v, err := strconv.ParseUint(expr, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s %q", f.name, expr)
+	}
+	if uint(v) < f.min || uint(v) > f.max {
+		return 0, fmt.Errorf(
+			"%s %d out of range [%d, %d]", f.name, v, f.min, f.max)
+	}
+	return uint(v), nil
+}
+
+// Next returns the first activation of the schedule strictly after
+// the given time, evaluated in UTC. A zero time is returned if the
+// schedule does not fire within the next five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(_maxScheduleSearch)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay returns whether the day of the given time matches the
+// schedule. As in the standard cron, if both the day of month and the
+// day of week are restricted, the day matches when either matches.
+func (s *Schedule) matchDay(t time.Time) bool {
+	domMatch := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dowMatch := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.dayOfMonthStar || s.dayOfWeekStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ScheduleTestSuite struct {
+	suite.Suite
+}
+
+func TestSchedule(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
+
+func (suite *ScheduleTestSuite) parseTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	suite.NoError(err)
+	return t
+}
+
+// TestParseScheduleErrors tests that invalid schedules are rejected
+func (suite *ScheduleTestSuite) TestParseScheduleErrors() {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"a * * * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"1,,2 * * * *",
+		"@every",
+	} {
+		_, err := ParseSchedule(expr)
+		suite.Error(err, expr)
+	}
+}
+
+// TestScheduleNext tests the next activation of various schedules
+func (suite *ScheduleTestSuite) TestScheduleNext() {
+	tt := []struct {
+		expr     string
+		from     string
+		expected string
+	}{
+		{"* * * * *", "2019-01-01T00:00:00Z", "2019-01-01T00:01:00Z"},
+		{"* * * * *", "2019-01-01T00:00:30Z", "2019-01-01T00:01:00Z"},
+		{"*/15 * * * *", "2019-01-01T00:07:00Z", "2019-01-01T00:15:00Z"},
+		{"*/15 * * * *", "2019-01-01T00:45:00Z", "2019-01-01T01:00:00Z"},
+		{"5/20 * * * *", "2019-01-01T00:06:00Z", "2019-01-01T00:25:00Z"},
+		{"0,30 * * * *", "2019-01-01T00:10:00Z", "2019-01-01T00:30:00Z"},
+		{"0 9-17/4 * * *", "2019-01-01T10:00:00Z", "2019-01-01T13:00:00Z"},
+		{"30 2 * * *", "2019-01-01T03:00:00Z", "2019-01-02T02:30:00Z"},
+		{"0 0 1 * *", "2019-01-15T00:00:00Z", "2019-02-01T00:00:00Z"},
+		{"0 0 31 * *", "2019-02-01T00:00:00Z", "2019-03-31T00:00:00Z"},
+		{"0 0 29 2 *", "2019-01-01T00:00:00Z", "2020-02-29T00:00:00Z"},
+		{"0 0 * * 1", "2019-01-01T00:00:00Z", "2019-01-07T00:00:00Z"},
+		{"0 0 * * 7", "2019-01-01T00:00:00Z", "2019-01-06T00:00:00Z"},
+		// day of month or day of week when both are restricted
+		{"0 0 15 * 1", "2019-01-01T00:00:00Z", "2019-01-07T00:00:00Z"},
+		{"0 0 15 * 1", "2019-01-08T00:00:00Z", "2019-01-14T00:00:00Z"},
+		{"0 0 15 * 1", "2019-01-14T00:00:00Z", "2019-01-15T00:00:00Z"},
+		{"0 0 1 1 *", "2019-06-01T00:00:00Z", "2020-01-01T00:00:00Z"},
+		{"@hourly", "2019-01-01T00:10:00Z", "2019-01-01T01:00:00Z"},
+		{"@daily", "2019-01-01T00:10:00Z", "2019-01-02T00:00:00Z"},
+		{"@weekly", "2019-01-01T00:00:00Z", "2019-01-06T00:00:00Z"},
+		{"@monthly", "2019-12-10T00:00:00Z", "2020-01-01T00:00:00Z"},
+		{"@yearly", "2019-12-10T00:00:00Z", "2020-01-01T00:00:00Z"},
+	}
+
+	for _, test := range tt {
+		s, err := ParseSchedule(test.expr)
+		suite.NoError(err, test.expr)
+		suite.Equal(
+			suite.parseTime(test.expected),
+			s.Next(suite.parseTime(test.from)),
+			test.expr)
+	}
+}
+
+// TestScheduleNextNonUTC tests that schedules are evaluated in UTC
+func (suite *ScheduleTestSuite) TestScheduleNextNonUTC() {
+	s, err := ParseSchedule("0 12 * * *")
+	suite.NoError(err)
+
+	from := suite.parseTime("2019-01-01T13:00:00+02:00")
+	suite.Equal(suite.parseTime("2019-01-01T12:00:00Z"), s.Next(from))
+}
+
+// TestScheduleNextNever tests a schedule which never fires
+func (suite *ScheduleTestSuite) TestScheduleNextNever() {
+	s, err := ParseSchedule("0 0 30 2 *")
+	suite.NoError(err)
+	suite.True(s.Next(suite.parseTime("2019-01-01T00:00:00Z")).IsZero())
+}
@@ -0,0 +1,467 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbcron "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+
+	"github.com/uber/peloton/pkg/auth"
+	"github.com/uber/peloton/pkg/common/background"
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/jobmgr/cached"
+	"github.com/uber/peloton/pkg/jobmgr/goalstate"
+	handlerutil "github.com/uber/peloton/pkg/jobmgr/util/handler"
+	jobutil "github.com/uber/peloton/pkg/jobmgr/util/job"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/atomic"
+	"github.com/uber-go/tally"
+)
+
+const (
+	_cronSchedulerName = "cronScheduler"
+
+	// CronJobLabelKey is the key of the label added to every batch job
+	// created by a cron job, its value is the name of the cron job
+	CronJobLabelKey = "peloton.cron_job"
+
+	// _runNameTimeFormat is the format of the time of the run appended
+	// to the name of the template of a cron job
+	_runNameTimeFormat = "20060102-1504"
+)
+
+// _runNamespace is the namespace of the IDs of the scheduled runs
+var _runNamespace = uuid.NewSHA1(uuid.NameSpace_OID, []byte(CronJobLabelKey))
+
+// Scheduler creates batch jobs from the templates of the cron jobs
+// whenever their schedule fires, applying their collision policy to the
+// runs which are still active
+type Scheduler interface {
+	// Register registers the scheduler in background.Manager, so that
+	// it only runs on the leader
+	Register(manager background.Manager) error
+
+	// Create creates a new cron job and computes its first run.
+	Create(ctx context.Context, spec *pbcron.CronJobSpec) error
+
+	// Replace replaces the specification of an existing cron job and
+	// recomputes its next run according to the new schedule.
+	Replace(ctx context.Context, spec *pbcron.CronJobSpec) error
+
+	// Trigger starts a run of the cron job immediately, irrespective of
+	// its schedule. It returns nil if the run has been skipped due to the
+	// collision policy of the cron job.
+	Trigger(ctx context.Context, name string) (*v1alphapeloton.JobID, error)
+
+	// Delete deletes the cron job. All the mutations of the cron jobs go
+	// through the scheduler, so that they are serialized with its runs.
+	Delete(ctx context.Context, name string) error
+}
+
+// scheduler implements Scheduler
+type scheduler struct {
+	sync.Mutex
+
+	cronJobOps      ormobjects.CronJobOps
+	jobFactory      cached.JobFactory
+	goalStateDriver goalstate.Driver
+	jobCreator      jobutil.JobCreator
+	metrics         *Metrics
+	config          *Config
+}
+
+// NewScheduler creates a new cron job Scheduler
+func NewScheduler(
+	cronJobOps ormobjects.CronJobOps,
+	jobFactory cached.JobFactory,
+	goalStateDriver goalstate.Driver,
+	jobCreator jobutil.JobCreator,
+	parent tally.Scope,
+	config *Config,
+) Scheduler {
+	if config == nil {
+		config = &Config{}
+	}
+	config.normalize()
+
+	return &scheduler{
+		cronJobOps:      cronJobOps,
+		jobFactory:      jobFactory,
+		goalStateDriver: goalStateDriver,
+		jobCreator:      jobCreator,
+		metrics:         NewMetrics(parent),
+		config:          config,
+	}
+}
+
+// Register registers the scheduler in background.Manager
+func (s *scheduler) Register(manager background.Manager) error {
+	return manager.RegisterWorks(
+		background.Work{
+			Name: _cronSchedulerName,
+			Func: func(_ *atomic.Bool) {
+				s.run()
+			},
+			Period: s.config.SchedulePeriod,
+		},
+	)
+}
+
+// run evaluates the schedule of all cron jobs and creates the runs
+// which are due
+func (s *scheduler) run() {
+	stopWatch := s.metrics.ScheduleDuration.Start()
+	defer stopWatch.Stop()
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), s.config.ScheduleTimeout)
+	defer cancel()
+
+	s.schedule(ctx, time.Now().UTC())
+}
+
+// schedule evaluates all cron jobs at the given time
+func (s *scheduler) schedule(ctx context.Context, now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	cronJobs, err := s.cronJobOps.GetAll(ctx)
+	if err != nil {
+		s.metrics.GetCronJobsFail.Inc(1)
+		log.WithError(err).Warn("failed to get cron jobs")
+		return
+	}
+	s.metrics.CronJobs.Update(float64(len(cronJobs)))
+
+	var activeRuns int
+	for _, cronJob := range cronJobs {
+		status, _, err := s.process(ctx, cronJob, now, false)
+		if err != nil {
+			log.WithError(err).
+				WithField("cron_job", cronJob.GetSpec().GetName()).
+				Warn("failed to schedule cron job")
+			continue
+		}
+		activeRuns += len(status.GetActiveRuns())
+	}
+	s.metrics.ActiveRuns.Update(float64(activeRuns))
+}
+
+// Create creates a new cron job
+func (s *scheduler) Create(
+	ctx context.Context,
+	spec *pbcron.CronJobSpec,
+) error {
+	sched, err := ParseSchedule(spec.GetSchedule())
+	if err != nil {
+		return err
+	}
+
+	status := &pbcron.CronJobStatus{
+		NextRunTime: formatTime(sched.Next(time.Now())),
+	}
+	if user, ok := auth.UserFromContext(ctx); ok {
+		status.CreatedBy = user.Name()
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	return s.cronJobOps.Create(ctx, spec, status)
+}
+
+// Replace replaces the specification of an existing cron job
+func (s *scheduler) Replace(
+	ctx context.Context,
+	spec *pbcron.CronJobSpec,
+) error {
+	sched, err := ParseSchedule(spec.GetSchedule())
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	cronJob, err := s.cronJobOps.Get(ctx, spec.GetName())
+	if err != nil {
+		return err
+	}
+
+	if err := s.cronJobOps.UpdateSpec(ctx, spec); err != nil {
+		return err
+	}
+
+	status := cronJob.GetStatus()
+	if status == nil {
+		status = &pbcron.CronJobStatus{}
+	}
+	status.NextRunTime = formatTime(sched.Next(time.Now()))
+	return s.cronJobOps.UpdateStatus(ctx, spec.GetName(), status)
+}
+
+// Trigger starts a run of the cron job immediately
+func (s *scheduler) Trigger(
+	ctx context.Context,
+	name string,
+) (*v1alphapeloton.JobID, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	cronJob, err := s.cronJobOps.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	_, jobID, err := s.process(ctx, cronJob, time.Now().UTC(), true)
+	if err != nil {
+		return nil, err
+	}
+	if jobID == nil {
+		return nil, nil
+	}
+	return &v1alphapeloton.JobID{Value: jobID.GetValue()}, nil
+}
+
+// Delete deletes the cron job
+func (s *scheduler) Delete(ctx context.Context, name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.cronJobOps.Delete(ctx, name)
+}
+
+// process prunes the terminated runs of a cron job, and creates a new
+// run if the cron job is due at the given time or if force is set.
+// The updated status of the cron job is persisted and returned along
+// with the ID of the created run, if any.
+func (s *scheduler) process(
+	ctx context.Context,
+	cronJob *pbcron.CronJobInfo,
+	now time.Time,
+	force bool,
+) (*pbcron.CronJobStatus, *peloton.JobID, error) {
+	spec := cronJob.GetSpec()
+	status := &pbcron.CronJobStatus{}
+	if cronJob.GetStatus() != nil {
+		status = proto.Clone(cronJob.GetStatus()).(*pbcron.CronJobStatus)
+	}
+
+	sched, err := ParseSchedule(spec.GetSchedule())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	activeRuns := s.getActiveRuns(ctx, status.GetActiveRuns())
+	changed := len(activeRuns) != len(status.GetActiveRuns())
+	status.ActiveRuns = activeRuns
+
+	due := force
+	var next time.Time
+	if !force {
+		next, err = time.Parse(time.RFC3339, status.GetNextRunTime())
+		if err == nil {
+			due = !now.Before(next)
+		} else if nextRun := formatTime(
+			sched.Next(now)); nextRun != status.GetNextRunTime() {
+			// the next run has not been computed yet
+			status.NextRunTime = nextRun
+			changed = true
+		}
+	}
+
+	var jobID *peloton.JobID
+	if due {
+		// the ID of a scheduled run is derived from the time it has been
+		// scheduled at, so that the run is not created twice if the
+		// status of the cron job could not be persisted
+		runID := &peloton.JobID{Value: uuid.New()}
+		if !force {
+			runID = newRunJobID(spec.GetName(), next)
+		}
+
+		jobID, err = s.runCronJob(ctx, spec, status, runID, now)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// a forced run does not change the schedule, runs missed
+		// while there was no leader are not caught up
+		if !force {
+			status.NextRunTime = formatTime(sched.Next(now))
+		}
+		changed = true
+	}
+
+	if changed {
+		if err := s.cronJobOps.UpdateStatus(
+			ctx, spec.GetName(), status); err != nil {
+			s.metrics.UpdateFail.Inc(1)
+			return nil, nil, err
+		}
+	}
+	return status, jobID, nil
+}
+
+// runCronJob applies the collision policy of the cron job and creates
+// a new run with the given ID, updating the status in place. It returns
+// nil if the run has been skipped.
+func (s *scheduler) runCronJob(
+	ctx context.Context,
+	spec *pbcron.CronJobSpec,
+	status *pbcron.CronJobStatus,
+	jobID *peloton.JobID,
+	now time.Time,
+) (*peloton.JobID, error) {
+	if len(status.GetActiveRuns()) > 0 {
+		switch spec.GetCollisionPolicy() {
+		case pbcron.CollisionPolicy_COLLISION_POLICY_SKIP:
+			log.WithField("cron_job", spec.GetName()).
+				WithField("active_runs", len(status.GetActiveRuns())).
+				Info("skip cron job run, previous runs are still active")
+			s.metrics.RunsSkipped.Inc(1)
+			status.SkippedCount++
+			return nil, nil
+
+		case pbcron.CollisionPolicy_COLLISION_POLICY_KILL_EXISTING:
+			for _, run := range status.GetActiveRuns() {
+				if err := jobutil.KillBatchJob(
+					ctx,
+					s.jobFactory,
+					s.goalStateDriver,
+					&peloton.JobID{Value: run.GetValue()},
+				); err != nil {
+					s.metrics.KillRunFail.Inc(1)
+					return nil, errors.Wrapf(
+						err, "failed to kill run %s", run.GetValue())
+				}
+				s.metrics.RunsKilled.Inc(1)
+			}
+			status.ActiveRuns = nil
+		}
+	}
+
+	if err := s.createRun(ctx, spec, jobID, now); err != nil {
+		s.metrics.CreateRunFail.Inc(1)
+		return nil, err
+	}
+
+	log.WithField("cron_job", spec.GetName()).
+		WithField("job_id", jobID.GetValue()).
+		Info("created cron job run")
+	s.metrics.RunsCreated.Inc(1)
+
+	status.ActiveRuns = append(
+		status.ActiveRuns, &v1alphapeloton.JobID{Value: jobID.GetValue()})
+	status.RunCount++
+	status.LastRunTime = formatTime(now)
+	return jobID, nil
+}
+
+// getActiveRuns returns the runs which have not terminated yet
+func (s *scheduler) getActiveRuns(
+	ctx context.Context,
+	runs []*v1alphapeloton.JobID,
+) []*v1alphapeloton.JobID {
+	var activeRuns []*v1alphapeloton.JobID
+	for _, run := range runs {
+		// terminated batch jobs are untracked from the cache
+		cachedJob := s.jobFactory.GetJob(&peloton.JobID{Value: run.GetValue()})
+		if cachedJob == nil {
+			continue
+		}
+
+		runtime, err := cachedJob.GetRuntime(ctx)
+		if err != nil {
+			// keep the run, it is checked again on the next evaluation
+			log.WithError(err).
+				WithField("job_id", run.GetValue()).
+				Warn("failed to get runtime of cron job run")
+			activeRuns = append(activeRuns, run)
+			continue
+		}
+
+		if !util.IsPelotonJobStateTerminal(runtime.GetState()) {
+			activeRuns = append(activeRuns, run)
+		}
+	}
+	return activeRuns
+}
+
+// createRun creates a batch job from the template of the cron job. A run
+// which has already been created by a previous evaluation is considered
+// created.
+func (s *scheduler) createRun(
+	ctx context.Context,
+	spec *pbcron.CronJobSpec,
+	jobID *peloton.JobID,
+	now time.Time,
+) error {
+	config, err := handlerutil.ConvertJobSpecToJobConfig(spec.GetTemplate())
+	if err != nil {
+		return err
+	}
+
+	name := spec.GetTemplate().GetName()
+	if len(name) == 0 {
+		name = spec.GetName()
+	}
+	config.Type = pbjob.JobType_BATCH
+	config.Name = fmt.Sprintf("%s-%s", name, now.Format(_runNameTimeFormat))
+	config.Labels = append(config.Labels, &peloton.Label{
+		Key:   CronJobLabelKey,
+		Value: spec.GetName(),
+	})
+
+	respErr, err := jobutil.CreateBatchJob(
+		ctx, s.jobCreator, s.jobFactory, jobID, config)
+	if err != nil {
+		return err
+	}
+	if respErr != nil {
+		return fmt.Errorf("failed to create run: %s", respErr.String())
+	}
+	return nil
+}
+
+// newRunJobID returns the ID of the batch job of the run of a cron job
+// scheduled at the given time
+func newRunJobID(name string, scheduled time.Time) *peloton.JobID {
+	return &peloton.JobID{
+		Value: uuid.NewSHA1(
+			_runNamespace, []byte(name+"@"+formatTime(scheduled))).String(),
+	}
+}
+
+// formatTime formats a time of the status of a cron job, the zero time
+// is formatted as an empty string
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,528 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbcron "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+
+	backgroundmocks "github.com/uber/peloton/pkg/common/background/mocks"
+	cachedmocks "github.com/uber/peloton/pkg/jobmgr/cached/mocks"
+	goalstatemocks "github.com/uber/peloton/pkg/jobmgr/goalstate/mocks"
+	jobutilmocks "github.com/uber/peloton/pkg/jobmgr/util/job/mocks"
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const _testCronJobName = "test-cron"
+
+type SchedulerTestSuite struct {
+	suite.Suite
+	mockCtrl *gomock.Controller
+
+	cronJobOps      *objectmocks.MockCronJobOps
+	jobFactory      *cachedmocks.MockJobFactory
+	cachedJob       *cachedmocks.MockJob
+	goalStateDriver *goalstatemocks.MockDriver
+	jobCreator      *jobutilmocks.MockJobCreator
+	scheduler       *scheduler
+
+	now    time.Time
+	runID  *v1alphapeloton.JobID
+	spec   *pbcron.CronJobSpec
+	status *pbcron.CronJobStatus
+}
+
+func (s *SchedulerTestSuite) SetupTest() {
+	s.mockCtrl = gomock.NewController(s.T())
+
+	s.cronJobOps = objectmocks.NewMockCronJobOps(s.mockCtrl)
+	s.jobFactory = cachedmocks.NewMockJobFactory(s.mockCtrl)
+	s.cachedJob = cachedmocks.NewMockJob(s.mockCtrl)
+	s.goalStateDriver = goalstatemocks.NewMockDriver(s.mockCtrl)
+	s.jobCreator = jobutilmocks.NewMockJobCreator(s.mockCtrl)
+	s.scheduler = NewScheduler(
+		s.cronJobOps,
+		s.jobFactory,
+		s.goalStateDriver,
+		s.jobCreator,
+		tally.NoopScope,
+		&Config{},
+	).(*scheduler)
+
+	s.now = time.Date(2019, 1, 1, 10, 0, 0, 0, time.UTC)
+	s.runID = &v1alphapeloton.JobID{Value: "previous-run"}
+	s.spec = &pbcron.CronJobSpec{
+		Name:            _testCronJobName,
+		Schedule:        "0 * * * *",
+		CollisionPolicy: pbcron.CollisionPolicy_COLLISION_POLICY_SKIP,
+		Template: &stateless.JobSpec{
+			Name:          "nightly",
+			InstanceCount: 3,
+		},
+	}
+	s.status = &pbcron.CronJobStatus{
+		NextRunTime: "2019-01-01T10:00:00Z",
+	}
+}
+
+func (s *SchedulerTestSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestSchedulerTestSuite(t *testing.T) {
+	suite.Run(t, new(SchedulerTestSuite))
+}
+
+func (s *SchedulerTestSuite) cronJob() *pbcron.CronJobInfo {
+	return &pbcron.CronJobInfo{Spec: s.spec, Status: s.status}
+}
+
+// expectActiveRun sets the expectations for a run which is still running
+func (s *SchedulerTestSuite) expectActiveRun() {
+	s.jobFactory.EXPECT().
+		GetJob(&peloton.JobID{Value: s.runID.GetValue()}).
+		Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{State: pbjob.JobState_RUNNING}, nil)
+}
+
+// expectCreateRun sets the expectations for the creation of a new run
+func (s *SchedulerTestSuite) expectCreateRun() {
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *pbjob.CreateRequest) {
+			s.NotEmpty(req.GetId().GetValue())
+			s.Equal(pbjob.JobType_BATCH, req.GetConfig().GetType())
+			s.Equal("nightly-20190101-1000", req.GetConfig().GetName())
+			s.Equal(uint32(3), req.GetConfig().GetInstanceCount())
+			s.Equal([]*peloton.Label{{
+				Key:   CronJobLabelKey,
+				Value: _testCronJobName,
+			}}, req.GetConfig().GetLabels())
+		}).
+		Return(&pbjob.CreateResponse{}, nil)
+}
+
+// TestRegister tests registering the scheduler in the background manager
+func (s *SchedulerTestSuite) TestRegister() {
+	manager := backgroundmocks.NewMockManager(s.mockCtrl)
+	manager.EXPECT().RegisterWorks(gomock.Any()).Return(nil)
+	s.NoError(s.scheduler.Register(manager))
+	s.Equal(_defaultSchedulePeriod, s.scheduler.config.SchedulePeriod)
+}
+
+// TestScheduleGetAllFail tests failing to read the cron jobs
+func (s *SchedulerTestSuite) TestScheduleGetAllFail() {
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return(nil, errors.New("test error"))
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleNotDue tests that nothing happens before the next run
+func (s *SchedulerTestSuite) TestScheduleNotDue() {
+	s.status.NextRunTime = "2019-01-01T11:00:00Z"
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleComputeNextRun tests that the next run is computed if
+// it is not set yet
+func (s *SchedulerTestSuite) TestScheduleComputeNextRun() {
+	s.status.NextRunTime = ""
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			s.Equal("2019-01-01T11:00:00Z", status.GetNextRunTime())
+			s.Equal(uint32(0), status.GetRunCount())
+		}).
+		Return(nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleDue tests creating a run when the cron job is due
+func (s *SchedulerTestSuite) TestScheduleDue() {
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.expectCreateRun()
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			s.Equal("2019-01-01T10:00:00Z", status.GetLastRunTime())
+			s.Equal("2019-01-01T11:00:00Z", status.GetNextRunTime())
+			s.Equal(uint32(1), status.GetRunCount())
+			s.Len(status.GetActiveRuns(), 1)
+		}).
+		Return(nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleMissedRuns tests that runs missed while there was no
+// leader are not caught up
+func (s *SchedulerTestSuite) TestScheduleMissedRuns() {
+	s.status.NextRunTime = "2019-01-01T05:00:00Z"
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.expectCreateRun()
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			s.Equal("2019-01-01T11:00:00Z", status.GetNextRunTime())
+			s.Equal(uint32(1), status.GetRunCount())
+		}).
+		Return(nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleUpdateStatusFail tests that a run is not created again if
+// the status of the cron job could not be persisted after creating it
+func (s *SchedulerTestSuite) TestScheduleUpdateStatusFail() {
+	runID := newRunJobID(_testCronJobName, s.now)
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil).
+		Times(2)
+
+	gomock.InOrder(
+		s.jobCreator.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, req *pbjob.CreateRequest) {
+				s.Equal(runID, req.GetId())
+			}).
+			Return(&pbjob.CreateResponse{}, nil),
+		s.cronJobOps.EXPECT().
+			UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+			Return(errors.New("update failed")),
+		s.jobCreator.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, req *pbjob.CreateRequest) {
+				s.Equal(runID, req.GetId())
+			}).
+			Return(nil, yarpcerrors.AlreadyExistsErrorf("job already exists")),
+		s.cronJobOps.EXPECT().
+			UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+			Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+				s.Equal(uint32(1), status.GetRunCount())
+				s.Equal([]*v1alphapeloton.JobID{{Value: runID.GetValue()}},
+					status.GetActiveRuns())
+			}).
+			Return(nil),
+	)
+
+	s.scheduler.schedule(context.Background(), s.now)
+	s.scheduler.schedule(context.Background(), s.now.Add(time.Minute))
+}
+
+// TestScheduleRunAlreadyCreated tests that a run reported as already
+// existing by the job service is considered created if it can be read
+func (s *SchedulerTestSuite) TestScheduleRunAlreadyCreated() {
+	runID := newRunJobID(_testCronJobName, s.now)
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&pbjob.CreateResponse{
+			Error: &pbjob.CreateResponse_Error{
+				AlreadyExists: &pbjob.JobAlreadyExists{Message: "exists"},
+			},
+		}, nil)
+	s.jobFactory.EXPECT().AddJob(runID).Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{State: pbjob.JobState_PENDING}, nil)
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			s.Equal(uint32(1), status.GetRunCount())
+			s.Len(status.GetActiveRuns(), 1)
+		}).
+		Return(nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleRunNotCreated tests that the status is not updated if the
+// job service reports a run as already existing but it cannot be read
+func (s *SchedulerTestSuite) TestScheduleRunNotCreated() {
+	runID := newRunJobID(_testCronJobName, s.now)
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&pbjob.CreateResponse{
+			Error: &pbjob.CreateResponse_Error{
+				AlreadyExists: &pbjob.JobAlreadyExists{Message: "write failed"},
+			},
+		}, nil)
+	s.jobFactory.EXPECT().AddJob(runID).Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(nil, errors.New("not found"))
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleCreateRunFail tests that the status is not updated if the
+// run fails to be created
+func (s *SchedulerTestSuite) TestScheduleCreateRunFail() {
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&pbjob.CreateResponse{
+			Error: &pbjob.CreateResponse_Error{
+				InvalidConfig: &pbjob.InvalidJobConfig{Message: "invalid"},
+			},
+		}, nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestSchedulePruneTerminatedRuns tests that terminated runs are
+// removed from the active runs
+func (s *SchedulerTestSuite) TestSchedulePruneTerminatedRuns() {
+	otherRunID := &v1alphapeloton.JobID{Value: "other-run"}
+	s.status.NextRunTime = "2019-01-01T11:00:00Z"
+	s.status.ActiveRuns = []*v1alphapeloton.JobID{s.runID, otherRunID}
+
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.jobFactory.EXPECT().
+		GetJob(&peloton.JobID{Value: s.runID.GetValue()}).
+		Return(nil)
+	s.jobFactory.EXPECT().
+		GetJob(&peloton.JobID{Value: otherRunID.GetValue()}).
+		Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{State: pbjob.JobState_SUCCEEDED}, nil)
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			s.Empty(status.GetActiveRuns())
+		}).
+		Return(nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleSkip tests the skip collision policy
+func (s *SchedulerTestSuite) TestScheduleSkip() {
+	s.status.ActiveRuns = []*v1alphapeloton.JobID{s.runID}
+
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.expectActiveRun()
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			s.Equal(uint32(1), status.GetSkippedCount())
+			s.Equal(uint32(0), status.GetRunCount())
+			s.Equal([]*v1alphapeloton.JobID{s.runID}, status.GetActiveRuns())
+			s.Equal("2019-01-01T11:00:00Z", status.GetNextRunTime())
+		}).
+		Return(nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleKillExisting tests the kill existing collision policy
+func (s *SchedulerTestSuite) TestScheduleKillExisting() {
+	s.spec.CollisionPolicy = pbcron.CollisionPolicy_COLLISION_POLICY_KILL_EXISTING
+	s.status.ActiveRuns = []*v1alphapeloton.JobID{s.runID}
+	runID := &peloton.JobID{Value: s.runID.GetValue()}
+
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.expectActiveRun()
+	s.jobFactory.EXPECT().AddJob(runID).Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{
+			State:     pbjob.JobState_RUNNING,
+			GoalState: pbjob.JobState_SUCCEEDED,
+		}, nil)
+	s.cachedJob.EXPECT().
+		CompareAndSetRuntime(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, runtime *pbjob.RuntimeInfo) {
+			s.Equal(pbjob.JobState_KILLED, runtime.GetGoalState())
+			s.Equal(uint64(1), runtime.GetDesiredStateVersion())
+		}).
+		Return(nil, nil)
+	s.goalStateDriver.EXPECT().EnqueueJob(runID, gomock.Any())
+	s.expectCreateRun()
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			s.Equal(uint32(1), status.GetRunCount())
+			s.Len(status.GetActiveRuns(), 1)
+			s.NotEqual(s.runID.GetValue(), status.GetActiveRuns()[0].GetValue())
+		}).
+		Return(nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleKillExistingFail tests that no run is created if the
+// active runs fail to be killed
+func (s *SchedulerTestSuite) TestScheduleKillExistingFail() {
+	s.spec.CollisionPolicy = pbcron.CollisionPolicy_COLLISION_POLICY_KILL_EXISTING
+	s.status.ActiveRuns = []*v1alphapeloton.JobID{s.runID}
+
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.expectActiveRun()
+	s.jobFactory.EXPECT().
+		AddJob(&peloton.JobID{Value: s.runID.GetValue()}).
+		Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(nil, errors.New("test error"))
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestScheduleRunOverlap tests the run overlap collision policy
+func (s *SchedulerTestSuite) TestScheduleRunOverlap() {
+	s.spec.CollisionPolicy = pbcron.CollisionPolicy_COLLISION_POLICY_RUN_OVERLAP
+	s.status.ActiveRuns = []*v1alphapeloton.JobID{s.runID}
+
+	s.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return([]*pbcron.CronJobInfo{s.cronJob()}, nil)
+	s.expectActiveRun()
+	s.expectCreateRun()
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			s.Len(status.GetActiveRuns(), 2)
+			s.Equal(s.runID, status.GetActiveRuns()[0])
+		}).
+		Return(nil)
+	s.scheduler.schedule(context.Background(), s.now)
+}
+
+// TestCreate tests creating a cron job with its first run computed
+func (s *SchedulerTestSuite) TestCreate() {
+	s.cronJobOps.EXPECT().
+		Create(gomock.Any(), s.spec, gomock.Any()).
+		Do(func(
+			_ context.Context,
+			_ *pbcron.CronJobSpec,
+			status *pbcron.CronJobStatus) {
+			s.NotEmpty(status.GetNextRunTime())
+		}).
+		Return(nil)
+	s.NoError(s.scheduler.Create(context.Background(), s.spec))
+}
+
+// TestCreateInvalidSchedule tests creating a cron job with an invalid
+// schedule
+func (s *SchedulerTestSuite) TestCreateInvalidSchedule() {
+	s.spec.Schedule = "invalid"
+	s.Error(s.scheduler.Create(context.Background(), s.spec))
+}
+
+// TestReplace tests replacing the spec of a cron job
+func (s *SchedulerTestSuite) TestReplace() {
+	s.status.RunCount = 4
+	s.cronJobOps.EXPECT().
+		Get(gomock.Any(), _testCronJobName).
+		Return(s.cronJob(), nil)
+	s.cronJobOps.EXPECT().UpdateSpec(gomock.Any(), s.spec).Return(nil)
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			s.Equal(uint32(4), status.GetRunCount())
+			s.NotEqual("2019-01-01T10:00:00Z", status.GetNextRunTime())
+		}).
+		Return(nil)
+	s.NoError(s.scheduler.Replace(context.Background(), s.spec))
+}
+
+// TestReplaceNotFound tests replacing a cron job which does not exist
+func (s *SchedulerTestSuite) TestReplaceNotFound() {
+	s.cronJobOps.EXPECT().
+		Get(gomock.Any(), _testCronJobName).
+		Return(nil, errors.New("not found"))
+	s.Error(s.scheduler.Replace(context.Background(), s.spec))
+}
+
+// TestTrigger tests starting a run irrespective of the schedule
+func (s *SchedulerTestSuite) TestTrigger() {
+	s.status.NextRunTime = "2019-01-01T11:00:00Z"
+	s.cronJobOps.EXPECT().
+		Get(gomock.Any(), _testCronJobName).
+		Return(s.cronJob(), nil)
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&pbjob.CreateResponse{}, nil)
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Do(func(_ context.Context, _ string, status *pbcron.CronJobStatus) {
+			// the schedule is not affected by a forced run
+			s.Equal("2019-01-01T11:00:00Z", status.GetNextRunTime())
+			s.Equal(uint32(1), status.GetRunCount())
+		}).
+		Return(nil)
+
+	jobID, err := s.scheduler.Trigger(context.Background(), _testCronJobName)
+	s.NoError(err)
+	s.NotNil(jobID)
+}
+
+// TestTriggerSkipped tests starting a run which is skipped due to the
+// collision policy
+func (s *SchedulerTestSuite) TestTriggerSkipped() {
+	s.status.ActiveRuns = []*v1alphapeloton.JobID{s.runID}
+	s.cronJobOps.EXPECT().
+		Get(gomock.Any(), _testCronJobName).
+		Return(s.cronJob(), nil)
+	s.expectActiveRun()
+	s.cronJobOps.EXPECT().
+		UpdateStatus(gomock.Any(), _testCronJobName, gomock.Any()).
+		Return(nil)
+
+	jobID, err := s.scheduler.Trigger(context.Background(), _testCronJobName)
+	s.NoError(err)
+	s.Nil(jobID)
+}
+
+// TestDelete tests deleting a cron job
+func (s *SchedulerTestSuite) TestDelete() {
+	s.cronJobOps.EXPECT().Delete(gomock.Any(), _testCronJobName).Return(nil)
+	s.NoError(s.scheduler.Delete(context.Background(), _testCronJobName))
+}
@@ -0,0 +1,345 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronsvc
+
+import (
+	"context"
+
+	v0peloton "github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	pbcron "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc"
+
+	"github.com/uber/peloton/pkg/auth"
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/leader"
+	yarpcutil "github.com/uber/peloton/pkg/common/util/yarpc"
+	"github.com/uber/peloton/pkg/jobmgr/cron"
+	handlerutil "github.com/uber/peloton/pkg/jobmgr/util/handler"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	"github.com/gocql/gocql"
+	log "github.com/sirupsen/logrus"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	errNullSpec           = yarpcerrors.InvalidArgumentErrorf("cron job spec is null")
+	errEmptyName          = yarpcerrors.InvalidArgumentErrorf("cron job name is empty")
+	errInvalidPolicy      = yarpcerrors.InvalidArgumentErrorf("cron job collision policy is invalid")
+	errNullTemplate       = yarpcerrors.InvalidArgumentErrorf("cron job template is null")
+	errNullResourcePoolID = yarpcerrors.InvalidArgumentErrorf("cron job template resource pool ID is null")
+	errZeroInstanceCount  = yarpcerrors.InvalidArgumentErrorf("cron job template instance count is zero")
+	errNullDefaultSpec    = yarpcerrors.InvalidArgumentErrorf("cron job template default spec is null")
+)
+
+type serviceHandler struct {
+	cronJobOps    ormobjects.CronJobOps
+	scheduler     cron.Scheduler
+	candidate     leader.Candidate
+	respoolClient respool.ResourceManagerYARPCClient
+}
+
+// InitV1AlphaCronJobServiceHandler initializes the Cron Job Service Handler
+func InitV1AlphaCronJobServiceHandler(
+	d *yarpc.Dispatcher,
+	ormStore *ormobjects.Store,
+	scheduler cron.Scheduler,
+	candidate leader.Candidate,
+) {
+	handler := &serviceHandler{
+		cronJobOps: ormobjects.NewCronJobOps(ormStore),
+		scheduler:  scheduler,
+		candidate:  candidate,
+		respoolClient: respool.NewResourceManagerYARPCClient(
+			d.ClientConfig(common.PelotonResourceManager)),
+	}
+	d.Register(svc.BuildCronJobServiceYARPCProcedures(handler))
+}
+
+// CreateCronJob creates a new cron job
+func (h *serviceHandler) CreateCronJob(
+	ctx context.Context,
+	req *svc.CreateCronJobRequest,
+) (resp *svc.CreateCronJobResponse, err error) {
+	defer func() {
+		h.logResult(ctx, "CreateCronJob", req, err)
+		if err != nil {
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	if !h.candidate.IsLeader() {
+		return nil, yarpcerrors.UnavailableErrorf(
+			"CronJobSVC.%s is not supported on non-leader", "CreateCronJob")
+	}
+
+	if err := validateSpec(req.GetSpec()); err != nil {
+		return nil, err
+	}
+
+	if err := h.authorizeSpec(ctx, req.GetSpec()); err != nil {
+		return nil, err
+	}
+
+	if err := h.scheduler.Create(ctx, req.GetSpec()); err != nil {
+		return nil, err
+	}
+	return &svc.CreateCronJobResponse{}, nil
+}
+
+// ReplaceCronJob replaces the spec of an existing cron job
+func (h *serviceHandler) ReplaceCronJob(
+	ctx context.Context,
+	req *svc.ReplaceCronJobRequest,
+) (resp *svc.ReplaceCronJobResponse, err error) {
+	defer func() {
+		h.logResult(ctx, "ReplaceCronJob", req, err)
+		if err != nil {
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	if !h.candidate.IsLeader() {
+		return nil, yarpcerrors.UnavailableErrorf(
+			"CronJobSVC.%s is not supported on non-leader", "ReplaceCronJob")
+	}
+
+	if err := validateSpec(req.GetSpec()); err != nil {
+		return nil, err
+	}
+
+	// the caller must be permitted on the cron job before and after
+	// the replace
+	if err := h.authorizeCronJob(ctx, req.GetSpec().GetName()); err != nil {
+		return nil, err
+	}
+	if err := h.authorizeSpec(ctx, req.GetSpec()); err != nil {
+		return nil, err
+	}
+
+	if err := h.scheduler.Replace(ctx, req.GetSpec()); err != nil {
+		return nil, convertNotFound(err, req.GetSpec().GetName())
+	}
+	return &svc.ReplaceCronJobResponse{}, nil
+}
+
+// GetCronJob returns the spec and the status of a cron job
+func (h *serviceHandler) GetCronJob(
+	ctx context.Context,
+	req *svc.GetCronJobRequest,
+) (resp *svc.GetCronJobResponse, err error) {
+	defer func() {
+		if err != nil {
+			h.logResult(ctx, "GetCronJob", req, err)
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	cronJob, err := h.cronJobOps.Get(ctx, req.GetName())
+	if err != nil {
+		return nil, convertNotFound(err, req.GetName())
+	}
+	return &svc.GetCronJobResponse{CronJob: cronJob}, nil
+}
+
+// ListCronJobs returns the spec and the status of all the cron jobs
+func (h *serviceHandler) ListCronJobs(
+	ctx context.Context,
+	req *svc.ListCronJobsRequest,
+) (resp *svc.ListCronJobsResponse, err error) {
+	defer func() {
+		if err != nil {
+			h.logResult(ctx, "ListCronJobs", req, err)
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	cronJobs, err := h.cronJobOps.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &svc.ListCronJobsResponse{CronJobs: cronJobs}, nil
+}
+
+// DeleteCronJob deletes a cron job, the runs it created are not affected
+func (h *serviceHandler) DeleteCronJob(
+	ctx context.Context,
+	req *svc.DeleteCronJobRequest,
+) (resp *svc.DeleteCronJobResponse, err error) {
+	defer func() {
+		h.logResult(ctx, "DeleteCronJob", req, err)
+		if err != nil {
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	if !h.candidate.IsLeader() {
+		return nil, yarpcerrors.UnavailableErrorf(
+			"CronJobSVC.%s is not supported on non-leader", "DeleteCronJob")
+	}
+
+	cronJob, err := h.cronJobOps.Get(ctx, req.GetName())
+	if err != nil {
+		return nil, convertNotFound(err, req.GetName())
+	}
+
+	if err := h.authorizeSpec(ctx, cronJob.GetSpec()); err != nil {
+		return nil, err
+	}
+
+	if err := h.scheduler.Delete(ctx, req.GetName()); err != nil {
+		return nil, err
+	}
+	return &svc.DeleteCronJobResponse{}, nil
+}
+
+// StartCronJob starts a run of a cron job immediately
+func (h *serviceHandler) StartCronJob(
+	ctx context.Context,
+	req *svc.StartCronJobRequest,
+) (resp *svc.StartCronJobResponse, err error) {
+	defer func() {
+		h.logResult(ctx, "StartCronJob", req, err)
+		if err != nil {
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	if !h.candidate.IsLeader() {
+		return nil, yarpcerrors.UnavailableErrorf(
+			"CronJobSVC.%s is not supported on non-leader", "StartCronJob")
+	}
+
+	if err := h.authorizeCronJob(ctx, req.GetName()); err != nil {
+		return nil, err
+	}
+
+	jobID, err := h.scheduler.Trigger(ctx, req.GetName())
+	if err != nil {
+		return nil, convertNotFound(err, req.GetName())
+	}
+
+	if jobID == nil {
+		log.WithField("cron_job", req.GetName()).
+			Info("run skipped due to the collision policy of the cron job")
+	}
+	return &svc.StartCronJobResponse{JobId: jobID}, nil
+}
+
+// authorizeSpec checks whether the caller is permitted to call the
+// procedure of the request on the cron job with the spec, i.e. to create
+// batch jobs in the resource pool of its template
+func (h *serviceHandler) authorizeSpec(
+	ctx context.Context,
+	spec *pbcron.CronJobSpec,
+) error {
+	return handlerutil.AuthorizeJobCreation(
+		ctx,
+		h.respoolClient,
+		&v0peloton.ResourcePoolID{
+			Value: spec.GetTemplate().GetRespoolId().GetValue(),
+		},
+	)
+}
+
+// authorizeCronJob checks whether the caller is permitted to call the
+// procedure of the request on the existing cron job. The cron job is only
+// read for requests which went through auth.
+func (h *serviceHandler) authorizeCronJob(
+	ctx context.Context,
+	name string,
+) error {
+	if _, ok := auth.UserFromContext(ctx); !ok {
+		return nil
+	}
+
+	cronJob, err := h.cronJobOps.Get(ctx, name)
+	if err != nil {
+		return convertNotFound(err, name)
+	}
+	return h.authorizeSpec(ctx, cronJob.GetSpec())
+}
+
+// logResult logs the result of a call to the service
+func (h *serviceHandler) logResult(
+	ctx context.Context,
+	procedure string,
+	req interface{},
+	err error,
+) {
+	entry := log.WithField("request", req).
+		WithField("headers", yarpcutil.GetHeaders(ctx))
+
+	if err != nil {
+		entry.WithError(err).Warnf("CronJobSVC.%s failed", procedure)
+		return
+	}
+	entry.Infof("CronJobSVC.%s succeeded", procedure)
+}
+
+// validateSpec validates the spec of a cron job
+func validateSpec(spec *pbcron.CronJobSpec) error {
+	if spec == nil {
+		return errNullSpec
+	}
+
+	if len(spec.GetName()) == 0 {
+		return errEmptyName
+	}
+
+	if _, err := cron.ParseSchedule(spec.GetSchedule()); err != nil {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"invalid cron job schedule: %v", err)
+	}
+
+	if spec.GetCollisionPolicy() ==
+		pbcron.CollisionPolicy_COLLISION_POLICY_INVALID {
+		return errInvalidPolicy
+	}
+
+	template := spec.GetTemplate()
+	if template == nil {
+		return errNullTemplate
+	}
+
+	if template.GetRespoolId() == nil {
+		return errNullResourcePoolID
+	}
+
+	if template.GetInstanceCount() == 0 {
+		return errZeroInstanceCount
+	}
+
+	if template.GetDefaultSpec() == nil {
+		return errNullDefaultSpec
+	}
+
+	if _, err := handlerutil.ConvertJobSpecToJobConfig(template); err != nil {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"invalid cron job template: %v", err)
+	}
+	return nil
+}
+
+// convertNotFound converts the not found error of the storage into
+// a yarpc not found error
+func convertNotFound(err error, name string) error {
+	if err == gocql.ErrNotFound {
+		return yarpcerrors.NotFoundErrorf("cron job %s not found", name)
+	}
+	return err
+}
@@ -0,0 +1,366 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronsvc
+
+import (
+	"context"
+	"testing"
+
+	v0peloton "github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	respoolmocks "github.com/uber/peloton/.gen/peloton/api/v0/respool/mocks"
+	pbcron "github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron/svc"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
+
+	"github.com/uber/peloton/pkg/auth"
+	authmocks "github.com/uber/peloton/pkg/auth/mocks"
+	leadermocks "github.com/uber/peloton/pkg/common/leader/mocks"
+	cronmocks "github.com/uber/peloton/pkg/jobmgr/cron/mocks"
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
+
+	"github.com/gocql/gocql"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const _testCronJobName = "test-cron"
+
+type cronHandlerTestSuite struct {
+	suite.Suite
+
+	ctrl          *gomock.Controller
+	cronJobOps    *objectmocks.MockCronJobOps
+	scheduler     *cronmocks.MockScheduler
+	candidate     *leadermocks.MockCandidate
+	respoolClient *respoolmocks.MockResourceManagerYARPCClient
+	handler       *serviceHandler
+
+	spec *pbcron.CronJobSpec
+}
+
+func (suite *cronHandlerTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.cronJobOps = objectmocks.NewMockCronJobOps(suite.ctrl)
+	suite.scheduler = cronmocks.NewMockScheduler(suite.ctrl)
+	suite.candidate = leadermocks.NewMockCandidate(suite.ctrl)
+	suite.respoolClient = respoolmocks.NewMockResourceManagerYARPCClient(suite.ctrl)
+	suite.handler = &serviceHandler{
+		cronJobOps:    suite.cronJobOps,
+		scheduler:     suite.scheduler,
+		candidate:     suite.candidate,
+		respoolClient: suite.respoolClient,
+	}
+
+	suite.spec = &pbcron.CronJobSpec{
+		Name:            _testCronJobName,
+		Schedule:        "*/10 * * * *",
+		CollisionPolicy: pbcron.CollisionPolicy_COLLISION_POLICY_KILL_EXISTING,
+		Template: &stateless.JobSpec{
+			Name:          "report",
+			InstanceCount: 1,
+			RespoolId:     &v1alphapeloton.ResourcePoolID{Value: "respool"},
+			DefaultSpec: &pod.PodSpec{
+				Containers: []*pod.ContainerSpec{{Name: "report"}},
+			},
+		},
+	}
+}
+
+func (suite *cronHandlerTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestCronHandler(t *testing.T) {
+	suite.Run(t, new(cronHandlerTestSuite))
+}
+
+// TestCreateCronJob tests creating a cron job
+func (suite *cronHandlerTestSuite) TestCreateCronJob() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.scheduler.EXPECT().Create(gomock.Any(), suite.spec).Return(nil)
+
+	resp, err := suite.handler.CreateCronJob(
+		context.Background(),
+		&svc.CreateCronJobRequest{Spec: suite.spec})
+	suite.NoError(err)
+	suite.NotNil(resp)
+}
+
+// TestCreateCronJobPermissionDenied tests that a cron job can't be created
+// by a caller not permitted on the resource pool of its template
+func (suite *cronHandlerTestSuite) TestCreateCronJobPermissionDenied() {
+	user := authmocks.NewMockUser(suite.ctrl)
+	ctx := auth.ContextWithUser(context.Background(), user)
+
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.respoolClient.EXPECT().
+		GetResourcePool(gomock.Any(), &respool.GetRequest{
+			Id: &v0peloton.ResourcePoolID{Value: "respool"},
+		}).
+		Return(&respool.GetResponse{
+			Poolinfo: &respool.ResourcePoolInfo{
+				Path:   &respool.ResourcePoolPath{Value: "/reports"},
+				Config: &respool.ResourcePoolConfig{OwningTeam: "reports"},
+			},
+		}, nil)
+	user.EXPECT().
+		IsPermittedOnResource(gomock.Any(), &auth.Resource{
+			RespoolPath: "/reports",
+			OwningTeam:  "reports",
+		}).
+		Return(false)
+
+	_, err := suite.handler.CreateCronJob(
+		ctx,
+		&svc.CreateCronJobRequest{Spec: suite.spec})
+	suite.True(yarpcerrors.IsPermissionDenied(err))
+}
+
+// TestCreateCronJobNonLeader tests creating a cron job on a non-leader
+func (suite *cronHandlerTestSuite) TestCreateCronJobNonLeader() {
+	suite.candidate.EXPECT().IsLeader().Return(false)
+
+	resp, err := suite.handler.CreateCronJob(
+		context.Background(),
+		&svc.CreateCronJobRequest{Spec: suite.spec})
+	suite.Nil(resp)
+	suite.True(yarpcerrors.IsUnavailable(err))
+}
+
+// TestCreateCronJobAlreadyExists tests creating a cron job which
+// already exists
+func (suite *cronHandlerTestSuite) TestCreateCronJobAlreadyExists() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.scheduler.EXPECT().
+		Create(gomock.Any(), suite.spec).
+		Return(yarpcerrors.AlreadyExistsErrorf("already exists"))
+
+	_, err := suite.handler.CreateCronJob(
+		context.Background(),
+		&svc.CreateCronJobRequest{Spec: suite.spec})
+	suite.True(yarpcerrors.IsAlreadyExists(err))
+}
+
+// TestCreateCronJobInvalidSpec tests creating cron jobs with invalid specs
+func (suite *cronHandlerTestSuite) TestCreateCronJobInvalidSpec() {
+	tt := []func(spec *pbcron.CronJobSpec){
+		func(spec *pbcron.CronJobSpec) { spec.Name = "" },
+		func(spec *pbcron.CronJobSpec) { spec.Schedule = "* * *" },
+		func(spec *pbcron.CronJobSpec) {
+			spec.CollisionPolicy = pbcron.CollisionPolicy_COLLISION_POLICY_INVALID
+		},
+		func(spec *pbcron.CronJobSpec) { spec.Template = nil },
+		func(spec *pbcron.CronJobSpec) { spec.Template.RespoolId = nil },
+		func(spec *pbcron.CronJobSpec) { spec.Template.InstanceCount = 0 },
+		func(spec *pbcron.CronJobSpec) { spec.Template.DefaultSpec = nil },
+		func(spec *pbcron.CronJobSpec) {
+			spec.Template.DefaultSpec.InitContainers = []*pod.ContainerSpec{{}}
+		},
+	}
+
+	for _, mutate := range tt {
+		spec := proto.Clone(suite.spec).(*pbcron.CronJobSpec)
+		mutate(spec)
+
+		suite.candidate.EXPECT().IsLeader().Return(true)
+		_, err := suite.handler.CreateCronJob(
+			context.Background(),
+			&svc.CreateCronJobRequest{Spec: spec})
+		suite.True(yarpcerrors.IsInvalidArgument(err), spec.String())
+	}
+
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	_, err := suite.handler.CreateCronJob(
+		context.Background(),
+		&svc.CreateCronJobRequest{})
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestReplaceCronJob tests replacing the spec of a cron job
+func (suite *cronHandlerTestSuite) TestReplaceCronJob() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.scheduler.EXPECT().Replace(gomock.Any(), suite.spec).Return(nil)
+
+	resp, err := suite.handler.ReplaceCronJob(
+		context.Background(),
+		&svc.ReplaceCronJobRequest{Spec: suite.spec})
+	suite.NoError(err)
+	suite.NotNil(resp)
+}
+
+// TestReplaceCronJobNotFound tests replacing a cron job which does not
+// exist
+func (suite *cronHandlerTestSuite) TestReplaceCronJobNotFound() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.scheduler.EXPECT().
+		Replace(gomock.Any(), suite.spec).
+		Return(gocql.ErrNotFound)
+
+	_, err := suite.handler.ReplaceCronJob(
+		context.Background(),
+		&svc.ReplaceCronJobRequest{Spec: suite.spec})
+	suite.True(yarpcerrors.IsNotFound(err))
+}
+
+// TestGetCronJob tests getting a cron job
+func (suite *cronHandlerTestSuite) TestGetCronJob() {
+	cronJob := &pbcron.CronJobInfo{
+		Spec:   suite.spec,
+		Status: &pbcron.CronJobStatus{RunCount: 2},
+	}
+	suite.cronJobOps.EXPECT().
+		Get(gomock.Any(), _testCronJobName).
+		Return(cronJob, nil)
+
+	resp, err := suite.handler.GetCronJob(
+		context.Background(),
+		&svc.GetCronJobRequest{Name: _testCronJobName})
+	suite.NoError(err)
+	suite.Equal(cronJob, resp.GetCronJob())
+}
+
+// TestGetCronJobNotFound tests getting a cron job which does not exist
+func (suite *cronHandlerTestSuite) TestGetCronJobNotFound() {
+	suite.cronJobOps.EXPECT().
+		Get(gomock.Any(), _testCronJobName).
+		Return(nil, gocql.ErrNotFound)
+
+	_, err := suite.handler.GetCronJob(
+		context.Background(),
+		&svc.GetCronJobRequest{Name: _testCronJobName})
+	suite.True(yarpcerrors.IsNotFound(err))
+}
+
+// TestListCronJobs tests listing all the cron jobs
+func (suite *cronHandlerTestSuite) TestListCronJobs() {
+	cronJobs := []*pbcron.CronJobInfo{{Spec: suite.spec}}
+	suite.cronJobOps.EXPECT().GetAll(gomock.Any()).Return(cronJobs, nil)
+
+	resp, err := suite.handler.ListCronJobs(
+		context.Background(),
+		&svc.ListCronJobsRequest{})
+	suite.NoError(err)
+	suite.Equal(cronJobs, resp.GetCronJobs())
+
+	suite.cronJobOps.EXPECT().
+		GetAll(gomock.Any()).
+		Return(nil, errors.New("test error"))
+	_, err = suite.handler.ListCronJobs(
+		context.Background(),
+		&svc.ListCronJobsRequest{})
+	suite.True(yarpcerrors.IsInternal(err))
+}
+
+// TestDeleteCronJob tests deleting a cron job
+func (suite *cronHandlerTestSuite) TestDeleteCronJob() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.cronJobOps.EXPECT().
+		Get(gomock.Any(), _testCronJobName).
+		Return(&pbcron.CronJobInfo{Spec: suite.spec}, nil)
+	suite.scheduler.EXPECT().Delete(gomock.Any(), _testCronJobName).Return(nil)
+
+	resp, err := suite.handler.DeleteCronJob(
+		context.Background(),
+		&svc.DeleteCronJobRequest{Name: _testCronJobName})
+	suite.NoError(err)
+	suite.NotNil(resp)
+}
+
+// TestDeleteCronJobNotFound tests deleting a cron job which does not
+// exist
+func (suite *cronHandlerTestSuite) TestDeleteCronJobNotFound() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.cronJobOps.EXPECT().
+		Get(gomock.Any(), _testCronJobName).
+		Return(nil, gocql.ErrNotFound)
+
+	_, err := suite.handler.DeleteCronJob(
+		context.Background(),
+		&svc.DeleteCronJobRequest{Name: _testCronJobName})
+	suite.True(yarpcerrors.IsNotFound(err))
+}
+
+// TestStartCronJob tests starting a run of a cron job
+func (suite *cronHandlerTestSuite) TestStartCronJob() {
+	jobID := &v1alphapeloton.JobID{Value: "run"}
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.scheduler.EXPECT().
+		Trigger(gomock.Any(), _testCronJobName).
+		Return(jobID, nil)
+
+	resp, err := suite.handler.StartCronJob(
+		context.Background(),
+		&svc.StartCronJobRequest{Name: _testCronJobName})
+	suite.NoError(err)
+	suite.Equal(jobID, resp.GetJobId())
+}
+
+// TestStartCronJobPermissionDenied tests that a run of a cron job can't
+// be started by a caller not permitted on the resource pool of its template
+func (suite *cronHandlerTestSuite) TestStartCronJobPermissionDenied() {
+	user := authmocks.NewMockUser(suite.ctrl)
+	ctx := auth.ContextWithUser(context.Background(), user)
+
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.cronJobOps.EXPECT().
+		Get(gomock.Any(), _testCronJobName).
+		Return(&pbcron.CronJobInfo{Spec: suite.spec}, nil)
+	suite.respoolClient.EXPECT().
+		GetResourcePool(gomock.Any(), gomock.Any()).
+		Return(&respool.GetResponse{
+			Poolinfo: &respool.ResourcePoolInfo{
+				Path: &respool.ResourcePoolPath{Value: "/reports"},
+			},
+		}, nil)
+	user.EXPECT().
+		IsPermittedOnResource(gomock.Any(), gomock.Any()).
+		Return(false)
+
+	_, err := suite.handler.StartCronJob(
+		ctx,
+		&svc.StartCronJobRequest{Name: _testCronJobName})
+	suite.True(yarpcerrors.IsPermissionDenied(err))
+}
+
+// TestStartCronJobSkipped tests starting a run of a cron job which is
+// skipped due to its collision policy
+func (suite *cronHandlerTestSuite) TestStartCronJobSkipped() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.scheduler.EXPECT().
+		Trigger(gomock.Any(), _testCronJobName).
+		Return(nil, nil)
+
+	resp, err := suite.handler.StartCronJob(
+		context.Background(),
+		&svc.StartCronJobRequest{Name: _testCronJobName})
+	suite.NoError(err)
+	suite.Nil(resp.GetJobId())
+}
+
+// TestStartCronJobNonLeader tests starting a run on a non-leader
+func (suite *cronHandlerTestSuite) TestStartCronJobNonLeader() {
+	suite.candidate.EXPECT().IsLeader().Return(false)
+
+	_, err := suite.handler.StartCronJob(
+		context.Background(),
+		&svc.StartCronJobRequest{Name: _testCronJobName})
+	suite.True(yarpcerrors.IsUnavailable(err))
+}
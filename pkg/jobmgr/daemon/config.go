@@ -0,0 +1,42 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import "time"
+
+const (
+	_defaultReconcilePeriod  = 1 * time.Minute
+	_defaultReconcileTimeout = 30 * time.Second
+)
+
+// Config for the daemon job reconciler
+type Config struct {
+	// Period at which the instances of daemon jobs are reconciled with
+	// the hosts of the cluster
+	ReconcilePeriod time.Duration `yaml:"reconcile_period"`
+
+	// Timeout of a single reconciliation of all daemon jobs
+	ReconcileTimeout time.Duration `yaml:"reconcile_timeout"`
+}
+
+func (c *Config) normalize() {
+	if c.ReconcilePeriod == time.Duration(0) {
+		c.ReconcilePeriod = _defaultReconcilePeriod
+	}
+
+	if c.ReconcileTimeout == time.Duration(0) {
+		c.ReconcileTimeout = _defaultReconcileTimeout
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import "github.com/uber-go/tally"
+
+// Metrics is the struct containing all metrics relevant for
+// the daemon job reconciler
+type Metrics struct {
+	EligibleHosts      tally.Gauge
+	DaemonJobs         tally.Gauge
+	InstancesAdded     tally.Counter
+	InstancesStopped   tally.Counter
+	InstancesRestarted tally.Counter
+	ReconcileDuration  tally.Timer
+
+	GetHostsFail     tally.Counter
+	ReconcileJobFail tally.Counter
+}
+
+// NewMetrics returns a new Metrics struct, with all metrics
+// initialized and rooted at the given tally.Scope
+func NewMetrics(scope tally.Scope) *Metrics {
+	daemonScope := scope.SubScope("daemon")
+	instanceScope := daemonScope.SubScope("instance")
+	return &Metrics{
+		EligibleHosts:      daemonScope.Gauge("eligible_hosts"),
+		DaemonJobs:         daemonScope.Gauge("jobs"),
+		InstancesAdded:     instanceScope.Counter("added"),
+		InstancesStopped:   instanceScope.Counter("stopped"),
+		InstancesRestarted: instanceScope.Counter("restarted"),
+		ReconcileDuration:  daemonScope.Timer("duration"),
+
+		GetHostsFail:     daemonScope.Counter("get_hosts_fail"),
+		ReconcileJobFail: daemonScope.Counter("reconcile_job_fail"),
+	}
+}
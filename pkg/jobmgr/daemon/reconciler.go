@@ -0,0 +1,395 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	pbhost "github.com/uber/peloton/.gen/peloton/api/v0/host"
+	host_svc "github.com/uber/peloton/.gen/peloton/api/v0/host/svc"
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbtask "github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/common/background"
+	"github.com/uber/peloton/pkg/common/constraints"
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/jobmgr/cached"
+	jobmgrcommon "github.com/uber/peloton/pkg/jobmgr/common"
+	"github.com/uber/peloton/pkg/jobmgr/goalstate"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	"github.com/gogo/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/atomic"
+)
+
+const _daemonReconcilerName = "daemonReconciler"
+
+// _unavailableHostStates are the maintenance states of the hosts which
+// should not run daemon instances
+var _unavailableHostStates = []pbhost.HostState{
+	pbhost.HostState_HOST_STATE_DRAINING,
+	pbhost.HostState_HOST_STATE_DRAINED,
+	pbhost.HostState_HOST_STATE_DOWN,
+}
+
+// Reconciler keeps the instances of daemon jobs in sync with the hosts of
+// the cluster. Every active host registered with the host manager which
+// matches the constraint of a daemon job runs one instance of the job,
+// pinned to the host. Instances on hosts which are gone, in maintenance or
+// no longer match the constraint are stopped, and started again once their
+// host becomes eligible.
+type Reconciler struct {
+	JobFactory      cached.JobFactory
+	JobConfigOps    ormobjects.JobConfigOps
+	GoalStateDriver goalstate.Driver
+	HostMgrClient   hostsvc.InternalHostServiceYARPCClient
+	HostClient      host_svc.HostServiceYARPCClient
+	Metrics         *Metrics
+	Config          *Config
+}
+
+// Register register the reconciler in background.Manager
+func (r *Reconciler) Register(manager background.Manager) error {
+	if r.Config == nil {
+		r.Config = &Config{}
+	}
+
+	r.Config.normalize()
+	return manager.RegisterWorks(
+		background.Work{
+			Name: _daemonReconcilerName,
+			Func: func(_ *atomic.Bool) {
+				r.Reconcile()
+			},
+			Period: r.Config.ReconcilePeriod,
+		},
+	)
+}
+
+// Reconcile adds, stops and restarts the instances of all daemon jobs
+// according to the hosts which are currently eligible to run them
+func (r *Reconciler) Reconcile() {
+	stopWatch := r.Metrics.ReconcileDuration.Start()
+	defer stopWatch.Stop()
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), r.Config.ReconcileTimeout)
+	defer cancel()
+
+	hosts, err := r.getHosts(ctx)
+	if err != nil {
+		r.Metrics.GetHostsFail.Inc(1)
+		log.WithError(err).
+			Warn("failed to get hosts to reconcile daemon jobs")
+		return
+	}
+
+	// the agent map of host manager is empty until it is loaded for the
+	// first time, do not stop every daemon instance in the mean time
+	if len(hosts) == 0 {
+		log.Info("no hosts available, skip reconciling daemon jobs")
+		return
+	}
+	r.Metrics.EligibleHosts.Update(float64(len(hosts)))
+
+	var daemonJobs uint
+	for _, cachedJob := range r.JobFactory.GetAllJobs() {
+		if cachedJob.GetJobType() != pbjob.JobType_DAEMON {
+			continue
+		}
+
+		daemonJobs++
+		if err := r.reconcileJob(ctx, cachedJob, hosts); err != nil {
+			r.Metrics.ReconcileJobFail.Inc(1)
+			log.WithError(err).
+				WithField("job_id", cachedJob.ID().GetValue()).
+				Warn("failed to reconcile daemon job")
+		}
+	}
+	r.Metrics.DaemonJobs.Update(float64(daemonJobs))
+}
+
+// getHosts returns the attributes of the active hosts which are not
+// in maintenance, keyed by hostname
+func (r *Reconciler) getHosts(
+	ctx context.Context) (map[string][]*mesos.Attribute, error) {
+	agentInfo, err := r.HostMgrClient.GetMesosAgentInfo(
+		ctx,
+		&hostsvc.GetMesosAgentInfoRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string][]*mesos.Attribute)
+	for _, agent := range agentInfo.GetAgents() {
+		if !agent.GetActive() {
+			continue
+		}
+		hosts[agent.GetAgentInfo().GetHostname()] =
+			agent.GetAgentInfo().GetAttributes()
+	}
+
+	maintenance, err := r.HostClient.QueryHosts(
+		ctx,
+		&host_svc.QueryHostsRequest{HostStates: _unavailableHostStates})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hostInfo := range maintenance.GetHostInfos() {
+		delete(hosts, hostInfo.GetHostname())
+	}
+	return hosts, nil
+}
+
+// reconcileJob reconciles the instances of a single daemon job
+func (r *Reconciler) reconcileJob(
+	ctx context.Context,
+	cachedJob cached.Job,
+	hosts map[string][]*mesos.Attribute) error {
+	runtime, err := cachedJob.GetRuntime(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the job is being stopped or deleted, its instances are handled by
+	// the goal state engine
+	if util.IsPelotonJobStateTerminal(runtime.GetGoalState()) ||
+		util.IsPelotonJobStateTerminal(runtime.GetState()) {
+		return nil
+	}
+
+	config, configAddOn, err := r.JobConfigOps.Get(
+		ctx,
+		cachedJob.ID(),
+		runtime.GetConfigurationVersion())
+	if err != nil {
+		return err
+	}
+
+	eligible, err := eligibleHosts(config.GetDefaultConfig(), hosts)
+	if err != nil {
+		return err
+	}
+
+	pinned := make(map[string]uint32)
+	for instID, instanceConfig := range config.GetInstanceConfig() {
+		if hostname := PinnedHost(instanceConfig); len(hostname) != 0 {
+			pinned[hostname] = instID
+		}
+	}
+
+	var newHosts []string
+	for hostname := range eligible {
+		if _, ok := pinned[hostname]; !ok {
+			newHosts = append(newHosts, hostname)
+		}
+	}
+
+	if err := r.patchInstances(
+		ctx, cachedJob, pinned, eligible); err != nil {
+		return err
+	}
+
+	if len(newHosts) == 0 {
+		return nil
+	}
+	sort.Strings(newHosts)
+	return r.addInstances(ctx, cachedJob, config, configAddOn, newHosts)
+}
+
+// addInstances adds one instance pinned to each of the given hosts to the
+// job, the goal state engine creates the new instances
+func (r *Reconciler) addInstances(
+	ctx context.Context,
+	cachedJob cached.Job,
+	config *pbjob.JobConfig,
+	configAddOn *models.ConfigAddOn,
+	hosts []string) error {
+	if config.InstanceConfig == nil {
+		config.InstanceConfig = make(map[uint32]*pbtask.TaskConfig)
+	}
+	for _, hostname := range hosts {
+		config.InstanceConfig[config.InstanceCount] =
+			PinToHost(config.GetDefaultConfig(), hostname)
+		config.InstanceCount++
+	}
+
+	// first persist the configuration
+	newConfig, err := cachedJob.CompareAndSetConfig(ctx, config, configAddOn)
+	if err != nil {
+		return err
+	}
+
+	// next persist the runtime state and the new configuration version
+	err = cachedJob.Update(ctx, &pbjob.JobInfo{
+		Runtime: &pbjob.RuntimeInfo{
+			ConfigurationVersion: newConfig.GetChangeLog().GetVersion(),
+			State:                pbjob.JobState_INITIALIZED,
+		},
+	}, nil,
+		cached.UpdateCacheAndDB)
+	if err != nil {
+		return err
+	}
+
+	r.GoalStateDriver.EnqueueJob(cachedJob.ID(), time.Now())
+	r.Metrics.InstancesAdded.Inc(int64(len(hosts)))
+
+	log.WithField("job_id", cachedJob.ID().GetValue()).
+		WithField("hosts", hosts).
+		Info("added daemon instances")
+	return nil
+}
+
+// patchInstances stops the instances pinned to hosts which are no longer
+// eligible and restarts the stopped instances whose host is eligible again
+func (r *Reconciler) patchInstances(
+	ctx context.Context,
+	cachedJob cached.Job,
+	pinned map[string]uint32,
+	eligible map[string]bool) error {
+	runtimeDiffs := make(map[uint32]jobmgrcommon.RuntimeDiff)
+	var stopped, restarted int64
+	for hostname, instID := range pinned {
+		cachedTask := cachedJob.GetTask(instID)
+		if cachedTask == nil {
+			// the instance is not created yet
+			continue
+		}
+
+		taskRuntime, err := cachedTask.GetRuntime(ctx)
+		if err != nil {
+			return err
+		}
+
+		killed := taskRuntime.GetGoalState() == pbtask.TaskState_KILLED
+		if !eligible[hostname] && !killed {
+			runtimeDiffs[instID] = jobmgrcommon.RuntimeDiff{
+				jobmgrcommon.GoalStateField: pbtask.TaskState_KILLED,
+				jobmgrcommon.MessageField:   "Host is not eligible for daemon job",
+			}
+			stopped++
+		} else if eligible[hostname] && killed {
+			runtimeDiffs[instID] = jobmgrcommon.RuntimeDiff{
+				jobmgrcommon.GoalStateField: pbtask.TaskState_RUNNING,
+				jobmgrcommon.MessageField:   "Host is eligible for daemon job",
+			}
+			restarted++
+		}
+	}
+
+	if len(runtimeDiffs) == 0 {
+		return nil
+	}
+
+	if err := cachedJob.PatchTasks(ctx, runtimeDiffs); err != nil {
+		return err
+	}
+
+	for instID := range runtimeDiffs {
+		r.GoalStateDriver.EnqueueTask(cachedJob.ID(), instID, time.Now())
+	}
+	r.Metrics.InstancesStopped.Inc(stopped)
+	r.Metrics.InstancesRestarted.Inc(restarted)
+	return nil
+}
+
+// eligibleHosts returns the hosts matching the constraint of the task config
+func eligibleHosts(
+	config *pbtask.TaskConfig,
+	hosts map[string][]*mesos.Attribute) (map[string]bool, error) {
+	evaluator := constraints.NewEvaluator(pbtask.LabelConstraint_HOST)
+	eligible := make(map[string]bool)
+	for hostname, attributes := range hosts {
+		if config.GetConstraint() != nil {
+			result, err := evaluator.Evaluate(
+				config.GetConstraint(),
+				constraints.GetHostLabelValues(hostname, attributes))
+			if err != nil {
+				return nil, err
+			}
+			if result == constraints.EvaluateResultMismatch {
+				continue
+			}
+		}
+		eligible[hostname] = true
+	}
+	return eligible, nil
+}
+
+// PinToHost returns a copy of the task config with a host constraint which
+// restricts the task to the given host, in addition to the constraint of
+// the task config.
+func PinToHost(config *pbtask.TaskConfig, hostname string) *pbtask.TaskConfig {
+	pinnedConfig := proto.Clone(config).(*pbtask.TaskConfig)
+	hostConstraint := &pbtask.Constraint{
+		Type: pbtask.Constraint_LABEL_CONSTRAINT,
+		LabelConstraint: &pbtask.LabelConstraint{
+			Kind: pbtask.LabelConstraint_HOST,
+			Label: &peloton.Label{
+				Key:   constraints.HostNameKey,
+				Value: hostname,
+			},
+			Condition:   pbtask.LabelConstraint_CONDITION_EQUAL,
+			Requirement: 1,
+		},
+	}
+
+	if config.GetConstraint() == nil {
+		pinnedConfig.Constraint = hostConstraint
+		return pinnedConfig
+	}
+
+	pinnedConfig.Constraint = &pbtask.Constraint{
+		Type: pbtask.Constraint_AND_CONSTRAINT,
+		AndConstraint: &pbtask.AndConstraint{
+			Constraints: []*pbtask.Constraint{
+				hostConstraint,
+				pinnedConfig.GetConstraint(),
+			},
+		},
+	}
+	return pinnedConfig
+}
+
+// PinnedHost returns the host the task config has been pinned to by
+// PinToHost, and an empty string if the task config is not pinned.
+func PinnedHost(config *pbtask.TaskConfig) string {
+	constraint := config.GetConstraint()
+	if constraint.GetType() == pbtask.Constraint_AND_CONSTRAINT {
+		andConstraints := constraint.GetAndConstraint().GetConstraints()
+		if len(andConstraints) == 0 {
+			return ""
+		}
+		constraint = andConstraints[0]
+	}
+
+	labelConstraint := constraint.GetLabelConstraint()
+	if constraint.GetType() != pbtask.Constraint_LABEL_CONSTRAINT ||
+		labelConstraint.GetKind() != pbtask.LabelConstraint_HOST ||
+		labelConstraint.GetCondition() != pbtask.LabelConstraint_CONDITION_EQUAL ||
+		labelConstraint.GetLabel().GetKey() != constraints.HostNameKey {
+		return ""
+	}
+	return labelConstraint.GetLabel().GetValue()
+}
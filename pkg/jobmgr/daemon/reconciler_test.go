@@ -0,0 +1,372 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	mesos_master "github.com/uber/peloton/.gen/mesos/v1/master"
+	pbhost "github.com/uber/peloton/.gen/peloton/api/v0/host"
+	host_svc "github.com/uber/peloton/.gen/peloton/api/v0/host/svc"
+	host_svc_mocks "github.com/uber/peloton/.gen/peloton/api/v0/host/svc/mocks"
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbtask "github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
+	host_mocks "github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc/mocks"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	backgroundmocks "github.com/uber/peloton/pkg/common/background/mocks"
+	"github.com/uber/peloton/pkg/jobmgr/cached"
+	cachedmocks "github.com/uber/peloton/pkg/jobmgr/cached/mocks"
+	jobmgrcommon "github.com/uber/peloton/pkg/jobmgr/common"
+	goalstatemocks "github.com/uber/peloton/pkg/jobmgr/goalstate/mocks"
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+)
+
+const (
+	_testHost1 = "host1"
+	_testHost2 = "host2"
+	_testHost3 = "host3"
+)
+
+type ReconcilerTestSuite struct {
+	suite.Suite
+	mockCtrl *gomock.Controller
+
+	testScope       tally.TestScope
+	jobFactory      *cachedmocks.MockJobFactory
+	cachedJob       *cachedmocks.MockJob
+	jobConfigOps    *objectmocks.MockJobConfigOps
+	goalStateDriver *goalstatemocks.MockDriver
+	hostMgrClient   *host_mocks.MockInternalHostServiceYARPCClient
+	hostClient      *host_svc_mocks.MockHostServiceYARPCClient
+	reconciler      *Reconciler
+
+	jobID *peloton.JobID
+}
+
+func (s *ReconcilerTestSuite) SetupTest() {
+	s.mockCtrl = gomock.NewController(s.T())
+
+	s.testScope = tally.NewTestScope("", nil)
+	s.jobFactory = cachedmocks.NewMockJobFactory(s.mockCtrl)
+	s.cachedJob = cachedmocks.NewMockJob(s.mockCtrl)
+	s.jobConfigOps = objectmocks.NewMockJobConfigOps(s.mockCtrl)
+	s.goalStateDriver = goalstatemocks.NewMockDriver(s.mockCtrl)
+	s.hostMgrClient = host_mocks.NewMockInternalHostServiceYARPCClient(s.mockCtrl)
+	s.hostClient = host_svc_mocks.NewMockHostServiceYARPCClient(s.mockCtrl)
+	s.jobID = &peloton.JobID{Value: "daemon-job"}
+
+	config := &Config{}
+	config.normalize()
+
+	s.reconciler = &Reconciler{
+		JobFactory:      s.jobFactory,
+		JobConfigOps:    s.jobConfigOps,
+		GoalStateDriver: s.goalStateDriver,
+		HostMgrClient:   s.hostMgrClient,
+		HostClient:      s.hostClient,
+		Metrics:         NewMetrics(s.testScope),
+		Config:          config,
+	}
+}
+
+func (s *ReconcilerTestSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestReconcilerTestSuite(t *testing.T) {
+	suite.Run(t, new(ReconcilerTestSuite))
+}
+
+// makeAgent returns a mesos agent with a text attribute zone
+func makeAgent(
+	hostname string,
+	zone string,
+	active bool) *mesos_master.Response_GetAgents_Agent {
+	attributeName := "zone"
+	attributeType := mesos.Value_TEXT
+	return &mesos_master.Response_GetAgents_Agent{
+		AgentInfo: &mesos.AgentInfo{
+			Hostname: &hostname,
+			Attributes: []*mesos.Attribute{
+				{
+					Name: &attributeName,
+					Type: &attributeType,
+					Text: &mesos.Value_Text{Value: &zone},
+				},
+			},
+		},
+		Active: &active,
+	}
+}
+
+// expectHosts sets up the host manager to return the given agents, with
+// the given hosts in maintenance
+func (s *ReconcilerTestSuite) expectHosts(
+	agents []*mesos_master.Response_GetAgents_Agent,
+	maintenance ...string) {
+	s.hostMgrClient.EXPECT().
+		GetMesosAgentInfo(gomock.Any(), &hostsvc.GetMesosAgentInfoRequest{}).
+		Return(&hostsvc.GetMesosAgentInfoResponse{Agents: agents}, nil)
+
+	var hostInfos []*pbhost.HostInfo
+	for _, hostname := range maintenance {
+		hostInfos = append(hostInfos, &pbhost.HostInfo{
+			Hostname: hostname,
+			State:    pbhost.HostState_HOST_STATE_DRAINING,
+		})
+	}
+	s.hostClient.EXPECT().
+		QueryHosts(gomock.Any(), &host_svc.QueryHostsRequest{
+			HostStates: _unavailableHostStates,
+		}).
+		Return(&host_svc.QueryHostsResponse{HostInfos: hostInfos}, nil)
+}
+
+// expectDaemonJob sets up the job factory to return a single daemon job
+// with the given config
+func (s *ReconcilerTestSuite) expectDaemonJob(config *pbjob.JobConfig) {
+	s.jobFactory.EXPECT().
+		GetAllJobs().
+		Return(map[string]cached.Job{s.jobID.GetValue(): s.cachedJob})
+	s.cachedJob.EXPECT().GetJobType().Return(pbjob.JobType_DAEMON)
+	s.cachedJob.EXPECT().ID().Return(s.jobID).AnyTimes()
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{
+			State:                pbjob.JobState_RUNNING,
+			GoalState:            pbjob.JobState_RUNNING,
+			ConfigurationVersion: 1,
+		}, nil)
+	s.jobConfigOps.EXPECT().
+		Get(gomock.Any(), s.jobID, uint64(1)).
+		Return(config, &models.ConfigAddOn{}, nil)
+}
+
+// zoneConfig returns a daemon job config constrained to the zone
+func zoneConfig(zone string) *pbjob.JobConfig {
+	return &pbjob.JobConfig{
+		Type: pbjob.JobType_DAEMON,
+		DefaultConfig: &pbtask.TaskConfig{
+			Constraint: &pbtask.Constraint{
+				Type: pbtask.Constraint_LABEL_CONSTRAINT,
+				LabelConstraint: &pbtask.LabelConstraint{
+					Kind: pbtask.LabelConstraint_HOST,
+					Label: &peloton.Label{
+						Key:   "zone",
+						Value: zone,
+					},
+					Condition:   pbtask.LabelConstraint_CONDITION_EQUAL,
+					Requirement: 1,
+				},
+			},
+		},
+		ChangeLog: &peloton.ChangeLog{Version: 1},
+	}
+}
+
+// TestReconcilerRegister tests that the reconciler registers with the
+// background manager
+func (s *ReconcilerTestSuite) TestReconcilerRegister() {
+	mockBackgroundManager := backgroundmocks.NewMockManager(s.mockCtrl)
+	mockBackgroundManager.EXPECT().RegisterWorks(gomock.Any()).Return(nil)
+	s.NoError(s.reconciler.Register(mockBackgroundManager))
+}
+
+// TestReconcileAddInstances tests that an instance pinned to each new
+// eligible host is added to a daemon job
+func (s *ReconcilerTestSuite) TestReconcileAddInstances() {
+	s.expectHosts([]*mesos_master.Response_GetAgents_Agent{
+		makeAgent(_testHost2, "zone1", true),
+		makeAgent(_testHost1, "zone1", true),
+		// inactive host
+		makeAgent(_testHost3, "zone1", false),
+		// host in another zone
+		makeAgent("host4", "zone2", true),
+		// host in maintenance
+		makeAgent("host5", "zone1", true),
+	}, "host5")
+	s.expectDaemonJob(zoneConfig("zone1"))
+
+	s.cachedJob.EXPECT().
+		CompareAndSetConfig(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(
+			_ context.Context,
+			config *pbjob.JobConfig,
+			_ *models.ConfigAddOn) {
+			s.Equal(uint32(2), config.GetInstanceCount())
+			s.Equal(_testHost1, PinnedHost(config.GetInstanceConfig()[0]))
+			s.Equal(_testHost2, PinnedHost(config.GetInstanceConfig()[1]))
+		}).
+		Return(&pbjob.JobConfig{
+			ChangeLog: &peloton.ChangeLog{Version: 2},
+		}, nil)
+	s.cachedJob.EXPECT().
+		Update(gomock.Any(), &pbjob.JobInfo{
+			Runtime: &pbjob.RuntimeInfo{
+				ConfigurationVersion: 2,
+				State:                pbjob.JobState_INITIALIZED,
+			},
+		}, nil, cached.UpdateCacheAndDB).
+		Return(nil)
+	s.goalStateDriver.EXPECT().EnqueueJob(s.jobID, gomock.Any())
+
+	s.reconciler.Reconcile()
+	s.Equal(int64(2), s.testScope.Snapshot().
+		Counters()["daemon.instance.added+"].Value())
+}
+
+// TestReconcilePatchInstances tests that instances on hosts which are not
+// eligible are stopped, and stopped instances on eligible hosts restarted
+func (s *ReconcilerTestSuite) TestReconcilePatchInstances() {
+	s.expectHosts([]*mesos_master.Response_GetAgents_Agent{
+		makeAgent(_testHost1, "zone1", true),
+		makeAgent(_testHost2, "zone1", true),
+	}, _testHost2)
+
+	config := zoneConfig("zone1")
+	config.InstanceCount = 3
+	config.InstanceConfig = map[uint32]*pbtask.TaskConfig{
+		0: PinToHost(config.GetDefaultConfig(), _testHost1),
+		1: PinToHost(config.GetDefaultConfig(), _testHost2),
+		2: PinToHost(config.GetDefaultConfig(), _testHost3),
+	}
+	s.expectDaemonJob(config)
+
+	goalStates := []pbtask.TaskState{
+		pbtask.TaskState_KILLED,
+		pbtask.TaskState_RUNNING,
+		pbtask.TaskState_KILLED,
+	}
+	for instID, goalState := range goalStates {
+		cachedTask := cachedmocks.NewMockTask(s.mockCtrl)
+		s.cachedJob.EXPECT().GetTask(uint32(instID)).Return(cachedTask)
+		cachedTask.EXPECT().
+			GetRuntime(gomock.Any()).
+			Return(&pbtask.RuntimeInfo{GoalState: goalState}, nil)
+	}
+
+	s.cachedJob.EXPECT().
+		PatchTasks(gomock.Any(), gomock.Any()).
+		Do(func(
+			_ context.Context,
+			runtimeDiffs map[uint32]jobmgrcommon.RuntimeDiff) {
+			s.Len(runtimeDiffs, 2)
+			s.Equal(pbtask.TaskState_RUNNING,
+				runtimeDiffs[0][jobmgrcommon.GoalStateField])
+			s.Equal(pbtask.TaskState_KILLED,
+				runtimeDiffs[1][jobmgrcommon.GoalStateField])
+		}).
+		Return(nil)
+	s.goalStateDriver.EXPECT().EnqueueTask(s.jobID, uint32(0), gomock.Any())
+	s.goalStateDriver.EXPECT().EnqueueTask(s.jobID, uint32(1), gomock.Any())
+
+	s.reconciler.Reconcile()
+}
+
+// TestReconcileTerminalJob tests that a daemon job which is being stopped
+// is not reconciled
+func (s *ReconcilerTestSuite) TestReconcileTerminalJob() {
+	s.expectHosts([]*mesos_master.Response_GetAgents_Agent{
+		makeAgent(_testHost1, "zone1", true),
+	})
+	s.jobFactory.EXPECT().
+		GetAllJobs().
+		Return(map[string]cached.Job{s.jobID.GetValue(): s.cachedJob})
+	s.cachedJob.EXPECT().GetJobType().Return(pbjob.JobType_DAEMON)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{
+			State:     pbjob.JobState_RUNNING,
+			GoalState: pbjob.JobState_KILLED,
+		}, nil)
+
+	s.reconciler.Reconcile()
+}
+
+// TestReconcileSkipNonDaemonJob tests that only daemon jobs are reconciled
+func (s *ReconcilerTestSuite) TestReconcileSkipNonDaemonJob() {
+	s.expectHosts([]*mesos_master.Response_GetAgents_Agent{
+		makeAgent(_testHost1, "zone1", true),
+	})
+	s.jobFactory.EXPECT().
+		GetAllJobs().
+		Return(map[string]cached.Job{s.jobID.GetValue(): s.cachedJob})
+	s.cachedJob.EXPECT().GetJobType().Return(pbjob.JobType_SERVICE)
+
+	s.reconciler.Reconcile()
+}
+
+// TestReconcileNoHosts tests that daemon jobs are not reconciled when
+// host manager has no hosts
+func (s *ReconcilerTestSuite) TestReconcileNoHosts() {
+	s.expectHosts(nil)
+	s.reconciler.Reconcile()
+}
+
+// TestReconcileGetHostsFailure tests the failure to get the hosts
+func (s *ReconcilerTestSuite) TestReconcileGetHostsFailure() {
+	s.hostMgrClient.EXPECT().
+		GetMesosAgentInfo(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("test error"))
+
+	s.reconciler.Reconcile()
+	s.Equal(int64(1), s.testScope.Snapshot().
+		Counters()["daemon.get_hosts_fail+"].Value())
+}
+
+// TestReconcileJobFailure tests the failure to reconcile a daemon job
+func (s *ReconcilerTestSuite) TestReconcileJobFailure() {
+	s.expectHosts([]*mesos_master.Response_GetAgents_Agent{
+		makeAgent(_testHost1, "zone1", true),
+	})
+	s.expectDaemonJob(zoneConfig("zone1"))
+	s.cachedJob.EXPECT().
+		CompareAndSetConfig(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("test error"))
+
+	s.reconciler.Reconcile()
+	s.Equal(int64(1), s.testScope.Snapshot().
+		Counters()["daemon.reconcile_job_fail+"].Value())
+}
+
+// TestPinToHost tests pinning a task config to a host
+func (s *ReconcilerTestSuite) TestPinToHost() {
+	config := &pbtask.TaskConfig{}
+	s.Empty(PinnedHost(config))
+
+	pinned := PinToHost(config, _testHost1)
+	s.Equal(_testHost1, PinnedHost(pinned))
+	s.Nil(config.GetConstraint())
+
+	// the constraint of the config is kept
+	config = zoneConfig("zone1").GetDefaultConfig()
+	s.Empty(PinnedHost(config))
+
+	pinned = PinToHost(config, _testHost1)
+	s.Equal(_testHost1, PinnedHost(pinned))
+	s.Equal(pbtask.Constraint_AND_CONSTRAINT, pinned.GetConstraint().GetType())
+	s.Equal(config.GetConstraint(),
+		pinned.GetConstraint().GetAndConstraint().GetConstraints()[1])
+}
@@ -48,8 +48,9 @@ func JobUntrack(ctx context.Context, entity goalstate.Entity) error {
 			// if config is not found, untrack the job from cache
 			return err
 		}
-	} else if jobConfig.GetType() == job.JobType_SERVICE {
-		// service jobs are always active and never untracked.
+	} else if jobConfig.GetType() == job.JobType_SERVICE ||
+		jobConfig.GetType() == job.JobType_DAEMON {
+		// service and daemon jobs are always active and never untracked.
 		// Call runtime updater, because job runtime can change
 		// when an update is running on the job.
 		return JobRuntimeUpdater(ctx, entity)
@@ -288,6 +288,18 @@ func (d *jobStateDeterminer) getState(
 ) (job.JobState, error) {
 	totalInstanceCount := d.config.GetInstanceCount()
 
+	// Instances of a daemon job are killed when their host goes away and
+	// the job may have no instance at all on an empty cluster, so a daemon
+	// job which is not being stopped stays active irrespective of the
+	// terminal instances.
+	if d.config.GetType() == job.JobType_DAEMON &&
+		!util.IsPelotonJobStateTerminal(jobRuntime.GetGoalState()) {
+		if d.stateCounts[task.TaskState_RUNNING.String()] > 0 {
+			return job.JobState_RUNNING, nil
+		}
+		return job.JobState_PENDING, nil
+	}
+
 	// There are two reasons where state counts can be greater than
 	// configured instance count
 	// 1. storage materialized view is diverged and till it converges
@@ -364,7 +376,9 @@ func (d *partiallyCreatedJobStateDeterminer) getState(
 	instanceCount := getTotalInstanceCount(d.stateCounts)
 
 	switch d.cachedJob.GetJobType() {
-	case job.JobType_BATCH:
+	case job.JobType_BATCH, job.JobType_DAEMON:
+		// daemon instances are added for new hosts the same way as batch
+		// instances are created, by creating the missing tasks
 		return job.JobState_INITIALIZED, nil
 	case job.JobType_SERVICE:
 
@@ -806,8 +820,8 @@ func shouldRecalculateJobStateFromCache(
 	jobState job.JobState,
 	forceRecalculateFromCache bool) bool {
 
-	if jobType == job.JobType_SERVICE {
-		// always compute from cache for stateless services
+	if jobType == job.JobType_SERVICE || jobType == job.JobType_DAEMON {
+		// always compute from cache for stateless services and daemons
 		return true
 	}
 
@@ -1851,6 +1851,69 @@ func (suite *JobRuntimeUpdaterTestSuite) TestJobStateDeterminer_NoInstancesCreat
 	suite.Equal(job.JobState_KILLED, jobState)
 }
 
+// TestJobStateDeterminer_DaemonJob tests that a daemon job which is not
+// being stopped stays active irrespective of its terminal instances
+func (suite *JobRuntimeUpdaterTestSuite) TestJobStateDeterminer_DaemonJob() {
+	testTable := []struct {
+		stateCounts      map[string]uint32
+		desiredGoalState job.JobState
+		expectedState    job.JobState
+		msg              string
+	}{
+		{
+			stateCounts:      map[string]uint32{},
+			desiredGoalState: job.JobState_RUNNING,
+			expectedState:    job.JobState_PENDING,
+			msg:              "daemon job without instances is pending",
+		},
+		{
+			stateCounts: map[string]uint32{
+				pbtask.TaskState_RUNNING.String(): 1,
+				pbtask.TaskState_KILLED.String():  1,
+			},
+			desiredGoalState: job.JobState_RUNNING,
+			expectedState:    job.JobState_RUNNING,
+			msg:              "daemon job with killed instances keeps running",
+		},
+		{
+			stateCounts: map[string]uint32{
+				pbtask.TaskState_KILLED.String(): 2,
+			},
+			desiredGoalState: job.JobState_RUNNING,
+			expectedState:    job.JobState_PENDING,
+			msg:              "daemon job with all instances killed is pending",
+		},
+		{
+			stateCounts: map[string]uint32{
+				pbtask.TaskState_KILLED.String(): 2,
+			},
+			desiredGoalState: job.JobState_KILLED,
+			expectedState:    job.JobState_KILLED,
+			msg:              "stopped daemon job with all instances killed is killed",
+		},
+	}
+
+	suite.cachedConfig.EXPECT().
+		GetType().
+		Return(pbjob.JobType_DAEMON).
+		AnyTimes()
+
+	suite.cachedConfig.EXPECT().
+		GetInstanceCount().
+		Return(uint32(2)).
+		AnyTimes()
+
+	for _, tt := range testTable {
+		jobState, err := newJobStateDeterminer(tt.stateCounts, suite.cachedConfig).
+			getState(context.Background(), &pbjob.RuntimeInfo{
+				State:     job.JobState_PENDING,
+				GoalState: tt.desiredGoalState,
+			})
+		suite.NoError(err)
+		suite.Equal(tt.expectedState, jobState, tt.msg)
+	}
+}
+
 // TestDetermineJobRuntimeStateStaleJob tests determining job runtime state
 // for a stale active job with out of sync materialized view
 func (suite *JobRuntimeUpdaterTestSuite) TestDetermineJobRuntimeStateStaleJob() {
@@ -2493,6 +2556,9 @@ func (suite *JobRuntimeUpdaterTestSuite) TestShouldRecalculateJobStateNonBatch()
 	suite.True(shouldRecalculateJobStateFromCache(
 		suite.cachedJob, pbjob.JobType_SERVICE, pbjob.JobState_RUNNING,
 		suite.goalStateDriver.jobRuntimeCalculationViaCache))
+	suite.True(shouldRecalculateJobStateFromCache(
+		suite.cachedJob, pbjob.JobType_DAEMON, pbjob.JobState_RUNNING,
+		suite.goalStateDriver.jobRuntimeCalculationViaCache))
 }
 
 // TestshouldRecalculateJobStateTerminalJob tests shouldRecalculateJobStateFromCache
@@ -64,6 +64,8 @@ type UpdateMetrics struct {
 	UpdateRunFail           tally.Counter
 	UpdateWriteProgress     tally.Counter
 	UpdateWriteProgressFail tally.Counter
+	UpdateStageBaking       tally.Counter
+	UpdateStageUnhealthy    tally.Counter
 }
 
 // Metrics is the struct containing all the counters that track job and task
@@ -121,6 +123,8 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		UpdateRunFail:           updateScope.Counter("run_fail"),
 		UpdateWriteProgress:     updateScope.Counter("write_progress"),
 		UpdateWriteProgressFail: updateScope.Counter("write_progress_fail"),
+		UpdateStageBaking:       updateScope.Counter("stage_baking"),
+		UpdateStageUnhealthy:    updateScope.Counter("stage_unhealthy"),
 	}
 
 	return &Metrics{
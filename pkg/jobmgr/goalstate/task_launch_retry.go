@@ -106,7 +106,8 @@ func TaskLaunchRetry(ctx context.Context, entity goalstate.Entity) error {
 			return err
 		}
 
-		if cachedConfig.GetType() == job.JobType_SERVICE {
+		if cachedConfig.GetType() == job.JobType_SERVICE ||
+			cachedConfig.GetType() == job.JobType_DAEMON {
 			return nil
 		}
 
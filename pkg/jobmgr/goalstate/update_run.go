@@ -100,9 +100,38 @@ func UpdateRun(ctx context.Context, entity goalstate.Entity) error {
 		return err
 	}
 
+	updateConfig := cachedWorkflow.GetUpdateConfig()
+
+	// a staged update does not start the instances of its next stage
+	// until the instances of the finished stage have baked and are healthy
+	maxInstancesProcessed, stopped, err := runUpdateStrategy(
+		ctx,
+		cachedJob,
+		cachedWorkflow,
+		updateConfig.GetStrategy(),
+		instancesDone,
+		instancesFailed,
+		instancesCurrent,
+		goalStateDriver,
+	)
+	if err != nil {
+		goalStateDriver.mtx.updateMetrics.UpdateRunFail.Inc(1)
+		return err
+	}
+	if stopped {
+		goalStateDriver.mtx.updateMetrics.UpdateRun.Inc(1)
+		return nil
+	}
+
 	instancesToAdd, instancesToUpdate, instancesToRemove :=
 		getInstancesForUpdateRun(
-			cachedWorkflow, instancesCurrent, instancesDone, instancesFailed)
+			cachedWorkflow,
+			updateConfig,
+			maxInstancesProcessed,
+			instancesCurrent,
+			instancesDone,
+			instancesFailed,
+		)
 
 	instancesToAdd, instancesToUpdate, instancesToRemove, instancesRemovedDone, err :=
 		confirmInstancesStatus(
@@ -196,53 +225,16 @@ func processFailedUpdate(
 	// the update itself is not a rollback
 	if cachedUpdate.GetUpdateConfig().RollbackOnFailure &&
 		!isUpdateRollback(cachedUpdate) {
-		// write the progress first, because when rollback happens,
-		// workflow does not know the newly finished/failed instances.
-		cachedUpdate.WriteProgress(
+		if err := rollbackUpdate(
 			ctx,
-			cachedUpdate.GetState().State,
+			cachedJob,
+			cachedUpdate,
 			instancesDone,
 			instancesFailed,
 			instancesCurrent,
-		)
-
-		if err := cachedJob.RollbackWorkflow(ctx); err != nil {
-			log.WithFields(log.Fields{
-				"update_id": cachedUpdate.ID().GetValue(),
-				"job_id":    cachedJob.ID().GetValue(),
-			}).WithError(err).
-				Info("fail to rollback update")
-			return err
-		}
-
-		cachedConfig, err := cachedJob.GetConfig(ctx)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"update_id": cachedUpdate.ID().GetValue(),
-				"job_id":    cachedJob.ID().GetValue(),
-			}).WithError(err).
-				Info("fail to get job config to rollback update")
-			return err
-		}
-
-		if err := handleUnchangedInstancesInUpdate(
-			ctx,
-			cachedUpdate,
-			cachedJob,
-			cachedConfig,
 		); err != nil {
-			log.WithFields(log.Fields{
-				"update_id": cachedUpdate.ID().GetValue(),
-				"job_id":    cachedJob.ID().GetValue(),
-			}).WithError(err).
-				Info("fail to update unchanged instances to rollback update")
 			return err
 		}
-
-		log.WithFields(log.Fields{
-			"update_id": cachedUpdate.ID().GetValue(),
-			"job_id":    cachedJob.ID().GetValue(),
-		}).Info("update rolling back")
 	} else {
 		if err := cachedUpdate.WriteProgress(
 			ctx,
@@ -259,6 +251,65 @@ func processFailedUpdate(
 	return nil
 }
 
+// rollbackUpdate rolls back the update to the previous job configuration
+func rollbackUpdate(
+	ctx context.Context,
+	cachedJob cached.Job,
+	cachedUpdate cached.Update,
+	instancesDone []uint32,
+	instancesFailed []uint32,
+	instancesCurrent []uint32,
+) error {
+	// write the progress first, because when rollback happens,
+	// workflow does not know the newly finished/failed instances.
+	cachedUpdate.WriteProgress(
+		ctx,
+		cachedUpdate.GetState().State,
+		instancesDone,
+		instancesFailed,
+		instancesCurrent,
+	)
+
+	if err := cachedJob.RollbackWorkflow(ctx); err != nil {
+		log.WithFields(log.Fields{
+			"update_id": cachedUpdate.ID().GetValue(),
+			"job_id":    cachedJob.ID().GetValue(),
+		}).WithError(err).
+			Info("fail to rollback update")
+		return err
+	}
+
+	cachedConfig, err := cachedJob.GetConfig(ctx)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"update_id": cachedUpdate.ID().GetValue(),
+			"job_id":    cachedJob.ID().GetValue(),
+		}).WithError(err).
+			Info("fail to get job config to rollback update")
+		return err
+	}
+
+	if err := handleUnchangedInstancesInUpdate(
+		ctx,
+		cachedUpdate,
+		cachedJob,
+		cachedConfig,
+	); err != nil {
+		log.WithFields(log.Fields{
+			"update_id": cachedUpdate.ID().GetValue(),
+			"job_id":    cachedJob.ID().GetValue(),
+		}).WithError(err).
+			Info("fail to update unchanged instances to rollback update")
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"update_id": cachedUpdate.ID().GetValue(),
+		"job_id":    cachedJob.ID().GetValue(),
+	}).Info("update rolling back")
+	return nil
+}
+
 // isUpdateRollback returns if an update is a rolling back to a
 // previous version
 func isUpdateRollback(cachedUpdate cached.Update) bool {
@@ -753,9 +804,13 @@ func confirmInstancesStatus(
 }
 
 // getInstancesForUpdateRun returns the instances to update/add in
-// the given call of UpdateRun.
+// the given call of UpdateRun. maxInstancesProcessed limits the number
+// of instances processed by a staged update, including the instances
+// which are done, failed or being updated.
 func getInstancesForUpdateRun(
 	update cached.Update,
+	updateConfig *pbupdate.UpdateConfig,
+	maxInstancesProcessed int,
 	instancesCurrent []uint32,
 	instancesDone []uint32,
 	instancesFailed []uint32,
@@ -769,16 +824,26 @@ func getInstancesForUpdateRun(
 		unprocessedInstancesToUpdate, unprocessedInstancesToRemove := getUnprocessedInstances(
 		update, instancesCurrent, instancesDone, instancesFailed)
 
-	// if batch size is 0 or updateConfig is nil, update all of the instances
-	if update.GetUpdateConfig().GetBatchSize() == 0 {
+	// if batch size is 0 or updateConfig is nil, and the update is not
+	// staged, update all of the instances
+	if updateConfig.GetBatchSize() == 0 &&
+		maxInstancesProcessed == _noStageLimit {
 		return unprocessedInstancesToAdd,
 			unprocessedInstancesToUpdate,
 			unprocessedInstancesToRemove
 	}
 
-	maxNumOfInstancesToProcess :=
-		int(update.GetUpdateConfig().GetBatchSize()) - len(instancesCurrent)
-	// if instances being updated are more than batch size, do not update anything
+	maxNumOfInstancesToProcess := maxInstancesProcessed -
+		len(instancesCurrent) - len(instancesDone) - len(instancesFailed)
+	if updateConfig.GetBatchSize() != 0 {
+		batchLimit := int(updateConfig.GetBatchSize()) - len(instancesCurrent)
+		if maxInstancesProcessed == _noStageLimit ||
+			batchLimit < maxNumOfInstancesToProcess {
+			maxNumOfInstancesToProcess = batchLimit
+		}
+	}
+	// if instances being updated are more than batch size,
+	// or the current stage is fully processed, do not update anything
 	if maxNumOfInstancesToProcess <= 0 {
 		return nil, nil, nil
 	}
@@ -0,0 +1,368 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goalstate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbtask "github.com/uber/peloton/.gen/peloton/api/v0/task"
+	pbupdate "github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+
+	"github.com/uber/peloton/pkg/common/util"
+	versionutil "github.com/uber/peloton/pkg/common/util/entityversion"
+	"github.com/uber/peloton/pkg/jobmgr/cached"
+	"github.com/uber/peloton/pkg/storage"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// _noStageLimit indicates that the number of instances processed
+// by an update is not limited by the stages of its strategy
+const _noStageLimit = -1
+
+// updateStageHealth is the health of the instances updated in a stage
+type updateStageHealth struct {
+	// number of instances checked, removed instances are not checked
+	numInstances int
+	// number of instances which failed the update or are unhealthy
+	numUnhealthy int
+	// the last time an instance of the stage started running
+	lastStartTime time.Time
+}
+
+// runUpdateStrategy applies the strategy of a staged update. It returns
+// the maximum number of instances which may be processed by the update
+// so far, and whether the update has been stopped because the instances
+// of its last finished stage are unhealthy.
+// The instances of an update are processed in a fixed order, so the
+// instances of a stage are the ones between the previous stage and
+// the stage itself in that order.
+// A stage which paused the update is not checked again once the update
+// has been resumed, so that the operator can proceed with the update.
+func runUpdateStrategy(
+	ctx context.Context,
+	cachedJob cached.Job,
+	cachedUpdate cached.Update,
+	strategy *pbupdate.UpdateStrategy,
+	instancesDone []uint32,
+	instancesFailed []uint32,
+	instancesCurrent []uint32,
+	goalStateDriver *driver,
+) (maxInstancesProcessed int, stopped bool, err error) {
+	// rollbacks are not staged, the previous configuration
+	// is restored as fast as possible
+	if len(strategy.GetStages()) == 0 || isUpdateRollback(cachedUpdate) {
+		return _noStageLimit, false, nil
+	}
+
+	instances := append([]uint32{}, cachedUpdate.GetInstancesAdded()...)
+	instances = append(instances, cachedUpdate.GetInstancesUpdated()...)
+	instances = append(instances, cachedUpdate.GetInstancesRemoved()...)
+
+	numProcessed := len(instancesDone) + len(instancesFailed)
+	if numProcessed >= len(instances) {
+		return _noStageLimit, false, nil
+	}
+
+	targets := getUpdateStageTargets(strategy, len(instances))
+	stage := 0
+	for stage < len(targets) && targets[stage] <= numProcessed {
+		stage++
+	}
+
+	maxInstancesProcessed = _noStageLimit
+	if stage < len(targets) {
+		maxInstancesProcessed = targets[stage]
+	}
+
+	// no stage has finished yet, or the next stage has already started
+	if stage == 0 ||
+		len(instancesCurrent) != 0 ||
+		numProcessed != targets[stage-1] {
+		return maxInstancesProcessed, false, nil
+	}
+
+	if strategy.GetBakeTimeSecs() == 0 &&
+		strategy.GetUnhealthyAction() ==
+			pbupdate.UnhealthyAction_UNHEALTHY_ACTION_INVALID {
+		return maxInstancesProcessed, false, nil
+	}
+
+	stageStart := 0
+	for i := stage - 1; i >= 0; i-- {
+		if targets[i] < numProcessed {
+			stageStart = targets[i]
+			break
+		}
+	}
+	stageInstances := instances[stageStart:numProcessed]
+
+	// the stage has already been found unhealthy, and the update has
+	// been resumed by the operator since it was paused
+	if strategy.GetUnhealthyAction() ==
+		pbupdate.UnhealthyAction_UNHEALTHY_ACTION_PAUSE {
+		paused, err := isUpdateStagePaused(
+			ctx,
+			goalStateDriver.updateStore,
+			cachedUpdate.ID(),
+			stageInstances,
+		)
+		if err != nil {
+			return 0, false, err
+		}
+		if paused {
+			return maxInstancesProcessed, false, nil
+		}
+	}
+
+	health, err := getUpdateStageHealth(
+		ctx,
+		cachedJob,
+		stageInstances,
+		instancesFailed,
+		cachedUpdate.GetInstancesRemoved(),
+	)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if strategy.GetUnhealthyAction() !=
+		pbupdate.UnhealthyAction_UNHEALTHY_ACTION_INVALID &&
+		float64(health.numUnhealthy) >
+			strategy.GetMaxUnhealthyRatio()*float64(health.numInstances) {
+		goalStateDriver.mtx.updateMetrics.UpdateStageUnhealthy.Inc(1)
+		message := fmt.Sprintf(
+			"%d of %d instances updated in stage %d are unhealthy, "+
+				"which exceeds the max unhealthy ratio of %.2f",
+			health.numUnhealthy,
+			health.numInstances,
+			stage,
+			strategy.GetMaxUnhealthyRatio(),
+		)
+		return 0, true, processUnhealthyUpdateStage(
+			ctx,
+			cachedJob,
+			cachedUpdate,
+			strategy.GetUnhealthyAction(),
+			message,
+			stageInstances,
+			instancesDone,
+			instancesFailed,
+			instancesCurrent,
+			goalStateDriver,
+		)
+	}
+
+	bakeEndTime := health.lastStartTime.Add(
+		time.Duration(strategy.GetBakeTimeSecs()) * time.Second)
+	if time.Now().Before(bakeEndTime) {
+		goalStateDriver.mtx.updateMetrics.UpdateStageBaking.Inc(1)
+		// run the update again once the stage has baked
+		goalStateDriver.EnqueueUpdate(
+			cachedJob.ID(),
+			cachedUpdate.ID(),
+			bakeEndTime,
+		)
+		return numProcessed, false, nil
+	}
+
+	return maxInstancesProcessed, false, nil
+}
+
+// getUpdateStageTargets returns the number of instances which should
+// have been processed by the end of each stage of an update
+// of numInstances instances.
+func getUpdateStageTargets(
+	strategy *pbupdate.UpdateStrategy,
+	numInstances int,
+) []int {
+	var targets []int
+	prevTarget := 0
+	for _, stage := range strategy.GetStages() {
+		target := int(stage.GetInstanceCount())
+		if stage.GetInstancePercentage() > 0 {
+			target = int(math.Ceil(
+				stage.GetInstancePercentage() * float64(numInstances) / 100))
+		}
+
+		// stages never cover fewer instances than
+		// their previous stage or more than the update
+		if target < prevTarget {
+			target = prevTarget
+		}
+		if target > numInstances {
+			target = numInstances
+		}
+
+		targets = append(targets, target)
+		prevTarget = target
+	}
+	return targets
+}
+
+// getUpdateStageHealth returns the health of the instances of a stage
+func getUpdateStageHealth(
+	ctx context.Context,
+	cachedJob cached.Job,
+	stageInstances []uint32,
+	instancesFailed []uint32,
+	instancesRemoved []uint32,
+) (*updateStageHealth, error) {
+	instancesToCheck := util.SubtractSlice(stageInstances, instancesRemoved)
+	health := &updateStageHealth{
+		numInstances: len(instancesToCheck),
+		numUnhealthy: len(util.IntersectSlice(instancesToCheck, instancesFailed)),
+	}
+
+	for _, instID := range util.SubtractSlice(instancesToCheck, instancesFailed) {
+		cachedTask := cachedJob.GetTask(instID)
+		if cachedTask == nil {
+			continue
+		}
+
+		runtime, err := cachedTask.GetRuntime(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if runtime.GetHealthy() == pbtask.HealthState_UNHEALTHY ||
+			runtime.GetState() == pbtask.TaskState_FAILED ||
+			runtime.GetState() == pbtask.TaskState_LOST {
+			health.numUnhealthy++
+		}
+
+		startTime, err := time.Parse(time.RFC3339Nano, runtime.GetStartTime())
+		if err == nil && startTime.After(health.lastStartTime) {
+			health.lastStartTime = startTime
+		}
+	}
+
+	return health, nil
+}
+
+// isUpdateStagePaused returns whether an update has been paused by its
+// strategy at the end of a stage. The pause is recorded with its reason
+// in the workflow events of the instances of the stage, which are their
+// last events as the instances of the next stage have not started yet.
+func isUpdateStagePaused(
+	ctx context.Context,
+	updateStore storage.UpdateStore,
+	updateID *peloton.UpdateID,
+	stageInstances []uint32,
+) (bool, error) {
+	events, err := updateStore.GetWorkflowEvents(
+		ctx,
+		updateID,
+		stageInstances[len(stageInstances)-1],
+		1,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	// pausing an update through the API does not record a reason
+	return len(events) > 0 &&
+		events[0].GetState() == stateless.WorkflowState_WORKFLOW_STATE_PAUSED &&
+		len(events[0].GetMessage()) > 0, nil
+}
+
+// processUnhealthyUpdateStage pauses or rolls back an update whose
+// last finished stage is unhealthy, and records the reason in the
+// workflow events of the instances of the stage.
+func processUnhealthyUpdateStage(
+	ctx context.Context,
+	cachedJob cached.Job,
+	cachedUpdate cached.Update,
+	action pbupdate.UnhealthyAction,
+	message string,
+	stageInstances []uint32,
+	instancesDone []uint32,
+	instancesFailed []uint32,
+	instancesCurrent []uint32,
+	goalStateDriver *driver,
+) error {
+	var state pbupdate.State
+	switch action {
+	case pbupdate.UnhealthyAction_UNHEALTHY_ACTION_ROLLBACK:
+		if err := rollbackUpdate(
+			ctx,
+			cachedJob,
+			cachedUpdate,
+			instancesDone,
+			instancesFailed,
+			instancesCurrent,
+		); err != nil {
+			return err
+		}
+		state = pbupdate.State_ROLLING_BACKWARD
+
+	case pbupdate.UnhealthyAction_UNHEALTHY_ACTION_PAUSE:
+		// write the progress first, because when pause happens,
+		// workflow does not know the newly finished/failed instances.
+		if err := cachedUpdate.WriteProgress(
+			ctx,
+			cachedUpdate.GetState().State,
+			instancesDone,
+			instancesFailed,
+			instancesCurrent,
+		); err != nil {
+			return err
+		}
+
+		runtime, err := cachedJob.GetRuntime(ctx)
+		if err != nil {
+			return err
+		}
+
+		if _, _, err := cachedJob.PauseWorkflow(
+			ctx,
+			versionutil.GetJobEntityVersion(
+				runtime.GetConfigurationVersion(),
+				runtime.GetDesiredStateVersion(),
+				runtime.GetWorkflowVersion(),
+			),
+		); err != nil {
+			return err
+		}
+		state = pbupdate.State_PAUSED
+	}
+
+	for _, instID := range stageInstances {
+		if err := goalStateDriver.updateStore.AddWorkflowEvent(
+			ctx,
+			cachedUpdate.ID(),
+			instID,
+			cachedUpdate.GetWorkflowType(),
+			state,
+			message,
+		); err != nil {
+			return err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"update_id": cachedUpdate.ID().GetValue(),
+		"job_id":    cachedJob.ID().GetValue(),
+		"action":    action.String(),
+	}).Info(message)
+
+	goalStateDriver.EnqueueUpdate(cachedJob.ID(), cachedUpdate.ID(), time.Now())
+	return nil
+}
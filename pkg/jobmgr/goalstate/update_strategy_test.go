@@ -0,0 +1,523 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goalstate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbtask "github.com/uber/peloton/.gen/peloton/api/v0/task"
+	pbupdate "github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	goalstatemocks "github.com/uber/peloton/pkg/common/goalstate/mocks"
+	"github.com/uber/peloton/pkg/jobmgr/cached"
+	cachedmocks "github.com/uber/peloton/pkg/jobmgr/cached/mocks"
+	storemocks "github.com/uber/peloton/pkg/storage/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+)
+
+type UpdateStrategyTestSuite struct {
+	suite.Suite
+	ctrl                  *gomock.Controller
+	updateGoalStateEngine *goalstatemocks.MockEngine
+	updateStore           *storemocks.MockUpdateStore
+	goalStateDriver       *driver
+	jobID                 *peloton.JobID
+	updateID              *peloton.UpdateID
+	cachedJob             *cachedmocks.MockJob
+	cachedUpdate          *cachedmocks.MockUpdate
+	cachedTask            *cachedmocks.MockTask
+	strategy              *pbupdate.UpdateStrategy
+	instances             []uint32
+}
+
+func TestUpdateStrategy(t *testing.T) {
+	suite.Run(t, new(UpdateStrategyTestSuite))
+}
+
+func (suite *UpdateStrategyTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.updateGoalStateEngine = goalstatemocks.NewMockEngine(suite.ctrl)
+	suite.updateStore = storemocks.NewMockUpdateStore(suite.ctrl)
+	suite.goalStateDriver = &driver{
+		updateEngine: suite.updateGoalStateEngine,
+		updateStore:  suite.updateStore,
+		mtx:          NewMetrics(tally.NoopScope),
+		cfg:          &Config{},
+	}
+	suite.goalStateDriver.cfg.normalize()
+
+	suite.jobID = &peloton.JobID{Value: uuid.NewRandom().String()}
+	suite.updateID = &peloton.UpdateID{Value: uuid.NewRandom().String()}
+	suite.cachedJob = cachedmocks.NewMockJob(suite.ctrl)
+	suite.cachedUpdate = cachedmocks.NewMockUpdate(suite.ctrl)
+	suite.cachedTask = cachedmocks.NewMockTask(suite.ctrl)
+
+	// canary stage of 1 instance, then 50% and 100% of the instances
+	suite.strategy = &pbupdate.UpdateStrategy{
+		Stages: []*pbupdate.UpdateStage{
+			{InstanceCount: 1},
+			{InstancePercentage: 50},
+			{InstancePercentage: 100},
+		},
+		BakeTimeSecs:      60,
+		MaxUnhealthyRatio: 0.2,
+		UnhealthyAction:   pbupdate.UnhealthyAction_UNHEALTHY_ACTION_PAUSE,
+	}
+	suite.instances = []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	suite.cachedJob.EXPECT().
+		ID().
+		Return(suite.jobID).
+		AnyTimes()
+	suite.cachedUpdate.EXPECT().
+		ID().
+		Return(suite.updateID).
+		AnyTimes()
+	suite.cachedUpdate.EXPECT().
+		GetWorkflowType().
+		Return(models.WorkflowType_UPDATE).
+		AnyTimes()
+	suite.cachedUpdate.EXPECT().
+		GetState().
+		Return(&cached.UpdateStateVector{
+			State: pbupdate.State_ROLLING_FORWARD,
+		}).
+		AnyTimes()
+	suite.cachedUpdate.EXPECT().
+		GetInstancesAdded().
+		Return(nil).
+		AnyTimes()
+	suite.cachedUpdate.EXPECT().
+		GetInstancesUpdated().
+		Return(suite.instances).
+		AnyTimes()
+	suite.cachedUpdate.EXPECT().
+		GetInstancesRemoved().
+		Return(nil).
+		AnyTimes()
+}
+
+func (suite *UpdateStrategyTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+// expectTaskRuntime sets up the runtime returned for an instance
+func (suite *UpdateStrategyTestSuite) expectTaskRuntime(
+	instID uint32,
+	healthy pbtask.HealthState,
+	startTime time.Time,
+) {
+	cachedTask := cachedmocks.NewMockTask(suite.ctrl)
+	suite.cachedJob.EXPECT().
+		GetTask(instID).
+		Return(cachedTask)
+	cachedTask.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbtask.RuntimeInfo{
+			State:     pbtask.TaskState_RUNNING,
+			Healthy:   healthy,
+			StartTime: startTime.UTC().Format(time.RFC3339Nano),
+		}, nil)
+}
+
+// expectStageEvents sets up the last workflow event of the last
+// instance of a finished stage
+func (suite *UpdateStrategyTestSuite) expectStageEvents(
+	instID uint32,
+	events ...*stateless.WorkflowEvent,
+) {
+	suite.updateStore.EXPECT().
+		GetWorkflowEvents(gomock.Any(), suite.updateID, instID, uint32(1)).
+		Return(events, nil)
+}
+
+// TestGetUpdateStageTargets tests computing the number of
+// instances covered by each stage of an update
+func (suite *UpdateStrategyTestSuite) TestGetUpdateStageTargets() {
+	strategy := &pbupdate.UpdateStrategy{
+		Stages: []*pbupdate.UpdateStage{
+			{InstanceCount: 1},
+			{InstancePercentage: 5},
+			{InstancePercentage: 25},
+			{InstanceCount: 5},
+			{InstanceCount: 100},
+		},
+	}
+
+	suite.Equal([]int{1, 2, 10, 10, 40}, getUpdateStageTargets(strategy, 40))
+	suite.Equal([]int{1, 1, 1, 1, 1}, getUpdateStageTargets(strategy, 1))
+}
+
+// TestRunUpdateStrategyNotStaged tests that an update without
+// stages is not limited
+func (suite *UpdateStrategyTestSuite) TestRunUpdateStrategyNotStaged() {
+	maxInstancesProcessed, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		suite.cachedUpdate,
+		nil,
+		nil,
+		nil,
+		nil,
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.False(stopped)
+	suite.Equal(_noStageLimit, maxInstancesProcessed)
+}
+
+// TestRunUpdateStrategyInStage tests that an update in the middle
+// of a stage is limited to the instances of the stage
+func (suite *UpdateStrategyTestSuite) TestRunUpdateStrategyInStage() {
+	maxInstancesProcessed, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		suite.cachedUpdate,
+		suite.strategy,
+		[]uint32{0, 1},
+		nil,
+		[]uint32{2},
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.False(stopped)
+	suite.Equal(5, maxInstancesProcessed)
+}
+
+// TestRunUpdateStrategyBaking tests that the next stage does not
+// start before the instances of the finished stage have baked
+func (suite *UpdateStrategyTestSuite) TestRunUpdateStrategyBaking() {
+	suite.expectStageEvents(0)
+	suite.expectTaskRuntime(0, pbtask.HealthState_HEALTHY, time.Now())
+	suite.updateGoalStateEngine.EXPECT().
+		Enqueue(gomock.Any(), gomock.Any()).
+		Do(func(_ interface{}, deadline time.Time) {
+			suite.True(deadline.After(time.Now().Add(50 * time.Second)))
+		})
+
+	maxInstancesProcessed, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		suite.cachedUpdate,
+		suite.strategy,
+		[]uint32{0},
+		nil,
+		nil,
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.False(stopped)
+	suite.Equal(1, maxInstancesProcessed)
+}
+
+// TestRunUpdateStrategyBaked tests that the next stage starts once
+// the instances of the finished stage have baked and are healthy
+func (suite *UpdateStrategyTestSuite) TestRunUpdateStrategyBaked() {
+	startTime := time.Now().Add(-2 * time.Minute)
+	suite.expectStageEvents(4)
+	for _, instID := range []uint32{1, 2, 3, 4} {
+		suite.expectTaskRuntime(instID, pbtask.HealthState_HEALTHY, startTime)
+	}
+
+	maxInstancesProcessed, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		suite.cachedUpdate,
+		suite.strategy,
+		[]uint32{0, 1, 2, 3, 4},
+		nil,
+		nil,
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.False(stopped)
+	suite.Equal(10, maxInstancesProcessed)
+}
+
+// TestRunUpdateStrategyUnhealthyPause tests that the update is paused
+// when too many instances of the finished stage are unhealthy
+func (suite *UpdateStrategyTestSuite) TestRunUpdateStrategyUnhealthyPause() {
+	instancesDone := []uint32{0, 1, 2, 3}
+	instancesFailed := []uint32{4}
+	startTime := time.Now().Add(-2 * time.Minute)
+	suite.expectStageEvents(4)
+	suite.expectTaskRuntime(1, pbtask.HealthState_HEALTHY, startTime)
+	suite.expectTaskRuntime(2, pbtask.HealthState_UNHEALTHY, startTime)
+	suite.expectTaskRuntime(3, pbtask.HealthState_HEALTHY, startTime)
+
+	gomock.InOrder(
+		suite.cachedUpdate.EXPECT().
+			WriteProgress(
+				gomock.Any(),
+				pbupdate.State_ROLLING_FORWARD,
+				instancesDone,
+				instancesFailed,
+				nil,
+			).Return(nil),
+		suite.cachedJob.EXPECT().
+			GetRuntime(gomock.Any()).
+			Return(&pbjob.RuntimeInfo{
+				ConfigurationVersion: 2,
+				DesiredStateVersion:  1,
+				WorkflowVersion:      3,
+			}, nil),
+		suite.cachedJob.EXPECT().
+			PauseWorkflow(gomock.Any(), gomock.Any()).
+			Return(suite.updateID, nil, nil),
+	)
+
+	for _, instID := range []uint32{1, 2, 3, 4} {
+		suite.updateStore.EXPECT().
+			AddWorkflowEvent(
+				gomock.Any(),
+				suite.updateID,
+				instID,
+				models.WorkflowType_UPDATE,
+				pbupdate.State_PAUSED,
+				"2 of 4 instances updated in stage 2 are unhealthy, "+
+					"which exceeds the max unhealthy ratio of 0.20",
+			).Return(nil)
+	}
+
+	suite.updateGoalStateEngine.EXPECT().
+		Enqueue(gomock.Any(), gomock.Any())
+
+	_, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		suite.cachedUpdate,
+		suite.strategy,
+		instancesDone,
+		instancesFailed,
+		nil,
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.True(stopped)
+}
+
+// TestRunUpdateStrategyUnhealthyPauseResume tests that the next stage
+// starts once an update paused by its strategy has been resumed
+func (suite *UpdateStrategyTestSuite) TestRunUpdateStrategyUnhealthyPauseResume() {
+	instancesDone := []uint32{0, 1, 2, 3}
+	instancesFailed := []uint32{4}
+	startTime := time.Now().Add(-2 * time.Minute)
+	suite.expectTaskRuntime(1, pbtask.HealthState_HEALTHY, startTime)
+	suite.expectTaskRuntime(2, pbtask.HealthState_UNHEALTHY, startTime)
+	suite.expectTaskRuntime(3, pbtask.HealthState_HEALTHY, startTime)
+
+	// the workflow event recorded by the pause is read back
+	// when the update runs again after being resumed
+	pauseEvent := &stateless.WorkflowEvent{}
+	gomock.InOrder(
+		suite.updateStore.EXPECT().
+			GetWorkflowEvents(gomock.Any(), suite.updateID, uint32(4), uint32(1)).
+			Return(nil, nil),
+		suite.updateStore.EXPECT().
+			GetWorkflowEvents(gomock.Any(), suite.updateID, uint32(4), uint32(1)).
+			Return([]*stateless.WorkflowEvent{pauseEvent}, nil),
+	)
+
+	suite.cachedUpdate.EXPECT().
+		WriteProgress(
+			gomock.Any(),
+			pbupdate.State_ROLLING_FORWARD,
+			instancesDone,
+			instancesFailed,
+			nil,
+		).Return(nil)
+	suite.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{}, nil)
+	suite.cachedJob.EXPECT().
+		PauseWorkflow(gomock.Any(), gomock.Any()).
+		Return(suite.updateID, nil, nil)
+	suite.updateStore.EXPECT().
+		AddWorkflowEvent(
+			gomock.Any(),
+			suite.updateID,
+			gomock.Any(),
+			models.WorkflowType_UPDATE,
+			pbupdate.State_PAUSED,
+			gomock.Any(),
+		).
+		Do(func(
+			_ context.Context,
+			_ *peloton.UpdateID,
+			_ uint32,
+			_ models.WorkflowType,
+			_ pbupdate.State,
+			message string,
+		) {
+			pauseEvent.State = stateless.WorkflowState_WORKFLOW_STATE_PAUSED
+			pauseEvent.Message = message
+		}).
+		Return(nil).
+		Times(4)
+	suite.updateGoalStateEngine.EXPECT().
+		Enqueue(gomock.Any(), gomock.Any())
+
+	_, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		suite.cachedUpdate,
+		suite.strategy,
+		instancesDone,
+		instancesFailed,
+		nil,
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.True(stopped)
+
+	// the update only runs again once it has been resumed, the stage
+	// is not checked again and the instances of the next stage start
+	maxInstancesProcessed, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		suite.cachedUpdate,
+		suite.strategy,
+		instancesDone,
+		instancesFailed,
+		nil,
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.False(stopped)
+	suite.Equal(10, maxInstancesProcessed)
+}
+
+// TestRunUpdateStrategyUserPause tests that a stage is checked again
+// after the update has been paused through the API at its end
+func (suite *UpdateStrategyTestSuite) TestRunUpdateStrategyUserPause() {
+	startTime := time.Now().Add(-2 * time.Minute)
+	suite.expectStageEvents(4, &stateless.WorkflowEvent{
+		State: stateless.WorkflowState_WORKFLOW_STATE_PAUSED,
+	})
+	for _, instID := range []uint32{1, 2, 3, 4} {
+		suite.expectTaskRuntime(instID, pbtask.HealthState_HEALTHY, startTime)
+	}
+
+	maxInstancesProcessed, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		suite.cachedUpdate,
+		suite.strategy,
+		[]uint32{0, 1, 2, 3, 4},
+		nil,
+		nil,
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.False(stopped)
+	suite.Equal(10, maxInstancesProcessed)
+}
+
+// TestRunUpdateStrategyUnhealthyRollback tests that the update is rolled
+// back when too many instances of the finished stage are unhealthy
+func (suite *UpdateStrategyTestSuite) TestRunUpdateStrategyUnhealthyRollback() {
+	suite.strategy.UnhealthyAction =
+		pbupdate.UnhealthyAction_UNHEALTHY_ACTION_ROLLBACK
+	instancesDone := []uint32{0}
+	suite.expectTaskRuntime(0, pbtask.HealthState_UNHEALTHY, time.Now())
+
+	gomock.InOrder(
+		suite.cachedUpdate.EXPECT().
+			WriteProgress(
+				gomock.Any(),
+				pbupdate.State_ROLLING_FORWARD,
+				instancesDone,
+				nil,
+				nil,
+			).Return(nil),
+		suite.cachedJob.EXPECT().
+			RollbackWorkflow(gomock.Any()).
+			Return(nil),
+		suite.cachedJob.EXPECT().
+			GetConfig(gomock.Any()).
+			Return(&pbjob.JobConfig{
+				InstanceCount: uint32(len(suite.instances)),
+			}, nil),
+	)
+
+	suite.cachedUpdate.EXPECT().
+		GetGoalState().
+		Return(&cached.UpdateStateVector{
+			Instances: suite.instances,
+		})
+
+	suite.updateStore.EXPECT().
+		AddWorkflowEvent(
+			gomock.Any(),
+			suite.updateID,
+			uint32(0),
+			models.WorkflowType_UPDATE,
+			pbupdate.State_ROLLING_BACKWARD,
+			gomock.Any(),
+		).Return(nil)
+
+	suite.updateGoalStateEngine.EXPECT().
+		Enqueue(gomock.Any(), gomock.Any())
+
+	_, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		suite.cachedUpdate,
+		suite.strategy,
+		instancesDone,
+		nil,
+		nil,
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.True(stopped)
+}
+
+// TestRunUpdateStrategyRollback tests that a rollback is not staged
+func (suite *UpdateStrategyTestSuite) TestRunUpdateStrategyRollback() {
+	cachedUpdate := cachedmocks.NewMockUpdate(suite.ctrl)
+	cachedUpdate.EXPECT().
+		GetWorkflowType().
+		Return(models.WorkflowType_UPDATE)
+	cachedUpdate.EXPECT().
+		GetState().
+		Return(&cached.UpdateStateVector{
+			State: pbupdate.State_ROLLING_BACKWARD,
+		})
+
+	maxInstancesProcessed, stopped, err := runUpdateStrategy(
+		context.Background(),
+		suite.cachedJob,
+		cachedUpdate,
+		suite.strategy,
+		[]uint32{0},
+		nil,
+		nil,
+		suite.goalStateDriver,
+	)
+	suite.NoError(err)
+	suite.False(stopped)
+	suite.Equal(_noStageLimit, maxInstancesProcessed)
+}
@@ -22,6 +22,7 @@ import (
 	mesos "github.com/uber/peloton/.gen/mesos/v1"
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/api/v0/update"
 
 	"github.com/uber/peloton/pkg/common/taskconfig"
 
@@ -66,20 +67,54 @@ var (
 		"can't override the preemption policy of a task" +
 			" which is going to be a part of a gang having tasks with" +
 			" a different preemption policy")
+	errDaemonInstanceCount = yarpcerrors.InvalidArgumentErrorf(
+		"daemon job should not set InstanceCount or InstanceConfig")
+	errAutoscalingJobType = yarpcerrors.InvalidArgumentErrorf(
+		"autoscaling policy is only supported for service jobs")
+	errAutoscalingMetric = yarpcerrors.InvalidArgumentErrorf(
+		"autoscaling policy should set the metric and a positive target value")
 
 	_jobTypeTaskValidate = map[job.JobType]func(*task.TaskConfig) error{
 		job.JobType_BATCH:   validateBatchTaskConfig,
 		job.JobType_SERVICE: validateStatelessTaskConfig,
+		job.JobType_DAEMON:  validateStatelessTaskConfig,
 	}
 
 	_jobTypeJobValidate = map[job.JobType]func(*job.JobConfig) error{
 		job.JobType_BATCH:   validateBatchJobConfig,
 		job.JobType_SERVICE: validateStatelessJobConfig,
+		job.JobType_DAEMON:  validateStatelessJobConfig,
 	}
 )
 
 // ValidateConfig validates the job and instance specific configs
 func ValidateConfig(jobConfig *job.JobConfig, maxTasksPerJob uint32) error {
+	// the instances of a daemon job are added by jobmgr, one for each
+	// host matching the job constraint, so a new daemon job has none.
+	if jobConfig.GetType() == job.JobType_DAEMON &&
+		(jobConfig.GetInstanceCount() != 0 ||
+			len(jobConfig.GetInstanceConfig()) != 0) {
+		return errDaemonInstanceCount
+	}
+
+	// the default config is the config of every daemon instance
+	if jobConfig.GetType() == job.JobType_DAEMON {
+		if jobConfig.GetDefaultConfig().GetCommand() == nil {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"missing command info for daemon job")
+		}
+		if err := validateStatelessTaskConfig(
+			jobConfig.GetDefaultConfig()); err != nil {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"Invalid default config, %v", err)
+		}
+	}
+
+	if err := validateAutoscalingPolicy(
+		jobConfig, maxTasksPerJob); err != nil {
+		return err
+	}
+
 	return validateTaskConfigWithRange(
 		jobConfig,
 		maxTasksPerJob,
@@ -87,6 +122,58 @@ func ValidateConfig(jobConfig *job.JobConfig, maxTasksPerJob uint32) error {
 	)
 }
 
+// validateAutoscalingPolicy validates the autoscaling policy of the job,
+// if any
+func validateAutoscalingPolicy(
+	jobConfig *job.JobConfig,
+	maxTasksPerJob uint32) error {
+	policy := jobConfig.GetAutoscalingPolicy()
+	if policy == nil {
+		return nil
+	}
+
+	if jobConfig.GetType() != job.JobType_SERVICE {
+		return errAutoscalingJobType
+	}
+
+	if len(policy.GetMetric()) == 0 || policy.GetTargetValue() <= 0 {
+		return errAutoscalingMetric
+	}
+
+	if policy.GetMinInstances() == 0 ||
+		policy.GetMinInstances() > policy.GetMaxInstances() ||
+		policy.GetMaxInstances() > maxTasksPerJob {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"autoscaling policy should have 0 < min instances: %v <= "+
+				"max instances: %v <= %v",
+			policy.GetMinInstances(),
+			policy.GetMaxInstances(),
+			maxTasksPerJob)
+	}
+	return nil
+}
+
+// KeepAutoscaledInstanceCount sets the instance count of the new config
+// of an autoscaled job to its current instance count, bounded by the
+// autoscaling policy of the new config, so that replacing the job does
+// not undo the scaling done by the autoscaler
+func KeepAutoscaledInstanceCount(
+	prevConfig *job.JobConfig,
+	newConfig *job.JobConfig) {
+	policy := newConfig.GetAutoscalingPolicy()
+	if policy == nil || prevConfig.GetAutoscalingPolicy() == nil {
+		return
+	}
+
+	newConfig.InstanceCount = prevConfig.GetInstanceCount()
+	if newConfig.InstanceCount < policy.GetMinInstances() {
+		newConfig.InstanceCount = policy.GetMinInstances()
+	}
+	if newConfig.InstanceCount > policy.GetMaxInstances() {
+		newConfig.InstanceCount = policy.GetMaxInstances()
+	}
+}
+
 // ValidateUpdatedConfig validates the changes in the new config
 func ValidateUpdatedConfig(oldConfig *job.JobConfig,
 	newConfig *job.JobConfig,
@@ -160,6 +247,52 @@ func ValidateUpdatedConfig(oldConfig *job.JobConfig,
 	return errs.ErrorOrNil()
 }
 
+// ValidateUpdateConfig validates the strategy of an update config,
+// the update specs of the v1alpha API are validated once converted
+func ValidateUpdateConfig(updateConfig *update.UpdateConfig) error {
+	strategy := updateConfig.GetStrategy()
+	if strategy == nil {
+		return nil
+	}
+
+	if strategy.GetMaxUnhealthyRatio() < 0 ||
+		strategy.GetMaxUnhealthyRatio() > 1 {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"max unhealthy ratio must be between 0 and 1")
+	}
+
+	var prevPercentage float64
+	var prevCount uint32
+	for i, stage := range strategy.GetStages() {
+		percentage := stage.GetInstancePercentage()
+		if percentage < 0 || percentage > 100 {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"instance percentage of update stage %d must be between 0 and 100", i)
+		}
+
+		if percentage == 0 && stage.GetInstanceCount() == 0 {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"update stage %d does not cover any instance", i)
+		}
+
+		// stages which use the same unit must not decrease,
+		// the mix of both units can only be checked at run time
+		if percentage < prevPercentage ||
+			(percentage == 0 && stage.GetInstanceCount() < prevCount) {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"update stage %d covers fewer instances than the previous stage", i)
+		}
+
+		if percentage > 0 {
+			prevPercentage = percentage
+		} else {
+			prevCount = stage.GetInstanceCount()
+		}
+	}
+
+	return nil
+}
+
 // validateTaskConfigWithRange validates jobConfig with instancesNumber within [from, to)
 func validateTaskConfigWithRange(jobConfig *job.JobConfig, maxTasksPerJob uint32, from uint32, to uint32) error {
 
@@ -132,6 +132,134 @@ func TestValidateTaskConfigFailureStateless(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestValidateDaemonJobConfig(t *testing.T) {
+	defaultConfig := &task.TaskConfig{
+		Command: &mesos.CommandInfo{
+			Value: util.PtrPrintf("echo Hello"),
+		},
+	}
+
+	jobConfig := job.JobConfig{
+		Name:          fmt.Sprintf("TestJob_1"),
+		Type:          job.JobType_DAEMON,
+		DefaultConfig: defaultConfig,
+	}
+	assert.NoError(t, ValidateConfig(&jobConfig, maxTasksPerJob))
+
+	// daemon job can't set the instance count
+	jobConfig.InstanceCount = 1
+	assert.Equal(t, errDaemonInstanceCount,
+		ValidateConfig(&jobConfig, maxTasksPerJob))
+
+	// daemon job can't set instance configs
+	jobConfig.InstanceCount = 0
+	jobConfig.InstanceConfig = map[uint32]*task.TaskConfig{0: defaultConfig}
+	assert.Equal(t, errDaemonInstanceCount,
+		ValidateConfig(&jobConfig, maxTasksPerJob))
+
+	// daemon job needs a default command
+	jobConfig.InstanceConfig = nil
+	jobConfig.DefaultConfig = &task.TaskConfig{}
+	assert.Error(t, ValidateConfig(&jobConfig, maxTasksPerJob))
+
+	// daemon job follows the stateless SLA restrictions
+	jobConfig.DefaultConfig = defaultConfig
+	jobConfig.SLA = &job.SlaConfig{MaxRunningTime: 1}
+	assert.Equal(t, errIncorrectMaxRunningTimeSLA,
+		ValidateConfig(&jobConfig, maxTasksPerJob))
+}
+
+func TestValidateAutoscalingPolicy(t *testing.T) {
+	jobConfig := job.JobConfig{
+		Name:          fmt.Sprintf("TestJob_1"),
+		Type:          job.JobType_SERVICE,
+		InstanceCount: 2,
+		DefaultConfig: &task.TaskConfig{
+			Command: &mesos.CommandInfo{
+				Value: util.PtrPrintf("echo Hello"),
+			},
+		},
+		AutoscalingPolicy: &job.AutoscalingPolicy{
+			MinInstances: 1,
+			MaxInstances: 10,
+			Metric:       "cpu_utilization",
+			TargetValue:  0.6,
+		},
+	}
+	assert.NoError(t, ValidateConfig(&jobConfig, maxTasksPerJob))
+
+	// the policy needs a target value
+	jobConfig.AutoscalingPolicy.TargetValue = 0
+	assert.Equal(t, errAutoscalingMetric,
+		ValidateConfig(&jobConfig, maxTasksPerJob))
+	jobConfig.AutoscalingPolicy.TargetValue = 0.6
+
+	// min instances can't be larger than max instances
+	jobConfig.AutoscalingPolicy.MinInstances = 11
+	assert.Error(t, ValidateConfig(&jobConfig, maxTasksPerJob))
+
+	// min instances can't be 0
+	jobConfig.AutoscalingPolicy.MinInstances = 0
+	assert.Error(t, ValidateConfig(&jobConfig, maxTasksPerJob))
+	jobConfig.AutoscalingPolicy.MinInstances = 1
+
+	// max instances can't exceed the tasks per job
+	assert.Error(t, ValidateConfig(&jobConfig, 5))
+
+	// batch jobs can't be autoscaled
+	jobConfig.Type = job.JobType_BATCH
+	assert.Equal(t, errAutoscalingJobType,
+		ValidateConfig(&jobConfig, maxTasksPerJob))
+}
+
+// TestKeepAutoscaledInstanceCount tests that the instance count of an
+// autoscaled job is kept when it is replaced
+func TestKeepAutoscaledInstanceCount(t *testing.T) {
+	policy := &job.AutoscalingPolicy{
+		MinInstances: 2,
+		MaxInstances: 10,
+		Metric:       "cpu_utilization",
+		TargetValue:  0.6,
+	}
+	prevConfig := &job.JobConfig{
+		InstanceCount:     8,
+		AutoscalingPolicy: policy,
+	}
+
+	// the job was not autoscaled
+	newConfig := &job.JobConfig{
+		InstanceCount:     3,
+		AutoscalingPolicy: policy,
+	}
+	KeepAutoscaledInstanceCount(&job.JobConfig{InstanceCount: 8}, newConfig)
+	assert.Equal(t, uint32(3), newConfig.GetInstanceCount())
+
+	// the job is no longer autoscaled
+	newConfig = &job.JobConfig{InstanceCount: 3}
+	KeepAutoscaledInstanceCount(prevConfig, newConfig)
+	assert.Equal(t, uint32(3), newConfig.GetInstanceCount())
+
+	newConfig = &job.JobConfig{
+		InstanceCount:     3,
+		AutoscalingPolicy: policy,
+	}
+	KeepAutoscaledInstanceCount(prevConfig, newConfig)
+	assert.Equal(t, uint32(8), newConfig.GetInstanceCount())
+
+	// the instance count is bounded by the new policy
+	newConfig = &job.JobConfig{
+		InstanceCount: 3,
+		AutoscalingPolicy: &job.AutoscalingPolicy{
+			MinInstances: 2,
+			MaxInstances: 5,
+			Metric:       "cpu_utilization",
+			TargetValue:  0.6,
+		},
+	}
+	KeepAutoscaledInstanceCount(prevConfig, newConfig)
+	assert.Equal(t, uint32(5), newConfig.GetInstanceCount())
+}
+
 func TestValidateTaskConfigFailureBatch(t *testing.T) {
 	jobConfig := job.JobConfig{
 		Name:          fmt.Sprintf("TestJob_1"),
@@ -494,14 +622,14 @@ func TestValidateInvalidUpdateConfigJobType(t *testing.T) {
 	oldConfig := getConfig(oldConfig, t)
 
 	invalidNewConfig := getConfig(newConfig, t)
-	invalidNewConfig.Type = job.JobType_DAEMON
+	invalidNewConfig.Type = job.JobType(100)
 
 	err := ValidateUpdatedConfig(oldConfig, invalidNewConfig, maxTasksPerJob)
 	assert.Error(t, err)
 	expectedErrors := `2 errors occurred:
 
 * updating Type not supported
-* code:invalid-argument message:invalid job type: DAEMON`
+* code:invalid-argument message:invalid job type: 100`
 	assert.Equal(t, err.Error(), expectedErrors)
 }
 
@@ -32,6 +32,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 
 	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/audit"
 	"github.com/uber/peloton/pkg/common/leader"
 	"github.com/uber/peloton/pkg/common/util"
 	versionutil "github.com/uber/peloton/pkg/common/util/entityversion"
@@ -61,7 +62,8 @@ var (
 		"resource pool")
 )
 
-// InitServiceHandler initializes the job manager
+// InitServiceHandler initializes the job manager and returns the handler,
+// which is also used by the cron scheduler to create the runs of cron jobs
 func InitServiceHandler(
 	d *yarpc.Dispatcher,
 	parent tally.Scope,
@@ -72,7 +74,7 @@ func InitServiceHandler(
 	goalStateDriver goalstate.Driver,
 	candidate leader.Candidate,
 	clientName string,
-	jobSvcCfg Config) {
+	jobSvcCfg Config) job.JobManagerYARPCServer {
 
 	jobSvcCfg.normalize()
 	handler := &serviceHandler{
@@ -92,6 +94,7 @@ func InitServiceHandler(
 	}
 
 	d.Register(job.BuildJobManagerYARPCProcedures(handler))
+	return handler
 }
 
 // serviceHandler implements peloton.api.job.JobManager
@@ -158,6 +161,7 @@ func (h *serviceHandler) Create(
 	if jobID == nil || len(jobID.GetValue()) == 0 {
 		jobID = &peloton.JobID{Value: uuid.New()}
 	}
+	audit.AddJobID(ctx, jobID.GetValue())
 
 	if uuid.Parse(jobID.GetValue()) == nil {
 		log.WithField("job_id", jobID.GetValue()).Warn("JobID is not valid UUID")
@@ -187,6 +191,15 @@ func (h *serviceHandler) Create(
 		}, nil
 	}
 
+	if err := handler.AuthorizeJobCreation(
+		ctx,
+		h.respoolClient,
+		jobConfig.GetRespoolID(),
+	); err != nil {
+		h.metrics.JobCreateFail.Inc(1)
+		return nil, err
+	}
+
 	// Validate job config with default task configs
 	err = jobconfig.ValidateConfig(jobConfig, h.jobSvcCfg.MaxTasksPerJob)
 	if err != nil {
@@ -281,6 +294,8 @@ func (h *serviceHandler) Update(
 			Info("JobManager.Update succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetId().GetValue())
+
 	h.metrics.JobAPIUpdate.Inc(1)
 
 	if !h.candidate.IsLeader() {
@@ -333,6 +348,20 @@ func (h *serviceHandler) Update(
 		newConfig.RespoolID = oldConfig.GetRespoolID()
 	}
 
+	// the caller must be permitted on the job before and after the
+	// update, so that a job cannot be moved to another owner
+	for _, c := range []*job.JobConfig{oldConfig, newConfig} {
+		if err := handler.AuthorizeJobConfig(
+			ctx,
+			h.respoolClient,
+			c,
+			oldConfigAddOn,
+		); err != nil {
+			h.metrics.JobUpdateFail.Inc(1)
+			return nil, err
+		}
+	}
+
 	// Remove the existing secret volumes from the config. These were added by
 	// peloton at the time of secret creation. We will add them to new config
 	// after validating the new config at the time of handling secrets. If we
@@ -517,6 +546,8 @@ func (h *serviceHandler) Refresh(ctx context.Context, req *job.RefreshRequest) (
 			Debug("JobManager.Refresh succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetId().GetValue())
+
 	h.metrics.JobAPIRefresh.Inc(1)
 
 	if !h.candidate.IsLeader() {
@@ -545,6 +576,16 @@ func (h *serviceHandler) Refresh(ctx context.Context, req *job.RefreshRequest) (
 		return &job.RefreshResponse{}, yarpcerrors.NotFoundErrorf("job not found")
 	}
 
+	if err := handler.AuthorizeJobConfig(
+		ctx,
+		h.respoolClient,
+		jobConfig,
+		configAddOn,
+	); err != nil {
+		h.metrics.JobRefreshFail.Inc(1)
+		return &job.RefreshResponse{}, err
+	}
+
 	// Update cache and enqueue job into goal state
 	cachedJob := h.jobFactory.AddJob(req.GetId())
 	cachedJob.Update(ctx, &job.JobInfo{
@@ -643,6 +684,8 @@ func (h *serviceHandler) Delete(
 			Info("JobManager.Delete succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetId().GetValue())
+
 	h.metrics.JobAPIDelete.Inc(1)
 
 	jobRuntime, err := handler.GetJobRuntimeWithoutFillingCache(
@@ -661,6 +704,16 @@ func (h *serviceHandler) Delete(
 			fmt.Sprintf("Job is not in a terminal state: %s", jobRuntime.State))
 	}
 
+	if err := handler.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		req.GetId().GetValue(),
+	); err != nil {
+		h.metrics.JobDeleteFail.Inc(1)
+		return nil, err
+	}
+
 	// Delete job from DB
 	if err := h.jobStore.DeleteJob(ctx, req.GetId().GetValue()); err != nil {
 		h.metrics.JobDeleteFail.Inc(1)
@@ -710,6 +763,8 @@ func (h *serviceHandler) Restart(
 			Info("JobManager.Restart succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetId().GetValue())
+
 	h.metrics.JobAPIRestart.Inc(1)
 
 	updateID, resourceVersion, err := h.createNonUpdateWorkflow(
@@ -753,6 +808,8 @@ func (h *serviceHandler) Start(
 			Info("JobManager.Start succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetId().GetValue())
+
 	h.metrics.JobAPIStart.Inc(1)
 
 	updateID, resourceVersion, err := h.createNonUpdateWorkflow(
@@ -796,6 +853,8 @@ func (h *serviceHandler) Stop(
 			Info("JobManager.Stop succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetId().GetValue())
+
 	h.metrics.JobAPIStop.Inc(1)
 
 	updateID, resourceVersion, err := h.createNonUpdateWorkflow(
@@ -858,6 +917,15 @@ func (h *serviceHandler) createNonUpdateWorkflow(
 		return nil, 0, err
 	}
 
+	if err := handler.AuthorizeJobConfig(
+		ctx,
+		h.respoolClient,
+		jobConfig,
+		configAddOn,
+	); err != nil {
+		return nil, 0, err
+	}
+
 	if jobConfig.GetType() != job.JobType_SERVICE {
 		return nil, 0, yarpcerrors.InvalidArgumentErrorf(
 			"%s supported only for service jobs", workflowType.String())
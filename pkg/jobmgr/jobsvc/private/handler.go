@@ -41,6 +41,18 @@ import (
 	"go.uber.org/yarpc/yarpcerrors"
 )
 
+const (
+	// _defaultAuditQueryRange is the time range of audit
+	// queries which do not specify a start time
+	_defaultAuditQueryRange = 24 * time.Hour
+	// _maxAuditQueryRange bounds the number of days
+	// read by an audit query
+	_maxAuditQueryRange = 31 * 24 * time.Hour
+	// _defaultAuditQueryLimit is the maximum number of events
+	// returned by audit queries which do not specify a limit
+	_defaultAuditQueryLimit = 100
+)
+
 type serviceHandler struct {
 	jobStore        storage.JobStore
 	updateStore     storage.UpdateStore
@@ -48,6 +60,7 @@ type serviceHandler struct {
 	jobIndexOps     ormobjects.JobIndexOps
 	jobConfigOps    ormobjects.JobConfigOps
 	jobNameToIDOps  ormobjects.JobNameToIDOps
+	auditEventOps   ormobjects.AuditEventOps
 	jobFactory      cached.JobFactory
 	goalStateDriver goalstate.Driver
 	candidate       leader.Candidate
@@ -73,6 +86,7 @@ func InitPrivateJobServiceHandler(
 		jobIndexOps:     ormobjects.NewJobIndexOps(ormStore),
 		jobConfigOps:    ormobjects.NewJobConfigOps(ormStore),
 		jobNameToIDOps:  ormobjects.NewJobNameToIDOps(ormStore),
+		auditEventOps:   ormobjects.NewAuditEventOps(ormStore),
 		jobFactory:      jobFactory,
 		goalStateDriver: goalStateDriver,
 		candidate:       candidate,
@@ -299,6 +313,75 @@ func (h *serviceHandler) QueryJobCache(
 	return &jobmgrsvc.QueryJobCacheResponse{Result: result}, nil
 }
 
+// QueryAuditEvents queries the audit log of the calls to mutating procedures
+func (h *serviceHandler) QueryAuditEvents(
+	ctx context.Context,
+	req *jobmgrsvc.QueryAuditEventsRequest,
+) (resp *jobmgrsvc.QueryAuditEventsResponse, err error) {
+	defer func() {
+		headers := yarpcutil.GetHeaders(ctx)
+		if err != nil {
+			log.WithField("request", req).
+				WithField("headers", headers).
+				WithError(err).
+				Warn("JobSVC.QueryAuditEvents failed")
+			err = yarpcutil.ConvertToYARPCError(err)
+			return
+		}
+
+		log.WithField("request", req).
+			WithField("num_of_results", len(resp.GetEvents())).
+			WithField("headers", headers).
+			Debug("JobSVC.QueryAuditEvents succeeded")
+	}()
+
+	endTime := time.Now().UTC()
+	if len(req.GetEndTime()) != 0 {
+		if endTime, err = time.Parse(time.RFC3339, req.GetEndTime()); err != nil {
+			return nil, yarpcerrors.InvalidArgumentErrorf(
+				"invalid end time %s", req.GetEndTime())
+		}
+	}
+
+	startTime := endTime.Add(-_defaultAuditQueryRange)
+	if len(req.GetStartTime()) != 0 {
+		if startTime, err = time.Parse(time.RFC3339, req.GetStartTime()); err != nil {
+			return nil, yarpcerrors.InvalidArgumentErrorf(
+				"invalid start time %s", req.GetStartTime())
+		}
+	}
+
+	if startTime.After(endTime) {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"start time is after end time")
+	}
+	if endTime.Sub(startTime) > _maxAuditQueryRange {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"time range cannot exceed %v", _maxAuditQueryRange)
+	}
+
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = _defaultAuditQueryLimit
+	}
+
+	events, err := h.auditEventOps.Query(ctx, &ormobjects.AuditEventFilter{
+		User:      req.GetUser(),
+		Procedure: req.GetProcedure(),
+		JobID:     req.GetJobId(),
+		PodName:   req.GetPodName(),
+		RespoolID: req.GetRespoolId(),
+		StartTime: startTime,
+		EndTime:   endTime,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to query audit events")
+	}
+
+	return &jobmgrsvc.QueryAuditEventsResponse{Events: events}, nil
+}
+
 // nameMatch returns true if queryName not set, or jobName
 // and queryName are the same
 func nameMatch(jobName string, queryName string) bool {
@@ -16,9 +16,11 @@ package private
 
 import (
 	"context"
+	"errors"
 	"github.com/uber/peloton/pkg/jobmgr/util/handler"
 	"strconv"
 	"testing"
+	"time"
 
 	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
@@ -31,6 +33,7 @@ import (
 
 	"github.com/uber/peloton/pkg/common"
 	"github.com/uber/peloton/pkg/jobmgr/cached"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 
 	leadermocks "github.com/uber/peloton/pkg/common/leader/mocks"
 	cachedmocks "github.com/uber/peloton/pkg/jobmgr/cached/mocks"
@@ -69,6 +72,7 @@ type privateHandlerTestSuite struct {
 	taskStore       *storemocks.MockTaskStore
 	jobIndexOps     *objectmocks.MockJobIndexOps
 	jobConfigOps    *objectmocks.MockJobConfigOps
+	auditEventOps   *objectmocks.MockAuditEventOps
 }
 
 func (suite *privateHandlerTestSuite) SetupTest() {
@@ -83,6 +87,7 @@ func (suite *privateHandlerTestSuite) SetupTest() {
 	suite.taskStore = storemocks.NewMockTaskStore(suite.ctrl)
 	suite.jobIndexOps = objectmocks.NewMockJobIndexOps(suite.ctrl)
 	suite.jobConfigOps = objectmocks.NewMockJobConfigOps(suite.ctrl)
+	suite.auditEventOps = objectmocks.NewMockAuditEventOps(suite.ctrl)
 	suite.handler = &serviceHandler{
 		jobFactory:      suite.jobFactory,
 		candidate:       suite.candidate,
@@ -92,6 +97,7 @@ func (suite *privateHandlerTestSuite) SetupTest() {
 		taskStore:       suite.taskStore,
 		jobIndexOps:     suite.jobIndexOps,
 		jobConfigOps:    suite.jobConfigOps,
+		auditEventOps:   suite.auditEventOps,
 		rootCtx:         context.Background(),
 	}
 }
@@ -565,3 +571,89 @@ func (suite *privateHandlerTestSuite) TestQueryJobCacheGoalStateEngineNotStarted
 	suite.Nil(result)
 	suite.Error(err)
 }
+
+// TestQueryAuditEvents tests querying the audit log
+func (suite *privateHandlerTestSuite) TestQueryAuditEvents() {
+	events := []*models.AuditEvent{
+		{
+			EventId:   "event1",
+			User:      "alice",
+			Procedure: "peloton.api.v1alpha.job.stateless.svc.JobService::StopJob",
+			JobIds:    []string{testJobID},
+			Outcome:   "ok",
+		},
+	}
+
+	// the time range and the limit default to the last day and 100 events
+	suite.auditEventOps.EXPECT().
+		Query(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, filter *ormobjects.AuditEventFilter) {
+			suite.Equal("alice", filter.User)
+			suite.Equal(testJobID, filter.JobID)
+			suite.Equal(24*time.Hour, filter.EndTime.Sub(filter.StartTime))
+			suite.Equal(100, filter.Limit)
+		}).
+		Return(events, nil)
+
+	resp, err := suite.handler.QueryAuditEvents(
+		context.Background(),
+		&jobmgrsvc.QueryAuditEventsRequest{
+			User:  "alice",
+			JobId: testJobID,
+		},
+	)
+	suite.NoError(err)
+	suite.Equal(events, resp.GetEvents())
+
+	suite.auditEventOps.EXPECT().
+		Query(gomock.Any(), &ormobjects.AuditEventFilter{
+			Procedure: "StopJob",
+			StartTime: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+			Limit:     10,
+		}).
+		Return(nil, nil)
+
+	resp, err = suite.handler.QueryAuditEvents(
+		context.Background(),
+		&jobmgrsvc.QueryAuditEventsRequest{
+			Procedure: "StopJob",
+			StartTime: "2019-01-01T00:00:00Z",
+			EndTime:   "2019-01-03T00:00:00Z",
+			Limit:     10,
+		},
+	)
+	suite.NoError(err)
+	suite.Empty(resp.GetEvents())
+}
+
+// TestQueryAuditEventsFailure tests the failures to query the audit log
+func (suite *privateHandlerTestSuite) TestQueryAuditEventsFailure() {
+	requests := []*jobmgrsvc.QueryAuditEventsRequest{
+		{StartTime: "yesterday"},
+		{EndTime: "today"},
+		{
+			StartTime: "2019-01-03T00:00:00Z",
+			EndTime:   "2019-01-01T00:00:00Z",
+		},
+		{
+			StartTime: "2019-01-01T00:00:00Z",
+			EndTime:   "2019-03-01T00:00:00Z",
+		},
+	}
+
+	for _, req := range requests {
+		_, err := suite.handler.QueryAuditEvents(context.Background(), req)
+		suite.True(yarpcerrors.IsInvalidArgument(err))
+	}
+
+	suite.auditEventOps.EXPECT().
+		Query(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("test error"))
+
+	_, err := suite.handler.QueryAuditEvents(
+		context.Background(),
+		&jobmgrsvc.QueryAuditEventsRequest{},
+	)
+	suite.Error(err)
+}
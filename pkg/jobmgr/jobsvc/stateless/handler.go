@@ -32,6 +32,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 	v1alphaquery "github.com/uber/peloton/.gen/peloton/api/v1alpha/query"
 	"github.com/uber/peloton/.gen/peloton/private/models"
+	"github.com/uber/peloton/pkg/common/audit"
 	"github.com/uber/peloton/pkg/common/concurrency"
 
 	"github.com/uber/peloton/pkg/common"
@@ -161,6 +162,7 @@ func (h *serviceHandler) CreateJob(
 	if len(pelotonJobID.GetValue()) == 0 {
 		pelotonJobID = &peloton.JobID{Value: uuid.New()}
 	}
+	audit.AddJobID(ctx, pelotonJobID.GetValue())
 
 	if uuid.Parse(pelotonJobID.GetValue()) == nil {
 		return nil, yarpcerrors.InvalidArgumentErrorf("jobID is not valid UUID")
@@ -178,6 +180,14 @@ func (h *serviceHandler) CreateJob(
 		return nil, errors.Wrap(err, "failed to convert job spec")
 	}
 
+	if err := handlerutil.AuthorizeJobCreation(
+		ctx,
+		h.respoolClient,
+		jobConfig.GetRespoolID(),
+	); err != nil {
+		return nil, err
+	}
+
 	// Validate job config with default task configs
 	err = jobconfig.ValidateConfig(
 		jobConfig,
@@ -275,6 +285,8 @@ func (h *serviceHandler) ReplaceJob(
 			Info("JobSVC.ReplaceJob succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil,
 			yarpcerrors.UnavailableErrorf("JobSVC.ReplaceJob is not supported on non-leader")
@@ -287,6 +299,12 @@ func (h *serviceHandler) ReplaceJob(
 			"JobID must be of UUID format")
 	}
 
+	if err := jobconfig.ValidateUpdateConfig(
+		handlerutil.ConvertUpdateSpecToUpdateConfig(req.GetUpdateSpec()),
+	); err != nil {
+		return nil, err
+	}
+
 	jobConfig, err := handlerutil.ConvertJobSpecToJobConfig(req.GetSpec())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert job spec")
@@ -316,9 +334,23 @@ func (h *serviceHandler) ReplaceJob(
 		return nil, errors.Wrap(err, "failed to get previous job spec")
 	}
 
+	// the caller must be permitted on the job before and after the
+	// replace, so that a job cannot be moved to another owner
+	for _, config := range []*pbjob.JobConfig{prevJobConfig, jobConfig} {
+		if err := handlerutil.AuthorizeJobConfig(
+			ctx,
+			h.respoolClient,
+			config,
+			prevConfigAddOn,
+		); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := validateJobConfigUpdate(prevJobConfig, jobConfig); err != nil {
 		return nil, errors.Wrap(err, "failed to validate spec update")
 	}
+	jobconfig.KeepAutoscaledInstanceCount(prevJobConfig, jobConfig)
 
 	// get the new configAddOn
 	var respoolPath string
@@ -397,6 +429,8 @@ func (h *serviceHandler) RestartJob(
 			Info("JobSVC.RestartJob succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil, yarpcerrors.UnavailableErrorf("JobSVC.RestartJob is not supported on non-leader")
 	}
@@ -417,6 +451,15 @@ func (h *serviceHandler) RestartJob(
 		return nil, errors.Wrap(err, "fail to get job config")
 	}
 
+	if err := handlerutil.AuthorizeJobConfig(
+		ctx,
+		h.respoolClient,
+		jobConfig,
+		configAddOn,
+	); err != nil {
+		return nil, err
+	}
+
 	// copy the config with provided resource version number
 	newConfig := *jobConfig
 	now := time.Now()
@@ -499,6 +542,17 @@ func (h *serviceHandler) PauseJobWorkflow(
 			Info("JobSVC.PauseJobWorkflow succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		req.GetJobId().GetValue(),
+	); err != nil {
+		return nil, err
+	}
+
 	cachedJob := h.jobFactory.AddJob(&peloton.JobID{Value: req.GetJobId().GetValue()})
 	opaque := cached.WithOpaqueData(nil)
 	if req.GetOpaqueData() != nil {
@@ -544,10 +598,21 @@ func (h *serviceHandler) ResumeJobWorkflow(
 			Info("JobSVC.ResumeJobWorkflow succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil, yarpcerrors.UnavailableErrorf("JobSVC.ResumeJobWorkflow is not supported on non-leader")
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		req.GetJobId().GetValue(),
+	); err != nil {
+		return nil, err
+	}
+
 	cachedJob := h.jobFactory.AddJob(&peloton.JobID{Value: req.GetJobId().GetValue()})
 	opaque := cached.WithOpaqueData(nil)
 	if req.GetOpaqueData() != nil {
@@ -593,10 +658,21 @@ func (h *serviceHandler) AbortJobWorkflow(
 			Info("JobSVC.AbortJobWorkflow succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil, yarpcerrors.UnavailableErrorf("JobSVC.AbortJobWorkflow is not supported on non-leader")
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		req.GetJobId().GetValue(),
+	); err != nil {
+		return nil, err
+	}
+
 	cachedJob := h.jobFactory.AddJob(&peloton.JobID{Value: req.GetJobId().GetValue()})
 	opaque := cached.WithOpaqueData(nil)
 	if req.GetOpaqueData() != nil {
@@ -644,10 +720,21 @@ func (h *serviceHandler) StartJob(
 			Info("JobSVC.StartJob succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil, yarpcerrors.UnavailableErrorf("JobSVC.StartJob is not supported on non-leader")
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		req.GetJobId().GetValue(),
+	); err != nil {
+		return nil, err
+	}
+
 	pelotonJobID := &peloton.JobID{Value: req.GetJobId().GetValue()}
 
 	var jobRuntime *pbjob.RuntimeInfo
@@ -719,10 +806,21 @@ func (h *serviceHandler) StopJob(
 			Info("JobSVC.StopJob succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil, yarpcerrors.UnavailableErrorf("JobSVC.StopJob is not supported on non-leader")
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		req.GetJobId().GetValue(),
+	); err != nil {
+		return nil, err
+	}
+
 	cachedJob := h.jobFactory.AddJob(&peloton.JobID{
 		Value: req.GetJobId().GetValue(),
 	})
@@ -804,10 +902,21 @@ func (h *serviceHandler) DeleteJob(
 			Info("JobSVC.DeleteJob succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil, yarpcerrors.UnavailableErrorf("JobSVC.DeleteJob is not supported on non-leader")
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		req.GetJobId().GetValue(),
+	); err != nil {
+		return nil, err
+	}
+
 	cachedJob := h.jobFactory.AddJob(&peloton.JobID{
 		Value: req.GetJobId().GetValue(),
 	})
@@ -1534,6 +1643,7 @@ func (h *serviceHandler) GetReplaceJobDiff(
 	if err := validateJobConfigUpdate(prevJobConfig, jobConfig); err != nil {
 		return nil, err
 	}
+	jobconfig.KeepAutoscaledInstanceCount(prevJobConfig, jobConfig)
 
 	added, updated, removed, unchanged, err :=
 		cached.GetInstancesToProcessForUpdate(
@@ -1575,11 +1685,22 @@ func (h *serviceHandler) RefreshJob(
 			Info("JobSVC.RefreshJob succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil,
 			yarpcerrors.UnavailableErrorf("JobSVC.RefreshJob is not supported on non-leader")
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		req.GetJobId().GetValue(),
+	); err != nil {
+		return nil, err
+	}
+
 	pelotonJobID := &peloton.JobID{Value: req.GetJobId().GetValue()}
 
 	jobRuntime, err := h.jobStore.GetJobRuntime(ctx, req.GetJobId().GetValue())
@@ -38,6 +38,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/private/models"
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 
+	"github.com/uber/peloton/pkg/auth"
 	"github.com/uber/peloton/pkg/common"
 	"github.com/uber/peloton/pkg/common/util"
 	versionutil "github.com/uber/peloton/pkg/common/util/entityversion"
@@ -48,6 +49,7 @@ import (
 	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 
 	respoolmocks "github.com/uber/peloton/.gen/peloton/api/v0/respool/mocks"
+	authmocks "github.com/uber/peloton/pkg/auth/mocks"
 	leadermocks "github.com/uber/peloton/pkg/common/leader/mocks"
 	cachedmocks "github.com/uber/peloton/pkg/jobmgr/cached/mocks"
 	goalstatemocks "github.com/uber/peloton/pkg/jobmgr/goalstate/mocks"
@@ -1253,6 +1255,39 @@ func (suite *statelessHandlerTestSuite) TestReplaceJobFailNonLeader() {
 	suite.Error(err)
 }
 
+// TestReplaceJobInvalidUpdateStrategy tests the failure case of replacing
+// job due to an invalid update strategy
+func (suite *statelessHandlerTestSuite) TestReplaceJobInvalidUpdateStrategy() {
+	strategies := []*stateless.UpdateStrategy{
+		{MaxUnhealthyRatio: 1.5},
+		{Stages: []*stateless.UpdateStage{{PodPercentage: 120}}},
+		{Stages: []*stateless.UpdateStage{{}}},
+		{Stages: []*stateless.UpdateStage{{PodCount: 5}, {PodCount: 1}}},
+		{Stages: []*stateless.UpdateStage{{PodPercentage: 50}, {PodPercentage: 5}}},
+	}
+
+	for _, strategy := range strategies {
+		suite.candidate.EXPECT().
+			IsLeader().
+			Return(true)
+
+		resp, err := suite.handler.ReplaceJob(
+			context.Background(),
+			&statelesssvc.ReplaceJobRequest{
+				JobId:   &v1alphapeloton.JobID{Value: testJobID},
+				Version: &v1alphapeloton.EntityVersion{Value: testEntityVersion},
+				Spec:    &stateless.JobSpec{},
+				UpdateSpec: &stateless.UpdateSpec{
+					BatchSize: 1,
+					Strategy:  strategy,
+				},
+			},
+		)
+		suite.True(yarpcerrors.IsInvalidArgument(err))
+		suite.Nil(resp)
+	}
+}
+
 // TestReplaceJobGetJobConfigFailure tests the failure case of replacing job
 // due to not able to get job config
 func (suite *statelessHandlerTestSuite) TestReplaceJobGetJobConfigFailure() {
@@ -2883,6 +2918,43 @@ func (suite *statelessHandlerTestSuite) TestStopJobSuccess() {
 		versionutil.GetJobEntityVersion(testConfigurationVersion, testDesiredStateVersion+1, testWorkflowVersion))
 }
 
+// TestStopJobPermissionDeniedFailure tests the failure case of
+// stopping a job which the caller is not permitted to stop
+func (suite *statelessHandlerTestSuite) TestStopJobPermissionDeniedFailure() {
+	user := authmocks.NewMockUser(suite.ctrl)
+	ctx := auth.ContextWithUser(context.Background(), user)
+
+	suite.candidate.EXPECT().IsLeader().Return(true)
+
+	suite.jobStore.EXPECT().
+		GetJobConfig(gomock.Any(), testJobID).
+		Return(&pbjob.JobConfig{
+			OwningTeam: "web",
+			LdapGroups: []string{"web"},
+		}, &models.ConfigAddOn{
+			SystemLabels: []*peloton.Label{
+				{Key: common.SystemLabelResourcePool, Value: "/web/prod"},
+			},
+		}, nil)
+
+	user.EXPECT().
+		IsPermittedOnResource(gomock.Any(), &auth.Resource{
+			RespoolPath: "/web/prod",
+			OwningTeam:  "web",
+			LdapGroups:  []string{"web"},
+		}).
+		Return(false)
+
+	resp, err := suite.handler.StopJob(
+		ctx,
+		&statelesssvc.StopJobRequest{
+			JobId:   &v1alphapeloton.JobID{Value: testJobID},
+			Version: versionutil.GetJobEntityVersion(testConfigurationVersion, testDesiredStateVersion, testWorkflowVersion),
+		})
+	suite.True(yarpcerrors.IsPermissionDenied(err))
+	suite.Nil(resp)
+}
+
 // TestStopJobNonLeaderFailure tests the failure case of stop
 // a job due to jobmgr is not leader
 func (suite *statelessHandlerTestSuite) TestStopJobNonLeaderFailure() {
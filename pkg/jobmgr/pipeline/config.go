@@ -0,0 +1,42 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import "time"
+
+const (
+	_defaultEvaluatePeriod  = 10 * time.Second
+	_defaultEvaluateTimeout = 30 * time.Second
+)
+
+// Config for the pipeline engine
+type Config struct {
+	// Period at which the states of the batch jobs of the active
+	// pipelines are checked to start their downstream batch jobs
+	EvaluatePeriod time.Duration `yaml:"evaluate_period"`
+
+	// Timeout of a single evaluation of all active pipelines
+	EvaluateTimeout time.Duration `yaml:"evaluate_timeout"`
+}
+
+func (c *Config) normalize() {
+	if c.EvaluatePeriod == time.Duration(0) {
+		c.EvaluatePeriod = _defaultEvaluatePeriod
+	}
+
+	if c.EvaluateTimeout == time.Duration(0) {
+		c.EvaluateTimeout = _defaultEvaluateTimeout
+	}
+}
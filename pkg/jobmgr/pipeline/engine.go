@@ -0,0 +1,637 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbpipeline "github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+
+	"github.com/uber/peloton/pkg/auth"
+	"github.com/uber/peloton/pkg/common/background"
+	"github.com/uber/peloton/pkg/common/recovery"
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/jobmgr/cached"
+	"github.com/uber/peloton/pkg/jobmgr/goalstate"
+	jobutil "github.com/uber/peloton/pkg/jobmgr/util/job"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	"github.com/gocql/gocql"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/atomic"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_pipelineEngineName = "pipelineEngine"
+
+	// PipelineLabelKey is the key of the label added to every batch job
+	// created by a pipeline, its value is the ID of the pipeline
+	PipelineLabelKey = "peloton.pipeline"
+
+	// PipelineNodeLabelKey is the key of the label added to every batch
+	// job created by a pipeline, its value is the name of the node
+	PipelineNodeLabelKey = "peloton.pipeline_node"
+)
+
+// Engine runs the pipelines of batch jobs. It starts the batch jobs of
+// the nodes of a pipeline once their upstream batch jobs have terminated,
+// and skips the nodes whose upstream conditions can no longer be met.
+type Engine interface {
+	// Register registers the engine in background.Manager, so that
+	// it only runs on the leader
+	Register(manager background.Manager) error
+
+	// Create creates a new pipeline and starts the batch jobs of the
+	// nodes without upstream nodes.
+	Create(
+		ctx context.Context,
+		spec *pbpipeline.PipelineSpec,
+	) (*peloton.PipelineID, error)
+
+	// Kill kills the running batch jobs of a pipeline, the nodes which
+	// have not been started yet are never started.
+	Kill(ctx context.Context, id *peloton.PipelineID) error
+}
+
+// engine implements Engine
+type engine struct {
+	sync.Mutex
+
+	pipelineOps     ormobjects.PipelineOps
+	jobRuntimeOps   ormobjects.JobRuntimeOps
+	jobFactory      cached.JobFactory
+	goalStateDriver goalstate.Driver
+	jobCreator      jobutil.JobCreator
+	metrics         *Metrics
+	scope           tally.Scope
+	config          *Config
+}
+
+// NewEngine creates a new pipeline Engine
+func NewEngine(
+	pipelineOps ormobjects.PipelineOps,
+	jobRuntimeOps ormobjects.JobRuntimeOps,
+	jobFactory cached.JobFactory,
+	goalStateDriver goalstate.Driver,
+	jobCreator jobutil.JobCreator,
+	parent tally.Scope,
+	config *Config,
+) Engine {
+	if config == nil {
+		config = &Config{}
+	}
+	config.normalize()
+
+	return &engine{
+		pipelineOps:     pipelineOps,
+		jobRuntimeOps:   jobRuntimeOps,
+		jobFactory:      jobFactory,
+		goalStateDriver: goalStateDriver,
+		jobCreator:      jobCreator,
+		metrics:         NewMetrics(parent),
+		scope:           parent.SubScope("pipeline"),
+		config:          config,
+	}
+}
+
+// Register registers the engine in background.Manager
+func (e *engine) Register(manager background.Manager) error {
+	return manager.RegisterWorks(
+		background.Work{
+			Name: _pipelineEngineName,
+			Func: func(_ *atomic.Bool) {
+				e.run()
+			},
+			Period: e.config.EvaluatePeriod,
+		},
+	)
+}
+
+// run evaluates all the pipelines which have not terminated yet. The
+// pipelines are read from DB on every run, so that a new leader picks
+// them up without any other recovery.
+func (e *engine) run() {
+	stopWatch := e.metrics.EvaluateDuration.Start()
+	defer stopWatch.Stop()
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), e.config.EvaluateTimeout)
+	defer cancel()
+
+	e.Lock()
+	defer e.Unlock()
+
+	var active int
+	err := recovery.RecoverActivePipelines(
+		ctx,
+		e.scope,
+		e.pipelineOps,
+		func(ctx context.Context, info *pbpipeline.PipelineInfo) error {
+			active++
+			if err := e.evaluate(ctx, info); err != nil {
+				log.WithError(err).
+					WithField("pipeline_id", info.GetId().GetValue()).
+					Warn("failed to evaluate pipeline")
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		e.metrics.GetPipelinesFail.Inc(1)
+		log.WithError(err).Warn("failed to get active pipelines")
+		return
+	}
+	e.metrics.ActivePipelines.Update(float64(active))
+}
+
+// Create creates a new pipeline
+func (e *engine) Create(
+	ctx context.Context,
+	spec *pbpipeline.PipelineSpec,
+) (*peloton.PipelineID, error) {
+	if err := ValidateSpec(spec); err != nil {
+		return nil, err
+	}
+
+	id := &peloton.PipelineID{Value: uuid.New()}
+	status := &pbpipeline.PipelineStatus{
+		State:        pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		GoalState:    pbpipeline.PipelineState_PIPELINE_STATE_SUCCEEDED,
+		CreationTime: formatTime(time.Now()),
+	}
+	if user, ok := auth.UserFromContext(ctx); ok {
+		status.CreatedBy = user.Name()
+	}
+	for _, n := range spec.GetNodes() {
+		status.Nodes = append(status.Nodes, &pbpipeline.NodeStatus{
+			Name:  n.GetName(),
+			State: pbpipeline.NodeState_NODE_STATE_PENDING,
+		})
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	if err := e.pipelineOps.Create(ctx, id, spec, status); err != nil {
+		return nil, err
+	}
+	e.metrics.PipelinesCreated.Inc(1)
+
+	// the pipeline is evaluated again on the next run if its root
+	// nodes cannot be started right away
+	info := &pbpipeline.PipelineInfo{Id: id, Spec: spec, Status: status}
+	if err := e.evaluate(ctx, info); err != nil {
+		log.WithError(err).
+			WithField("pipeline_id", id.GetValue()).
+			Warn("failed to evaluate created pipeline")
+	}
+	return id, nil
+}
+
+// Kill kills a pipeline
+func (e *engine) Kill(ctx context.Context, id *peloton.PipelineID) error {
+	e.Lock()
+	defer e.Unlock()
+
+	info, err := e.pipelineOps.Get(ctx, id)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return yarpcerrors.NotFoundErrorf(
+				"pipeline %s not found", id.GetValue())
+		}
+		return err
+	}
+	if util.IsPipelineStateTerminal(info.GetStatus().GetState()) {
+		return nil
+	}
+
+	if info.GetStatus().GetGoalState() !=
+		pbpipeline.PipelineState_PIPELINE_STATE_KILLED {
+		status := proto.Clone(info.GetStatus()).(*pbpipeline.PipelineStatus)
+		status.GoalState = pbpipeline.PipelineState_PIPELINE_STATE_KILLED
+		if err := e.pipelineOps.UpdateStatus(ctx, id, status); err != nil {
+			e.metrics.UpdateFail.Inc(1)
+			return err
+		}
+		info.Status = status
+	}
+
+	return e.evaluate(ctx, info)
+}
+
+// evaluate updates the states of the nodes of a pipeline from the states
+// of their batch jobs, starts the nodes whose upstream conditions are met
+// and persists the status of the pipeline if it has changed. Starting
+// the nodes again after failing to persist the status is a no-op as
+// their job IDs are deterministic.
+func (e *engine) evaluate(
+	ctx context.Context,
+	info *pbpipeline.PipelineInfo,
+) error {
+	status := proto.Clone(info.GetStatus()).(*pbpipeline.PipelineStatus)
+	g := newGraph(info.GetSpec())
+
+	nodes := make(map[string]*pbpipeline.NodeStatus)
+	for _, n := range status.GetNodes() {
+		nodes[n.GetName()] = n
+	}
+
+	changed, err := e.updateRunningNodes(ctx, status)
+	if err != nil {
+		return err
+	}
+
+	killed := status.GetGoalState() ==
+		pbpipeline.PipelineState_PIPELINE_STATE_KILLED
+	if killed {
+		if e.killNodes(ctx, status) {
+			changed = true
+		}
+	} else {
+		// starting or skipping a node may unblock its downstream nodes,
+		// so iterate until no node changes anymore
+		for {
+			progress, err := e.advanceNodes(ctx, info, status, g, nodes)
+			if progress {
+				changed = true
+			}
+			if err != nil {
+				if changed {
+					e.persist(ctx, info, status)
+				}
+				return err
+			}
+			if !progress {
+				break
+			}
+		}
+	}
+
+	if state, done := pipelineState(status, g, killed); done {
+		status.State = state
+		status.CompletionTime = formatTime(time.Now())
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	if err := e.persist(ctx, info, status); err != nil {
+		return err
+	}
+
+	if util.IsPipelineStateTerminal(status.GetState()) {
+		e.complete(ctx, info)
+	}
+	return nil
+}
+
+// persist writes the status of a pipeline to DB and replaces the status
+// of the pipeline info
+func (e *engine) persist(
+	ctx context.Context,
+	info *pbpipeline.PipelineInfo,
+	status *pbpipeline.PipelineStatus,
+) error {
+	if err := e.pipelineOps.UpdateStatus(
+		ctx, info.GetId(), status); err != nil {
+		e.metrics.UpdateFail.Inc(1)
+		return err
+	}
+	info.Status = status
+	return nil
+}
+
+// complete removes a terminated pipeline from the active pipelines
+func (e *engine) complete(ctx context.Context, info *pbpipeline.PipelineInfo) {
+	switch info.GetStatus().GetState() {
+	case pbpipeline.PipelineState_PIPELINE_STATE_SUCCEEDED:
+		e.metrics.PipelinesSucceeded.Inc(1)
+	case pbpipeline.PipelineState_PIPELINE_STATE_FAILED:
+		e.metrics.PipelinesFailed.Inc(1)
+	case pbpipeline.PipelineState_PIPELINE_STATE_KILLED:
+		e.metrics.PipelinesKilled.Inc(1)
+	}
+
+	log.WithField("pipeline_id", info.GetId().GetValue()).
+		WithField("state", info.GetStatus().GetState().String()).
+		Info("pipeline terminated")
+
+	// the pipeline is removed from the active pipelines on the next
+	// run if the delete fails
+	if err := e.pipelineOps.DeleteActive(ctx, info.GetId()); err != nil {
+		log.WithError(err).
+			WithField("pipeline_id", info.GetId().GetValue()).
+			Warn("failed to delete pipeline from active pipelines")
+	}
+}
+
+// updateRunningNodes updates the state of the running nodes from the
+// state of their batch jobs
+func (e *engine) updateRunningNodes(
+	ctx context.Context,
+	status *pbpipeline.PipelineStatus,
+) (bool, error) {
+	changed := false
+	for _, n := range status.GetNodes() {
+		if n.GetState() != pbpipeline.NodeState_NODE_STATE_RUNNING {
+			continue
+		}
+
+		state, err := e.getJobState(ctx, n.GetJobId())
+		if err != nil {
+			return changed, err
+		}
+		if !util.IsPelotonJobStateTerminal(state) {
+			continue
+		}
+
+		switch state {
+		case pbjob.JobState_SUCCEEDED:
+			n.State = pbpipeline.NodeState_NODE_STATE_SUCCEEDED
+			e.metrics.NodesSucceeded.Inc(1)
+		case pbjob.JobState_FAILED:
+			n.State = pbpipeline.NodeState_NODE_STATE_FAILED
+			e.metrics.NodesFailed.Inc(1)
+		default:
+			n.State = pbpipeline.NodeState_NODE_STATE_KILLED
+			e.metrics.NodesKilled.Inc(1)
+		}
+		n.CompletionTime = formatTime(time.Now())
+		changed = true
+	}
+	return changed, nil
+}
+
+// killNodes kills the batch jobs of the running nodes and marks the
+// pending nodes as killed
+func (e *engine) killNodes(
+	ctx context.Context,
+	status *pbpipeline.PipelineStatus,
+) bool {
+	changed := false
+	for _, n := range status.GetNodes() {
+		switch n.GetState() {
+		case pbpipeline.NodeState_NODE_STATE_PENDING:
+			n.State = pbpipeline.NodeState_NODE_STATE_KILLED
+			n.CompletionTime = formatTime(time.Now())
+			e.metrics.NodesKilled.Inc(1)
+			changed = true
+
+		case pbpipeline.NodeState_NODE_STATE_RUNNING:
+			// the node is marked as killed once its batch job has
+			// terminated
+			if err := jobutil.KillBatchJob(
+				ctx, e.jobFactory, e.goalStateDriver, n.GetJobId()); err != nil {
+				e.metrics.KillNodeFail.Inc(1)
+				log.WithError(err).
+					WithField("job_id", n.GetJobId().GetValue()).
+					Warn("failed to kill pipeline node")
+			}
+		}
+	}
+	return changed
+}
+
+// advanceNodes starts the pending nodes whose upstream conditions are
+// met, and skips the ones whose upstream conditions can no longer be met
+func (e *engine) advanceNodes(
+	ctx context.Context,
+	info *pbpipeline.PipelineInfo,
+	status *pbpipeline.PipelineStatus,
+	g *graph,
+	nodes map[string]*pbpipeline.NodeStatus,
+) (bool, error) {
+	changed := false
+	for _, node := range info.GetSpec().GetNodes() {
+		n := nodes[node.GetName()]
+		if n.GetState() != pbpipeline.NodeState_NODE_STATE_PENDING {
+			continue
+		}
+
+		ready, skip := checkUpstream(g.upstream[node.GetName()], nodes)
+		if skip {
+			n.State = pbpipeline.NodeState_NODE_STATE_SKIPPED
+			n.CompletionTime = formatTime(time.Now())
+			e.metrics.NodesSkipped.Inc(1)
+			changed = true
+			continue
+		}
+		if !ready {
+			continue
+		}
+
+		if err := e.startNode(ctx, info, node, n); err != nil {
+			e.metrics.StartNodeFail.Inc(1)
+			return changed, errors.Wrapf(
+				err, "failed to start node %s", node.GetName())
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// checkUpstream checks the incoming edges of a pending node. It returns
+// ready if the conditions of all the edges are met, and skip if the
+// condition of one of the edges can no longer be met.
+func checkUpstream(
+	edges []*pbpipeline.Edge,
+	nodes map[string]*pbpipeline.NodeStatus,
+) (ready bool, skip bool) {
+	ready = true
+	for _, e := range edges {
+		state := nodes[e.GetFrom()].GetState()
+		if !isNodeStateTerminal(state) {
+			ready = false
+			continue
+		}
+
+		var expected pbpipeline.NodeState
+		switch e.GetCondition() {
+		case pbpipeline.EdgeCondition_EDGE_CONDITION_ON_SUCCESS:
+			expected = pbpipeline.NodeState_NODE_STATE_SUCCEEDED
+		case pbpipeline.EdgeCondition_EDGE_CONDITION_ON_FAILURE:
+			expected = pbpipeline.NodeState_NODE_STATE_FAILED
+		}
+		if state != expected {
+			return false, true
+		}
+	}
+	return ready, false
+}
+
+// pipelineState returns the terminal state of a pipeline once all its
+// nodes have terminated. A pipeline fails if one of its nodes has failed
+// or has been killed, unless the failure is handled by an ON_FAILURE
+// edge.
+func pipelineState(
+	status *pbpipeline.PipelineStatus,
+	g *graph,
+	killed bool,
+) (pbpipeline.PipelineState, bool) {
+	failed := false
+	for _, n := range status.GetNodes() {
+		if !isNodeStateTerminal(n.GetState()) {
+			return status.GetState(), false
+		}
+
+		switch n.GetState() {
+		case pbpipeline.NodeState_NODE_STATE_FAILED:
+			if !hasFailureHandler(g.downstream[n.GetName()]) {
+				failed = true
+			}
+		case pbpipeline.NodeState_NODE_STATE_KILLED:
+			failed = true
+		}
+	}
+
+	switch {
+	case killed:
+		return pbpipeline.PipelineState_PIPELINE_STATE_KILLED, true
+	case failed:
+		return pbpipeline.PipelineState_PIPELINE_STATE_FAILED, true
+	default:
+		return pbpipeline.PipelineState_PIPELINE_STATE_SUCCEEDED, true
+	}
+}
+
+// hasFailureHandler returns true if one of the edges is an ON_FAILURE edge
+func hasFailureHandler(edges []*pbpipeline.Edge) bool {
+	for _, e := range edges {
+		if e.GetCondition() == pbpipeline.EdgeCondition_EDGE_CONDITION_ON_FAILURE {
+			return true
+		}
+	}
+	return false
+}
+
+// isNodeStateTerminal returns true if the node will not change state
+func isNodeStateTerminal(state pbpipeline.NodeState) bool {
+	switch state {
+	case pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_FAILED,
+		pbpipeline.NodeState_NODE_STATE_KILLED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED:
+		return true
+	default:
+		return false
+	}
+}
+
+// startNode creates the batch job of a node. The ID of the batch job is
+// derived from the pipeline ID and the node name, so that the node is not
+// started twice if the status of the pipeline could not be persisted. The
+// node stays pending and is started again on the next evaluation if the
+// batch job could not be created.
+func (e *engine) startNode(
+	ctx context.Context,
+	info *pbpipeline.PipelineInfo,
+	node *pbpipeline.Node,
+	n *pbpipeline.NodeStatus,
+) error {
+	config := proto.Clone(node.GetConfig()).(*pbjob.JobConfig)
+	if len(config.GetName()) == 0 {
+		config.Name = fmt.Sprintf(
+			"%s-%s", info.GetSpec().GetName(), node.GetName())
+	}
+	config.Labels = append(config.Labels,
+		&peloton.Label{
+			Key:   PipelineLabelKey,
+			Value: info.GetId().GetValue(),
+		},
+		&peloton.Label{
+			Key:   PipelineNodeLabelKey,
+			Value: node.GetName(),
+		},
+	)
+
+	jobID := newNodeJobID(info.GetId(), node.GetName())
+	respErr, err := jobutil.CreateBatchJob(
+		ctx, e.jobCreator, e.jobFactory, jobID, config)
+	if err != nil {
+		return err
+	}
+
+	if respErr != nil {
+		// the batch job is invalid and will never be created
+		n.State = pbpipeline.NodeState_NODE_STATE_FAILED
+		n.CompletionTime = formatTime(time.Now())
+		e.metrics.NodesFailed.Inc(1)
+
+		log.WithField("pipeline_id", info.GetId().GetValue()).
+			WithField("node", node.GetName()).
+			WithField("error", respErr.String()).
+			Warn("failed to create batch job of pipeline node")
+		return nil
+	}
+
+	// the batch job may also have been created by a previous evaluation
+	n.State = pbpipeline.NodeState_NODE_STATE_RUNNING
+	n.JobId = jobID
+	n.StartTime = formatTime(time.Now())
+	e.metrics.NodesStarted.Inc(1)
+
+	log.WithField("pipeline_id", info.GetId().GetValue()).
+		WithField("node", node.GetName()).
+		WithField("job_id", jobID.GetValue()).
+		Info("started pipeline node")
+	return nil
+}
+
+// getJobState returns the state of a batch job, from the cache if the
+// job is still tracked or from DB otherwise
+func (e *engine) getJobState(
+	ctx context.Context,
+	jobID *peloton.JobID,
+) (pbjob.JobState, error) {
+	if cachedJob := e.jobFactory.GetJob(jobID); cachedJob != nil {
+		runtime, err := cachedJob.GetRuntime(ctx)
+		if err == nil {
+			return runtime.GetState(), nil
+		}
+	}
+
+	runtime, err := e.jobRuntimeOps.Get(ctx, jobID)
+	if err != nil {
+		if errors.Cause(err) == gocql.ErrNotFound {
+			// the batch job has been deleted
+			return pbjob.JobState_DELETED, nil
+		}
+		return pbjob.JobState_UNKNOWN, err
+	}
+	return runtime.GetState(), nil
+}
+
+// newNodeJobID returns the ID of the batch job of a node
+func newNodeJobID(id *peloton.PipelineID, node string) *peloton.JobID {
+	return &peloton.JobID{
+		Value: uuid.NewSHA1(uuid.Parse(id.GetValue()), []byte(node)).String(),
+	}
+}
+
+// formatTime formats a time of the status of a pipeline
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,747 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbpipeline "github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+
+	backgroundmocks "github.com/uber/peloton/pkg/common/background/mocks"
+	cachedmocks "github.com/uber/peloton/pkg/jobmgr/cached/mocks"
+	goalstatemocks "github.com/uber/peloton/pkg/jobmgr/goalstate/mocks"
+	jobutilmocks "github.com/uber/peloton/pkg/jobmgr/util/job/mocks"
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	onSuccess = pbpipeline.EdgeCondition_EDGE_CONDITION_ON_SUCCESS
+	onFailure = pbpipeline.EdgeCondition_EDGE_CONDITION_ON_FAILURE
+)
+
+func newNode(name string) *pbpipeline.Node {
+	return &pbpipeline.Node{
+		Name: name,
+		Config: &pbjob.JobConfig{
+			Type:          pbjob.JobType_BATCH,
+			InstanceCount: 1,
+		},
+	}
+}
+
+func newEdge(
+	from, to string,
+	condition pbpipeline.EdgeCondition,
+) *pbpipeline.Edge {
+	return &pbpipeline.Edge{From: from, To: to, Condition: condition}
+}
+
+type EngineTestSuite struct {
+	suite.Suite
+	mockCtrl *gomock.Controller
+
+	pipelineOps     *objectmocks.MockPipelineOps
+	jobRuntimeOps   *objectmocks.MockJobRuntimeOps
+	jobFactory      *cachedmocks.MockJobFactory
+	cachedJob       *cachedmocks.MockJob
+	goalStateDriver *goalstatemocks.MockDriver
+	jobCreator      *jobutilmocks.MockJobCreator
+	engine          *engine
+
+	id   *peloton.PipelineID
+	spec *pbpipeline.PipelineSpec
+}
+
+func (s *EngineTestSuite) SetupTest() {
+	s.mockCtrl = gomock.NewController(s.T())
+
+	s.pipelineOps = objectmocks.NewMockPipelineOps(s.mockCtrl)
+	s.jobRuntimeOps = objectmocks.NewMockJobRuntimeOps(s.mockCtrl)
+	s.jobFactory = cachedmocks.NewMockJobFactory(s.mockCtrl)
+	s.cachedJob = cachedmocks.NewMockJob(s.mockCtrl)
+	s.goalStateDriver = goalstatemocks.NewMockDriver(s.mockCtrl)
+	s.jobCreator = jobutilmocks.NewMockJobCreator(s.mockCtrl)
+	s.engine = NewEngine(
+		s.pipelineOps,
+		s.jobRuntimeOps,
+		s.jobFactory,
+		s.goalStateDriver,
+		s.jobCreator,
+		tally.NoopScope,
+		&Config{},
+	).(*engine)
+
+	// extract -> transform -> load, with alert handling the failure
+	// of extract
+	s.id = &peloton.PipelineID{Value: uuid.New()}
+	s.spec = &pbpipeline.PipelineSpec{
+		Name: "etl",
+		Nodes: []*pbpipeline.Node{
+			newNode("extract"),
+			newNode("transform"),
+			newNode("load"),
+			newNode("alert"),
+		},
+		Edges: []*pbpipeline.Edge{
+			newEdge("extract", "transform", onSuccess),
+			newEdge("transform", "load", onSuccess),
+			newEdge("extract", "alert", onFailure),
+		},
+	}
+}
+
+func (s *EngineTestSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestEngineTestSuite(t *testing.T) {
+	suite.Run(t, new(EngineTestSuite))
+}
+
+// newInfo returns the pipeline with the given node states, running
+// nodes get the job ID derived from their name
+func (s *EngineTestSuite) newInfo(
+	states ...pbpipeline.NodeState,
+) *pbpipeline.PipelineInfo {
+	status := &pbpipeline.PipelineStatus{
+		State:     pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		GoalState: pbpipeline.PipelineState_PIPELINE_STATE_SUCCEEDED,
+	}
+	for i, n := range s.spec.GetNodes() {
+		ns := &pbpipeline.NodeStatus{Name: n.GetName(), State: states[i]}
+		if states[i] != pbpipeline.NodeState_NODE_STATE_PENDING {
+			ns.JobId = newNodeJobID(s.id, n.GetName())
+		}
+		status.Nodes = append(status.Nodes, ns)
+	}
+	return &pbpipeline.PipelineInfo{Id: s.id, Spec: s.spec, Status: status}
+}
+
+// expectJobState sets the expectation for reading the state of the
+// batch job of a node from the cache
+func (s *EngineTestSuite) expectJobState(
+	node string,
+	state pbjob.JobState,
+) *gomock.Call {
+	s.jobFactory.EXPECT().
+		GetJob(newNodeJobID(s.id, node)).
+		Return(s.cachedJob)
+	return s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{State: state}, nil)
+}
+
+// expectStartNode sets the expectation for the creation of the batch job
+// of a node
+func (s *EngineTestSuite) expectStartNode(
+	node string,
+	resp *pbjob.CreateResponse,
+	err error,
+) {
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *pbjob.CreateRequest) {
+			s.Equal(newNodeJobID(s.id, node), req.GetId())
+			s.Equal("etl-"+node, req.GetConfig().GetName())
+			s.Equal([]*peloton.Label{
+				{Key: PipelineLabelKey, Value: s.id.GetValue()},
+				{Key: PipelineNodeLabelKey, Value: node},
+			}, req.GetConfig().GetLabels())
+		}).
+		Return(resp, err)
+}
+
+// expectUpdateStatus sets the expectation for persisting the status of
+// the pipeline, and checks the node states
+func (s *EngineTestSuite) expectUpdateStatus(
+	state pbpipeline.PipelineState,
+	states ...pbpipeline.NodeState,
+) {
+	s.pipelineOps.EXPECT().
+		UpdateStatus(gomock.Any(), s.id, gomock.Any()).
+		Do(func(
+			_ context.Context,
+			_ *peloton.PipelineID,
+			status *pbpipeline.PipelineStatus,
+		) {
+			s.Equal(state, status.GetState())
+			for i, n := range status.GetNodes() {
+				s.Equal(states[i], n.GetState(), n.GetName())
+			}
+		}).
+		Return(nil)
+}
+
+// TestCreate tests creating a pipeline, which starts its root node
+func (s *EngineTestSuite) TestCreate() {
+	var id *peloton.PipelineID
+	s.pipelineOps.EXPECT().
+		Create(gomock.Any(), gomock.Any(), s.spec, gomock.Any()).
+		Do(func(
+			_ context.Context,
+			pipelineID *peloton.PipelineID,
+			_ *pbpipeline.PipelineSpec,
+			status *pbpipeline.PipelineStatus,
+		) {
+			id = pipelineID
+			s.Equal(pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+				status.GetState())
+			s.Len(status.GetNodes(), 4)
+		}).
+		Return(nil)
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *pbjob.CreateRequest) {
+			s.Equal(newNodeJobID(id, "extract"), req.GetId())
+		}).
+		Return(&pbjob.CreateResponse{}, nil)
+	s.pipelineOps.EXPECT().
+		UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(
+			_ context.Context,
+			_ *peloton.PipelineID,
+			status *pbpipeline.PipelineStatus,
+		) {
+			s.Equal(pbpipeline.NodeState_NODE_STATE_RUNNING,
+				status.GetNodes()[0].GetState())
+			s.Equal(newNodeJobID(id, "extract"),
+				status.GetNodes()[0].GetJobId())
+			s.Equal(pbpipeline.NodeState_NODE_STATE_PENDING,
+				status.GetNodes()[1].GetState())
+		}).
+		Return(nil)
+
+	pipelineID, err := s.engine.Create(context.Background(), s.spec)
+	s.NoError(err)
+	s.Equal(id, pipelineID)
+}
+
+// TestCreateInvalidSpec tests creating a pipeline with a cycle
+func (s *EngineTestSuite) TestCreateInvalidSpec() {
+	s.spec.Edges = append(s.spec.Edges,
+		newEdge("load", "extract", onSuccess))
+
+	_, err := s.engine.Create(context.Background(), s.spec)
+	s.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestCreateFail tests failing to persist a pipeline
+func (s *EngineTestSuite) TestCreateFail() {
+	s.pipelineOps.EXPECT().
+		Create(gomock.Any(), gomock.Any(), s.spec, gomock.Any()).
+		Return(errors.New("create failed"))
+
+	_, err := s.engine.Create(context.Background(), s.spec)
+	s.Error(err)
+}
+
+// TestEvaluateStartsDownstreamNode tests that a node is started once its
+// upstream node has succeeded, and that the ON_FAILURE edges are skipped
+func (s *EngineTestSuite) TestEvaluateStartsDownstreamNode() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.expectJobState("extract", pbjob.JobState_SUCCEEDED)
+	s.expectStartNode("transform", &pbjob.CreateResponse{}, nil)
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+	)
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+	s.Equal(pbpipeline.NodeState_NODE_STATE_RUNNING,
+		info.GetStatus().GetNodes()[1].GetState())
+}
+
+// TestEvaluateNoChange tests that the status of a pipeline is not
+// persisted if none of its nodes has changed
+func (s *EngineTestSuite) TestEvaluateNoChange() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.expectJobState("extract", pbjob.JobState_RUNNING)
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+}
+
+// TestEvaluatePropagatesFailure tests that the failure of a node skips
+// its ON_SUCCESS downstream nodes transitively, and starts its
+// ON_FAILURE downstream nodes
+func (s *EngineTestSuite) TestEvaluatePropagatesFailure() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.expectJobState("extract", pbjob.JobState_FAILED)
+	s.expectStartNode("alert", &pbjob.CreateResponse{}, nil)
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_FAILED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+	)
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+}
+
+// TestEvaluateSucceeded tests that a pipeline succeeds once all its
+// nodes have terminated without unhandled failures
+func (s *EngineTestSuite) TestEvaluateSucceeded() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+	)
+
+	s.expectJobState("load", pbjob.JobState_SUCCEEDED)
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+	)
+	s.pipelineOps.EXPECT().DeleteActive(gomock.Any(), s.id).Return(nil)
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+}
+
+// TestEvaluateFailed tests that a pipeline fails if the batch job of
+// one of its nodes has failed without being handled
+func (s *EngineTestSuite) TestEvaluateFailed() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+	)
+
+	// the batch job is no longer in the cache
+	s.jobFactory.EXPECT().
+		GetJob(newNodeJobID(s.id, "load")).
+		Return(nil)
+	s.jobRuntimeOps.EXPECT().
+		Get(gomock.Any(), newNodeJobID(s.id, "load")).
+		Return(&pbjob.RuntimeInfo{State: pbjob.JobState_FAILED}, nil)
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_FAILED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_FAILED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+	)
+	s.pipelineOps.EXPECT().
+		DeleteActive(gomock.Any(), s.id).
+		Return(errors.New("delete failed"))
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+}
+
+// TestEvaluateDeletedJob tests that a node whose batch job has been
+// deleted is considered killed
+func (s *EngineTestSuite) TestEvaluateDeletedJob() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.jobFactory.EXPECT().
+		GetJob(newNodeJobID(s.id, "extract")).
+		Return(nil)
+	s.jobRuntimeOps.EXPECT().
+		Get(gomock.Any(), newNodeJobID(s.id, "extract")).
+		Return(nil, gocql.ErrNotFound)
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_FAILED,
+		pbpipeline.NodeState_NODE_STATE_KILLED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+	)
+	s.pipelineOps.EXPECT().DeleteActive(gomock.Any(), s.id).Return(nil)
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+}
+
+// TestEvaluateGetJobStateFail tests failing to read the state of a
+// batch job
+func (s *EngineTestSuite) TestEvaluateGetJobStateFail() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.jobFactory.EXPECT().
+		GetJob(newNodeJobID(s.id, "extract")).
+		Return(nil)
+	s.jobRuntimeOps.EXPECT().
+		Get(gomock.Any(), newNodeJobID(s.id, "extract")).
+		Return(nil, errors.New("get failed"))
+
+	s.Error(s.engine.evaluate(context.Background(), info))
+}
+
+// TestStartNodeInvalidConfig tests that a node fails if its batch job
+// is rejected by the job service
+func (s *EngineTestSuite) TestStartNodeInvalidConfig() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.expectStartNode("extract", &pbjob.CreateResponse{
+		Error: &pbjob.CreateResponse_Error{
+			InvalidConfig: &pbjob.InvalidJobConfig{
+				Message: "invalid config",
+			},
+		},
+	}, nil)
+	s.expectStartNode("alert", &pbjob.CreateResponse{}, nil)
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_FAILED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+	)
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+}
+
+// TestStartNodeAlreadyExists tests that a node whose batch job has been
+// created by a previous evaluation is considered running
+func (s *EngineTestSuite) TestStartNodeAlreadyExists() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.expectStartNode("extract", &pbjob.CreateResponse{
+		Error: &pbjob.CreateResponse_Error{
+			AlreadyExists: &pbjob.JobAlreadyExists{
+				Message: "job already exists",
+			},
+		},
+	}, nil)
+	s.jobFactory.EXPECT().
+		AddJob(newNodeJobID(s.id, "extract")).
+		Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{State: pbjob.JobState_PENDING}, nil)
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+}
+
+// TestStartNodeAlreadyExistsError tests that a node is considered running
+// if the creation of its batch job fails with an already exists error
+func (s *EngineTestSuite) TestStartNodeAlreadyExistsError() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.expectStartNode("extract", nil,
+		yarpcerrors.AlreadyExistsErrorf("job already exists"))
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+}
+
+// TestStartNodeAlreadyExistsNotCreated tests that a node stays pending if
+// the job service reports its batch job as already existing but the batch
+// job has not been created
+func (s *EngineTestSuite) TestStartNodeAlreadyExistsNotCreated() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.expectStartNode("extract", &pbjob.CreateResponse{
+		Error: &pbjob.CreateResponse_Error{
+			AlreadyExists: &pbjob.JobAlreadyExists{
+				Message: "write failed",
+			},
+		},
+	}, nil)
+	s.jobFactory.EXPECT().
+		AddJob(newNodeJobID(s.id, "extract")).
+		Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(nil, errors.New("not found"))
+
+	s.Error(s.engine.evaluate(context.Background(), info))
+	s.Equal(pbpipeline.NodeState_NODE_STATE_PENDING,
+		info.GetStatus().GetNodes()[0].GetState())
+}
+
+// TestStartNodeFail tests that a node stays pending if its batch job
+// could not be created
+func (s *EngineTestSuite) TestStartNodeFail() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.expectStartNode("extract", nil, errors.New("create failed"))
+
+	s.Error(s.engine.evaluate(context.Background(), info))
+	s.Equal(pbpipeline.NodeState_NODE_STATE_PENDING,
+		info.GetStatus().GetNodes()[0].GetState())
+}
+
+// TestEvaluateUpdateStatusFail tests that the status in memory is not
+// changed if it could not be persisted
+func (s *EngineTestSuite) TestEvaluateUpdateStatusFail() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.expectStartNode("extract", &pbjob.CreateResponse{}, nil)
+	s.pipelineOps.EXPECT().
+		UpdateStatus(gomock.Any(), s.id, gomock.Any()).
+		Return(errors.New("update failed"))
+
+	s.Error(s.engine.evaluate(context.Background(), info))
+	s.Equal(pbpipeline.NodeState_NODE_STATE_PENDING,
+		info.GetStatus().GetNodes()[0].GetState())
+}
+
+// TestKill tests killing a pipeline
+func (s *EngineTestSuite) TestKill() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+	transformJobID := newNodeJobID(s.id, "transform")
+
+	gomock.InOrder(
+		s.pipelineOps.EXPECT().Get(gomock.Any(), s.id).Return(info, nil),
+		s.pipelineOps.EXPECT().
+			UpdateStatus(gomock.Any(), s.id, gomock.Any()).
+			Do(func(
+				_ context.Context,
+				_ *peloton.PipelineID,
+				status *pbpipeline.PipelineStatus,
+			) {
+				s.Equal(pbpipeline.PipelineState_PIPELINE_STATE_KILLED,
+					status.GetGoalState())
+			}).
+			Return(nil),
+		s.expectJobState("transform", pbjob.JobState_RUNNING),
+	)
+	s.jobFactory.EXPECT().AddJob(transformJobID).Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{
+			State:     pbjob.JobState_RUNNING,
+			GoalState: pbjob.JobState_SUCCEEDED,
+		}, nil)
+	s.cachedJob.EXPECT().
+		CompareAndSetRuntime(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, runtime *pbjob.RuntimeInfo) {
+			s.Equal(pbjob.JobState_KILLED, runtime.GetGoalState())
+		}).
+		Return(nil, nil)
+	s.goalStateDriver.EXPECT().EnqueueJob(transformJobID, gomock.Any())
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_KILLED,
+		pbpipeline.NodeState_NODE_STATE_KILLED,
+	)
+
+	s.NoError(s.engine.Kill(context.Background(), s.id))
+
+	// the pipeline is killed once the batch job has terminated
+	s.expectJobState("transform", pbjob.JobState_KILLED)
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_KILLED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_KILLED,
+		pbpipeline.NodeState_NODE_STATE_KILLED,
+		pbpipeline.NodeState_NODE_STATE_KILLED,
+	)
+	s.pipelineOps.EXPECT().DeleteActive(gomock.Any(), s.id).Return(nil)
+
+	s.NoError(s.engine.evaluate(context.Background(), info))
+}
+
+// TestKillFailures tests killing pipelines which do not exist or have
+// already terminated
+func (s *EngineTestSuite) TestKillFailures() {
+	// the pipeline does not exist
+	s.pipelineOps.EXPECT().
+		Get(gomock.Any(), s.id).
+		Return(nil, gocql.ErrNotFound)
+	err := s.engine.Kill(context.Background(), s.id)
+	s.True(yarpcerrors.IsNotFound(err))
+
+	s.pipelineOps.EXPECT().
+		Get(gomock.Any(), s.id).
+		Return(nil, errors.New("get failed"))
+	s.Error(s.engine.Kill(context.Background(), s.id))
+
+	// the pipeline has already terminated
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+	)
+	info.Status.State = pbpipeline.PipelineState_PIPELINE_STATE_SUCCEEDED
+	s.pipelineOps.EXPECT().
+		Get(gomock.Any(), s.id).
+		Return(info, nil)
+	s.NoError(s.engine.Kill(context.Background(), s.id))
+}
+
+// TestRun tests evaluating the active pipelines
+func (s *EngineTestSuite) TestRun() {
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+
+	s.pipelineOps.EXPECT().
+		GetActive(gomock.Any()).
+		Return([]*peloton.PipelineID{s.id}, nil)
+	s.pipelineOps.EXPECT().
+		Get(gomock.Any(), s.id).
+		Return(info, nil)
+	s.expectJobState("extract", pbjob.JobState_SUCCEEDED)
+	s.expectStartNode("transform", &pbjob.CreateResponse{}, nil)
+	s.expectUpdateStatus(
+		pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_SUCCEEDED,
+		pbpipeline.NodeState_NODE_STATE_RUNNING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_SKIPPED,
+	)
+
+	s.engine.run()
+}
+
+// TestRunEvaluateFail tests that failing to evaluate a pipeline does
+// not prevent the evaluation of the other pipelines
+func (s *EngineTestSuite) TestRunEvaluateFail() {
+	otherID := &peloton.PipelineID{Value: uuid.New()}
+	info := s.newInfo(
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+		pbpipeline.NodeState_NODE_STATE_PENDING,
+	)
+	otherInfo := &pbpipeline.PipelineInfo{
+		Id:   otherID,
+		Spec: s.spec,
+		Status: &pbpipeline.PipelineStatus{
+			State:     pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+			GoalState: pbpipeline.PipelineState_PIPELINE_STATE_SUCCEEDED,
+			Nodes:     info.GetStatus().GetNodes(),
+		},
+	}
+
+	s.pipelineOps.EXPECT().
+		GetActive(gomock.Any()).
+		Return([]*peloton.PipelineID{s.id, otherID}, nil)
+	s.pipelineOps.EXPECT().Get(gomock.Any(), s.id).Return(info, nil)
+	s.pipelineOps.EXPECT().Get(gomock.Any(), otherID).Return(otherInfo, nil)
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("create failed")).
+		Times(2)
+
+	s.engine.run()
+}
+
+// TestRunGetActiveFail tests failing to get the active pipelines
+func (s *EngineTestSuite) TestRunGetActiveFail() {
+	s.pipelineOps.EXPECT().
+		GetActive(gomock.Any()).
+		Return(nil, errors.New("get active failed"))
+
+	s.engine.run()
+}
+
+// TestRegister tests registering the engine in the background manager
+func (s *EngineTestSuite) TestRegister() {
+	manager := backgroundmocks.NewMockManager(s.mockCtrl)
+	manager.EXPECT().RegisterWorks(gomock.Any()).Return(nil)
+	s.NoError(s.engine.Register(manager))
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import "github.com/uber-go/tally"
+
+// Metrics is the struct containing all metrics relevant for
+// the pipeline engine
+type Metrics struct {
+	ActivePipelines    tally.Gauge
+	PipelinesCreated   tally.Counter
+	PipelinesSucceeded tally.Counter
+	PipelinesFailed    tally.Counter
+	PipelinesKilled    tally.Counter
+	EvaluateDuration   tally.Timer
+
+	NodesStarted   tally.Counter
+	NodesSucceeded tally.Counter
+	NodesFailed    tally.Counter
+	NodesKilled    tally.Counter
+	NodesSkipped   tally.Counter
+
+	GetPipelinesFail tally.Counter
+	StartNodeFail    tally.Counter
+	KillNodeFail     tally.Counter
+	UpdateFail       tally.Counter
+}
+
+// NewMetrics returns a new Metrics struct, with all metrics
+// initialized and rooted at the given tally.Scope
+func NewMetrics(scope tally.Scope) *Metrics {
+	pipelineScope := scope.SubScope("pipeline")
+	nodeScope := pipelineScope.SubScope("node")
+	return &Metrics{
+		ActivePipelines:    pipelineScope.Gauge("active"),
+		PipelinesCreated:   pipelineScope.Counter("created"),
+		PipelinesSucceeded: pipelineScope.Counter("succeeded"),
+		PipelinesFailed:    pipelineScope.Counter("failed"),
+		PipelinesKilled:    pipelineScope.Counter("killed"),
+		EvaluateDuration:   pipelineScope.Timer("duration"),
+
+		NodesStarted:   nodeScope.Counter("started"),
+		NodesSucceeded: nodeScope.Counter("succeeded"),
+		NodesFailed:    nodeScope.Counter("failed"),
+		NodesKilled:    nodeScope.Counter("killed"),
+		NodesSkipped:   nodeScope.Counter("skipped"),
+
+		GetPipelinesFail: pipelineScope.Counter("get_pipelines_fail"),
+		StartNodeFail:    nodeScope.Counter("start_fail"),
+		KillNodeFail:     nodeScope.Counter("kill_fail"),
+		UpdateFail:       pipelineScope.Counter("update_fail"),
+	}
+}
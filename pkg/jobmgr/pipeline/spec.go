@@ -0,0 +1,144 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	pbpipeline "github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	errNullSpec      = yarpcerrors.InvalidArgumentErrorf("pipeline spec is null")
+	errEmptyName     = yarpcerrors.InvalidArgumentErrorf("pipeline name is empty")
+	errNoNodes       = yarpcerrors.InvalidArgumentErrorf("pipeline has no node")
+	errCycle         = yarpcerrors.InvalidArgumentErrorf("pipeline edges contain a cycle")
+	errEmptyNodeName = yarpcerrors.InvalidArgumentErrorf("pipeline node name is empty")
+)
+
+// graph indexes the edges of a pipeline by node name
+type graph struct {
+	// incoming edges of each node
+	upstream map[string][]*pbpipeline.Edge
+	// outgoing edges of each node
+	downstream map[string][]*pbpipeline.Edge
+}
+
+// newGraph indexes the edges of a pipeline spec
+func newGraph(spec *pbpipeline.PipelineSpec) *graph {
+	g := &graph{
+		upstream:   make(map[string][]*pbpipeline.Edge),
+		downstream: make(map[string][]*pbpipeline.Edge),
+	}
+	for _, e := range spec.GetEdges() {
+		g.upstream[e.GetTo()] = append(g.upstream[e.GetTo()], e)
+		g.downstream[e.GetFrom()] = append(g.downstream[e.GetFrom()], e)
+	}
+	return g
+}
+
+// ValidateSpec validates the nodes and the edges of a pipeline spec.
+// The configurations of the batch jobs are validated by the job service
+// when the nodes are started.
+func ValidateSpec(spec *pbpipeline.PipelineSpec) error {
+	if spec == nil {
+		return errNullSpec
+	}
+
+	if len(spec.GetName()) == 0 {
+		return errEmptyName
+	}
+
+	if len(spec.GetNodes()) == 0 {
+		return errNoNodes
+	}
+
+	nodes := make(map[string]bool)
+	for _, n := range spec.GetNodes() {
+		if len(n.GetName()) == 0 {
+			return errEmptyNodeName
+		}
+		if nodes[n.GetName()] {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"pipeline node %s is duplicated", n.GetName())
+		}
+		if n.GetConfig() == nil {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"pipeline node %s has no job config", n.GetName())
+		}
+		if n.GetConfig().GetType() != pbjob.JobType_BATCH {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"pipeline node %s is not a batch job", n.GetName())
+		}
+		nodes[n.GetName()] = true
+	}
+
+	edges := make(map[string]bool)
+	for _, e := range spec.GetEdges() {
+		if !nodes[e.GetFrom()] || !nodes[e.GetTo()] {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"pipeline edge %s -> %s refers to an unknown node",
+				e.GetFrom(), e.GetTo())
+		}
+		if e.GetCondition() == pbpipeline.EdgeCondition_EDGE_CONDITION_INVALID {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"pipeline edge %s -> %s has an invalid condition",
+				e.GetFrom(), e.GetTo())
+		}
+		key := e.GetFrom() + "\x00" + e.GetTo()
+		if edges[key] {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"pipeline edge %s -> %s is duplicated",
+				e.GetFrom(), e.GetTo())
+		}
+		edges[key] = true
+	}
+
+	if hasCycle(spec) {
+		return errCycle
+	}
+	return nil
+}
+
+// hasCycle returns true if the edges of the pipeline contain a cycle,
+// by removing the nodes without incoming edges until none is left
+func hasCycle(spec *pbpipeline.PipelineSpec) bool {
+	g := newGraph(spec)
+
+	inDegree := make(map[string]int)
+	var ready []string
+	for _, n := range spec.GetNodes() {
+		inDegree[n.GetName()] = len(g.upstream[n.GetName()])
+		if inDegree[n.GetName()] == 0 {
+			ready = append(ready, n.GetName())
+		}
+	}
+
+	visited := 0
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		visited++
+
+		for _, e := range g.downstream[name] {
+			inDegree[e.GetTo()]--
+			if inDegree[e.GetTo()] == 0 {
+				ready = append(ready, e.GetTo())
+			}
+		}
+	}
+	return visited != len(spec.GetNodes())
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"testing"
+
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	pbpipeline "github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type SpecTestSuite struct {
+	suite.Suite
+	spec *pbpipeline.PipelineSpec
+}
+
+func (s *SpecTestSuite) SetupTest() {
+	s.spec = &pbpipeline.PipelineSpec{
+		Name: "etl",
+		Nodes: []*pbpipeline.Node{
+			newNode("extract"),
+			newNode("transform"),
+			newNode("load"),
+			newNode("alert"),
+		},
+		Edges: []*pbpipeline.Edge{
+			newEdge("extract", "transform", onSuccess),
+			newEdge("transform", "load", onSuccess),
+			newEdge("extract", "alert", onFailure),
+		},
+	}
+}
+
+func TestSpecTestSuite(t *testing.T) {
+	suite.Run(t, new(SpecTestSuite))
+}
+
+// TestValidateSpec tests validating a valid pipeline spec
+func (s *SpecTestSuite) TestValidateSpec() {
+	s.NoError(ValidateSpec(s.spec))
+}
+
+// TestValidateSpecFailures tests validating invalid pipeline specs
+func (s *SpecTestSuite) TestValidateSpecFailures() {
+	tests := []struct {
+		msg    string
+		modify func(spec *pbpipeline.PipelineSpec)
+	}{
+		{
+			msg: "empty name",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Name = ""
+			},
+		},
+		{
+			msg: "no node",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Nodes = nil
+				spec.Edges = nil
+			},
+		},
+		{
+			msg: "empty node name",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Nodes = append(spec.Nodes, newNode(""))
+			},
+		},
+		{
+			msg: "duplicated node",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Nodes = append(spec.Nodes, newNode("load"))
+			},
+		},
+		{
+			msg: "node without config",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Nodes[0].Config = nil
+			},
+		},
+		{
+			msg: "node which is not a batch job",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Nodes[0].Config.Type = pbjob.JobType_SERVICE
+			},
+		},
+		{
+			msg: "edge to an unknown node",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Edges = append(spec.Edges,
+					newEdge("load", "publish", onSuccess))
+			},
+		},
+		{
+			msg: "edge with an invalid condition",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Edges[0].Condition =
+					pbpipeline.EdgeCondition_EDGE_CONDITION_INVALID
+			},
+		},
+		{
+			msg: "duplicated edge",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Edges = append(spec.Edges,
+					newEdge("extract", "transform", onFailure))
+			},
+		},
+		{
+			msg: "self edge",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Edges = append(spec.Edges,
+					newEdge("load", "load", onSuccess))
+			},
+		},
+		{
+			msg: "cycle",
+			modify: func(spec *pbpipeline.PipelineSpec) {
+				spec.Edges = append(spec.Edges,
+					newEdge("load", "extract", onSuccess))
+			},
+		},
+	}
+
+	s.Error(ValidateSpec(nil))
+
+	for _, t := range tests {
+		s.SetupTest()
+		t.modify(s.spec)
+		err := ValidateSpec(s.spec)
+		s.Error(err, t.msg)
+		s.True(yarpcerrors.IsInvalidArgument(err), t.msg)
+	}
+}
+
+// TestCheckUpstream tests the evaluation of the incoming edges of a node
+func (s *SpecTestSuite) TestCheckUpstream() {
+	g := newGraph(s.spec)
+	nodes := map[string]*pbpipeline.NodeStatus{
+		"extract":   {State: pbpipeline.NodeState_NODE_STATE_SUCCEEDED},
+		"transform": {State: pbpipeline.NodeState_NODE_STATE_RUNNING},
+		"load":      {State: pbpipeline.NodeState_NODE_STATE_PENDING},
+		"alert":     {State: pbpipeline.NodeState_NODE_STATE_PENDING},
+	}
+
+	// root node
+	ready, skip := checkUpstream(g.upstream["extract"], nodes)
+	s.True(ready)
+	s.False(skip)
+
+	// upstream node is running
+	ready, skip = checkUpstream(g.upstream["load"], nodes)
+	s.False(ready)
+	s.False(skip)
+
+	// ON_FAILURE edge from a succeeded node can no longer be met
+	ready, skip = checkUpstream(g.upstream["alert"], nodes)
+	s.False(ready)
+	s.True(skip)
+
+	nodes["transform"].State = pbpipeline.NodeState_NODE_STATE_SUCCEEDED
+	ready, skip = checkUpstream(g.upstream["load"], nodes)
+	s.True(ready)
+	s.False(skip)
+
+	// skipped nodes propagate to their downstream nodes
+	nodes["transform"].State = pbpipeline.NodeState_NODE_STATE_SKIPPED
+	ready, skip = checkUpstream(g.upstream["load"], nodes)
+	s.False(ready)
+	s.True(skip)
+}
+
+// TestPipelineState tests computing the terminal state of a pipeline
+func (s *SpecTestSuite) TestPipelineState() {
+	g := newGraph(s.spec)
+	status := &pbpipeline.PipelineStatus{
+		State: pbpipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		Nodes: []*pbpipeline.NodeStatus{
+			{Name: "extract", State: pbpipeline.NodeState_NODE_STATE_SUCCEEDED},
+			{Name: "transform", State: pbpipeline.NodeState_NODE_STATE_RUNNING},
+			{Name: "load", State: pbpipeline.NodeState_NODE_STATE_PENDING},
+			{Name: "alert", State: pbpipeline.NodeState_NODE_STATE_SKIPPED},
+		},
+	}
+
+	_, done := pipelineState(status, g, false)
+	s.False(done)
+
+	status.Nodes[1].State = pbpipeline.NodeState_NODE_STATE_SUCCEEDED
+	status.Nodes[2].State = pbpipeline.NodeState_NODE_STATE_SUCCEEDED
+	state, done := pipelineState(status, g, false)
+	s.True(done)
+	s.Equal(pbpipeline.PipelineState_PIPELINE_STATE_SUCCEEDED, state)
+
+	// the failure of extract is handled by alert
+	status.Nodes[0].State = pbpipeline.NodeState_NODE_STATE_FAILED
+	status.Nodes[1].State = pbpipeline.NodeState_NODE_STATE_SKIPPED
+	status.Nodes[2].State = pbpipeline.NodeState_NODE_STATE_SKIPPED
+	status.Nodes[3].State = pbpipeline.NodeState_NODE_STATE_SUCCEEDED
+	state, done = pipelineState(status, g, false)
+	s.True(done)
+	s.Equal(pbpipeline.PipelineState_PIPELINE_STATE_SUCCEEDED, state)
+
+	// the failure of load is not handled
+	status.Nodes[0].State = pbpipeline.NodeState_NODE_STATE_SUCCEEDED
+	status.Nodes[1].State = pbpipeline.NodeState_NODE_STATE_SUCCEEDED
+	status.Nodes[2].State = pbpipeline.NodeState_NODE_STATE_FAILED
+	status.Nodes[3].State = pbpipeline.NodeState_NODE_STATE_SKIPPED
+	state, done = pipelineState(status, g, false)
+	s.True(done)
+	s.Equal(pbpipeline.PipelineState_PIPELINE_STATE_FAILED, state)
+
+	state, done = pipelineState(status, g, true)
+	s.True(done)
+	s.Equal(pbpipeline.PipelineState_PIPELINE_STATE_KILLED, state)
+}
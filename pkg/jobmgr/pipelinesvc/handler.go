@@ -0,0 +1,253 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipelinesvc
+
+import (
+	"context"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbpipeline "github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline/svc"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+
+	"github.com/uber/peloton/pkg/auth"
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/leader"
+	yarpcutil "github.com/uber/peloton/pkg/common/util/yarpc"
+	"github.com/uber/peloton/pkg/jobmgr/pipeline"
+	handlerutil "github.com/uber/peloton/pkg/jobmgr/util/handler"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	"github.com/gocql/gocql"
+	log "github.com/sirupsen/logrus"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var errNullPipelineID = yarpcerrors.InvalidArgumentErrorf("pipeline ID is null")
+
+type serviceHandler struct {
+	pipelineOps   ormobjects.PipelineOps
+	engine        pipeline.Engine
+	candidate     leader.Candidate
+	respoolClient respool.ResourceManagerYARPCClient
+}
+
+// InitServiceHandler initializes the Pipeline Service Handler
+func InitServiceHandler(
+	d *yarpc.Dispatcher,
+	ormStore *ormobjects.Store,
+	engine pipeline.Engine,
+	candidate leader.Candidate,
+) {
+	handler := &serviceHandler{
+		pipelineOps: ormobjects.NewPipelineOps(ormStore),
+		engine:      engine,
+		candidate:   candidate,
+		respoolClient: respool.NewResourceManagerYARPCClient(
+			d.ClientConfig(common.PelotonResourceManager)),
+	}
+	d.Register(svc.BuildPipelineServiceYARPCProcedures(handler))
+}
+
+// CreatePipeline creates a new pipeline
+func (h *serviceHandler) CreatePipeline(
+	ctx context.Context,
+	req *svc.CreatePipelineRequest,
+) (resp *svc.CreatePipelineResponse, err error) {
+	defer func() {
+		h.logResult(ctx, "CreatePipeline", req, err)
+		if err != nil {
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	if !h.candidate.IsLeader() {
+		return nil, yarpcerrors.UnavailableErrorf(
+			"PipelineSVC.%s is not supported on non-leader", "CreatePipeline")
+	}
+
+	if err := h.authorizeSpec(ctx, req.GetSpec()); err != nil {
+		return nil, err
+	}
+
+	id, err := h.engine.Create(ctx, req.GetSpec())
+	if err != nil {
+		return nil, err
+	}
+	return &svc.CreatePipelineResponse{PipelineId: id}, nil
+}
+
+// GetPipeline returns the spec and the status of a pipeline
+func (h *serviceHandler) GetPipeline(
+	ctx context.Context,
+	req *svc.GetPipelineRequest,
+) (resp *svc.GetPipelineResponse, err error) {
+	defer func() {
+		if err != nil {
+			h.logResult(ctx, "GetPipeline", req, err)
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	if req.GetPipelineId() == nil {
+		return nil, errNullPipelineID
+	}
+
+	info, err := h.pipelineOps.Get(ctx, req.GetPipelineId())
+	if err != nil {
+		return nil, convertNotFound(err, req.GetPipelineId())
+	}
+	return &svc.GetPipelineResponse{Pipeline: info}, nil
+}
+
+// ListPipelines returns the spec and the status of the pipelines which
+// have not terminated yet
+func (h *serviceHandler) ListPipelines(
+	ctx context.Context,
+	req *svc.ListPipelinesRequest,
+) (resp *svc.ListPipelinesResponse, err error) {
+	defer func() {
+		if err != nil {
+			h.logResult(ctx, "ListPipelines", req, err)
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	ids, err := h.pipelineOps.GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines []*pbpipeline.PipelineInfo
+	for _, id := range ids {
+		info, err := h.pipelineOps.Get(ctx, id)
+		if err != nil {
+			if err == gocql.ErrNotFound {
+				// the pipeline is removed from the active pipelines
+				// on the next recovery
+				continue
+			}
+			return nil, err
+		}
+		pipelines = append(pipelines, info)
+	}
+	return &svc.ListPipelinesResponse{Pipelines: pipelines}, nil
+}
+
+// KillPipeline kills a pipeline
+func (h *serviceHandler) KillPipeline(
+	ctx context.Context,
+	req *svc.KillPipelineRequest,
+) (resp *svc.KillPipelineResponse, err error) {
+	defer func() {
+		h.logResult(ctx, "KillPipeline", req, err)
+		if err != nil {
+			err = yarpcutil.ConvertToYARPCError(err)
+		}
+	}()
+
+	if !h.candidate.IsLeader() {
+		return nil, yarpcerrors.UnavailableErrorf(
+			"PipelineSVC.%s is not supported on non-leader", "KillPipeline")
+	}
+
+	if req.GetPipelineId() == nil {
+		return nil, errNullPipelineID
+	}
+
+	if err := h.authorizePipeline(ctx, req.GetPipelineId()); err != nil {
+		return nil, err
+	}
+
+	if err := h.engine.Kill(ctx, req.GetPipelineId()); err != nil {
+		return nil, err
+	}
+	return &svc.KillPipelineResponse{}, nil
+}
+
+// authorizeSpec checks whether the caller is permitted to call the
+// procedure of the request on the pipeline with the spec, i.e. to create
+// batch jobs in the resource pools of all its nodes
+func (h *serviceHandler) authorizeSpec(
+	ctx context.Context,
+	spec *pbpipeline.PipelineSpec,
+) error {
+	if _, ok := auth.UserFromContext(ctx); !ok {
+		return nil
+	}
+
+	authorized := make(map[string]bool)
+	for _, node := range spec.GetNodes() {
+		respoolID := node.GetConfig().GetRespoolID()
+		if authorized[respoolID.GetValue()] {
+			continue
+		}
+		if err := handlerutil.AuthorizeJobCreation(
+			ctx,
+			h.respoolClient,
+			respoolID,
+		); err != nil {
+			return err
+		}
+		authorized[respoolID.GetValue()] = true
+	}
+	return nil
+}
+
+// authorizePipeline checks whether the caller is permitted to call the
+// procedure of the request on the existing pipeline. The pipeline is only
+// read for requests which went through auth.
+func (h *serviceHandler) authorizePipeline(
+	ctx context.Context,
+	id *peloton.PipelineID,
+) error {
+	if _, ok := auth.UserFromContext(ctx); !ok {
+		return nil
+	}
+
+	info, err := h.pipelineOps.Get(ctx, id)
+	if err != nil {
+		return convertNotFound(err, id)
+	}
+	return h.authorizeSpec(ctx, info.GetSpec())
+}
+
+// logResult logs the result of a call to the service
+func (h *serviceHandler) logResult(
+	ctx context.Context,
+	procedure string,
+	req interface{},
+	err error,
+) {
+	entry := log.WithField("request", req).
+		WithField("headers", yarpcutil.GetHeaders(ctx))
+
+	if err != nil {
+		entry.WithError(err).Warnf("PipelineSVC.%s failed", procedure)
+		return
+	}
+	entry.Infof("PipelineSVC.%s succeeded", procedure)
+}
+
+// convertNotFound converts the not found error of the storage into
+// a yarpc not found error
+func convertNotFound(err error, id *peloton.PipelineID) error {
+	if err == gocql.ErrNotFound {
+		return yarpcerrors.NotFoundErrorf(
+			"pipeline %s not found", id.GetValue())
+	}
+	return err
+}
@@ -0,0 +1,262 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipelinesvc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pbpipeline "github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline/svc"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	respoolmocks "github.com/uber/peloton/.gen/peloton/api/v0/respool/mocks"
+
+	"github.com/uber/peloton/pkg/auth"
+	authmocks "github.com/uber/peloton/pkg/auth/mocks"
+	leadermocks "github.com/uber/peloton/pkg/common/leader/mocks"
+	pipelinemocks "github.com/uber/peloton/pkg/jobmgr/pipeline/mocks"
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type pipelineHandlerTestSuite struct {
+	suite.Suite
+
+	ctrl          *gomock.Controller
+	pipelineOps   *objectmocks.MockPipelineOps
+	engine        *pipelinemocks.MockEngine
+	candidate     *leadermocks.MockCandidate
+	respoolClient *respoolmocks.MockResourceManagerYARPCClient
+	handler       *serviceHandler
+
+	id   *peloton.PipelineID
+	spec *pbpipeline.PipelineSpec
+}
+
+func (suite *pipelineHandlerTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.pipelineOps = objectmocks.NewMockPipelineOps(suite.ctrl)
+	suite.engine = pipelinemocks.NewMockEngine(suite.ctrl)
+	suite.candidate = leadermocks.NewMockCandidate(suite.ctrl)
+	suite.respoolClient = respoolmocks.NewMockResourceManagerYARPCClient(suite.ctrl)
+	suite.handler = &serviceHandler{
+		pipelineOps:   suite.pipelineOps,
+		engine:        suite.engine,
+		candidate:     suite.candidate,
+		respoolClient: suite.respoolClient,
+	}
+
+	suite.id = &peloton.PipelineID{Value: uuid.New()}
+	suite.spec = &pbpipeline.PipelineSpec{
+		Name: "etl",
+		Nodes: []*pbpipeline.Node{
+			{Name: "extract"},
+		},
+	}
+}
+
+func (suite *pipelineHandlerTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestPipelineHandler(t *testing.T) {
+	suite.Run(t, new(pipelineHandlerTestSuite))
+}
+
+// TestCreatePipeline tests creating a pipeline
+func (suite *pipelineHandlerTestSuite) TestCreatePipeline() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.engine.EXPECT().Create(gomock.Any(), suite.spec).Return(suite.id, nil)
+
+	resp, err := suite.handler.CreatePipeline(
+		context.Background(),
+		&svc.CreatePipelineRequest{Spec: suite.spec})
+	suite.NoError(err)
+	suite.Equal(suite.id, resp.GetPipelineId())
+}
+
+// TestCreatePipelinePermissionDenied tests that a pipeline can't be
+// created by a caller not permitted on the resource pools of its nodes
+func (suite *pipelineHandlerTestSuite) TestCreatePipelinePermissionDenied() {
+	user := authmocks.NewMockUser(suite.ctrl)
+	ctx := auth.ContextWithUser(context.Background(), user)
+	respoolID := &peloton.ResourcePoolID{Value: uuid.New()}
+	spec := &pbpipeline.PipelineSpec{
+		Name: "etl",
+		Nodes: []*pbpipeline.Node{
+			{Name: "extract", Config: &job.JobConfig{RespoolID: respoolID}},
+			{Name: "load", Config: &job.JobConfig{RespoolID: respoolID}},
+		},
+	}
+
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.respoolClient.EXPECT().
+		GetResourcePool(gomock.Any(), &respool.GetRequest{Id: respoolID}).
+		Return(&respool.GetResponse{
+			Poolinfo: &respool.ResourcePoolInfo{
+				Path:   &respool.ResourcePoolPath{Value: "/etl"},
+				Config: &respool.ResourcePoolConfig{OwningTeam: "data"},
+			},
+		}, nil)
+	user.EXPECT().
+		IsPermittedOnResource(gomock.Any(), &auth.Resource{
+			RespoolPath: "/etl",
+			OwningTeam:  "data",
+		}).
+		Return(false)
+
+	_, err := suite.handler.CreatePipeline(
+		ctx,
+		&svc.CreatePipelineRequest{Spec: spec})
+	suite.True(yarpcerrors.IsPermissionDenied(err))
+}
+
+// TestCreatePipelineNonLeader tests creating a pipeline on a non-leader
+func (suite *pipelineHandlerTestSuite) TestCreatePipelineNonLeader() {
+	suite.candidate.EXPECT().IsLeader().Return(false)
+
+	resp, err := suite.handler.CreatePipeline(
+		context.Background(),
+		&svc.CreatePipelineRequest{Spec: suite.spec})
+	suite.Nil(resp)
+	suite.True(yarpcerrors.IsUnavailable(err))
+}
+
+// TestCreatePipelineInvalidSpec tests creating a pipeline with an
+// invalid spec
+func (suite *pipelineHandlerTestSuite) TestCreatePipelineInvalidSpec() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.engine.EXPECT().
+		Create(gomock.Any(), suite.spec).
+		Return(nil, yarpcerrors.InvalidArgumentErrorf("invalid spec"))
+
+	_, err := suite.handler.CreatePipeline(
+		context.Background(),
+		&svc.CreatePipelineRequest{Spec: suite.spec})
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestGetPipeline tests getting a pipeline
+func (suite *pipelineHandlerTestSuite) TestGetPipeline() {
+	info := &pbpipeline.PipelineInfo{Id: suite.id, Spec: suite.spec}
+	suite.pipelineOps.EXPECT().Get(gomock.Any(), suite.id).Return(info, nil)
+
+	resp, err := suite.handler.GetPipeline(
+		context.Background(),
+		&svc.GetPipelineRequest{PipelineId: suite.id})
+	suite.NoError(err)
+	suite.Equal(info, resp.GetPipeline())
+}
+
+// TestGetPipelineFailures tests failures to get a pipeline
+func (suite *pipelineHandlerTestSuite) TestGetPipelineFailures() {
+	_, err := suite.handler.GetPipeline(
+		context.Background(),
+		&svc.GetPipelineRequest{})
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+
+	suite.pipelineOps.EXPECT().
+		Get(gomock.Any(), suite.id).
+		Return(nil, gocql.ErrNotFound)
+	_, err = suite.handler.GetPipeline(
+		context.Background(),
+		&svc.GetPipelineRequest{PipelineId: suite.id})
+	suite.True(yarpcerrors.IsNotFound(err))
+}
+
+// TestListPipelines tests listing the active pipelines
+func (suite *pipelineHandlerTestSuite) TestListPipelines() {
+	missingID := &peloton.PipelineID{Value: uuid.New()}
+	info := &pbpipeline.PipelineInfo{Id: suite.id, Spec: suite.spec}
+	suite.pipelineOps.EXPECT().
+		GetActive(gomock.Any()).
+		Return([]*peloton.PipelineID{suite.id, missingID}, nil)
+	suite.pipelineOps.EXPECT().Get(gomock.Any(), suite.id).Return(info, nil)
+	suite.pipelineOps.EXPECT().
+		Get(gomock.Any(), missingID).
+		Return(nil, gocql.ErrNotFound)
+
+	resp, err := suite.handler.ListPipelines(
+		context.Background(),
+		&svc.ListPipelinesRequest{})
+	suite.NoError(err)
+	suite.Equal([]*pbpipeline.PipelineInfo{info}, resp.GetPipelines())
+}
+
+// TestListPipelinesFailures tests failures to list the active pipelines
+func (suite *pipelineHandlerTestSuite) TestListPipelinesFailures() {
+	suite.pipelineOps.EXPECT().
+		GetActive(gomock.Any()).
+		Return(nil, errors.New("test error"))
+	_, err := suite.handler.ListPipelines(
+		context.Background(),
+		&svc.ListPipelinesRequest{})
+	suite.True(yarpcerrors.IsInternal(err))
+
+	suite.pipelineOps.EXPECT().
+		GetActive(gomock.Any()).
+		Return([]*peloton.PipelineID{suite.id}, nil)
+	suite.pipelineOps.EXPECT().
+		Get(gomock.Any(), suite.id).
+		Return(nil, errors.New("test error"))
+	_, err = suite.handler.ListPipelines(
+		context.Background(),
+		&svc.ListPipelinesRequest{})
+	suite.True(yarpcerrors.IsInternal(err))
+}
+
+// TestKillPipeline tests killing a pipeline
+func (suite *pipelineHandlerTestSuite) TestKillPipeline() {
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.engine.EXPECT().Kill(gomock.Any(), suite.id).Return(nil)
+
+	resp, err := suite.handler.KillPipeline(
+		context.Background(),
+		&svc.KillPipelineRequest{PipelineId: suite.id})
+	suite.NoError(err)
+	suite.NotNil(resp)
+}
+
+// TestKillPipelineFailures tests failures to kill a pipeline
+func (suite *pipelineHandlerTestSuite) TestKillPipelineFailures() {
+	suite.candidate.EXPECT().IsLeader().Return(false)
+	_, err := suite.handler.KillPipeline(
+		context.Background(),
+		&svc.KillPipelineRequest{PipelineId: suite.id})
+	suite.True(yarpcerrors.IsUnavailable(err))
+
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	_, err = suite.handler.KillPipeline(
+		context.Background(),
+		&svc.KillPipelineRequest{})
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+
+	suite.candidate.EXPECT().IsLeader().Return(true)
+	suite.engine.EXPECT().
+		Kill(gomock.Any(), suite.id).
+		Return(yarpcerrors.NotFoundErrorf("pipeline not found"))
+	_, err = suite.handler.KillPipeline(
+		context.Background(),
+		&svc.KillPipelineRequest{PipelineId: suite.id})
+	suite.True(yarpcerrors.IsNotFound(err))
+}
@@ -21,12 +21,15 @@ import (
 	mesos "github.com/uber/peloton/.gen/mesos/v1"
 	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
 	v0peloton "github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 	pbtask "github.com/uber/peloton/.gen/peloton/api/v0/task"
 	v1alphapeloton "github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
 	pbpod "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/pod/svc"
 	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
 
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/audit"
 	"github.com/uber/peloton/pkg/common/leader"
 	"github.com/uber/peloton/pkg/common/util"
 	versionutil "github.com/uber/peloton/pkg/common/util/entityversion"
@@ -61,6 +64,7 @@ type serviceHandler struct {
 	logManager         logmanager.LogManager
 	mesosAgentWorkDir  string
 	hostMgrClient      hostsvc.InternalHostServiceYARPCClient
+	respoolClient      respool.ResourceManagerYARPCClient
 }
 
 // InitV1AlphaPodServiceHandler initializes the Pod Service Handler
@@ -86,6 +90,9 @@ func InitV1AlphaPodServiceHandler(
 		logManager:         logManager,
 		mesosAgentWorkDir:  mesosAgentWorkDir,
 		hostMgrClient:      hostMgrClient,
+		respoolClient: respool.NewResourceManagerYARPCClient(
+			d.ClientConfig(common.PelotonResourceManager),
+		),
 	}
 	d.Register(svc.BuildPodServiceYARPCProcedures(handler))
 }
@@ -111,6 +118,8 @@ func (h *serviceHandler) StartPod(
 			Info("PodSVC.StartPod succeeded")
 	}()
 
+	audit.AddPodName(ctx, req.GetPodName().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil,
 			yarpcerrors.UnavailableErrorf("PodSVC.StartPod is not supported on non-leader")
@@ -121,6 +130,15 @@ func (h *serviceHandler) StartPod(
 		return nil, err
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		jobID,
+	); err != nil {
+		return nil, err
+	}
+
 	cachedJob := h.jobFactory.AddJob(&v0peloton.JobID{Value: jobID})
 	cachedConfig, err := cachedJob.GetConfig(ctx)
 	if err != nil {
@@ -279,6 +297,8 @@ func (h *serviceHandler) StopPod(
 			Info("PodSVC.StopPod succeeded")
 	}()
 
+	audit.AddPodName(ctx, req.GetPodName().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil,
 			yarpcerrors.UnavailableErrorf("PodSVC.StopPod is not supported on non-leader")
@@ -289,6 +309,15 @@ func (h *serviceHandler) StopPod(
 		return nil, err
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		jobID,
+	); err != nil {
+		return nil, err
+	}
+
 	cachedJob := h.jobFactory.AddJob(&v0peloton.JobID{Value: jobID})
 
 	runtimeInfo, err := h.podStore.GetTaskRuntime(
@@ -347,6 +376,8 @@ func (h *serviceHandler) RestartPod(
 			Info("PodSVC.RestartPod succeeded")
 	}()
 
+	audit.AddPodName(ctx, req.GetPodName().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil,
 			yarpcerrors.UnavailableErrorf("PodSVC.RestartPod is not supported on non-leader")
@@ -357,6 +388,15 @@ func (h *serviceHandler) RestartPod(
 		return nil, yarpcerrors.InvalidArgumentErrorf("invalid pod name")
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		jobID,
+	); err != nil {
+		return nil, err
+	}
+
 	cachedJob := h.jobFactory.AddJob(&v0peloton.JobID{Value: jobID})
 
 	newPodID, err := h.getPodIDForRestart(ctx,
@@ -612,6 +652,8 @@ func (h *serviceHandler) RefreshPod(
 			Info("PodSVC.RefreshPod succeeded")
 	}()
 
+	audit.AddPodName(ctx, req.GetPodName().GetValue())
+
 	if !h.candidate.IsLeader() {
 		return nil,
 			yarpcerrors.UnavailableErrorf("PodSVC.RefreshPod is not supported on non-leader")
@@ -622,6 +664,15 @@ func (h *serviceHandler) RefreshPod(
 		return nil, err
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		jobID,
+	); err != nil {
+		return nil, err
+	}
+
 	pelotonJobID := &v0peloton.JobID{Value: jobID}
 	taskInfo, err := h.podStore.GetTaskForJob(ctx, jobID, instanceID)
 
@@ -718,11 +769,22 @@ func (h *serviceHandler) DeletePodEvents(
 			Info("PodSVC.DeletePodEvents succeeded")
 	}()
 
+	audit.AddPodName(ctx, req.GetPodName().GetValue())
+
 	jobID, instanceID, err := util.ParseTaskID(req.GetPodName().GetValue())
 	if err != nil {
 		return nil, err
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		h.jobStore,
+		h.respoolClient,
+		jobID,
+	); err != nil {
+		return nil, err
+	}
+
 	runID, err := util.ParseRunID(req.GetPodId().GetValue())
 	if err != nil {
 		return nil, err
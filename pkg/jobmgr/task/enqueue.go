@@ -24,26 +24,45 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 
+	"github.com/uber/peloton/pkg/common/tracing"
 	taskutil "github.com/uber/peloton/pkg/common/util/task"
 	jobmgrcommon "github.com/uber/peloton/pkg/jobmgr/common"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 )
 
 // EnqueueGangs enqueues all tasks organized in gangs to respool in resmgr.
+// It starts the trace of the placement of the tasks, which is carried
+// by the tasks to the placement engine and back to the launcher.
 func EnqueueGangs(
 	ctx context.Context,
 	tasks []*task.TaskInfo,
 	jobConfig jobmgrcommon.JobConfig,
 	client resmgrsvc.ResourceManagerServiceYARPCClient) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "jobmgr.enqueue_gangs")
+	defer span.Finish()
+	span.SetTag("task_count", len(tasks))
+
 	ctxWithTimeout, cancelFunc := context.WithTimeout(ctx, 10*time.Second)
 	defer cancelFunc()
 
 	gangs := taskutil.ConvertToResMgrGangs(tasks, jobConfig)
+	traceID := tracing.EncodeSpanContext(span.Context())
+	for _, gang := range gangs {
+		for _, t := range gang.GetTasks() {
+			t.TraceID = traceID
+		}
+	}
 	var request = &resmgrsvc.EnqueueGangsRequest{
 		Gangs:   gangs,
 		ResPool: jobConfig.GetRespoolID(),
 	}
 
 	response, err := client.EnqueueGangs(ctxWithTimeout, request)
+	if err != nil || response.GetError() != nil {
+		ext.Error.Set(span, true)
+	}
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{
 			"request": request,
@@ -21,6 +21,8 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/suite"
 
@@ -31,6 +33,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 
 	res_mocks "github.com/uber/peloton/.gen/peloton/private/resmgrsvc/mocks"
+	"github.com/uber/peloton/pkg/common/tracing"
 	taskutil "github.com/uber/peloton/pkg/common/util/task"
 )
 
@@ -172,3 +175,47 @@ func (suite *TaskUtilTestSuite) TestEnqueueGangsFailure() {
 		mockResmgrClient)
 	suite.Error(err)
 }
+
+// TestEnqueueGangsTraced tests that the enqueued tasks
+// carry the trace of their placement
+func (suite *TaskUtilTestSuite) TestEnqueueGangsTraced() {
+	ctrl := gomock.NewController(suite.T())
+	defer ctrl.Finish()
+
+	globalTracer := opentracing.GlobalTracer()
+	defer opentracing.SetGlobalTracer(globalTracer)
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+
+	mockResmgrClient := res_mocks.NewMockResourceManagerServiceYARPCClient(ctrl)
+	var tasksInfo []*task.TaskInfo
+	for _, v := range suite.taskInfos {
+		tasksInfo = append(tasksInfo, v)
+	}
+
+	var traceID string
+	mockResmgrClient.EXPECT().EnqueueGangs(gomock.Any(), gomock.Any()).
+		Do(func(ctx context.Context, req *resmgrsvc.EnqueueGangsRequest) {
+			traceID = tracing.TraceIDFromContext(ctx)
+			count := 0
+			for _, g := range req.GetGangs() {
+				for _, t := range g.GetTasks() {
+					suite.Equal(traceID, t.GetTraceID())
+					count++
+				}
+			}
+			suite.Equal(testInstanceCount, count)
+		}).
+		Return(&resmgrsvc.EnqueueGangsResponse{}, nil)
+
+	suite.NoError(EnqueueGangs(
+		context.Background(),
+		tasksInfo,
+		suite.testJobConfig,
+		mockResmgrClient))
+	suite.NotEmpty(traceID)
+
+	spans := tracer.FinishedSpans()
+	suite.Len(spans, 1)
+	suite.Equal("jobmgr.enqueue_gangs", spans[0].OperationName)
+}
@@ -30,6 +30,7 @@ import (
 
 	"github.com/uber/peloton/pkg/common"
 	"github.com/uber/peloton/pkg/common/lifecycle"
+	"github.com/uber/peloton/pkg/common/tracing"
 	"github.com/uber/peloton/pkg/common/util"
 	"github.com/uber/peloton/pkg/jobmgr/cached"
 	jobmgrcommon "github.com/uber/peloton/pkg/jobmgr/common"
@@ -160,10 +161,22 @@ func (p *processor) process() {
 
 func (p *processor) processPlacement(ctx context.Context, placement *resmgr.Placement) {
 	var tasks []*peloton.TaskID
+	var traceIDs []string
 	for _, t := range placement.GetTaskIDs() {
 		tasks = append(tasks, t.GetPelotonTaskID())
+		traceIDs = append(traceIDs, t.GetTraceID())
 	}
 
+	// continue the traces of the tasks, so that launching
+	// the tasks in host manager is part of them
+	span, ctx := tracing.StartSpanFromTraceIDs(
+		ctx,
+		"jobmgr.process_placement",
+		traceIDs,
+	)
+	defer span.Finish()
+	span.SetTag("hostname", placement.GetHostname())
+
 	launchableTasks, skippedTasks, err := p.taskLauncher.GetLaunchableTasks(
 		ctx,
 		tasks,
@@ -67,7 +67,7 @@ func CreateInitializingTask(jobID *peloton.JobID, instanceID uint32, jobConfig *
 // GetDefaultTaskGoalState from the job type.
 func GetDefaultTaskGoalState(jobType job.JobType) task.TaskState {
 	switch jobType {
-	case job.JobType_SERVICE:
+	case job.JobType_SERVICE, job.JobType_DAEMON:
 		return task.TaskState_RUNNING
 
 	default:
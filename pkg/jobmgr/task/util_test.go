@@ -351,6 +351,9 @@ func (suite *JobmgrTaskUtilTestSuite) TestGetDefaultTaskGoalState() {
 	state = GetDefaultTaskGoalState(job.JobType_BATCH)
 	suite.Equal(state, task.TaskState_SUCCEEDED)
 
+	state = GetDefaultTaskGoalState(job.JobType_DAEMON)
+	suite.Equal(state, task.TaskState_RUNNING)
+
 }
 
 // TestCreateSecretProto tests if CreateSecretProto creates a secret protobuf
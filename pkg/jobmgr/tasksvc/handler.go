@@ -25,11 +25,13 @@ import (
 	pb_job "github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/query"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 
 	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/audit"
 	"github.com/uber/peloton/pkg/common/leader"
 	"github.com/uber/peloton/pkg/common/util"
 	yarpcutil "github.com/uber/peloton/pkg/common/util/yarpc"
@@ -84,6 +86,7 @@ func InitServiceHandler(
 		frameworkInfoStore: frameworkInfoStore,
 		metrics:            NewMetrics(parent.SubScope("jobmgr").SubScope("task")),
 		resmgrClient:       resmgrsvc.NewResourceManagerServiceYARPCClient(d.ClientConfig(common.PelotonResourceManager)),
+		respoolClient:      respool.NewResourceManagerYARPCClient(d.ClientConfig(common.PelotonResourceManager)),
 		taskLauncher:       launcher.GetLauncher(),
 		jobFactory:         jobFactory,
 		goalStateDriver:    goalStateDriver,
@@ -104,6 +107,7 @@ type serviceHandler struct {
 	frameworkInfoStore storage.FrameworkInfoStore
 	metrics            *Metrics
 	resmgrClient       resmgrsvc.ResourceManagerServiceYARPCClient
+	respoolClient      respool.ResourceManagerYARPCClient
 	taskLauncher       launcher.Launcher
 	jobFactory         cached.JobFactory
 	goalStateDriver    goalstate.Driver
@@ -291,6 +295,15 @@ func (m *serviceHandler) DeletePodEvents(
 			Info("TaskManager.DeletePodEvents succeeded")
 	}()
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		m.jobStore,
+		m.respoolClient,
+		body.GetJobId().GetValue(),
+	); err != nil {
+		return nil, err
+	}
+
 	if err := m.taskStore.DeletePodEvents(
 		ctx,
 		body.GetJobId().GetValue(),
@@ -377,6 +390,8 @@ func (m *serviceHandler) Refresh(ctx context.Context, req *task.RefreshRequest)
 			Info("TaskManager.Refresh succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	m.metrics.TaskAPIRefresh.Inc(1)
 
 	if !m.candidate.IsLeader() {
@@ -384,7 +399,7 @@ func (m *serviceHandler) Refresh(ctx context.Context, req *task.RefreshRequest)
 		return nil, yarpcerrors.UnavailableErrorf("Task Refresh API not suppported on non-leader")
 	}
 
-	jobConfig, _, err := m.jobStore.GetJobConfig(ctx, req.GetJobId().GetValue())
+	jobConfig, configAddOn, err := m.jobStore.GetJobConfig(ctx, req.GetJobId().GetValue())
 	if err != nil {
 		log.WithError(err).
 			WithField("job_id", req.GetJobId().GetValue()).
@@ -393,6 +408,16 @@ func (m *serviceHandler) Refresh(ctx context.Context, req *task.RefreshRequest)
 		return &task.RefreshResponse{}, yarpcerrors.NotFoundErrorf("job not found")
 	}
 
+	if err := handlerutil.AuthorizeJobConfig(
+		ctx,
+		m.respoolClient,
+		jobConfig,
+		configAddOn,
+	); err != nil {
+		m.metrics.TaskRefreshFail.Inc(1)
+		return nil, err
+	}
+
 	reqRange := req.GetRange()
 	if reqRange == nil {
 		reqRange = &task.InstanceRange{
@@ -498,6 +523,8 @@ func (m *serviceHandler) Start(
 			Info("TaskManager.Start succeeded")
 	}()
 
+	audit.AddJobID(ctx, body.GetJobId().GetValue())
+
 	m.metrics.TaskAPIStart.Inc(1)
 	ctx, cancelFunc := context.WithTimeout(
 		ctx,
@@ -510,6 +537,16 @@ func (m *serviceHandler) Start(
 		return nil, yarpcerrors.UnavailableErrorf("Task Start API not suppported on non-leader")
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		m.jobStore,
+		m.respoolClient,
+		body.GetJobId().GetValue(),
+	); err != nil {
+		m.metrics.TaskStartFail.Inc(1)
+		return nil, err
+	}
+
 	cachedJob := m.jobFactory.AddJob(body.JobId)
 	cachedConfig, err := cachedJob.GetConfig(ctx)
 
@@ -790,6 +827,8 @@ func (m *serviceHandler) Stop(
 			Info("TaskManager.Stop succeeded")
 	}()
 
+	audit.AddJobID(ctx, body.GetJobId().GetValue())
+
 	m.metrics.TaskAPIStop.Inc(1)
 	ctx, cancelFunc := context.WithTimeout(
 		ctx,
@@ -802,6 +841,16 @@ func (m *serviceHandler) Stop(
 		return nil, yarpcerrors.UnavailableErrorf("Task Stop API not suppported on non-leader")
 	}
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		m.jobStore,
+		m.respoolClient,
+		body.GetJobId().GetValue(),
+	); err != nil {
+		m.metrics.TaskStopFail.Inc(1)
+		return nil, err
+	}
+
 	cachedJob := m.jobFactory.AddJob(body.JobId)
 	cachedConfig, err := cachedJob.GetConfig(ctx)
 
@@ -933,6 +982,8 @@ func (m *serviceHandler) Restart(
 			Info("TaskManager.Restart succeeded")
 	}()
 
+	audit.AddJobID(ctx, req.GetJobId().GetValue())
+
 	m.metrics.TaskAPIRestart.Inc(1)
 
 	if !m.candidate.IsLeader() {
@@ -948,6 +999,16 @@ func (m *serviceHandler) Restart(
 	)
 	defer cancelFunc()
 
+	if err := handlerutil.AuthorizeJob(
+		ctx,
+		m.jobStore,
+		m.respoolClient,
+		req.GetJobId().GetValue(),
+	); err != nil {
+		m.metrics.TaskRestartFail.Inc(1)
+		return nil, err
+	}
+
 	cachedJob := m.jobFactory.AddJob(req.JobId)
 	runtimeDiffs, err := m.getRuntimeDiffsForRestart(ctx,
 		cachedJob,
@@ -33,6 +33,9 @@ import (
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 
 	resmocks "github.com/uber/peloton/.gen/peloton/private/resmgrsvc/mocks"
+	"github.com/uber/peloton/pkg/auth"
+	authmocks "github.com/uber/peloton/pkg/auth/mocks"
+	"github.com/uber/peloton/pkg/common"
 	leadermocks "github.com/uber/peloton/pkg/common/leader/mocks"
 	cachedmocks "github.com/uber/peloton/pkg/jobmgr/cached/mocks"
 	goalstatemocks "github.com/uber/peloton/pkg/jobmgr/goalstate/mocks"
@@ -331,6 +334,45 @@ func (suite *TaskHandlerTestSuite) TestGetTasks_GetTaskFail() {
 		"test err")
 }
 
+// TestTaskMutationsPermissionDenied tests that the tasks of a job can't
+// be started, stopped or restarted by a caller not permitted on the job
+func (suite *TaskHandlerTestSuite) TestTaskMutationsPermissionDenied() {
+	user := authmocks.NewMockUser(suite.ctrl)
+	ctx := auth.ContextWithUser(context.Background(), user)
+
+	suite.mockedCandidate.EXPECT().IsLeader().Return(true).Times(3)
+	suite.mockedJobStore.EXPECT().
+		GetJobConfig(gomock.Any(), testJob).
+		Return(&job.JobConfig{OwningTeam: "web"}, &models.ConfigAddOn{
+			SystemLabels: []*peloton.Label{
+				{Key: common.SystemLabelResourcePool, Value: "/web/prod"},
+			},
+		}, nil).
+		Times(3)
+	user.EXPECT().
+		IsPermittedOnResource(gomock.Any(), &auth.Resource{
+			RespoolPath: "/web/prod",
+			OwningTeam:  "web",
+		}).
+		Return(false).
+		Times(3)
+
+	_, err := suite.handler.Start(ctx, &task.StartRequest{
+		JobId: suite.testJobID,
+	})
+	suite.True(yarpcerrors.IsPermissionDenied(err))
+
+	_, err = suite.handler.Stop(ctx, &task.StopRequest{
+		JobId: suite.testJobID,
+	})
+	suite.True(yarpcerrors.IsPermissionDenied(err))
+
+	_, err = suite.handler.Restart(ctx, &task.RestartRequest{
+		JobId: suite.testJobID,
+	})
+	suite.True(yarpcerrors.IsPermissionDenied(err))
+}
+
 func (suite *TaskHandlerTestSuite) TestStopAllTasks() {
 	expectedTaskIds := make(map[*mesos.TaskID]bool)
 	for _, taskInfo := range suite.taskInfos {
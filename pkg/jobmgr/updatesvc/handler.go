@@ -28,6 +28,7 @@ import (
 	versionutil "github.com/uber/peloton/pkg/common/util/entityversion"
 	"github.com/uber/peloton/pkg/jobmgr/cached"
 	"github.com/uber/peloton/pkg/jobmgr/goalstate"
+	jobconfig "github.com/uber/peloton/pkg/jobmgr/job/config"
 	jobutil "github.com/uber/peloton/pkg/jobmgr/util/job"
 	"github.com/uber/peloton/pkg/storage"
 
@@ -125,6 +126,11 @@ func (h *serviceHandler) CreateUpdate(
 		return nil, yarpcerrors.UnimplementedErrorf("in-place update is not supported yet")
 	}
 
+	if err := jobconfig.ValidateUpdateConfig(req.GetUpdateConfig()); err != nil {
+		h.metrics.UpdateCreateFail.Inc(1)
+		return nil, err
+	}
+
 	// Validate that the job does exist
 	jobRuntime, err := h.jobStore.GetJobRuntime(ctx, jobID.GetValue())
 	if err != nil {
@@ -160,6 +166,7 @@ func (h *serviceHandler) CreateUpdate(
 		h.metrics.UpdateCreateFail.Inc(1)
 		return nil, err
 	}
+	jobconfig.KeepAutoscaledInstanceCount(prevJobConfig, jobConfig)
 
 	var respoolPath string
 	for _, label := range prevConfigAddOn.GetSystemLabels() {
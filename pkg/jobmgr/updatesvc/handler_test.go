@@ -299,6 +299,28 @@ func (suite *UpdateSvcTestSuite) TestCreateBadJobUUID() {
 		"code:invalid-argument message:JobID must be of UUID format")
 }
 
+// TestCreateInvalidUpdateStrategy tests creating a job update
+// with an invalid update strategy
+func (suite *UpdateSvcTestSuite) TestCreateInvalidUpdateStrategy() {
+	_, err := suite.h.CreateUpdate(
+		context.Background(),
+		&svc.CreateUpdateRequest{
+			JobId:     suite.jobID,
+			JobConfig: suite.newJobConfig,
+			UpdateConfig: &update.UpdateConfig{
+				BatchSize: 1,
+				Strategy: &update.UpdateStrategy{
+					Stages: []*update.UpdateStage{
+						{InstanceCount: 5},
+						{InstanceCount: 1},
+					},
+				},
+			},
+		},
+	)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
 // TestCreateFailJobNotFound tests failing to find the job provided
 // in the create update request
 func (suite *UpdateSvcTestSuite) TestCreateFailJobNotFound() {
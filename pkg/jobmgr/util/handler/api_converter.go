@@ -338,6 +338,14 @@ func ConvertTaskConstraintsToPodConstraints(constraints []*task.Constraint) []*p
 			}
 		}
 
+		if constraint.GetNotConstraint() != nil {
+			podConstraint.NotConstraint = &pod.NotConstraint{}
+			if c := constraint.GetNotConstraint().GetConstraint(); c != nil {
+				podConstraint.NotConstraint.Constraint =
+					ConvertTaskConstraintsToPodConstraints([]*task.Constraint{c})[0]
+			}
+		}
+
 		podConstraints = append(podConstraints, podConstraint)
 	}
 	return podConstraints
@@ -422,6 +430,8 @@ func ConvertJobConfigToJobSpec(config *job.JobConfig) *stateless.JobSpec {
 		InstanceSpec:  instanceSpec,
 		RespoolId: &v1alphapeloton.ResourcePoolID{
 			Value: config.GetRespoolID().GetValue()},
+		AutoscalingPolicy: convertAutoscalingPolicyToV1Alpha(
+			config.GetAutoscalingPolicy()),
 	}
 }
 
@@ -559,6 +569,8 @@ func ConvertUpdateModelToWorkflowInfo(
 			MaxTolerableInstanceFailures: updateInfo.GetUpdateConfig().GetMaxFailureInstances(),
 			StartPaused:                  updateInfo.GetUpdateConfig().GetStartPaused(),
 			InPlace:                      updateInfo.GetUpdateConfig().GetInPlace(),
+			Strategy: convertUpdateStrategyToV1Alpha(
+				updateInfo.GetUpdateConfig().GetStrategy()),
 		}
 	} else if updateInfo.GetType() == models.WorkflowType_RESTART {
 		result.RestartSpec = &stateless.RestartSpec{
@@ -704,9 +716,52 @@ func ConvertJobSpecToJobConfig(spec *stateless.JobSpec) (*job.JobConfig, error)
 		}
 	}
 
+	result.AutoscalingPolicy = convertAutoscalingPolicyToV0(
+		spec.GetAutoscalingPolicy())
+
 	return result, nil
 }
 
+// convertAutoscalingPolicyToV0 converts v1alpha autoscaling policy
+// to v0 autoscaling policy
+func convertAutoscalingPolicyToV0(
+	policy *stateless.AutoscalingPolicy,
+) *job.AutoscalingPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &job.AutoscalingPolicy{
+		MinInstances:          policy.GetMinInstances(),
+		MaxInstances:          policy.GetMaxInstances(),
+		Metric:                policy.GetMetric(),
+		TargetValue:           policy.GetTargetValue(),
+		ScaleUpCooldownSecs:   policy.GetScaleUpCooldownSecs(),
+		ScaleDownCooldownSecs: policy.GetScaleDownCooldownSecs(),
+		BatchSize:             policy.GetBatchSize(),
+	}
+}
+
+// convertAutoscalingPolicyToV1Alpha converts v0 autoscaling policy
+// to v1alpha autoscaling policy
+func convertAutoscalingPolicyToV1Alpha(
+	policy *job.AutoscalingPolicy,
+) *stateless.AutoscalingPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &stateless.AutoscalingPolicy{
+		MinInstances:          policy.GetMinInstances(),
+		MaxInstances:          policy.GetMaxInstances(),
+		Metric:                policy.GetMetric(),
+		TargetValue:           policy.GetTargetValue(),
+		ScaleUpCooldownSecs:   policy.GetScaleUpCooldownSecs(),
+		ScaleDownCooldownSecs: policy.GetScaleDownCooldownSecs(),
+		BatchSize:             policy.GetBatchSize(),
+	}
+}
+
 // ConvertPodSpecToTaskConfig converts a pod spec to task config
 func ConvertPodSpecToTaskConfig(spec *pod.PodSpec) (*task.TaskConfig, error) {
 	if len(spec.GetContainers()) > 1 {
@@ -871,6 +926,14 @@ func ConvertPodConstraintsToTaskConstraints(
 			}
 		}
 
+		if podConstraint.GetNotConstraint() != nil {
+			taskConstraint.NotConstraint = &task.NotConstraint{}
+			if c := podConstraint.GetNotConstraint().GetConstraint(); c != nil {
+				taskConstraint.NotConstraint.Constraint =
+					ConvertPodConstraintsToTaskConstraints([]*pod.Constraint{c})[0]
+			}
+		}
+
 		result = append(result, taskConstraint)
 	}
 
@@ -887,6 +950,57 @@ func ConvertUpdateSpecToUpdateConfig(spec *stateless.UpdateSpec) *update.UpdateC
 		StartPaused:         spec.GetStartPaused(),
 		InPlace:             spec.GetInPlace(),
 		StartTasks:          spec.GetStartPods(),
+		Strategy:            convertUpdateStrategyToV0(spec.GetStrategy()),
+	}
+}
+
+// convertUpdateStrategyToV0 converts v1alpha update strategy
+// to v0 update strategy
+func convertUpdateStrategyToV0(
+	strategy *stateless.UpdateStrategy,
+) *update.UpdateStrategy {
+	if strategy == nil {
+		return nil
+	}
+
+	var stages []*update.UpdateStage
+	for _, stage := range strategy.GetStages() {
+		stages = append(stages, &update.UpdateStage{
+			InstanceCount:      stage.GetPodCount(),
+			InstancePercentage: stage.GetPodPercentage(),
+		})
+	}
+
+	return &update.UpdateStrategy{
+		Stages:            stages,
+		BakeTimeSecs:      strategy.GetBakeTimeSecs(),
+		MaxUnhealthyRatio: strategy.GetMaxUnhealthyRatio(),
+		UnhealthyAction:   update.UnhealthyAction(strategy.GetUnhealthyAction()),
+	}
+}
+
+// convertUpdateStrategyToV1Alpha converts v0 update strategy
+// to v1alpha update strategy
+func convertUpdateStrategyToV1Alpha(
+	strategy *update.UpdateStrategy,
+) *stateless.UpdateStrategy {
+	if strategy == nil {
+		return nil
+	}
+
+	var stages []*stateless.UpdateStage
+	for _, stage := range strategy.GetStages() {
+		stages = append(stages, &stateless.UpdateStage{
+			PodCount:      stage.GetInstanceCount(),
+			PodPercentage: stage.GetInstancePercentage(),
+		})
+	}
+
+	return &stateless.UpdateStrategy{
+		Stages:            stages,
+		BakeTimeSecs:      strategy.GetBakeTimeSecs(),
+		MaxUnhealthyRatio: strategy.GetMaxUnhealthyRatio(),
+		UnhealthyAction:   stateless.UnhealthyAction(strategy.GetUnhealthyAction()),
 	}
 }
 
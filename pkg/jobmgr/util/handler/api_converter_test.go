@@ -576,6 +576,21 @@ func (suite *apiConverterTestSuite) TestConvertTaskConstraintsToPodConstraintsAn
 				},
 			},
 		},
+		{
+			Type: task.Constraint_NOT_CONSTRAINT,
+			NotConstraint: &task.NotConstraint{
+				Constraint: &task.Constraint{
+					Type: task.Constraint_LABEL_CONSTRAINT,
+					LabelConstraint: &task.LabelConstraint{
+						Kind: task.LabelConstraint_HOST,
+						Label: &peloton.Label{
+							Key:   "hostname",
+							Value: "host",
+						},
+					},
+				},
+			},
+		},
 	}
 
 	podConstraints := []*pod.Constraint{
@@ -667,6 +682,21 @@ func (suite *apiConverterTestSuite) TestConvertTaskConstraintsToPodConstraintsAn
 				},
 			},
 		},
+		{
+			Type: pod.Constraint_CONSTRAINT_TYPE_NOT,
+			NotConstraint: &pod.NotConstraint{
+				Constraint: &pod.Constraint{
+					Type: pod.Constraint_CONSTRAINT_TYPE_LABEL,
+					LabelConstraint: &pod.LabelConstraint{
+						Kind: pod.LabelConstraint_LABEL_CONSTRAINT_KIND_HOST,
+						Label: &v1alphapeloton.Label{
+							Key:   "hostname",
+							Value: "host",
+						},
+					},
+				},
+			},
+		},
 	}
 
 	suite.Equal(podConstraints, ConvertTaskConstraintsToPodConstraints(taskConstraints))
@@ -1204,6 +1234,87 @@ func (suite *apiConverterTestSuite) TestConvertUpdateModelToWorkflowInfo() {
 	suite.Equal(updateModel.GetUpdateConfig().GetStartPaused(), workflowInfo.GetUpdateSpec().GetStartPaused())
 }
 
+// TestConvertUpdateStrategy tests conversion of the update strategy
+// from v1alpha UpdateSpec to v0 UpdateConfig and back
+func (suite *apiConverterTestSuite) TestConvertUpdateStrategy() {
+	spec := &stateless.UpdateSpec{
+		BatchSize: 2,
+		Strategy: &stateless.UpdateStrategy{
+			Stages: []*stateless.UpdateStage{
+				{PodCount: 1},
+				{PodPercentage: 25},
+				{PodPercentage: 100},
+			},
+			BakeTimeSecs:      300,
+			MaxUnhealthyRatio: 0.1,
+			UnhealthyAction:   stateless.UnhealthyAction_UNHEALTHY_ACTION_ROLLBACK,
+		},
+	}
+
+	updateConfig := ConvertUpdateSpecToUpdateConfig(spec)
+	suite.Equal(&update.UpdateStrategy{
+		Stages: []*update.UpdateStage{
+			{InstanceCount: 1},
+			{InstancePercentage: 25},
+			{InstancePercentage: 100},
+		},
+		BakeTimeSecs:      300,
+		MaxUnhealthyRatio: 0.1,
+		UnhealthyAction:   update.UnhealthyAction_UNHEALTHY_ACTION_ROLLBACK,
+	}, updateConfig.GetStrategy())
+
+	updateModel := &models.UpdateModel{
+		Type:         models.WorkflowType_UPDATE,
+		State:        update.State_ROLLING_FORWARD,
+		UpdateConfig: updateConfig,
+	}
+	workflowInfo := ConvertUpdateModelToWorkflowInfo(
+		&job.RuntimeInfo{}, updateModel, nil, nil)
+	suite.Equal(spec.GetStrategy(), workflowInfo.GetUpdateSpec().GetStrategy())
+
+	suite.Nil(ConvertUpdateSpecToUpdateConfig(
+		&stateless.UpdateSpec{BatchSize: 2}).GetStrategy())
+}
+
+// TestConvertAutoscalingPolicy tests conversion of the autoscaling policy
+// from v1alpha JobSpec to v0 JobConfig and back
+func (suite *apiConverterTestSuite) TestConvertAutoscalingPolicy() {
+	spec := &stateless.JobSpec{
+		Name:          "test-job",
+		InstanceCount: 2,
+		AutoscalingPolicy: &stateless.AutoscalingPolicy{
+			MinInstances:          2,
+			MaxInstances:          10,
+			Metric:                "cpu_utilization",
+			TargetValue:           0.6,
+			ScaleUpCooldownSecs:   60,
+			ScaleDownCooldownSecs: 300,
+			BatchSize:             2,
+		},
+	}
+
+	config, err := ConvertJobSpecToJobConfig(spec)
+	suite.NoError(err)
+	suite.Equal(&job.AutoscalingPolicy{
+		MinInstances:          2,
+		MaxInstances:          10,
+		Metric:                "cpu_utilization",
+		TargetValue:           0.6,
+		ScaleUpCooldownSecs:   60,
+		ScaleDownCooldownSecs: 300,
+		BatchSize:             2,
+	}, config.GetAutoscalingPolicy())
+	suite.Equal(
+		spec.GetAutoscalingPolicy(),
+		ConvertJobConfigToJobSpec(config).GetAutoscalingPolicy())
+
+	spec.AutoscalingPolicy = nil
+	config, err = ConvertJobSpecToJobConfig(spec)
+	suite.NoError(err)
+	suite.Nil(config.GetAutoscalingPolicy())
+	suite.Nil(ConvertJobConfigToJobSpec(config).GetAutoscalingPolicy())
+}
+
 // TestConvertUpdateModelToWorkflowInfoRestart tests conversion from
 // private UpdateModel to v1alpha stateless.WorkflowInfo for restart workflow type
 func (suite *apiConverterTestSuite) TestConvertUpdateModelToWorkflowInfoRestart() {
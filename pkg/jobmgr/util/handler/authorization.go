@@ -0,0 +1,166 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pelotonv0respool "github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/auth"
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/storage"
+
+	"github.com/pkg/errors"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// AuthorizeJob checks whether the caller is permitted to call the
+// procedure of the request on the job, using the current config of the
+// job. The config is only read for requests which went through auth.
+func AuthorizeJob(
+	ctx context.Context,
+	jobStore storage.JobStore,
+	respoolClient pelotonv0respool.ResourceManagerYARPCClient,
+	jobID string,
+) error {
+	if _, ok := auth.UserFromContext(ctx); !ok {
+		return nil
+	}
+
+	config, configAddOn, err := jobStore.GetJobConfig(ctx, jobID)
+	if err != nil {
+		return errors.Wrap(err, "fail to get job config for authorization")
+	}
+
+	return AuthorizeJobConfig(ctx, respoolClient, config, configAddOn)
+}
+
+// AuthorizeJobConfig checks whether the caller is permitted to call the
+// procedure of the request on the job with the config. The resource pool
+// path is read from the system labels of the job, and looked up from
+// resource manager for jobs created before the labels were added.
+func AuthorizeJobConfig(
+	ctx context.Context,
+	respoolClient pelotonv0respool.ResourceManagerYARPCClient,
+	config *job.JobConfig,
+	configAddOn *models.ConfigAddOn,
+) error {
+	if _, ok := auth.UserFromContext(ctx); !ok {
+		return nil
+	}
+
+	respoolPath := getRespoolPathFromSystemLabels(configAddOn)
+	if len(respoolPath) == 0 {
+		var err error
+		respoolPath, err = getRespoolPath(ctx, respoolClient, config.GetRespoolID())
+		if err != nil {
+			return err
+		}
+	}
+
+	return auth.AuthorizeResource(ctx, NewJobResource(config, respoolPath))
+}
+
+// AuthorizeJobCreation checks whether the caller is permitted to call the
+// procedure of the request to create a job in the resource pool. The
+// caller is authorized against the path and the owners of the resource
+// pool, never against the owners in the config of the new job, which are
+// chosen by the caller.
+func AuthorizeJobCreation(
+	ctx context.Context,
+	respoolClient pelotonv0respool.ResourceManagerYARPCClient,
+	respoolID *peloton.ResourcePoolID,
+) error {
+	if _, ok := auth.UserFromContext(ctx); !ok {
+		return nil
+	}
+
+	resp, err := respoolClient.GetResourcePool(
+		ctx,
+		&pelotonv0respool.GetRequest{Id: respoolID},
+	)
+	if err != nil {
+		return errors.Wrap(err, "fail to get resource pool for authorization")
+	}
+	if resp.GetError() != nil || resp.GetPoolinfo() == nil {
+		return yarpcerrors.NotFoundErrorf(
+			"resource pool %s not found", respoolID.GetValue())
+	}
+
+	return auth.AuthorizeResource(
+		ctx,
+		NewRespoolResource(resp.GetPoolinfo()),
+	)
+}
+
+// NewRespoolResource returns the resource used to authorize
+// requests which create jobs in the resource pool
+func NewRespoolResource(
+	respoolInfo *pelotonv0respool.ResourcePoolInfo) *auth.Resource {
+	return &auth.Resource{
+		RespoolPath: respoolInfo.GetPath().GetValue(),
+		OwningTeam:  respoolInfo.GetConfig().GetOwningTeam(),
+		LdapGroups:  respoolInfo.GetConfig().GetLdapGroups(),
+	}
+}
+
+// NewJobResource returns the resource used to authorize
+// requests on the job with the config
+func NewJobResource(config *job.JobConfig, respoolPath string) *auth.Resource {
+	return &auth.Resource{
+		RespoolPath: respoolPath,
+		Owner:       config.GetOwner(),
+		OwningTeam:  config.GetOwningTeam(),
+		LdapGroups:  config.GetLdapGroups(),
+	}
+}
+
+func getRespoolPathFromSystemLabels(configAddOn *models.ConfigAddOn) string {
+	for _, label := range configAddOn.GetSystemLabels() {
+		if label.GetKey() == common.SystemLabelResourcePool {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+// getRespoolPath returns the path of the resource pool, it returns
+// an empty path if no resource pool is specified
+func getRespoolPath(
+	ctx context.Context,
+	respoolClient pelotonv0respool.ResourceManagerYARPCClient,
+	respoolID *peloton.ResourcePoolID,
+) (string, error) {
+	if len(respoolID.GetValue()) == 0 {
+		return "", nil
+	}
+
+	resp, err := respoolClient.GetResourcePool(
+		ctx,
+		&pelotonv0respool.GetRequest{Id: respoolID},
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "fail to get resource pool for authorization")
+	}
+	if resp.GetError() != nil {
+		return "", yarpcerrors.NotFoundErrorf(
+			"resource pool %s not found", respoolID.GetValue())
+	}
+	return resp.GetPoolinfo().GetPath().GetValue(), nil
+}
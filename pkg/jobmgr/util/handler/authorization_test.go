@@ -0,0 +1,213 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/auth"
+	"github.com/uber/peloton/pkg/common"
+
+	respoolmocks "github.com/uber/peloton/.gen/peloton/api/v0/respool/mocks"
+	authmocks "github.com/uber/peloton/pkg/auth/mocks"
+	storemocks "github.com/uber/peloton/pkg/storage/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type AuthorizationTestSuite struct {
+	suite.Suite
+
+	ctrl          *gomock.Controller
+	jobStore      *storemocks.MockJobStore
+	respoolClient *respoolmocks.MockResourceManagerYARPCClient
+	user          *authmocks.MockUser
+	ctx           context.Context
+	jobID         string
+	respoolID     *peloton.ResourcePoolID
+	config        *job.JobConfig
+	resource      *auth.Resource
+}
+
+func TestAuthorization(t *testing.T) {
+	suite.Run(t, new(AuthorizationTestSuite))
+}
+
+func (suite *AuthorizationTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.jobStore = storemocks.NewMockJobStore(suite.ctrl)
+	suite.respoolClient = respoolmocks.NewMockResourceManagerYARPCClient(suite.ctrl)
+	suite.user = authmocks.NewMockUser(suite.ctrl)
+	suite.ctx = auth.ContextWithUser(context.Background(), suite.user)
+	suite.jobID = uuid.New()
+	suite.respoolID = &peloton.ResourcePoolID{Value: uuid.New()}
+	suite.config = &job.JobConfig{
+		RespoolID:  suite.respoolID,
+		Owner:      "alice",
+		OwningTeam: "infra",
+		LdapGroups: []string{"infra", "oncall"},
+	}
+	suite.resource = &auth.Resource{
+		RespoolPath: "/infra/compute",
+		Owner:       "alice",
+		OwningTeam:  "infra",
+		LdapGroups:  []string{"infra", "oncall"},
+	}
+}
+
+func (suite *AuthorizationTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+// TestAuthorizeJobWithoutUser tests that requests
+// which did not go through auth are permitted
+func (suite *AuthorizationTestSuite) TestAuthorizeJobWithoutUser() {
+	suite.NoError(AuthorizeJob(
+		context.Background(),
+		suite.jobStore,
+		suite.respoolClient,
+		suite.jobID,
+	))
+}
+
+// TestAuthorizeJobWithSystemLabels tests authorizing a job which has
+// its resource pool path in the system labels
+func (suite *AuthorizationTestSuite) TestAuthorizeJobWithSystemLabels() {
+	suite.jobStore.EXPECT().
+		GetJobConfig(gomock.Any(), suite.jobID).
+		Return(suite.config, &models.ConfigAddOn{
+			SystemLabels: []*peloton.Label{
+				{Key: common.SystemLabelResourcePool, Value: "/infra/compute"},
+			},
+		}, nil)
+	suite.user.EXPECT().
+		IsPermittedOnResource(gomock.Any(), suite.resource).
+		Return(true)
+
+	suite.NoError(AuthorizeJob(
+		suite.ctx,
+		suite.jobStore,
+		suite.respoolClient,
+		suite.jobID,
+	))
+}
+
+// TestAuthorizeJobLookupRespoolPath tests authorizing a job which
+// does not have its resource pool path in the system labels
+func (suite *AuthorizationTestSuite) TestAuthorizeJobLookupRespoolPath() {
+	suite.jobStore.EXPECT().
+		GetJobConfig(gomock.Any(), suite.jobID).
+		Return(suite.config, &models.ConfigAddOn{}, nil)
+	suite.respoolClient.EXPECT().
+		GetResourcePool(gomock.Any(), &respool.GetRequest{Id: suite.respoolID}).
+		Return(&respool.GetResponse{
+			Poolinfo: &respool.ResourcePoolInfo{
+				Path: &respool.ResourcePoolPath{Value: "/infra/compute"},
+			},
+		}, nil)
+	suite.user.EXPECT().
+		IsPermittedOnResource(gomock.Any(), suite.resource).
+		Return(false)
+
+	err := AuthorizeJob(
+		suite.ctx,
+		suite.jobStore,
+		suite.respoolClient,
+		suite.jobID,
+	)
+	suite.True(yarpcerrors.IsPermissionDenied(err))
+}
+
+// TestAuthorizeJobFailure tests the failures to get
+// the job config and the resource pool of the job
+func (suite *AuthorizationTestSuite) TestAuthorizeJobFailure() {
+	suite.jobStore.EXPECT().
+		GetJobConfig(gomock.Any(), suite.jobID).
+		Return(nil, nil, errors.New("test error"))
+	suite.Error(AuthorizeJob(
+		suite.ctx,
+		suite.jobStore,
+		suite.respoolClient,
+		suite.jobID,
+	))
+
+	suite.respoolClient.EXPECT().
+		GetResourcePool(gomock.Any(), gomock.Any()).
+		Return(&respool.GetResponse{
+			Error: &respool.GetResponse_Error{
+				NotFound: &respool.ResourcePoolNotFound{Id: suite.respoolID},
+			},
+		}, nil)
+	err := AuthorizeJobConfig(
+		suite.ctx,
+		suite.respoolClient,
+		suite.config,
+		nil,
+	)
+	suite.True(yarpcerrors.IsNotFound(err))
+}
+
+// TestAuthorizeJobCreation tests that the creation of a job is authorized
+// against its resource pool, not against the owners in the job config
+func (suite *AuthorizationTestSuite) TestAuthorizeJobCreation() {
+	suite.NoError(AuthorizeJobCreation(
+		context.Background(),
+		suite.respoolClient,
+		suite.respoolID,
+	))
+
+	suite.respoolClient.EXPECT().
+		GetResourcePool(gomock.Any(), &respool.GetRequest{Id: suite.respoolID}).
+		Return(&respool.GetResponse{
+			Poolinfo: &respool.ResourcePoolInfo{
+				Id:   suite.respoolID,
+				Path: &respool.ResourcePoolPath{Value: "/search/compute"},
+				Config: &respool.ResourcePoolConfig{
+					OwningTeam: "search",
+					LdapGroups: []string{"search"},
+				},
+			},
+		}, nil)
+	suite.user.EXPECT().
+		IsPermittedOnResource(gomock.Any(), &auth.Resource{
+			RespoolPath: "/search/compute",
+			OwningTeam:  "search",
+			LdapGroups:  []string{"search"},
+		}).
+		Return(false)
+
+	err := AuthorizeJobCreation(suite.ctx, suite.respoolClient, suite.respoolID)
+	suite.True(yarpcerrors.IsPermissionDenied(err))
+
+	suite.respoolClient.EXPECT().
+		GetResourcePool(gomock.Any(), gomock.Any()).
+		Return(&respool.GetResponse{
+			Error: &respool.GetResponse_Error{
+				NotFound: &respool.ResourcePoolNotFound{Id: suite.respoolID},
+			},
+		}, nil)
+	err = AuthorizeJobCreation(suite.ctx, suite.respoolClient, suite.respoolID)
+	suite.True(yarpcerrors.IsNotFound(err))
+}
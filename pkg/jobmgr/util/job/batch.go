@@ -0,0 +1,122 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/jobmgr/cached"
+	jobmgrcommon "github.com/uber/peloton/pkg/jobmgr/common"
+	"github.com/uber/peloton/pkg/jobmgr/goalstate"
+
+	"github.com/pkg/errors"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// JobCreator creates the batch jobs which job manager derives from other
+// entities, such as the runs of cron jobs and the nodes of pipelines.
+// It is implemented by the job service handler.
+type JobCreator interface {
+	Create(
+		ctx context.Context,
+		req *job.CreateRequest,
+	) (*job.CreateResponse, error)
+}
+
+// CreateBatchJob creates a batch job with the given ID. A batch job which
+// already exists is considered created, so that callers can derive the
+// ID deterministically and create the job again if they failed to record
+// it. It returns the error of the response if the job has been rejected
+// and will never be created, and an error if the creation should be
+// retried.
+func CreateBatchJob(
+	ctx context.Context,
+	jobCreator JobCreator,
+	jobFactory cached.JobFactory,
+	jobID *peloton.JobID,
+	config *job.JobConfig,
+) (*job.CreateResponse_Error, error) {
+	resp, err := jobCreator.Create(ctx, &job.CreateRequest{
+		Id:     jobID,
+		Config: config,
+	})
+	if err != nil {
+		if yarpcerrors.IsAlreadyExists(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if resp.GetError().GetAlreadyExists() != nil {
+		// the job service also reports the failures to persist the batch
+		// job as already exists, so only consider it created if it can
+		// be read back
+		if _, err := jobFactory.AddJob(jobID).GetRuntime(ctx); err != nil {
+			return nil, errors.Wrapf(err,
+				"failed to get batch job %s", jobID.GetValue())
+		}
+		return nil, nil
+	}
+	return resp.GetError(), nil
+}
+
+// KillBatchJob sets the goal state of a batch job to KILLED and enqueues
+// it into the goal state engine. Terminated batch jobs are left as is.
+func KillBatchJob(
+	ctx context.Context,
+	jobFactory cached.JobFactory,
+	goalStateDriver goalstate.Driver,
+	jobID *peloton.JobID,
+) error {
+	cachedJob := jobFactory.AddJob(jobID)
+
+	var count int
+	for {
+		runtime, err := cachedJob.GetRuntime(ctx)
+		if err != nil {
+			return err
+		}
+
+		if runtime.GetGoalState() == job.JobState_KILLED ||
+			util.IsPelotonJobStateTerminal(runtime.GetState()) {
+			return nil
+		}
+
+		runtime.DesiredStateVersion++
+		runtime.GoalState = job.JobState_KILLED
+
+		_, err = cachedJob.CompareAndSetRuntime(ctx, runtime)
+		if err == nil {
+			break
+		}
+
+		// concurrency error; retry MaxConcurrencyErrorRetry times
+		if err == jobmgrcommon.UnexpectedVersionError {
+			count = count + 1
+			if count < jobmgrcommon.MaxConcurrencyErrorRetry {
+				continue
+			}
+		}
+		return err
+	}
+
+	goalStateDriver.EnqueueJob(jobID, time.Now())
+	return nil
+}
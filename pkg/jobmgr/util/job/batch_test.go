@@ -0,0 +1,229 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"testing"
+
+	pbjob "github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+
+	cachedmocks "github.com/uber/peloton/pkg/jobmgr/cached/mocks"
+	jobmgrcommon "github.com/uber/peloton/pkg/jobmgr/common"
+	goalstatemocks "github.com/uber/peloton/pkg/jobmgr/goalstate/mocks"
+	jobutilmocks "github.com/uber/peloton/pkg/jobmgr/util/job/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type BatchJobTestSuite struct {
+	suite.Suite
+	mockCtrl *gomock.Controller
+
+	jobFactory      *cachedmocks.MockJobFactory
+	cachedJob       *cachedmocks.MockJob
+	goalStateDriver *goalstatemocks.MockDriver
+	jobCreator      *jobutilmocks.MockJobCreator
+
+	jobID  *peloton.JobID
+	config *pbjob.JobConfig
+}
+
+func (s *BatchJobTestSuite) SetupTest() {
+	s.mockCtrl = gomock.NewController(s.T())
+	s.jobFactory = cachedmocks.NewMockJobFactory(s.mockCtrl)
+	s.cachedJob = cachedmocks.NewMockJob(s.mockCtrl)
+	s.goalStateDriver = goalstatemocks.NewMockDriver(s.mockCtrl)
+	s.jobCreator = jobutilmocks.NewMockJobCreator(s.mockCtrl)
+
+	s.jobID = &peloton.JobID{Value: "b1f4a7a2-5c5d-4c57-9c34-0d7c0e6a4b8e"}
+	s.config = &pbjob.JobConfig{Type: pbjob.JobType_BATCH}
+}
+
+func (s *BatchJobTestSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestBatchJob(t *testing.T) {
+	suite.Run(t, new(BatchJobTestSuite))
+}
+
+func (s *BatchJobTestSuite) createBatchJob() (*pbjob.CreateResponse_Error, error) {
+	return CreateBatchJob(
+		context.Background(), s.jobCreator, s.jobFactory, s.jobID, s.config)
+}
+
+// TestCreateBatchJob tests creating a batch job
+func (s *BatchJobTestSuite) TestCreateBatchJob() {
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), &pbjob.CreateRequest{
+			Id:     s.jobID,
+			Config: s.config,
+		}).
+		Return(&pbjob.CreateResponse{JobId: s.jobID}, nil)
+
+	respErr, err := s.createBatchJob()
+	s.NoError(err)
+	s.Nil(respErr)
+}
+
+// TestCreateBatchJobError tests that a failure to create a batch job
+// is returned to be retried
+func (s *BatchJobTestSuite) TestCreateBatchJobError() {
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.UnavailableErrorf("unavailable"))
+
+	_, err := s.createBatchJob()
+	s.Error(err)
+}
+
+// TestCreateBatchJobAlreadyExistsError tests that a batch job reported
+// as already existing by an error is considered created
+func (s *BatchJobTestSuite) TestCreateBatchJobAlreadyExistsError() {
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(nil, yarpcerrors.AlreadyExistsErrorf("already exists"))
+
+	respErr, err := s.createBatchJob()
+	s.NoError(err)
+	s.Nil(respErr)
+}
+
+// TestCreateBatchJobAlreadyExists tests that a batch job reported as
+// already existing by the response is considered created once it has
+// been read back
+func (s *BatchJobTestSuite) TestCreateBatchJobAlreadyExists() {
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&pbjob.CreateResponse{
+			Error: &pbjob.CreateResponse_Error{
+				AlreadyExists: &pbjob.JobAlreadyExists{Id: s.jobID},
+			},
+		}, nil)
+	s.jobFactory.EXPECT().AddJob(s.jobID).Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{State: pbjob.JobState_PENDING}, nil)
+
+	respErr, err := s.createBatchJob()
+	s.NoError(err)
+	s.Nil(respErr)
+}
+
+// TestCreateBatchJobAlreadyExistsNotCreated tests that a batch job
+// reported as already existing which cannot be read back is retried
+func (s *BatchJobTestSuite) TestCreateBatchJobAlreadyExistsNotCreated() {
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&pbjob.CreateResponse{
+			Error: &pbjob.CreateResponse_Error{
+				AlreadyExists: &pbjob.JobAlreadyExists{Id: s.jobID},
+			},
+		}, nil)
+	s.jobFactory.EXPECT().AddJob(s.jobID).Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(nil, errors.New("not found"))
+
+	_, err := s.createBatchJob()
+	s.Error(err)
+}
+
+// TestCreateBatchJobInvalid tests that the error of the response of a
+// rejected batch job is returned
+func (s *BatchJobTestSuite) TestCreateBatchJobInvalid() {
+	s.jobCreator.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&pbjob.CreateResponse{
+			Error: &pbjob.CreateResponse_Error{
+				InvalidConfig: &pbjob.InvalidJobConfig{Id: s.jobID},
+			},
+		}, nil)
+
+	respErr, err := s.createBatchJob()
+	s.NoError(err)
+	s.NotNil(respErr.GetInvalidConfig())
+}
+
+// TestKillBatchJob tests setting the goal state of a batch job to KILLED
+func (s *BatchJobTestSuite) TestKillBatchJob() {
+	s.jobFactory.EXPECT().AddJob(s.jobID).Return(s.cachedJob)
+	gomock.InOrder(
+		s.cachedJob.EXPECT().
+			GetRuntime(gomock.Any()).
+			Return(&pbjob.RuntimeInfo{
+				State:     pbjob.JobState_RUNNING,
+				GoalState: pbjob.JobState_SUCCEEDED,
+			}, nil),
+		s.cachedJob.EXPECT().
+			CompareAndSetRuntime(gomock.Any(), gomock.Any()).
+			Return(nil, jobmgrcommon.UnexpectedVersionError),
+		s.cachedJob.EXPECT().
+			GetRuntime(gomock.Any()).
+			Return(&pbjob.RuntimeInfo{
+				State:     pbjob.JobState_RUNNING,
+				GoalState: pbjob.JobState_SUCCEEDED,
+			}, nil),
+		s.cachedJob.EXPECT().
+			CompareAndSetRuntime(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, runtime *pbjob.RuntimeInfo) {
+				s.Equal(pbjob.JobState_KILLED, runtime.GetGoalState())
+				s.Equal(uint64(1), runtime.GetDesiredStateVersion())
+			}).
+			Return(nil, nil),
+	)
+	s.goalStateDriver.EXPECT().EnqueueJob(s.jobID, gomock.Any())
+
+	s.NoError(KillBatchJob(
+		context.Background(), s.jobFactory, s.goalStateDriver, s.jobID))
+}
+
+// TestKillBatchJobTerminated tests that a terminated batch job is not
+// killed again
+func (s *BatchJobTestSuite) TestKillBatchJobTerminated() {
+	s.jobFactory.EXPECT().AddJob(s.jobID).Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{
+			State:     pbjob.JobState_SUCCEEDED,
+			GoalState: pbjob.JobState_SUCCEEDED,
+		}, nil)
+
+	s.NoError(KillBatchJob(
+		context.Background(), s.jobFactory, s.goalStateDriver, s.jobID))
+}
+
+// TestKillBatchJobError tests that a failure to update the runtime of
+// a batch job is returned
+func (s *BatchJobTestSuite) TestKillBatchJobError() {
+	s.jobFactory.EXPECT().AddJob(s.jobID).Return(s.cachedJob)
+	s.cachedJob.EXPECT().
+		GetRuntime(gomock.Any()).
+		Return(&pbjob.RuntimeInfo{
+			State:     pbjob.JobState_RUNNING,
+			GoalState: pbjob.JobState_SUCCEEDED,
+		}, nil)
+	s.cachedJob.EXPECT().
+		CompareAndSetRuntime(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("db error"))
+
+	s.Error(KillBatchJob(
+		context.Background(), s.jobFactory, s.goalStateDriver, s.jobID))
+}
@@ -15,8 +15,9 @@
 package watchsvc
 
 const (
-	_defaultBufferSize int = 100
-	_defaultMaxClient  int = 1000
+	_defaultBufferSize  int = 100
+	_defaultMaxClient   int = 1000
+	_defaultHistorySize int = 10000
 )
 
 // Config for Watch API
@@ -26,6 +27,10 @@ type Config struct {
 
 	// Maximum number of concurrent watch clients
 	MaxClient int `yaml:"max_client"`
+
+	// Number of the last changes kept to be replayed to the clients
+	// resuming their watch from a start revision
+	HistorySize int `yaml:"history_size"`
 }
 
 func (c *Config) normalize() {
@@ -35,4 +40,7 @@ func (c *Config) normalize() {
 	if c.MaxClient <= 0 {
 		c.MaxClient = _defaultMaxClient
 	}
+	if c.HistorySize <= 0 {
+		c.HistorySize = _defaultHistorySize
+	}
 }
@@ -62,7 +62,8 @@ func InitV1AlphaWatchServiceHandler(
 
 // Watch creates a watch to get notified about changes to Peloton objects.
 // Changed objects are streamed back to the caller till the watch is
-// cancelled.
+// cancelled. If the start revision is set, the changes since the start
+// revision are streamed back first.
 func (h *ServiceHandler) Watch(
 	req *svc.WatchRequest,
 	stream svc.WatchServiceServiceWatchYARPCServer,
@@ -72,7 +73,10 @@ func (h *ServiceHandler) Watch(
 		log.WithField("request", req).
 			Debug("starting new pod watch")
 
-		watchID, watchClient, err := h.processor.NewTaskClient(req.GetPodFilter())
+		watchID, watchClient, err := h.processor.NewTaskClient(
+			req.GetPodFilter(),
+			req.GetStartRevision(),
+		)
 		if err != nil {
 			log.WithError(err).
 				Warn("failed to create pod watch client")
@@ -84,7 +88,8 @@ func (h *ServiceHandler) Watch(
 		}()
 
 		initResp := &svc.WatchResponse{
-			WatchId: watchID,
+			WatchId:  watchID,
+			Revision: watchClient.Revision,
 		}
 		if err := stream.Send(initResp); err != nil {
 			log.WithField("watch_id", watchID).
@@ -95,10 +100,11 @@ func (h *ServiceHandler) Watch(
 
 		for {
 			select {
-			case p := <-watchClient.Input:
+			case e := <-watchClient.Input:
 				resp := &svc.WatchResponse{
-					WatchId: watchID,
-					Pods:    []*pod.PodSummary{p},
+					WatchId:  watchID,
+					Revision: e.Revision,
+					Pods:     []*pod.PodSummary{e.Pod},
 				}
 				if err := stream.Send(resp); err != nil {
 					log.WithField("watch_id", watchID).
@@ -111,11 +111,11 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch() {
 		// do not set buffer size for input to make sure the
 		// tests sends all the events before sending stop
 		// signal
-		Input:  make(chan *pod.PodSummary),
+		Input:  make(chan *TaskEvent),
 		Signal: make(chan StopSignal, 1),
 	}
 
-	suite.processor.EXPECT().NewTaskClient(gomock.Any()).
+	suite.processor.EXPECT().NewTaskClient(gomock.Any(), gomock.Any()).
 		Return(watchID, taskClient, nil)
 	suite.processor.EXPECT().StopTaskClient(watchID)
 
@@ -134,11 +134,12 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch() {
 			Pods:    nil,
 		}).
 		Return(nil)
-	for _, p := range pods {
+	for i, p := range pods {
 		suite.watchServer.EXPECT().
 			Send(&watchsvc.WatchResponse{
-				WatchId: watchID,
-				Pods:    []*pod.PodSummary{p},
+				WatchId:  watchID,
+				Revision: uint64(i + 1),
+				Pods:     []*pod.PodSummary{p},
 			}).
 			Return(nil)
 	}
@@ -148,8 +149,8 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch() {
 	}
 
 	go func() {
-		for _, p := range pods {
-			taskClient.Input <- p
+		for i, p := range pods {
+			taskClient.Input <- &TaskEvent{Revision: uint64(i + 1), Pod: p}
 		}
 		// cancelling task watch
 		taskClient.Signal <- StopSignalCancel
@@ -170,11 +171,11 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_Overflow() {
 		// do not set buffer size for input to make sure the
 		// tests sends all the events before sending stop
 		// signal
-		Input:  make(chan *pod.PodSummary),
+		Input:  make(chan *TaskEvent),
 		Signal: make(chan StopSignal, 1),
 	}
 
-	suite.processor.EXPECT().NewTaskClient(gomock.Any()).
+	suite.processor.EXPECT().NewTaskClient(gomock.Any(), gomock.Any()).
 		Return(watchID, taskClient, nil)
 	suite.processor.EXPECT().StopTaskClient(watchID)
 
@@ -193,11 +194,12 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_Overflow() {
 			Pods:    nil,
 		}).
 		Return(nil)
-	for _, p := range pods {
+	for i, p := range pods {
 		suite.watchServer.EXPECT().
 			Send(&watchsvc.WatchResponse{
-				WatchId: watchID,
-				Pods:    []*pod.PodSummary{p},
+				WatchId:  watchID,
+				Revision: uint64(i + 1),
+				Pods:     []*pod.PodSummary{p},
 			}).
 			Return(nil)
 	}
@@ -207,8 +209,8 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_Overflow() {
 	}
 
 	go func() {
-		for _, p := range pods {
-			taskClient.Input <- p
+		for i, p := range pods {
+			taskClient.Input <- &TaskEvent{Revision: uint64(i + 1), Pod: p}
 		}
 		// simulate buffer overflow
 		taskClient.Signal <- StopSignalOverflow
@@ -222,7 +224,7 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_Overflow() {
 // TestTaskWatch_MaxClientReached checks Watch will return resource-exhausted
 // error when NewTaskClient reached max client.
 func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_MaxClientReached() {
-	suite.processor.EXPECT().NewTaskClient(gomock.Any()).
+	suite.processor.EXPECT().NewTaskClient(gomock.Any(), gomock.Any()).
 		Return("", nil, yarpcerrors.ResourceExhaustedErrorf("max client reached"))
 
 	req := &watchsvc.WatchRequest{
@@ -241,11 +243,11 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_InitSendError() {
 		// do not set buffer size for input to make sure the
 		// tests sends all the events before sending stop
 		// signal
-		Input:  make(chan *pod.PodSummary),
+		Input:  make(chan *TaskEvent),
 		Signal: make(chan StopSignal, 1),
 	}
 
-	suite.processor.EXPECT().NewTaskClient(gomock.Any()).
+	suite.processor.EXPECT().NewTaskClient(gomock.Any(), gomock.Any()).
 		Return(watchID, taskClient, nil)
 	suite.processor.EXPECT().StopTaskClient(watchID)
 
@@ -276,11 +278,11 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_SendError() {
 		// do not set buffer size for input to make sure the
 		// tests sends all the events before sending stop
 		// signal
-		Input:  make(chan *pod.PodSummary),
+		Input:  make(chan *TaskEvent),
 		Signal: make(chan StopSignal, 1),
 	}
 
-	suite.processor.EXPECT().NewTaskClient(gomock.Any()).
+	suite.processor.EXPECT().NewTaskClient(gomock.Any(), gomock.Any()).
 		Return(watchID, taskClient, nil)
 	suite.processor.EXPECT().StopTaskClient(watchID)
 
@@ -301,8 +303,9 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_SendError() {
 	// subsequent response
 	suite.watchServer.EXPECT().
 		Send(&watchsvc.WatchResponse{
-			WatchId: watchID,
-			Pods:    []*pod.PodSummary{p},
+			WatchId:  watchID,
+			Revision: 1,
+			Pods:     []*pod.PodSummary{p},
 		}).
 		Return(sendErr)
 
@@ -311,7 +314,7 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_SendError() {
 	}
 
 	go func() {
-		taskClient.Input <- p
+		taskClient.Input <- &TaskEvent{Revision: 1, Pod: p}
 		taskClient.Signal <- StopSignalCancel
 	}()
 
@@ -320,6 +323,67 @@ func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_SendError() {
 	suite.Equal(sendErr, err)
 }
 
+// TestTaskWatch_StartRevision tests that the start revision is passed
+// to the watch processor, and that the initial response contains the
+// revision of the watch processor.
+func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_StartRevision() {
+	watchID := NewWatchID(ClientTypeTask)
+	taskClient := &TaskClient{
+		Revision: 20,
+		Input:    make(chan *TaskEvent),
+		Signal:   make(chan StopSignal, 1),
+	}
+	p := &pod.PodSummary{
+		PodName: &peloton.PodName{Value: "pod-0"},
+	}
+
+	suite.processor.EXPECT().NewTaskClient(gomock.Any(), uint64(10)).
+		Return(watchID, taskClient, nil)
+	suite.processor.EXPECT().StopTaskClient(watchID)
+
+	suite.watchServer.EXPECT().
+		Send(&watchsvc.WatchResponse{
+			WatchId:  watchID,
+			Revision: 20,
+		}).
+		Return(nil)
+	suite.watchServer.EXPECT().
+		Send(&watchsvc.WatchResponse{
+			WatchId:  watchID,
+			Revision: 12,
+			Pods:     []*pod.PodSummary{p},
+		}).
+		Return(nil)
+
+	req := &watchsvc.WatchRequest{
+		StartRevision: 10,
+		PodFilter:     &watch.PodFilter{},
+	}
+
+	go func() {
+		taskClient.Input <- &TaskEvent{Revision: 12, Pod: p}
+		taskClient.Signal <- StopSignalCancel
+	}()
+
+	err := suite.handler.Watch(req, suite.watchServer)
+	suite.True(yarpcerrors.IsCancelled(err))
+}
+
+// TestTaskWatch_StartRevisionOutOfRange checks Watch will return
+// out-of-range error when the start revision is too old.
+func (suite *WatchServiceHandlerTestSuite) TestTaskWatch_StartRevisionOutOfRange() {
+	suite.processor.EXPECT().NewTaskClient(gomock.Any(), uint64(10)).
+		Return("", nil, yarpcerrors.OutOfRangeErrorf("start revision too old"))
+
+	req := &watchsvc.WatchRequest{
+		StartRevision: 10,
+		PodFilter:     &watch.PodFilter{},
+	}
+
+	err := suite.handler.Watch(req, suite.watchServer)
+	suite.True(yarpcerrors.IsOutOfRange(err))
+}
+
 // TestCancel tests Cancel request are proxied to watch processor correctly.
 func (suite *WatchServiceHandlerTestSuite) TestCancel() {
 	watchID := NewWatchID(ClientTypeTask)
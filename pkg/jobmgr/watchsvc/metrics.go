@@ -20,8 +20,12 @@ import (
 
 // Metrics is a placeholder for all metrics in watch api.
 type Metrics struct {
-	WatchPodCancel   tally.Counter
-	WatchPodOverflow tally.Counter
+	WatchPodCancel     tally.Counter
+	WatchPodOverflow   tally.Counter
+	WatchPodOutOfRange tally.Counter
+
+	// Number of pod changes replayed to the clients resuming their watch
+	TaskEventsReplayed tally.Counter
 
 	CancelNotFound tally.Counter
 
@@ -33,8 +37,11 @@ type Metrics struct {
 func NewMetrics(scope tally.Scope) *Metrics {
 	subScope := scope.SubScope("watch")
 	return &Metrics{
-		WatchPodCancel:   subScope.Counter("watch_pod_cancel"),
-		WatchPodOverflow: subScope.Counter("watch_pod_overflow"),
+		WatchPodCancel:     subScope.Counter("watch_pod_cancel"),
+		WatchPodOverflow:   subScope.Counter("watch_pod_overflow"),
+		WatchPodOutOfRange: subScope.Counter("watch_pod_out_of_range"),
+
+		TaskEventsReplayed: subScope.Counter("task_events_replayed"),
 
 		CancelNotFound: subScope.Counter("cancel_not_found"),
 
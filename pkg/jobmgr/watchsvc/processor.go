@@ -15,7 +15,9 @@
 package watchsvc
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
 	"sync"
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
@@ -23,6 +25,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/watch"
 
+	"github.com/uber/peloton/pkg/common/cirbuf"
 	"github.com/uber/peloton/pkg/common/util"
 
 	"github.com/pborman/uuid"
@@ -57,6 +60,10 @@ func (s StopSignal) String() string {
 	}
 }
 
+// _revisionTermShift is the shift of the leadership term in the revisions,
+// the revisions of a term are its changes counted in the lower bits
+const _revisionTermShift = 32
+
 // ClientType is a enum string to be embedded in the watch id
 // returned to the client, used to indicate the watch client type.
 type ClientType string
@@ -76,18 +83,27 @@ func (t ClientType) String() string {
 // client lifecycle, and task / job event fan-out.
 type WatchProcessor interface {
 	// NewTaskClient creates a new watch client for task event changes.
-	// Returns the watch id and a new instance of TaskClient.
-	NewTaskClient(filter *watch.PodFilter) (string, *TaskClient, error)
-
-	// StopTaskClients stops all the task clients on leadership change.
+	// Returns the watch id and a new instance of TaskClient. If the start
+	// revision is set, the changes since the start revision which are
+	// still in the history are replayed to the client. Returns
+	// "out-of-range" error if the start revision is older than the
+	// history, and "invalid-argument" error if it is newer than the
+	// current revision.
+	NewTaskClient(
+		filter *watch.PodFilter,
+		startRevision uint64,
+	) (string, *TaskClient, error)
+
+	// StopTaskClients stops all the task clients on leadership change,
+	// and clears the history of changes.
 	StopTaskClients()
 
 	// StopTaskClient stops a task watch client. Returns "not-found" error
 	// if the corresponding watch client is not found.
 	StopTaskClient(watchID string) error
 
-	// NotifyTaskChange receives pod event, and notifies all the clients
-	// which are interested in the pod.
+	// NotifyTaskChange receives pod event, assigns it the next revision,
+	// and notifies all the clients which are interested in the pod.
 	NotifyTaskChange(pod *pod.PodSummary, podLabels []*peloton.Label)
 }
 
@@ -96,19 +112,43 @@ type watchProcessor struct {
 	sync.Mutex
 	bufferSize  int
 	maxClient   int
+	historySize int
 	taskClients map[string]*TaskClient
 	jobClients  map[string]*JobClient
 	metrics     *Metrics
+
+	// revision assigned to the last pod change. Its upper bits identify
+	// the leadership term, so that a client resuming a watch started on
+	// another leader, or before a leader change, queries the pods again
+	// instead of getting the changes of unrelated revisions.
+	revision uint64
+	// history of the last pod changes, used to replay the changes missed
+	// by a client which resumes its watch
+	history *cirbuf.CircularBuffer
 }
 
 var processor *watchProcessor
 var onceInitWatchProcessor sync.Once
 
+// TaskEvent is a pod change along with the revision assigned to it.
+type TaskEvent struct {
+	Revision uint64
+	Pod      *pod.PodSummary
+}
+
+// taskChange is a pod change kept in the history of the watch processor.
+type taskChange struct {
+	event  *TaskEvent
+	labels []*peloton.Label
+}
+
 // TaskClient represents a client which interested in task event changes.
 type TaskClient struct {
 	Filter *watch.PodFilter
-	Input  chan *pod.PodSummary
-	Signal chan StopSignal
+	// Revision of the watch processor when the client was created
+	Revision uint64
+	Input    chan *TaskEvent
+	Signal   chan StopSignal
 }
 
 // JobClient represents a client which interested in job event changes.
@@ -125,13 +165,16 @@ func newWatchProcessor(
 	parent tally.Scope,
 ) *watchProcessor {
 	cfg.normalize()
-	return &watchProcessor{
+	p := &watchProcessor{
 		bufferSize:  cfg.BufferSize,
 		maxClient:   cfg.MaxClient,
+		historySize: cfg.HistorySize,
 		taskClients: make(map[string]*TaskClient),
 		jobClients:  make(map[string]*JobClient),
 		metrics:     NewMetrics(parent),
 	}
+	p.startTerm()
+	return p
 }
 
 // InitWatchProcessor initializes WatchProcessor singleton.
@@ -157,7 +200,10 @@ func NewWatchID(clientType ClientType) string {
 
 // NewTaskClient creates a new watch client for task event changes.
 // Returns the watch id and a new instance of TaskClient.
-func (p *watchProcessor) NewTaskClient(filter *watch.PodFilter) (string, *TaskClient, error) {
+func (p *watchProcessor) NewTaskClient(
+	filter *watch.PodFilter,
+	startRevision uint64,
+) (string, *TaskClient, error) {
 	sw := p.metrics.ProcessorLockDuration.Start()
 	p.Lock()
 	defer p.Unlock()
@@ -167,20 +213,109 @@ func (p *watchProcessor) NewTaskClient(filter *watch.PodFilter) (string, *TaskCl
 		return "", nil, yarpcerrors.ResourceExhaustedErrorf("max client reached")
 	}
 
+	var events []*TaskEvent
+	if startRevision != 0 {
+		var err error
+		if events, err = p.getTaskHistory(filter, startRevision); err != nil {
+			return "", nil, err
+		}
+	}
+
 	watchID := NewWatchID(ClientTypeTask)
-	p.taskClients[watchID] = &TaskClient{
-		Input: make(chan *pod.PodSummary, p.bufferSize),
+	c := &TaskClient{
+		Revision: p.revision,
+		// Make room for the replayed changes so that the client does not
+		// overflow before reading them
+		Input: make(chan *TaskEvent, p.bufferSize+len(events)),
 		// Make buffer size 1 so that sender is not blocked when sending
 		// the Signal
 		Signal: make(chan StopSignal, 1),
 		Filter: filter,
 	}
+	for _, e := range events {
+		c.Input <- e
+	}
+	p.taskClients[watchID] = c
+	p.metrics.TaskEventsReplayed.Inc(int64(len(events)))
 
-	log.WithField("watch_id", watchID).Info("task watch client created")
-	return watchID, p.taskClients[watchID], nil
+	log.WithFields(log.Fields{
+		"watch_id":       watchID,
+		"start_revision": startRevision,
+		"replayed":       len(events),
+	}).Info("task watch client created")
+	return watchID, c, nil
 }
 
-// StopTaskClients stops all the task clients on job manager leader change
+// getTaskHistory returns the pod changes since the start revision which
+// match the filter.
+// Note: not thread safe and need to be called with lock
+func (p *watchProcessor) getTaskHistory(
+	filter *watch.PodFilter,
+	startRevision uint64,
+) ([]*TaskEvent, error) {
+	if startRevision>>_revisionTermShift != p.revision>>_revisionTermShift {
+		p.metrics.WatchPodOutOfRange.Inc(1)
+		return nil, yarpcerrors.OutOfRangeErrorf(
+			"start revision %d is not from the current leader, "+
+				"pods need to be queried again", startRevision)
+	}
+
+	if startRevision > p.revision+1 {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"start revision %d is newer than server revision %d",
+			startRevision, p.revision)
+	}
+
+	oldest := p.revision + 1 - uint64(p.history.Size())
+	if startRevision < oldest {
+		p.metrics.WatchPodOutOfRange.Inc(1)
+		return nil, yarpcerrors.OutOfRangeErrorf(
+			"start revision %d is older than the oldest revision %d, "+
+				"pods need to be queried again", startRevision, oldest)
+	}
+
+	var events []*TaskEvent
+	if startRevision > p.revision {
+		return events, nil
+	}
+
+	// the last change is at sequence head-1 in the history
+	head, _ := p.history.GetRange()
+	items, err := p.history.GetItemsByRange(
+		head-(p.revision+1-startRevision), head-1)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		c := item.Value.(*taskChange)
+		if matchPodFilter(filter, c.event.Pod, c.labels) {
+			events = append(events, c.event)
+		}
+	}
+	return events, nil
+}
+
+// addTaskHistory adds a pod change to the history, dropping the oldest
+// change if the history is full.
+// Note: not thread safe and need to be called with lock
+func (p *watchProcessor) addTaskHistory(c *taskChange) {
+	if p.history.Size() >= p.history.Capacity() {
+		_, tail := p.history.GetRange()
+		if _, err := p.history.MoveTail(tail + 1); err != nil {
+			log.WithError(err).Warn("failed to drop oldest pod change")
+			return
+		}
+	}
+
+	if _, err := p.history.AddItem(c); err != nil {
+		log.WithError(err).Warn("failed to add pod change to history")
+	}
+}
+
+// StopTaskClients stops all the task clients on job manager leader change.
+// The changes are not notified while not being leader, so a new term is
+// started for clients resuming their watch to query the pods again.
 func (p *watchProcessor) StopTaskClients() {
 	p.Lock()
 	defer p.Unlock()
@@ -188,6 +323,19 @@ func (p *watchProcessor) StopTaskClients() {
 	for watchID := range p.taskClients {
 		p.stopTaskClient(watchID, StopSignalCancel)
 	}
+	p.startTerm()
+}
+
+// startTerm clears the history and moves the revision to a new randomly
+// chosen term, the terms of the job manager instances are not persisted.
+// Note: not thread safe and need to be called with lock
+func (p *watchProcessor) startTerm() {
+	term := p.revision >> _revisionTermShift
+	for term == p.revision>>_revisionTermShift {
+		term = uint64(binary.BigEndian.Uint32(uuid.NewRandom()))
+	}
+	p.revision = term << _revisionTermShift
+	p.history = cirbuf.NewCircularBuffer(p.historySize)
 }
 
 // StopTaskClient stops a task watch client. Returns "not-found" error
@@ -222,8 +370,9 @@ func (p *watchProcessor) stopTaskClient(
 	return nil
 }
 
-// NotifyTaskChange receives pod event, and notifies all the clients
-// which are interested in the pod.
+// NotifyTaskChange receives pod event, assigns it the next revision,
+// adds it to the history and notifies all the clients which are
+// interested in the pod.
 func (p *watchProcessor) NotifyTaskChange(
 	pod *pod.PodSummary,
 	podLabels []*peloton.Label) {
@@ -232,74 +381,88 @@ func (p *watchProcessor) NotifyTaskChange(
 	defer p.Unlock()
 	sw.Stop()
 
-	for watchID, c := range p.taskClients {
-		filterCheckPass := true
-
-		if c.Filter != nil {
+	// the changes of a term are exhausted
+	if uint32(p.revision) == math.MaxUint32 {
+		p.startTerm()
+	}
+	p.revision++
+	event := &TaskEvent{
+		Revision: p.revision,
+		Pod:      pod,
+	}
+	p.addTaskHistory(&taskChange{event: event, labels: podLabels})
 
-			// Check the job ID filter
-			if c.Filter.GetJobId() != nil {
-				jobID, _, err := util.ParseTaskID(pod.GetPodName().GetValue())
-				if err != nil {
-					// Cannot parse podName to match the jobID, assume that
-					// filter does not match.
-					filterCheckPass = false
-				}
+	for watchID, c := range p.taskClients {
+		if !matchPodFilter(c.Filter, pod, podLabels) {
+			continue
+		}
 
-				if jobID != c.Filter.GetJobId().GetValue() {
-					// job id filter did not match
-					filterCheckPass = false
-				}
+		select {
+		case c.Input <- event:
+		default:
+			log.WithField("watch_id", watchID).
+				Warn("event overflow for task watch client")
+			p.stopTaskClient(watchID, StopSignalOverflow)
+		}
+	}
+}
 
-				// check the podname filter next
-				if filterCheckPass == true && len(c.Filter.GetPodNames()) > 0 {
-					found := false
-					for _, podName := range c.Filter.GetPodNames() {
-						if podName.GetValue() == pod.GetPodName().GetValue() {
-							found = true
-							break
-						}
-					}
-					if found == false {
-						// pod name filter did not match
-						filterCheckPass = false
-					}
-				}
-			}
+// matchPodFilter returns true if the pod matches the filter of a task
+// watch client
+func matchPodFilter(
+	filter *watch.PodFilter,
+	pod *pod.PodSummary,
+	podLabels []*peloton.Label,
+) bool {
+	if filter == nil {
+		return true
+	}
 
-			if filterCheckPass == false {
-				continue
-			}
+	// Check the job ID filter
+	if filter.GetJobId() != nil {
+		jobID, _, err := util.ParseTaskID(pod.GetPodName().GetValue())
+		if err != nil {
+			// Cannot parse podName to match the jobID, assume that
+			// filter does not match.
+			return false
+		}
 
-			// Check the pod label filter next
-			for _, labelFilter := range c.Filter.GetLabels() {
-				found := false
-				for _, labelPod := range podLabels {
-					if labelFilter.GetKey() == labelPod.GetKey() &&
-						labelFilter.GetValue() == labelPod.GetValue() {
-						found = true
-						break
-					}
-				}
+		if jobID != filter.GetJobId().GetValue() {
+			// job id filter did not match
+			return false
+		}
 
-				if found == false {
-					// label filter did not match
-					filterCheckPass = false
+		// check the podname filter next
+		if len(filter.GetPodNames()) > 0 {
+			found := false
+			for _, podName := range filter.GetPodNames() {
+				if podName.GetValue() == pod.GetPodName().GetValue() {
+					found = true
 					break
 				}
 			}
+			if !found {
+				// pod name filter did not match
+				return false
+			}
 		}
+	}
 
-		if filterCheckPass == false {
-			continue
+	// Check the pod label filter next
+	for _, labelFilter := range filter.GetLabels() {
+		found := false
+		for _, labelPod := range podLabels {
+			if labelFilter.GetKey() == labelPod.GetKey() &&
+				labelFilter.GetValue() == labelPod.GetValue() {
+				found = true
+				break
+			}
 		}
 
-		select {
-		case c.Input <- pod:
-		default:
-			log.WithField("watch_id", watchID).
-				Warn("event overflow for task watch client")
-			p.stopTaskClient(watchID, StopSignalOverflow)
+		if !found {
+			// label filter did not match
+			return false
 		}
 	}
+	return true
 }
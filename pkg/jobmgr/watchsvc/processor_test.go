@@ -17,6 +17,7 @@ package watchsvc
 import (
 	"context"
 	"fmt"
+	"math"
 	"sync"
 	"testing"
 	"time"
@@ -53,8 +54,9 @@ func (suite *WatchProcessorTestSuite) SetupTest() {
 	suite.testScope = tally.NewTestScope("", map[string]string{})
 
 	suite.config = Config{
-		BufferSize: 10,
-		MaxClient:  2,
+		BufferSize:  10,
+		MaxClient:   2,
+		HistorySize: 5,
 	}
 	suite.jobID = &peloton.JobID{Value: uuid.NewRandom().String()}
 	suite.instanceID = uint32(1)
@@ -75,7 +77,7 @@ func (suite *WatchProcessorTestSuite) TestInitWatchProcessor() {
 
 // TestTaskClient tests basic setup and teardown of task watch client
 func (suite *WatchProcessorTestSuite) TestTaskClient() {
-	watchID, c, err := suite.processor.NewTaskClient(nil)
+	watchID, c, err := suite.processor.NewTaskClient(nil, 0)
 	suite.NoError(err)
 	suite.NotEmpty(watchID)
 	suite.NotNil(c)
@@ -105,7 +107,7 @@ func (suite *WatchProcessorTestSuite) TestTaskClient() {
 // TestTaskClient_StopNonexistentClient tests an error will be thrown if
 // tearing down a client with unknown watch id.
 func (suite *WatchProcessorTestSuite) TestTaskClient_StopNonexistentClient() {
-	watchID, c, err := suite.processor.NewTaskClient(nil)
+	watchID, c, err := suite.processor.NewTaskClient(nil, 0)
 	suite.NoError(err)
 	suite.NotEmpty(watchID)
 	suite.NotNil(c)
@@ -117,12 +119,12 @@ func (suite *WatchProcessorTestSuite) TestTaskClient_StopNonexistentClient() {
 
 // Test stop all clients on losing leadership
 func (suite *WatchProcessorTestSuite) TestTaskClient_StopAllClients() {
-	watchID1, c, err := suite.processor.NewTaskClient(nil)
+	watchID1, c, err := suite.processor.NewTaskClient(nil, 0)
 	suite.NoError(err)
 	suite.NotEmpty(watchID1)
 	suite.NotNil(c)
 
-	watchID2, c, err := suite.processor.NewTaskClient(nil)
+	watchID2, c, err := suite.processor.NewTaskClient(nil, 0)
 	suite.NoError(err)
 	suite.NotEmpty(watchID2)
 	suite.NotNil(c)
@@ -138,7 +140,7 @@ func (suite *WatchProcessorTestSuite) TestTaskClient_StopAllClients() {
 // creating a new client if max number of clients is reached.
 func (suite *WatchProcessorTestSuite) TestTaskClient_MaxClientReached() {
 	for i := 0; i < 3; i++ {
-		watchID, c, err := suite.processor.NewTaskClient(nil)
+		watchID, c, err := suite.processor.NewTaskClient(nil, 0)
 		if i < 2 {
 			suite.NoError(err)
 			suite.NotEmpty(watchID)
@@ -154,7 +156,7 @@ func (suite *WatchProcessorTestSuite) TestTaskClient_MaxClientReached() {
 // sent to the client and the client will be closed if the client buffer is
 // overflown.
 func (suite *WatchProcessorTestSuite) TestTaskClient_EventOverflow() {
-	watchID, c, err := suite.processor.NewTaskClient(nil)
+	watchID, c, err := suite.processor.NewTaskClient(nil, 0)
 	suite.NoError(err)
 	suite.NotEmpty(watchID)
 	suite.NotNil(c)
@@ -197,7 +199,7 @@ func (suite *WatchProcessorTestSuite) TestTaskClientPodFilter() {
 	wg.Add(1)
 	received := 0
 
-	watchID, c, err := suite.processor.NewTaskClient(filter)
+	watchID, c, err := suite.processor.NewTaskClient(filter, 0)
 	suite.NoError(err)
 	suite.NotEmpty(watchID)
 	suite.NotNil(c)
@@ -256,7 +258,7 @@ func (suite *WatchProcessorTestSuite) TestTaskClientPodLabelFilter() {
 	wg.Add(1)
 	received := 0
 
-	watchID, c, err := suite.processor.NewTaskClient(filter)
+	watchID, c, err := suite.processor.NewTaskClient(filter, 0)
 	suite.NoError(err)
 	suite.NotEmpty(watchID)
 	suite.NotNil(c)
@@ -298,3 +300,139 @@ func (suite *WatchProcessorTestSuite) TestTaskClientPodLabelFilter() {
 	suite.Equal(2, received)
 	mutex.Unlock()
 }
+
+// TestTaskClient_Revision tests that every pod change is notified with
+// the next revision
+func (suite *WatchProcessorTestSuite) TestTaskClient_Revision() {
+	_, c, err := suite.processor.NewTaskClient(nil, 0)
+	suite.NoError(err)
+
+	for i := 1; i <= 3; i++ {
+		suite.processor.NotifyTaskChange(&pod.PodSummary{}, nil)
+		e := <-c.Input
+		suite.Equal(c.Revision+uint64(i), e.Revision)
+	}
+}
+
+// TestTaskClient_StartRevision tests that the changes since the start
+// revision which match the filter are replayed to a new client
+func (suite *WatchProcessorTestSuite) TestTaskClient_StartRevision() {
+	label := &v0peloton.Label{Key: "key1", Value: "value1"}
+	filter := &watch.PodFilter{
+		Labels: handlerutil.ConvertLabels([]*v0peloton.Label{label}),
+	}
+
+	_, c, err := suite.processor.NewTaskClient(nil, 0)
+	suite.NoError(err)
+	start := c.Revision + 1
+
+	for i := 0; i < 4; i++ {
+		labels := []*v0peloton.Label{label}
+		if i%2 == 1 {
+			labels = nil
+		}
+		suite.processor.NotifyTaskChange(&pod.PodSummary{
+			PodName: &peloton.PodName{Value: fmt.Sprintf("pod-%d", i)},
+		}, labels)
+	}
+
+	// resume after the first change
+	_, c, err = suite.processor.NewTaskClient(filter, start+1)
+	suite.NoError(err)
+	suite.Equal(start+3, c.Revision)
+	suite.Len(c.Input, 1)
+	e := <-c.Input
+	suite.Equal(start+2, e.Revision)
+	suite.Equal("pod-2", e.Pod.GetPodName().GetValue())
+}
+
+// TestTaskClient_StartRevisionNoChange tests resuming a watch when no
+// change happened since the last response
+func (suite *WatchProcessorTestSuite) TestTaskClient_StartRevisionNoChange() {
+	_, c, err := suite.processor.NewTaskClient(nil, 0)
+	suite.NoError(err)
+
+	_, c, err = suite.processor.NewTaskClient(nil, c.Revision+1)
+	suite.NoError(err)
+	suite.Len(c.Input, 0)
+}
+
+// TestTaskClient_StartRevisionOutOfRange tests that an out-of-range error
+// is returned if the start revision is older than the history, and an
+// invalid-argument error if it is newer than the current revision
+func (suite *WatchProcessorTestSuite) TestTaskClient_StartRevisionOutOfRange() {
+	watchID, c, err := suite.processor.NewTaskClient(nil, 0)
+	suite.NoError(err)
+	start := c.Revision + 1
+	suite.NoError(suite.processor.StopTaskClient(watchID))
+
+	// the history keeps the last 5 changes
+	for i := 0; i < 7; i++ {
+		suite.processor.NotifyTaskChange(&pod.PodSummary{}, nil)
+	}
+
+	_, _, err = suite.processor.NewTaskClient(nil, start+1)
+	suite.True(yarpcerrors.IsOutOfRange(err))
+	suite.Equal(int64(1), suite.testScope.Snapshot().
+		Counters()["watch.watch_pod_out_of_range+"].Value())
+
+	_, c, err = suite.processor.NewTaskClient(nil, start+2)
+	suite.NoError(err)
+	suite.Len(c.Input, 5)
+
+	_, _, err = suite.processor.NewTaskClient(nil, start+8)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestTaskClient_StopAllClientsClearsHistory tests that the history is
+// cleared on losing leadership
+func (suite *WatchProcessorTestSuite) TestTaskClient_StopAllClientsClearsHistory() {
+	_, c, err := suite.processor.NewTaskClient(nil, 0)
+	suite.NoError(err)
+	start := c.Revision + 1
+
+	suite.processor.NotifyTaskChange(&pod.PodSummary{}, nil)
+	suite.processor.StopTaskClients()
+
+	_, _, err = suite.processor.NewTaskClient(nil, start)
+	suite.True(yarpcerrors.IsOutOfRange(err))
+
+	// the changes of the new term do not make the start revision valid
+	suite.processor.NotifyTaskChange(&pod.PodSummary{}, nil)
+	_, _, err = suite.processor.NewTaskClient(nil, start)
+	suite.True(yarpcerrors.IsOutOfRange(err))
+}
+
+// TestTaskClient_StartRevisionOtherLeader tests that an out-of-range
+// error is returned if the start revision is from another leader, even
+// if the current leader has as many changes
+func (suite *WatchProcessorTestSuite) TestTaskClient_StartRevisionOtherLeader() {
+	other := newWatchProcessor(suite.config, tally.NoopScope)
+	_, c, err := other.NewTaskClient(nil, 0)
+	suite.NoError(err)
+	other.NotifyTaskChange(&pod.PodSummary{}, nil)
+	start := c.Revision + 1
+
+	suite.processor.NotifyTaskChange(&pod.PodSummary{}, nil)
+	_, _, err = suite.processor.NewTaskClient(nil, start)
+	suite.True(yarpcerrors.IsOutOfRange(err))
+}
+
+// TestTaskClient_RevisionTermExhausted tests that a new term is started
+// when the revisions of the current term are exhausted
+func (suite *WatchProcessorTestSuite) TestTaskClient_RevisionTermExhausted() {
+	p := suite.processor.(*watchProcessor)
+	p.revision |= math.MaxUint32
+	start := p.revision
+
+	_, c, err := suite.processor.NewTaskClient(nil, 0)
+	suite.NoError(err)
+	suite.processor.NotifyTaskChange(&pod.PodSummary{}, nil)
+	e := <-c.Input
+	suite.NotEqual(start>>_revisionTermShift, e.Revision>>_revisionTermShift)
+	suite.Equal(uint32(1), uint32(e.Revision))
+
+	_, c, err = suite.processor.NewTaskClient(nil, e.Revision)
+	suite.NoError(err)
+	suite.Len(c.Input, 1)
+}
@@ -0,0 +1,361 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/auth"
+	"github.com/uber/peloton/pkg/common/audit"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	// _apiServicePrefix is the prefix of the services
+	// of the peloton API
+	_apiServicePrefix = "peloton.api."
+
+	// _applicationErrorOutcome is the outcome of calls
+	// which returned an application error
+	_applicationErrorOutcome = "application-error"
+
+	// _auditWriteTimeout is the timeout to write an audit event
+	_auditWriteTimeout = 10 * time.Second
+
+	// _defaultAuditBufferSize is the default number of audit events
+	// waiting to be written
+	_defaultAuditBufferSize = 1000
+)
+
+// _mutatingMethodPrefixes are the prefixes of the methods of the
+// peloton API which are audited when no rule is configured
+var _mutatingMethodPrefixes = []string{
+	"Create",
+	"Replace",
+	"Patch",
+	"Update",
+	"Restart",
+	"Start",
+	"Stop",
+	"Kill",
+	"Delete",
+	"Pause",
+	"Resume",
+	"Abort",
+	"Rollback",
+	"Refresh",
+}
+
+// AuditConfig is the config of the audit log of mutating procedures
+type AuditConfig struct {
+	Enabled bool
+	// Methods are the rules of the audited procedures, in the same
+	// format as the rules of RateLimitConfig. If no rule is configured,
+	// the mutating procedures of the peloton API are audited.
+	Methods []string
+	// BufferSize is the maximum number of audit events waiting to be
+	// written, the events of the calls made while the buffer is full
+	// are dropped
+	BufferSize int `yaml:"buffer_size"`
+}
+
+// AuditInboundMiddleware records the calls to mutating procedures in
+// the audit log, with the authenticated user, the targets added by the
+// handler, a digest of the request, the outcome and the latency.
+// The events are written in the background, so that the calls do not
+// wait for the storage. It needs to run after AuthInboundMiddleware to
+// get the user.
+type AuditInboundMiddleware struct {
+	enabled bool
+
+	// key is service name, value is the method rules of the
+	// service. It is nil if no rule is configured.
+	rules map[string][]string
+
+	ops ormobjects.AuditEventOps
+	now func() time.Time
+
+	// events waiting to be written by the writer goroutine
+	events   chan *models.AuditEvent
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+
+	recorded   tally.Counter
+	recordFail tally.Counter
+	dropped    tally.Counter
+}
+
+// auditResponseWriter tracks whether the handler
+// sets an application error
+type auditResponseWriter struct {
+	transport.ResponseWriter
+	isApplicationError bool
+}
+
+// SetApplicationError marks the response as an application error
+func (w *auditResponseWriter) SetApplicationError() {
+	w.isApplicationError = true
+	w.ResponseWriter.SetApplicationError()
+}
+
+// NewAuditInboundMiddleware returns AuditInboundMiddleware which
+// writes the audit events with ops. If enabled, it starts the goroutine
+// writing the events, which is stopped by Stop.
+func NewAuditInboundMiddleware(
+	config AuditConfig,
+	ops ormobjects.AuditEventOps,
+	scope tally.Scope,
+) (*AuditInboundMiddleware, error) {
+	result := &AuditInboundMiddleware{
+		enabled:    config.Enabled,
+		ops:        ops,
+		now:        time.Now,
+		recorded:   scope.Counter("recorded"),
+		recordFail: scope.Counter("record_fail"),
+		dropped:    scope.Counter("dropped"),
+	}
+
+	for _, method := range config.Methods {
+		results := strings.Split(method, _ruleSeparator)
+		if len(results) != 2 {
+			return nil, yarpcerrors.InvalidArgumentErrorf(
+				"invalid audit config for method: %s", method)
+		}
+
+		if result.rules == nil {
+			result.rules = make(map[string][]string)
+		}
+		result.rules[results[0]] = append(result.rules[results[0]], results[1])
+	}
+
+	if result.enabled {
+		bufferSize := config.BufferSize
+		if bufferSize <= 0 {
+			bufferSize = _defaultAuditBufferSize
+		}
+		result.events = make(chan *models.AuditEvent, bufferSize)
+		result.wg.Add(1)
+		go result.writeEvents()
+	}
+
+	return result, nil
+}
+
+// Stop waits for the buffered audit events to be written and stops the
+// goroutine writing the events. No call must be handled after Stop.
+func (m *AuditInboundMiddleware) Stop() {
+	m.stopOnce.Do(func() {
+		if m.events != nil {
+			close(m.events)
+		}
+	})
+	m.wg.Wait()
+}
+
+// Handle invokes the underlying handler and records
+// the call if the procedure is audited
+func (m *AuditInboundMiddleware) Handle(
+	ctx context.Context,
+	req *transport.Request,
+	resw transport.ResponseWriter,
+	h transport.UnaryHandler,
+) error {
+	if !m.isAudited(req.Procedure) {
+		return h.Handle(ctx, req, resw)
+	}
+
+	digest, err := digestRequest(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, targets := audit.NewContext(ctx)
+	w := &auditResponseWriter{ResponseWriter: resw}
+	start := m.now()
+	err = h.Handle(ctx, req, w)
+	m.record(ctx, req, digest, targets, start, err, w.isApplicationError)
+
+	return err
+}
+
+// HandleOneway invokes the underlying handler and records
+// the call if the procedure is audited
+func (m *AuditInboundMiddleware) HandleOneway(
+	ctx context.Context,
+	req *transport.Request,
+	h transport.OnewayHandler,
+) error {
+	if !m.isAudited(req.Procedure) {
+		return h.HandleOneway(ctx, req)
+	}
+
+	digest, err := digestRequest(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, targets := audit.NewContext(ctx)
+	start := m.now()
+	err = h.HandleOneway(ctx, req)
+	m.record(ctx, req, digest, targets, start, err, false)
+
+	return err
+}
+
+// HandleStream invokes the underlying handler, streaming
+// procedures are not audited since none of them mutates state
+func (m *AuditInboundMiddleware) HandleStream(
+	s *transport.ServerStream,
+	h transport.StreamHandler,
+) error {
+	return h.HandleStream(s)
+}
+
+// isAudited returns whether calls to the procedure are recorded
+func (m *AuditInboundMiddleware) isAudited(procedure string) bool {
+	if !m.enabled {
+		return false
+	}
+
+	results := strings.Split(procedure, _procedureSeparator)
+	if len(results) != 2 {
+		return false
+	}
+	service := results[0]
+	method := results[1]
+
+	if m.rules == nil {
+		if !strings.HasPrefix(service, _apiServicePrefix) {
+			return false
+		}
+		for _, prefix := range _mutatingMethodPrefixes {
+			if strings.HasPrefix(method, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, rule := range m.rules[service] {
+		if matchRule(method, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// record queues the audit event of a call to be written. The event is
+// dropped if the buffer of the events is full, so that a slow storage
+// does not slow down the calls.
+func (m *AuditInboundMiddleware) record(
+	ctx context.Context,
+	req *transport.Request,
+	digest string,
+	targets *audit.Targets,
+	start time.Time,
+	err error,
+	isApplicationError bool,
+) {
+	event := &models.AuditEvent{
+		EventId:       uuid.New(),
+		Time:          start.UTC().Format(time.RFC3339Nano),
+		Caller:        req.Caller,
+		Procedure:     req.Procedure,
+		JobIds:        targets.JobIDs(),
+		PodNames:      targets.PodNames(),
+		RespoolIds:    targets.RespoolIDs(),
+		RequestDigest: digest,
+		Outcome:       yarpcerrors.CodeOK.String(),
+		LatencyMs:     int64(m.now().Sub(start) / time.Millisecond),
+	}
+
+	if user, ok := auth.UserFromContext(ctx); ok {
+		event.User = user.Name()
+	}
+
+	if err != nil {
+		event.Outcome = errorCode(err)
+		event.Message = err.Error()
+	} else if isApplicationError {
+		event.Outcome = _applicationErrorOutcome
+	}
+
+	select {
+	case m.events <- event:
+	default:
+		m.dropped.Inc(1)
+		log.WithField("event", event).
+			Warn("audit event buffer is full, dropping audit event")
+	}
+}
+
+// writeEvents writes the queued audit events until Stop is called
+func (m *AuditInboundMiddleware) writeEvents() {
+	defer m.wg.Done()
+
+	for event := range m.events {
+		m.write(event)
+	}
+}
+
+// write writes an audit event. A failure to write the event is logged,
+// it does not fail the call which has completed.
+func (m *AuditInboundMiddleware) write(event *models.AuditEvent) {
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		_auditWriteTimeout,
+	)
+	defer cancel()
+
+	if err := m.ops.Create(ctx, event); err != nil {
+		m.recordFail.Inc(1)
+		log.WithError(err).
+			WithField("event", event).
+			Warn("failed to record audit event")
+		return
+	}
+	m.recorded.Inc(1)
+}
+
+// digestRequest returns the hex encoded SHA-256 digest of the request
+// body, the body is replaced so that the handler can still read it
+func digestRequest(req *transport.Request) (string, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return "", err
+		}
+		req.Body = bytes.NewReader(body)
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/auth"
+	auth_mocks "github.com/uber/peloton/pkg/auth/mocks"
+	"github.com/uber/peloton/pkg/common/audit"
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_testAuditedProcedure = "peloton.api.v1alpha.job.stateless.svc.JobService::StopJob"
+	_testReadProcedure    = "peloton.api.v1alpha.job.stateless.svc.JobService::GetJob"
+	_testRequestBody      = "request"
+)
+
+type AuditInboundMiddlewareSuite struct {
+	suite.Suite
+
+	ctrl  *gomock.Controller
+	ops   *objectmocks.MockAuditEventOps
+	u     *auth_mocks.MockUser
+	scope tally.TestScope
+	m     *AuditInboundMiddleware
+	r     *transport.Request
+	start time.Time
+}
+
+func (suite *AuditInboundMiddlewareSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.ops = objectmocks.NewMockAuditEventOps(suite.ctrl)
+	suite.u = auth_mocks.NewMockUser(suite.ctrl)
+	suite.scope = tally.NewTestScope("", nil)
+
+	var err error
+	suite.m, err = NewAuditInboundMiddleware(
+		AuditConfig{Enabled: true},
+		suite.ops,
+		suite.scope,
+	)
+	suite.NoError(err)
+
+	// each call to now advances the clock by 15ms
+	suite.start = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := suite.start
+	suite.m.now = func() time.Time {
+		defer func() { now = now.Add(15 * time.Millisecond) }()
+		return now
+	}
+
+	suite.r = &transport.Request{
+		Caller:    "peloton-cli",
+		Service:   "peloton.api.v1alpha.job.stateless.svc.JobService",
+		Procedure: _testAuditedProcedure,
+		Body:      bytes.NewReader([]byte(_testRequestBody)),
+	}
+}
+
+func (suite *AuditInboundMiddlewareSuite) TearDownTest() {
+	suite.m.Stop()
+	suite.ctrl.Finish()
+}
+
+func TestAuditInboundMiddleware(t *testing.T) {
+	suite.Run(t, new(AuditInboundMiddlewareSuite))
+}
+
+// expectedEvent returns the event expected for the test request
+func (suite *AuditInboundMiddlewareSuite) expectedEvent(
+	outcome string,
+	message string,
+) *models.AuditEvent {
+	digest := sha256.Sum256([]byte(_testRequestBody))
+	return &models.AuditEvent{
+		Time:          suite.start.Format(time.RFC3339Nano),
+		User:          "alice",
+		Caller:        "peloton-cli",
+		Procedure:     _testAuditedProcedure,
+		JobIds:        []string{"job1"},
+		RequestDigest: hex.EncodeToString(digest[:]),
+		Outcome:       outcome,
+		Message:       message,
+		LatencyMs:     15,
+	}
+}
+
+// TestHandle tests recording a successful call
+func (suite *AuditInboundMiddlewareSuite) TestHandle() {
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	ctx := auth.ContextWithUser(context.Background(), suite.u)
+
+	h.EXPECT().Handle(gomock.Any(), suite.r, gomock.Any()).
+		Do(func(
+			ctx context.Context,
+			req *transport.Request,
+			resw transport.ResponseWriter,
+		) {
+			// the handler can still read the request
+			body, err := ioutil.ReadAll(req.Body)
+			suite.NoError(err)
+			suite.Equal(_testRequestBody, string(body))
+			audit.AddJobID(ctx, "job1")
+		}).
+		Return(nil)
+	suite.u.EXPECT().Name().Return("alice")
+	suite.ops.EXPECT().Create(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, event *models.AuditEvent) {
+			suite.NotEmpty(event.GetEventId())
+			event.EventId = ""
+			suite.Equal(suite.expectedEvent("ok", ""), event)
+		}).
+		Return(nil)
+
+	suite.NoError(suite.m.Handle(ctx, suite.r, nil, h))
+	suite.m.Stop()
+	suite.Equal(int64(1), suite.scope.Snapshot().Counters()["recorded+"].Value())
+}
+
+// TestHandleFailure tests recording a failed call, and that failing
+// to record the call does not change the result of the call
+func (suite *AuditInboundMiddlewareSuite) TestHandleFailure() {
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	ctx := auth.ContextWithUser(context.Background(), suite.u)
+	handlerErr := yarpcerrors.NotFoundErrorf("job not found")
+
+	h.EXPECT().Handle(gomock.Any(), suite.r, gomock.Any()).
+		Do(func(
+			ctx context.Context,
+			req *transport.Request,
+			resw transport.ResponseWriter,
+		) {
+			audit.AddJobID(ctx, "job1")
+		}).
+		Return(handlerErr)
+	suite.u.EXPECT().Name().Return("alice")
+	suite.ops.EXPECT().Create(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, event *models.AuditEvent) {
+			event.EventId = ""
+			suite.Equal(
+				suite.expectedEvent("not-found", handlerErr.Error()),
+				event,
+			)
+		}).
+		Return(errors.New("test error"))
+
+	suite.Equal(handlerErr, suite.m.Handle(ctx, suite.r, nil, h))
+	suite.m.Stop()
+	suite.Equal(int64(1), suite.scope.Snapshot().Counters()["record_fail+"].Value())
+}
+
+// TestHandleBufferFull tests that the calls do not wait for the audit
+// events to be written, and that the events are dropped when the
+// buffer is full
+func (suite *AuditInboundMiddlewareSuite) TestHandleBufferFull() {
+	m, err := NewAuditInboundMiddleware(
+		AuditConfig{Enabled: true, BufferSize: 1},
+		suite.ops,
+		suite.scope,
+	)
+	suite.NoError(err)
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	h.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(3)
+
+	// the first event is written until released, the second one is
+	// buffered and the third one is dropped
+	writing := make(chan struct{})
+	release := make(chan struct{})
+	suite.ops.EXPECT().Create(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *models.AuditEvent) {
+			writing <- struct{}{}
+			<-release
+		}).
+		Return(nil)
+	suite.ops.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	suite.NoError(m.Handle(context.Background(), suite.r, nil, h))
+	<-writing
+	suite.NoError(m.Handle(context.Background(), suite.r, nil, h))
+	suite.NoError(m.Handle(context.Background(), suite.r, nil, h))
+	suite.Equal(int64(1), suite.scope.Snapshot().Counters()["dropped+"].Value())
+
+	close(release)
+	m.Stop()
+	suite.Equal(int64(2), suite.scope.Snapshot().Counters()["recorded+"].Value())
+}
+
+// TestHandleOneway tests recording a oneway call
+func (suite *AuditInboundMiddlewareSuite) TestHandleOneway() {
+	h := transporttest.NewMockOnewayHandler(suite.ctrl)
+	ctx := auth.ContextWithUser(context.Background(), suite.u)
+
+	h.EXPECT().HandleOneway(gomock.Any(), suite.r).
+		Do(func(ctx context.Context, req *transport.Request) {
+			audit.AddJobID(ctx, "job1")
+		}).
+		Return(nil)
+	suite.u.EXPECT().Name().Return("alice")
+	suite.ops.EXPECT().Create(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, event *models.AuditEvent) {
+			event.EventId = ""
+			suite.Equal(suite.expectedEvent("ok", ""), event)
+		}).
+		Return(nil)
+
+	suite.NoError(suite.m.HandleOneway(ctx, suite.r, h))
+	suite.m.Stop()
+}
+
+// TestHandleNotAudited tests that calls to read-only
+// procedures are not recorded
+func (suite *AuditInboundMiddlewareSuite) TestHandleNotAudited() {
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	suite.r.Procedure = _testReadProcedure
+
+	h.EXPECT().Handle(gomock.Any(), suite.r, gomock.Any()).Return(nil)
+	suite.NoError(suite.m.Handle(context.Background(), suite.r, nil, h))
+}
+
+// TestIsAudited tests matching procedures with the
+// default and the configured rules
+func (suite *AuditInboundMiddlewareSuite) TestIsAudited() {
+	suite.True(suite.m.isAudited(_testAuditedProcedure))
+	suite.True(suite.m.isAudited("peloton.api.v0.respool.ResourceManager::DeleteResourcePool"))
+	suite.False(suite.m.isAudited(_testReadProcedure))
+	suite.False(suite.m.isAudited("peloton.private.resmgr.ResourceManagerService::KillTasks"))
+	suite.False(suite.m.isAudited("invalid"))
+
+	m, err := NewAuditInboundMiddleware(
+		AuditConfig{
+			Enabled: true,
+			Methods: []string{
+				"peloton.api.v1alpha.job.stateless.svc.JobService:GetJob",
+				"peloton.private.resmgr.ResourceManagerService:Kill*",
+			},
+		},
+		suite.ops,
+		suite.scope,
+	)
+	suite.NoError(err)
+	defer m.Stop()
+	suite.True(m.isAudited(_testReadProcedure))
+	suite.True(m.isAudited("peloton.private.resmgr.ResourceManagerService::KillTasks"))
+	suite.False(m.isAudited(_testAuditedProcedure))
+
+	disabled, err := NewAuditInboundMiddleware(AuditConfig{}, suite.ops, suite.scope)
+	suite.NoError(err)
+	suite.False(disabled.isAudited(_testAuditedProcedure))
+
+	_, err = NewAuditInboundMiddleware(
+		AuditConfig{Enabled: true, Methods: []string{"StopJob"}},
+		suite.ops,
+		suite.scope,
+	)
+	suite.Error(err)
+}
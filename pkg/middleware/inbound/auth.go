@@ -40,7 +40,7 @@ type AuthInboundMiddleware struct {
 
 // Handle authenticates user and invokes the underlying handler
 func (m *AuthInboundMiddleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
-	permitted, err := m.isPermitted(req.Headers, req.Service, req.Procedure)
+	user, permitted, err := m.isPermitted(req.Headers, req.Service, req.Procedure)
 	if err != nil {
 		return err
 	}
@@ -49,12 +49,12 @@ func (m *AuthInboundMiddleware) Handle(ctx context.Context, req *transport.Reque
 		return yarpcerrors.PermissionDeniedErrorf(permissionDeniedErrorStr, req.Procedure, req.Service)
 	}
 
-	return h.Handle(ctx, req, resw)
+	return h.Handle(contextWithUser(ctx, user), req, resw)
 }
 
 // HandleOneway authenticates user and invokes the underlying handler
 func (m *AuthInboundMiddleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
-	permitted, err := m.isPermitted(req.Headers, req.Service, req.Procedure)
+	user, permitted, err := m.isPermitted(req.Headers, req.Service, req.Procedure)
 	if err != nil {
 		return err
 	}
@@ -63,7 +63,7 @@ func (m *AuthInboundMiddleware) HandleOneway(ctx context.Context, req *transport
 		return yarpcerrors.PermissionDeniedErrorf(permissionDeniedErrorStr, req.Procedure, req.Service)
 	}
 
-	return h.HandleOneway(ctx, req)
+	return h.HandleOneway(contextWithUser(ctx, user), req)
 }
 
 // HandleStream authenticates user and invokes the underlying handler
@@ -71,7 +71,9 @@ func (m *AuthInboundMiddleware) HandleStream(s *transport.ServerStream, h transp
 	service := s.Request().Meta.Service
 	procedure := s.Request().Meta.Procedure
 
-	permitted, err := m.isPermitted(s.Request().Meta.Headers, service, procedure)
+	// the user is not passed to stream handlers, none of the
+	// streaming procedures needs resource-scoped authorization
+	_, permitted, err := m.isPermitted(s.Request().Meta.Headers, service, procedure)
 	if err != nil {
 		return err
 	}
@@ -83,7 +85,9 @@ func (m *AuthInboundMiddleware) HandleStream(s *transport.ServerStream, h transp
 	return h.HandleStream(s)
 }
 
-func (m *AuthInboundMiddleware) isPermitted(headers transport.Headers, service string, procedure string) (permitted bool, err error) {
+// isPermitted authenticates the caller and checks whether the procedure is
+// permitted, the user is nil if the service is not authenticated by peloton
+func (m *AuthInboundMiddleware) isPermitted(headers transport.Headers, service string, procedure string) (user auth.User, permitted bool, err error) {
 	defer func() {
 		if !permitted {
 			log.WithFields(log.Fields{
@@ -96,17 +100,26 @@ func (m *AuthInboundMiddleware) isPermitted(headers transport.Headers, service s
 	// Other services such as Mesos callback (service name: Scheduler)
 	// cannot be authenticated by peloton auth mechanism for now.
 	if !strings.HasPrefix(service, _pelotonServicePrefix) {
-		return true, nil
+		return nil, true, nil
 	}
 
-	user, err := m.Authenticate(headers)
+	user, err = m.Authenticate(headers)
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 
 	m.RedactToken(headers)
 
-	return user.IsPermitted(procedure), nil
+	return user, user.IsPermitted(procedure), nil
+}
+
+// contextWithUser passes the authenticated user to the handler,
+// so that it can authorize the resources of the request
+func contextWithUser(ctx context.Context, user auth.User) context.Context {
+	if user == nil {
+		return ctx
+	}
+	return auth.ContextWithUser(ctx, user)
 }
 
 // NewAuthInboundMiddleware returns AuthInboundMiddleware with auth check
@@ -18,6 +18,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/uber/peloton/pkg/auth"
 	auth_mocks "github.com/uber/peloton/pkg/auth/mocks"
 
 	"github.com/golang/mock/gomock"
@@ -66,6 +67,39 @@ func (suite *AuthInboundMiddlewareSuite) TestHandleSuccess() {
 	suite.NoError(suite.m.Handle(context.Background(), suite.r, nil, h))
 }
 
+func (suite *AuthInboundMiddlewareSuite) TestHandlePassesUserToHandler() {
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	suite.s.EXPECT().Authenticate(gomock.Any()).Return(suite.u, nil)
+	suite.s.EXPECT().RedactToken(gomock.Any()).Return()
+	suite.u.EXPECT().IsPermitted(gomock.Any()).Return(true)
+	h.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(
+			ctx context.Context,
+			req *transport.Request,
+			resw transport.ResponseWriter) {
+			user, ok := auth.UserFromContext(ctx)
+			suite.True(ok)
+			suite.Equal(suite.u, user)
+		}).
+		Return(nil)
+	suite.NoError(suite.m.Handle(context.Background(), suite.r, nil, h))
+}
+
+func (suite *AuthInboundMiddlewareSuite) TestHandleNonPelotonServiceNoUser() {
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	h.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(
+			ctx context.Context,
+			req *transport.Request,
+			resw transport.ResponseWriter) {
+			_, ok := auth.UserFromContext(ctx)
+			suite.False(ok)
+		}).
+		Return(nil)
+	suite.r.Service = "Scheduler"
+	suite.NoError(suite.m.Handle(context.Background(), suite.r, nil, h))
+}
+
 func (suite *AuthInboundMiddlewareSuite) TestHandleAuthenticateFail() {
 	h := transporttest.NewMockUnaryHandler(suite.ctrl)
 	suite.s.EXPECT().Authenticate(gomock.Any()).Return(nil, errors.New("test error"))
@@ -15,9 +15,14 @@
 package inbound
 
 import (
+	"container/list"
 	"context"
 	"strings"
+	"sync"
 
+	"github.com/uber/peloton/pkg/auth"
+
+	"github.com/uber-go/tally"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/yarpcerrors"
 	"golang.org/x/time/rate"
@@ -27,11 +32,23 @@ var rateLimitError = yarpcerrors.ResourceExhaustedErrorf("rate limit reached for
 
 type RateLimitInboundMiddleware struct {
 	enabled bool
+	// perCaller is set if every caller has its own token buckets
+	perCaller bool
 
 	// key is service Name, value rateLimiter in the
 	// same order as defined in RateLimitConfig.Methods
 	rateLimits       map[string][]*rateLimiter
-	defaultRateLimit *rate.Limiter
+	defaultRateLimit *rateLimiter
+
+	// key is the caller, value rateLimiter in the same
+	// order as defined in RateLimitConfig.Overrides
+	overrides map[string][]*callerRateLimiter
+
+	// token buckets of the callers, which are created from
+	// the matched rateLimiter
+	limiters *limiterCache
+
+	scope tally.Scope
 }
 
 type rateLimiter struct {
@@ -40,12 +57,39 @@ type rateLimiter struct {
 	// if a certain method matches the rule, then that method would use
 	// the *rate.Limiter associated with the rule.
 	rule string
+	// bucket is the config of the token buckets of the
+	// callers which match the rule
+	bucket TokenBucket
+}
+
+// callerRateLimiter is the rateLimiter of an override, which
+// may apply to the methods of all the services
+type callerRateLimiter struct {
+	*rateLimiter
+	// service is the service name, or * for all the services
+	service string
 }
 
 const (
 	_ruleSeparator = ":"
 	// rule that matches all methods under all services
 	_matchAllRule = "*"
+
+	// _defaultLimiterCacheSize is the default maximum number
+	// of token buckets kept for the callers
+	_defaultLimiterCacheSize = 10000
+
+	// _callerTag is the tag of the rejected calls, its value is the
+	// caller of an override or the class of the caller otherwise,
+	// so that the number of tagged metrics stays bounded
+	_callerTag = "caller"
+
+	// the classes of the callers: the authenticated users, the calling
+	// services of the calls without authenticated user, and the calls
+	// without either which share their token buckets
+	_userCallerClass    = "_user"
+	_serviceCallerClass = "_service"
+	_anonymousCaller    = "_anonymous"
 )
 
 type TokenBucket struct {
@@ -68,35 +112,143 @@ type RateLimitConfig struct {
 	// Default is the default rate limit config,
 	// if the method called is not defined in Methods field.
 	Default TokenBucket
+
+	// PerCaller gives every caller its own token buckets instead of
+	// sharing them with all the callers. The caller is the authenticated
+	// user, or the calling service of the request for the calls without
+	// authenticated user. The calling service is set by the client, so it
+	// is only trusted as much as the client. The calls without either
+	// share the same token buckets.
+	PerCaller bool `yaml:"per_caller"`
+	// Overrides are the rate limits of specific callers, which are
+	// evaluated from top to down before Methods. The Name of an
+	// override may use * as service to match all the services.
+	Overrides []struct {
+		Caller      string
+		Name        string
+		TokenBucket `yaml:",inline"`
+	}
+	// CacheSize is the maximum number of token buckets kept for the
+	// callers, the least recently used bucket is evicted when the
+	// cache is full. Defaults to 10000.
+	CacheSize int `yaml:"cache_size"`
+	// PreAuth is the rate limit of all the calls together, which is
+	// checked before the calls are authenticated when the calls are
+	// rate limited per caller, so that the authentication of the calls
+	// is rate limited too. There is no such limit if it is not set.
+	PreAuth *TokenBucket `yaml:"pre_auth"`
 }
 
-func NewRateLimitInboundMiddleware(config RateLimitConfig) (*RateLimitInboundMiddleware, error) {
-	result := &RateLimitInboundMiddleware{rateLimits: make(map[string][]*rateLimiter)}
+// NewRateLimitInboundMiddlewares returns the rate limit middlewares which
+// go before and after the auth middleware. The calls are rate limited
+// before authentication unless the rate limits depend on the caller, in
+// which case only the PreAuth limit is checked before authentication.
+func NewRateLimitInboundMiddlewares(
+	config RateLimitConfig,
+	scope tally.Scope,
+) (preAuth *RateLimitInboundMiddleware, postAuth *RateLimitInboundMiddleware, err error) {
+	if !config.PerCaller && len(config.Overrides) == 0 {
+		preAuth, err = NewRateLimitInboundMiddleware(config, scope)
+		if err != nil {
+			return nil, nil, err
+		}
+		postAuth, err = NewRateLimitInboundMiddleware(RateLimitConfig{}, scope)
+		return preAuth, postAuth, err
+	}
+
+	preAuthConfig := RateLimitConfig{
+		Enabled: config.Enabled,
+		Default: TokenBucket{Rate: -1, Burst: -1},
+	}
+	if config.PreAuth != nil {
+		preAuthConfig.Default = *config.PreAuth
+	}
+	preAuth, err = NewRateLimitInboundMiddleware(
+		preAuthConfig,
+		scope.SubScope("pre_auth"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	postAuth, err = NewRateLimitInboundMiddleware(config, scope)
+	return preAuth, postAuth, err
+}
+
+func NewRateLimitInboundMiddleware(
+	config RateLimitConfig,
+	scope tally.Scope,
+) (*RateLimitInboundMiddleware, error) {
+	result := &RateLimitInboundMiddleware{
+		rateLimits: make(map[string][]*rateLimiter),
+		overrides:  make(map[string][]*callerRateLimiter),
+		scope:      scope,
+	}
 	if !config.Enabled {
 		return result, nil
 	}
 
 	result.enabled = config.Enabled
+	result.perCaller = config.PerCaller
 	for _, method := range config.Methods {
-		results := strings.Split(method.Name, _ruleSeparator)
-		if len(results) != 2 {
-			return nil, yarpcerrors.InvalidArgumentErrorf(
-				"invalid config for method: %s", method.Name)
+		service, rule, err := parseRateLimitRule(method.Name)
+		if err != nil {
+			return nil, err
 		}
 
-		service := results[0]
-		rule := results[1]
 		result.rateLimits[service] =
 			append(result.rateLimits[service],
-				&rateLimiter{rule: rule, Limiter: createLimiter(method.Rate, method.Burst)},
+				newRateLimiter(rule, method.TokenBucket),
 			)
 
 	}
 
-	result.defaultRateLimit = createLimiter(config.Default.Rate, config.Default.Burst)
+	for _, override := range config.Overrides {
+		service, rule, err := parseRateLimitRule(override.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(override.Caller) == 0 {
+			return nil, yarpcerrors.InvalidArgumentErrorf(
+				"no caller in override for method: %s", override.Name)
+		}
+
+		result.overrides[override.Caller] =
+			append(result.overrides[override.Caller],
+				&callerRateLimiter{
+					rateLimiter: newRateLimiter(rule, override.TokenBucket),
+					service:     service,
+				},
+			)
+	}
+
+	cacheSize := config.CacheSize
+	if cacheSize <= 0 {
+		cacheSize = _defaultLimiterCacheSize
+	}
+	result.limiters = newLimiterCache(cacheSize, scope)
+
+	result.defaultRateLimit = newRateLimiter(_matchAllRule, config.Default)
 	return result, nil
 }
 
+// parseRateLimitRule splits a rule in the service:method format
+func parseRateLimitRule(name string) (string, string, error) {
+	results := strings.Split(name, _ruleSeparator)
+	if len(results) != 2 || len(results[1]) == 0 {
+		return "", "", yarpcerrors.InvalidArgumentErrorf(
+			"invalid config for method: %s", name)
+	}
+	return results[0], results[1], nil
+}
+
+func newRateLimiter(rule string, bucket TokenBucket) *rateLimiter {
+	return &rateLimiter{
+		Limiter: createLimiter(bucket.Rate, bucket.Burst),
+		rule:    rule,
+		bucket:  bucket,
+	}
+}
+
 func createLimiter(r rate.Limit, b int) *rate.Limiter {
 	// no rate limit
 	if r < 0 || b < 0 {
@@ -113,7 +265,8 @@ func (m *RateLimitInboundMiddleware) Handle(
 	resw transport.ResponseWriter,
 	h transport.UnaryHandler,
 ) error {
-	if !m.allow(req.Procedure) {
+	caller, class := callerName(ctx, req.Caller)
+	if !m.allow(req.Procedure, caller, class) {
 		return rateLimitError
 	}
 
@@ -126,7 +279,8 @@ func (m *RateLimitInboundMiddleware) HandleOneway(
 	req *transport.Request,
 	h transport.OnewayHandler,
 ) error {
-	if !m.allow(req.Procedure) {
+	caller, class := callerName(ctx, req.Caller)
+	if !m.allow(req.Procedure, caller, class) {
 		return rateLimitError
 	}
 
@@ -138,15 +292,39 @@ func (m *RateLimitInboundMiddleware) HandleStream(
 	s *transport.ServerStream,
 	h transport.StreamHandler,
 ) error {
-	if !m.allow(s.Request().Meta.Procedure) {
+	// the authenticated user is not passed to stream handlers,
+	// so streams are rate limited by their calling service
+	meta := s.Request().Meta
+	caller, class := callerName(context.Background(), meta.Caller)
+	if !m.allow(meta.Procedure, caller, class) {
 		return rateLimitError
 	}
 
 	return h.HandleStream(s)
 }
 
-// allow returns if a procedure can be called given the rate limit
-func (m *RateLimitInboundMiddleware) allow(procedure string) bool {
+// callerName returns the name and the class of the caller of a call:
+// the authenticated user, or the calling service of the call if there is
+// no authenticated user, or _anonymousCaller otherwise
+func callerName(ctx context.Context, service string) (string, string) {
+	if user, ok := auth.UserFromContext(ctx); ok {
+		if name := user.Name(); len(name) != 0 {
+			return name, _userCallerClass
+		}
+	}
+	if len(service) != 0 {
+		return service, _serviceCallerClass
+	}
+	return _anonymousCaller, _anonymousCaller
+}
+
+// allow returns if a procedure can be called by the caller
+// given the rate limit
+func (m *RateLimitInboundMiddleware) allow(
+	procedure string,
+	caller string,
+	class string,
+) bool {
 	// if rate limit is not enabled, always allow a method call
 	if !m.enabled {
 		return true
@@ -156,24 +334,65 @@ func (m *RateLimitInboundMiddleware) allow(procedure string) bool {
 	service := results[0]
 	method := results[1]
 
+	var allowed bool
+	tag := class
+	if rl := m.matchOverride(caller, service, method); rl != nil {
+		// overrides are always per caller
+		allowed = m.limiters.get(caller, rl).Allow()
+		tag = caller
+	} else if rl := m.match(service, method); m.perCaller {
+		allowed = m.limiters.get(caller, rl).Allow()
+	} else {
+		allowed = rl.Allow()
+	}
+
+	if !allowed {
+		m.scope.Tagged(map[string]string{_callerTag: tag}).
+			Counter("rejected").Inc(1)
+	}
+	return allowed
+}
+
+// matchOverride returns the rateLimiter of the first override
+// of the caller which matches the method, or nil
+func (m *RateLimitInboundMiddleware) matchOverride(
+	caller string,
+	service string,
+	method string,
+) *rateLimiter {
+	for _, rl := range m.overrides[caller] {
+		if rl.service != _matchAllRule && rl.service != service {
+			continue
+		}
+		if matchRule(method, rl.rule) {
+			return rl.rateLimiter
+		}
+	}
+	return nil
+}
+
+// match returns the rateLimiter of the first rule which matches
+// the method, or the default rateLimiter
+func (m *RateLimitInboundMiddleware) match(
+	service string,
+	method string,
+) *rateLimiter {
 	// service is not configured, check if there is
 	// default rate limit
-	var ok bool
-	var rls []*rateLimiter
-	if rls, ok = m.rateLimits[service]; !ok {
-		return m.defaultRateLimit.Allow()
+	rls, ok := m.rateLimits[service]
+	if !ok {
+		return m.defaultRateLimit
 	}
 
 	// found the service, check if method is configured
 	for _, rl := range rls {
 		if matchRule(method, rl.rule) {
-			return rl.Allow()
+			return rl
 		}
 	}
 
 	// no rate limit configured,
-	return m.defaultRateLimit.Allow()
-
+	return m.defaultRateLimit
 }
 
 func matchRule(method string, rule string) bool {
@@ -187,3 +406,58 @@ func matchRule(method string, rule string) bool {
 
 	return rule == method
 }
+
+// limiterKey is the key of the token bucket of a caller for a rule
+type limiterKey struct {
+	caller string
+	rule   *rateLimiter
+}
+
+type limiterEntry struct {
+	key     limiterKey
+	limiter *rate.Limiter
+}
+
+// limiterCache keeps the token buckets of the callers,
+// and evicts the least recently used one when it is full
+type limiterCache struct {
+	sync.Mutex
+
+	size      int
+	entries   map[limiterKey]*list.Element
+	lru       *list.List
+	evictions tally.Counter
+}
+
+func newLimiterCache(size int, scope tally.Scope) *limiterCache {
+	return &limiterCache{
+		size:      size,
+		entries:   make(map[limiterKey]*list.Element),
+		lru:       list.New(),
+		evictions: scope.Counter("limiter_evictions"),
+	}
+}
+
+// get returns the token bucket of the caller for the rule, an
+// evicted bucket is created again full of tokens
+func (c *limiterCache) get(caller string, rl *rateLimiter) *rate.Limiter {
+	c.Lock()
+	defer c.Unlock()
+
+	key := limiterKey{caller: caller, rule: rl}
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		return e.Value.(*limiterEntry).limiter
+	}
+
+	if c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*limiterEntry).key)
+		c.evictions.Inc(1)
+	}
+
+	limiter := createLimiter(rl.bucket.Rate, rl.bucket.Burst)
+	c.entries[key] = c.lru.PushFront(&limiterEntry{key: key, limiter: limiter})
+	return limiter
+}
@@ -21,10 +21,16 @@ import (
 	"go.uber.org/yarpc/api/transport/transporttest"
 	"testing"
 
+	"github.com/uber/peloton/pkg/auth"
+	auth_mocks "github.com/uber/peloton/pkg/auth/mocks"
+
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 	"golang.org/x/time/rate"
 )
 
+const _testCaller = "testCaller"
+
 type RateLimitInboundMiddlewareTestSuite struct {
 	suite.Suite
 
@@ -80,11 +86,11 @@ func (suite *RateLimitInboundMiddlewareTestSuite) TestAllow() {
 		{procedure: "testService2::get2", allow: false},
 	}
 
-	mw, err := NewRateLimitInboundMiddleware(config)
+	mw, err := NewRateLimitInboundMiddleware(config, tally.NoopScope)
 	suite.NoError(err)
 
 	for _, test := range tests {
-		suite.Equal(mw.allow(test.procedure), test.allow, test.procedure)
+		suite.Equal(mw.allow(test.procedure, _testCaller, _userCallerClass), test.allow, test.procedure)
 	}
 }
 
@@ -110,17 +116,17 @@ func (suite *RateLimitInboundMiddlewareTestSuite) TestAllowDefaultConfig() {
 		{procedure: "testService2::get2", allow: true},
 	}
 
-	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{})
+	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{}, tally.NoopScope)
 	suite.NoError(err)
 
 	for _, test := range tests {
-		suite.Equal(mw.allow(test.procedure), test.allow, test.procedure)
+		suite.Equal(mw.allow(test.procedure, _testCaller, _userCallerClass), test.allow, test.procedure)
 	}
 }
 
 // TestHandleSuccess tests handle method passes rate limit check successfully
 func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleSuccess() {
-	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{})
+	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{}, tally.NoopScope)
 	suite.NoError(err)
 
 	h := transporttest.NewMockUnaryHandler(suite.ctrl)
@@ -130,7 +136,7 @@ func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleSuccess() {
 
 // TestHandleFailure tests handle method passing rate limit check failed
 func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleFailure() {
-	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{Enabled: true})
+	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{Enabled: true}, tally.NoopScope)
 	suite.NoError(err)
 
 	h := transporttest.NewMockUnaryHandler(suite.ctrl)
@@ -139,7 +145,7 @@ func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleFailure() {
 
 // TestHandleOnewaySuccess tests handleOneWay method passes rate limit check successfully
 func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleOnewaySuccess() {
-	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{})
+	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{}, tally.NoopScope)
 	suite.NoError(err)
 
 	h := transporttest.NewMockOnewayHandler(suite.ctrl)
@@ -150,7 +156,7 @@ func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleOnewaySuccess() {
 
 // TestHandleOnewayFailure tests handleOneWay method passing rate limit check failed
 func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleOnewayFailure() {
-	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{Enabled: true})
+	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{Enabled: true}, tally.NoopScope)
 	suite.NoError(err)
 
 	h := transporttest.NewMockOnewayHandler(suite.ctrl)
@@ -160,7 +166,7 @@ func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleOnewayFailure() {
 
 // TestHandleStreamSuccess tests handleStream method passes rate limit check successfully
 func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleStreamSuccess() {
-	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{})
+	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{}, tally.NoopScope)
 	suite.NoError(err)
 
 	h := transporttest.NewMockStreamHandler(suite.ctrl)
@@ -178,7 +184,7 @@ func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleStreamSuccess() {
 
 // TestHandleStreamFailure tests handleOneWay method passing rate limit check failed
 func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleStreamFailure() {
-	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{Enabled: true})
+	mw, err := NewRateLimitInboundMiddleware(RateLimitConfig{Enabled: true}, tally.NoopScope)
 	suite.NoError(err)
 
 	h := transporttest.NewMockStreamHandler(suite.ctrl)
@@ -193,6 +199,238 @@ func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleStreamFailure() {
 	suite.Error(mw.HandleStream(ss, h))
 }
 
+// TestAllowPerCaller tests that every caller has its own token
+// buckets if per caller rate limit is enabled
+func (suite *RateLimitInboundMiddlewareTestSuite) TestAllowPerCaller() {
+	config := RateLimitConfig{
+		Enabled: true,
+		Methods: []struct {
+			Name        string
+			TokenBucket `yaml:",inline"`
+		}{
+			{Name: "testService:get*", TokenBucket: TokenBucket{Rate: 0, Burst: 1}},
+		},
+		Default:   TokenBucket{Rate: 0, Burst: 2},
+		PerCaller: true,
+	}
+
+	scope := tally.NewTestScope("", nil)
+	mw, err := NewRateLimitInboundMiddleware(config, scope)
+	suite.NoError(err)
+
+	suite.True(mw.allow("testService::get1", "alice", _userCallerClass))
+	suite.False(mw.allow("testService::get2", "alice", _userCallerClass))
+	suite.True(mw.allow("testService::get1", "bob", _userCallerClass))
+	suite.False(mw.allow("testService::get1", "bob", _userCallerClass))
+
+	// the default bucket is not shared with the get* rule
+	suite.True(mw.allow("testService::list", "alice", _userCallerClass))
+	suite.True(mw.allow("testService1::list", "alice", _userCallerClass))
+	suite.False(mw.allow("testService::list", "alice", _userCallerClass))
+
+	// the rejected calls are tagged with the class of the callers
+	counters := scope.Snapshot().Counters()
+	suite.Equal(int64(3), counters["rejected+caller=_user"].Value())
+	suite.Nil(counters["rejected+caller=alice"])
+}
+
+// TestAllowOverrides tests that the overrides of a caller are
+// evaluated before the rules of all the callers
+func (suite *RateLimitInboundMiddlewareTestSuite) TestAllowOverrides() {
+	config := RateLimitConfig{
+		Enabled: true,
+		Methods: []struct {
+			Name        string
+			TokenBucket `yaml:",inline"`
+		}{
+			{Name: "testService:get*", TokenBucket: TokenBucket{Rate: rate.Inf, Burst: 1}},
+		},
+		Default: TokenBucket{Rate: rate.Inf, Burst: 1},
+		Overrides: []struct {
+			Caller      string
+			Name        string
+			TokenBucket `yaml:",inline"`
+		}{
+			{Caller: "alice", Name: "testService:get1", TokenBucket: TokenBucket{Rate: 0, Burst: 1}},
+			{Caller: "alice", Name: "*:*", TokenBucket: TokenBucket{Rate: 0, Burst: 0}},
+		},
+	}
+
+	scope := tally.NewTestScope("", nil)
+	mw, err := NewRateLimitInboundMiddleware(config, scope)
+	suite.NoError(err)
+
+	suite.True(mw.allow("testService::get1", "alice", _userCallerClass))
+	suite.False(mw.allow("testService::get1", "alice", _userCallerClass))
+	suite.False(mw.allow("testService::get2", "alice", _userCallerClass))
+	suite.False(mw.allow("testService1::list", "alice", _userCallerClass))
+
+	suite.True(mw.allow("testService::get1", "bob", _userCallerClass))
+	suite.True(mw.allow("testService::get1", "bob", _userCallerClass))
+	suite.True(mw.allow("testService1::list", "bob", _userCallerClass))
+
+	// the rejected calls of an override are tagged with its caller
+	suite.Equal(
+		int64(3),
+		scope.Snapshot().Counters()["rejected+caller=alice"].Value(),
+	)
+}
+
+// TestLimiterCacheEviction tests that the least recently used
+// token bucket is evicted when the cache is full
+func (suite *RateLimitInboundMiddlewareTestSuite) TestLimiterCacheEviction() {
+	config := RateLimitConfig{
+		Enabled:   true,
+		Default:   TokenBucket{Rate: 0, Burst: 1},
+		PerCaller: true,
+		CacheSize: 2,
+	}
+
+	scope := tally.NewTestScope("", nil)
+	mw, err := NewRateLimitInboundMiddleware(config, scope)
+	suite.NoError(err)
+
+	suite.True(mw.allow("testService::get1", "alice", _userCallerClass))
+	suite.True(mw.allow("testService::get1", "bob", _userCallerClass))
+	suite.False(mw.allow("testService::get1", "alice", _userCallerClass))
+
+	// bob is the least recently used caller
+	suite.True(mw.allow("testService::get1", "carol", _userCallerClass))
+	suite.Equal(2, mw.limiters.lru.Len())
+	suite.False(mw.allow("testService::get1", "alice", _userCallerClass))
+	suite.True(mw.allow("testService::get1", "bob", _userCallerClass))
+	suite.Equal(
+		int64(2),
+		scope.Snapshot().Counters()["limiter_evictions+"].Value(),
+	)
+}
+
+// TestInvalidConfig tests creating the middleware with invalid rules
+func (suite *RateLimitInboundMiddlewareTestSuite) TestInvalidConfig() {
+	config := RateLimitConfig{
+		Enabled: true,
+		Methods: []struct {
+			Name        string
+			TokenBucket `yaml:",inline"`
+		}{
+			{Name: "testService"},
+		},
+	}
+	_, err := NewRateLimitInboundMiddleware(config, tally.NoopScope)
+	suite.Error(err)
+
+	config = RateLimitConfig{
+		Enabled: true,
+		Overrides: []struct {
+			Caller      string
+			Name        string
+			TokenBucket `yaml:",inline"`
+		}{
+			{Name: "testService:get1"},
+		},
+	}
+	_, err = NewRateLimitInboundMiddleware(config, tally.NoopScope)
+	suite.Error(err)
+}
+
+// TestHandleAuthenticatedUser tests that calls are rate
+// limited by the authenticated user
+func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleAuthenticatedUser() {
+	config := RateLimitConfig{
+		Enabled:   true,
+		Default:   TokenBucket{Rate: 0, Burst: 1},
+		PerCaller: true,
+	}
+	mw, err := NewRateLimitInboundMiddleware(config, tally.NoopScope)
+	suite.NoError(err)
+
+	user := auth_mocks.NewMockUser(suite.ctrl)
+	user.EXPECT().Name().Return("alice").AnyTimes()
+	ctx := auth.ContextWithUser(context.Background(), user)
+
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	h.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	suite.NoError(mw.Handle(ctx, suite.r, nil, h))
+	suite.Error(mw.Handle(ctx, suite.r, nil, h))
+}
+
+// TestHandleCallerService tests that calls without authenticated
+// user are rate limited by their calling service
+func (suite *RateLimitInboundMiddlewareTestSuite) TestHandleCallerService() {
+	config := RateLimitConfig{
+		Enabled:   true,
+		Default:   TokenBucket{Rate: 0, Burst: 1},
+		PerCaller: true,
+	}
+	scope := tally.NewTestScope("", nil)
+	mw, err := NewRateLimitInboundMiddleware(config, scope)
+	suite.NoError(err)
+
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	h.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+	request := func(caller string) *transport.Request {
+		return &transport.Request{
+			Caller:    caller,
+			Procedure: suite.r.Procedure,
+		}
+	}
+	suite.NoError(mw.Handle(context.Background(), request("service-a"), nil, h))
+	suite.Error(mw.Handle(context.Background(), request("service-a"), nil, h))
+	suite.NoError(mw.Handle(context.Background(), request("service-b"), nil, h))
+
+	// the calls without calling service share their token buckets
+	suite.NoError(mw.Handle(context.Background(), request(""), nil, h))
+	suite.Error(mw.Handle(context.Background(), request(""), nil, h))
+
+	counters := scope.Snapshot().Counters()
+	suite.Equal(int64(1), counters["rejected+caller=_service"].Value())
+	suite.Equal(int64(1), counters["rejected+caller=_anonymous"].Value())
+}
+
+// TestNewRateLimitInboundMiddlewares tests that the calls are rate
+// limited before authentication unless the limits depend on the caller
+func (suite *RateLimitInboundMiddlewareTestSuite) TestNewRateLimitInboundMiddlewares() {
+	config := RateLimitConfig{
+		Enabled: true,
+		Default: TokenBucket{Rate: 0, Burst: 1},
+	}
+	preAuth, postAuth, err := NewRateLimitInboundMiddlewares(
+		config, tally.NoopScope)
+	suite.NoError(err)
+	suite.True(preAuth.allow("testService::get1", _anonymousCaller, _anonymousCaller))
+	suite.False(preAuth.allow("testService::get1", _anonymousCaller, _anonymousCaller))
+	suite.True(postAuth.allow("testService::get1", "alice", _userCallerClass))
+	suite.True(postAuth.allow("testService::get1", "alice", _userCallerClass))
+
+	// only the pre auth limit is checked before authentication
+	// when the calls are rate limited per caller
+	config.PerCaller = true
+	config.PreAuth = &TokenBucket{Rate: 0, Burst: 2}
+	scope := tally.NewTestScope("", nil)
+	preAuth, postAuth, err = NewRateLimitInboundMiddlewares(config, scope)
+	suite.NoError(err)
+	suite.True(preAuth.allow("testService::get1", _anonymousCaller, _anonymousCaller))
+	suite.True(preAuth.allow("testService::get2", _anonymousCaller, _anonymousCaller))
+	suite.False(preAuth.allow("testService::get1", _anonymousCaller, _anonymousCaller))
+	suite.True(postAuth.allow("testService::get1", "alice", _userCallerClass))
+	suite.False(postAuth.allow("testService::get1", "alice", _userCallerClass))
+	suite.True(postAuth.allow("testService::get1", "bob", _userCallerClass))
+
+	counters := scope.Snapshot().Counters()
+	suite.Equal(int64(1), counters["pre_auth.rejected+caller=_anonymous"].Value())
+	suite.Equal(int64(1), counters["rejected+caller=_user"].Value())
+
+	// no pre auth limit by default
+	config.PreAuth = nil
+	preAuth, _, err = NewRateLimitInboundMiddlewares(config, tally.NoopScope)
+	suite.NoError(err)
+	for i := 0; i < 3; i++ {
+		suite.True(preAuth.allow("testService::get1", _anonymousCaller, _anonymousCaller))
+	}
+}
+
 func TestRateLimitInboundMiddlewareTestSuite(t *testing.T) {
 	suite.Run(t, &RateLimitInboundMiddlewareTestSuite{})
 }
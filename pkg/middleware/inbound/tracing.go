@@ -0,0 +1,76 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"context"
+
+	"github.com/uber/peloton/pkg/auth"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+)
+
+const _userSpanTag = "peloton.user"
+
+// TracingInboundMiddleware tags the span of an inbound call, which the
+// transport starts from the span context propagated by the caller, with
+// the authenticated user, so that the traces of a user can be searched.
+// It has to run after AuthInboundMiddleware.
+type TracingInboundMiddleware struct{}
+
+// Handle tags the span and invokes the underlying handler
+func (m *TracingInboundMiddleware) Handle(
+	ctx context.Context,
+	req *transport.Request,
+	resw transport.ResponseWriter,
+	h transport.UnaryHandler,
+) error {
+	tagSpanWithUser(ctx)
+	return h.Handle(ctx, req, resw)
+}
+
+// HandleOneway tags the span and invokes the underlying handler
+func (m *TracingInboundMiddleware) HandleOneway(
+	ctx context.Context,
+	req *transport.Request,
+	h transport.OnewayHandler,
+) error {
+	tagSpanWithUser(ctx)
+	return h.HandleOneway(ctx, req)
+}
+
+// HandleStream invokes the underlying handler, the authenticated
+// user is not passed to stream handlers
+func (m *TracingInboundMiddleware) HandleStream(
+	s *transport.ServerStream,
+	h transport.StreamHandler,
+) error {
+	return h.HandleStream(s)
+}
+
+// tagSpanWithUser tags the span in ctx with the authenticated user
+func tagSpanWithUser(ctx context.Context) {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+
+	user, ok := auth.UserFromContext(ctx)
+	if !ok || len(user.Name()) == 0 {
+		return
+	}
+	span.SetTag(_userSpanTag, user.Name())
+}
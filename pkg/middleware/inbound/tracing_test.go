@@ -0,0 +1,109 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"context"
+	"testing"
+
+	"github.com/uber/peloton/pkg/auth"
+	auth_mocks "github.com/uber/peloton/pkg/auth/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+)
+
+type TracingInboundMiddlewareTestSuite struct {
+	suite.Suite
+
+	ctrl   *gomock.Controller
+	tracer *mocktracer.MockTracer
+	mw     *TracingInboundMiddleware
+	r      *transport.Request
+}
+
+func (suite *TracingInboundMiddlewareTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.tracer = mocktracer.New()
+	suite.mw = &TracingInboundMiddleware{}
+	suite.r = &transport.Request{
+		Procedure: "testService::get1",
+	}
+}
+
+func (suite *TracingInboundMiddlewareTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestTracingInboundMiddleware(t *testing.T) {
+	suite.Run(t, new(TracingInboundMiddlewareTestSuite))
+}
+
+// contextWithSpanAndUser returns a context with a span, and the
+// user with the given name if the name is not empty
+func (suite *TracingInboundMiddlewareTestSuite) contextWithSpanAndUser(
+	name string,
+) (context.Context, *mocktracer.MockSpan) {
+	span := suite.tracer.StartSpan("test").(*mocktracer.MockSpan)
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	if len(name) == 0 {
+		return ctx, span
+	}
+
+	user := auth_mocks.NewMockUser(suite.ctrl)
+	user.EXPECT().Name().Return(name).AnyTimes()
+	return auth.ContextWithUser(ctx, user), span
+}
+
+// TestHandle tests that the span is tagged with the authenticated user
+func (suite *TracingInboundMiddlewareTestSuite) TestHandle() {
+	ctx, span := suite.contextWithSpanAndUser("alice")
+
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	h.EXPECT().Handle(ctx, suite.r, nil).Return(nil)
+	suite.NoError(suite.mw.Handle(ctx, suite.r, nil, h))
+	suite.Equal("alice", span.Tag(_userSpanTag))
+}
+
+// TestHandleOneway tests that the span is tagged with the authenticated user
+func (suite *TracingInboundMiddlewareTestSuite) TestHandleOneway() {
+	ctx, span := suite.contextWithSpanAndUser("alice")
+
+	h := transporttest.NewMockOnewayHandler(suite.ctrl)
+	h.EXPECT().HandleOneway(ctx, suite.r).Return(nil)
+	suite.NoError(suite.mw.HandleOneway(ctx, suite.r, h))
+	suite.Equal("alice", span.Tag(_userSpanTag))
+}
+
+// TestHandleAnonymous tests that the span of an anonymous call is not tagged
+func (suite *TracingInboundMiddlewareTestSuite) TestHandleAnonymous() {
+	ctx, span := suite.contextWithSpanAndUser("")
+
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	h.EXPECT().Handle(ctx, suite.r, nil).Return(nil)
+	suite.NoError(suite.mw.Handle(ctx, suite.r, nil, h))
+	suite.Nil(span.Tag(_userSpanTag))
+}
+
+// TestHandleWithoutSpan tests a call which is not traced
+func (suite *TracingInboundMiddlewareTestSuite) TestHandleWithoutSpan() {
+	h := transporttest.NewMockUnaryHandler(suite.ctrl)
+	h.EXPECT().Handle(gomock.Any(), suite.r, nil).Return(nil)
+	suite.NoError(suite.mw.Handle(context.Background(), suite.r, nil, h))
+}
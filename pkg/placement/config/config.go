@@ -23,6 +23,7 @@ import (
 	"github.com/uber/peloton/pkg/common/leader"
 	"github.com/uber/peloton/pkg/common/logging"
 	"github.com/uber/peloton/pkg/common/metrics"
+	"github.com/uber/peloton/pkg/common/tracing"
 	"github.com/uber/peloton/pkg/hostmgr/mesos"
 	"github.com/uber/peloton/pkg/storage/config"
 )
@@ -44,6 +45,7 @@ type Config struct {
 	Storage      config.Config         `yaml:"storage"`
 	SentryConfig logging.SentryConfig  `yaml:"sentry"`
 	Auth         auth.Config           `yaml:"auth"`
+	Tracing      tracing.Config        `yaml:"tracing"`
 }
 
 // PlacementStrategy determines the placement strategy that the placement
@@ -103,6 +105,29 @@ type PlacementConfig struct {
 	// MaxDesiredHostPlacementDuration is the max time duration to try to
 	// place a task on the desired host.
 	MaxDesiredHostPlacementDuration time.Duration `yaml:"max_desired_host_placement_duration"`
+
+	// Defragmentation is the config of the defragmenter which preempts
+	// running tasks to free whole hosts for tasks which cannot be placed.
+	Defragmentation DefragmentationConfig `yaml:"defragmentation"`
+}
+
+// DefragmentationConfig is the config of the defragmenter of the
+// placement engine.
+type DefragmentationConfig struct {
+	// Enabled determines if the defragmenter is running.
+	Enabled bool `yaml:"enabled"`
+
+	// Period is the period at which the defragmenter looks for running
+	// tasks to preempt.
+	Period time.Duration `yaml:"period"`
+
+	// UnplacedTaskTTL is how long a task which failed to be placed is
+	// considered by the defragmenter, unless it fails to be placed again.
+	UnplacedTaskTTL time.Duration `yaml:"unplaced_task_ttl"`
+
+	// MaxPreemptionsPerRound is the maximal number of running tasks that
+	// are preempted in a single defragmentation round.
+	MaxPreemptionsPerRound int `yaml:"max_preemptions_per_round"`
 }
 
 // MaxRoundsConfig is the config of the maximal number of successful rounds
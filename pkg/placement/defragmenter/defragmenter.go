@@ -0,0 +1,491 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package defragmenter
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
+	"github.com/uber/peloton/.gen/peloton/private/resmgr"
+
+	"github.com/uber/peloton/pkg/common/async"
+	"github.com/uber/peloton/pkg/placement/config"
+	"github.com/uber/peloton/pkg/placement/hosts"
+	tally_metrics "github.com/uber/peloton/pkg/placement/metrics"
+	"github.com/uber/peloton/pkg/placement/models"
+	"github.com/uber/peloton/pkg/placement/plugins/mimir/lib/algorithms"
+	"github.com/uber/peloton/pkg/placement/plugins/mimir/lib/model/placement"
+	"github.com/uber/peloton/pkg/placement/plugins/mimir/v0"
+	"github.com/uber/peloton/pkg/placement/tasks"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// concurrency and minimal number of hosts before concurrency is
+	// used by the relocator, same as for the mimir placer.
+	_relocatorConcurrency = 4
+	_relocatorMinimumSize = 300
+
+	_defaultPeriod                 = 60 * time.Second
+	_defaultUnplacedTaskTTL        = 5 * time.Minute
+	_defaultMaxPreemptionsPerRound = 20
+)
+
+// Defragmenter represents a placement engine's defragmentation module.
+// It keeps track of the tasks which could not be placed, and periodically
+// preempts running preemptible tasks, which can be placed elsewhere, to
+// free whole hosts for them.
+type Defragmenter interface {
+	// Adding daemon interface for Defragmenter
+	async.Daemon
+
+	// AddUnplacedTasks records tasks which could not be placed, such that
+	// the next defragmentation rounds try to free hosts for them.
+	AddUnplacedTasks(tasks []*resmgr.Task)
+
+	// Defragment runs a single defragmentation round and returns the
+	// running tasks which were preempted.
+	Defragment(ctx context.Context) ([]*resmgr.Task, error)
+}
+
+// unplacedTask is a task which could not be placed.
+type unplacedTask struct {
+	task *resmgr.Task
+	// time after which the task is no longer considered
+	expiry time.Time
+}
+
+// gang is the group of unplaced tasks of a job, for which hosts
+// should be freed together.
+type gang struct {
+	jobID string
+	tasks []*resmgr.Task
+	gpus  float64
+}
+
+// eviction is the running tasks which should be preempted to make an
+// unplaced task fit on a host.
+type eviction struct {
+	group    *placement.Group
+	entities []*placement.Entity
+	rank     int
+}
+
+// defragmenter is the struct which implements Defragmenter interface
+type defragmenter struct {
+	lock sync.Mutex
+	// Defragmentation config
+	config *config.DefragmentationConfig
+	// Placement engine metrics
+	metrics *tally_metrics.Metrics
+	// hostService for fetching the hosts and the tasks running on them
+	hostService hosts.Service
+	// taskService for preempting the running tasks
+	taskService tasks.Service
+	// relocator ranks the running tasks by how many other hosts are
+	// better for them than their current host
+	relocator algorithms.Relocator
+	// daemon object for making defragmenter a daemon process
+	daemon async.Daemon
+	// unplaced tasks indexed by task id
+	unplaced map[string]*unplacedTask
+}
+
+// NewDefragmenter creates a new defragmenter which periodically preempts
+// running tasks to free whole hosts for the tasks which could not be placed.
+func NewDefragmenter(
+	metrics *tally_metrics.Metrics,
+	cfg *config.PlacementConfig,
+	hostsService hosts.Service,
+	taskService tasks.Service) Defragmenter {
+	defragConfig := cfg.Defragmentation
+	if defragConfig.Period == 0 {
+		defragConfig.Period = _defaultPeriod
+	}
+	if defragConfig.UnplacedTaskTTL == 0 {
+		defragConfig.UnplacedTaskTTL = _defaultUnplacedTaskTTL
+	}
+	if defragConfig.MaxPreemptionsPerRound == 0 {
+		defragConfig.MaxPreemptionsPerRound = _defaultMaxPreemptionsPerRound
+	}
+
+	defragmenter := &defragmenter{
+		config:      &defragConfig,
+		metrics:     metrics,
+		hostService: hostsService,
+		taskService: taskService,
+		relocator: algorithms.NewRelocator(
+			_relocatorConcurrency,
+			_relocatorMinimumSize),
+		unplaced: make(map[string]*unplacedTask),
+	}
+	defragmenter.daemon = async.NewDaemon(
+		"Placement Engine Defragmenter", defragmenter)
+	return defragmenter
+}
+
+// Start method starts the daemon process if defragmentation is enabled
+func (d *defragmenter) Start() {
+	if !d.config.Enabled {
+		log.Info("Defragmentation is not enabled")
+		return
+	}
+	d.daemon.Start()
+}
+
+// Run method implements runnable from daemon
+// this is the method which gets called while starting the
+// daemon process.
+func (d *defragmenter) Run(ctx context.Context) error {
+	timer := time.NewTimer(d.config.Period)
+	for {
+		select {
+		case <-ctx.Done():
+			if !timer.Stop() {
+				<-timer.C
+			}
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if _, err := d.Defragment(ctx); err != nil {
+			log.WithError(err).Info("failed to defragment hosts")
+		}
+		timer.Reset(d.config.Period)
+	}
+}
+
+// Stop method will stop the daemon process.
+func (d *defragmenter) Stop() {
+	if !d.config.Enabled {
+		return
+	}
+	d.daemon.Stop()
+}
+
+// AddUnplacedTasks records the tasks which could not be placed
+func (d *defragmenter) AddUnplacedTasks(tasks []*resmgr.Task) {
+	if !d.config.Enabled {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	expiry := time.Now().Add(d.config.UnplacedTaskTTL)
+	for _, task := range tasks {
+		d.unplaced[task.GetId().GetValue()] = &unplacedTask{
+			task:   task,
+			expiry: expiry,
+		}
+	}
+}
+
+// Defragment method is being called from Run method
+// This method does following steps
+//  1. Group the unplaced tasks into gangs, largest first
+//  2. Fetch the hosts and the tasks running on them for each gang
+//  3. Rank the running preemptible tasks with the mimir relocator
+//  4. Pick one host per task of the gang, which needs the fewest and
+//     most relocatable running tasks to be preempted
+//  5. Preempt the running tasks of the picked hosts in resource manager
+func (d *defragmenter) Defragment(ctx context.Context) ([]*resmgr.Task, error) {
+	start := time.Now()
+	defer func() {
+		d.metrics.DefragDuration.Record(time.Since(start))
+	}()
+
+	gangs := d.unplacedGangs(start)
+
+	budget := d.config.MaxPreemptionsPerRound
+	preempted := make(map[string]struct{})
+	var toPreempt []*resmgr.Task
+	for _, g := range gangs {
+		hosts, err := d.getHosts(ctx, g.tasks[0])
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", g.jobID).
+				Info("failed to fetch hosts for defragmentation")
+			continue
+		}
+
+		evictions, runningTasks := d.findEvictions(g, hosts, preempted)
+		if evictions == nil {
+			log.WithField("job_id", g.jobID).
+				Debug("unable to free enough hosts for unplaced tasks")
+			continue
+		}
+
+		var gangTasks []*resmgr.Task
+		for _, e := range evictions {
+			for _, entity := range e.entities {
+				gangTasks = append(gangTasks, runningTasks[entity])
+			}
+		}
+		if len(gangTasks) == 0 {
+			// the tasks fit on the hosts already, e.g. because running
+			// tasks completed since they failed to be placed
+			d.removeUnplacedTasks(g.tasks)
+			continue
+		}
+		if len(gangTasks) > budget {
+			continue
+		}
+
+		for _, task := range gangTasks {
+			preempted[task.GetId().GetValue()] = struct{}{}
+		}
+		budget -= len(gangTasks)
+		toPreempt = append(toPreempt, gangTasks...)
+		d.metrics.DefragHostsFreed.Inc(int64(len(evictions)))
+		d.removeUnplacedTasks(g.tasks)
+
+		log.WithFields(log.Fields{
+			"job_id":          g.jobID,
+			"unplaced_tasks":  len(g.tasks),
+			"preempted_tasks": len(gangTasks),
+		}).Info("Freeing hosts for unplaced tasks")
+	}
+
+	if len(toPreempt) == 0 {
+		return nil, nil
+	}
+
+	if err := d.taskService.Preempt(
+		ctx,
+		toPreempt,
+		resmgr.PreemptionReason_PREEMPTION_REASON_DEFRAGMENTATION); err != nil {
+		return nil, err
+	}
+	return toPreempt, nil
+}
+
+// unplacedGangs drops the expired unplaced tasks and returns the remaining
+// ones grouped by job, with the largest gangs first.
+func (d *defragmenter) unplacedGangs(now time.Time) []*gang {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	gangsByJob := make(map[string]*gang)
+	for id, u := range d.unplaced {
+		if now.After(u.expiry) {
+			delete(d.unplaced, id)
+			continue
+		}
+		jobID := u.task.GetJobId().GetValue()
+		g, ok := gangsByJob[jobID]
+		if !ok {
+			g = &gang{jobID: jobID}
+			gangsByJob[jobID] = g
+		}
+		g.tasks = append(g.tasks, u.task)
+		g.gpus += u.task.GetResource().GetGpuLimit()
+	}
+	d.metrics.DefragUnplacedTasks.Update(float64(len(d.unplaced)))
+
+	gangs := make([]*gang, 0, len(gangsByJob))
+	for _, g := range gangsByJob {
+		sort.Slice(g.tasks, func(i, j int) bool {
+			return g.tasks[i].GetId().GetValue() < g.tasks[j].GetId().GetValue()
+		})
+		gangs = append(gangs, g)
+	}
+	sort.Slice(gangs, func(i, j int) bool {
+		if gangs[i].gpus != gangs[j].gpus {
+			return gangs[i].gpus > gangs[j].gpus
+		}
+		if len(gangs[i].tasks) != len(gangs[j].tasks) {
+			return len(gangs[i].tasks) > len(gangs[j].tasks)
+		}
+		return gangs[i].jobID < gangs[j].jobID
+	})
+	return gangs
+}
+
+// removeUnplacedTasks stops tracking the given tasks, they will be tracked
+// again if they still cannot be placed once the hosts have been freed.
+func (d *defragmenter) removeUnplacedTasks(tasks []*resmgr.Task) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for _, task := range tasks {
+		delete(d.unplaced, task.GetId().GetValue())
+	}
+}
+
+// getHosts fetches the hosts which could run the given task if they
+// were empty, together with all tasks running on them.
+func (d *defragmenter) getHosts(
+	ctx context.Context,
+	task *resmgr.Task) ([]*models.Host, error) {
+	filter := &hostsvc.HostFilter{
+		ResourceConstraint: &hostsvc.ResourceConstraint{
+			Minimum:  task.GetResource(),
+			NumPorts: task.GetNumPorts(),
+		},
+		SchedulingConstraint: task.GetConstraint(),
+	}
+	// The host service only uses the task to select the type of the
+	// running tasks to fetch, fetch the tasks of all types such that
+	// the free resources of the hosts are accurate.
+	return d.hostService.GetHosts(
+		ctx,
+		&resmgr.Task{Type: resmgr.TaskType_UNKNOWN},
+		filter)
+}
+
+// findEvictions returns one eviction per task of the gang, or nil if not
+// all tasks of the gang can be made to fit. It also returns the map from
+// the entities to the running tasks they were created from.
+func (d *defragmenter) findEvictions(
+	g *gang,
+	hosts []*models.Host,
+	preempted map[string]struct{},
+) ([]*eviction, map[*placement.Entity]*resmgr.Task) {
+	groups := make([]*placement.Group, 0, len(hosts))
+	runningTasks := make(map[*placement.Entity]*resmgr.Task)
+	var ranks []*placement.RelocationRank
+	for _, host := range hosts {
+		group := v0_mimir.OfferToGroup(&hostsvc.HostOffer{
+			Hostname:   host.GetHost().GetHostname(),
+			AgentId:    host.GetHost().GetAgentId(),
+			Resources:  host.GetHost().GetResources(),
+			Attributes: host.GetHost().GetAttributes(),
+		})
+		var candidates []*placement.Entity
+		for _, task := range host.GetTasks() {
+			if _, ok := preempted[task.GetId().GetValue()]; ok {
+				// already preempted for another gang in this round
+				continue
+			}
+			entity := v0_mimir.TaskToEntity(task, false)
+			group.Entities.Add(entity)
+			runningTasks[entity] = task
+			if task.GetPreemptible() {
+				candidates = append(candidates, entity)
+			}
+		}
+		group.Update()
+		groups = append(groups, group)
+		for _, entity := range candidates {
+			ranks = append(ranks, placement.NewRelocationRank(entity, group))
+		}
+	}
+
+	// Rank the running preemptible tasks by how many other hosts are
+	// better for them than their current host.
+	scopeSet := placement.NewScopeSet(groups)
+	d.relocator.Relocate(ranks, groups, scopeSet)
+	ranksByGroup := make(map[*placement.Group][]*placement.RelocationRank)
+	for _, rank := range ranks {
+		if rank.Rank == 0 {
+			// the task cannot be placed anywhere else
+			continue
+		}
+		ranksByGroup[rank.CurrentGroup] = append(
+			ranksByGroup[rank.CurrentGroup], rank)
+	}
+
+	used := make(map[*placement.Group]struct{})
+	evictions := make([]*eviction, 0, len(g.tasks))
+	for _, task := range g.tasks {
+		entity := v0_mimir.TaskToEntity(task, false)
+		var best *eviction
+		for _, group := range groups {
+			if _, ok := used[group]; ok {
+				continue
+			}
+			e := evict(group, entity, task, runningTasks, ranksByGroup[group], scopeSet)
+			if e != nil && better(e, best) {
+				best = e
+			}
+		}
+		if best == nil {
+			return nil, runningTasks
+		}
+		used[best.group] = struct{}{}
+		evictions = append(evictions, best)
+	}
+	return evictions, runningTasks
+}
+
+// evict returns the most relocatable running tasks of the group which need
+// to be preempted for the unplaced entity to fit on the group, or nil if the
+// entity does not fit even after preempting all of them. Only tasks with a
+// priority not higher than the one of the unplaced task are preempted.
+func evict(
+	group *placement.Group,
+	entity *placement.Entity,
+	task *resmgr.Task,
+	runningTasks map[*placement.Entity]*resmgr.Task,
+	ranks []*placement.RelocationRank,
+	scopeSet *placement.ScopeSet) *eviction {
+	var candidates []*placement.RelocationRank
+	for _, rank := range ranks {
+		if runningTasks[rank.Entity].GetPriority() <= task.GetPriority() {
+			candidates = append(candidates, rank)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Rank != candidates[j].Rank {
+			return candidates[i].Rank > candidates[j].Rank
+		}
+		return candidates[i].Entity.Name < candidates[j].Entity.Name
+	})
+
+	transcript := placement.NewTranscript("defragmentation")
+	result := &eviction{group: group}
+	fits := entity.Requirement.Passed(group, scopeSet, entity, transcript)
+	for _, candidate := range candidates {
+		if fits {
+			break
+		}
+		group.Entities.Remove(candidate.Entity)
+		group.Update()
+		result.entities = append(result.entities, candidate.Entity)
+		result.rank += candidate.Rank
+		fits = entity.Requirement.Passed(group, scopeSet, entity, transcript)
+	}
+
+	// Add the removed entities back to the group
+	for _, removed := range result.entities {
+		group.Entities.Add(removed)
+	}
+	group.Update()
+
+	if !fits {
+		return nil
+	}
+	return result
+}
+
+// better returns true if the eviction preempts fewer running tasks than
+// the other eviction, or as many tasks which are more relocatable.
+func better(e, other *eviction) bool {
+	if other == nil {
+		return true
+	}
+	if len(e.entities) != len(other.entities) {
+		return len(e.entities) < len(other.entities)
+	}
+	if e.rank != other.rank {
+		return e.rank > other.rank
+	}
+	return e.group.Name < other.group.Name
+}
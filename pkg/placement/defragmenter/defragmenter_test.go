@@ -0,0 +1,310 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package defragmenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
+	"github.com/uber/peloton/.gen/peloton/private/resmgr"
+
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/placement/config"
+	hosts_mock "github.com/uber/peloton/pkg/placement/hosts/mocks"
+	"github.com/uber/peloton/pkg/placement/metrics"
+	"github.com/uber/peloton/pkg/placement/models"
+	tasks_mock "github.com/uber/peloton/pkg/placement/tasks/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+)
+
+type DefragmenterTestSuite struct {
+	suite.Suite
+
+	ctrl            *gomock.Controller
+	mockHostService *hosts_mock.MockService
+	mockTaskService *tasks_mock.MockService
+	defragmenter    *defragmenter
+}
+
+func TestDefragmenter(t *testing.T) {
+	suite.Run(t, new(DefragmenterTestSuite))
+}
+
+func (suite *DefragmenterTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.mockHostService = hosts_mock.NewMockService(suite.ctrl)
+	suite.mockTaskService = tasks_mock.NewMockService(suite.ctrl)
+	suite.defragmenter = NewDefragmenter(
+		metrics.NewMetrics(tally.NoopScope),
+		&config.PlacementConfig{
+			Defragmentation: config.DefragmentationConfig{
+				Enabled: true,
+				Period:  time.Hour,
+			},
+		},
+		suite.mockHostService,
+		suite.mockTaskService,
+	).(*defragmenter)
+}
+
+func (suite *DefragmenterTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+// newTask creates a task of the given job using the given number of cpus.
+func newTask(
+	jobID string,
+	instance int,
+	cpus float64,
+	preemptible bool) *resmgr.Task {
+	return &resmgr.Task{
+		Id: &peloton.TaskID{
+			Value: fmt.Sprintf("%s-%d", jobID, instance),
+		},
+		JobId:       &peloton.JobID{Value: jobID},
+		Preemptible: preemptible,
+		Resource: &task.ResourceConfig{
+			CpuLimit: cpus,
+		},
+	}
+}
+
+// newHost creates a host with 48 cpus running the given tasks.
+func newHost(hostname string, tasks ...*resmgr.Task) *models.Host {
+	return models.NewHosts(&hostsvc.HostInfo{
+		Hostname: hostname,
+		Resources: []*mesos.Resource{
+			util.NewMesosResourceBuilder().
+				WithName("cpus").
+				WithValue(48).
+				Build(),
+			util.NewMesosResourceBuilder().
+				WithName("mem").
+				WithValue(1024).
+				Build(),
+			util.NewMesosResourceBuilder().
+				WithName("disk").
+				WithValue(1024).
+				Build(),
+			util.NewMesosResourceBuilder().
+				WithName("gpus").
+				WithValue(0).
+				Build(),
+		},
+	}, tasks)
+}
+
+// setupHosts creates three hosts with 8, 8 and 28 free cpus. Only the
+// preemptible tasks of host1 can be placed on another host, i.e. host3.
+func (suite *DefragmenterTestSuite) setupHosts() (
+	[]*models.Host, []*resmgr.Task) {
+	p1 := newTask("running", 1, 16, true)
+	p2 := newTask("running", 2, 16, true)
+	p3 := newTask("running", 3, 24, true)
+	hosts := []*models.Host{
+		newHost("host1", p1, p2, newTask("running", 4, 8, false)),
+		newHost("host2", p3, newTask("running", 5, 16, false)),
+		newHost("host3", newTask("running", 6, 20, false)),
+	}
+	return hosts, []*resmgr.Task{p1, p2, p3}
+}
+
+// TestDefragmentFreesHost tests that the relocatable tasks of the host
+// which needs the fewest preemptions are preempted.
+func (suite *DefragmenterTestSuite) TestDefragmentFreesHost() {
+	hosts, running := suite.setupHosts()
+	unplaced := newTask("unplaced", 0, 32, false)
+	suite.defragmenter.AddUnplacedTasks([]*resmgr.Task{unplaced})
+
+	suite.mockHostService.EXPECT().
+		GetHosts(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context,
+			task *resmgr.Task,
+			filter *hostsvc.HostFilter) {
+			suite.Equal(resmgr.TaskType_UNKNOWN, task.GetType())
+			suite.Equal(unplaced.GetResource(),
+				filter.GetResourceConstraint().GetMinimum())
+		}).
+		Return(hosts, nil)
+	suite.mockTaskService.EXPECT().
+		Preempt(
+			gomock.Any(),
+			running[:2],
+			resmgr.PreemptionReason_PREEMPTION_REASON_DEFRAGMENTATION).
+		Return(nil)
+
+	preempted, err := suite.defragmenter.Defragment(context.Background())
+	suite.NoError(err)
+	suite.Equal(running[:2], preempted)
+
+	// the unplaced task is no longer tracked
+	preempted, err = suite.defragmenter.Defragment(context.Background())
+	suite.NoError(err)
+	suite.Empty(preempted)
+}
+
+// TestDefragmentWholeGang tests that no task is preempted unless hosts
+// can be freed for all the unplaced tasks of a job.
+func (suite *DefragmenterTestSuite) TestDefragmentWholeGang() {
+	hosts, _ := suite.setupHosts()
+	suite.defragmenter.AddUnplacedTasks([]*resmgr.Task{
+		newTask("unplaced", 0, 32, false),
+		newTask("unplaced", 1, 32, false),
+	})
+
+	suite.mockHostService.EXPECT().
+		GetHosts(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(hosts, nil)
+
+	preempted, err := suite.defragmenter.Defragment(context.Background())
+	suite.NoError(err)
+	suite.Empty(preempted)
+	suite.Len(suite.defragmenter.unplaced, 2)
+}
+
+// TestDefragmentTaskFits tests that no task is preempted for unplaced tasks
+// which fit on the hosts already.
+func (suite *DefragmenterTestSuite) TestDefragmentTaskFits() {
+	hosts, _ := suite.setupHosts()
+	suite.defragmenter.AddUnplacedTasks([]*resmgr.Task{
+		newTask("unplaced", 0, 8, false),
+	})
+
+	suite.mockHostService.EXPECT().
+		GetHosts(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(hosts, nil)
+
+	preempted, err := suite.defragmenter.Defragment(context.Background())
+	suite.NoError(err)
+	suite.Empty(preempted)
+	suite.Empty(suite.defragmenter.unplaced)
+}
+
+// TestDefragmentHigherPriority tests that running tasks with a higher
+// priority than the unplaced task are not preempted.
+func (suite *DefragmenterTestSuite) TestDefragmentHigherPriority() {
+	hosts, running := suite.setupHosts()
+	for _, t := range running {
+		t.Priority = 1
+	}
+	suite.defragmenter.AddUnplacedTasks([]*resmgr.Task{
+		newTask("unplaced", 0, 32, false),
+	})
+
+	suite.mockHostService.EXPECT().
+		GetHosts(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(hosts, nil)
+
+	preempted, err := suite.defragmenter.Defragment(context.Background())
+	suite.NoError(err)
+	suite.Empty(preempted)
+}
+
+// TestDefragmentMaxPreemptions tests that no more than the maximal number
+// of running tasks are preempted in a round.
+func (suite *DefragmenterTestSuite) TestDefragmentMaxPreemptions() {
+	suite.defragmenter.config.MaxPreemptionsPerRound = 1
+	hosts, _ := suite.setupHosts()
+	suite.defragmenter.AddUnplacedTasks([]*resmgr.Task{
+		newTask("unplaced", 0, 32, false),
+	})
+
+	suite.mockHostService.EXPECT().
+		GetHosts(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(hosts, nil)
+
+	preempted, err := suite.defragmenter.Defragment(context.Background())
+	suite.NoError(err)
+	suite.Empty(preempted)
+	suite.Len(suite.defragmenter.unplaced, 1)
+}
+
+// TestDefragmentFailures tests the failures to get hosts and to preempt.
+func (suite *DefragmenterTestSuite) TestDefragmentFailures() {
+	hosts, running := suite.setupHosts()
+	suite.defragmenter.AddUnplacedTasks([]*resmgr.Task{
+		newTask("unplaced", 0, 32, false),
+	})
+
+	suite.mockHostService.EXPECT().
+		GetHosts(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("get hosts failed"))
+
+	preempted, err := suite.defragmenter.Defragment(context.Background())
+	suite.NoError(err)
+	suite.Empty(preempted)
+
+	suite.mockHostService.EXPECT().
+		GetHosts(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(hosts, nil)
+	suite.mockTaskService.EXPECT().
+		Preempt(gomock.Any(), running[:2], gomock.Any()).
+		Return(errors.New("preempt failed"))
+
+	_, err = suite.defragmenter.Defragment(context.Background())
+	suite.Error(err)
+}
+
+// TestUnplacedGangs tests that unplaced tasks are grouped by job with the
+// largest gangs first and that expired tasks are dropped.
+func (suite *DefragmenterTestSuite) TestUnplacedGangs() {
+	gpuTask := newTask("gpu", 0, 1, false)
+	gpuTask.Resource.GpuLimit = 4
+	suite.defragmenter.AddUnplacedTasks([]*resmgr.Task{
+		newTask("small", 0, 1, false),
+		newTask("large", 1, 1, false),
+		newTask("large", 0, 1, false),
+		gpuTask,
+	})
+
+	gangs := suite.defragmenter.unplacedGangs(time.Now())
+	suite.Len(gangs, 3)
+	suite.Equal("gpu", gangs[0].jobID)
+	suite.Equal("large", gangs[1].jobID)
+	suite.Equal("large-0", gangs[1].tasks[0].GetId().GetValue())
+	suite.Equal("small", gangs[2].jobID)
+
+	gangs = suite.defragmenter.unplacedGangs(
+		time.Now().Add(2 * _defaultUnplacedTaskTTL))
+	suite.Empty(gangs)
+	suite.Empty(suite.defragmenter.unplaced)
+}
+
+// TestDisabled tests that a disabled defragmenter ignores unplaced tasks.
+func (suite *DefragmenterTestSuite) TestDisabled() {
+	suite.defragmenter.config.Enabled = false
+	suite.defragmenter.Start()
+	suite.defragmenter.AddUnplacedTasks([]*resmgr.Task{
+		newTask("unplaced", 0, 32, false),
+	})
+	suite.Empty(suite.defragmenter.unplaced)
+	suite.defragmenter.Stop()
+}
+
+// TestStartStop tests starting and stopping the defragmenter.
+func (suite *DefragmenterTestSuite) TestStartStop() {
+	suite.defragmenter.Start()
+	suite.defragmenter.Stop()
+}
@@ -28,7 +28,9 @@ import (
 	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
 	"github.com/uber/peloton/.gen/peloton/private/resmgr"
 	"github.com/uber/peloton/pkg/common/async"
+	"github.com/uber/peloton/pkg/common/tracing"
 	"github.com/uber/peloton/pkg/placement/config"
+	"github.com/uber/peloton/pkg/placement/defragmenter"
 	"github.com/uber/peloton/pkg/placement/hosts"
 	tally_metrics "github.com/uber/peloton/pkg/placement/metrics"
 	"github.com/uber/peloton/pkg/placement/models"
@@ -97,6 +99,7 @@ func NewEngine(
 	}
 	result.daemon = async.NewDaemon("Placement Engine", result)
 	result.reserver = reserver.NewReserver(scope, config, hostsService, taskService)
+	result.defragmenter = defragmenter.NewDefragmenter(scope, config, hostsService, taskService)
 	return result
 }
 
@@ -109,12 +112,14 @@ type engine struct {
 	strategy     plugins.Strategy
 	daemon       async.Daemon
 	reserver     reserver.Reserver
+	defragmenter defragmenter.Defragmenter
 	hostsService hosts.Service
 }
 
 func (e *engine) Start() {
 	e.daemon.Start()
 	e.reserver.Start()
+	e.defragmenter.Start()
 	e.metrics.Running.Update(1)
 }
 
@@ -147,6 +152,7 @@ func (e *engine) Run(ctx context.Context) error {
 func (e *engine) Stop() {
 	e.daemon.Stop()
 	e.reserver.Stop()
+	e.defragmenter.Stop()
 	e.metrics.Running.Update(0)
 }
 
@@ -254,6 +260,16 @@ func (e *engine) placeAssignmentGroup(
 	ctx context.Context,
 	filter *hostsvc.HostFilter,
 	assignments []*models.Assignment) []*models.Assignment {
+	// continue the traces of the tasks, so that acquiring the offers
+	// and setting the placements are part of them
+	span, ctx := tracing.StartSpanFromTraceIDs(
+		ctx,
+		"placement.place_assignment_group",
+		getTraceIDs(assignments),
+	)
+	defer span.Finish()
+	span.SetTag("task_count", len(assignments))
+
 	for len(assignments) > 0 {
 		log.WithFields(log.Fields{
 			"filter":          filter,
@@ -381,6 +397,7 @@ func (e *engine) returnStarvedAssignments(
 		a.Reason = reason
 	}
 	e.taskService.SetPlacements(ctx, nil, failedAssignments)
+	e.defragmenter.AddUnplacedTasks(getResmgrTasks(failedAssignments))
 }
 
 // filters the assignments into three groups
@@ -550,6 +567,7 @@ func (e *engine) cleanup(
 		e.createPlacement(assigned),
 		unassigned,
 	)
+	e.defragmenter.AddUnplacedTasks(getResmgrTasks(unassigned))
 
 	// Find the unused offers.
 	unusedOffers := e.findUnusedHosts(assigned, retryable, offers)
@@ -577,17 +595,34 @@ func getTasks(tasks []*models.Task) []*peloton.TaskID {
 	return taskIDs
 }
 
+func getResmgrTasks(assignments []*models.Assignment) []*resmgr.Task {
+	var tasks []*resmgr.Task
+	for _, assignment := range assignments {
+		tasks = append(tasks, assignment.GetTask().GetTask())
+	}
+	return tasks
+}
+
 func getPlacementTasks(tasks []*models.Task) []*resmgr.Placement_Task {
 	var placementTasks []*resmgr.Placement_Task
 	for _, task := range tasks {
 		placementTasks = append(placementTasks, &resmgr.Placement_Task{
 			PelotonTaskID: task.GetTask().GetId(),
 			MesosTaskID:   task.GetTask().GetTaskId(),
+			TraceID:       task.GetTask().GetTraceID(),
 		})
 	}
 	return placementTasks
 }
 
+func getTraceIDs(assignments []*models.Assignment) []string {
+	var traceIDs []string
+	for _, assignment := range assignments {
+		traceIDs = append(traceIDs, assignment.GetTask().GetTask().GetTraceID())
+	}
+	return traceIDs
+}
+
 type concurrencySafeAssignmentSlice struct {
 	sync.RWMutex
 	slice []*models.Assignment
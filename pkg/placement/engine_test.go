@@ -25,6 +25,7 @@ import (
 
 	"github.com/uber/peloton/pkg/common/async"
 	"github.com/uber/peloton/pkg/placement/config"
+	defragmenter_mock "github.com/uber/peloton/pkg/placement/defragmenter/mocks"
 	"github.com/uber/peloton/pkg/placement/models"
 	offers_mock "github.com/uber/peloton/pkg/placement/offers/mocks"
 	"github.com/uber/peloton/pkg/placement/plugins/batch"
@@ -354,6 +355,41 @@ func TestEnginePlaceNoHostsMakesTaskExceedDeadline(t *testing.T) {
 	engine.placeAssignmentGroup(context.Background(), filter, assignments)
 }
 
+// Test tasks which cannot get placed are handed to the defragmenter.
+func TestEnginePlaceNoHostsAddsUnplacedTasks(t *testing.T) {
+	ctrl, engine, mockOfferService, mockTaskService, _ := setupEngine(t)
+	defer ctrl.Finish()
+	mockDefragmenter := defragmenter_mock.NewMockDefragmenter(ctrl)
+	engine.defragmenter = mockDefragmenter
+	engine.config.MaxPlacementDuration = time.Millisecond
+	assignment := testutil.SetupAssignment(time.Now().Add(time.Millisecond), 1)
+	assignments := []*models.Assignment{assignment}
+
+	mockOfferService.EXPECT().
+		Acquire(
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+		).MinTimes(1).
+		Return(nil, _testReason)
+
+	mockTaskService.EXPECT().
+		SetPlacements(
+			gomock.Any(),
+			nil,
+			gomock.Any(),
+		).Times(1).
+		Return()
+
+	mockDefragmenter.EXPECT().
+		AddUnplacedTasks([]*resmgr.Task{assignment.GetTask().GetTask()}).
+		Times(1)
+
+	filter := &hostsvc.HostFilter{}
+	engine.placeAssignmentGroup(context.Background(), filter, assignments)
+}
+
 func TestEnginePlaceTaskExceedMaxRoundsAndGetsPlaced(t *testing.T) {
 	ctrl, engine, mockOfferService, mockTaskService, mockStrategy := setupEngine(t)
 	defer ctrl.Finish()
@@ -691,6 +727,7 @@ func TestEngineCreatePlacement(t *testing.T) {
 	host := testutil.SetupHostOffers()
 	assignment1 := testutil.SetupAssignment(deadline, 1)
 	assignment1.SetHost(host)
+	assignment1.GetTask().GetTask().TraceID = "uber-trace-id=1"
 	assignment2 := testutil.SetupAssignment(deadline, 1)
 	assignments := []*models.Assignment{
 		assignment1,
@@ -710,6 +747,7 @@ func TestEngineCreatePlacement(t *testing.T) {
 			{
 				PelotonTaskID: assignment1.GetTask().GetTask().GetId(),
 				MesosTaskID:   assignment1.GetTask().GetTask().GetTaskId(),
+				TraceID:       "uber-trace-id=1",
 			},
 		}, placements[0].GetTaskIDs())
 	assert.Equal(t, 3, len(placements[0].GetPorts()))
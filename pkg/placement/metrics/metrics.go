@@ -91,6 +91,27 @@ type Metrics struct {
 	// HostGetFail indicates the number of times the scheduler requested
 	// an Host and it failed
 	HostGetFail tally.Counter
+
+	// Defragmentation Metrics
+
+	// DefragUnplacedTasks is the number of tasks which could not be placed
+	// considered in a defragmentation round
+	DefragUnplacedTasks tally.Gauge
+
+	// DefragHostsFreed counts the number of hosts selected to be freed
+	// for tasks which could not be placed
+	DefragHostsFreed tally.Counter
+
+	// DefragTasksPreempted counts the number of running tasks which were
+	// preempted to free hosts
+	DefragTasksPreempted tally.Counter
+
+	// DefragPreemptFail counts the number of times the preemption of
+	// the selected running tasks failed
+	DefragPreemptFail tally.Counter
+
+	// DefragDuration is the timer for a defragmentation round
+	DefragDuration tally.Timer
 }
 
 // NewMetrics returns a new Metrics struct with all metrics initialized and
@@ -114,6 +135,9 @@ func NewMetrics(scope tally.Scope) *Metrics {
 	placementFailScope := placementScope.Tagged(map[string]string{"result": "fail"})
 	placementTimeScope := placementScope.Tagged(map[string]string{"type": "timer"})
 
+	defragScope := scope.SubScope("defrag")
+	defragFailScope := defragScope.Tagged(map[string]string{"result": "fail"})
+
 	return &Metrics{
 		Running:      scope.Gauge("running"),
 		OfferStarved: scope.Counter("offer_starved"),
@@ -138,5 +162,11 @@ func NewMetrics(scope tally.Scope) *Metrics {
 
 		HostGet:     HostSuccessScope.Counter("get"),
 		HostGetFail: HostFailScope.Counter("get"),
+
+		DefragUnplacedTasks:  defragScope.Gauge("unplaced_tasks"),
+		DefragHostsFreed:     defragScope.Counter("hosts_freed"),
+		DefragTasksPreempted: defragScope.Counter("tasks_preempted"),
+		DefragPreemptFail:    defragFailScope.Counter("preempt"),
+		DefragDuration:       defragScope.Timer("duration"),
 	}
 }
@@ -98,6 +98,11 @@ func (s *service) Acquire(
 	}
 
 	if len(hostOffers) == 0 {
+		// include which host filters rejected the hosts so that the
+		// reason explains why the task could not be placed
+		if len(filterResults) > 0 {
+			return offers, _noHostOffers + ": " + string(filterRes)
+		}
 		return offers, _noHostOffers
 	}
 
@@ -79,6 +79,21 @@ func TestOfferService_Dequeue(t *testing.T) {
 	hosts, reason = service.Acquire(ctx, true, resmgr.TaskType_UNKNOWN, filter)
 	assert.Equal(t, reason, _noHostOffers)
 
+	// Acquire Host Offers does not return any offer as all the hosts
+	// were filtered
+	mockHostManager.EXPECT().
+		AcquireHostOffers(
+			gomock.Any(),
+			&hostsvc.AcquireHostOffersRequest{Filter: filter}).
+		Return(&hostsvc.AcquireHostOffersResponse{
+			HostOffers: nil,
+			FilterResultCounts: map[string]uint32{
+				"MISMATCH_CONSTRAINTS": 3,
+			},
+		}, nil)
+	hosts, reason = service.Acquire(ctx, true, resmgr.TaskType_UNKNOWN, filter)
+	assert.Equal(t, reason, _noHostOffers+`: {"MISMATCH_CONSTRAINTS":3}`)
+
 	// Acquire Host Offers get tasks failure
 	filterResult := map[string]uint32{
 		"MISMATCH_CONSTRAINTS": 3,
@@ -115,6 +115,9 @@ func makeAffinityRequirements(constraint *task.Constraint) placement.Requirement
 			subRequirements = append(subRequirements, subRequirement)
 		}
 		return requirements.NewOrRequirement(subRequirements...)
+	case task.Constraint_NOT_CONSTRAINT:
+		return NewNotRequirement(
+			makeAffinityRequirements(constraint.GetNotConstraint().GetConstraint()))
 	default:
 		if constraint != nil {
 			log.WithField("type", constraint.GetType()).
@@ -21,6 +21,7 @@ import (
 	"github.com/uber/peloton/.gen/mesos/v1"
 	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
 
+	"github.com/uber/peloton/pkg/common/constraints"
 	common "github.com/uber/peloton/pkg/placement/plugins/mimir/common"
 	"github.com/uber/peloton/pkg/placement/plugins/mimir/lib/model/labels"
 	"github.com/uber/peloton/pkg/placement/plugins/mimir/lib/model/metrics"
@@ -96,5 +97,8 @@ func makeLabels(hostOffer *hostsvc.HostOffer) *labels.Bag {
 		result.Add(labels.NewLabel(names...))
 	}
 	result.Add(labels.NewLabel(common.HostNameLabel, hostOffer.GetHostname()))
+	// host constraints on the hostname, e.g. of daemon instances pinned to
+	// their host, use the same key as the host manager
+	result.Add(labels.NewLabel(constraints.HostNameKey, hostOffer.GetHostname()))
 	return result
 }
@@ -37,4 +37,6 @@ func TestGroupMapper_Convert(t *testing.T) {
 	assert.Equal(t, 1, group.Labels.Count(labels.NewLabel("attribute", "text")))
 	assert.Equal(t, 1, group.Labels.Count(labels.NewLabel("attribute", "1")))
 	assert.Equal(t, 1, group.Labels.Count(labels.NewLabel("attribute", "[31000-31009]")))
+	assert.Equal(t, 1, group.Labels.Count(labels.NewLabel(common.HostNameLabel, "hostname")))
+	assert.Equal(t, 1, group.Labels.Count(labels.NewLabel("hostname", "hostname")))
 }
@@ -0,0 +1,59 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0_mimir
+
+import (
+	"fmt"
+
+	"github.com/uber/peloton/pkg/placement/plugins/mimir/lib/model/placement"
+)
+
+// NotRequirement represents the negation of a sub affinity requirement,
+// which can be an and, or, label or relation requirement.
+type NotRequirement struct {
+	Requirement placement.Requirement
+}
+
+// NewNotRequirement creates a new not requirement.
+func NewNotRequirement(requirement placement.Requirement) *NotRequirement {
+	return &NotRequirement{
+		Requirement: requirement,
+	}
+}
+
+// Passed checks if the sub requirement is not fulfilled by the given group
+// within the scope groups.
+func (requirement *NotRequirement) Passed(group *placement.Group, scopeSet *placement.ScopeSet,
+	entity *placement.Entity, transcript *placement.Transcript) bool {
+	result := !requirement.Requirement.Passed(group, scopeSet, entity,
+		transcript.Subscript(requirement.Requirement))
+	if result {
+		transcript.IncPassed()
+	} else {
+		transcript.IncFailed()
+	}
+	return result
+}
+
+func (requirement *NotRequirement) String() string {
+	return fmt.Sprintf("the requirement; %v, should be false",
+		requirement.Requirement.String())
+}
+
+// Composite returns true as the requirement is composite and the name of
+// its composite nature.
+func (requirement *NotRequirement) Composite() (bool, string) {
+	return true, "not"
+}
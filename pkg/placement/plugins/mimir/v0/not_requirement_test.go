@@ -0,0 +1,78 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0_mimir_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/peloton/pkg/placement/plugins/mimir/lib/model/labels"
+	"github.com/uber/peloton/pkg/placement/plugins/mimir/lib/model/placement"
+	"github.com/uber/peloton/pkg/placement/plugins/mimir/lib/model/requirements"
+	"github.com/uber/peloton/pkg/placement/plugins/mimir/v0"
+)
+
+func setupNotRequirement() *v0_mimir.NotRequirement {
+	return v0_mimir.NewNotRequirement(
+		requirements.NewLabelRequirement(
+			nil,
+			labels.NewLabel("host", "host1"),
+			requirements.Equal,
+			1,
+		),
+	)
+}
+
+func setupGroup(hostname string) *placement.Group {
+	group := placement.NewGroup(hostname)
+	group.Labels.Add(labels.NewLabel("host", hostname))
+	return group
+}
+
+func TestNotRequirement_String_and_Composite(t *testing.T) {
+	requirement := setupNotRequirement()
+
+	assert.Equal(t, fmt.Sprintf("the requirement; %v, should be false",
+		requirement.Requirement.String()),
+		requirement.String())
+	composite, name := requirement.Composite()
+	assert.True(t, composite)
+	assert.Equal(t, "not", name)
+}
+
+func TestNotRequirement_Passed_negates_subrequirement(t *testing.T) {
+	scopeSet := placement.NewScopeSet(nil)
+	requirement := setupNotRequirement()
+
+	assert.False(t, requirement.Passed(setupGroup("host1"), scopeSet, nil, nil))
+	assert.True(t, requirement.Passed(setupGroup("host2"), scopeSet, nil, nil))
+}
+
+func TestNotRequirement_Passed_updates_transcript(t *testing.T) {
+	scopeSet := placement.NewScopeSet(nil)
+	requirement := setupNotRequirement()
+
+	transcript := placement.NewTranscript("transcript")
+	requirement.Passed(setupGroup("host1"), scopeSet, nil, transcript)
+	assert.Equal(t, 0, transcript.GroupsPassed)
+	assert.Equal(t, 1, transcript.GroupsFailed)
+	assert.Equal(t, 1, len(transcript.Subscripts))
+	for _, subscript := range transcript.Subscripts {
+		assert.Equal(t, 1, subscript.GroupsPassed)
+		assert.Equal(t, 0, subscript.GroupsFailed)
+	}
+}
@@ -419,6 +419,7 @@ func (r *reserver) processCompletedReservations(ctx context.Context) error {
 				{
 					PelotonTaskID: res.GetTask().GetId(),
 					MesosTaskID:   res.GetTask().GetTaskId(),
+					TraceID:       res.GetTask().GetTraceID(),
 				},
 			},
 			Type:        r.config.TaskType,
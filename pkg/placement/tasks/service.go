@@ -20,6 +20,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/private/resmgr"
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 	"github.com/uber/peloton/pkg/placement/config"
@@ -32,6 +33,7 @@ const (
 	_failedToEnqueueTasks  = "failed to enqueue tasks back to resource manager"
 	_failedToDequeueTasks  = "failed to dequeue tasks from resource manager"
 	_failedToSetPlacements = "failed to set placements"
+	_failedToPreemptTasks  = "failed to preempt tasks"
 )
 
 // Service will manage gangs/tasks and placements used by any placement strategy.
@@ -45,6 +47,13 @@ type Service interface {
 		successFullPlacements []*resmgr.Placement,
 		failedAssignments []*models.Assignment,
 	)
+
+	// Preempt asks the service to preempt the given running tasks.
+	Preempt(
+		ctx context.Context,
+		tasks []*resmgr.Task,
+		reason resmgr.PreemptionReason,
+	) error
 }
 
 // NewService will create a new task service.
@@ -218,6 +227,49 @@ func (s *service) SetPlacements(
 	s.metrics.SetPlacementSuccess.Inc(int64(len(placements)))
 }
 
+// Preempt asks the resource manager to preempt the given running tasks.
+// Tasks which the resource manager refuses to preempt, e.g. because they
+// are no longer running, are logged and otherwise ignored.
+func (s *service) Preempt(
+	ctx context.Context,
+	tasks []*resmgr.Task,
+	reason resmgr.PreemptionReason,
+) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ctx, cancelFunc := context.WithTimeout(ctx, _timeout)
+	defer cancelFunc()
+
+	taskIDs := make([]*peloton.TaskID, 0, len(tasks))
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.GetId())
+	}
+
+	request := &resmgrsvc.PreemptTasksRequest{
+		Tasks:  taskIDs,
+		Reason: reason,
+	}
+	response, err := s.resourceManager.PreemptTasks(ctx, request)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"preempt_tasks_request": request,
+		}).WithError(err).Error(_failedToPreemptTasks)
+		s.metrics.DefragPreemptFail.Inc(1)
+		return err
+	}
+
+	for _, respErr := range response.GetError() {
+		log.WithField("error", respErr.String()).
+			Info("task not preempted")
+	}
+
+	s.metrics.DefragTasksPreempted.Inc(
+		int64(len(tasks) - len(response.GetError())))
+	return nil
+}
+
 func (s *service) createTasks(gang *resmgrsvc.Gang, now time.Time) []*models.Task {
 	var tasks []*models.Task
 	resTasks := gang.GetTasks()
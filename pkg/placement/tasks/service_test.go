@@ -218,3 +218,48 @@ func TestTaskService_SetPlacements(t *testing.T) {
 	)
 	service.SetPlacements(ctx, placements, nil)
 }
+
+func TestTaskService_Preempt(t *testing.T) {
+	service, mockResourceManager, ctrl := setupService(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	tasks := []*resmgr.Task{
+		{Id: &peloton.TaskID{Value: "job-0"}},
+		{Id: &peloton.TaskID{Value: "job-1"}},
+	}
+	request := &resmgrsvc.PreemptTasksRequest{
+		Tasks: []*peloton.TaskID{
+			{Value: "job-0"},
+			{Value: "job-1"},
+		},
+		Reason: resmgr.PreemptionReason_PREEMPTION_REASON_DEFRAGMENTATION,
+	}
+
+	// No tasks to preempt
+	assert.NoError(t, service.Preempt(
+		ctx, nil, resmgr.PreemptionReason_PREEMPTION_REASON_DEFRAGMENTATION))
+
+	// Some tasks are refused by the resource manager
+	mockResourceManager.EXPECT().
+		PreemptTasks(gomock.Any(), request).
+		Return(&resmgrsvc.PreemptTasksResponse{
+			Error: []*resmgrsvc.PreemptTasksResponse_Error{
+				{
+					PreemptError: &resmgrsvc.PreemptTasksError{
+						Task:    &peloton.TaskID{Value: "job-1"},
+						Message: "Task is not running",
+					},
+				},
+			},
+		}, nil)
+	assert.NoError(t, service.Preempt(
+		ctx, tasks, resmgr.PreemptionReason_PREEMPTION_REASON_DEFRAGMENTATION))
+
+	// Resource manager request fails
+	mockResourceManager.EXPECT().
+		PreemptTasks(gomock.Any(), request).
+		Return(nil, errors.New("resource manager preempt tasks request failed"))
+	assert.Error(t, service.Preempt(
+		ctx, tasks, resmgr.PreemptionReason_PREEMPTION_REASON_DEFRAGMENTATION))
+}
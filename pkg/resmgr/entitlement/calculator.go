@@ -122,6 +122,13 @@ func (c *Calculator) Start() error {
 	return nil
 }
 
+// RunOnce calculates the entitlement of all the resource pools from
+// their current demand and allocation. It fails if a calculation is
+// already running.
+func (c *Calculator) RunOnce(ctx context.Context) error {
+	return c.calculateEntitlement(ctx)
+}
+
 // calculateEntitlement runs one entitlement calculation cycle.
 func (c *Calculator) calculateEntitlement(ctx context.Context) error {
 	log.Info("calculating entitlement")
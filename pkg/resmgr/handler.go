@@ -1095,6 +1095,114 @@ func (h *ServiceHandler) GetPreemptibleTasks(
 	}, nil
 }
 
+// PreemptTasks adds the running preemptible tasks in the request to the
+// preemption queue, from where they are picked up by the job manager to be
+// preempted.
+func (h *ServiceHandler) PreemptTasks(
+	ctx context.Context,
+	req *resmgrsvc.PreemptTasksRequest,
+) (*resmgrsvc.PreemptTasksResponse, error) {
+	h.metrics.APIPreemptTasks.Inc(1)
+
+	log.WithField("tasks", req.GetTasks()).
+		WithField("reason", req.GetReason().String()).
+		Info("tasks to be preempted")
+
+	var respErrors []*resmgrsvc.PreemptTasksResponse_Error
+	var tasksToPreempt []*rmtask.RMTask
+	for _, taskID := range req.GetTasks() {
+		rmTask := h.rmTracker.GetTask(taskID)
+		if rmTask == nil {
+			respErrors = append(respErrors,
+				&resmgrsvc.PreemptTasksResponse_Error{
+					NotFound: &resmgrsvc.TasksNotFound{
+						Message: "Task Not Found",
+						Task:    taskID,
+					},
+				})
+			continue
+		}
+
+		var reason string
+		if !rmTask.Task().GetPreemptible() {
+			reason = "Task is not preemptible"
+		} else if rmTask.GetCurrentState().State != t.TaskState_RUNNING {
+			reason = "Task is not running"
+		}
+		if reason != "" {
+			respErrors = append(respErrors,
+				&resmgrsvc.PreemptTasksResponse_Error{
+					PreemptError: &resmgrsvc.PreemptTasksError{
+						Message: reason,
+						Task:    taskID,
+					},
+				})
+			continue
+		}
+		tasksToPreempt = append(tasksToPreempt, rmTask)
+	}
+
+	if len(tasksToPreempt) > 0 {
+		if err := h.preemptionQueue.EnqueueTasks(
+			tasksToPreempt,
+			req.GetReason()); err != nil {
+			h.metrics.PreemptTasksFail.Inc(1)
+			return &resmgrsvc.PreemptTasksResponse{}, err
+		}
+	}
+
+	h.metrics.PreemptTasksSuccess.Inc(1)
+	return &resmgrsvc.PreemptTasksResponse{
+		Error: respErrors,
+	}, nil
+}
+
+// GetPendingReasons returns the reasons why the tasks in the request are
+// not running yet, using the last admission control rejection of the
+// resource pool and the last failed placement returned by the placement
+// engine.
+func (h *ServiceHandler) GetPendingReasons(
+	ctx context.Context,
+	req *resmgrsvc.GetPendingReasonsRequest,
+) (*resmgrsvc.GetPendingReasonsResponse, error) {
+	h.metrics.APIGetPendingReasons.Inc(1)
+
+	var respErrors []*resmgrsvc.GetPendingReasonsResponse_Error
+	var reasons []*resmgrsvc.PendingReason
+	for _, taskID := range req.GetTasks() {
+		rmTask := h.rmTracker.GetTask(taskID)
+		if rmTask == nil {
+			respErrors = append(respErrors,
+				&resmgrsvc.GetPendingReasonsResponse_Error{
+					NotFound: &resmgrsvc.TasksNotFound{
+						Message: "Task Not Found",
+						Task:    taskID,
+					},
+				})
+			continue
+		}
+
+		rmTaskState := rmTask.GetCurrentState()
+		reason := &resmgrsvc.PendingReason{
+			Task:            taskID,
+			State:           rmTaskState.State,
+			StateReason:     rmTaskState.Reason,
+			AdmissionReason: rmTask.Respool().GetAdmissionFailure(taskID),
+		}
+		if failure := rmTask.GetPlacementFailure(); !failure.Time.IsZero() {
+			reason.PlacementReason = failure.Reason
+			reason.LastPlacementFailureTime = failure.Time.Format(time.RFC3339)
+		}
+		reasons = append(reasons, reason)
+	}
+
+	h.metrics.GetPendingReasonsSuccess.Inc(1)
+	return &resmgrsvc.GetPendingReasonsResponse{
+		Error:   respErrors,
+		Reasons: reasons,
+	}, nil
+}
+
 // UpdateTasksState will be called to notify the resource manager about the tasks
 // which have been moved to cooresponding state , by that resource manager
 // can take appropriate actions for those tasks. As an example if the tasks been
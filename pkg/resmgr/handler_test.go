@@ -1350,6 +1350,88 @@ func (s *HandlerTestSuite) TestGetPreemptibleTasksError() {
 	s.handler.rmTracker = rm_task.GetTracker()
 }
 
+func (s *HandlerTestSuite) TestPreemptTasks() {
+	defer s.handler.rmTracker.Clear()
+
+	mockPreemptionQueue := mocks.NewMockQueue(s.ctrl)
+	s.handler.preemptionQueue = mockPreemptionQueue
+
+	resp, err := respool.NewRespool(
+		tally.NoopScope,
+		"respool-1",
+		nil,
+		&pb_respool.ResourcePoolConfig{
+			Policy: pb_respool.SchedulingPolicy_PriorityFIFO,
+		},
+		s.cfg,
+	)
+	s.NoError(err, "create resource pool should not fail")
+
+	running := []task.TaskState{
+		task.TaskState_PENDING,
+		task.TaskState_READY,
+		task.TaskState_PLACING,
+		task.TaskState_PLACED,
+		task.TaskState_LAUNCHING,
+		task.TaskState_RUNNING,
+	}
+	addTask := func(id string, preemptible bool, states []task.TaskState) *peloton.TaskID {
+		taskID := &peloton.TaskID{Value: id}
+		s.rmTaskTracker.AddTask(&resmgr.Task{
+			Id:          taskID,
+			Preemptible: preemptible,
+		}, nil, resp,
+			tasktestutil.CreateTaskConfig())
+		tasktestutil.ValidateStateTransitions(
+			s.handler.rmTracker.GetTask(taskID), states)
+		return taskID
+	}
+
+	preemptibleTask := addTask("job-preempt-1", true, running)
+	nonPreemptibleTask := addTask("job-preempt-2", false, running)
+	pendingTask := addTask("job-preempt-3", true, running[:1])
+	unknownTask := &peloton.TaskID{Value: "job-preempt-4"}
+
+	mockPreemptionQueue.EXPECT().
+		EnqueueTasks(gomock.Any(),
+			resmgr.PreemptionReason_PREEMPTION_REASON_DEFRAGMENTATION).
+		Do(func(tasks []*rm_task.RMTask, _ resmgr.PreemptionReason) {
+			s.Len(tasks, 1)
+			s.Equal(preemptibleTask.GetValue(), tasks[0].Task().GetId().GetValue())
+		}).
+		Return(nil)
+
+	res, err := s.handler.PreemptTasks(
+		context.Background(),
+		&resmgrsvc.PreemptTasksRequest{
+			Tasks: []*peloton.TaskID{
+				preemptibleTask,
+				nonPreemptibleTask,
+				pendingTask,
+				unknownTask,
+			},
+			Reason: resmgr.PreemptionReason_PREEMPTION_REASON_DEFRAGMENTATION,
+		})
+	s.NoError(err)
+	s.Len(res.GetError(), 3)
+	s.Equal(nonPreemptibleTask, res.GetError()[0].GetPreemptError().GetTask())
+	s.Equal(pendingTask, res.GetError()[1].GetPreemptError().GetTask())
+	s.Equal(unknownTask, res.GetError()[2].GetNotFound().GetTask())
+
+	// enqueue to the preemption queue fails
+	mockPreemptionQueue.EXPECT().
+		EnqueueTasks(gomock.Any(), gomock.Any()).
+		Return(errors.New("queue full"))
+
+	_, err = s.handler.PreemptTasks(
+		context.Background(),
+		&resmgrsvc.PreemptTasksRequest{
+			Tasks:  []*peloton.TaskID{preemptibleTask},
+			Reason: resmgr.PreemptionReason_PREEMPTION_REASON_DEFRAGMENTATION,
+		})
+	s.Error(err)
+}
+
 func (s *HandlerTestSuite) TestAddTaskError() {
 	tracker := task_mocks.NewMockTracker(s.ctrl)
 	s.handler.rmTracker = tracker
@@ -1877,6 +1959,65 @@ func (s *HandlerTestSuite) assertTasksAdmitted(gangs []*resmgrsvc.Gang) {
 	}
 }
 
+func (s *HandlerTestSuite) TestGetPendingReasons() {
+	defer s.handler.rmTracker.Clear()
+
+	resp, err := respool.NewRespool(
+		tally.NoopScope,
+		"respool-1",
+		nil,
+		&pb_respool.ResourcePoolConfig{
+			Policy: pb_respool.SchedulingPolicy_PriorityFIFO,
+		},
+		s.cfg,
+	)
+	s.NoError(err, "create resource pool should not fail")
+
+	taskID := &peloton.TaskID{Value: "job-pending-1"}
+	rmTask := &resmgr.Task{Id: taskID}
+	s.rmTaskTracker.AddTask(rmTask, nil, resp,
+		tasktestutil.CreateTaskConfig())
+	tasktestutil.ValidateStateTransitions(
+		s.handler.rmTracker.GetTask(taskID),
+		[]task.TaskState{
+			task.TaskState_PENDING,
+			task.TaskState_READY,
+			task.TaskState_PLACING,
+		})
+	unknownTask := &peloton.TaskID{Value: "job-pending-2"}
+
+	// placement engine fails to place the task
+	placementReason := "no hosts matched the constraint"
+	_, err = s.handler.SetPlacements(
+		s.context,
+		&resmgrsvc.SetPlacementsRequest{
+			FailedPlacements: []*resmgrsvc.SetPlacementsRequest_FailedPlacement{
+				{
+					Gang:   &resmgrsvc.Gang{Tasks: []*resmgr.Task{rmTask}},
+					Reason: placementReason,
+				},
+			},
+		})
+	s.NoError(err)
+
+	res, err := s.handler.GetPendingReasons(
+		s.context,
+		&resmgrsvc.GetPendingReasonsRequest{
+			Tasks: []*peloton.TaskID{taskID, unknownTask},
+		})
+	s.NoError(err)
+	s.Len(res.GetError(), 1)
+	s.Equal(unknownTask, res.GetError()[0].GetNotFound().GetTask())
+
+	s.Len(res.GetReasons(), 1)
+	reason := res.GetReasons()[0]
+	s.Equal(taskID, reason.GetTask())
+	s.Equal(task.TaskState_READY, reason.GetState())
+	s.Equal(placementReason, reason.GetPlacementReason())
+	s.NotEmpty(reason.GetLastPlacementFailureTime())
+	s.Empty(reason.GetAdmissionReason())
+}
+
 // asserts the failed placements call
 func (s *HandlerTestSuite) assertSetFailedPlacement(gangs []*resmgrsvc.Gang) {
 	var failedPlacements []*resmgrsvc.SetPlacementsRequest_FailedPlacement
@@ -0,0 +1,145 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+
+	rmtask "github.com/uber/peloton/pkg/resmgr/task"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// evictionBudget tracks how many instances of each job are unavailable,
+// so that the tasks of the DRAINING hosts are only evicted if the number
+// of unavailable instances of their jobs stays within the
+// maximumUnavailableInstances of the SLA of the jobs.
+// The instances of a job which are not RUNNING in jobmgr are unavailable,
+// including the instances which are not known to resmgr. The tasks being
+// evicted from DRAINING hosts may still be RUNNING in jobmgr, so the
+// instances which are not RUNNING in the task tracker are counted too.
+type evictionBudget struct {
+	ctx         context.Context
+	jobIndexOps ormobjects.JobIndexOps
+	rmTracker   rmtask.Tracker
+	// number of unavailable instances by job ID, computed on first use
+	unavailable map[string]uint32
+}
+
+// newEvictionBudget returns a new evictionBudget
+func newEvictionBudget(
+	ctx context.Context,
+	jobIndexOps ormobjects.JobIndexOps,
+	rmTracker rmtask.Tracker) *evictionBudget {
+	return &evictionBudget{
+		ctx:         ctx,
+		jobIndexOps: jobIndexOps,
+		rmTracker:   rmTracker,
+		unavailable: make(map[string]uint32),
+	}
+}
+
+// unavailableInstances returns the number of instances of the job which
+// are not RUNNING, which is the larger of the instances of the job not
+// RUNNING in jobmgr and of the tasks of the job not RUNNING in the
+// task tracker
+func (b *evictionBudget) unavailableInstances(jobID string) (uint32, error) {
+	if count, ok := b.unavailable[jobID]; ok {
+		return count, nil
+	}
+
+	summary, err := b.jobIndexOps.GetSummary(
+		b.ctx,
+		&peloton.JobID{Value: jobID},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint32
+	running := summary.GetRuntime().GetTaskStats()[task.TaskState_RUNNING.String()]
+	if summary.GetInstanceCount() > running {
+		count = summary.GetInstanceCount() - running
+	}
+
+	var evicting uint32
+	for state, tasks := range b.rmTracker.GetActiveTasks(jobID, "", nil) {
+		if state != task.TaskState_RUNNING.String() {
+			evicting += uint32(len(tasks))
+		}
+	}
+	if evicting > count {
+		count = evicting
+	}
+
+	b.unavailable[jobID] = count
+	return count, nil
+}
+
+// reserve returns the largest subset of the tasks which can be evicted
+// while keeping every job within its maximum number of unavailable
+// instances, and counts the RUNNING tasks of the subset as unavailable.
+// It also returns the reason why the other tasks cannot be evicted yet,
+// or an empty string if all the tasks can be evicted. The other tasks
+// are evicted in the next drain cycles, once the evicted instances are
+// available again.
+func (b *evictionBudget) reserve(
+	tasks []*rmtask.RMTask,
+) ([]*rmtask.RMTask, string) {
+	var evictable []*rmtask.RMTask
+	var reason string
+	for _, t := range tasks {
+		// the tasks which are not RUNNING are already unavailable, and
+		// the jobs without limit can always be evicted
+		maxUnavailable := t.Task().GetMaximumUnavailableInstances()
+		if maxUnavailable == 0 ||
+			t.GetCurrentState().State != task.TaskState_RUNNING {
+			evictable = append(evictable, t)
+			continue
+		}
+
+		jobID := t.Task().GetJobId().GetValue()
+		unavailable, err := b.unavailableInstances(jobID)
+		if err != nil {
+			log.WithField("job_id", jobID).
+				WithError(err).
+				Warn("Failed to get the unavailable instances of job")
+			if len(reason) == 0 {
+				reason = fmt.Sprintf(
+					"failed to get the unavailable instances of job %s",
+					jobID)
+			}
+			continue
+		}
+		if unavailable+1 > maxUnavailable {
+			if len(reason) == 0 {
+				reason = fmt.Sprintf(
+					"evicting more instances of job %s would exceed its "+
+						"maximum of %d unavailable instances, %d are unavailable",
+					jobID, maxUnavailable, unavailable)
+			}
+			continue
+		}
+
+		b.unavailable[jobID]++
+		evictable = append(evictable, t)
+	}
+	return evictable, reason
+}
@@ -16,6 +16,7 @@ package host
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
@@ -26,6 +27,7 @@ import (
 	"github.com/uber/peloton/pkg/common/stringset"
 	"github.com/uber/peloton/pkg/resmgr/preemption"
 	rmtask "github.com/uber/peloton/pkg/resmgr/task"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 
 	"github.com/hashicorp/go-multierror"
 	log "github.com/sirupsen/logrus"
@@ -45,12 +47,14 @@ const (
 type Drainer struct {
 	hostMgrClient   hostsvc.InternalHostServiceYARPCClient // Host Manager client
 	metrics         *Metrics
-	rmTracker       rmtask.Tracker      // Task Tracker
-	started         int32               // State of the host drainer
-	drainerPeriod   time.Duration       // Period to run host drainer
-	preemptionQueue preemption.Queue    // Preemption Queue
-	lifecycle       lifecycle.LifeCycle // Lifecycle manager
-	drainingHosts   stringset.StringSet // Set of hosts currently being drained
+	rmTracker       rmtask.Tracker         // Task Tracker
+	jobIndexOps     ormobjects.JobIndexOps // Job index of jobmgr
+	started         int32                  // State of the host drainer
+	drainerPeriod   time.Duration          // Period to run host drainer
+	preemptionQueue preemption.Queue       // Preemption Queue
+	lifecycle       lifecycle.LifeCycle    // Lifecycle manager
+	drainingHosts   stringset.StringSet    // Set of hosts currently being drained
+	blockedHosts    map[string]string      // Reasons of the blocked hosts last reported to Host Manager
 }
 
 // NewDrainer creates a new Drainer
@@ -59,12 +63,14 @@ func NewDrainer(
 	hostMgrClient hostsvc.InternalHostServiceYARPCClient,
 	drainerPeriod time.Duration,
 	rmTracker rmtask.Tracker,
+	jobIndexOps ormobjects.JobIndexOps,
 	preemptionQueue preemption.Queue) *Drainer {
 
 	return &Drainer{
 		hostMgrClient:   hostMgrClient,
 		metrics:         NewMetrics(parent.SubScope("drainer")),
 		rmTracker:       rmTracker,
+		jobIndexOps:     jobIndexOps,
 		preemptionQueue: preemptionQueue,
 		drainerPeriod:   drainerPeriod,
 		lifecycle:       lifecycle.NewLifeCycle(),
@@ -117,6 +123,7 @@ func (d *Drainer) Stop() error {
 	d.lifecycle.Wait()
 	// Clear the set
 	d.drainingHosts.Clear()
+	d.blockedHosts = nil
 	log.Info("Host Drainer Stopped")
 	return nil
 }
@@ -145,6 +152,13 @@ func (d *Drainer) performDrainCycle() error {
 	return d.drainHosts()
 }
 
+// drainHosts evicts the tasks of the DRAINING hosts and marks the hosts
+// without tasks as drained. The hosts are drained in batches: the tasks of
+// a host are only evicted if the number of unavailable instances of each
+// of their jobs stays within the maximumUnavailableInstances of the SLA of
+// the job. The hosts with tasks which cannot be evicted yet are reported
+// to Host Manager as blocked, and their remaining tasks are retried in the
+// next drain cycle.
 func (d *Drainer) drainHosts() error {
 	var errs error
 
@@ -152,10 +166,18 @@ func (d *Drainer) drainHosts() error {
 	log.WithField("hosts", drainingHosts).Info("Draining hosts")
 	// No-op if there are no hosts to drain
 	if len(drainingHosts) == 0 {
-		return nil
+		return d.reportBlockedHosts(nil)
 	}
+	// Evict the hosts in the same order in every cycle so that
+	// the budget of the jobs goes to the same hosts
+	sort.Strings(drainingHosts)
+
 	// Get all tasks on the DRAINING hosts
 	tasksByHost := d.rmTracker.TasksByHosts(drainingHosts, resmgr.TaskType_UNKNOWN)
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+	budget := newEvictionBudget(ctx, d.jobIndexOps, d.rmTracker)
+	blockedHosts := make(map[string]string)
 	var drainedHosts []string
 	for _, host := range drainingHosts {
 		if _, ok := tasksByHost[host]; !ok {
@@ -163,8 +185,20 @@ func (d *Drainer) drainHosts() error {
 			continue
 		}
 
+		tasks, reason := budget.reserve(tasksByHost[host])
+		if len(reason) != 0 {
+			log.WithField("host", host).
+				WithField("reason", reason).
+				WithField("evicted_tasks", len(tasks)).
+				Info("Draining host is blocked")
+			blockedHosts[host] = reason
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+
 		err := d.preemptionQueue.EnqueueTasks(
-			tasksByHost[host],
+			tasks,
 			resmgr.PreemptionReason_PREEMPTION_REASON_HOST_MAINTENANCE)
 		if err != nil {
 			log.WithField("host", host).
@@ -173,6 +207,13 @@ func (d *Drainer) drainHosts() error {
 			errs = multierror.Append(errs, err)
 		}
 	}
+
+	d.metrics.DrainBlockedHosts.Update(float64(len(blockedHosts)))
+	if err := d.reportBlockedHosts(blockedHosts); err != nil {
+		log.WithError(err).Error("Failed to report blocked hosts")
+		errs = multierror.Append(errs, err)
+	}
+
 	if len(drainedHosts) != 0 {
 		err := d.markHostsDrained(drainedHosts)
 		if err != nil {
@@ -184,6 +225,46 @@ func (d *Drainer) drainHosts() error {
 	return errs
 }
 
+// reportBlockedHosts sends the reasons why the blocked hosts are not
+// drained to Host Manager, if they changed since the last report
+func (d *Drainer) reportBlockedHosts(blockedHosts map[string]string) error {
+	if len(blockedHosts) == len(d.blockedHosts) {
+		changed := false
+		for host, reason := range blockedHosts {
+			if lastReason, ok := d.blockedHosts[host]; !ok || lastReason != reason {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return nil
+		}
+	}
+
+	var hosts []string
+	for host := range blockedHosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	request := &hostsvc.SetDrainBlockedHostsRequest{}
+	for _, host := range hosts {
+		request.BlockedHosts = append(request.BlockedHosts,
+			&hostsvc.DrainBlockedHost{
+				Hostname: host,
+				Reason:   blockedHosts[host],
+			})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+	if _, err := d.hostMgrClient.SetDrainBlockedHosts(ctx, request); err != nil {
+		return err
+	}
+	d.blockedHosts = blockedHosts
+	return nil
+}
+
 func (d *Drainer) markHostsDrained(hosts []string) error {
 	err := backoff.Retry(
 		func() error {
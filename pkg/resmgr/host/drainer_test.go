@@ -20,7 +20,9 @@ import (
 	"time"
 
 	"github.com/uber/peloton/.gen/mesos/v1"
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
 	host_mocks "github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc/mocks"
 	"github.com/uber/peloton/.gen/peloton/private/resmgr"
@@ -32,6 +34,7 @@ import (
 	"github.com/uber/peloton/pkg/common/stringset"
 	preemption_mocks "github.com/uber/peloton/pkg/resmgr/preemption/mocks"
 	rm_task "github.com/uber/peloton/pkg/resmgr/task"
+	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
 
 	"github.com/golang/mock/gomock"
 	"github.com/pborman/uuid"
@@ -51,6 +54,7 @@ type DrainerTestSuite struct {
 	drainer            Drainer
 	preemptor          *preemption_mocks.MockQueue
 	mockHostmgr        *host_mocks.MockInternalHostServiceYARPCClient
+	mockJobIndexOps    *objectmocks.MockJobIndexOps
 	eventStreamHandler *eventstream.Handler
 	hostnames          []string
 }
@@ -74,12 +78,15 @@ func (suite *DrainerTestSuite) SetupTest() {
 		tally.Scope(tally.NoopScope))
 
 	suite.preemptor = preemption_mocks.NewMockQueue(suite.mockCtrl)
+	suite.mockJobIndexOps = objectmocks.NewMockJobIndexOps(suite.mockCtrl)
 
 	suite.drainer = Drainer{
+		metrics:         NewMetrics(tally.NoopScope),
 		drainerPeriod:   drainerPeriod,
 		hostMgrClient:   suite.mockHostmgr,
 		preemptionQueue: suite.preemptor,
 		rmTracker:       suite.tracker,
+		jobIndexOps:     suite.mockJobIndexOps,
 		lifecycle:       lifecycle.NewLifeCycle(),
 		drainingHosts:   stringset.New(),
 	}
@@ -122,6 +129,7 @@ func (suite *DrainerTestSuite) TestDrainer_Init() {
 		suite.mockHostmgr,
 		drainerPeriod,
 		suite.tracker,
+		suite.mockJobIndexOps,
 		suite.preemptor)
 	suite.NotNil(r)
 }
@@ -225,3 +233,250 @@ func (suite *DrainerTestSuite) TestDrainCycle_MarkHostsDrained() {
 	err := suite.drainer.performDrainCycle()
 	suite.NoError(err)
 }
+
+// TestDrainCycle_SLA tests that the tasks of a DRAINING host are only
+// evicted if their jobs stay within their maximum unavailable instances
+func (suite *DrainerTestSuite) TestDrainCycle_SLA() {
+	suite.tracker.Clear()
+
+	// one instance of the job can be unavailable, and
+	// each instance is running on its own host
+	jobID := uuid.New()
+	hosts := []string{"hostname-a", "hostname-b", "hostname-c"}
+	for i, host := range hosts {
+		taskID := fmt.Sprintf("%s-%d", jobID, i)
+		t := &resmgr.Task{
+			Name:     taskID,
+			JobId:    &peloton.JobID{Value: jobID},
+			Id:       &peloton.TaskID{Value: taskID},
+			TaskId:   &mesos_v1.TaskID{Value: &[]string{fmt.Sprintf("%s-%d-1", jobID, i)}[0]},
+			Hostname: host,
+
+			MaximumUnavailableInstances: 1,
+		}
+		suite.addTaskToTracker(t)
+		suite.NoError(suite.tracker.GetTask(t.GetId()).
+			TransitTo(task.TaskState_RUNNING.String()))
+	}
+
+	// jobmgr has the three instances RUNNING
+	suite.mockJobIndexOps.EXPECT().
+		GetSummary(gomock.Any(), &peloton.JobID{Value: jobID}).
+		Return(&job.JobSummary{
+			InstanceCount: 3,
+			Runtime: &job.RuntimeInfo{
+				TaskStats: map[string]uint32{
+					task.TaskState_RUNNING.String(): 3,
+				},
+			},
+		}, nil).
+		Times(2)
+
+	drainingHosts := []string{"hostname-b", "hostname-a"}
+	suite.mockHostmgr.EXPECT().
+		GetDrainingHosts(gomock.Any(), gomock.Any()).
+		Return(&hostsvc.GetDrainingHostsResponse{
+			Hostnames: drainingHosts,
+		}, nil).
+		Times(2)
+
+	// only the task of the first host is evicted in each cycle
+	suite.preemptor.EXPECT().
+		EnqueueTasks(gomock.Any(), gomock.Any()).
+		Do(func(tasks []*rm_task.RMTask, _ resmgr.PreemptionReason) {
+			suite.Len(tasks, 1)
+			suite.Equal("hostname-a", tasks[0].Task().GetHostname())
+		}).
+		Return(nil).
+		Times(2)
+
+	// the blocked host is only reported when the reasons change
+	suite.mockHostmgr.EXPECT().
+		SetDrainBlockedHosts(gomock.Any(), gomock.Any()).
+		Do(func(_ interface{}, request *hostsvc.SetDrainBlockedHostsRequest) {
+			suite.Len(request.GetBlockedHosts(), 1)
+			suite.Equal("hostname-b", request.GetBlockedHosts()[0].GetHostname())
+			suite.Contains(request.GetBlockedHosts()[0].GetReason(), jobID)
+		}).
+		Return(&hostsvc.SetDrainBlockedHostsResponse{}, nil)
+
+	for i := 0; i < 2; i++ {
+		suite.NoError(suite.drainer.performDrainCycle())
+	}
+
+	// the blocked hosts are cleared once the hosts are drained
+	suite.mockHostmgr.EXPECT().
+		GetDrainingHosts(gomock.Any(), gomock.Any()).
+		Return(&hostsvc.GetDrainingHostsResponse{}, nil)
+	suite.mockHostmgr.EXPECT().
+		SetDrainBlockedHosts(
+			gomock.Any(),
+			&hostsvc.SetDrainBlockedHostsRequest{}).
+		Return(&hostsvc.SetDrainBlockedHostsResponse{}, nil)
+	suite.NoError(suite.drainer.performDrainCycle())
+}
+
+// TestDrainCycle_SLAPartialHost tests that a host running more instances
+// of a job than can be unavailable is drained in several cycles
+func (suite *DrainerTestSuite) TestDrainCycle_SLAPartialHost() {
+	suite.tracker.Clear()
+
+	// one instance of the job can be unavailable, and
+	// both instances are running on the same host
+	jobID := uuid.New()
+	for i := 0; i < 2; i++ {
+		taskID := fmt.Sprintf("%s-%d", jobID, i)
+		t := &resmgr.Task{
+			Name:     taskID,
+			JobId:    &peloton.JobID{Value: jobID},
+			Id:       &peloton.TaskID{Value: taskID},
+			TaskId:   &mesos_v1.TaskID{Value: &[]string{fmt.Sprintf("%s-%d-1", jobID, i)}[0]},
+			Hostname: hostname,
+
+			MaximumUnavailableInstances: 1,
+		}
+		suite.addTaskToTracker(t)
+		suite.NoError(suite.tracker.GetTask(t.GetId()).
+			TransitTo(task.TaskState_RUNNING.String()))
+	}
+
+	suite.mockJobIndexOps.EXPECT().
+		GetSummary(gomock.Any(), &peloton.JobID{Value: jobID}).
+		Return(&job.JobSummary{
+			InstanceCount: 2,
+			Runtime: &job.RuntimeInfo{
+				TaskStats: map[string]uint32{
+					task.TaskState_RUNNING.String(): 2,
+				},
+			},
+		}, nil)
+
+	suite.mockHostmgr.EXPECT().
+		GetDrainingHosts(gomock.Any(), gomock.Any()).
+		Return(&hostsvc.GetDrainingHostsResponse{
+			Hostnames: suite.hostnames,
+		}, nil)
+
+	// one of the instances is evicted, the other one
+	// is evicted once the first one is available again
+	suite.preemptor.EXPECT().
+		EnqueueTasks(gomock.Any(), gomock.Any()).
+		Do(func(tasks []*rm_task.RMTask, _ resmgr.PreemptionReason) {
+			suite.Len(tasks, 1)
+			suite.Equal(jobID, tasks[0].Task().GetJobId().GetValue())
+		}).
+		Return(nil)
+	suite.mockHostmgr.EXPECT().
+		SetDrainBlockedHosts(gomock.Any(), gomock.Any()).
+		Do(func(_ interface{}, request *hostsvc.SetDrainBlockedHostsRequest) {
+			suite.Len(request.GetBlockedHosts(), 1)
+			suite.Equal(hostname, request.GetBlockedHosts()[0].GetHostname())
+			suite.Contains(request.GetBlockedHosts()[0].GetReason(),
+				"1 are unavailable")
+		}).
+		Return(&hostsvc.SetDrainBlockedHostsResponse{}, nil)
+	suite.NoError(suite.drainer.performDrainCycle())
+}
+
+// TestDrainCycle_SLAUnknownInstances tests that the instances of a job
+// which are not RUNNING in jobmgr are unavailable even if they are not
+// in the task tracker
+func (suite *DrainerTestSuite) TestDrainCycle_SLAUnknownInstances() {
+	suite.tracker.Clear()
+
+	jobID := uuid.New()
+	taskID := fmt.Sprintf("%s-%d", jobID, 0)
+	t := &resmgr.Task{
+		Name:     taskID,
+		JobId:    &peloton.JobID{Value: jobID},
+		Id:       &peloton.TaskID{Value: taskID},
+		TaskId:   &mesos_v1.TaskID{Value: &[]string{fmt.Sprintf("%s-%d-1", jobID, 0)}[0]},
+		Hostname: hostname,
+
+		MaximumUnavailableInstances: 1,
+	}
+	suite.addTaskToTracker(t)
+	suite.NoError(suite.tracker.GetTask(t.GetId()).
+		TransitTo(task.TaskState_RUNNING.String()))
+
+	// the second instance of the job is KILLED in jobmgr, which
+	// is not in the task tracker
+	suite.mockJobIndexOps.EXPECT().
+		GetSummary(gomock.Any(), &peloton.JobID{Value: jobID}).
+		Return(&job.JobSummary{
+			InstanceCount: 2,
+			Runtime: &job.RuntimeInfo{
+				TaskStats: map[string]uint32{
+					task.TaskState_RUNNING.String(): 1,
+					task.TaskState_KILLED.String():  1,
+				},
+			},
+		}, nil)
+
+	suite.mockHostmgr.EXPECT().
+		GetDrainingHosts(gomock.Any(), gomock.Any()).
+		Return(&hostsvc.GetDrainingHostsResponse{
+			Hostnames: suite.hostnames,
+		}, nil)
+	suite.mockHostmgr.EXPECT().
+		SetDrainBlockedHosts(gomock.Any(), gomock.Any()).
+		Do(func(_ interface{}, request *hostsvc.SetDrainBlockedHostsRequest) {
+			suite.Len(request.GetBlockedHosts(), 1)
+			suite.Contains(request.GetBlockedHosts()[0].GetReason(),
+				"1 are unavailable")
+		}).
+		Return(&hostsvc.SetDrainBlockedHostsResponse{}, nil)
+	suite.NoError(suite.drainer.performDrainCycle())
+}
+
+// TestDrainCycle_SetDrainBlockedHostsError tests the failure to report
+// the blocked hosts to host manager
+func (suite *DrainerTestSuite) TestDrainCycle_SetDrainBlockedHostsError() {
+	suite.tracker.Clear()
+
+	jobID := uuid.New()
+	taskID := fmt.Sprintf("%s-%d", jobID, 0)
+	t := &resmgr.Task{
+		Name:     taskID,
+		JobId:    &peloton.JobID{Value: jobID},
+		Id:       &peloton.TaskID{Value: taskID},
+		TaskId:   &mesos_v1.TaskID{Value: &[]string{fmt.Sprintf("%s-%d-1", jobID, 0)}[0]},
+		Hostname: hostname,
+
+		MaximumUnavailableInstances: 1,
+	}
+	suite.addTaskToTracker(t)
+
+	// the job has an instance which is not running yet
+	taskID = fmt.Sprintf("%s-%d", jobID, 1)
+	suite.addTaskToTracker(&resmgr.Task{
+		Name:  taskID,
+		JobId: &peloton.JobID{Value: jobID},
+		Id:    &peloton.TaskID{Value: taskID},
+
+		MaximumUnavailableInstances: 1,
+	})
+	suite.NoError(suite.tracker.GetTask(t.GetId()).
+		TransitTo(task.TaskState_RUNNING.String()))
+	suite.mockJobIndexOps.EXPECT().
+		GetSummary(gomock.Any(), gomock.Any()).
+		Return(&job.JobSummary{
+			InstanceCount: 2,
+			Runtime: &job.RuntimeInfo{
+				TaskStats: map[string]uint32{
+					task.TaskState_RUNNING.String(): 1,
+				},
+			},
+		}, nil)
+
+	suite.mockHostmgr.EXPECT().
+		GetDrainingHosts(gomock.Any(), gomock.Any()).
+		Return(&hostsvc.GetDrainingHostsResponse{
+			Hostnames: suite.hostnames,
+		}, nil)
+	suite.mockHostmgr.EXPECT().
+		SetDrainBlockedHosts(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("fake SetDrainBlockedHosts error"))
+	suite.Error(suite.drainer.performDrainCycle())
+	suite.Nil(suite.drainer.blockedHosts)
+}
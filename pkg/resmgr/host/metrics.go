@@ -20,6 +20,10 @@ import "github.com/uber-go/tally"
 type Metrics struct {
 	HostDrainSuccess tally.Counter
 	HostDrainFail    tally.Counter
+
+	// Number of DRAINING hosts whose tasks cannot be evicted without
+	// violating the SLA of their jobs
+	DrainBlockedHosts tally.Gauge
 }
 
 // NewMetrics returns a new instance of host.Metrics.
@@ -29,5 +33,7 @@ func NewMetrics(scope tally.Scope) *Metrics {
 	return &Metrics{
 		HostDrainSuccess: hostSuccessScope.Counter("host_drain"),
 		HostDrainFail:    hostFailScope.Counter("host_drain"),
+
+		DrainBlockedHosts: scope.Gauge("drain_blocked_hosts"),
 	}
 }
@@ -30,6 +30,13 @@ type Metrics struct {
 	GetPreemptibleTasksSuccess tally.Counter
 	GetPreemptibleTasksTimeout tally.Counter
 
+	APIPreemptTasks     tally.Counter
+	PreemptTasksSuccess tally.Counter
+	PreemptTasksFail    tally.Counter
+
+	APIGetPendingReasons     tally.Counter
+	GetPendingReasonsSuccess tally.Counter
+
 	APISetPlacements    tally.Counter
 	SetPlacementSuccess tally.Counter
 	SetPlacementFail    tally.Counter
@@ -77,6 +84,13 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		GetPreemptibleTasksSuccess: successScope.Counter("get_preemptible_tasks"),
 		GetPreemptibleTasksTimeout: timeoutScope.Counter("get_preemptible_tasks"),
 
+		APIPreemptTasks:     apiScope.Counter("preempt_tasks"),
+		PreemptTasksSuccess: successScope.Counter("preempt_tasks"),
+		PreemptTasksFail:    failScope.Counter("preempt_tasks"),
+
+		APIGetPendingReasons:     apiScope.Counter("get_pending_reasons"),
+		GetPendingReasonsSuccess: successScope.Counter("get_pending_reasons"),
+
 		APISetPlacements:    apiScope.Counter("set_placements"),
 		SetPlacementSuccess: successScope.Counter("set_placements"),
 		SetPlacementFail:    failScope.Counter("set_placements"),
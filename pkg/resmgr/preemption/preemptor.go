@@ -204,6 +204,13 @@ func (p *Preemptor) EnqueueTasks(
 	return p.processTasks(tasks, reason)
 }
 
+// RunOnce updates how long each resource pool has been over allocated,
+// and enqueues the tasks to preempt from the pools which have been over
+// allocated for the sustained over allocation count of cycles.
+func (p *Preemptor) RunOnce() error {
+	return p.preemptOnce()
+}
+
 func (p *Preemptor) preemptOnce() error {
 	// collect resource allocation from all resource pools
 	p.updateResourcePoolsState()
@@ -0,0 +1,289 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/resmgr/scalar"
+)
+
+// drfResourceKinds are the resource kinds considered while calculating
+// the dominant share of a job.
+var drfResourceKinds = []string{
+	common.CPU,
+	common.MEMORY,
+	common.DISK,
+	common.GPU,
+}
+
+// drfJob holds the gangs and the resource usage of a single job in the
+// DRFQueue.
+type drfJob struct {
+	// the job id
+	id string
+	// the order in which the job became active in the queue, used to
+	// break ties between jobs with equal dominant share.
+	seq uint64
+	// gangs of the job ordered by priority and the order they came in
+	gangs *PriorityQueue
+	// resources of the gangs which have left the queue for this job
+	usage *scalar.Resources
+}
+
+// priority returns the priority of the head gang of the job.
+func (j *drfJob) priority() int {
+	return j.gangs.list.GetHighestLevel()
+}
+
+// DRFQueue is a queue which interleaves gangs across jobs using dominant
+// resource fairness. Gangs of the highest priority are always served first,
+// amongst the jobs having a gang of that priority the job with the lowest
+// dominant share of cpu, memory, disk and gpu is served next. The share of
+// a job is the resources of its gangs which left the queue, relative to the
+// resources of all the gangs which left the queue for the active jobs.
+// A job stops being accounted for once it has no more gangs in the queue.
+type DRFQueue struct {
+	sync.RWMutex
+
+	// max number of gangs in the queue, negative means no limit
+	limit int64
+	// map of job id to the job's gangs and usage
+	jobs map[string]*drfJob
+	// monotonically increasing counter to order the jobs
+	seq uint64
+}
+
+// NewDRFQueue initializes the dominant resource fairness queue and returns
+// the pointer
+func NewDRFQueue(limit int64) *DRFQueue {
+	return &DRFQueue{
+		limit: limit,
+		jobs:  make(map[string]*drfJob),
+	}
+}
+
+// Enqueue queues a gang (task list gang) into the queue of its job
+func (q *DRFQueue) Enqueue(gang *resmgrsvc.Gang) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if (gang == nil) || (len(gang.Tasks) == 0) {
+		return errors.New("enqueue of empty list")
+	}
+
+	if q.limit >= 0 && q.limit <= int64(q.size()) {
+		return fmt.Errorf("list size limit reached")
+	}
+
+	jobID := gangJobID(gang)
+	job, ok := q.jobs[jobID]
+	if !ok {
+		q.seq++
+		job = &drfJob{
+			id:    jobID,
+			seq:   q.seq,
+			gangs: NewPriorityQueue(math.MaxInt64),
+			usage: &scalar.Resources{},
+		}
+		q.jobs[jobID] = job
+	}
+	return job.gangs.Enqueue(gang)
+}
+
+// Dequeue dequeues the gang of the highest priority from the job with the
+// lowest dominant share
+func (q *DRFQueue) Dequeue() (*resmgrsvc.Gang, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	job := q.next(q.jobs, q.shares(q.jobs))
+	if job == nil {
+		return nil, ErrorQueueEmpty("dequeue failed, queue is empty")
+	}
+
+	gang, err := job.gangs.Dequeue()
+	if err != nil {
+		return nil, err
+	}
+	q.charge(job, gang)
+	return gang, nil
+}
+
+// Peek peeks the limit number of gangs in the order they would be dequeued.
+// It will return an `ErrorQueueEmpty` if there is no gangs in the queue
+func (q *DRFQueue) Peek(limit uint32) ([]*resmgrsvc.Gang, error) {
+	q.RLock()
+	defer q.RUnlock()
+
+	// simulate the dequeues on a copy of the jobs so that the queue
+	// itself is not modified
+	pending := make(map[string][]*resmgrsvc.Gang)
+	jobs := make(map[string]*drfJob)
+	for id, job := range q.jobs {
+		gangs, err := job.gangs.Peek(limit)
+		if err != nil {
+			if _, ok := err.(ErrorQueueEmpty); ok {
+				continue
+			}
+			return nil, fmt.Errorf("peek failed err: %s", err)
+		}
+		pending[id] = gangs
+		jobs[id] = &drfJob{
+			id:    id,
+			seq:   job.seq,
+			usage: job.usage.Clone(),
+		}
+	}
+
+	var items []*resmgrsvc.Gang
+	for uint32(len(items)) < limit && len(jobs) > 0 {
+		var selected *drfJob
+		var selectedPriority int
+		shares := q.shares(jobs)
+		for id, job := range jobs {
+			priority := int(pending[id][0].GetTasks()[0].GetPriority())
+			if selected == nil ||
+				priority > selectedPriority ||
+				(priority == selectedPriority &&
+					lessShare(job, selected, shares)) {
+				selected = job
+				selectedPriority = priority
+			}
+		}
+
+		gang := pending[selected.id][0]
+		items = append(items, gang)
+		selected.usage = selected.usage.Add(scalar.GetGangResources(gang))
+		pending[selected.id] = pending[selected.id][1:]
+		if len(pending[selected.id]) == 0 {
+			delete(jobs, selected.id)
+		}
+	}
+
+	if len(items) == 0 {
+		return items, ErrorQueueEmpty("peek failed, queue is empty")
+	}
+	return items, nil
+}
+
+// Remove removes the item from the queue and charges its resources to the
+// share of its job
+func (q *DRFQueue) Remove(gang *resmgrsvc.Gang) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if gang == nil || len(gang.Tasks) <= 0 {
+		return errors.New("removal of empty list")
+	}
+
+	job, ok := q.jobs[gangJobID(gang)]
+	if !ok {
+		return fmt.Errorf("job %s not found in queue", gangJobID(gang))
+	}
+
+	if err := job.gangs.Remove(gang); err != nil {
+		return err
+	}
+	q.charge(job, gang)
+	return nil
+}
+
+// Size returns the number of elements in the DRFQueue
+func (q *DRFQueue) Size() int {
+	q.RLock()
+	defer q.RUnlock()
+	return q.size()
+}
+
+func (q *DRFQueue) size() int {
+	size := 0
+	for _, job := range q.jobs {
+		size += job.gangs.Size()
+	}
+	return size
+}
+
+// charge adds the gang resources to the usage of the job and stops
+// accounting for the job if it has no gangs left.
+func (q *DRFQueue) charge(job *drfJob, gang *resmgrsvc.Gang) {
+	if job.gangs.Size() == 0 {
+		delete(q.jobs, job.id)
+		return
+	}
+	job.usage = job.usage.Add(scalar.GetGangResources(gang))
+}
+
+// next returns the job which should be served next, nil if there are no
+// gangs in the queue.
+func (q *DRFQueue) next(
+	jobs map[string]*drfJob,
+	shares map[string]float64) *drfJob {
+	var selected *drfJob
+	for _, job := range jobs {
+		if job.gangs.Size() == 0 {
+			continue
+		}
+		if selected == nil ||
+			job.priority() > selected.priority() ||
+			(job.priority() == selected.priority() &&
+				lessShare(job, selected, shares)) {
+			selected = job
+		}
+	}
+	return selected
+}
+
+// shares returns the dominant share of every job.
+func (q *DRFQueue) shares(jobs map[string]*drfJob) map[string]float64 {
+	total := &scalar.Resources{}
+	for _, job := range jobs {
+		total = total.Add(job.usage)
+	}
+
+	shares := make(map[string]float64, len(jobs))
+	for id, job := range jobs {
+		var dominant float64
+		for _, kind := range drfResourceKinds {
+			if total.Get(kind) <= 0 {
+				continue
+			}
+			if share := job.usage.Get(kind) / total.Get(kind); share > dominant {
+				dominant = share
+			}
+		}
+		shares[id] = dominant
+	}
+	return shares
+}
+
+// lessShare returns true if job j1 should be served before job j2.
+func lessShare(j1, j2 *drfJob, shares map[string]float64) bool {
+	if shares[j1.id] != shares[j2.id] {
+		return shares[j1.id] < shares[j2.id]
+	}
+	return j1.seq < j2.seq
+}
+
+// gangJobID returns the job id of the gang.
+func gangJobID(gang *resmgrsvc.Gang) string {
+	return gang.GetTasks()[0].GetJobId().GetValue()
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/private/resmgr"
+	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DRFQueueTestSuite struct {
+	suite.Suite
+	q *DRFQueue
+}
+
+func TestDRFQueue(t *testing.T) {
+	suite.Run(t, new(DRFQueueTestSuite))
+}
+
+func (suite *DRFQueueTestSuite) SetupTest() {
+	suite.q = NewDRFQueue(math.MaxInt64)
+}
+
+// createGang creates a gang with a single task of the job
+func createGang(
+	jobID string,
+	instance int,
+	priority uint32,
+	resource *task.ResourceConfig) *resmgrsvc.Gang {
+	rmTask := CreateResmgrTask(
+		&peloton.JobID{Value: jobID},
+		&peloton.TaskID{Value: fmt.Sprintf("%s-%d", jobID, instance)},
+		priority)
+	rmTask.Resource = resource
+	return &resmgrsvc.Gang{
+		Tasks: []*resmgr.Task{rmTask},
+	}
+}
+
+// taskNames returns the name of the first task of every gang
+func taskNames(gangs []*resmgrsvc.Gang) []string {
+	var names []string
+	for _, gang := range gangs {
+		names = append(names, gang.GetTasks()[0].GetName())
+	}
+	return names
+}
+
+func (suite *DRFQueueTestSuite) enqueueCPUGangs(jobID string, count int) {
+	for i := 0; i < count; i++ {
+		suite.NoError(suite.q.Enqueue(createGang(
+			jobID, i, 0, &task.ResourceConfig{CpuLimit: 1})))
+	}
+}
+
+// TestInterleaveJobs tests that gangs of jobs with equal resources are
+// interleaved
+func (suite *DRFQueueTestSuite) TestInterleaveJobs() {
+	suite.enqueueCPUGangs("job1", 3)
+	suite.enqueueCPUGangs("job2", 2)
+	suite.Equal(5, suite.q.Size())
+
+	var gangs []*resmgrsvc.Gang
+	for i := 0; i < 5; i++ {
+		gang, err := suite.q.Dequeue()
+		suite.NoError(err)
+		gangs = append(gangs, gang)
+	}
+	suite.Equal(
+		[]string{"job1-0", "job2-0", "job1-1", "job2-1", "job1-2"},
+		taskNames(gangs))
+	suite.Equal(0, suite.q.Size())
+
+	_, err := suite.q.Dequeue()
+	suite.Error(err)
+}
+
+// TestPeekMatchesDequeue tests that peek returns the gangs in the dequeue
+// order without removing them
+func (suite *DRFQueueTestSuite) TestPeekMatchesDequeue() {
+	suite.enqueueCPUGangs("job1", 3)
+	suite.enqueueCPUGangs("job2", 2)
+
+	gangs, err := suite.q.Peek(4)
+	suite.NoError(err)
+	suite.Equal(
+		[]string{"job1-0", "job2-0", "job1-1", "job2-1"},
+		taskNames(gangs))
+	suite.Equal(5, suite.q.Size())
+
+	// peeking again does not change the order
+	gangs, err = suite.q.Peek(1)
+	suite.NoError(err)
+	suite.Equal([]string{"job1-0"}, taskNames(gangs))
+}
+
+// TestPeekEmpty tests peek on an empty queue
+func (suite *DRFQueueTestSuite) TestPeekEmpty() {
+	gangs, err := suite.q.Peek(1)
+	suite.Empty(gangs)
+	suite.Error(err)
+	_, ok := err.(ErrorQueueEmpty)
+	suite.True(ok)
+}
+
+// TestPriorityBeforeShare tests that higher priority gangs are served
+// before the job with the lowest share
+func (suite *DRFQueueTestSuite) TestPriorityBeforeShare() {
+	suite.enqueueCPUGangs("job1", 2)
+	suite.NoError(suite.q.Enqueue(createGang(
+		"job2", 0, 5, &task.ResourceConfig{CpuLimit: 1})))
+	suite.NoError(suite.q.Enqueue(createGang(
+		"job2", 1, 5, &task.ResourceConfig{CpuLimit: 1})))
+
+	gangs, err := suite.q.Peek(4)
+	suite.NoError(err)
+	suite.Equal(
+		[]string{"job2-0", "job2-1", "job1-0", "job1-1"},
+		taskNames(gangs))
+}
+
+// TestDominantShare tests that the job share is the share of its dominant
+// resource
+func (suite *DRFQueueTestSuite) TestDominantShare() {
+	suite.NoError(suite.q.Enqueue(createGang(
+		"job1", 0, 0, &task.ResourceConfig{CpuLimit: 1, GpuLimit: 1})))
+	suite.NoError(suite.q.Enqueue(createGang(
+		"job1", 1, 0, &task.ResourceConfig{CpuLimit: 1, GpuLimit: 1})))
+	suite.NoError(suite.q.Enqueue(createGang(
+		"job2", 0, 0, &task.ResourceConfig{CpuLimit: 10})))
+	suite.NoError(suite.q.Enqueue(createGang(
+		"job2", 1, 0, &task.ResourceConfig{CpuLimit: 10})))
+
+	// job1 and job2 have got one gang each, job1 has all of the gpu share
+	// and job2 10/11 of the cpu share so job2 goes next.
+	gangs, err := suite.q.Peek(3)
+	suite.NoError(err)
+	suite.Equal(
+		[]string{"job1-0", "job2-0", "job2-1"},
+		taskNames(gangs))
+}
+
+// TestRemoveChargesJob tests that removing a gang charges its job
+func (suite *DRFQueueTestSuite) TestRemoveChargesJob() {
+	suite.enqueueCPUGangs("job1", 2)
+	suite.enqueueCPUGangs("job2", 2)
+
+	gangs, err := suite.q.Peek(1)
+	suite.NoError(err)
+	suite.Equal([]string{"job1-0"}, taskNames(gangs))
+	suite.NoError(suite.q.Remove(gangs[0]))
+
+	gang, err := suite.q.Dequeue()
+	suite.NoError(err)
+	suite.Equal("job2-0", gang.GetTasks()[0].GetName())
+	suite.Equal(2, suite.q.Size())
+}
+
+// TestRemoveErrors tests the errors on removing a gang
+func (suite *DRFQueueTestSuite) TestRemoveErrors() {
+	suite.Error(suite.q.Remove(nil))
+	suite.Error(suite.q.Remove(createGang(
+		"job1", 0, 0, &task.ResourceConfig{CpuLimit: 1})))
+}
+
+// TestJobAccountingReset tests that a job is not accounted for after all
+// its gangs left the queue
+func (suite *DRFQueueTestSuite) TestJobAccountingReset() {
+	suite.enqueueCPUGangs("job1", 1)
+	_, err := suite.q.Dequeue()
+	suite.NoError(err)
+	suite.Empty(suite.q.jobs)
+}
+
+// TestEnqueueErrors tests the errors on enqueuing a gang
+func (suite *DRFQueueTestSuite) TestEnqueueErrors() {
+	suite.Error(suite.q.Enqueue(nil))
+	suite.Error(suite.q.Enqueue(&resmgrsvc.Gang{}))
+
+	q := NewDRFQueue(1)
+	suite.NoError(q.Enqueue(createGang(
+		"job1", 0, 0, &task.ResourceConfig{CpuLimit: 1})))
+	suite.EqualError(q.Enqueue(createGang(
+		"job2", 0, 0, &task.ResourceConfig{CpuLimit: 1})),
+		"list size limit reached")
+}
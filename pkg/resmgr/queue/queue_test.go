@@ -38,9 +38,16 @@ func (suite *QueueTestSuite) TestCreateQueueSuccess() {
 	suite.NotNil(q)
 }
 
+// TestCreateDRFQueue tests the creation of the dominant resource fairness queue
+func (suite *QueueTestSuite) TestCreateDRFQueue() {
+	q, err := CreateQueue(respool.SchedulingPolicy_DominantResourceFairness, 100)
+	suite.NoError(err)
+	suite.IsType(&DRFQueue{}, q)
+}
+
 // TestCreateQueue tests the Create Queue
 func (suite *QueueTestSuite) TestCreateQueueError() {
-	q, err := CreateQueue(2, 100)
+	q, err := CreateQueue(100, 100)
 	suite.Nil(q)
 	suite.Error(err)
 	suite.EqualError(err, "invalid queue type")
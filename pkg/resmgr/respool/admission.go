@@ -15,6 +15,8 @@
 package respool
 
 import (
+	"fmt"
+
 	"github.com/uber/peloton/.gen/peloton/private/resmgr"
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 
@@ -70,11 +72,13 @@ func (qt QueueType) String() string {
 	return "undefined"
 }
 
-// returns true if the gang can be admitted to the pool
-type admitter func(gang *resmgrsvc.Gang, pool *resPool) bool
+// returns an empty string if the gang can be admitted to the pool, otherwise
+// the reason why the gang was rejected
+type admitter func(gang *resmgrsvc.Gang, pool *resPool) string
 
-// returns true iff there's enough resources in the pool to admit the gang
-func entitlementAdmitter(gang *resmgrsvc.Gang, pool *resPool) bool {
+// returns an empty string iff there's enough resources in the pool to admit
+// the gang
+func entitlementAdmitter(gang *resmgrsvc.Gang, pool *resPool) string {
 	var currentAllocation, currentEntitlement *scalar.Resources
 	if !isRevocable(gang) {
 		currentEntitlement = pool.nonSlackEntitlement
@@ -93,23 +97,29 @@ func entitlementAdmitter(gang *resmgrsvc.Gang, pool *resPool) bool {
 		"resources_required": neededResources,
 	}).Debug("checking entitlement")
 
-	return currentAllocation.
+	if currentAllocation.
 		Add(neededResources).
-		LessThanOrEqual(currentEntitlement)
+		LessThanOrEqual(currentEntitlement) {
+		return ""
+	}
+	return fmt.Sprintf(
+		"resource pool %s entitlement exceeded: "+
+			"allocation [%s] + required [%s] > entitlement [%s]",
+		pool.GetPath(), currentAllocation, neededResources, currentEntitlement)
 }
 
-// returns true if a controller gang can be admitted to the pool
-func controllerAdmitter(gang *resmgrsvc.Gang, pool *resPool) bool {
+// returns an empty string if a controller gang can be admitted to the pool
+func controllerAdmitter(gang *resmgrsvc.Gang, pool *resPool) string {
 	// ignore check on admission for non-controller tasks,
 	// and revocable tasks (can not be of controller type)
 	if !isController(gang) || isRevocable(gang) {
-		return true
+		return ""
 	}
 
 	if pool.controllerLimit == nil {
 		log.WithField("respool_id", pool.id).
 			Debug("resource pool doesn't have a controller limit")
-		return true
+		return ""
 	}
 
 	// check controller limit and allocation
@@ -124,9 +134,15 @@ func controllerAdmitter(gang *resmgrsvc.Gang, pool *resPool) bool {
 		"resources_required": neededResources,
 	}).Debug("checking controller limit")
 
-	return controllerAllocation.
+	if controllerAllocation.
 		Add(neededResources).
-		LessThanOrEqual(controllerLimit)
+		LessThanOrEqual(controllerLimit) {
+		return ""
+	}
+	return fmt.Sprintf(
+		"resource pool %s controller limit exceeded: "+
+			"allocation [%s] + required [%s] > limit [%s]",
+		pool.GetPath(), controllerAllocation, neededResources, controllerLimit)
 }
 
 // For admission of non preemptible gangs there are 2 approaches:
@@ -136,14 +152,14 @@ func controllerAdmitter(gang *resmgrsvc.Gang, pool *resPool) bool {
 //    (higher priority allocation) > reservation
 // Peloton takes approach 1 by checking the total allocation of all
 // non-preemptible gangs and the resource pool reservation.
-func reservationAdmitter(gang *resmgrsvc.Gang, pool *resPool) bool {
+func reservationAdmitter(gang *resmgrsvc.Gang, pool *resPool) string {
 	if !pool.isPreemptionEnabled() ||
 		isPreemptible(gang) ||
 		isRevocable(gang) {
 		// don't need to check reservation if
 		// 1. preemption is disabled or
 		// 2. its a preemptible job
-		return true
+		return ""
 	}
 
 	npAllocation := pool.allocation.GetByType(scalar.NonPreemptibleAllocation)
@@ -157,9 +173,15 @@ func reservationAdmitter(gang *resmgrsvc.Gang, pool *resPool) bool {
 		"resources_required":    neededResources,
 	}).Debug("checking reservation")
 
-	return npAllocation.
+	if npAllocation.
 		Add(neededResources).
-		LessThanOrEqual(reservation)
+		LessThanOrEqual(reservation) {
+		return ""
+	}
+	return fmt.Sprintf(
+		"resource pool %s reservation exceeded by non-preemptible tasks: "+
+			"allocation [%s] + required [%s] > reservation [%s]",
+		pool.GetPath(), npAllocation, neededResources, reservation)
 }
 
 type admissionController struct {
@@ -194,7 +216,11 @@ func (ac admissionController) TryAdmit(
 		return errGangInvalid
 	}
 
-	if admitted := ac.canAdmit(gang, pool); !admitted {
+	if reason := ac.canAdmit(gang, pool); reason != "" {
+		// remember why the gang was rejected so that it can be surfaced
+		// to the users of pending tasks
+		pool.setAdmissionFailures(gang, reason)
+
 		if qt == PendingQueue {
 			// If a gang can't be admitted from the pending queue to the resource
 			// pool, then if:
@@ -244,6 +270,7 @@ func (ac admissionController) TryAdmit(
 	}
 
 	pool.allocation = pool.allocation.Add(scalar.GetGangAllocation(gang))
+	pool.clearAdmissionFailures(gang)
 	return nil
 }
 
@@ -303,6 +330,7 @@ func (ac admissionController) validateGang(
 		} else {
 			// the task is invalid so we mark the gang as invalid
 			delete(pool.invalidTasks, task.Id.Value)
+			delete(pool.admissionFailures, task.Id.Value)
 			isGangValid = false
 		}
 	}
@@ -361,20 +389,21 @@ func (ac admissionController) validateGang(
 	return false, nil
 }
 
-// returns true if gang can be admitted to the pool
+// returns an empty string if gang can be admitted to the pool, otherwise
+// the reason of the first admitter which rejected the gang
 func (ac admissionController) canAdmit(
 	gang *resmgrsvc.Gang,
-	pool *resPool) bool {
+	pool *resPool) string {
 
 	// loop through the admitters
 	for _, admitter := range ac.admitters {
-		if !admitter(gang, pool) {
+		if reason := admitter(gang, pool); reason != "" {
 			// bail out fast
-			return false
+			return reason
 		}
 	}
 	// all admitters can admit
-	return true
+	return ""
 }
 
 // removeGangFromQueue removes a gang from a queue (pending/np/controller/revocable)
@@ -107,6 +107,29 @@ func (s *ResPoolSuite) TestBatchAdmissionController_TryAdmitValidationFail() {
 	s.Equal(float64(0), resPool.GetDemand().GPU)
 }
 
+func (s *ResPoolSuite) TestBatchAdmissionController_AdmissionFailureReason() {
+	pool := s.createTestResourcePool()
+	resPool, ok := pool.(*resPool)
+	s.True(ok)
+
+	task := s.getTasks()[0]
+	gang := makeTaskGang(task)
+
+	err := resPool.EnqueueGang(gang)
+	s.NoError(err)
+
+	// no entitlement so the gang is rejected with the entitlement reason
+	err = admission.TryAdmit(gang, resPool, PendingQueue)
+	s.Equal(errResourcePoolFull, err)
+	s.Contains(resPool.GetAdmissionFailure(task.Id), "entitlement exceeded")
+
+	// the reason is cleared once the gang is admitted
+	resPool.SetNonSlackEntitlement(s.getEntitlement())
+	err = admission.TryAdmit(gang, resPool, PendingQueue)
+	s.NoError(err)
+	s.Empty(resPool.GetAdmissionFailure(task.Id))
+}
+
 func (s *ResPoolSuite) TestBatchAdmissionController_TryAdmitSuccess() {
 	pool := s.createTestResourcePool()
 	resPool, ok := pool.(*resPool)
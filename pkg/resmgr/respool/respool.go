@@ -162,6 +162,10 @@ type ResPool interface {
 	// discarded asynchronously which scheduling.
 	AddInvalidTask(task *peloton.TaskID)
 
+	// GetAdmissionFailure returns the reason why the task was last rejected
+	// by admission control, or an empty string if it was not rejected.
+	GetAdmissionFailure(task *peloton.TaskID) string
+
 	// UpdateResourceMetrics updates metrics for this resource pool
 	// on each entitlement cycle calculation (15s)
 	UpdateResourceMetrics()
@@ -243,6 +247,9 @@ type resPool struct {
 	// set of invalid tasks which will be discarded during admission control.
 	invalidTasks map[string]bool
 
+	// reasons of the last admission rejection keyed by task id.
+	admissionFailures map[string]string
+
 	metrics *Metrics
 }
 
@@ -298,6 +305,7 @@ func NewRespool(
 		slackLimit:          &scalar.Resources{},
 		reservation:         &scalar.Resources{},
 		invalidTasks:        make(map[string]bool),
+		admissionFailures:   make(map[string]string),
 		preemptionCfg:       preemptionConfig,
 	}
 	pool.path = pool.calculatePath()
@@ -1131,6 +1139,33 @@ func (n *resPool) AddInvalidTask(task *peloton.TaskID) {
 	n.Lock()
 	defer n.Unlock()
 	n.invalidTasks[task.Value] = true
+	delete(n.admissionFailures, task.Value)
+}
+
+// GetAdmissionFailure returns the reason why the task was last rejected
+// by admission control, or an empty string if it was not rejected.
+func (n *resPool) GetAdmissionFailure(task *peloton.TaskID) string {
+	n.RLock()
+	defer n.RUnlock()
+	return n.admissionFailures[task.GetValue()]
+}
+
+// setAdmissionFailures records the admission rejection reason for all the
+// tasks of the gang.
+// NB: Acquire lock on the resource pool before calling
+func (n *resPool) setAdmissionFailures(gang *resmgrsvc.Gang, reason string) {
+	for _, task := range gang.GetTasks() {
+		n.admissionFailures[task.GetId().GetValue()] = reason
+	}
+}
+
+// clearAdmissionFailures removes the admission rejection reasons for all
+// the tasks of the gang.
+// NB: Acquire lock on the resource pool before calling
+func (n *resPool) clearAdmissionFailures(gang *resmgrsvc.Gang) {
+	for _, task := range gang.GetTasks() {
+		delete(n.admissionFailures, task.GetId().GetValue())
+	}
 }
 
 // PeekGangs returns a list of gangs from the queue based on the queue type.
@@ -58,6 +58,7 @@ func NewResourcePoolConfigValidator(rTree Tree) (Validator, error) {
 	return resourcePoolConfigValidator.Register(
 		[]ResourcePoolConfigValidatorFunc{
 			ValidateResourcePool,
+			ValidatePolicy,
 			ValidateCycle,
 			ValidateParent,
 			ValidateSiblings,
@@ -382,6 +383,36 @@ func ValidateResourcePoolPath(_ Tree,
 	return nil
 }
 
+// ValidatePolicy validates the scheduling policy is known and unchanged for an
+// existing resource pool, since the queues of a resource pool are created
+// with the policy when the resource pool is added.
+func ValidatePolicy(resTree Tree,
+	resourcePoolConfigData ResourcePoolConfigData) error {
+	policy := resourcePoolConfigData.ResourcePoolConfig.GetPolicy()
+	if _, ok := respool.SchedulingPolicy_name[int32(policy)]; !ok ||
+		policy == respool.SchedulingPolicy_UNKNOWN {
+		return errors.Errorf("invalid scheduling policy %v", policy)
+	}
+
+	existingResourcePool, err := resTree.Get(resourcePoolConfigData.ID)
+	if err != nil {
+		// new resource pool
+		return nil
+	}
+
+	existingPolicy := existingResourcePool.ResourcePoolConfig().GetPolicy()
+	if existingPolicy != respool.SchedulingPolicy_UNKNOWN &&
+		existingPolicy != policy {
+		return errors.Errorf(
+			"scheduling policy of resource pool %s cannot be changed "+
+				"from %v to %v",
+			resourcePoolConfigData.ID.GetValue(),
+			existingPolicy,
+			policy)
+	}
+	return nil
+}
+
 // ValidateControllerLimit validates the controller limit
 func ValidateControllerLimit(_ Tree,
 	resourcePoolConfigData ResourcePoolConfigData) error {
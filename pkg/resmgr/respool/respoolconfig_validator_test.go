@@ -196,7 +196,7 @@ func (s *resPoolConfigValidatorSuite) TestNewValidator() {
 
 	rcv, ok := v.(*resourcePoolConfigValidator)
 	s.True(ok)
-	s.Equal(6, len(rcv.resourcePoolConfigValidatorFuncs))
+	s.Equal(7, len(rcv.resourcePoolConfigValidatorFuncs))
 }
 
 func (s *resPoolConfigValidatorSuite) TestValidateOverrideRoot() {
@@ -595,6 +595,59 @@ func (s *resPoolConfigValidatorSuite) TestNoPolicy() {
 		pb_respool.SchedulingPolicy_PriorityFIFO)
 }
 
+func (s *resPoolConfigValidatorSuite) TestValidatePolicy() {
+	tt := []struct {
+		msg    string
+		id     string
+		policy pb_respool.SchedulingPolicy
+		err    string
+	}{
+		{
+			msg:    "new resource pool with DRF policy",
+			id:     "respool_new",
+			policy: pb_respool.SchedulingPolicy_DominantResourceFairness,
+		},
+		{
+			msg:    "existing resource pool with same policy",
+			id:     "respool11",
+			policy: pb_respool.SchedulingPolicy_PriorityFIFO,
+		},
+		{
+			msg:    "existing resource pool with changed policy",
+			id:     "respool11",
+			policy: pb_respool.SchedulingPolicy_DominantResourceFairness,
+			err: "scheduling policy of resource pool respool11 cannot be " +
+				"changed from PriorityFIFO to DominantResourceFairness",
+		},
+		{
+			msg:    "unknown policy",
+			id:     "respool_new",
+			policy: pb_respool.SchedulingPolicy(100),
+			err:    "invalid scheduling policy 100",
+		},
+	}
+
+	rv := &resourcePoolConfigValidator{resTree: s.resourceTree}
+	_, err := rv.Register(
+		[]ResourcePoolConfigValidatorFunc{ValidatePolicy})
+	s.NoError(err)
+
+	for _, t := range tt {
+		err := rv.Validate(ResourcePoolConfigData{
+			ID: &peloton.ResourcePoolID{Value: t.id},
+			ResourcePoolConfig: &pb_respool.ResourcePoolConfig{
+				Parent: &peloton.ResourcePoolID{Value: "respool1"},
+				Policy: t.policy,
+			},
+		})
+		if t.err != "" {
+			s.EqualError(err, t.err, t.msg)
+		} else {
+			s.NoError(err, t.msg)
+		}
+	}
+}
+
 func (s *resPoolConfigValidatorSuite) TestValidatePathError() {
 	rv := &resourcePoolConfigValidator{resTree: s.resourceTree}
 	_, err := rv.Register(
@@ -16,12 +16,15 @@ package respoolsvc
 
 import (
 	"context"
+	"path"
 	"sync"
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 
+	"github.com/uber/peloton/pkg/auth"
 	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/audit"
 	rc "github.com/uber/peloton/pkg/resmgr/common"
 	res "github.com/uber/peloton/pkg/resmgr/respool"
 	"github.com/uber/peloton/pkg/resmgr/scalar"
@@ -108,6 +111,7 @@ func (h *ServiceHandler) CreateResourcePool(
 	resPoolID := &peloton.ResourcePoolID{
 		Value: uuid.New(),
 	}
+	audit.AddRespoolID(ctx, resPoolID.GetValue())
 
 	resourcePoolConfigData := res.ResourcePoolConfigData{
 		ID:                 resPoolID,
@@ -135,6 +139,11 @@ func (h *ServiceHandler) CreateResourcePool(
 		}, nil
 	}
 
+	if err := h.authorizeNewResPool(ctx, resPoolConfig); err != nil {
+		h.metrics.CreateResourcePoolFail.Inc(1)
+		return nil, err
+	}
+
 	// TODO Handle parent of the new_resource_pool_config
 	// already has tasks added running, drain, distinguish?
 
@@ -277,6 +286,7 @@ func (h *ServiceHandler) DeleteResourcePool(
 	}
 
 	resPoolID := lookupRes.GetId()
+	audit.AddRespoolID(ctx, resPoolID.GetValue())
 
 	if resPoolID == nil {
 		h.metrics.DeleteResourcePoolFail.Inc(1)
@@ -293,6 +303,15 @@ func (h *ServiceHandler) DeleteResourcePool(
 		return resp, nil
 	}
 
+	if err := authorizeResPool(
+		ctx,
+		resPool.GetPath(),
+		resPool.ResourcePoolConfig(),
+	); err != nil {
+		h.metrics.DeleteResourcePoolFail.Inc(1)
+		return nil, err
+	}
+
 	// As if the resource pool is not leaf, Delete method should
 	// not let this operation occur. As delete is only supported for
 	// leaf resource pools
@@ -347,6 +366,50 @@ func (h *ServiceHandler) DeleteResourcePool(
 	}, nil
 }
 
+// authorizeNewResPool checks whether the caller is permitted to call the
+// procedure of the request on the resource pool with the config, the
+// path of the resource pool is derived from its parent. The owners of
+// the parent are used rather than the owners in the config, which are
+// chosen by the caller.
+// The config is expected to be validated.
+func (h *ServiceHandler) authorizeNewResPool(
+	ctx context.Context,
+	config *respool.ResourcePoolConfig,
+) error {
+	if _, ok := auth.UserFromContext(ctx); !ok {
+		return nil
+	}
+
+	parentID := config.GetParent()
+	if parentID == nil {
+		parentID = &peloton.ResourcePoolID{Value: common.RootResPoolID}
+	}
+	parent, err := h.resPoolTree.Get(parentID)
+	if err != nil {
+		return err
+	}
+
+	return authorizeResPool(
+		ctx,
+		path.Join(parent.GetPath(), config.GetName()),
+		parent.ResourcePoolConfig(),
+	)
+}
+
+// authorizeResPool checks whether the caller is permitted to call the
+// procedure of the request on the resource pool
+func authorizeResPool(
+	ctx context.Context,
+	respoolPath string,
+	config *respool.ResourcePoolConfig,
+) error {
+	return auth.AuthorizeResource(ctx, &auth.Resource{
+		RespoolPath: respoolPath,
+		OwningTeam:  config.GetOwningTeam(),
+		LdapGroups:  config.GetLdapGroups(),
+	})
+}
+
 // getDeleteResponse returns the empty respool DeleteResponse
 func (h *ServiceHandler) getDeleteResponse() *respool.DeleteResponse {
 	return &respool.DeleteResponse{
@@ -410,6 +473,7 @@ func (h *ServiceHandler) UpdateResourcePool(
 
 	resPoolID := req.GetId()
 	resPoolConfig := req.GetConfig()
+	audit.AddRespoolID(ctx, resPoolID.GetValue())
 
 	resourcePoolConfigData := res.ResourcePoolConfigData{
 		ID:                 resPoolID,
@@ -456,6 +520,21 @@ func (h *ServiceHandler) UpdateResourcePool(
 		}, nil
 	}
 
+	// the caller must be permitted on the resource pool
+	// before and after the update
+	if err := authorizeResPool(
+		ctx,
+		existingResPool.GetPath(),
+		existingResPool.ResourcePoolConfig(),
+	); err != nil {
+		h.metrics.UpdateResourcePoolFail.Inc(1)
+		return nil, err
+	}
+	if err := h.authorizeNewResPool(ctx, resPoolConfig); err != nil {
+		h.metrics.UpdateResourcePoolFail.Inc(1)
+		return nil, err
+	}
+
 	// update persistent store.
 	if err := h.store.UpdateResourcePool(ctx, resPoolID, resPoolConfig); err != nil {
 		h.metrics.UpdateResourcePoolFail.Inc(1)
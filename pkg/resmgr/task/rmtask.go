@@ -60,6 +60,14 @@ type RMTaskState struct {
 	LastUpdateTime time.Time
 }
 
+// PlacementFailure represents the last failed placement of the rm task
+type PlacementFailure struct {
+	// The reason returned by the placement engine
+	Reason string
+	// Time when the failed placement was returned
+	Time time.Time
+}
+
 // RMTask is the wrapper around resmgr.task for state machine
 type RMTask struct {
 	mu sync.Mutex // Mutex for synchronization
@@ -75,6 +83,9 @@ type RMTask struct {
 
 	runTimeStats *RunTimeStats // run time stats for resmgr task
 
+	// last failed placement of the task, kept to explain pending tasks
+	placementFailure PlacementFailure
+
 	// observes the state transitions of the rm task
 	transitionObserver TransitionObserver
 }
@@ -385,6 +396,13 @@ func (rmTask *RMTask) Respool() respool.ResPool {
 	return rmTask.respool
 }
 
+// GetPlacementFailure returns the last failed placement of the RMTask
+func (rmTask *RMTask) GetPlacementFailure() PlacementFailure {
+	rmTask.mu.Lock()
+	defer rmTask.mu.Unlock()
+	return rmTask.placementFailure
+}
+
 // RunTimeStats returns the runtime stats of the RMTask
 func (rmTask *RMTask) RunTimeStats() *RunTimeStats {
 	return rmTask.runTimeStats
@@ -452,6 +470,11 @@ func (rmTask *RMTask) RequeueUnPlaced(reason string) error {
 		return errUnplacedTaskInWrongState
 	}
 
+	rmTask.placementFailure = PlacementFailure{
+		Reason: reason,
+		Time:   time.Now(),
+	}
+
 	// If task is in PLACING state we need to determine which STATE it will
 	// transition to based on retry attempts
 
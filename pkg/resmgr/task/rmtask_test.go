@@ -910,7 +910,12 @@ func (s *RMTaskTestSuite) TestRMTaskRequeueUnPlacedTaskInPlacingToReady() {
 		rmTask.stateMachine = sm
 		s.NoError(err)
 
-		return rmTask.RequeueUnPlaced("")
+		err = rmTask.RequeueUnPlaced("no hosts matched constraint")
+		s.Equal(
+			"no hosts matched constraint",
+			rmTask.GetPlacementFailure().Reason)
+		s.False(rmTask.GetPlacementFailure().Time.IsZero())
+		return err
 	}
 
 	mockNode := mocks.NewMockResPool(s.ctrl)
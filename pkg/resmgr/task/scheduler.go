@@ -51,6 +51,8 @@ type Scheduler interface {
 	Start() error
 	// Stop stops the task scheduler goroutines
 	Stop() error
+	// RunOnce runs a single scheduling cycle without the goroutines
+	RunOnce()
 	// Enqueues gang (task list) into resource pool ready queue
 	EnqueueGang(gang *resmgrsvc.Gang) error
 	// Dequeues gang (task list) from the resource pool ready queue
@@ -157,6 +159,12 @@ func (s *scheduler) Start() error {
 	return nil
 }
 
+// RunOnce moves the gangs admitted by the entitlement of each leaf
+// resource pool from PENDING to READY.
+func (s *scheduler) RunOnce() {
+	s.scheduleTasks()
+}
+
 // scheduleTasks moves gang tasks to ready queue in every scheduling cycle
 func (s *scheduler) scheduleTasks() {
 	// We need to iterate for all the leaf nodes in the list
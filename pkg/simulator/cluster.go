@@ -0,0 +1,208 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/hostmgr/offer/offerpool"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
+
+	"go.uber.org/yarpc"
+)
+
+// minOfferedCPU is the least amount of cpu for which a host sends an offer.
+const minOfferedCPU = 0.001
+
+// host is a synthetic host of the simulated cluster.
+type host struct {
+	hostname string
+	agentID  string
+	// total resources of the host
+	capacity scalar.Resources
+	// resources used by the tasks running on the host
+	used scalar.Resources
+	// resources of the host in the offer pool
+	offered scalar.Resources
+}
+
+// cluster is the set of synthetic hosts of the simulation. Like the Mesos
+// master it offers the resources of the hosts which are neither used nor
+// already offered to the offer pool of the host manager.
+type cluster struct {
+	hosts     map[string]*host
+	hostnames []string
+	pool      offerpool.Pool
+	offerSeq  int
+}
+
+func newCluster(specs []*HostSpec, pool offerpool.Pool) *cluster {
+	c := &cluster{
+		hosts: make(map[string]*host),
+		pool:  pool,
+	}
+	for i, spec := range specs {
+		prefix := spec.Prefix
+		if prefix == "" {
+			prefix = fmt.Sprintf("host%d", i)
+		}
+		for j := 0; j < spec.Count; j++ {
+			hostname := fmt.Sprintf("%s-%d", prefix, j)
+			c.hosts[hostname] = &host{
+				hostname: hostname,
+				agentID:  hostname + "-agent",
+				capacity: scalar.Resources{
+					CPU:  spec.CPU,
+					Mem:  spec.MemMb,
+					Disk: spec.DiskMb,
+					GPU:  spec.GPU,
+				},
+			}
+			c.hostnames = append(c.hostnames, hostname)
+		}
+	}
+	sort.Strings(c.hostnames)
+	return c
+}
+
+// capacity returns the total resources of the hosts.
+func (c *cluster) capacity() scalar.Resources {
+	var total scalar.Resources
+	for _, h := range c.hosts {
+		total = total.Add(h.capacity)
+	}
+	return total
+}
+
+// used returns the resources used by the running tasks.
+func (c *cluster) used() scalar.Resources {
+	var total scalar.Resources
+	for _, h := range c.hosts {
+		total = total.Add(h.used)
+	}
+	return total
+}
+
+// sendOffers adds the offers of the hosts with unused resources to the
+// offer pool and refreshes the ranking of the hosts.
+func (c *cluster) sendOffers(ctx context.Context) {
+	var offers []*mesos.Offer
+	for _, hostname := range c.hostnames {
+		h := c.hosts[hostname]
+		free := h.capacity.Subtract(h.used).Subtract(h.offered)
+		if free.CPU < minOfferedCPU || free.Mem <= 0 {
+			continue
+		}
+		offers = append(offers, c.newOffer(h, free))
+		h.offered = h.offered.Add(free)
+	}
+	if len(offers) == 0 {
+		return
+	}
+	c.pool.AddOffers(ctx, offers)
+	c.pool.GetBinPackingRanker().RefreshRanking(c.pool.GetHostOfferIndex())
+}
+
+// newOffer creates an offer of resources of a host.
+func (c *cluster) newOffer(h *host, res scalar.Resources) *mesos.Offer {
+	c.offerSeq++
+	offerID := fmt.Sprintf("offer-%d", c.offerSeq)
+
+	var resources []*mesos.Resource
+	for _, r := range []struct {
+		name  string
+		value float64
+	}{
+		{common.MesosCPU, res.CPU},
+		{common.MesosMem, res.Mem},
+		{common.MesosDisk, res.Disk},
+		{common.MesosGPU, res.GPU},
+	} {
+		if r.value <= 0 {
+			continue
+		}
+		resources = append(resources, util.NewMesosResourceBuilder().
+			WithName(r.name).
+			WithValue(r.value).
+			Build())
+	}
+
+	return &mesos.Offer{
+		Id:          &mesos.OfferID{Value: &offerID},
+		FrameworkId: &mesos.FrameworkID{Value: &simulatorName},
+		AgentId:     &mesos.AgentID{Value: &h.agentID},
+		Hostname:    &h.hostname,
+		Resources:   resources,
+	}
+}
+
+// launch claims the offers of a host for the tasks of a placement which
+// use the given resources. The unused part of the offers is offered again.
+func (c *cluster) launch(
+	hostname string,
+	hostOfferID string,
+	taskIDs []*peloton.TaskID,
+	res scalar.Resources) error {
+	h, ok := c.hosts[hostname]
+	if !ok {
+		return fmt.Errorf("unknown host %s", hostname)
+	}
+	offers, err := c.pool.ClaimForLaunch(hostname, false, hostOfferID, taskIDs...)
+	if err != nil {
+		return err
+	}
+	h.offered = h.offered.Subtract(scalar.FromOfferMap(offers))
+	h.used = h.used.Add(res)
+	return nil
+}
+
+// release releases the resources of a task which stopped running on a host.
+func (c *cluster) release(hostname string, res scalar.Resources) {
+	if h, ok := c.hosts[hostname]; ok {
+		h.used = h.used.Subtract(res)
+	}
+}
+
+// capacityClient is the host manager client of the entitlement calculator,
+// it returns the capacity of the simulated cluster.
+type capacityClient struct {
+	hostsvc.InternalHostServiceYARPCClient
+
+	cluster *cluster
+}
+
+// ClusterCapacity returns the total resources of the simulated hosts.
+func (c *capacityClient) ClusterCapacity(
+	ctx context.Context,
+	req *hostsvc.ClusterCapacityRequest,
+	opts ...yarpc.CallOption) (*hostsvc.ClusterCapacityResponse, error) {
+	total := c.cluster.capacity()
+	return &hostsvc.ClusterCapacityResponse{
+		PhysicalResources: []*hostsvc.Resource{
+			{Kind: common.CPU, Capacity: total.CPU},
+			{Kind: common.MEMORY, Capacity: total.Mem},
+			{Kind: common.DISK, Capacity: total.Disk},
+			{Kind: common.GPU, Capacity: total.GPU},
+		},
+	}, nil
+}
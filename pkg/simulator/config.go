@@ -0,0 +1,117 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"time"
+
+	"github.com/uber/peloton/pkg/hostmgr/binpacking"
+	placement_config "github.com/uber/peloton/pkg/placement/config"
+	"github.com/uber/peloton/pkg/resmgr"
+	"github.com/uber/peloton/pkg/resmgr/common"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	_defaultStep           = time.Second
+	_defaultReportInterval = time.Minute
+	_defaultMaxDuration    = 24 * time.Hour
+	_defaultDequeueLimit   = 100
+)
+
+// Config holds the configs of the components run by the simulator.
+type Config struct {
+	// Config of the resource manager, the same section as in the
+	// resource manager config.
+	ResManager resmgr.Config `yaml:"resmgr"`
+
+	// Config of the placement engine, the same section as in the
+	// placement engine config.
+	Placement placement_config.PlacementConfig `yaml:"placement"`
+
+	// Config of the simulation.
+	Simulator SimulatorConfig `yaml:"simulator"`
+}
+
+// SimulatorConfig is the config of the simulation.
+type SimulatorConfig struct {
+	// Step is the amount of virtual time between two scheduling
+	// cycles of the simulation.
+	Step time.Duration `yaml:"step"`
+
+	// ReportInterval is the amount of virtual time between two samples
+	// of the state of the resource pools in the report.
+	ReportInterval time.Duration `yaml:"report_interval"`
+
+	// MaxDuration is the amount of virtual time after which the
+	// simulation is stopped, even if some tasks have not finished.
+	MaxDuration time.Duration `yaml:"max_duration"`
+
+	// BinPacking is the name of the bin-packing ranker which ranks the
+	// hosts of the offer pool, same as bin_packing of the host manager.
+	BinPacking string `yaml:"bin_packing"`
+
+	// BinPackingWeights are the weights of the weighted bin-packing
+	// rankers, same as bin_packing_weights of the host manager.
+	BinPackingWeights binpacking.WeightedConfig `yaml:"bin_packing_weights"`
+}
+
+// validate returns an error if the resource manager config cannot be
+// simulated.
+func (c *Config) validate() error {
+	if c.ResManager.RmTaskConfig == nil {
+		return errors.New("resmgr.task config is required")
+	}
+	if c.ResManager.EntitlementCaculationPeriod <= 0 {
+		return errors.New("resmgr.entitlement_calculation_period must be positive")
+	}
+	preemptionCfg := c.ResManager.PreemptionConfig
+	if preemptionCfg != nil && preemptionCfg.Enabled &&
+		preemptionCfg.TaskPreemptionPeriod <= 0 {
+		return errors.New("resmgr.preemption.task_preemption_period must be positive")
+	}
+	return nil
+}
+
+// normalize fills in the defaults of the unset values.
+func (c *Config) normalize() {
+	if c.Simulator.Step <= 0 {
+		c.Simulator.Step = _defaultStep
+	}
+	if c.Simulator.ReportInterval <= 0 {
+		c.Simulator.ReportInterval = _defaultReportInterval
+	}
+	if c.Simulator.MaxDuration <= 0 {
+		c.Simulator.MaxDuration = _defaultMaxDuration
+	}
+	if c.Placement.TaskDequeueLimit <= 0 {
+		c.Placement.TaskDequeueLimit = _defaultDequeueLimit
+	}
+	if c.Simulator.BinPacking == "" {
+		c.Simulator.BinPacking = binpacking.FirstFit
+	}
+	if c.ResManager.PreemptionConfig == nil {
+		c.ResManager.PreemptionConfig = &common.PreemptionConfig{}
+	}
+	// host reservation is done by the reserver of the placement engine,
+	// which is not simulated
+	taskConfig := *c.ResManager.RmTaskConfig
+	taskConfig.EnableHostReservation = false
+	c.ResManager.RmTaskConfig = &taskConfig
+	if c.Placement.Strategy == "" {
+		c.Placement.Strategy = placement_config.Batch
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/uber/peloton/pkg/resmgr/scalar"
+)
+
+// Report is the result of a simulation.
+type Report struct {
+	// Duration is the virtual time simulated.
+	Duration time.Duration `json:"duration"`
+
+	// Finished is true if all the tasks of the trace finished within the
+	// maximal duration of the simulation.
+	Finished bool `json:"finished"`
+
+	// Pools are the reports of the leaf resource pools, by path.
+	Pools []*PoolReport `json:"pools"`
+
+	// Cluster are the samples of the state of the cluster.
+	Cluster []*ClusterSample `json:"cluster"`
+}
+
+// PoolReport is the report of a leaf resource pool.
+type PoolReport struct {
+	Path string `json:"path"`
+
+	// Wait is the time the tasks of the resource pool waited to be
+	// running after being enqueued, a preempted task waits again.
+	Wait WaitStats `json:"wait"`
+
+	// Completed is the number of tasks which finished.
+	Completed int `json:"completed"`
+
+	// Preemptions is the number of tasks which were preempted.
+	Preemptions int `json:"preemptions"`
+
+	// Samples of the state of the resource pool over time.
+	Samples []*PoolSample `json:"samples"`
+}
+
+// WaitStats are statistics of wait times.
+type WaitStats struct {
+	Count int           `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	Max   time.Duration `json:"max"`
+}
+
+// PoolSample is the state of a leaf resource pool at a point in time.
+type PoolSample struct {
+	// Time is the virtual time since the start of the simulation.
+	Time time.Duration `json:"time"`
+
+	// Pending is the number of tasks waiting to be running.
+	Pending int `json:"pending"`
+
+	// Running is the number of running tasks.
+	Running int `json:"running"`
+
+	// Allocation is the allocation of the resource pool.
+	Allocation Resources `json:"allocation"`
+
+	// Entitlement is the entitlement of the resource pool.
+	Entitlement Resources `json:"entitlement"`
+
+	// Utilization is the fraction of the cpu of the cluster allocated
+	// to the resource pool.
+	Utilization float64 `json:"utilization"`
+
+	// Preemptions is the number of tasks preempted so far.
+	Preemptions int `json:"preemptions"`
+}
+
+// ClusterSample is the state of the cluster at a point in time.
+type ClusterSample struct {
+	// Time is the virtual time since the start of the simulation.
+	Time time.Duration `json:"time"`
+
+	// Pending is the number of tasks waiting to be running.
+	Pending int `json:"pending"`
+
+	// Running is the number of running tasks.
+	Running int `json:"running"`
+
+	// Utilization is the fraction of the cpu of the cluster used by the
+	// running tasks.
+	Utilization float64 `json:"utilization"`
+
+	// Fairness is the Jain's index of the cpu allocations of the leaf
+	// resource pools with tasks, weighted by their cpu shares. It is 1
+	// when the allocations are proportional to the shares and tends to
+	// 1/n when a single resource pool gets all the allocation.
+	Fairness float64 `json:"fairness"`
+}
+
+// Resources are the amounts of resources of a resource pool.
+type Resources struct {
+	CPU    float64 `json:"cpu"`
+	MemMb  float64 `json:"mem_mb"`
+	DiskMb float64 `json:"disk_mb"`
+	GPU    float64 `json:"gpu"`
+}
+
+func newResources(r *scalar.Resources) Resources {
+	return Resources{
+		CPU:    r.GetCPU(),
+		MemMb:  r.GetMem(),
+		DiskMb: r.GetDisk(),
+		GPU:    r.GetGPU(),
+	}
+}
+
+// sample records the state of the resource pools and of the cluster.
+func (s *Simulator) sample() {
+	elapsed := s.elapsed()
+	capacity := s.cluster.capacity()
+
+	var allocations []float64
+	for _, path := range s.poolPaths {
+		p := s.pools[path]
+		allocation := p.respool.GetTotalAllocatedResources()
+		sample := &PoolSample{
+			Time:        elapsed,
+			Pending:     p.waiting,
+			Running:     p.running,
+			Allocation:  newResources(allocation),
+			Entitlement: newResources(p.respool.GetEntitlement()),
+			Preemptions: p.preemptions,
+		}
+		if capacity.CPU > 0 {
+			sample.Utilization = allocation.GetCPU() / capacity.CPU
+		}
+		p.samples = append(p.samples, sample)
+
+		if p.waiting+p.running > 0 {
+			allocations = append(allocations, allocation.GetCPU()/p.share)
+		}
+	}
+
+	sample := &ClusterSample{
+		Time:     elapsed,
+		Pending:  s.waiting,
+		Running:  s.running,
+		Fairness: jainIndex(allocations),
+	}
+	if capacity.CPU > 0 {
+		sample.Utilization = s.cluster.used().CPU / capacity.CPU
+	}
+	s.samples = append(s.samples, sample)
+}
+
+// report returns the report of the simulation.
+func (s *Simulator) report() *Report {
+	r := &Report{
+		Duration: s.elapsed(),
+		Finished: s.finished(),
+		Cluster:  s.samples,
+	}
+	for _, path := range s.poolPaths {
+		p := s.pools[path]
+		r.Pools = append(r.Pools, &PoolReport{
+			Path:        p.path,
+			Wait:        newWaitStats(p.waitTimes),
+			Completed:   p.completed,
+			Preemptions: p.preemptions,
+			Samples:     p.samples,
+		})
+	}
+	return r
+}
+
+// newWaitStats returns the statistics of the given wait times.
+func newWaitStats(waits []time.Duration) WaitStats {
+	if len(waits) == 0 {
+		return WaitStats{}
+	}
+	sorted := make([]time.Duration, len(waits))
+	copy(sorted, waits)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, w := range sorted {
+		total += w
+	}
+	return WaitStats{
+		Count: len(sorted),
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(sorted, 50),
+		P95:   percentile(sorted, 95),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// jainIndex returns the Jain's fairness index of the given values, it is
+// 1 when there are no values or all of them are zero.
+func jainIndex(values []float64) float64 {
+	var sum, squares float64
+	for _, v := range values {
+		sum += v
+		squares += v * v
+	}
+	if squares == 0 {
+		return 1
+	}
+	return sum * sum / (float64(len(values)) * squares)
+}
+
+// WriteJSON writes the report in JSON, the durations are in nanoseconds.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteTable writes the report as tables: a summary of the resource
+// pools, the samples of the resource pools and the samples of the cluster.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Simulated %v, finished: %v\n\n",
+		r.Duration, r.Finished)
+
+	fmt.Fprintln(tw, "POOL\tCOMPLETED\tPREEMPTED\tWAIT MEAN\tWAIT P50\t"+
+		"WAIT P95\tWAIT MAX\tMEAN UTILIZATION\t")
+	for _, p := range r.Pools {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%v\t%v\t%.3f\t\n",
+			p.Path,
+			p.Completed,
+			p.Preemptions,
+			roundDuration(p.Wait.Mean),
+			roundDuration(p.Wait.P50),
+			roundDuration(p.Wait.P95),
+			roundDuration(p.Wait.Max),
+			meanUtilization(p.Samples))
+	}
+
+	fmt.Fprintln(tw, "\nTIME\tPOOL\tPENDING\tRUNNING\tALLOCATED CPU\t"+
+		"ENTITLED CPU\tUTILIZATION\tPREEMPTED\t")
+	for i := range r.Cluster {
+		for _, p := range r.Pools {
+			if i >= len(p.Samples) {
+				continue
+			}
+			sample := p.Samples[i]
+			fmt.Fprintf(tw, "%v\t%s\t%d\t%d\t%.2f\t%.2f\t%.3f\t%d\t\n",
+				sample.Time,
+				p.Path,
+				sample.Pending,
+				sample.Running,
+				sample.Allocation.CPU,
+				sample.Entitlement.CPU,
+				sample.Utilization,
+				sample.Preemptions)
+		}
+	}
+
+	fmt.Fprintln(tw, "\nTIME\tPENDING\tRUNNING\tUTILIZATION\tFAIRNESS\t")
+	for _, sample := range r.Cluster {
+		fmt.Fprintf(tw, "%v\t%d\t%d\t%.3f\t%.3f\t\n",
+			sample.Time,
+			sample.Pending,
+			sample.Running,
+			sample.Utilization,
+			sample.Fairness)
+	}
+	return tw.Flush()
+}
+
+// meanUtilization returns the mean utilization of the samples.
+func meanUtilization(samples []*PoolSample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var total float64
+	for _, s := range samples {
+		total += s.Utilization
+	}
+	return total / float64(len(samples))
+}
+
+// roundDuration rounds a wait time to the second for display.
+func roundDuration(d time.Duration) time.Duration {
+	return d.Round(time.Second)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ReportTestSuite struct {
+	suite.Suite
+}
+
+func TestReport(t *testing.T) {
+	suite.Run(t, new(ReportTestSuite))
+}
+
+func (s *ReportTestSuite) TestJainIndex() {
+	s.Equal(1.0, jainIndex(nil))
+	s.Equal(1.0, jainIndex([]float64{0, 0}))
+	s.Equal(1.0, jainIndex([]float64{3, 3, 3}))
+	s.InDelta(0.5, jainIndex([]float64{4, 0}), 1e-9)
+	s.InDelta(0.9, jainIndex([]float64{1, 2}), 1e-9)
+}
+
+func (s *ReportTestSuite) TestNewWaitStats() {
+	s.Equal(WaitStats{}, newWaitStats(nil))
+
+	var waits []time.Duration
+	for i := 20; i > 0; i-- {
+		waits = append(waits, time.Duration(i)*time.Second)
+	}
+	stats := newWaitStats(waits)
+	s.Equal(20, stats.Count)
+	s.Equal(10500*time.Millisecond, stats.Mean)
+	s.Equal(10*time.Second, stats.P50)
+	s.Equal(19*time.Second, stats.P95)
+	s.Equal(20*time.Second, stats.Max)
+	// the wait times are not sorted in place
+	s.Equal(20*time.Second, waits[0])
+}
+
+func (s *ReportTestSuite) TestPercentile() {
+	sorted := []time.Duration{time.Second}
+	s.Equal(time.Second, percentile(sorted, 0))
+	s.Equal(time.Second, percentile(sorted, 95))
+
+	sorted = []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	s.Equal(2*time.Second, percentile(sorted, 50))
+	s.Equal(3*time.Second, percentile(sorted, 95))
+}
+
+func (s *ReportTestSuite) newReport() *Report {
+	return &Report{
+		Duration: 2 * time.Minute,
+		Finished: true,
+		Pools: []*PoolReport{
+			{
+				Path:        "/batch",
+				Completed:   3,
+				Preemptions: 1,
+				Wait:        newWaitStats([]time.Duration{time.Second}),
+				Samples: []*PoolSample{
+					{
+						Time:        0,
+						Pending:     2,
+						Running:     1,
+						Allocation:  Resources{CPU: 1},
+						Entitlement: Resources{CPU: 4},
+						Utilization: 0.25,
+					},
+					{
+						Time:        time.Minute,
+						Running:     2,
+						Allocation:  Resources{CPU: 2},
+						Entitlement: Resources{CPU: 4},
+						Utilization: 0.5,
+						Preemptions: 1,
+					},
+				},
+			},
+		},
+		Cluster: []*ClusterSample{
+			{Time: 0, Pending: 2, Running: 1, Utilization: 0.25, Fairness: 1},
+			{Time: time.Minute, Running: 2, Utilization: 0.5, Fairness: 1},
+		},
+	}
+}
+
+func (s *ReportTestSuite) TestWriteTable() {
+	var buf bytes.Buffer
+	s.NoError(s.newReport().WriteTable(&buf))
+
+	out := buf.String()
+	s.Contains(out, "Simulated 2m0s, finished: true")
+	s.Contains(out, "MEAN UTILIZATION")
+	s.Contains(out, "0.375")
+	s.Contains(out, "FAIRNESS")
+	s.Contains(out, "1m0s")
+}
+
+func (s *ReportTestSuite) TestWriteJSON() {
+	var buf bytes.Buffer
+	report := s.newReport()
+	s.NoError(report.WriteJSON(&buf))
+
+	var decoded Report
+	s.NoError(json.Unmarshal(buf.Bytes(), &decoded))
+	s.Equal(report, &decoded)
+}
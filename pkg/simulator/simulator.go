@@ -0,0 +1,909 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	pb_respool "github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	pbtask "github.com/uber/peloton/.gen/peloton/api/v0/task"
+	pb_eventstream "github.com/uber/peloton/.gen/peloton/private/eventstream"
+	"github.com/uber/peloton/.gen/peloton/private/hostmgr/hostsvc"
+	"github.com/uber/peloton/.gen/peloton/private/resmgr"
+	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/hostmgr/binpacking"
+	"github.com/uber/peloton/pkg/hostmgr/offer/offerpool"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
+	placement_config "github.com/uber/peloton/pkg/placement/config"
+	"github.com/uber/peloton/pkg/placement/models"
+	"github.com/uber/peloton/pkg/placement/plugins"
+	"github.com/uber/peloton/pkg/placement/plugins/batch"
+	mimir_strategy "github.com/uber/peloton/pkg/placement/plugins/mimir"
+	"github.com/uber/peloton/pkg/placement/plugins/mimir/lib/algorithms"
+	rm "github.com/uber/peloton/pkg/resmgr"
+	"github.com/uber/peloton/pkg/resmgr/entitlement"
+	"github.com/uber/peloton/pkg/resmgr/preemption"
+	"github.com/uber/peloton/pkg/resmgr/respool"
+	rmtask "github.com/uber/peloton/pkg/resmgr/task"
+	"github.com/uber/peloton/pkg/storage/connectors/memory"
+	memory_store "github.com/uber/peloton/pkg/storage/memory"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc"
+)
+
+const (
+	// timeout of the dequeue calls to the resource manager in ms, the
+	// simulator only dequeues what it knows to be queued
+	_dequeueTimeoutMs = 1
+
+	// maximal number of tasks of a GetPreemptibleTasks call
+	_preemptionDequeueLimit = 1000
+
+	// offers are never expired by the offer pool, they are held until
+	// they are used or the host changes
+	_offerHoldTime = 24 * time.Hour
+
+	// timeout of the placing status of the hosts in the offer pool, the
+	// hosts are placed and launched within a single step
+	_hostPlacingOfferStatusTimeout = 5 * time.Minute
+
+	_reasonNoHost = "no host with enough resources"
+)
+
+var (
+	// scarce resource types of the hosts, the only type supported by
+	// the offer pool
+	scarceResourceTypes = []string{"GPU"}
+
+	// simulatorName is the name of the simulator as a framework and as
+	// the owner of the resource pools.
+	simulatorName = "peloton-sim"
+
+	// created is set once a simulator is created, see New.
+	created int32
+
+	errAlreadyCreated = errors.New(
+		"a simulator was already created in this process")
+)
+
+// Simulator replays a workload trace against the resource manager, the
+// placement strategy and the offer pool of the host manager, which run
+// on a virtual clock against a cluster of synthetic hosts.
+type Simulator struct {
+	cfg   Config
+	scope tally.Scope
+
+	// virtual time of the start of the simulation and of the current step
+	start time.Time
+	now   time.Time
+
+	tree       respool.Tree
+	tracker    rmtask.Tracker
+	scheduler  rmtask.Scheduler
+	calculator *entitlement.Calculator
+	preemptor  *preemption.Preemptor
+	handler    *rm.ServiceHandler
+	offerPool  offerpool.Pool
+	strategy   plugins.Strategy
+	cluster    *cluster
+
+	// jobs of the trace which are not submitted yet, by submit time
+	jobs []*JobSpec
+	// tasks of the submitted jobs by peloton task id
+	tasks map[string]*simTask
+	// end times of the running tasks
+	completions completionHeap
+	// leaf resource pools by path
+	pools     map[string]*poolState
+	poolPaths []string
+
+	waiting     int
+	running     int
+	eventOffset uint64
+	samples     []*ClusterSample
+
+	nextEntitlement time.Time
+	nextPreemption  time.Time
+	nextSample      time.Time
+}
+
+// simJob is a job of the trace submitted to the resource manager.
+type simJob struct {
+	spec *JobSpec
+	id   *peloton.JobID
+	pool *poolState
+}
+
+type simTaskState int
+
+const (
+	simTaskWaiting simTaskState = iota
+	simTaskRunning
+	simTaskDone
+)
+
+// simTask is a task of a submitted job.
+type simTask struct {
+	job      *simJob
+	id       *peloton.TaskID
+	instance uint32
+	runID    uint64
+	mesosID  *mesos.TaskID
+	state    simTaskState
+	hostname string
+	// time the current run was enqueued
+	queuedAt time.Time
+}
+
+// resources returns the resources used by the task.
+func (t *simTask) resources() scalar.Resources {
+	return scalar.Resources{
+		CPU:  t.job.spec.CPU,
+		Mem:  t.job.spec.MemMb,
+		Disk: t.job.spec.DiskMb,
+		GPU:  t.job.spec.GPU,
+	}
+}
+
+// poolState is the state of a leaf resource pool tracked by the simulator.
+type poolState struct {
+	path    string
+	respool respool.ResPool
+	// cpu share of the resource pool, used for the fairness index
+	share float64
+
+	waiting     int
+	running     int
+	completed   int
+	preemptions int
+	waitTimes   []time.Duration
+	samples     []*PoolSample
+}
+
+// New creates a simulator of the given trace. The task tracker and the
+// task scheduler of the resource manager are process-wide singletons, so
+// only one simulator can be created in a process.
+func New(cfg Config, trace *Trace) (*Simulator, error) {
+	if err := trace.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if !atomic.CompareAndSwapInt32(&created, 0, 1) {
+		return nil, errAlreadyCreated
+	}
+	cfg.normalize()
+
+	start := time.Unix(0, 0).UTC()
+	s := &Simulator{
+		cfg:             cfg,
+		scope:           tally.NoopScope,
+		start:           start,
+		now:             start,
+		jobs:            trace.sortedJobs(),
+		tasks:           make(map[string]*simTask),
+		pools:           make(map[string]*poolState),
+		nextEntitlement: start,
+		nextPreemption:  start,
+		nextSample:      start,
+	}
+
+	var err error
+	if s.strategy, err = newStrategy(&s.cfg.Placement); err != nil {
+		return nil, err
+	}
+	if err := s.initHostManager(trace); err != nil {
+		return nil, err
+	}
+	if err := s.initResourceManager(trace); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newStrategy creates the placement strategy like the placement engine.
+func newStrategy(cfg *placement_config.PlacementConfig) (plugins.Strategy, error) {
+	switch cfg.Strategy {
+	case placement_config.Batch:
+		return batch.New(), nil
+	case placement_config.Mimir:
+		cfg.Concurrency = 1
+		return mimir_strategy.New(algorithms.NewPlacer(4, 300), cfg), nil
+	}
+	return nil, fmt.Errorf("unknown placement strategy %q", cfg.Strategy)
+}
+
+// initHostManager creates the offer pool and the synthetic hosts.
+func (s *Simulator) initHostManager(trace *Trace) error {
+	binpacking.Init()
+	binpacking.InitWeighted(
+		s.cfg.Simulator.BinPackingWeights, scarceResourceTypes)
+	ranker := binpacking.GetRankerByName(s.cfg.Simulator.BinPacking)
+	if ranker == nil {
+		return fmt.Errorf(
+			"unknown bin-packing ranker %q", s.cfg.Simulator.BinPacking)
+	}
+
+	s.offerPool = offerpool.NewOfferPool(
+		_offerHoldTime,
+		nil, // offers are never declined
+		offerpool.NewMetrics(s.scope),
+		nil, // offers are never declined
+		nil, // hosts have no reserved resources
+		scarceResourceTypes,
+		[]string{common.MesosCPU},
+		ranker,
+		_hostPlacingOfferStatusTimeout,
+	)
+	s.cluster = newCluster(trace.Hosts, s.offerPool)
+	return nil
+}
+
+// initResourceManager creates the resource pools of the trace and the
+// components of the resource manager.
+func (s *Simulator) initResourceManager(trace *Trace) error {
+	ctx := context.Background()
+	store, err := memory_store.NewStore(memory.NewMemoryConnector(), s.scope)
+	if err != nil {
+		return err
+	}
+
+	ids := map[string]string{"/": common.RootResPoolID}
+	for _, p := range trace.ResPools {
+		cfg := &pb_respool.ResourcePoolConfig{}
+		if p.Config != nil {
+			cfg = proto.Clone(p.Config).(*pb_respool.ResourcePoolConfig)
+		}
+		cfg.Name = poolName(p.Path)
+		cfg.Parent = &peloton.ResourcePoolID{Value: ids[parentPath(p.Path)]}
+		if cfg.Policy == pb_respool.SchedulingPolicy_UNKNOWN {
+			cfg.Policy = pb_respool.SchedulingPolicy_PriorityFIFO
+		}
+		ids[p.Path] = uuid.New()
+		err := store.CreateResourcePool(
+			ctx,
+			&peloton.ResourcePoolID{Value: ids[p.Path]},
+			cfg,
+			simulatorName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create resource pool %s", p.Path)
+		}
+	}
+
+	preemptionCfg := s.cfg.ResManager.PreemptionConfig
+	s.tree = respool.NewTree(s.scope, store, store, store, *preemptionCfg)
+	if err := s.tree.Start(); err != nil {
+		return err
+	}
+	nodes := s.tree.GetAllNodes(true)
+	for e := nodes.Front(); e != nil; e = e.Next() {
+		pool := e.Value.(respool.ResPool)
+		share := 1.0
+		if r, ok := pool.Resources()[common.CPU]; ok && r.GetShare() > 0 {
+			share = r.GetShare()
+		}
+		s.pools[pool.GetPath()] = &poolState{
+			path:    pool.GetPath(),
+			respool: pool,
+			share:   share,
+		}
+		s.poolPaths = append(s.poolPaths, pool.GetPath())
+	}
+	sort.Strings(s.poolPaths)
+
+	rmtask.InitTaskTracker(s.scope, s.cfg.ResManager.RmTaskConfig)
+	s.tracker = rmtask.GetTracker()
+	rmtask.InitScheduler(
+		s.scope,
+		s.tree,
+		s.cfg.ResManager.TaskSchedulingPeriod,
+		s.tracker)
+	s.scheduler = rmtask.GetScheduler()
+
+	capacity := &capacityClient{cluster: s.cluster}
+	s.calculator = entitlement.NewCalculator(
+		s.cfg.ResManager.EntitlementCaculationPeriod,
+		s.scope,
+		capacity,
+		s.tree)
+	s.preemptor = preemption.NewPreemptor(
+		s.scope,
+		preemptionCfg,
+		s.tracker,
+		s.tree)
+
+	// the dispatcher is never started, the simulator calls the handler
+	// directly
+	dispatcher := yarpc.NewDispatcher(yarpc.Config{Name: simulatorName})
+	s.handler = rm.NewServiceHandler(
+		dispatcher,
+		s.scope,
+		s.tracker,
+		s.tree,
+		s.preemptor,
+		capacity,
+		s.cfg.ResManager)
+	return nil
+}
+
+// Run replays the trace until all the tasks have finished or the maximal
+// duration of the simulation is reached, and returns the report.
+func (s *Simulator) Run(ctx context.Context) (*Report, error) {
+	end := s.start.Add(s.cfg.Simulator.MaxDuration)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := s.step(ctx); err != nil {
+			return nil, err
+		}
+		if s.finished() || !s.now.Before(end) {
+			break
+		}
+		s.now = s.now.Add(s.cfg.Simulator.Step)
+	}
+	if len(s.samples) == 0 || s.samples[len(s.samples)-1].Time != s.elapsed() {
+		s.sample()
+	}
+	return s.report(), nil
+}
+
+// finished returns true once all the jobs have been submitted and all
+// their tasks have finished.
+func (s *Simulator) finished() bool {
+	return len(s.jobs) == 0 && s.waiting == 0 && s.running == 0
+}
+
+// elapsed returns the virtual time since the start of the simulation.
+func (s *Simulator) elapsed() time.Duration {
+	return s.now.Sub(s.start)
+}
+
+// step runs one cycle of the components which are due at the current
+// virtual time.
+func (s *Simulator) step(ctx context.Context) error {
+	if err := s.finishTasks(ctx); err != nil {
+		return err
+	}
+	if err := s.submitJobs(ctx); err != nil {
+		return err
+	}
+
+	if !s.now.Before(s.nextEntitlement) {
+		if err := s.calculator.RunOnce(ctx); err != nil {
+			return errors.Wrap(err, "failed to calculate entitlement")
+		}
+		s.nextEntitlement = s.now.Add(
+			s.cfg.ResManager.EntitlementCaculationPeriod)
+	}
+
+	s.scheduler.RunOnce()
+	if err := s.place(ctx); err != nil {
+		return err
+	}
+
+	preemptionCfg := s.cfg.ResManager.PreemptionConfig
+	if preemptionCfg.Enabled && !s.now.Before(s.nextPreemption) {
+		if err := s.preempt(ctx); err != nil {
+			return err
+		}
+		s.nextPreemption = s.now.Add(preemptionCfg.TaskPreemptionPeriod)
+	}
+
+	if !s.now.Before(s.nextSample) {
+		s.sample()
+		s.nextSample = s.now.Add(s.cfg.Simulator.ReportInterval)
+	}
+	return nil
+}
+
+// submitJobs enqueues the tasks of the jobs submitted until now.
+func (s *Simulator) submitJobs(ctx context.Context) error {
+	for len(s.jobs) > 0 && !s.start.Add(s.jobs[0].Submit).After(s.now) {
+		spec := s.jobs[0]
+		s.jobs = s.jobs[1:]
+
+		job := &simJob{
+			spec: spec,
+			id:   &peloton.JobID{Value: uuid.New()},
+			pool: s.pools[spec.ResPool],
+		}
+		if job.pool == nil {
+			return fmt.Errorf(
+				"resource pool %s of job %s is not a leaf",
+				spec.ResPool, spec.Name)
+		}
+
+		tasks := make([]*simTask, 0, spec.Instances)
+		for i := uint32(0); i < spec.Instances; i++ {
+			t := &simTask{
+				job:      job,
+				id:       &peloton.TaskID{Value: util.CreatePelotonTaskID(job.id.GetValue(), i)},
+				instance: i,
+			}
+			s.tasks[t.id.GetValue()] = t
+			tasks = append(tasks, t)
+		}
+		if err := s.enqueue(ctx, job, tasks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueue enqueues a new run of the given tasks of a job to the resource
+// manager, every task in its own gang like the job manager does.
+func (s *Simulator) enqueue(
+	ctx context.Context,
+	job *simJob,
+	tasks []*simTask) error {
+	gangs := make([]*resmgrsvc.Gang, 0, len(tasks))
+	for _, t := range tasks {
+		t.runID++
+		t.mesosID = util.CreateMesosTaskID(job.id, t.instance, t.runID)
+		t.state = simTaskWaiting
+		t.hostname = ""
+		t.queuedAt = s.now
+		gangs = append(gangs, &resmgrsvc.Gang{
+			Tasks: []*resmgr.Task{{
+				Name:   job.spec.Name,
+				Id:     t.id,
+				JobId:  job.id,
+				TaskId: t.mesosID,
+				Resource: &pbtask.ResourceConfig{
+					CpuLimit:    job.spec.CPU,
+					MemLimitMb:  job.spec.MemMb,
+					DiskLimitMb: job.spec.DiskMb,
+					GpuLimit:    job.spec.GPU,
+				},
+				Priority:     job.spec.Priority,
+				Preemptible:  job.spec.Preemptible,
+				Type:         resmgr.TaskType_BATCH,
+				MinInstances: 1,
+			}},
+		})
+	}
+
+	resp, err := s.handler.EnqueueGangs(ctx, &resmgrsvc.EnqueueGangsRequest{
+		ResPool: &peloton.ResourcePoolID{Value: job.pool.respool.ID()},
+		Gangs:   gangs,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.GetError() != nil {
+		return fmt.Errorf(
+			"failed to enqueue tasks of job %s: %s",
+			job.spec.Name, resp.GetError().String())
+	}
+	job.pool.waiting += len(tasks)
+	s.waiting += len(tasks)
+	return nil
+}
+
+// place places the tasks in the ready queue of the resource manager on
+// the offers of the hosts, and launches the placed tasks.
+func (s *Simulator) place(ctx context.Context) error {
+	ready := s.tracker.GetActiveTasks(
+		"", "", []string{pbtask.TaskState_READY.String()},
+	)[pbtask.TaskState_READY.String()]
+	if len(ready) == 0 {
+		return nil
+	}
+	limit := len(ready)
+	if limit > s.cfg.Placement.TaskDequeueLimit {
+		limit = s.cfg.Placement.TaskDequeueLimit
+	}
+
+	resp, err := s.handler.DequeueGangs(ctx, &resmgrsvc.DequeueGangsRequest{
+		Limit:   uint32(limit),
+		Type:    resmgr.TaskType_BATCH,
+		Timeout: _dequeueTimeoutMs,
+	})
+	if err != nil {
+		return err
+	}
+
+	var assignments []*models.Assignment
+	deadline := s.now.Add(s.cfg.Placement.MaxDurations.Value(resmgr.TaskType_BATCH))
+	maxRounds := s.cfg.Placement.MaxRounds.Value(resmgr.TaskType_BATCH)
+	for _, gang := range resp.GetGangs() {
+		for _, task := range gang.GetTasks() {
+			assignments = append(assignments, models.NewAssignment(
+				models.NewTask(gang, task, deadline, deadline, maxRounds)))
+		}
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	s.cluster.sendOffers(ctx)
+
+	var placements []*resmgr.Placement
+	var failed []*resmgrsvc.SetPlacementsRequest_FailedPlacement
+	for filter, group := range s.strategy.Filters(assignments) {
+		placed, unplaced := s.placeGroup(filter, group)
+		placements = append(placements, placed...)
+		for _, a := range unplaced {
+			failed = append(failed, &resmgrsvc.SetPlacementsRequest_FailedPlacement{
+				Reason: a.GetReason(),
+				Gang: &resmgrsvc.Gang{
+					Tasks: []*resmgr.Task{a.GetTask().GetTask()},
+				},
+			})
+		}
+	}
+
+	setResp, err := s.handler.SetPlacements(ctx, &resmgrsvc.SetPlacementsRequest{
+		Placements:       placements,
+		FailedPlacements: failed,
+	})
+	if err != nil {
+		return err
+	}
+	// the placements which were not set are never launched
+	notSet := setResp.GetError().GetFailure().GetFailed()
+	for _, f := range notSet {
+		s.returnOffers(f.GetPlacement().GetHostname())
+	}
+	return s.launch(ctx, len(placements)-len(notSet))
+}
+
+// returnOffers returns the offers of a host claimed for placement to the
+// offer pool.
+func (s *Simulator) returnOffers(hostname string) {
+	if err := s.offerPool.ReturnUnusedOffers(hostname); err != nil {
+		log.WithError(err).
+			WithField("hostname", hostname).
+			Warn("failed to return unused offers")
+	}
+}
+
+// placeGroup places a group of tasks with the same host filter like the
+// placement engine, and returns the placements and the tasks which could
+// not be placed.
+func (s *Simulator) placeGroup(
+	filter *hostsvc.HostFilter,
+	assignments []*models.Assignment,
+) ([]*resmgr.Placement, []*models.Assignment) {
+	offers, _, err := s.offerPool.ClaimForPlace(filter)
+	if err != nil {
+		log.WithError(err).Warn("failed to claim offers for placement")
+	}
+
+	hostnames := make([]string, 0, len(offers))
+	for hostname := range offers {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+
+	hosts := make([]*models.HostOffers, 0, len(offers))
+	for _, hostname := range hostnames {
+		offer := offers[hostname]
+		if len(offer.Offers) == 0 {
+			s.returnOffers(hostname)
+			continue
+		}
+		var resources []*mesos.Resource
+		for _, o := range offer.Offers {
+			resources = append(resources, o.GetResources()...)
+		}
+		hosts = append(hosts, models.NewHostOffers(
+			&hostsvc.HostOffer{
+				Hostname:   hostname,
+				AgentId:    offer.Offers[0].GetAgentId(),
+				Attributes: offer.Offers[0].GetAttributes(),
+				Resources:  resources,
+				Id:         &peloton.HostOfferID{Value: offer.ID},
+			},
+			nil,
+			s.now))
+	}
+
+	if len(hosts) > 0 {
+		for i, h := range s.strategy.GetTaskPlacements(assignments, hosts) {
+			if h >= 0 {
+				assignments[i].SetHost(hosts[h])
+			}
+		}
+	}
+
+	var unplaced []*models.Assignment
+	tasksByHost := make(map[*models.HostOffers][]*models.Task)
+	for _, a := range assignments {
+		if a.GetHost() == nil {
+			if a.GetReason() == "" {
+				a.SetReason(_reasonNoHost)
+			}
+			unplaced = append(unplaced, a)
+			continue
+		}
+		tasksByHost[a.GetHost()] = append(tasksByHost[a.GetHost()], a.GetTask())
+	}
+
+	var placements []*resmgr.Placement
+	for _, h := range hosts {
+		tasks, ok := tasksByHost[h]
+		if !ok {
+			s.returnOffers(h.GetOffer().GetHostname())
+			continue
+		}
+		placement := &resmgr.Placement{
+			Hostname:    h.GetOffer().GetHostname(),
+			AgentId:     h.GetOffer().GetAgentId(),
+			Type:        resmgr.TaskType_BATCH,
+			HostOfferID: h.GetOffer().GetId(),
+		}
+		for _, t := range tasks {
+			placement.Tasks = append(placement.Tasks, t.GetTask().GetId())
+			placement.TaskIDs = append(placement.TaskIDs, &resmgr.Placement_Task{
+				PelotonTaskID: t.GetTask().GetId(),
+				MesosTaskID:   t.GetTask().GetTaskId(),
+			})
+		}
+		placements = append(placements, placement)
+	}
+	return placements, unplaced
+}
+
+// launch gets the placements from the resource manager, launches their
+// tasks on the hosts and reports the tasks running like the job manager
+// and the host manager do.
+func (s *Simulator) launch(ctx context.Context, count int) error {
+	if count == 0 {
+		return nil
+	}
+	resp, err := s.handler.GetPlacements(ctx, &resmgrsvc.GetPlacementsRequest{
+		Limit:   uint32(count),
+		Timeout: _dequeueTimeoutMs,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, placement := range resp.GetPlacements() {
+		var tasks []*simTask
+		var taskIDs []*peloton.TaskID
+		var res scalar.Resources
+		for _, pt := range placement.GetTaskIDs() {
+			t, ok := s.tasks[pt.GetPelotonTaskID().GetValue()]
+			if !ok || t.mesosID.GetValue() != pt.GetMesosTaskID().GetValue() {
+				continue
+			}
+			tasks = append(tasks, t)
+			taskIDs = append(taskIDs, t.id)
+			res = res.Add(t.resources())
+		}
+
+		hostname := placement.GetHostname()
+		if len(tasks) == 0 {
+			s.returnOffers(hostname)
+			continue
+		}
+		err := s.cluster.launch(
+			hostname,
+			placement.GetHostOfferID().GetValue(),
+			taskIDs,
+			res)
+		if err != nil {
+			return errors.Wrapf(err, "failed to launch tasks on %s", hostname)
+		}
+		if err := s.startTasks(ctx, hostname, tasks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startTasks moves the launched tasks to RUNNING in the resource manager.
+func (s *Simulator) startTasks(
+	ctx context.Context,
+	hostname string,
+	tasks []*simTask) error {
+	entries := make(
+		[]*resmgrsvc.UpdateTasksStateRequest_UpdateTaskStateEntry, 0, len(tasks))
+	for _, t := range tasks {
+		entries = append(entries,
+			&resmgrsvc.UpdateTasksStateRequest_UpdateTaskStateEntry{
+				Task:        t.id,
+				MesosTaskId: t.mesosID,
+				State:       pbtask.TaskState_LAUNCHED,
+			})
+	}
+	_, err := s.handler.UpdateTasksState(ctx, &resmgrsvc.UpdateTasksStateRequest{
+		TaskStates: entries,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.notify(ctx, tasks, mesos.TaskState_TASK_RUNNING); err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		// the start time of the task ranks it for preemption
+		if rmTask := s.tracker.GetTask(t.id); rmTask != nil {
+			rmTask.UpdateStartTime(s.now)
+		}
+		t.state = simTaskRunning
+		t.hostname = hostname
+		heap.Push(&s.completions, completion{
+			end:   s.now.Add(t.job.spec.Duration),
+			task:  t,
+			runID: t.runID,
+		})
+
+		pool := t.job.pool
+		pool.waitTimes = append(pool.waitTimes, s.now.Sub(t.queuedAt))
+		pool.waiting--
+		pool.running++
+		s.waiting--
+		s.running++
+	}
+	return nil
+}
+
+// finishTasks finishes the tasks which have run for their duration.
+func (s *Simulator) finishTasks(ctx context.Context) error {
+	var tasks []*simTask
+	for s.completions.Len() > 0 && !s.completions[0].end.After(s.now) {
+		c := heap.Pop(&s.completions).(completion)
+		if c.task.state != simTaskRunning || c.task.runID != c.runID {
+			// the run was preempted
+			continue
+		}
+		tasks = append(tasks, c.task)
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	if err := s.notify(ctx, tasks, mesos.TaskState_TASK_FINISHED); err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		s.stopTask(t)
+		t.state = simTaskDone
+		t.job.pool.completed++
+	}
+	return nil
+}
+
+// preempt runs a preemption cycle, and kills and enqueues again the
+// tasks it selected like the job manager does.
+func (s *Simulator) preempt(ctx context.Context) error {
+	if err := s.preemptor.RunOnce(); err != nil {
+		log.WithError(err).Warn("preemption cycle failed")
+	}
+
+	for {
+		resp, err := s.handler.GetPreemptibleTasks(
+			ctx,
+			&resmgrsvc.GetPreemptibleTasksRequest{
+				Limit:   _preemptionDequeueLimit,
+				Timeout: _dequeueTimeoutMs,
+			})
+		if err != nil {
+			return err
+		}
+
+		var tasks []*simTask
+		for _, c := range resp.GetPreemptionCandidates() {
+			t, ok := s.tasks[c.GetId().GetValue()]
+			if !ok || t.state != simTaskRunning {
+				continue
+			}
+			tasks = append(tasks, t)
+		}
+		if len(tasks) > 0 {
+			if err := s.notify(ctx, tasks, mesos.TaskState_TASK_KILLED); err != nil {
+				return err
+			}
+		}
+
+		byJob := make(map[*simJob][]*simTask)
+		var jobs []*simJob
+		for _, t := range tasks {
+			s.stopTask(t)
+			t.job.pool.preemptions++
+			if _, ok := byJob[t.job]; !ok {
+				jobs = append(jobs, t.job)
+			}
+			byJob[t.job] = append(byJob[t.job], t)
+		}
+		for _, job := range jobs {
+			if err := s.enqueue(ctx, job, byJob[job]); err != nil {
+				return err
+			}
+		}
+
+		if len(resp.GetPreemptionCandidates()) < _preemptionDequeueLimit {
+			return nil
+		}
+	}
+}
+
+// stopTask releases the resources of a task which stopped running.
+func (s *Simulator) stopTask(t *simTask) {
+	s.cluster.release(t.hostname, t.resources())
+	t.job.pool.running--
+	s.running--
+}
+
+// notify sends the status updates of tasks to the resource manager like
+// the host manager does.
+func (s *Simulator) notify(
+	ctx context.Context,
+	tasks []*simTask,
+	state mesos.TaskState) error {
+	events := make([]*pb_eventstream.Event, 0, len(tasks))
+	for _, t := range tasks {
+		s.eventOffset++
+		events = append(events, &pb_eventstream.Event{
+			Offset: s.eventOffset,
+			Type:   pb_eventstream.Event_MESOS_TASK_STATUS,
+			MesosTaskStatus: &mesos.TaskStatus{
+				TaskId: t.mesosID,
+				State:  state.Enum(),
+			},
+		})
+	}
+	_, err := s.handler.NotifyTaskUpdates(ctx, &resmgrsvc.NotifyTaskUpdatesRequest{
+		Events: events,
+	})
+	return err
+}
+
+// completion is the end of a run of a task.
+type completion struct {
+	end   time.Time
+	task  *simTask
+	runID uint64
+}
+
+// completionHeap orders the runs of the tasks by end time.
+type completionHeap []completion
+
+func (h completionHeap) Len() int           { return len(h) }
+func (h completionHeap) Less(i, j int) bool { return h[i].end.Before(h[j].end) }
+func (h completionHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *completionHeap) Push(x interface{}) {
+	*h = append(*h, x.(completion))
+}
+
+func (h *completionHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	c := old[n-1]
+	*h = old[:n-1]
+	return c
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	placement_config "github.com/uber/peloton/pkg/placement/config"
+	"github.com/uber/peloton/pkg/resmgr"
+	"github.com/uber/peloton/pkg/resmgr/common"
+	rmtask "github.com/uber/peloton/pkg/resmgr/task"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// the trace of the simulation: the cluster has 8 cpus, the etl job
+// fills it and the adhoc job submitted later reclaims the reservation
+// of its resource pool.
+const _simulationTrace = `
+respools:
+- path: /batch
+  config:
+    resources:
+    - kind: cpu
+      reservation: 8
+      limit: 8
+      share: 1
+    - kind: memory
+      reservation: 32768
+      limit: 32768
+      share: 1
+- path: /batch/etl
+  config:
+    resources:
+    - kind: cpu
+      reservation: 4
+      limit: 8
+      share: 1
+    - kind: memory
+      reservation: 16384
+      limit: 32768
+      share: 1
+- path: /batch/adhoc
+  config:
+    resources:
+    - kind: cpu
+      reservation: 4
+      limit: 8
+      share: 1
+    - kind: memory
+      reservation: 16384
+      limit: 32768
+      share: 1
+hosts:
+- prefix: host
+  count: 2
+  cpu: 4
+  mem_mb: 16384
+jobs:
+- name: etl
+  respool: /batch/etl
+  instances: 12
+  cpu: 1
+  mem_mb: 1024
+  duration: 5m
+  preemptible: true
+- name: adhoc
+  respool: /batch/adhoc
+  submit: 2m
+  instances: 4
+  cpu: 1
+  mem_mb: 1024
+  duration: 1m
+  preemptible: true
+`
+
+type SimulatorTestSuite struct {
+	suite.Suite
+}
+
+func TestSimulator(t *testing.T) {
+	suite.Run(t, new(SimulatorTestSuite))
+}
+
+func (s *SimulatorTestSuite) newConfig() Config {
+	return Config{
+		ResManager: resmgr.Config{
+			TaskSchedulingPeriod:        time.Second,
+			EntitlementCaculationPeriod: 10 * time.Second,
+			RmTaskConfig: &rmtask.Config{
+				PlacingTimeout:   10 * time.Minute,
+				LaunchingTimeout: 20 * time.Minute,
+				ReservingTimeout: 30 * time.Minute,
+				PolicyName:       "exponential-policy",
+			},
+			PreemptionConfig: &common.PreemptionConfig{
+				Enabled:                      true,
+				TaskPreemptionPeriod:         10 * time.Second,
+				SustainedOverAllocationCount: 1,
+			},
+		},
+		Placement: placement_config.PlacementConfig{
+			Strategy: placement_config.Batch,
+			MaxRounds: placement_config.MaxRoundsConfig{
+				Batch: 1,
+			},
+			MaxDurations: placement_config.MaxDurationsConfig{
+				Batch: time.Minute,
+			},
+		},
+		Simulator: SimulatorConfig{
+			Step:           time.Second,
+			ReportInterval: time.Minute,
+			MaxDuration:    time.Hour,
+		},
+	}
+}
+
+func (s *SimulatorTestSuite) TestNewInvalidConfig() {
+	trace, err := ParseTrace([]byte(_simulationTrace))
+	s.Require().NoError(err)
+
+	cfg := s.newConfig()
+	cfg.ResManager.RmTaskConfig = nil
+	_, err = New(cfg, trace)
+	s.Error(err)
+
+	cfg = s.newConfig()
+	cfg.ResManager.EntitlementCaculationPeriod = 0
+	_, err = New(cfg, trace)
+	s.Error(err)
+
+	cfg = s.newConfig()
+	cfg.ResManager.PreemptionConfig.TaskPreemptionPeriod = 0
+	_, err = New(cfg, trace)
+	s.Error(err)
+}
+
+// TestRun runs the only simulation of the package, the task tracker and
+// the task scheduler of the resource manager are process-wide singletons.
+func (s *SimulatorTestSuite) TestRun() {
+	trace, err := ParseTrace([]byte(_simulationTrace))
+	s.Require().NoError(err)
+
+	sim, err := New(s.newConfig(), trace)
+	s.Require().NoError(err)
+
+	_, err = New(s.newConfig(), trace)
+	s.Equal(errAlreadyCreated, err)
+
+	report, err := sim.Run(context.Background())
+	s.Require().NoError(err)
+
+	s.True(report.Finished)
+	s.True(report.Duration < time.Hour)
+	s.Len(report.Pools, 2)
+	s.NotEmpty(report.Cluster)
+
+	pools := make(map[string]*PoolReport)
+	for _, p := range report.Pools {
+		pools[p.Path] = p
+	}
+	for path, instances := range map[string]int{
+		"/batch/etl":   12,
+		"/batch/adhoc": 4,
+	} {
+		p := pools[path]
+		s.Require().NotNil(p, path)
+		s.Equal(instances, p.Completed, path)
+		// every run of a task waits, including the runs after a
+		// preemption
+		s.Equal(instances+p.Preemptions, p.Wait.Count, path)
+		s.NotEmpty(p.Samples, path)
+	}
+
+	// the etl job does not fit in the cluster
+	s.True(pools["/batch/etl"].Wait.Max > 0)
+
+	for _, c := range report.Cluster {
+		s.True(c.Utilization >= 0 && c.Utilization <= 1)
+		s.True(c.Fairness > 0 && c.Fairness <= 1)
+	}
+	last := report.Cluster[len(report.Cluster)-1]
+	s.Equal(report.Duration, last.Time)
+	s.Zero(last.Pending)
+	s.Zero(last.Running)
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	pb_respool "github.com/uber/peloton/.gen/peloton/api/v0/respool"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Trace is a workload replayed by the simulator: the resource pools, the
+// hosts of the cluster and the jobs submitted over time.
+type Trace struct {
+	// Resource pools of the cluster, parents are listed before their
+	// children.
+	ResPools []*ResPoolSpec `yaml:"respools"`
+
+	// Hosts of the cluster.
+	Hosts []*HostSpec `yaml:"hosts"`
+
+	// Jobs submitted to the cluster.
+	Jobs []*JobSpec `yaml:"jobs"`
+}
+
+// ResPoolSpec is a resource pool of the trace.
+type ResPoolSpec struct {
+	// Path of the resource pool, e.g. /batch/etl.
+	Path string `yaml:"path"`
+
+	// Config of the resource pool, the name and the parent are derived
+	// from the path.
+	Config *pb_respool.ResourcePoolConfig `yaml:"config"`
+}
+
+// HostSpec is a group of identical hosts of the trace.
+type HostSpec struct {
+	// Prefix of the hostnames of the group, the hosts are named
+	// <prefix>-<index>.
+	Prefix string `yaml:"prefix"`
+
+	// Count is the number of hosts of the group.
+	Count int `yaml:"count"`
+
+	// Resources of each host of the group.
+	CPU    float64 `yaml:"cpu"`
+	MemMb  float64 `yaml:"mem_mb"`
+	DiskMb float64 `yaml:"disk_mb"`
+	GPU    float64 `yaml:"gpu"`
+}
+
+// JobSpec is a batch job of the trace.
+type JobSpec struct {
+	// Name of the job.
+	Name string `yaml:"name"`
+
+	// Path of the leaf resource pool of the job.
+	ResPool string `yaml:"respool"`
+
+	// Submit is the time the job is submitted, as an offset from the
+	// start of the simulation.
+	Submit time.Duration `yaml:"submit"`
+
+	// Instances is the number of tasks of the job.
+	Instances uint32 `yaml:"instances"`
+
+	// Resources of each task of the job.
+	CPU    float64 `yaml:"cpu"`
+	MemMb  float64 `yaml:"mem_mb"`
+	DiskMb float64 `yaml:"disk_mb"`
+	GPU    float64 `yaml:"gpu"`
+
+	// Duration is the time each task runs once it is started.
+	Duration time.Duration `yaml:"duration"`
+
+	// Priority of the tasks of the job.
+	Priority uint32 `yaml:"priority"`
+
+	// Preemptible determines if the tasks of the job can be preempted,
+	// non-preemptible tasks are only admitted within the reservation of
+	// their resource pool.
+	Preemptible bool `yaml:"preemptible"`
+}
+
+// LoadTrace reads a trace from a YAML file.
+func LoadTrace(path string) (*Trace, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read trace")
+	}
+	return ParseTrace(data)
+}
+
+// ParseTrace parses a trace from YAML and validates it.
+func ParseTrace(data []byte) (*Trace, error) {
+	var trace Trace
+	if err := yaml.Unmarshal(data, &trace); err != nil {
+		return nil, errors.Wrap(err, "failed to parse trace")
+	}
+	if err := trace.Validate(); err != nil {
+		return nil, err
+	}
+	return &trace, nil
+}
+
+// Validate returns an error if the trace cannot be replayed.
+func (t *Trace) Validate() error {
+	pools := make(map[string]bool)
+	parents := make(map[string]bool)
+	for _, p := range t.ResPools {
+		if !strings.HasPrefix(p.Path, "/") || p.Path == "/" ||
+			strings.HasSuffix(p.Path, "/") {
+			return fmt.Errorf("invalid resource pool path %q", p.Path)
+		}
+		if pools[p.Path] {
+			return fmt.Errorf("duplicate resource pool %s", p.Path)
+		}
+		if parent := parentPath(p.Path); parent != "/" && !pools[parent] {
+			return fmt.Errorf(
+				"parent of resource pool %s must be listed before it", p.Path)
+		}
+		pools[p.Path] = true
+		parents[parentPath(p.Path)] = true
+	}
+
+	if len(t.Hosts) == 0 {
+		return errors.New("trace has no hosts")
+	}
+	for _, h := range t.Hosts {
+		if h.Count <= 0 || h.CPU <= 0 || h.MemMb <= 0 {
+			return errors.New(
+				"hosts must have a positive count, cpu and memory")
+		}
+	}
+
+	for _, j := range t.Jobs {
+		if !pools[j.ResPool] {
+			return fmt.Errorf(
+				"resource pool %q of job %s not found", j.ResPool, j.Name)
+		}
+		if parents[j.ResPool] {
+			return fmt.Errorf(
+				"resource pool %s of job %s is not a leaf", j.ResPool, j.Name)
+		}
+		if j.Instances == 0 || j.Duration <= 0 {
+			return fmt.Errorf(
+				"job %s must have instances and a duration", j.Name)
+		}
+		if j.Submit < 0 {
+			return fmt.Errorf("job %s is submitted before the start", j.Name)
+		}
+	}
+	return nil
+}
+
+// sortedJobs returns the jobs ordered by submit time.
+func (t *Trace) sortedJobs() []*JobSpec {
+	jobs := make([]*JobSpec, len(t.Jobs))
+	copy(jobs, t.Jobs)
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].Submit < jobs[j].Submit
+	})
+	return jobs
+}
+
+// parentPath returns the path of the parent of a resource pool.
+func parentPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// poolName returns the name of a resource pool.
+func poolName(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const _testTrace = `
+respools:
+- path: /batch
+  config:
+    resources:
+    - kind: cpu
+      reservation: 4
+      limit: 8
+      share: 2
+- path: /batch/etl
+- path: /services
+hosts:
+- prefix: host
+  count: 2
+  cpu: 4
+  mem_mb: 8192
+jobs:
+- name: late
+  respool: /services
+  submit: 1m
+  instances: 1
+  cpu: 1
+  mem_mb: 1024
+  duration: 10s
+- name: early
+  respool: /batch/etl
+  instances: 4
+  cpu: 1
+  mem_mb: 1024
+  duration: 1m
+  preemptible: true
+`
+
+type TraceTestSuite struct {
+	suite.Suite
+}
+
+func TestTrace(t *testing.T) {
+	suite.Run(t, new(TraceTestSuite))
+}
+
+func (s *TraceTestSuite) TestParseTrace() {
+	trace, err := ParseTrace([]byte(_testTrace))
+	s.NoError(err)
+
+	s.Len(trace.ResPools, 3)
+	s.Equal("/batch", trace.ResPools[0].Path)
+	s.Equal(2.0, trace.ResPools[0].Config.GetResources()[0].GetShare())
+	s.Nil(trace.ResPools[1].Config)
+
+	s.Len(trace.Hosts, 1)
+	s.Equal(2, trace.Hosts[0].Count)
+	s.Equal(8192.0, trace.Hosts[0].MemMb)
+
+	s.Len(trace.Jobs, 2)
+	s.Equal(time.Minute, trace.Jobs[0].Submit)
+	s.Equal(uint32(4), trace.Jobs[1].Instances)
+	s.True(trace.Jobs[1].Preemptible)
+
+	jobs := trace.sortedJobs()
+	s.Equal("early", jobs[0].Name)
+	s.Equal("late", jobs[1].Name)
+	// the order of the trace is kept
+	s.Equal("late", trace.Jobs[0].Name)
+}
+
+func (s *TraceTestSuite) TestParseTraceInvalidYAML() {
+	_, err := ParseTrace([]byte("hosts: [}"))
+	s.Error(err)
+}
+
+func (s *TraceTestSuite) TestValidate() {
+	valid := func() *Trace {
+		trace, err := ParseTrace([]byte(_testTrace))
+		s.Require().NoError(err)
+		return trace
+	}
+
+	tt := []struct {
+		msg    string
+		modify func(t *Trace)
+	}{
+		{
+			msg:    "relative resource pool path",
+			modify: func(t *Trace) { t.ResPools[2].Path = "services" },
+		},
+		{
+			msg:    "root resource pool path",
+			modify: func(t *Trace) { t.ResPools[2].Path = "/" },
+		},
+		{
+			msg: "duplicate resource pool",
+			modify: func(t *Trace) {
+				t.ResPools = append(t.ResPools, &ResPoolSpec{Path: "/batch"})
+			},
+		},
+		{
+			msg: "parent listed after the child",
+			modify: func(t *Trace) {
+				t.ResPools[0], t.ResPools[1] = t.ResPools[1], t.ResPools[0]
+			},
+		},
+		{
+			msg:    "no hosts",
+			modify: func(t *Trace) { t.Hosts = nil },
+		},
+		{
+			msg:    "host without cpu",
+			modify: func(t *Trace) { t.Hosts[0].CPU = 0 },
+		},
+		{
+			msg:    "unknown resource pool",
+			modify: func(t *Trace) { t.Jobs[0].ResPool = "/unknown" },
+		},
+		{
+			msg:    "non-leaf resource pool",
+			modify: func(t *Trace) { t.Jobs[0].ResPool = "/batch" },
+		},
+		{
+			msg:    "no instances",
+			modify: func(t *Trace) { t.Jobs[0].Instances = 0 },
+		},
+		{
+			msg:    "no duration",
+			modify: func(t *Trace) { t.Jobs[0].Duration = 0 },
+		},
+		{
+			msg:    "negative submit time",
+			modify: func(t *Trace) { t.Jobs[0].Submit = -time.Second },
+		},
+	}
+
+	for _, test := range tt {
+		trace := valid()
+		test.modify(trace)
+		s.Error(trace.Validate(), test.msg)
+	}
+}
+
+func (s *TraceTestSuite) TestPaths() {
+	s.Equal("/", parentPath("/batch"))
+	s.Equal("/batch", parentPath("/batch/etl"))
+	s.Equal("batch", poolName("/batch"))
+	s.Equal("etl", poolName("/batch/etl"))
+}
@@ -340,7 +340,8 @@ func (suite *MockDatastoreTestSuite) TestWorkflowEventsFailures() {
 		updateID,
 		0,
 		models.WorkflowType_UPDATE,
-		update.State_ROLLING_FORWARD)
+		update.State_ROLLING_FORWARD,
+		"")
 	suite.Error(err)
 
 	err = suite.store.deleteWorkflowEvents(context.Background(), updateID, 0)
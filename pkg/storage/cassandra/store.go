@@ -2893,15 +2893,20 @@ func (s *Store) convertToWorkflowEvents(
 
 	var workflowEvents []*stateless.WorkflowEvent
 	for _, value := range result {
+		// message is not set for job update events
+		// and for events written before it was added
+		message, _ := value["message"].(string)
 		workflowEvent := &stateless.WorkflowEvent{
 			Type: stateless.WorkflowType(
 				models.WorkflowType_value[value["type"].(string)]),
 			State: stateless.WorkflowState(
 				update.State_value[value["state"].(string)]),
 			Timestamp: value["create_time"].(qb.UUID).Time().Format(time.RFC3339),
+			Message:   message,
 		}
 
-		if prevWorkflowEvent.GetState() != workflowEvent.GetState() {
+		if prevWorkflowEvent.GetState() != workflowEvent.GetState() ||
+			prevWorkflowEvent.GetMessage() != workflowEvent.GetMessage() {
 			workflowEvents = append(workflowEvents, workflowEvent)
 			count = 0
 			isLogged = false
@@ -2909,9 +2914,7 @@ func (s *Store) convertToWorkflowEvents(
 			continue
 		}
 
-		if prevWorkflowEvent.GetState() == workflowEvent.GetState() {
-			count++
-		}
+		count++
 
 		if count > _defaultWorkflowEventsDedupeWarnLimit && !isLogged {
 			log.WithFields(log.Fields{
@@ -2950,7 +2953,8 @@ func (s *Store) AddWorkflowEvent(
 	updateID *peloton.UpdateID,
 	instanceID uint32,
 	workflowType models.WorkflowType,
-	workflowState update.State) error {
+	workflowState update.State,
+	message string) error {
 	queryBuilder := s.DataStore.NewQuery()
 	stmt := queryBuilder.Insert(podWorkflowEventsTable).
 		Columns(
@@ -2958,12 +2962,14 @@ func (s *Store) AddWorkflowEvent(
 			"instance_id",
 			"type",
 			"state",
+			"message",
 			"create_time").
 		Values(
 			updateID.GetValue(),
 			int(instanceID),
 			workflowType.String(),
 			workflowState.String(),
+			message,
 			qb.UUID{UUID: gocql.UUIDFromTime(time.Now())})
 	err := s.applyStatement(ctx, stmt, updateID.GetValue())
 	if err != nil {
@@ -2346,6 +2346,7 @@ func (suite *CassandraStoreTestSuite) TestUpdate() {
 		0,
 		models.WorkflowType_UPDATE,
 		state,
+		"",
 	))
 
 	// create an update with bad updateConfig
@@ -2458,7 +2459,8 @@ func (suite *CassandraStoreTestSuite) TestUpdate() {
 		updateID,
 		0,
 		models.WorkflowType_UPDATE,
-		update.State_ROLLING_FORWARD))
+		update.State_ROLLING_FORWARD,
+		""))
 
 	// get the update
 	updateInfo, err = store.GetUpdate(
@@ -2506,7 +2508,8 @@ func (suite *CassandraStoreTestSuite) TestUpdate() {
 		updateID,
 		0,
 		models.WorkflowType_UPDATE,
-		update.State_ROLLING_FORWARD))
+		update.State_ROLLING_FORWARD,
+		""))
 
 	workflowEvents, err = store.GetWorkflowEvents(
 		context.Background(),
@@ -2527,6 +2530,25 @@ func (suite *CassandraStoreTestSuite) TestUpdate() {
 	suite.NoError(err)
 	suite.Equal(1, len(workflowEvents))
 
+	// Add ROLLING_FORWARD event with a message which is not deduped
+	suite.NoError(store.AddWorkflowEvent(
+		context.Background(),
+		updateID,
+		0,
+		models.WorkflowType_UPDATE,
+		update.State_ROLLING_FORWARD,
+		"test message"))
+
+	workflowEvents, err = store.GetWorkflowEvents(
+		context.Background(),
+		updateID,
+		0,
+		0,
+	)
+	suite.NoError(err)
+	suite.Equal(3, len(workflowEvents))
+	suite.Equal("test message", workflowEvents[0].GetMessage())
+
 	suite.NoError(store.deleteWorkflowEvents(context.Background(), updateID, 0))
 
 	workflowEvents, err = store.GetWorkflowEvents(
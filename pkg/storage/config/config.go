@@ -15,7 +15,9 @@
 package config
 
 import (
+	"github.com/uber/peloton/pkg/common/envelope"
 	"github.com/uber/peloton/pkg/storage/cassandra"
+	"github.com/uber/peloton/pkg/storage/connectors/sql"
 )
 
 // Config contains the different DB config values for each
@@ -25,4 +27,21 @@ type Config struct {
 	UseCassandra       bool             `yaml:"use_cassandra"`
 	AutoMigrate        bool             `yaml:"auto_migrate"`
 	DbWriteConcurrency int              `yaml:"db_write_concurrency"`
+
+	// UseMemory keeps all storage in process memory instead of Cassandra.
+	// Data is lost on restart and is not shared between processes, so this
+	// is only meant for development and integration tests.
+	UseMemory bool `yaml:"use_memory"`
+
+	// SQL is the config of the relational database used when UseSQL is set
+	SQL sql.Config `yaml:"sql"`
+	// UseSQL stores the storage objects in a relational database instead
+	// of Cassandra. Only the storage objects are moved: the legacy store
+	// (storage.Store, created by stores.MustCreateStore) is still backed
+	// by Cassandra, so the Cassandra config is required and Cassandra must
+	// be reachable even if UseSQL is set.
+	UseSQL bool `yaml:"use_sql"`
+
+	// SecretEncryption is the config of the encryption of secrets at rest
+	SecretEncryption envelope.Config `yaml:"secret_encryption"`
 }
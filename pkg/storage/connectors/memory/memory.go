@@ -0,0 +1,531 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+	"github.com/uber/peloton/pkg/storage/orm"
+
+	"github.com/gocql/gocql"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// row is a single row of a table, keyed by column name
+type row map[string]interface{}
+
+// table holds all rows of a table, keyed by partition key and then
+// by clustering key
+type table struct {
+	partitions map[string]map[string]row
+}
+
+type memoryConnector struct {
+	// implements orm.Connector interface
+	orm.Connector
+
+	sync.RWMutex
+	// tables maps table name to the rows of that table
+	tables map[string]*table
+}
+
+// NewMemoryConnector initializes a connector which keeps all rows in
+// process memory. It honors the partition and clustering keys of the
+// storage objects the same way as Cassandra does, so it can be used in
+// place of the Cassandra connector when running without a database.
+func NewMemoryConnector() orm.Connector {
+	return &memoryConnector{
+		tables: make(map[string]*table),
+	}
+}
+
+// ensure that implementation (memoryConnector) satisfies the interface
+var _ orm.Connector = (*memoryConnector)(nil)
+
+// keyString serializes the values of the given key columns of a row
+// into a string which can be used as a map key
+func keyString(r row, names []string) (string, error) {
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value, ok := r[name]
+		if !ok {
+			return "", yarpcerrors.InvalidArgumentErrorf(
+				"missing value for key column %s", name)
+		}
+		parts = append(parts, fmt.Sprintf("%v", value))
+	}
+	return strings.Join(parts, "\x00"), nil
+}
+
+// clusteringKeyNames returns the names of clustering keys of the object
+func clusteringKeyNames(e *base.Definition) []string {
+	var names []string
+	for _, ck := range e.Key.ClusteringKeys {
+		names = append(names, ck.Name)
+	}
+	return names
+}
+
+// toRow converts a list of columns to a row. Byte slices are copied so that
+// the stored row does not alias the memory of the caller.
+func toRow(columns []base.Column) row {
+	r := make(row, len(columns))
+	for _, column := range columns {
+		r[column.Name] = copyValue(column.Value)
+	}
+	return r
+}
+
+func copyValue(value interface{}) interface{} {
+	if b, ok := value.([]byte); ok && b != nil {
+		c := make([]byte, len(b))
+		copy(c, b)
+		return c
+	}
+	return value
+}
+
+// getTable returns the table for the object, creating it if needed.
+// Must be called with the write lock held.
+func (c *memoryConnector) getTable(e *base.Definition) *table {
+	t, ok := c.tables[e.Name]
+	if !ok {
+		t = &table{partitions: make(map[string]map[string]row)}
+		c.tables[e.Name] = t
+	}
+	return t
+}
+
+// CreateIfNotExists creates a new row if it doesn't already exist.
+func (c *memoryConnector) CreateIfNotExists(
+	ctx context.Context,
+	e *base.Definition,
+	values []base.Column,
+) error {
+	return c.create(e, values, true)
+}
+
+// Create creates a new row, overwriting the columns of an existing row
+// with the same primary key.
+func (c *memoryConnector) Create(
+	ctx context.Context,
+	e *base.Definition,
+	values []base.Column,
+) error {
+	return c.create(e, values, false)
+}
+
+func (c *memoryConnector) create(
+	e *base.Definition,
+	values []base.Column,
+	casWrite bool,
+) error {
+	r := toRow(values)
+	pk, err := keyString(r, e.Key.PartitionKeys)
+	if err != nil {
+		return err
+	}
+	ck, err := keyString(r, clusteringKeyNames(e))
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	t := c.getTable(e)
+	partition, ok := t.partitions[pk]
+	if !ok {
+		partition = make(map[string]row)
+		t.partitions[pk] = partition
+	}
+
+	existing, ok := partition[ck]
+	if !ok {
+		partition[ck] = r
+		return nil
+	}
+	if casWrite {
+		return yarpcerrors.AlreadyExistsErrorf("item already exists")
+	}
+	for name, value := range r {
+		existing[name] = value
+	}
+	return nil
+}
+
+// Get fetches a row by its primary key. gocql.ErrNotFound is returned
+// if the row does not exist, the same as for the Cassandra connector.
+func (c *memoryConnector) Get(
+	ctx context.Context,
+	e *base.Definition,
+	keys []base.Column,
+) ([]base.Column, error) {
+	k := toRow(keys)
+	pk, err := keyString(k, e.Key.PartitionKeys)
+	if err != nil {
+		return nil, err
+	}
+	ck, err := keyString(k, clusteringKeyNames(e))
+	if err != nil {
+		return nil, err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	t, ok := c.tables[e.Name]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	r, ok := t.partitions[pk][ck]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return toColumns(e, r), nil
+}
+
+// toColumns converts a stored row to the list of columns of the object
+func toColumns(e *base.Definition, r row) []base.Column {
+	columns := make([]base.Column, 0, len(e.ColumnToType))
+	for _, name := range e.GetColumnsToRead() {
+		columns = append(columns, base.Column{
+			Name:  name,
+			Value: copyValue(r[name]),
+		})
+	}
+	return columns
+}
+
+// GetAll fetches all rows matching the given key columns. Keys are
+// usually the partition keys of the object, if no keys are provided all
+// rows of the table are returned.
+func (c *memoryConnector) GetAll(
+	ctx context.Context,
+	e *base.Definition,
+	keys []base.Column,
+) ([][]base.Column, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	rows := c.matchRows(e, toRow(keys))
+	result := make([][]base.Column, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, toColumns(e, r))
+	}
+	return result, nil
+}
+
+// GetAllIter gives an iterator over all rows matching the given key columns
+func (c *memoryConnector) GetAllIter(
+	ctx context.Context,
+	e *base.Definition,
+	keys []base.Column,
+) (orm.Iterator, error) {
+	rows, err := c.GetAll(ctx, e, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator{rows: rows}, nil
+}
+
+// matchRows returns the rows of the table whose columns are equal to the
+// given key columns, sorted by the clustering keys of the object.
+// Must be called with the lock held.
+func (c *memoryConnector) matchRows(e *base.Definition, keys row) []row {
+	t, ok := c.tables[e.Name]
+	if !ok {
+		return nil
+	}
+
+	var partitions []map[string]row
+	if pk, err := keyString(keys, e.Key.PartitionKeys); err == nil {
+		if partition, ok := t.partitions[pk]; ok {
+			partitions = append(partitions, partition)
+		}
+	} else {
+		for _, partition := range t.partitions {
+			partitions = append(partitions, partition)
+		}
+	}
+
+	var rows []row
+	for _, partition := range partitions {
+		for _, r := range partition {
+			if matches(r, keys) {
+				rows = append(rows, r)
+			}
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		// rows of different partitions are ordered by their partition keys
+		// to keep the results stable across calls
+		for _, pk := range e.Key.PartitionKeys {
+			if cmp := compare(rows[i][pk], rows[j][pk]); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		for _, ck := range e.Key.ClusteringKeys {
+			cmp := compare(rows[i][ck.Name], rows[j][ck.Name])
+			if cmp == 0 {
+				continue
+			}
+			if ck.Descending {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	return rows
+}
+
+// matches returns true if all key columns are equal in the row
+func matches(r row, keys row) bool {
+	for name, value := range keys {
+		if compare(r[name], value) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// compare compares two column values of the same column, and returns
+// a negative number, zero or a positive number if a is less than, equal
+// to or greater than b.
+func compare(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	switch av := a.(type) {
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			switch {
+			case av.Before(bv):
+				return -1
+			case av.After(bv):
+				return 1
+			}
+			return 0
+		}
+	case gocql.UUID:
+		if bv, ok := b.(gocql.UUID); ok {
+			// time based UUIDs are ordered by their timestamp in Cassandra
+			if av.Version() == 1 && bv.Version() == 1 {
+				if cmp := compare(av.Time(), bv.Time()); cmp != 0 {
+					return cmp
+				}
+			}
+			return bytes.Compare(av.Bytes(), bv.Bytes())
+		}
+	case []byte:
+		if bv, ok := b.([]byte); ok {
+			return bytes.Compare(av, bv)
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0
+			case !av:
+				return -1
+			}
+			return 1
+		}
+	}
+
+	va := reflect.ValueOf(a)
+	vb := reflect.ValueOf(b)
+	switch {
+	case isInt(va) && isInt(vb), isInt(va) && isUint(vb),
+		isUint(va) && isInt(vb), isUint(va) && isUint(vb):
+		return compareIntegers(va, vb)
+	case va.Kind() == reflect.String && vb.Kind() == reflect.String:
+		return strings.Compare(va.String(), vb.String())
+	}
+	return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// compareIntegers compares integer values of possibly different types
+func compareIntegers(a, b reflect.Value) int {
+	if isInt(a) && a.Int() < 0 || isInt(b) && b.Int() < 0 {
+		ai, bi := toInt64(a), toInt64(b)
+		switch {
+		case ai < bi:
+			return -1
+		case ai > bi:
+			return 1
+		}
+		return 0
+	}
+	au, bu := toUint64(a), toUint64(b)
+	switch {
+	case au < bu:
+		return -1
+	case au > bu:
+		return 1
+	}
+	return 0
+}
+
+func toInt64(v reflect.Value) int64 {
+	if isInt(v) {
+		return v.Int()
+	}
+	return int64(v.Uint())
+}
+
+func toUint64(v reflect.Value) uint64 {
+	if isUint(v) {
+		return v.Uint()
+	}
+	return uint64(v.Int())
+}
+
+// Update updates the given columns of a row, creating the row if it does
+// not exist yet.
+func (c *memoryConnector) Update(
+	ctx context.Context,
+	e *base.Definition,
+	values []base.Column,
+	keys []base.Column,
+) error {
+	k := toRow(keys)
+	pk, err := keyString(k, e.Key.PartitionKeys)
+	if err != nil {
+		return err
+	}
+	ck, err := keyString(k, clusteringKeyNames(e))
+	if err != nil {
+		return err
+	}
+
+	// primary key columns cannot be updated, same as in Cassandra
+	for _, column := range values {
+		if _, ok := k[column.Name]; ok {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"primary key part %s found in update", column.Name)
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	t := c.getTable(e)
+	partition, ok := t.partitions[pk]
+	if !ok {
+		partition = make(map[string]row)
+		t.partitions[pk] = partition
+	}
+	r, ok := partition[ck]
+	if !ok {
+		r = k
+		partition[ck] = r
+	}
+	for _, column := range values {
+		r[column.Name] = copyValue(column.Value)
+	}
+	return nil
+}
+
+// Delete deletes the rows matching the given key columns. Keys can be
+// either the full primary key or only the partition key, in which case
+// the whole partition is deleted.
+func (c *memoryConnector) Delete(
+	ctx context.Context,
+	e *base.Definition,
+	keys []base.Column,
+) error {
+	k := toRow(keys)
+	pk, err := keyString(k, e.Key.PartitionKeys)
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	t, ok := c.tables[e.Name]
+	if !ok {
+		return nil
+	}
+	partition, ok := t.partitions[pk]
+	if !ok {
+		return nil
+	}
+	for ck, r := range partition {
+		if matches(r, k) {
+			delete(partition, ck)
+		}
+	}
+	if len(partition) == 0 {
+		delete(t.partitions, pk)
+	}
+	return nil
+}
+
+// iterator implements orm.Iterator over rows which are already read
+type iterator struct {
+	rows [][]base.Column
+}
+
+// Next returns the next row, or nil once all rows have been returned
+func (i *iterator) Next() ([]base.Column, error) {
+	if len(i.rows) == 0 {
+		return nil, nil
+	}
+	r := i.rows[0]
+	i.rows = i.rows[1:]
+	return r, nil
+}
+
+// Close drops the remaining rows
+func (i *iterator) Close() {
+	i.rows = nil
+}
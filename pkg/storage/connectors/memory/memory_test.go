@@ -0,0 +1,271 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+	"github.com/uber/peloton/pkg/storage/orm"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// testDefinition is a table with partition key "id" only
+var testDefinition = &base.Definition{
+	Name: "test_table",
+	Key: &base.PrimaryKey{
+		PartitionKeys: []string{"id"},
+	},
+	ColumnToType: map[string]reflect.Type{
+		"id":   reflect.TypeOf(1),
+		"data": reflect.TypeOf("data"),
+		"name": reflect.TypeOf("name"),
+	},
+}
+
+// testDefinitionWithCK is a table with partition key "id" and
+// descending clustering key "ck"
+var testDefinitionWithCK = &base.Definition{
+	Name: "test_table_ck",
+	Key: &base.PrimaryKey{
+		PartitionKeys: []string{"id"},
+		ClusteringKeys: []*base.ClusteringKey{
+			{
+				Name:       "ck",
+				Descending: true,
+			},
+		},
+	},
+	ColumnToType: map[string]reflect.Type{
+		"id":   reflect.TypeOf(1),
+		"ck":   reflect.TypeOf(1),
+		"data": reflect.TypeOf("data"),
+	},
+}
+
+var testRow = []base.Column{
+	{Name: "id", Value: uint64(1)},
+	{Name: "name", Value: "test"},
+	{Name: "data", Value: "testdata"},
+}
+
+var keyRow = []base.Column{
+	{Name: "id", Value: uint64(1)},
+}
+
+func rowWithCK(id, ck uint64, data string) []base.Column {
+	return []base.Column{
+		{Name: "id", Value: id},
+		{Name: "ck", Value: ck},
+		{Name: "data", Value: data},
+	}
+}
+
+func columnValue(row []base.Column, name string) interface{} {
+	for _, col := range row {
+		if col.Name == name {
+			return col.Value
+		}
+	}
+	return nil
+}
+
+type MemoryConnSuite struct {
+	suite.Suite
+
+	ctx       context.Context
+	connector orm.Connector
+}
+
+func (suite *MemoryConnSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.connector = NewMemoryConnector()
+}
+
+func TestMemoryConnSuite(t *testing.T) {
+	suite.Run(t, new(MemoryConnSuite))
+}
+
+// TestCreateGetDelete creates a row, reads it back, deletes it and
+// verifies that the row was deleted
+func (suite *MemoryConnSuite) TestCreateGetDelete() {
+	suite.NoError(suite.connector.Create(suite.ctx, testDefinition, testRow))
+
+	row, err := suite.connector.Get(suite.ctx, testDefinition, keyRow)
+	suite.NoError(err)
+	suite.Len(row, 3)
+	suite.Equal("test", columnValue(row, "name"))
+	suite.Equal("testdata", columnValue(row, "data"))
+
+	suite.NoError(suite.connector.Delete(suite.ctx, testDefinition, keyRow))
+
+	_, err = suite.connector.Get(suite.ctx, testDefinition, keyRow)
+	suite.Equal(gocql.ErrNotFound, err)
+
+	// deleting a row which does not exist is a noop
+	suite.NoError(suite.connector.Delete(suite.ctx, testDefinition, keyRow))
+}
+
+// TestGetMissingKey tests that reads fail if a key column is missing
+func (suite *MemoryConnSuite) TestGetMissingKey() {
+	_, err := suite.connector.Get(suite.ctx, testDefinition, nil)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+
+	_, err = suite.connector.Get(suite.ctx, testDefinitionWithCK, keyRow)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestCreateUpdateGet tests that update only changes the given columns
+func (suite *MemoryConnSuite) TestCreateUpdateGet() {
+	suite.NoError(suite.connector.Create(suite.ctx, testDefinition, testRow))
+
+	err := suite.connector.Update(
+		suite.ctx,
+		testDefinition,
+		[]base.Column{{Name: "name", Value: "test-update"}},
+		keyRow)
+	suite.NoError(err)
+
+	row, err := suite.connector.Get(suite.ctx, testDefinition, keyRow)
+	suite.NoError(err)
+	suite.Equal("test-update", columnValue(row, "name"))
+	suite.Equal("testdata", columnValue(row, "data"))
+
+	// primary key columns cannot be updated
+	err = suite.connector.Update(suite.ctx, testDefinition, testRow, keyRow)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestUpdateCreatesRow tests that update of a missing row creates it
+func (suite *MemoryConnSuite) TestUpdateCreatesRow() {
+	err := suite.connector.Update(
+		suite.ctx,
+		testDefinition,
+		[]base.Column{{Name: "name", Value: "test"}},
+		keyRow)
+	suite.NoError(err)
+
+	row, err := suite.connector.Get(suite.ctx, testDefinition, keyRow)
+	suite.NoError(err)
+	suite.Equal(uint64(1), columnValue(row, "id"))
+	suite.Equal("test", columnValue(row, "name"))
+	suite.Nil(columnValue(row, "data"))
+}
+
+// TestCreateIfNotExists tests that a row is only created once
+func (suite *MemoryConnSuite) TestCreateIfNotExists() {
+	suite.NoError(
+		suite.connector.CreateIfNotExists(suite.ctx, testDefinition, testRow))
+
+	err := suite.connector.CreateIfNotExists(
+		suite.ctx, testDefinition, testRow)
+	suite.True(yarpcerrors.IsAlreadyExists(err))
+
+	// plain create overwrites the row
+	suite.NoError(suite.connector.Create(suite.ctx, testDefinition, testRow))
+}
+
+// TestCreateGetAll tests that GetAll returns the rows of a partition
+// sorted by the clustering key
+func (suite *MemoryConnSuite) TestCreateGetAll() {
+	for _, row := range [][]base.Column{
+		rowWithCK(1, 10, "testdata10"),
+		rowWithCK(1, 30, "testdata30"),
+		rowWithCK(1, 20, "testdata20"),
+		rowWithCK(2, 10, "otherdata"),
+	} {
+		suite.NoError(
+			suite.connector.Create(suite.ctx, testDefinitionWithCK, row))
+	}
+
+	rows, err := suite.connector.GetAll(
+		suite.ctx, testDefinitionWithCK, keyRow)
+	suite.NoError(err)
+	suite.Len(rows, 3)
+	suite.Equal("testdata30", columnValue(rows[0], "data"))
+	suite.Equal("testdata20", columnValue(rows[1], "data"))
+	suite.Equal("testdata10", columnValue(rows[2], "data"))
+
+	// without keys all rows of the table are returned
+	rows, err = suite.connector.GetAll(suite.ctx, testDefinitionWithCK, nil)
+	suite.NoError(err)
+	suite.Len(rows, 4)
+
+	// deleting by partition key removes the whole partition
+	suite.NoError(
+		suite.connector.Delete(suite.ctx, testDefinitionWithCK, keyRow))
+	rows, err = suite.connector.GetAll(
+		suite.ctx, testDefinitionWithCK, keyRow)
+	suite.NoError(err)
+	suite.Empty(rows)
+}
+
+// TestCreateGetAllIter tests iterating over the rows of a partition
+func (suite *MemoryConnSuite) TestCreateGetAllIter() {
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDefinitionWithCK, rowWithCK(1, 10, "testdata10")))
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDefinitionWithCK, rowWithCK(1, 20, "testdata20")))
+
+	iter, err := suite.connector.GetAllIter(
+		suite.ctx, testDefinitionWithCK, keyRow)
+	suite.NoError(err)
+	defer iter.Close()
+
+	var data []interface{}
+	for {
+		row, err := iter.Next()
+		suite.NoError(err)
+		if row == nil {
+			break
+		}
+		data = append(data, columnValue(row, "data"))
+	}
+	suite.Equal([]interface{}{"testdata20", "testdata10"}, data)
+}
+
+// TestReturnedRowsAreCopies tests that modifying a row read from the
+// connector does not change the stored row
+func (suite *MemoryConnSuite) TestReturnedRowsAreCopies() {
+	row := []base.Column{
+		{Name: "id", Value: uint64(1)},
+		{Name: "data", Value: []byte("data")},
+	}
+	suite.NoError(suite.connector.Create(suite.ctx, testDefinition, row))
+	row[1].Value.([]byte)[0] = 'x'
+
+	result, err := suite.connector.Get(suite.ctx, testDefinition, keyRow)
+	suite.NoError(err)
+	suite.Equal([]byte("data"), columnValue(result, "data"))
+}
+
+// TestCompare tests ordering of column values
+func (suite *MemoryConnSuite) TestCompare() {
+	suite.Equal(0, compare(uint32(1), int(1)))
+	suite.Equal(-1, compare(int64(-1), uint64(1)))
+	suite.Equal(1, compare("b", "a"))
+	suite.Equal(-1, compare(nil, "a"))
+	suite.Equal(-1, compare(false, true))
+
+	first := gocql.TimeUUID()
+	second := gocql.UUIDFromTime(first.Time().Add(time.Second))
+	suite.Equal(-1, compare(first, second))
+}
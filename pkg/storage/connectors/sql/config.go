@@ -0,0 +1,62 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+
+	// Pull in the SQL drivers for migrate
+	_ "github.com/gemnasium/migrate/driver/mysql"
+	_ "github.com/gemnasium/migrate/driver/postgres"
+	_ "github.com/gemnasium/migrate/driver/sqlite3"
+	"github.com/gemnasium/migrate/migrate"
+	log "github.com/sirupsen/logrus"
+)
+
+// Config is the config for the SQL connector
+type Config struct {
+	// Driver is the database driver, one of postgres, mysql or sqlite3
+	Driver string `yaml:"driver"`
+	// DataSource is the driver specific data source name without the
+	// scheme, e.g. user:password@host:5432/peloton?sslmode=disable for
+	// postgres, user:password@tcp(host:3306)/peloton?parseTime=true for
+	// mysql, or the path of the database file for sqlite3.
+	// The mysql data source must set parseTime=true.
+	DataSource string `yaml:"data_source"`
+	// Migrations is the directory with the schema migrations of the driver
+	Migrations string `yaml:"migrations"`
+	// MaxOpenConns is the maximum number of open connections to the
+	// database, unlimited if 0
+	MaxOpenConns int `yaml:"max_open_conns"`
+	// MaxIdleConns is the maximum number of idle connections kept open,
+	// the driver default is used if 0
+	MaxIdleConns int `yaml:"max_idle_conns"`
+}
+
+// AutoMigrate migrates the db schemas for the SQL database
+func (c *Config) AutoMigrate() []error {
+	errs, ok := migrate.UpSync(c.MigrateString(), c.Migrations)
+	log.Infof("UpSync complete")
+	if !ok {
+		log.Errorf("UpSync failed with errors: %v", errs)
+		return errs
+	}
+	return nil
+}
+
+// MigrateString returns the db string required for database migration
+func (c *Config) MigrateString() string {
+	return fmt.Sprintf("%s://%s", c.Driver, c.DataSource)
+}
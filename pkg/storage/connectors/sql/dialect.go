@@ -0,0 +1,252 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	// Postgres is the driver name for PostgreSQL
+	Postgres = "postgres"
+	// MySQL is the driver name for MySQL
+	MySQL = "mysql"
+	// SQLite is the driver name for SQLite
+	SQLite = "sqlite3"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(gocql.UUID{})
+)
+
+// dialect hides the differences in SQL syntax between the supported
+// databases
+type dialect interface {
+	// bindVar returns the placeholder for the i-th (0 based) query argument
+	bindVar(i int) string
+	// quote quotes an identifier
+	quote(name string) string
+	// columnType returns the column type used to store a Go type,
+	// key columns may need a bounded type to be indexed
+	columnType(typ reflect.Type, key bool) (string, error)
+	// upsertClause returns the clause which turns an insert into an
+	// overwrite of the value columns if the row already exists
+	upsertClause(keys, values []string) string
+	// ignoreClause returns the clause which turns an insert into a no-op
+	// if the row already exists
+	ignoreClause(keys []string) string
+	// dataSourceName returns the data source name passed to the driver
+	dataSourceName(dataSource string) string
+}
+
+// getDialect returns the dialect of the given driver
+func getDialect(driver string) (dialect, error) {
+	switch driver {
+	case Postgres:
+		return postgresDialect{}, nil
+	case MySQL:
+		return mysqlDialect{}, nil
+	case SQLite:
+		return sqliteDialect{}, nil
+	}
+	return nil, fmt.Errorf("unsupported sql driver %q", driver)
+}
+
+// quoteIdentifier quotes an identifier with the given quote character
+func quoteIdentifier(name string, q string) string {
+	return q + strings.Replace(name, q, q+q, -1) + q
+}
+
+// excludedAssignments returns the assignments of columns to the values
+// proposed for insertion, for ON CONFLICT clauses
+func excludedAssignments(d dialect, values []string) string {
+	assignments := make([]string, len(values))
+	for i, v := range values {
+		assignments[i] = fmt.Sprintf("%s = excluded.%s", d.quote(v), d.quote(v))
+	}
+	return strings.Join(assignments, ", ")
+}
+
+// quoteAll quotes a list of identifiers
+func quoteAll(d dialect, names []string) []string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = d.quote(n)
+	}
+	return quoted
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) bindVar(i int) string {
+	return fmt.Sprintf("$%d", i+1)
+}
+
+func (postgresDialect) quote(name string) string {
+	return quoteIdentifier(name, `"`)
+}
+
+func (postgresDialect) columnType(typ reflect.Type, key bool) (string, error) {
+	switch typ {
+	case timeType:
+		return "TIMESTAMP WITH TIME ZONE", nil
+	case uuidType:
+		return "TEXT", nil
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return "TEXT", nil
+	case reflect.Int, reflect.Int32, reflect.Int64,
+		reflect.Uint32, reflect.Uint64:
+		return "BIGINT", nil
+	case reflect.Bool:
+		return "BOOLEAN", nil
+	case reflect.Slice:
+		return "BYTEA", nil
+	}
+	return "", fmt.Errorf("unsupported column type %v", typ)
+}
+
+func (d postgresDialect) upsertClause(keys, values []string) string {
+	if len(values) == 0 {
+		return d.ignoreClause(keys)
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s",
+		strings.Join(quoteAll(d, keys), ", "), excludedAssignments(d, values))
+}
+
+func (d postgresDialect) ignoreClause(keys []string) string {
+	return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING",
+		strings.Join(quoteAll(d, keys), ", "))
+}
+
+func (postgresDialect) dataSourceName(dataSource string) string {
+	return Postgres + "://" + dataSource
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) bindVar(i int) string {
+	return "?"
+}
+
+func (mysqlDialect) quote(name string) string {
+	return quoteIdentifier(name, "`")
+}
+
+func (mysqlDialect) columnType(typ reflect.Type, key bool) (string, error) {
+	switch typ {
+	case timeType:
+		return "DATETIME(6)", nil
+	case uuidType:
+		return "VARCHAR(64)", nil
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		// MySQL can only index text columns with a bounded length
+		if key {
+			return "VARCHAR(255)", nil
+		}
+		return "LONGTEXT", nil
+	case reflect.Int, reflect.Int32, reflect.Int64,
+		reflect.Uint32, reflect.Uint64:
+		return "BIGINT", nil
+	case reflect.Bool:
+		return "BOOLEAN", nil
+	case reflect.Slice:
+		if key {
+			return "VARBINARY(255)", nil
+		}
+		return "LONGBLOB", nil
+	}
+	return "", fmt.Errorf("unsupported column type %v", typ)
+}
+
+func (d mysqlDialect) upsertClause(keys, values []string) string {
+	if len(values) == 0 {
+		return d.ignoreClause(keys)
+	}
+	assignments := make([]string, len(values))
+	for i, v := range values {
+		assignments[i] = fmt.Sprintf("%s = VALUES(%s)", d.quote(v), d.quote(v))
+	}
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+}
+
+// ignoreClause uses a no-op assignment rather than INSERT IGNORE, which
+// would also silence errors other than duplicate keys. MySQL reports
+// zero affected rows if the row is left unchanged.
+func (d mysqlDialect) ignoreClause(keys []string) string {
+	k := d.quote(keys[0])
+	return fmt.Sprintf(" ON DUPLICATE KEY UPDATE %s = %s", k, k)
+}
+
+func (mysqlDialect) dataSourceName(dataSource string) string {
+	return dataSource
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) bindVar(i int) string {
+	return "?"
+}
+
+func (sqliteDialect) quote(name string) string {
+	return quoteIdentifier(name, `"`)
+}
+
+func (sqliteDialect) columnType(typ reflect.Type, key bool) (string, error) {
+	switch typ {
+	case timeType:
+		return "TIMESTAMP", nil
+	case uuidType:
+		return "TEXT", nil
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return "TEXT", nil
+	case reflect.Int, reflect.Int32, reflect.Int64,
+		reflect.Uint32, reflect.Uint64:
+		return "INTEGER", nil
+	case reflect.Bool:
+		return "BOOLEAN", nil
+	case reflect.Slice:
+		return "BLOB", nil
+	}
+	return "", fmt.Errorf("unsupported column type %v", typ)
+}
+
+func (d sqliteDialect) upsertClause(keys, values []string) string {
+	if len(values) == 0 {
+		return d.ignoreClause(keys)
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s",
+		strings.Join(quoteAll(d, keys), ", "), excludedAssignments(d, values))
+}
+
+func (d sqliteDialect) ignoreClause(keys []string) string {
+	return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING",
+		strings.Join(quoteAll(d, keys), ", "))
+}
+
+func (sqliteDialect) dataSourceName(dataSource string) string {
+	return dataSource
+}
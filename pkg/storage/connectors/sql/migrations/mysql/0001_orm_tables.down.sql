@@ -0,0 +1,9 @@
+DROP TABLE `secret_info`;
+DROP TABLE `pod_events`;
+DROP TABLE `active_pipelines`;
+DROP TABLE `pipelines`;
+DROP TABLE `job_runtime`;
+DROP TABLE `job_name_to_id`;
+DROP TABLE `job_index`;
+DROP TABLE `job_config`;
+DROP TABLE `cron_jobs`;
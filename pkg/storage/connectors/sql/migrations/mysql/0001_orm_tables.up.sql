@@ -0,0 +1,116 @@
+-- Tables of the storage objects, the primary key of each table is made of
+-- the partition keys followed by the clustering keys of the object.
+
+CREATE TABLE `cron_jobs` (
+  `shard_id` BIGINT NOT NULL,
+  `name` VARCHAR(255) NOT NULL,
+  `creation_time` DATETIME(6),
+  `spec` LONGBLOB,
+  `status` LONGBLOB,
+  `update_time` DATETIME(6),
+  PRIMARY KEY (`shard_id`, `name`)
+);
+
+CREATE INDEX `cron_jobs_clustering_order` ON `cron_jobs` (`shard_id`, `name` DESC);
+
+CREATE TABLE `job_config` (
+  `job_id` VARCHAR(255) NOT NULL,
+  `version` BIGINT NOT NULL,
+  `config` LONGBLOB,
+  `config_addon` LONGBLOB,
+  `creation_time` DATETIME(6),
+  PRIMARY KEY (`job_id`, `version`)
+);
+
+CREATE INDEX `job_config_clustering_order` ON `job_config` (`job_id`, `version` DESC);
+
+CREATE TABLE `job_index` (
+  `job_id` VARCHAR(255) NOT NULL,
+  `completion_time` DATETIME(6),
+  `config` LONGTEXT,
+  `creation_time` DATETIME(6),
+  `instance_count` BIGINT,
+  `job_type` BIGINT,
+  `labels` LONGTEXT,
+  `name` LONGTEXT,
+  `owner` LONGTEXT,
+  `respool_id` LONGTEXT,
+  `runtime_info` LONGTEXT,
+  `sla` LONGTEXT,
+  `start_time` DATETIME(6),
+  `state` LONGTEXT,
+  `update_time` DATETIME(6),
+  PRIMARY KEY (`job_id`)
+);
+
+CREATE TABLE `job_name_to_id` (
+  `job_name` VARCHAR(255) NOT NULL,
+  `update_time` VARCHAR(64) NOT NULL,
+  `job_id` LONGTEXT,
+  PRIMARY KEY (`job_name`, `update_time`)
+);
+
+CREATE INDEX `job_name_to_id_clustering_order` ON `job_name_to_id` (`job_name`, `update_time` DESC);
+
+CREATE TABLE `job_runtime` (
+  `job_id` VARCHAR(255) NOT NULL,
+  `runtime_info` LONGBLOB,
+  `state` LONGTEXT,
+  `update_time` DATETIME(6),
+  PRIMARY KEY (`job_id`)
+);
+
+CREATE TABLE `pipelines` (
+  `pipeline_id` VARCHAR(255) NOT NULL,
+  `creation_time` DATETIME(6),
+  `name` LONGTEXT,
+  `spec` LONGBLOB,
+  `state` LONGTEXT,
+  `status` LONGBLOB,
+  `update_time` DATETIME(6),
+  PRIMARY KEY (`pipeline_id`)
+);
+
+CREATE TABLE `active_pipelines` (
+  `shard_id` BIGINT NOT NULL,
+  `pipeline_id` VARCHAR(255) NOT NULL,
+  PRIMARY KEY (`shard_id`, `pipeline_id`)
+);
+
+CREATE INDEX `active_pipelines_clustering_order` ON `active_pipelines` (`shard_id`, `pipeline_id` DESC);
+
+CREATE TABLE `pod_events` (
+  `job_id` VARCHAR(255) NOT NULL,
+  `instance_id` BIGINT NOT NULL,
+  `run_id` BIGINT NOT NULL,
+  `update_time` VARCHAR(64) NOT NULL,
+  `actual_state` LONGTEXT,
+  `agent_id` LONGTEXT,
+  `config_version` BIGINT,
+  `desired_config_version` BIGINT,
+  `desired_run_id` BIGINT,
+  `goal_state` LONGTEXT,
+  `healthy` LONGTEXT,
+  `hostname` LONGTEXT,
+  `message` LONGTEXT,
+  `pod_status` LONGBLOB,
+  `previous_run_id` BIGINT,
+  `reason` LONGTEXT,
+  `volumeid` LONGTEXT,
+  PRIMARY KEY (`job_id`, `instance_id`, `run_id`, `update_time`)
+);
+
+CREATE INDEX `pod_events_clustering_order` ON `pod_events` (`job_id`, `instance_id`, `run_id` DESC, `update_time` DESC);
+
+CREATE TABLE `secret_info` (
+  `secret_id` VARCHAR(255) NOT NULL,
+  `valid` BOOLEAN NOT NULL,
+  `creation_time` DATETIME(6),
+  `data` LONGTEXT,
+  `job_id` LONGTEXT,
+  `path` LONGTEXT,
+  `version` BIGINT,
+  PRIMARY KEY (`secret_id`, `valid`)
+);
+
+CREATE INDEX `secret_info_clustering_order` ON `secret_info` (`secret_id`, `valid` DESC);
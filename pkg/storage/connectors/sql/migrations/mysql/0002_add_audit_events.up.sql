@@ -0,0 +1,18 @@
+CREATE TABLE `audit_events` (
+  `day` VARCHAR(255) NOT NULL,
+  `event_time` DATETIME(6) NOT NULL,
+  `event_id` VARCHAR(255) NOT NULL,
+  `caller` LONGTEXT,
+  `job_ids` LONGTEXT,
+  `latency_ms` BIGINT,
+  `message` LONGTEXT,
+  `outcome` LONGTEXT,
+  `pod_names` LONGTEXT,
+  `procedure` LONGTEXT,
+  `request_digest` LONGTEXT,
+  `respool_ids` LONGTEXT,
+  `user_name` LONGTEXT,
+  PRIMARY KEY (`day`, `event_time`, `event_id`)
+);
+
+CREATE INDEX `audit_events_clustering_order` ON `audit_events` (`day`, `event_time` DESC, `event_id` DESC);
@@ -0,0 +1,10 @@
+CREATE TABLE `maintenance_windows` (
+  `shard_id` BIGINT NOT NULL,
+  `window_id` VARCHAR(255) NOT NULL,
+  `creation_time` DATETIME(6),
+  `update_time` DATETIME(6),
+  `window` LONGBLOB,
+  PRIMARY KEY (`shard_id`, `window_id`)
+);
+
+CREATE INDEX `maintenance_windows_clustering_order` ON `maintenance_windows` (`shard_id`, `window_id` DESC);
@@ -0,0 +1 @@
+DROP TABLE "maintenance_windows";
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE "maintenance_windows" (
+  "shard_id" BIGINT NOT NULL,
+  "window_id" TEXT NOT NULL,
+  "creation_time" TIMESTAMP WITH TIME ZONE,
+  "update_time" TIMESTAMP WITH TIME ZONE,
+  "window" BYTEA,
+  PRIMARY KEY ("shard_id", "window_id")
+);
+
+CREATE INDEX "maintenance_windows_clustering_order" ON "maintenance_windows" ("shard_id", "window_id" DESC);
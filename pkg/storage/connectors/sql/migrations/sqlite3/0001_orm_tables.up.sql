@@ -0,0 +1,116 @@
+-- Tables of the storage objects, the primary key of each table is made of
+-- the partition keys followed by the clustering keys of the object.
+
+CREATE TABLE "cron_jobs" (
+  "shard_id" INTEGER NOT NULL,
+  "name" TEXT NOT NULL,
+  "creation_time" TIMESTAMP,
+  "spec" BLOB,
+  "status" BLOB,
+  "update_time" TIMESTAMP,
+  PRIMARY KEY ("shard_id", "name")
+);
+
+CREATE INDEX "cron_jobs_clustering_order" ON "cron_jobs" ("shard_id", "name" DESC);
+
+CREATE TABLE "job_config" (
+  "job_id" TEXT NOT NULL,
+  "version" INTEGER NOT NULL,
+  "config" BLOB,
+  "config_addon" BLOB,
+  "creation_time" TIMESTAMP,
+  PRIMARY KEY ("job_id", "version")
+);
+
+CREATE INDEX "job_config_clustering_order" ON "job_config" ("job_id", "version" DESC);
+
+CREATE TABLE "job_index" (
+  "job_id" TEXT NOT NULL,
+  "completion_time" TIMESTAMP,
+  "config" TEXT,
+  "creation_time" TIMESTAMP,
+  "instance_count" INTEGER,
+  "job_type" INTEGER,
+  "labels" TEXT,
+  "name" TEXT,
+  "owner" TEXT,
+  "respool_id" TEXT,
+  "runtime_info" TEXT,
+  "sla" TEXT,
+  "start_time" TIMESTAMP,
+  "state" TEXT,
+  "update_time" TIMESTAMP,
+  PRIMARY KEY ("job_id")
+);
+
+CREATE TABLE "job_name_to_id" (
+  "job_name" TEXT NOT NULL,
+  "update_time" TEXT NOT NULL,
+  "job_id" TEXT,
+  PRIMARY KEY ("job_name", "update_time")
+);
+
+CREATE INDEX "job_name_to_id_clustering_order" ON "job_name_to_id" ("job_name", "update_time" DESC);
+
+CREATE TABLE "job_runtime" (
+  "job_id" TEXT NOT NULL,
+  "runtime_info" BLOB,
+  "state" TEXT,
+  "update_time" TIMESTAMP,
+  PRIMARY KEY ("job_id")
+);
+
+CREATE TABLE "pipelines" (
+  "pipeline_id" TEXT NOT NULL,
+  "creation_time" TIMESTAMP,
+  "name" TEXT,
+  "spec" BLOB,
+  "state" TEXT,
+  "status" BLOB,
+  "update_time" TIMESTAMP,
+  PRIMARY KEY ("pipeline_id")
+);
+
+CREATE TABLE "active_pipelines" (
+  "shard_id" INTEGER NOT NULL,
+  "pipeline_id" TEXT NOT NULL,
+  PRIMARY KEY ("shard_id", "pipeline_id")
+);
+
+CREATE INDEX "active_pipelines_clustering_order" ON "active_pipelines" ("shard_id", "pipeline_id" DESC);
+
+CREATE TABLE "pod_events" (
+  "job_id" TEXT NOT NULL,
+  "instance_id" INTEGER NOT NULL,
+  "run_id" INTEGER NOT NULL,
+  "update_time" TEXT NOT NULL,
+  "actual_state" TEXT,
+  "agent_id" TEXT,
+  "config_version" INTEGER,
+  "desired_config_version" INTEGER,
+  "desired_run_id" INTEGER,
+  "goal_state" TEXT,
+  "healthy" TEXT,
+  "hostname" TEXT,
+  "message" TEXT,
+  "pod_status" BLOB,
+  "previous_run_id" INTEGER,
+  "reason" TEXT,
+  "volumeid" TEXT,
+  PRIMARY KEY ("job_id", "instance_id", "run_id", "update_time")
+);
+
+CREATE INDEX "pod_events_clustering_order" ON "pod_events" ("job_id", "instance_id", "run_id" DESC, "update_time" DESC);
+
+CREATE TABLE "secret_info" (
+  "secret_id" TEXT NOT NULL,
+  "valid" BOOLEAN NOT NULL,
+  "creation_time" TIMESTAMP,
+  "data" TEXT,
+  "job_id" TEXT,
+  "path" TEXT,
+  "version" INTEGER,
+  PRIMARY KEY ("secret_id", "valid")
+);
+
+CREATE INDEX "secret_info_clustering_order" ON "secret_info" ("secret_id", "valid" DESC);
@@ -0,0 +1,18 @@
+CREATE TABLE "audit_events" (
+  "day" TEXT NOT NULL,
+  "event_time" TIMESTAMP NOT NULL,
+  "event_id" TEXT NOT NULL,
+  "caller" TEXT,
+  "job_ids" TEXT,
+  "latency_ms" INTEGER,
+  "message" TEXT,
+  "outcome" TEXT,
+  "pod_names" TEXT,
+  "procedure" TEXT,
+  "request_digest" TEXT,
+  "respool_ids" TEXT,
+  "user_name" TEXT,
+  PRIMARY KEY ("day", "event_time", "event_id")
+);
+
+CREATE INDEX "audit_events_clustering_order" ON "audit_events" ("day", "event_time" DESC, "event_id" DESC);
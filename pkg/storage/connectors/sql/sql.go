@@ -0,0 +1,552 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+	"github.com/uber/peloton/pkg/storage/orm"
+
+	"github.com/gocql/gocql"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+
+	// Pull in the SQL drivers
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// operation tags for metrics
+	create  = "create"
+	cas     = "cas"
+	get     = "get"
+	getIter = "get_iter"
+	update  = "update"
+	del     = "delete"
+
+	useCasWrite = true
+)
+
+type sqlConnector struct {
+	// implements orm.Connector interface
+	orm.Connector
+	// DB is the database handle created for this connector
+	DB *dbsql.DB
+	// dialect of the database
+	dialect dialect
+	// scope is the storage scope for metrics
+	scope tally.Scope
+	// scope is the storage scope for success metrics
+	executeSuccessScope tally.Scope
+	// scope is the storage scope for failure metrics
+	executeFailScope tally.Scope
+	// drainRows reads all the rows of an iterator before returning it,
+	// so that the connection is released for the queries issued while
+	// iterating
+	drainRows bool
+}
+
+// NewSQLConnector initializes a SQL Connector
+func NewSQLConnector(config *Config, scope tally.Scope) (
+	orm.Connector, error) {
+	d, err := getDialect(config.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := dbsql.Open(config.Driver, d.dataSourceName(config.DataSource))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	if config.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	c := newSQLConnector(db, d, scope)
+	if config.Driver == SQLite {
+		// sqlite allows a single writer, and each connection to an
+		// in-memory database gets a database of its own. With a single
+		// connection, a query issued while iterating would wait forever
+		// for the connection held by the rows of the iterator.
+		db.SetMaxOpenConns(1)
+		c.drainRows = true
+	}
+	return c, nil
+}
+
+func newSQLConnector(
+	db *dbsql.DB,
+	d dialect,
+	scope tally.Scope,
+) *sqlConnector {
+	storeScope := scope.SubScope("sql")
+	return &sqlConnector{
+		DB:      db,
+		dialect: d,
+		scope:   storeScope,
+		executeSuccessScope: storeScope.Tagged(
+			map[string]string{"result": "success"}),
+		executeFailScope: storeScope.Tagged(
+			map[string]string{"result": "fail"}),
+	}
+}
+
+// ensure that implementation (sqlConnector) satisfies the interface
+var _ orm.Connector = (*sqlConnector)(nil)
+
+// uuidTimeFormat is the width of the timestamp prefixed to stored UUIDs
+const uuidTimeFormat = "%016x"
+
+// encodeUUID encodes a UUID as text prefixed with its timestamp, so that
+// time based UUIDs sort by time as Cassandra timeuuid columns do
+func encodeUUID(u gocql.UUID) string {
+	var ts int64
+	if u.Version() == 1 {
+		ts = u.Timestamp()
+	}
+	return fmt.Sprintf(uuidTimeFormat+":%s", ts, u.String())
+}
+
+// decodeUUID decodes a UUID encoded by encodeUUID
+func decodeUUID(s string) (gocql.UUID, error) {
+	return gocql.ParseUUID(s[strings.LastIndex(s, ":")+1:])
+}
+
+// toDriverValue converts a column value to a type supported by the
+// database drivers
+func toDriverValue(v interface{}) interface{} {
+	switch rv := v.(type) {
+	case gocql.UUID:
+		return encodeUUID(rv)
+	case time.Time:
+		return rv.UTC()
+	case int:
+		return int64(rv)
+	case int32:
+		return int64(rv)
+	case uint32:
+		return int64(rv)
+	case uint64:
+		// stored as a signed integer, the bits are preserved when the
+		// value is converted back to uint64
+		return int64(rv)
+	}
+	return v
+}
+
+// splitColumnNameValue is used to return list of column names and list of
+// their corresponding driver values, in the same order
+func splitColumnNameValue(row []base.Column) (
+	colNames []string, colValues []interface{}) {
+	for _, column := range row {
+		colNames = append(colNames, column.Name)
+		colValues = append(colValues, toDriverValue(column.Value))
+	}
+	return colNames, colValues
+}
+
+// buildResultRow is used to allocate memory for the row to be populated by
+// a read operation based on what object fields are being read
+func buildResultRow(e *base.Definition, columns []string) []interface{} {
+	results := make([]interface{}, len(columns))
+	for i, column := range columns {
+		typ := e.ColumnToType[column]
+
+		switch typ {
+		case timeType:
+			var value *time.Time
+			results[i] = &value
+			continue
+		case uuidType:
+			results[i] = &dbsql.NullString{}
+			continue
+		}
+
+		switch typ.Kind() {
+		case reflect.String:
+			results[i] = &dbsql.NullString{}
+		case reflect.Int, reflect.Int32, reflect.Int64,
+			reflect.Uint32, reflect.Uint64:
+			results[i] = &dbsql.NullInt64{}
+		case reflect.Bool:
+			results[i] = &dbsql.NullBool{}
+		case reflect.Slice:
+			var value []byte
+			results[i] = &value
+		default:
+			// This should only happen if we start using a new type
+			// without adding to the translation layer
+			log.WithFields(log.Fields{"type": typ.Kind(), "column": column}).
+				Infof("type not found")
+			var value interface{}
+			results[i] = &value
+		}
+	}
+	return results
+}
+
+// getRowFromResult translates a row read from the database into a list of
+// base.Column to be interpreted by base store client
+func getRowFromResult(
+	e *base.Definition, columnNames []string, columnVals []interface{},
+) ([]base.Column, error) {
+	row := make([]base.Column, 0, len(columnNames))
+	for i, columnName := range columnNames {
+		column := base.Column{
+			Name: columnName,
+		}
+
+		switch rv := columnVals[i].(type) {
+		case *dbsql.NullString:
+			if !rv.Valid {
+				break
+			}
+			if e.ColumnToType[columnName] != uuidType {
+				column.Value = rv.String
+				break
+			}
+			u, err := decodeUUID(rv.String)
+			if err != nil {
+				return nil, err
+			}
+			column.Value = u
+		case *dbsql.NullInt64:
+			if rv.Valid {
+				column.Value = rv.Int64
+			}
+		case *dbsql.NullBool:
+			if rv.Valid {
+				column.Value = rv.Bool
+			}
+		case **time.Time:
+			if *rv != nil {
+				column.Value = **rv
+			}
+		case *[]byte:
+			if *rv != nil {
+				column.Value = *rv
+			}
+		case *interface{}:
+			column.Value = *rv
+		}
+		row = append(row, column)
+	}
+	return row, nil
+}
+
+// CreateIfNotExists creates a new row in DB if it already doesn't exist.
+func (c *sqlConnector) CreateIfNotExists(
+	ctx context.Context,
+	e *base.Definition,
+	row []base.Column,
+) error {
+	return c.create(ctx, e, row, useCasWrite)
+}
+
+// Create creates a new row in DB, overwriting an existing row as a
+// Cassandra insert does.
+func (c *sqlConnector) Create(
+	ctx context.Context,
+	e *base.Definition,
+	row []base.Column,
+) error {
+	return c.create(ctx, e, row, !useCasWrite)
+}
+
+func (c *sqlConnector) create(
+	ctx context.Context,
+	e *base.Definition,
+	row []base.Column,
+	casWrite bool,
+) error {
+	colNames, colValues := splitColumnNameValue(row)
+	stmt := insertStmt(c.dialect, e, colNames, casWrite)
+
+	operation := create
+	if casWrite {
+		operation = cas
+	}
+
+	start := time.Now()
+	result, err := c.DB.ExecContext(ctx, stmt, colValues...)
+	if err != nil {
+		sendCounters(c.executeFailScope, e.Name, operation, err)
+		return err
+	}
+	if casWrite {
+		applied, err := result.RowsAffected()
+		if err != nil {
+			sendCounters(c.executeFailScope, e.Name, operation, err)
+			return err
+		}
+		if applied == 0 {
+			return yarpcerrors.AlreadyExistsErrorf("item already exists")
+		}
+	}
+
+	sendLatency(c.scope, e.Name, operation, time.Since(start))
+	sendCounters(c.executeSuccessScope, e.Name, operation, nil)
+	return nil
+}
+
+// Get fetches a record from DB using primary keys
+func (c *sqlConnector) Get(
+	ctx context.Context,
+	e *base.Definition,
+	keyCols []base.Column,
+) ([]base.Column, error) {
+	colNamesToRead := e.GetColumnsToRead()
+	keyColNames, keyColValues := splitColumnNameValue(keyCols)
+	stmt := selectStmt(c.dialect, e, colNamesToRead, keyColNames)
+
+	start := time.Now()
+	result := buildResultRow(e, colNamesToRead)
+	err := c.DB.QueryRowContext(ctx, stmt, keyColValues...).Scan(result...)
+	if err == dbsql.ErrNoRows {
+		// callers check for the gocql error as the ORM was
+		// written against Cassandra
+		err = gocql.ErrNotFound
+	}
+	if err != nil {
+		sendCounters(c.executeFailScope, e.Name, get, err)
+		return nil, err
+	}
+
+	sendLatency(c.scope, e.Name, get, time.Since(start))
+	sendCounters(c.executeSuccessScope, e.Name, get, nil)
+	return getRowFromResult(e, colNamesToRead, result)
+}
+
+// GetAll fetches all rows from DB using partition keys
+func (c *sqlConnector) GetAll(
+	ctx context.Context,
+	e *base.Definition,
+	keyCols []base.Column,
+) (rows [][]base.Column, errors error) {
+	iter, err := c.GetAllIter(ctx, e, keyCols)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	for {
+		row, errors := iter.Next()
+		if errors != nil {
+			return nil, errors
+		}
+		if row != nil {
+			rows = append(rows, row)
+		} else {
+			return rows, nil
+		}
+	}
+}
+
+// GetAllIter gives an iterator to fetch all rows from DB
+func (c *sqlConnector) GetAllIter(
+	ctx context.Context,
+	e *base.Definition,
+	keyCols []base.Column,
+) (iter orm.Iterator, err error) {
+	colNamesToRead := e.GetColumnsToRead()
+	keyColNames, keyColValues := splitColumnNameValue(keyCols)
+	stmt := selectStmt(c.dialect, e, colNamesToRead, keyColNames)
+
+	start := time.Now()
+	rows, err := c.DB.QueryContext(ctx, stmt, keyColValues...)
+	if err != nil {
+		sendCounters(c.executeFailScope, e.Name, getIter, err)
+		return nil, err
+	}
+	sendLatency(c.scope, e.Name, getIter, time.Since(start))
+
+	sqlIter := &sqlIterator{
+		rows:           rows,
+		tableDef:       e,
+		colNamesToRead: colNamesToRead,
+		successScope:   c.executeSuccessScope,
+		failScope:      c.executeFailScope,
+	}
+	if c.drainRows {
+		return drain(sqlIter)
+	}
+	return sqlIter, nil
+}
+
+// Delete deletes a record from DB using primary keys
+func (c *sqlConnector) Delete(
+	ctx context.Context,
+	e *base.Definition,
+	keyCols []base.Column,
+) error {
+	keyColNames, keyColValues := splitColumnNameValue(keyCols)
+	stmt := deleteStmt(c.dialect, e, keyColNames)
+
+	start := time.Now()
+	if _, err := c.DB.ExecContext(ctx, stmt, keyColValues...); err != nil {
+		sendCounters(c.executeFailScope, e.Name, del, err)
+		return err
+	}
+
+	sendLatency(c.scope, e.Name, del, time.Since(start))
+	sendCounters(c.executeSuccessScope, e.Name, del, nil)
+	return nil
+}
+
+// Update updates a row in DB. Like a Cassandra update, the row is created
+// if it doesn't exist.
+func (c *sqlConnector) Update(
+	ctx context.Context,
+	e *base.Definition,
+	row []base.Column,
+	keyCols []base.Column,
+) error {
+	for _, column := range row {
+		if isKeyColumn(e, column.Name) {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"primary key part %s found in update", column.Name)
+		}
+	}
+
+	colNames, colValues := splitColumnNameValue(append(
+		append([]base.Column{}, keyCols...), row...))
+	stmt := insertStmt(c.dialect, e, colNames, !useCasWrite)
+
+	start := time.Now()
+	if _, err := c.DB.ExecContext(ctx, stmt, colValues...); err != nil {
+		sendCounters(c.executeFailScope, e.Name, update, err)
+		return err
+	}
+
+	sendLatency(c.scope, e.Name, update, time.Since(start))
+	sendCounters(c.executeSuccessScope, e.Name, update, nil)
+	return nil
+}
+
+// sqlIterator implements interface Iterator for SQL databases
+type sqlIterator struct {
+	rows           *dbsql.Rows
+	tableDef       *base.Definition
+	colNamesToRead []string
+	successScope   tally.Scope
+	failScope      tally.Scope
+}
+
+// ensure that implementation (sqlIterator) satisfies the interface
+var _ orm.Iterator = (*sqlIterator)(nil)
+
+func (iter *sqlIterator) Close() {
+	iter.rows.Close()
+}
+
+func (iter *sqlIterator) Next() ([]base.Column, error) {
+	if iter.rows.Next() {
+		result := buildResultRow(iter.tableDef, iter.colNamesToRead)
+		if err := iter.rows.Scan(result...); err != nil {
+			sendCounters(iter.failScope, iter.tableDef.Name, getIter, err)
+			return nil, err
+		}
+		return getRowFromResult(iter.tableDef, iter.colNamesToRead, result)
+	}
+	// Either end-of-results or error
+	if err := iter.rows.Err(); err != nil {
+		sendCounters(iter.failScope, iter.tableDef.Name, getIter, err)
+		return nil, err
+	}
+	sendCounters(iter.successScope, iter.tableDef.Name, getIter, nil)
+	return nil, nil
+}
+
+// drain reads all the rows of an iterator and closes it, and returns an
+// iterator over the rows read
+func drain(iter orm.Iterator) (orm.Iterator, error) {
+	defer iter.Close()
+
+	var rows [][]base.Column
+	for {
+		row, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			return &drainedIterator{rows: rows}, nil
+		}
+		rows = append(rows, row)
+	}
+}
+
+// drainedIterator implements interface Iterator for rows which have
+// already been read from DB
+type drainedIterator struct {
+	rows [][]base.Column
+}
+
+// ensure that implementation (drainedIterator) satisfies the interface
+var _ orm.Iterator = (*drainedIterator)(nil)
+
+func (iter *drainedIterator) Close() {
+	iter.rows = nil
+}
+
+func (iter *drainedIterator) Next() ([]base.Column, error) {
+	if len(iter.rows) == 0 {
+		return nil, nil
+	}
+	row := iter.rows[0]
+	iter.rows = iter.rows[1:]
+	return row, nil
+}
+
+// helper function to record call latency metric
+func sendLatency(
+	scope tally.Scope,
+	table, operation string,
+	d time.Duration,
+) {
+	s := scope.Tagged(map[string]string{
+		"table":     table,
+		"operation": operation,
+	})
+	s.Timer("execute_latency").Record(d)
+}
+
+// helper function to record query success/failure metrics
+func sendCounters(
+	scope tally.Scope,
+	table, operation string,
+	err error,
+) {
+	errMsg := "none"
+	switch {
+	case err == gocql.ErrNotFound:
+		errMsg = "not_found"
+	case err != nil:
+		errMsg = "unknown"
+	}
+	s := scope.Tagged(map[string]string{
+		"table":     table,
+		"operation": operation,
+		"error":     errMsg,
+	})
+	s.Counter("execute").Inc(1)
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// testDef is a table with partition key "id" and descending clustering
+// key "ck"
+var testDef = &base.Definition{
+	Name: "test_table",
+	Key: &base.PrimaryKey{
+		PartitionKeys: []string{"id"},
+		ClusteringKeys: []*base.ClusteringKey{
+			{Name: "ck", Descending: true},
+		},
+	},
+	ColumnToType: map[string]reflect.Type{
+		"id":      reflect.TypeOf(uint64(1)),
+		"ck":      reflect.TypeOf(gocql.UUID{}),
+		"name":    reflect.TypeOf("name"),
+		"data":    reflect.TypeOf([]byte{}),
+		"valid":   reflect.TypeOf(true),
+		"created": reflect.TypeOf(time.Time{}),
+	},
+}
+
+type SQLConnSuite struct {
+	suite.Suite
+
+	ctx       context.Context
+	connector *sqlConnector
+}
+
+func (suite *SQLConnSuite) SetupTest() {
+	suite.ctx = context.Background()
+
+	conn, err := NewSQLConnector(&Config{
+		Driver:     SQLite,
+		DataSource: ":memory:",
+	}, tally.NoopScope)
+	suite.NoError(err)
+	suite.connector = conn.(*sqlConnector)
+
+	stmts, err := CreateTableStmts(SQLite, testDef)
+	suite.NoError(err)
+	for _, stmt := range stmts {
+		_, err := suite.connector.DB.Exec(stmt)
+		suite.NoError(err)
+	}
+}
+
+func (suite *SQLConnSuite) TearDownTest() {
+	suite.connector.DB.Close()
+}
+
+func TestSQLConnSuite(t *testing.T) {
+	suite.Run(t, new(SQLConnSuite))
+}
+
+func testRow(id uint64, ck gocql.UUID, name string) []base.Column {
+	return []base.Column{
+		{Name: "id", Value: id},
+		{Name: "ck", Value: ck},
+		{Name: "name", Value: name},
+		{Name: "data", Value: []byte(name)},
+		{Name: "valid", Value: true},
+		{Name: "created", Value: time.Unix(1000, 0)},
+	}
+}
+
+func keyRow(id uint64, ck gocql.UUID) []base.Column {
+	return []base.Column{
+		{Name: "id", Value: id},
+		{Name: "ck", Value: ck},
+	}
+}
+
+func columnValue(row []base.Column, name string) interface{} {
+	for _, column := range row {
+		if column.Name == name {
+			return column.Value
+		}
+	}
+	return nil
+}
+
+// TestCreateGetDelete creates a row in the test table and reads it back
+// Then it deletes it and verifies that row was deleted
+func (suite *SQLConnSuite) TestCreateGetDelete() {
+	ck := gocql.TimeUUID()
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDef, testRow(1, ck, "test")))
+
+	row, err := suite.connector.Get(suite.ctx, testDef, keyRow(1, ck))
+	suite.NoError(err)
+	suite.Len(row, 6)
+	suite.Equal(int64(1), columnValue(row, "id"))
+	suite.Equal(ck, columnValue(row, "ck"))
+	suite.Equal("test", columnValue(row, "name"))
+	suite.Equal([]byte("test"), columnValue(row, "data"))
+	suite.Equal(true, columnValue(row, "valid"))
+	suite.True(time.Unix(1000, 0).Equal(
+		columnValue(row, "created").(time.Time)))
+
+	suite.NoError(suite.connector.Delete(suite.ctx, testDef, keyRow(1, ck)))
+	_, err = suite.connector.Get(suite.ctx, testDef, keyRow(1, ck))
+	suite.Equal(gocql.ErrNotFound, err)
+
+	// deleting a missing row is a noop
+	suite.NoError(suite.connector.Delete(suite.ctx, testDef, keyRow(1, ck)))
+}
+
+// TestCreateOverwrites tests that create overwrites an existing row
+// while create if not exists fails
+func (suite *SQLConnSuite) TestCreateOverwrites() {
+	ck := gocql.TimeUUID()
+	suite.NoError(suite.connector.CreateIfNotExists(
+		suite.ctx, testDef, testRow(1, ck, "first")))
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDef, testRow(1, ck, "second")))
+
+	err := suite.connector.CreateIfNotExists(
+		suite.ctx, testDef, testRow(1, ck, "third"))
+	suite.True(yarpcerrors.IsAlreadyExists(err))
+
+	row, err := suite.connector.Get(suite.ctx, testDef, keyRow(1, ck))
+	suite.NoError(err)
+	suite.Equal("second", columnValue(row, "name"))
+}
+
+// TestUpdate tests updating some columns of a row, and that update
+// creates the row if it doesn't exist
+func (suite *SQLConnSuite) TestUpdate() {
+	ck := gocql.TimeUUID()
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDef, testRow(1, ck, "test")))
+
+	suite.NoError(suite.connector.Update(
+		suite.ctx,
+		testDef,
+		[]base.Column{{Name: "name", Value: "updated"}},
+		keyRow(1, ck),
+	))
+	row, err := suite.connector.Get(suite.ctx, testDef, keyRow(1, ck))
+	suite.NoError(err)
+	suite.Equal("updated", columnValue(row, "name"))
+	suite.Equal([]byte("test"), columnValue(row, "data"))
+
+	newCK := gocql.TimeUUID()
+	suite.NoError(suite.connector.Update(
+		suite.ctx,
+		testDef,
+		[]base.Column{{Name: "name", Value: "new"}},
+		keyRow(1, newCK),
+	))
+	row, err = suite.connector.Get(suite.ctx, testDef, keyRow(1, newCK))
+	suite.NoError(err)
+	suite.Equal("new", columnValue(row, "name"))
+	suite.Nil(columnValue(row, "data"))
+
+	err = suite.connector.Update(
+		suite.ctx,
+		testDef,
+		[]base.Column{{Name: "ck", Value: newCK}},
+		[]base.Column{{Name: "id", Value: uint64(1)}},
+	)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestGetAll tests reading all rows of a partition in clustering order
+func (suite *SQLConnSuite) TestGetAll() {
+	first := gocql.TimeUUID()
+	second := gocql.UUIDFromTime(first.Time().Add(time.Second))
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDef, testRow(1, first, "first")))
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDef, testRow(1, second, "second")))
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDef, testRow(2, first, "other")))
+
+	partition := []base.Column{{Name: "id", Value: uint64(1)}}
+	rows, err := suite.connector.GetAll(suite.ctx, testDef, partition)
+	suite.NoError(err)
+	suite.Len(rows, 2)
+	// descending clustering order returns the latest row first
+	suite.Equal("second", columnValue(rows[0], "name"))
+	suite.Equal("first", columnValue(rows[1], "name"))
+
+	iter, err := suite.connector.GetAllIter(suite.ctx, testDef, nil)
+	suite.NoError(err)
+	defer iter.Close()
+	var count int
+	for {
+		row, err := iter.Next()
+		suite.NoError(err)
+		if row == nil {
+			break
+		}
+		count++
+	}
+	suite.Equal(3, count)
+
+	suite.NoError(suite.connector.Delete(suite.ctx, testDef, partition))
+	rows, err = suite.connector.GetAll(suite.ctx, testDef, partition)
+	suite.NoError(err)
+	suite.Empty(rows)
+}
+
+// TestGetAllIterQueryWhileIterating tests that rows can be read and
+// written while iterating with the single connection to sqlite
+func (suite *SQLConnSuite) TestGetAllIterQueryWhileIterating() {
+	first := gocql.TimeUUID()
+	second := gocql.UUIDFromTime(first.Time().Add(time.Second))
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDef, testRow(1, first, "first")))
+	suite.NoError(suite.connector.Create(
+		suite.ctx, testDef, testRow(1, second, "second")))
+
+	ctx, cancel := context.WithTimeout(suite.ctx, 5*time.Second)
+	defer cancel()
+
+	iter, err := suite.connector.GetAllIter(ctx, testDef, nil)
+	suite.NoError(err)
+	defer iter.Close()
+	var count int
+	for {
+		row, err := iter.Next()
+		suite.NoError(err)
+		if row == nil {
+			break
+		}
+		count++
+
+		ck := columnValue(row, "ck").(gocql.UUID)
+		_, err = suite.connector.Get(ctx, testDef, keyRow(1, ck))
+		suite.NoError(err)
+		suite.NoError(suite.connector.Update(ctx, testDef,
+			[]base.Column{{Name: "name", Value: "updated"}}, keyRow(1, ck)))
+	}
+	suite.Equal(2, count)
+}
+
+// TestUUIDEncoding tests that encoded time UUIDs sort by time
+func (suite *SQLConnSuite) TestUUIDEncoding() {
+	first := gocql.UUIDFromTime(time.Unix(1, 0))
+	second := gocql.UUIDFromTime(time.Unix(2, 0))
+	suite.True(encodeUUID(first) < encodeUUID(second))
+
+	u, err := decodeUUID(encodeUUID(first))
+	suite.NoError(err)
+	suite.Equal(first, u)
+
+	random, err := gocql.RandomUUID()
+	suite.NoError(err)
+	u, err = decodeUUID(encodeUUID(random))
+	suite.NoError(err)
+	suite.Equal(random, u)
+}
+
+// TestCreateTableStmts tests mapping an object definition to tables
+func (suite *SQLConnSuite) TestCreateTableStmts() {
+	stmts, err := CreateTableStmts(Postgres, testDef)
+	suite.NoError(err)
+	suite.Equal([]string{
+		"CREATE TABLE \"test_table\" (\n" +
+			"  \"id\" BIGINT NOT NULL,\n" +
+			"  \"ck\" TEXT NOT NULL,\n" +
+			"  \"created\" TIMESTAMP WITH TIME ZONE,\n" +
+			"  \"data\" BYTEA,\n" +
+			"  \"name\" TEXT,\n" +
+			"  \"valid\" BOOLEAN,\n" +
+			"  PRIMARY KEY (\"id\", \"ck\")\n" +
+			")",
+		"CREATE INDEX \"test_table_clustering_order\" ON \"test_table\" " +
+			"(\"id\", \"ck\" DESC)",
+	}, stmts)
+
+	stmts, err = CreateTableStmts(MySQL, testDef)
+	suite.NoError(err)
+	suite.Contains(stmts[0], "`ck` VARCHAR(64) NOT NULL")
+	suite.Contains(stmts[0], "`name` LONGTEXT")
+
+	_, err = CreateTableStmts("oracle", testDef)
+	suite.Error(err)
+}
+
+// TestStatements tests the statements built for each dialect
+func (suite *SQLConnSuite) TestStatements() {
+	columns := []string{"id", "ck", "name"}
+
+	suite.Equal(
+		`INSERT INTO "test_table" ("id", "ck", "name") VALUES ($1, $2, $3)`+
+			` ON CONFLICT ("id", "ck") DO UPDATE SET "name" = excluded."name"`,
+		insertStmt(postgresDialect{}, testDef, columns, false))
+	suite.Equal(
+		"INSERT INTO `test_table` (`id`, `ck`, `name`) VALUES (?, ?, ?)"+
+			" ON DUPLICATE KEY UPDATE `id` = `id`",
+		insertStmt(mysqlDialect{}, testDef, columns, true))
+	suite.Equal(
+		`SELECT "name" FROM "test_table" WHERE "id" = $1`+
+			` ORDER BY "id", "ck" DESC`,
+		selectStmt(postgresDialect{}, testDef, []string{"name"}, []string{"id"}))
+	suite.Equal(
+		`DELETE FROM "test_table" WHERE "id" = ? AND "ck" = ?`,
+		deleteStmt(sqliteDialect{}, testDef, []string{"id", "ck"}))
+}
+
+// TestNullColumns tests that NULL columns are read back as nil values
+func (suite *SQLConnSuite) TestNullColumns() {
+	ck := gocql.TimeUUID()
+	suite.NoError(suite.connector.Create(suite.ctx, testDef, keyRow(1, ck)))
+
+	row, err := suite.connector.Get(suite.ctx, testDef, keyRow(1, ck))
+	suite.NoError(err)
+	for _, name := range []string{"name", "data", "valid", "created"} {
+		suite.Nil(columnValue(row, name), name)
+	}
+
+	var count int
+	suite.NoError(suite.connector.DB.QueryRow(
+		`SELECT COUNT(*) FROM "test_table"`).Scan(&count))
+	suite.Equal(1, count)
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+)
+
+// primaryKeyColumns returns the partition keys followed by the clustering
+// keys of the object, which together form the primary key of its table
+func primaryKeyColumns(e *base.Definition) []string {
+	keys := append([]string{}, e.Key.PartitionKeys...)
+	for _, ck := range e.Key.ClusteringKeys {
+		keys = append(keys, ck.Name)
+	}
+	return keys
+}
+
+// isKeyColumn returns true if the column is part of the primary key
+func isKeyColumn(e *base.Definition, column string) bool {
+	for _, key := range primaryKeyColumns(e) {
+		if key == column {
+			return true
+		}
+	}
+	return false
+}
+
+// orderByClause returns the ORDER BY clause which reads rows in the same
+// order as Cassandra does within a partition: by the partition keys,
+// then by the clustering keys in their clustering order
+func orderByClause(d dialect, e *base.Definition) string {
+	var order []string
+	for _, pk := range e.Key.PartitionKeys {
+		order = append(order, d.quote(pk))
+	}
+	for _, ck := range e.Key.ClusteringKeys {
+		if ck.Descending {
+			order = append(order, d.quote(ck.Name)+" DESC")
+		} else {
+			order = append(order, d.quote(ck.Name))
+		}
+	}
+	return " ORDER BY " + strings.Join(order, ", ")
+}
+
+// whereClause returns the WHERE clause matching all the given columns,
+// with bind variables numbered from offset
+func whereClause(d dialect, conds []string, offset int) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	c := make([]string, len(conds))
+	for i, cond := range conds {
+		c[i] = fmt.Sprintf("%s = %s", d.quote(cond), d.bindVar(offset+i))
+	}
+	return " WHERE " + strings.Join(c, " AND ")
+}
+
+// insertStmt creates an insert statement for the given columns. Like a
+// Cassandra insert, the value columns of an existing row are overwritten,
+// unless ifNotExists is set in which case the existing row is kept.
+func insertStmt(
+	d dialect,
+	e *base.Definition,
+	colNames []string,
+	ifNotExists bool,
+) string {
+	keys := primaryKeyColumns(e)
+	var values []string
+	bindVars := make([]string, len(colNames))
+	for i, c := range colNames {
+		bindVars[i] = d.bindVar(i)
+		if !isKeyColumn(e, c) {
+			values = append(values, c)
+		}
+	}
+
+	clause := d.upsertClause(keys, values)
+	if ifNotExists {
+		clause = d.ignoreClause(keys)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)%s",
+		d.quote(e.Name),
+		strings.Join(quoteAll(d, colNames), ", "),
+		strings.Join(bindVars, ", "),
+		clause,
+	)
+}
+
+// selectStmt creates a select statement of the given columns for rows
+// matching the conditions
+func selectStmt(
+	d dialect,
+	e *base.Definition,
+	colNames []string,
+	conds []string,
+) string {
+	return fmt.Sprintf("SELECT %s FROM %s%s%s",
+		strings.Join(quoteAll(d, colNames), ", "),
+		d.quote(e.Name),
+		whereClause(d, conds, 0),
+		orderByClause(d, e),
+	)
+}
+
+// deleteStmt creates a delete statement for rows matching the conditions
+func deleteStmt(d dialect, e *base.Definition, conds []string) string {
+	return fmt.Sprintf("DELETE FROM %s%s",
+		d.quote(e.Name), whereClause(d, conds, 0))
+}
+
+// CreateTableStmts returns the statements which create the table of an
+// object for the given driver. The partition and clustering keys form the
+// primary key of the table, and descending clustering keys get an index
+// in their clustering order. It is used to write the schema migrations
+// of new storage objects.
+func CreateTableStmts(driver string, e *base.Definition) ([]string, error) {
+	d, err := getDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := primaryKeyColumns(e)
+	var values []string
+	for column := range e.ColumnToType {
+		if !isKeyColumn(e, column) {
+			values = append(values, column)
+		}
+	}
+	sort.Strings(values)
+
+	var columns []string
+	for _, column := range append(append([]string{}, keys...), values...) {
+		typ, err := d.columnType(
+			e.ColumnToType[column], isKeyColumn(e, column))
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", column, err)
+		}
+		null := ""
+		if isKeyColumn(e, column) {
+			null = " NOT NULL"
+		}
+		columns = append(columns,
+			fmt.Sprintf("%s %s%s", d.quote(column), typ, null))
+	}
+	columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)",
+		strings.Join(quoteAll(d, keys), ", ")))
+
+	stmts := []string{fmt.Sprintf("CREATE TABLE %s (\n  %s\n)",
+		d.quote(e.Name), strings.Join(columns, ",\n  "))}
+
+	descending := false
+	for _, ck := range e.Key.ClusteringKeys {
+		descending = descending || ck.Descending
+	}
+	if descending {
+		stmts = append(stmts, fmt.Sprintf("CREATE INDEX %s ON %s (%s)",
+			d.quote(e.Name+"_clustering_order"),
+			d.quote(e.Name),
+			strings.TrimPrefix(orderByClause(d, e), " ORDER BY "),
+		))
+	}
+	return stmts, nil
+}
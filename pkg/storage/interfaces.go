@@ -202,13 +202,14 @@ type UpdateStore interface {
 	GetUpdatesForJob(ctx context.Context, jobID string) ([]*peloton.UpdateID, error)
 
 	// AddWorkflowEvent adds a workflow event for an update and instance
-	// to track the progress
+	// to track the progress, with an optional message explaining it
 	AddWorkflowEvent(
 		ctx context.Context,
 		updateID *peloton.UpdateID,
 		instanceID uint32,
 		updateType models.WorkflowType,
 		updateState update.State,
+		message string,
 	) error
 
 	// GetWorkflowEvents gets workflow events for an update and instance,
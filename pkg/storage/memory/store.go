@@ -0,0 +1,876 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memory implements the legacy storage.Store interfaces in process
+// memory. Job configurations, job runtimes, the job index and pod events are
+// kept in an in-memory orm.Connector, so that they are shared with the ORM
+// objects created on the same connector, the same way the Cassandra store
+// and the ORM objects share the Cassandra tables.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/query"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/api/v0/volume"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/storage"
+	"github.com/uber/peloton/pkg/storage/objects"
+	"github.com/uber/peloton/pkg/storage/objects/base"
+	"github.com/uber/peloton/pkg/storage/orm"
+
+	"github.com/gocql/gocql"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	taskIDFmt = "%s-%d"
+
+	// Job query sort by field
+	creationTimeField   = "creation_time"
+	completionTimeField = "completion_time"
+	jobNameField        = "name"
+	jobOwnerField       = "owner"
+	jobStateField       = "state"
+
+	_defaultQueryLimit    uint32 = 10
+	_defaultQueryMaxLimit uint32 = 100
+
+	// _maxUpdatesPerJob is the maximum number of updates
+	// of each type kept for a job
+	_maxUpdatesPerJob = 10
+)
+
+// taskConfigRecord is a task configuration of a given version
+type taskConfigRecord struct {
+	config      *task.TaskConfig
+	configAddOn *models.ConfigAddOn
+}
+
+// frameworkInfo holds the Mesos identifiers of a framework
+type frameworkInfo struct {
+	mesosStreamID string
+	frameworkID   string
+}
+
+// workflowEvent is a state change of an update, for the whole job
+// or for a single instance
+type workflowEvent struct {
+	workflowType models.WorkflowType
+	state        update.State
+	message      string
+	createTime   time.Time
+}
+
+// volumeRecord is a persistent volume along with its timestamps
+type volumeRecord struct {
+	info       *volume.PersistentVolumeInfo
+	createTime time.Time
+	updateTime time.Time
+}
+
+// Store implements storage.Store in memory
+type Store struct {
+	sync.RWMutex
+
+	// connector keeps the tables shared with the ORM objects
+	connector orm.Connector
+	oClient   orm.Client
+
+	jobConfigOps objects.JobConfigOps
+	podEventsOps objects.PodEventsOps
+
+	activeJobs map[string]struct{}
+
+	// job id -> instance id -> runtime
+	taskRuntimes map[string]map[uint32]*task.RuntimeInfo
+	// job id -> version -> instance id -> config
+	taskConfigs map[string]map[uint64]map[int64]*taskConfigRecord
+
+	updates map[string]*models.UpdateModel
+	// update id -> events, latest first
+	jobUpdateEvents map[string][]*workflowEvent
+	// update id -> instance id -> events, latest first
+	podWorkflowEvents map[string]map[uint32][]*workflowEvent
+
+	frameworks map[string]*frameworkInfo
+	respools   map[string]*respool.ResourcePoolConfig
+	volumes    map[string]*volumeRecord
+}
+
+// ensure that implementation (Store) satisfies the interface
+var _ storage.Store = (*Store)(nil)
+
+// NewStore creates a Store which keeps the tables shared with the ORM
+// objects in the given connector.
+func NewStore(connector orm.Connector, scope tally.Scope) (*Store, error) {
+	ormStore, err := objects.NewStore(connector, scope)
+	if err != nil {
+		return nil, err
+	}
+	oClient, err := orm.NewClient(connector, objects.Objs...)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		connector:         connector,
+		oClient:           oClient,
+		jobConfigOps:      objects.NewJobConfigOps(ormStore),
+		podEventsOps:      objects.NewPodEventsOps(ormStore),
+		activeJobs:        make(map[string]struct{}),
+		taskRuntimes:      make(map[string]map[uint32]*task.RuntimeInfo),
+		taskConfigs:       make(map[string]map[uint64]map[int64]*taskConfigRecord),
+		updates:           make(map[string]*models.UpdateModel),
+		jobUpdateEvents:   make(map[string][]*workflowEvent),
+		podWorkflowEvents: make(map[string]map[uint32][]*workflowEvent),
+		frameworks:        make(map[string]*frameworkInfo),
+		respools:          make(map[string]*respool.ResourcePoolConfig),
+		volumes:           make(map[string]*volumeRecord),
+	}, nil
+}
+
+// scan reads all rows of the table of the given storage object
+func (s *Store) scan(
+	ctx context.Context,
+	e base.Object,
+) ([]base.Object, error) {
+	table, err := orm.TableFromObject(e)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.connector.GetAll(ctx, &table.Definition, nil)
+	if err != nil {
+		return nil, err
+	}
+	return table.BuildObjectsFromRows(e, rows), nil
+}
+
+// deletePartition deletes all rows in the partition of the given
+// storage object
+func (s *Store) deletePartition(ctx context.Context, e base.Object) error {
+	table, err := orm.TableFromObject(e)
+	if err != nil {
+		return err
+	}
+	return s.connector.Delete(
+		ctx, &table.Definition, table.GetPartitionKeyRowFromObject(e))
+}
+
+// CreateJobConfig creates a job config
+func (s *Store) CreateJobConfig(
+	ctx context.Context,
+	id *peloton.JobID,
+	jobConfig *job.JobConfig,
+	configAddOn *models.ConfigAddOn,
+	version uint64,
+	owner string,
+) error {
+	return s.jobConfigOps.Create(ctx, id, jobConfig, configAddOn, version)
+}
+
+// CreateJobRuntime creates runtime for a job
+func (s *Store) CreateJobRuntime(
+	ctx context.Context,
+	id *peloton.JobID,
+	initialRuntime *job.RuntimeInfo,
+) error {
+	return s.UpdateJobRuntime(ctx, id, initialRuntime)
+}
+
+// GetMaxJobConfigVersion returns the maximum version of configs of a given job
+func (s *Store) GetMaxJobConfigVersion(
+	ctx context.Context,
+	jobID string,
+) (uint64, error) {
+	objs, err := s.oClient.GetAll(ctx, &objects.JobConfigObject{JobID: jobID})
+	if err != nil {
+		return 0, err
+	}
+
+	var max uint64
+	for _, obj := range objs {
+		if v := obj.(*objects.JobConfigObject).Version; v > max {
+			max = v
+		}
+	}
+	return max, nil
+}
+
+// GetJobConfig returns the current job config given the job id
+func (s *Store) GetJobConfig(
+	ctx context.Context,
+	jobID string,
+) (*job.JobConfig, *models.ConfigAddOn, error) {
+	r, err := s.GetJobRuntime(ctx, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// ConfigurationVersion will be 0 for old jobs created before the
+	// migration to using of ConfigurationVersion.
+	if r.ConfigurationVersion == uint64(0) {
+		r.ConfigurationVersion = uint64(r.ConfigVersion)
+	}
+
+	return s.GetJobConfigWithVersion(ctx, jobID, r.GetConfigurationVersion())
+}
+
+// GetJobConfigWithVersion fetches the job configuration for a given
+// job of a given version
+func (s *Store) GetJobConfigWithVersion(
+	ctx context.Context,
+	jobID string,
+	version uint64,
+) (*job.JobConfig, *models.ConfigAddOn, error) {
+	config, configAddOn, err := s.jobConfigOps.Get(
+		ctx, &peloton.JobID{Value: jobID}, version)
+	if err == gocql.ErrNotFound {
+		return nil, nil, yarpcerrors.NotFoundErrorf("job:%s not found", jobID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config.GetChangeLog().GetVersion() < 1 {
+		// Older job which does not have changelog.
+		config.ChangeLog = &peloton.ChangeLog{
+			CreatedAt: uint64(time.Now().UnixNano()),
+			UpdatedAt: uint64(time.Now().UnixNano()),
+			Version:   version,
+		}
+	}
+	return config, configAddOn, nil
+}
+
+// QueryJobs returns all jobs in the resource pool that match the spec.
+// Unlike the Cassandra store, which relies on a lucene index, the job
+// index is scanned and filtered in memory.
+func (s *Store) QueryJobs(
+	ctx context.Context,
+	respoolID *peloton.ResourcePoolID,
+	spec *job.QuerySpec,
+	summaryOnly bool,
+) ([]*job.JobInfo, []*job.JobSummary, uint32, error) {
+	if spec == nil {
+		return nil, nil, 0, nil
+	}
+
+	objs, err := s.scan(ctx, &objects.JobIndexObject{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var indexes []*objects.JobIndexObject
+	for _, obj := range objs {
+		index := obj.(*objects.JobIndexObject)
+		match, err := matchJobQuery(index, respoolID, spec)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if match {
+			indexes = append(indexes, index)
+		}
+	}
+
+	// sort by creation time in descending order in case order by
+	// is not specified in the query spec
+	orderBy := spec.GetPagination().GetOrderBy()
+	if len(orderBy) == 0 {
+		orderBy = []*query.OrderBy{
+			{
+				Order:    query.OrderBy_DESC,
+				Property: &query.PropertyPath{Value: creationTimeField},
+			},
+		}
+	}
+	for _, order := range orderBy {
+		switch order.GetProperty().GetValue() {
+		case creationTimeField, completionTimeField,
+			jobNameField, jobOwnerField, jobStateField:
+			continue
+		}
+		return nil, nil, 0, yarpcerrors.InvalidArgumentErrorf(
+			"sort only supports fields: creation_time, completion_time, " +
+				"name, owner, state")
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return lessJobIndex(orderBy, indexes[i], indexes[j])
+	})
+
+	maxLimit := _defaultQueryMaxLimit
+	if spec.GetPagination().GetMaxLimit() != 0 {
+		maxLimit = spec.GetPagination().GetMaxLimit()
+	}
+	if uint32(len(indexes)) > maxLimit {
+		indexes = indexes[:maxLimit]
+	}
+	total := uint32(len(indexes))
+
+	// Apply offset and limit.
+	begin := spec.GetPagination().GetOffset()
+	if begin > total {
+		begin = total
+	}
+	indexes = indexes[begin:]
+
+	end := _defaultQueryLimit
+	if limit := spec.GetPagination().GetLimit(); limit > 0 {
+		end = limit
+	}
+	if end > uint32(len(indexes)) {
+		end = uint32(len(indexes))
+	}
+	indexes = indexes[:end]
+
+	var summaries []*job.JobSummary
+	for _, index := range indexes {
+		summary, err := index.ToJobSummary()
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", index.JobID).
+				Warn("failed to get job summary when executing jobs query")
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	if summaryOnly {
+		return nil, summaries, total, nil
+	}
+
+	var results []*job.JobInfo
+	for _, index := range indexes {
+		jobID := &peloton.JobID{Value: index.JobID}
+		jobRuntime, err := s.GetJobRuntime(ctx, jobID.GetValue())
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID.GetValue()).
+				Warn("no job runtime found when executing jobs query")
+			continue
+		}
+		jobConfig, _, err := s.GetJobConfig(ctx, jobID.GetValue())
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID.GetValue()).
+				Warn("no job config found when executing jobs query")
+			continue
+		}
+
+		// Unset instance config as its size can be huge.
+		jobConfig.InstanceConfig = nil
+
+		results = append(results, &job.JobInfo{
+			Id:      jobID,
+			Config:  jobConfig,
+			Runtime: jobRuntime,
+		})
+	}
+	return results, summaries, total, nil
+}
+
+// matchJobQuery returns true if the job index entry matches the query spec
+func matchJobQuery(
+	index *objects.JobIndexObject,
+	respoolID *peloton.ResourcePoolID,
+	spec *job.QuerySpec,
+) (bool, error) {
+	// labels must contain all the values of the specified labels
+	if len(spec.GetLabels()) > 0 {
+		var labels []*peloton.Label
+		if err := json.Unmarshal([]byte(index.Labels), &labels); err != nil {
+			return false, nil
+		}
+		values := make(map[string]struct{})
+		for _, label := range labels {
+			values[label.GetValue()] = struct{}{}
+		}
+		for _, label := range spec.GetLabels() {
+			if _, ok := values[label.GetValue()]; !ok {
+				return false, nil
+			}
+		}
+	}
+
+	// config must contain all specified keywords
+	config := strings.ToLower(index.Config)
+	for _, word := range spec.GetKeywords() {
+		if !strings.Contains(config, strings.ToLower(word)) {
+			return false, nil
+		}
+	}
+
+	if len(spec.GetJobStates()) > 0 {
+		found := false
+		for _, state := range spec.GetJobStates() {
+			if state.String() == index.State {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	if respoolID != nil && respoolID.GetValue() != index.RespoolID {
+		return false, nil
+	}
+
+	if owner := spec.GetOwner(); owner != "" && owner != index.Owner {
+		return false, nil
+	}
+
+	if name := spec.GetName(); name != "" &&
+		!strings.Contains(index.Name, name) {
+		return false, nil
+	}
+
+	match, err := inTimeRange(index.CreationTime, spec.GetCreationTimeRange())
+	if err != nil || !match {
+		return false, err
+	}
+	return inTimeRange(index.CompletionTime, spec.GetCompletionTimeRange())
+}
+
+// inTimeRange returns true if the time is within the range
+func inTimeRange(t time.Time, timeRange *peloton.TimeRange) (bool, error) {
+	if timeRange == nil {
+		return true, nil
+	}
+	min, err := ptypes.Timestamp(timeRange.GetMin())
+	if err != nil {
+		return false, err
+	}
+	max, err := ptypes.Timestamp(timeRange.GetMax())
+	if err != nil {
+		return false, err
+	}
+	if max.Before(min) {
+		return false, fmt.Errorf("Incorrect timerange")
+	}
+	return !t.Before(min) && !t.After(max), nil
+}
+
+// lessJobIndex holds the job sorting logic for job queries
+func lessJobIndex(
+	orderByList []*query.OrderBy,
+	j1 *objects.JobIndexObject,
+	j2 *objects.JobIndexObject,
+) bool {
+	for _, orderBy := range orderByList {
+		desc := orderBy.GetOrder() == query.OrderBy_DESC
+
+		var cmp int
+		switch orderBy.GetProperty().GetValue() {
+		case creationTimeField:
+			cmp = compareTime(j1.CreationTime, j2.CreationTime)
+		case completionTimeField:
+			cmp = compareTime(j1.CompletionTime, j2.CompletionTime)
+		case jobNameField:
+			cmp = strings.Compare(j1.Name, j2.Name)
+		case jobOwnerField:
+			cmp = strings.Compare(j1.Owner, j2.Owner)
+		case jobStateField:
+			cmp = strings.Compare(j1.State, j2.State)
+		}
+		if cmp != 0 {
+			return (cmp < 0) != desc
+		}
+	}
+	return j1.JobID < j2.JobID
+}
+
+func compareTime(t1, t2 time.Time) int {
+	switch {
+	case t1.Before(t2):
+		return -1
+	case t1.After(t2):
+		return 1
+	}
+	return 0
+}
+
+// GetJobsByStates returns all jobs which belong to one of the states
+func (s *Store) GetJobsByStates(
+	ctx context.Context,
+	states []job.JobState,
+) ([]peloton.JobID, error) {
+	objs, err := s.scan(ctx, &objects.JobRuntimeObject{})
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []peloton.JobID
+	for _, obj := range objs {
+		runtime := obj.(*objects.JobRuntimeObject)
+		for _, state := range states {
+			if runtime.State == state.String() {
+				jobs = append(jobs, peloton.JobID{Value: runtime.JobID})
+				break
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// AddActiveJob adds job to active jobs table
+func (s *Store) AddActiveJob(ctx context.Context, jobID *peloton.JobID) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.activeJobs[jobID.GetValue()] = struct{}{}
+	return nil
+}
+
+// DeleteActiveJob deletes job from active jobs table
+func (s *Store) DeleteActiveJob(
+	ctx context.Context,
+	jobID *peloton.JobID,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.activeJobs, jobID.GetValue())
+	return nil
+}
+
+// GetActiveJobs returns the active jobs
+func (s *Store) GetActiveJobs(ctx context.Context) ([]*peloton.JobID, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	var jobIDs []*peloton.JobID
+	for id := range s.activeJobs {
+		jobIDs = append(jobIDs, &peloton.JobID{Value: id})
+	}
+	sort.Slice(jobIDs, func(i, j int) bool {
+		return jobIDs[i].GetValue() < jobIDs[j].GetValue()
+	})
+	return jobIDs, nil
+}
+
+// DeleteJob deletes a job and associated tasks, by job id.
+func (s *Store) DeleteJob(ctx context.Context, jobID string) error {
+	id := &peloton.JobID{Value: jobID}
+
+	if err := s.deletePodEvents(ctx, jobID); err != nil {
+		return err
+	}
+
+	updateIDs, err := s.GetUpdatesForJob(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	for _, updateID := range updateIDs {
+		if err := s.deleteSingleUpdate(ctx, updateID); err != nil {
+			return err
+		}
+	}
+
+	s.Lock()
+	delete(s.taskRuntimes, jobID)
+	delete(s.taskConfigs, jobID)
+	s.Unlock()
+
+	// delete all versions of the job configuration
+	if err := s.deletePartition(
+		ctx, &objects.JobConfigObject{JobID: id.GetValue()}); err != nil {
+		return err
+	}
+	return s.oClient.Delete(
+		ctx, &objects.JobRuntimeObject{JobID: id.GetValue()})
+}
+
+// GetJobRuntime returns the job runtime info
+func (s *Store) GetJobRuntime(
+	ctx context.Context,
+	jobID string,
+) (*job.RuntimeInfo, error) {
+	obj := &objects.JobRuntimeObject{JobID: jobID}
+	if err := s.oClient.Get(ctx, obj); err != nil {
+		if err == gocql.ErrNotFound {
+			return nil, yarpcerrors.NotFoundErrorf("job:%s not found", jobID)
+		}
+		return nil, err
+	}
+
+	runtime := &job.RuntimeInfo{}
+	if err := proto.Unmarshal(obj.RuntimeInfo, runtime); err != nil {
+		return nil, err
+	}
+
+	if runtime.GetRevision().GetVersion() < 1 {
+		// Older job which does not have changelog.
+		runtime.Revision = &peloton.ChangeLog{
+			CreatedAt: uint64(time.Now().UnixNano()),
+			UpdatedAt: uint64(time.Now().UnixNano()),
+			Version:   1,
+		}
+	}
+	return runtime, nil
+}
+
+// GetAllJobsInJobIndex returns the job summaries of all the jobs
+// in the job index table.
+func (s *Store) GetAllJobsInJobIndex(
+	ctx context.Context,
+) ([]*job.JobSummary, error) {
+	objs, err := s.scan(ctx, &objects.JobIndexObject{})
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []*job.JobSummary
+	for _, obj := range objs {
+		summary, err := obj.(*objects.JobIndexObject).ToJobSummary()
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// UpdateJobRuntime updates the job runtime info
+func (s *Store) UpdateJobRuntime(
+	ctx context.Context,
+	id *peloton.JobID,
+	runtime *job.RuntimeInfo,
+) error {
+	runtimeBuffer, err := proto.Marshal(runtime)
+	if err != nil {
+		return err
+	}
+
+	obj := &objects.JobRuntimeObject{
+		JobID:       id.GetValue(),
+		RuntimeInfo: runtimeBuffer,
+		State:       runtime.GetState().String(),
+		UpdateTime:  time.Now().UTC(),
+	}
+	return s.oClient.Update(ctx, obj, "RuntimeInfo", "State", "UpdateTime")
+}
+
+// SetMesosStreamID stores the mesos stream id for a framework name
+func (s *Store) SetMesosStreamID(
+	ctx context.Context,
+	frameworkName string,
+	mesosStreamID string,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.getFrameworkInfo(frameworkName).mesosStreamID = mesosStreamID
+	return nil
+}
+
+// SetMesosFrameworkID stores the mesos framework id for a framework name
+func (s *Store) SetMesosFrameworkID(
+	ctx context.Context,
+	frameworkName string,
+	frameworkID string,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.getFrameworkInfo(frameworkName).frameworkID = frameworkID
+	return nil
+}
+
+// getFrameworkInfo returns the framework info, creating it if needed.
+// Must be called with the write lock held.
+func (s *Store) getFrameworkInfo(frameworkName string) *frameworkInfo {
+	info, ok := s.frameworks[frameworkName]
+	if !ok {
+		info = &frameworkInfo{}
+		s.frameworks[frameworkName] = info
+	}
+	return info
+}
+
+// GetMesosStreamID reads the mesos stream id for a framework name
+func (s *Store) GetMesosStreamID(
+	ctx context.Context,
+	frameworkName string,
+) (string, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	info, ok := s.frameworks[frameworkName]
+	if !ok {
+		return "", fmt.Errorf(
+			"FrameworkInfo not found for framework %v", frameworkName)
+	}
+	return info.mesosStreamID, nil
+}
+
+// GetFrameworkID reads the framework id for a framework name
+func (s *Store) GetFrameworkID(
+	ctx context.Context,
+	frameworkName string,
+) (string, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	info, ok := s.frameworks[frameworkName]
+	if !ok {
+		return "", fmt.Errorf(
+			"FrameworkInfo not found for framework %v", frameworkName)
+	}
+	return info.frameworkID, nil
+}
+
+// CreateResourcePool creates a resource pool with the resource pool id
+// and the config value
+func (s *Store) CreateResourcePool(
+	ctx context.Context,
+	id *peloton.ResourcePoolID,
+	resPoolConfig *respool.ResourcePoolConfig,
+	owner string,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.respools[id.GetValue()]; ok {
+		return yarpcerrors.AlreadyExistsErrorf(
+			"%v is not applied, item could exist already", id.GetValue())
+	}
+	s.respools[id.GetValue()] =
+		proto.Clone(resPoolConfig).(*respool.ResourcePoolConfig)
+	return nil
+}
+
+// DeleteResourcePool deletes the resource pool
+func (s *Store) DeleteResourcePool(
+	ctx context.Context,
+	id *peloton.ResourcePoolID,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.respools, id.GetValue())
+	return nil
+}
+
+// UpdateResourcePool updates the resource pool config for a given
+// resource pool ID
+func (s *Store) UpdateResourcePool(
+	ctx context.Context,
+	id *peloton.ResourcePoolID,
+	resPoolConfig *respool.ResourcePoolConfig,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.respools[id.GetValue()]; !ok {
+		return yarpcerrors.NotFoundErrorf(
+			"resource pool %v not found", id.GetValue())
+	}
+	s.respools[id.GetValue()] =
+		proto.Clone(resPoolConfig).(*respool.ResourcePoolConfig)
+	return nil
+}
+
+// GetAllResourcePools gets all the resource pool configs
+func (s *Store) GetAllResourcePools(
+	ctx context.Context,
+) (map[string]*respool.ResourcePoolConfig, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	result := make(map[string]*respool.ResourcePoolConfig)
+	for id, config := range s.respools {
+		result[id] = proto.Clone(config).(*respool.ResourcePoolConfig)
+	}
+	return result, nil
+}
+
+// CreatePersistentVolume creates a persistent volume entry.
+func (s *Store) CreatePersistentVolume(
+	ctx context.Context,
+	volumeInfo *volume.PersistentVolumeInfo,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	id := volumeInfo.GetId().GetValue()
+	if _, ok := s.volumes[id]; ok {
+		return yarpcerrors.AlreadyExistsErrorf(
+			"%v is not applied, item could exist already", id)
+	}
+	now := time.Now().UTC()
+	s.volumes[id] = &volumeRecord{
+		info:       proto.Clone(volumeInfo).(*volume.PersistentVolumeInfo),
+		createTime: now,
+		updateTime: now,
+	}
+	return nil
+}
+
+// UpdatePersistentVolume updates the state and goal state of a
+// persistent volume.
+func (s *Store) UpdatePersistentVolume(
+	ctx context.Context,
+	volumeInfo *volume.PersistentVolumeInfo,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	id := volumeInfo.GetId().GetValue()
+	record, ok := s.volumes[id]
+	if !ok {
+		// update creates the row in Cassandra
+		record = &volumeRecord{
+			info: &volume.PersistentVolumeInfo{Id: volumeInfo.GetId()},
+		}
+		s.volumes[id] = record
+	}
+	record.info.State = volumeInfo.GetState()
+	record.info.GoalState = volumeInfo.GetGoalState()
+	record.updateTime = time.Now().UTC()
+	return nil
+}
+
+// GetPersistentVolume gets the persistent volume object.
+func (s *Store) GetPersistentVolume(
+	ctx context.Context,
+	volumeID *peloton.VolumeID,
+) (*volume.PersistentVolumeInfo, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	record, ok := s.volumes[volumeID.GetValue()]
+	if !ok {
+		return nil, &storage.VolumeNotFoundError{VolumeID: volumeID}
+	}
+	info := proto.Clone(record.info).(*volume.PersistentVolumeInfo)
+	info.CreateTime = record.createTime.String()
+	info.UpdateTime = record.updateTime.String()
+	return info, nil
+}
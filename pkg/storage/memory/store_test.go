@@ -0,0 +1,335 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/query"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/api/v0/volume"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/storage"
+	"github.com/uber/peloton/pkg/storage/connectors/memory"
+	"github.com/uber/peloton/pkg/storage/objects"
+	"github.com/uber/peloton/pkg/storage/orm"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type MemoryStoreTestSuite struct {
+	suite.Suite
+
+	ctx       context.Context
+	connector orm.Connector
+	store     *Store
+	jobID     *peloton.JobID
+}
+
+func (suite *MemoryStoreTestSuite) SetupTest() {
+	var err error
+	suite.ctx = context.Background()
+	suite.connector = memory.NewMemoryConnector()
+	suite.store, err = NewStore(suite.connector, tally.NoopScope)
+	suite.NoError(err)
+	suite.jobID = &peloton.JobID{Value: uuid.New()}
+}
+
+func TestMemoryStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(MemoryStoreTestSuite))
+}
+
+func (suite *MemoryStoreTestSuite) createJob(
+	name string,
+	state job.JobState,
+	version uint64,
+) {
+	config := &job.JobConfig{
+		Name:          name,
+		Type:          job.JobType_BATCH,
+		InstanceCount: 3,
+		OwningTeam:    "team",
+		ChangeLog:     &peloton.ChangeLog{Version: version},
+	}
+	runtime := &job.RuntimeInfo{
+		State:                state,
+		CreationTime:         time.Now().UTC().Format(time.RFC3339Nano),
+		ConfigurationVersion: version,
+		Revision:             &peloton.ChangeLog{Version: 1},
+	}
+	suite.NoError(suite.store.CreateJobConfig(
+		suite.ctx, suite.jobID, config, &models.ConfigAddOn{}, version, "owner"))
+	suite.NoError(suite.store.CreateJobRuntime(suite.ctx, suite.jobID, runtime))
+}
+
+// TestJobConfigAndRuntime tests that job configs and runtimes round-trip
+// through the store, and are visible to the storage objects sharing
+// the same connector
+func (suite *MemoryStoreTestSuite) TestJobConfigAndRuntime() {
+	_, err := suite.store.GetJobRuntime(suite.ctx, suite.jobID.GetValue())
+	suite.True(yarpcerrors.IsNotFound(err))
+
+	suite.createJob("job", job.JobState_RUNNING, 1)
+
+	config, _, err := suite.store.GetJobConfig(
+		suite.ctx, suite.jobID.GetValue())
+	suite.NoError(err)
+	suite.Equal("job", config.GetName())
+
+	runtime, err := suite.store.GetJobRuntime(
+		suite.ctx, suite.jobID.GetValue())
+	suite.NoError(err)
+	suite.Equal(job.JobState_RUNNING, runtime.GetState())
+
+	ormStore, err := objects.NewStore(suite.connector, tally.NoopScope)
+	suite.NoError(err)
+	ormRuntime, err := objects.NewJobRuntimeOps(ormStore).Get(
+		suite.ctx, suite.jobID)
+	suite.NoError(err)
+	suite.Equal(job.JobState_RUNNING, ormRuntime.GetState())
+
+	ids, err := suite.store.GetJobsByStates(
+		suite.ctx, []job.JobState{job.JobState_RUNNING})
+	suite.NoError(err)
+	suite.Len(ids, 1)
+
+	suite.NoError(suite.store.DeleteJob(suite.ctx, suite.jobID.GetValue()))
+	_, _, err = suite.store.GetJobConfigWithVersion(
+		suite.ctx, suite.jobID.GetValue(), 1)
+	suite.True(yarpcerrors.IsNotFound(err))
+}
+
+// TestQueryJobs tests querying jobs written to the job index
+func (suite *MemoryStoreTestSuite) TestQueryJobs() {
+	ormStore, err := objects.NewStore(suite.connector, tally.NoopScope)
+	suite.NoError(err)
+	jobIndexOps := objects.NewJobIndexOps(ormStore)
+
+	for i := 0; i < 3; i++ {
+		id := &peloton.JobID{Value: uuid.New()}
+		suite.NoError(jobIndexOps.Create(
+			suite.ctx,
+			id,
+			&job.JobConfig{
+				Name:       fmt.Sprintf("query-job-%d", i),
+				Type:       job.JobType_BATCH,
+				OwningTeam: "team",
+			},
+			&job.RuntimeInfo{
+				State:        job.JobState_RUNNING,
+				CreationTime: time.Now().Add(time.Duration(i) * time.Second).UTC().Format(time.RFC3339Nano),
+			},
+			nil,
+		))
+	}
+
+	_, summaries, total, err := suite.store.QueryJobs(
+		suite.ctx, nil, &job.QuerySpec{Name: "query-job"}, true)
+	suite.NoError(err)
+	suite.Equal(uint32(3), total)
+	suite.Len(summaries, 3)
+	// latest created job is returned first by default
+	suite.Equal("query-job-2", summaries[0].GetName())
+
+	_, summaries, _, err = suite.store.QueryJobs(
+		suite.ctx, nil, &job.QuerySpec{Name: "query-job-1"}, true)
+	suite.NoError(err)
+	suite.Len(summaries, 1)
+
+	_, _, _, err = suite.store.QueryJobs(
+		suite.ctx,
+		nil,
+		&job.QuerySpec{
+			Pagination: &query.PaginationSpec{
+				OrderBy: []*query.OrderBy{
+					{Property: &query.PropertyPath{Value: "unknown"}},
+				},
+			},
+		},
+		true,
+	)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestTasks tests task runtimes and configs
+func (suite *MemoryStoreTestSuite) TestTasks() {
+	suite.createJob("job", job.JobState_RUNNING, 1)
+
+	suite.NoError(suite.store.CreateTaskConfig(
+		suite.ctx,
+		suite.jobID,
+		-1,
+		&task.TaskConfig{Name: "default"},
+		&models.ConfigAddOn{},
+		1,
+	))
+	suite.NoError(suite.store.CreateTaskConfig(
+		suite.ctx,
+		suite.jobID,
+		1,
+		&task.TaskConfig{Name: "instance-1"},
+		&models.ConfigAddOn{},
+		1,
+	))
+
+	configs, _, err := suite.store.GetTaskConfigs(
+		suite.ctx, suite.jobID, []uint32{0, 1}, 1)
+	suite.NoError(err)
+	suite.Equal("default", configs[0].GetName())
+	suite.Equal("instance-1", configs[1].GetName())
+
+	for i := uint32(0); i < 3; i++ {
+		suite.NoError(suite.store.CreateTaskRuntime(
+			suite.ctx,
+			suite.jobID,
+			i,
+			&task.RuntimeInfo{
+				State: task.TaskState_RUNNING,
+				MesosTaskId: &mesos.TaskID{
+					Value: &[]string{
+						fmt.Sprintf("%s-%d-1", suite.jobID.GetValue(), i),
+					}[0],
+				},
+				Revision: &peloton.ChangeLog{Version: 1},
+			},
+			"owner",
+			job.JobType_BATCH,
+		))
+	}
+
+	summary, err := suite.store.GetTaskStateSummaryForJob(
+		suite.ctx, suite.jobID)
+	suite.NoError(err)
+	suite.Equal(uint32(3), summary[task.TaskState_RUNNING.String()])
+
+	runtimes, err := suite.store.GetTaskRuntimesForJobByRange(
+		suite.ctx, suite.jobID, &task.InstanceRange{From: 1, To: 3})
+	suite.NoError(err)
+	suite.Len(runtimes, 2)
+
+	events, err := suite.store.GetPodEvents(
+		suite.ctx, suite.jobID.GetValue(), 0)
+	suite.NoError(err)
+	suite.Len(events, 1)
+
+	suite.NoError(suite.store.DeleteTaskRuntime(suite.ctx, suite.jobID, 0))
+	_, err = suite.store.GetTaskRuntime(suite.ctx, suite.jobID, 0)
+	suite.True(yarpcerrors.IsNotFound(err))
+}
+
+// TestUpdates tests creating updates, recording their progress
+// and workflow events
+func (suite *MemoryStoreTestSuite) TestUpdates() {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	updateID := &peloton.UpdateID{Value: uuid.New()}
+	updateInfo := &models.UpdateModel{
+		UpdateID:         updateID,
+		JobID:            suite.jobID,
+		Type:             models.WorkflowType_UPDATE,
+		State:            update.State_INITIALIZED,
+		InstancesTotal:   3,
+		InstancesDone:    1,
+		JobConfigVersion: 2,
+		CreationTime:     now,
+		UpdateTime:       now,
+	}
+	suite.NoError(suite.store.CreateUpdate(suite.ctx, updateInfo))
+	suite.Error(suite.store.CreateUpdate(suite.ctx, updateInfo))
+
+	u, err := suite.store.GetUpdate(suite.ctx, updateID)
+	suite.NoError(err)
+	suite.Equal(uint32(0), u.GetInstancesDone())
+
+	suite.NoError(suite.store.WriteUpdateProgress(suite.ctx, &models.UpdateModel{
+		UpdateID:         updateID,
+		State:            update.State_ROLLING_FORWARD,
+		PrevState:        update.State_INITIALIZED,
+		InstancesDone:    2,
+		InstancesCurrent: []uint32{2},
+		UpdateTime:       now,
+	}))
+	progress, err := suite.store.GetUpdateProgress(suite.ctx, updateID)
+	suite.NoError(err)
+	suite.Equal(update.State_ROLLING_FORWARD, progress.GetState())
+	suite.Equal(uint32(2), progress.GetInstancesDone())
+	suite.Equal([]uint32{2}, progress.GetInstancesCurrent())
+
+	for _, state := range []update.State{
+		update.State_ROLLING_FORWARD,
+		update.State_ROLLING_FORWARD,
+		update.State_SUCCEEDED,
+	} {
+		suite.NoError(suite.store.AddWorkflowEvent(
+			suite.ctx, updateID, 0, models.WorkflowType_UPDATE, state, ""))
+	}
+	events, err := suite.store.GetWorkflowEvents(suite.ctx, updateID, 0, 0)
+	suite.NoError(err)
+	suite.Len(events, 2)
+
+	ids, err := suite.store.GetUpdatesForJob(suite.ctx, suite.jobID.GetValue())
+	suite.NoError(err)
+	suite.Len(ids, 1)
+
+	suite.NoError(suite.store.DeleteUpdate(suite.ctx, updateID, suite.jobID, 2))
+	_, err = suite.store.GetUpdate(suite.ctx, updateID)
+	suite.True(yarpcerrors.IsNotFound(err))
+	events, err = suite.store.GetWorkflowEvents(suite.ctx, updateID, 0, 0)
+	suite.NoError(err)
+	suite.Empty(events)
+}
+
+// TestResourcePoolsAndVolumes tests resource pools and persistent volumes
+func (suite *MemoryStoreTestSuite) TestResourcePoolsAndVolumes() {
+	id := &peloton.ResourcePoolID{Value: "respool"}
+	config := &respool.ResourcePoolConfig{Name: "respool"}
+	suite.NoError(suite.store.CreateResourcePool(suite.ctx, id, config, "owner"))
+	suite.Error(suite.store.CreateResourcePool(suite.ctx, id, config, "owner"))
+
+	respools, err := suite.store.GetAllResourcePools(suite.ctx)
+	suite.NoError(err)
+	suite.Equal("respool", respools["respool"].GetName())
+
+	suite.NoError(suite.store.DeleteResourcePool(suite.ctx, id))
+	suite.Error(suite.store.UpdateResourcePool(suite.ctx, id, config))
+
+	volumeID := &peloton.VolumeID{Value: uuid.New()}
+	_, err = suite.store.GetPersistentVolume(suite.ctx, volumeID)
+	_, ok := err.(*storage.VolumeNotFoundError)
+	suite.True(ok)
+
+	suite.NoError(suite.store.CreatePersistentVolume(suite.ctx, &volume.PersistentVolumeInfo{
+		Id:    volumeID,
+		State: volume.VolumeState_INITIALIZED,
+	}))
+	suite.NoError(suite.store.UpdatePersistentVolume(suite.ctx, &volume.PersistentVolumeInfo{
+		Id:    volumeID,
+		State: volume.VolumeState_CREATED,
+	}))
+	info, err := suite.store.GetPersistentVolume(suite.ctx, volumeID)
+	suite.NoError(err)
+	suite.Equal(volume.VolumeState_CREATED, info.GetState())
+}
@@ -0,0 +1,611 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	mesos "github.com/uber/peloton/.gen/mesos/v1"
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/task"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/util"
+	"github.com/uber/peloton/pkg/storage/cassandra"
+	"github.com/uber/peloton/pkg/storage/objects"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	// Task query sort by field
+	taskCreationTimeField = "creation_time"
+	taskHostField         = "host"
+	taskInstanceIDField   = "instanceId"
+	taskMessageField      = "message"
+	taskNameField         = "name"
+	taskReasonField       = "reason"
+	taskStateField        = "state"
+)
+
+// CreateTaskRuntime creates a task runtime for a peloton job
+func (s *Store) CreateTaskRuntime(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	instanceID uint32,
+	runtime *task.RuntimeInfo,
+	owner string,
+	jobType job.JobType,
+) error {
+	s.setTaskRuntime(jobID, instanceID, runtime)
+
+	if err := s.podEventsOps.Create(
+		ctx, jobID, instanceID, runtime); err != nil {
+		log.WithError(err).
+			WithField("job_id", jobID.GetValue()).
+			WithField("instance_id", instanceID).
+			Error("unable to log task state changes")
+		return err
+	}
+	return nil
+}
+
+// setTaskRuntime stores a copy of the task runtime
+func (s *Store) setTaskRuntime(
+	jobID *peloton.JobID,
+	instanceID uint32,
+	runtime *task.RuntimeInfo,
+) {
+	s.Lock()
+	defer s.Unlock()
+
+	runtimes, ok := s.taskRuntimes[jobID.GetValue()]
+	if !ok {
+		runtimes = make(map[uint32]*task.RuntimeInfo)
+		s.taskRuntimes[jobID.GetValue()] = runtimes
+	}
+	runtimes[instanceID] = proto.Clone(runtime).(*task.RuntimeInfo)
+}
+
+// getTaskRuntimes returns copies of the task runtimes of a job, for which
+// the filter returns true
+func (s *Store) getTaskRuntimes(
+	jobID string,
+	filter func(uint32, *task.RuntimeInfo) bool,
+) map[uint32]*task.RuntimeInfo {
+	s.RLock()
+	defer s.RUnlock()
+
+	result := make(map[uint32]*task.RuntimeInfo)
+	for instanceID, runtime := range s.taskRuntimes[jobID] {
+		if filter == nil || filter(instanceID, runtime) {
+			result[instanceID] = proto.Clone(runtime).(*task.RuntimeInfo)
+		}
+	}
+	return result
+}
+
+// GetPodEvents returns pod events for a Job + Instance + PodID (optional).
+// If PodID is not provided, the pod events of the latest run are returned.
+// Pod events are sorted by PodID + Timestamp in descending order.
+func (s *Store) GetPodEvents(
+	ctx context.Context,
+	jobID string,
+	instanceID uint32,
+	podID ...string,
+) ([]*task.PodEvent, error) {
+	objs, err := s.oClient.GetAll(ctx, &objects.PodEventsObject{
+		JobID:      jobID,
+		InstanceID: instanceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, nil
+	}
+
+	// Events are sorted in descending order by run id and update time.
+	runID := objs[0].(*objects.PodEventsObject).RunID
+	if len(podID) > 0 && len(podID[0]) > 0 {
+		if runID, err = util.ParseRunID(podID[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	var podEvents []*task.PodEvent
+	for _, obj := range objs {
+		event := obj.(*objects.PodEventsObject)
+		if event.RunID != runID {
+			continue
+		}
+
+		mesosTaskID := fmt.Sprintf("%s-%d-%d",
+			event.JobID, event.InstanceID, event.RunID)
+		prevMesosTaskID := fmt.Sprintf("%s-%d-%d",
+			event.JobID, event.InstanceID, event.PreviousRunID)
+		desiredMesosTaskID := fmt.Sprintf("%s-%d-%d",
+			event.JobID, event.InstanceID, event.DesiredRunID)
+
+		podEvents = append(podEvents, &task.PodEvent{
+			TaskId:               &mesos.TaskID{Value: &mesosTaskID},
+			PrevTaskId:           &mesos.TaskID{Value: &prevMesosTaskID},
+			DesriedTaskId:        &mesos.TaskID{Value: &desiredMesosTaskID},
+			Timestamp:            event.UpdateTime.Time().Format(time.RFC3339),
+			ConfigVersion:        event.ConfigVersion,
+			DesiredConfigVersion: event.DesiredConfigVersion,
+			ActualState:          event.ActualState,
+			GoalState:            event.GoalState,
+			Message:              event.Message,
+			Reason:               event.Reason,
+			AgentID:              event.AgentID,
+			Hostname:             event.Hostname,
+			Healthy:              event.Healthy,
+		})
+	}
+	return podEvents, nil
+}
+
+// DeletePodEvents deletes the pod events for provided JobID,
+// InstanceID and RunID in the range [fromRunID-toRunID)
+func (s *Store) DeletePodEvents(
+	ctx context.Context,
+	jobID string,
+	instanceID uint32,
+	fromRunID uint64,
+	toRunID uint64,
+) error {
+	objs, err := s.oClient.GetAll(ctx, &objects.PodEventsObject{
+		JobID:      jobID,
+		InstanceID: instanceID,
+	})
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		event := obj.(*objects.PodEventsObject)
+		if event.RunID < fromRunID || event.RunID >= toRunID {
+			continue
+		}
+		if err := s.oClient.Delete(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletePodEvents deletes the pod events of all instances of a job
+func (s *Store) deletePodEvents(ctx context.Context, jobID string) error {
+	objs, err := s.scan(ctx, &objects.PodEventsObject{})
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		event := obj.(*objects.PodEventsObject)
+		if event.JobID != jobID {
+			continue
+		}
+		if err := s.oClient.Delete(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTasksForJob returns all the task runtimes (no configuration) in a map
+// of tasks.TaskInfo for a peloton job
+func (s *Store) GetTasksForJob(
+	ctx context.Context,
+	id *peloton.JobID,
+) (map[uint32]*task.TaskInfo, error) {
+	result := make(map[uint32]*task.TaskInfo)
+	for instanceID, runtime := range s.getTaskRuntimes(id.GetValue(), nil) {
+		result[instanceID] = &task.TaskInfo{
+			Runtime:    runtime,
+			InstanceId: instanceID,
+			JobId:      id,
+		}
+	}
+	return result, nil
+}
+
+// CreateTaskConfig creates the task configuration
+func (s *Store) CreateTaskConfig(
+	ctx context.Context,
+	id *peloton.JobID,
+	instanceID int64,
+	taskConfig *task.TaskConfig,
+	configAddOn *models.ConfigAddOn,
+	version uint64,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	versions, ok := s.taskConfigs[id.GetValue()]
+	if !ok {
+		versions = make(map[uint64]map[int64]*taskConfigRecord)
+		s.taskConfigs[id.GetValue()] = versions
+	}
+	configs, ok := versions[version]
+	if !ok {
+		configs = make(map[int64]*taskConfigRecord)
+		versions[version] = configs
+	}
+	configs[instanceID] = &taskConfigRecord{
+		config:      proto.Clone(taskConfig).(*task.TaskConfig),
+		configAddOn: proto.Clone(configAddOn).(*models.ConfigAddOn),
+	}
+	return nil
+}
+
+// getTaskConfigRecord returns the task config of an instance, or the
+// default task config of the job if the instance has no specific one.
+// Must be called with the lock held.
+func (s *Store) getTaskConfigRecord(
+	jobID string,
+	instanceID uint32,
+	version uint64,
+) (*taskConfigRecord, bool) {
+	configs := s.taskConfigs[jobID][version]
+	if record, ok := configs[int64(instanceID)]; ok {
+		return record, true
+	}
+	record, ok := configs[common.DefaultTaskConfigID]
+	return record, ok
+}
+
+// GetTaskConfig returns the task specific config
+func (s *Store) GetTaskConfig(
+	ctx context.Context,
+	id *peloton.JobID,
+	instanceID uint32,
+	version uint64,
+) (*task.TaskConfig, *models.ConfigAddOn, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	record, ok := s.getTaskConfigRecord(id.GetValue(), instanceID, version)
+	if !ok {
+		return nil, nil, yarpcerrors.NotFoundErrorf(
+			"task:%s not found", fmt.Sprintf(taskIDFmt, id.GetValue(), instanceID))
+	}
+	return proto.Clone(record.config).(*task.TaskConfig),
+		proto.Clone(record.configAddOn).(*models.ConfigAddOn),
+		nil
+}
+
+// GetTaskConfigs returns the task configs for a list of instance IDs,
+// job ID and config version.
+func (s *Store) GetTaskConfigs(
+	ctx context.Context,
+	id *peloton.JobID,
+	instanceIDs []uint32,
+	version uint64,
+) (map[uint32]*task.TaskConfig, *models.ConfigAddOn, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	taskConfigMap := make(map[uint32]*task.TaskConfig)
+	var configAddOn *models.ConfigAddOn
+	for _, instanceID := range instanceIDs {
+		record, ok := s.getTaskConfigRecord(id.GetValue(), instanceID, version)
+		if !ok {
+			// Either every instance has a override config or
+			// we have a default config.
+			return nil, nil, fmt.Errorf("unable to read default task config")
+		}
+		taskConfigMap[instanceID] = proto.Clone(record.config).(*task.TaskConfig)
+		configAddOn = record.configAddOn
+	}
+	if configAddOn != nil {
+		configAddOn = proto.Clone(configAddOn).(*models.ConfigAddOn)
+	}
+	return taskConfigMap, configAddOn, nil
+}
+
+// getTask returns the runtime and the config of a task
+func (s *Store) getTask(
+	ctx context.Context,
+	jobID string,
+	instanceID uint32,
+) (*task.TaskInfo, error) {
+	id := &peloton.JobID{Value: jobID}
+	runtime, err := s.GetTaskRuntime(ctx, id, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := s.GetTaskConfig(
+		ctx, id, instanceID, runtime.GetConfigVersion())
+	if err != nil {
+		return nil, err
+	}
+	return &task.TaskInfo{
+		Runtime:    runtime,
+		Config:     config,
+		InstanceId: instanceID,
+		JobId:      id,
+	}, nil
+}
+
+// GetTaskIDsForJobAndState returns a list of instance-ids for a peloton job
+// with certain state.
+func (s *Store) GetTaskIDsForJobAndState(
+	ctx context.Context,
+	id *peloton.JobID,
+	state string,
+) ([]uint32, error) {
+	runtimes := s.getTaskRuntimes(
+		id.GetValue(),
+		func(_ uint32, runtime *task.RuntimeInfo) bool {
+			return runtime.GetState().String() == state
+		})
+
+	var result []uint32
+	for instanceID := range runtimes {
+		result = append(result, instanceID)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result, nil
+}
+
+// GetTasksForJobAndStates returns the tasks for a peloton job which are in
+// one of the specified states.
+func (s *Store) GetTasksForJobAndStates(
+	ctx context.Context,
+	id *peloton.JobID,
+	states []task.TaskState,
+) (map[uint32]*task.TaskInfo, error) {
+	runtimes := s.getTaskRuntimes(
+		id.GetValue(),
+		func(_ uint32, runtime *task.RuntimeInfo) bool {
+			return util.ContainsTaskState(states, runtime.GetState())
+		})
+
+	result := make(map[uint32]*task.TaskInfo)
+	for instanceID := range runtimes {
+		taskInfo, err := s.getTask(ctx, id.GetValue(), instanceID)
+		if err != nil {
+			return nil, err
+		}
+		result[instanceID] = taskInfo
+	}
+	return result, nil
+}
+
+// GetTaskStateSummaryForJob returns the tasks count for a peloton job
+// for each task state
+func (s *Store) GetTaskStateSummaryForJob(
+	ctx context.Context,
+	id *peloton.JobID,
+) (map[string]uint32, error) {
+	resultMap := make(map[string]uint32)
+	for _, state := range task.TaskState_name {
+		resultMap[state] = 0
+	}
+	for _, runtime := range s.getTaskRuntimes(id.GetValue(), nil) {
+		resultMap[runtime.GetState().String()]++
+	}
+	return resultMap, nil
+}
+
+// inInstanceRange returns a filter for task runtimes with instance ID
+// in the given range. All instances are in a nil range.
+func inInstanceRange(
+	instanceRange *task.InstanceRange,
+) func(uint32, *task.RuntimeInfo) bool {
+	return func(instanceID uint32, _ *task.RuntimeInfo) bool {
+		return instanceRange == nil ||
+			(instanceID >= instanceRange.GetFrom() &&
+				instanceID < instanceRange.GetTo())
+	}
+}
+
+// GetTaskRuntimesForJobByRange returns the task runtimes of a job by
+// instance ID range.
+func (s *Store) GetTaskRuntimesForJobByRange(
+	ctx context.Context,
+	id *peloton.JobID,
+	instanceRange *task.InstanceRange,
+) (map[uint32]*task.RuntimeInfo, error) {
+	return s.getTaskRuntimes(id.GetValue(), inInstanceRange(instanceRange)), nil
+}
+
+// GetTasksForJobByRange returns the TaskInfo of a job by instance ID range.
+func (s *Store) GetTasksForJobByRange(
+	ctx context.Context,
+	id *peloton.JobID,
+	instanceRange *task.InstanceRange,
+) (map[uint32]*task.TaskInfo, error) {
+	runtimes := s.getTaskRuntimes(id.GetValue(), inInstanceRange(instanceRange))
+
+	// map of configVersion -> list of instance IDs with that version
+	configVersions := make(map[uint64][]uint32)
+	for instanceID, runtime := range runtimes {
+		configVersions[runtime.GetConfigVersion()] = append(
+			configVersions[runtime.GetConfigVersion()], instanceID)
+	}
+
+	result := make(map[uint32]*task.TaskInfo)
+	for configVersion, instances := range configVersions {
+		configs, _, err := s.GetTaskConfigs(ctx, id, instances, configVersion)
+		if err != nil {
+			return result, err
+		}
+		for _, instanceID := range instances {
+			result[instanceID] = &task.TaskInfo{
+				InstanceId: instanceID,
+				JobId:      id,
+				Config:     configs[instanceID],
+				Runtime:    runtimes[instanceID],
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetTaskRuntime returns the runtime of a task
+func (s *Store) GetTaskRuntime(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	instanceID uint32,
+) (*task.RuntimeInfo, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	runtime, ok := s.taskRuntimes[jobID.GetValue()][instanceID]
+	if !ok {
+		return nil, yarpcerrors.NotFoundErrorf(
+			"task:%s not found", fmt.Sprintf(taskIDFmt, jobID.GetValue(), instanceID))
+	}
+	return proto.Clone(runtime).(*task.RuntimeInfo), nil
+}
+
+// UpdateTaskRuntime updates a task for a peloton job
+func (s *Store) UpdateTaskRuntime(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	instanceID uint32,
+	runtime *task.RuntimeInfo,
+	jobType job.JobType,
+) error {
+	s.setTaskRuntime(jobID, instanceID, runtime)
+
+	// failing to add a pod event does not fail the update,
+	// same as in the Cassandra store
+	if err := s.podEventsOps.Create(
+		ctx, jobID, instanceID, runtime); err != nil {
+		log.WithError(err).
+			WithField("job_id", jobID.GetValue()).
+			WithField("instance_id", instanceID).
+			Debug("unable to log task state changes")
+	}
+	return nil
+}
+
+// GetTaskForJob returns a task by jobID and instanceID
+func (s *Store) GetTaskForJob(
+	ctx context.Context,
+	jobID string,
+	instanceID uint32,
+) (map[uint32]*task.TaskInfo, error) {
+	taskInfo, err := s.getTask(ctx, jobID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	return map[uint32]*task.TaskInfo{instanceID: taskInfo}, nil
+}
+
+// DeleteTaskRuntime deletes runtime of a particular task. The pod events
+// and the task configurations are retained.
+func (s *Store) DeleteTaskRuntime(
+	ctx context.Context,
+	id *peloton.JobID,
+	instanceID uint32,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.taskRuntimes[id.GetValue()], instanceID)
+	return nil
+}
+
+// GetTaskByID returns the task info of a task
+func (s *Store) GetTaskByID(
+	ctx context.Context,
+	taskID string,
+) (*task.TaskInfo, error) {
+	jobID, instanceID, err := util.ParseTaskID(taskID)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid task id")
+	}
+	return s.getTask(ctx, jobID, instanceID)
+}
+
+// QueryTasks returns the tasks filtered on states(spec.TaskStates),
+// names and hosts in the given offset..offset+limit range.
+func (s *Store) QueryTasks(
+	ctx context.Context,
+	id *peloton.JobID,
+	spec *task.QuerySpec,
+) ([]*task.TaskInfo, uint32, error) {
+	var tasks map[uint32]*task.TaskInfo
+	var err error
+	if len(spec.GetTaskStates()) == 0 {
+		tasks, err = s.GetTasksForJobByRange(ctx, id, nil)
+	} else {
+		tasks, err = s.GetTasksForJobAndStates(ctx, id, spec.GetTaskStates())
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var sortedTasks []*task.TaskInfo
+	for _, taskInfo := range tasks {
+		if len(spec.GetNames()) > 0 &&
+			!util.Contains(spec.GetNames(), taskInfo.GetConfig().GetName()) {
+			continue
+		}
+		if len(spec.GetHosts()) > 0 &&
+			!util.Contains(spec.GetHosts(), taskInfo.GetRuntime().GetHost()) {
+			continue
+		}
+		sortedTasks = append(sortedTasks, taskInfo)
+	}
+
+	orderByList := spec.GetPagination().GetOrderBy()
+	for _, orderBy := range orderByList {
+		switch orderBy.GetProperty().GetValue() {
+		case
+			taskCreationTimeField,
+			taskHostField,
+			taskInstanceIDField,
+			taskMessageField,
+			taskNameField,
+			taskReasonField,
+			taskStateField:
+			continue
+		}
+		return nil, 0, yarpcerrors.InvalidArgumentErrorf(
+			"Sort only supports fields: creation_time, host, instanceId, " +
+				"message, name, reason, state")
+	}
+	sort.Slice(sortedTasks, func(i, j int) bool {
+		return cassandra.Less(orderByList, sortedTasks[i], sortedTasks[j])
+	})
+
+	offset := spec.GetPagination().GetOffset()
+	limit := _defaultQueryLimit
+	if spec.GetPagination().GetLimit() != 0 {
+		limit = spec.GetPagination().GetLimit()
+	}
+	end := offset + limit
+	if end > uint32(len(sortedTasks)) {
+		end = uint32(len(sortedTasks))
+	}
+
+	var result []*task.TaskInfo
+	if offset < end {
+		result = sortedTasks[offset:end]
+	}
+	return result, uint32(len(sortedTasks)), nil
+}
@@ -0,0 +1,420 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// CreateUpdate creates a new update. If it already exists,
+// the create will return an error.
+func (s *Store) CreateUpdate(
+	ctx context.Context,
+	updateInfo *models.UpdateModel,
+) error {
+	if _, err := time.Parse(
+		time.RFC3339Nano, updateInfo.GetCreationTime()); err != nil {
+		return errors.Wrap(
+			yarpcerrors.InvalidArgumentErrorf(err.Error()),
+			"fail to parse creationTime")
+	}
+	if _, err := time.Parse(
+		time.RFC3339Nano, updateInfo.GetUpdateTime()); err != nil {
+		return errors.Wrap(
+			yarpcerrors.InvalidArgumentErrorf(err.Error()),
+			"fail to parse updateTime")
+	}
+
+	s.Lock()
+	id := updateInfo.GetUpdateID().GetValue()
+	if _, ok := s.updates[id]; ok {
+		s.Unlock()
+		return yarpcerrors.AlreadyExistsErrorf(
+			"%v is not applied, item could exist already", id)
+	}
+	u := proto.Clone(updateInfo).(*models.UpdateModel)
+	u.InstancesDone = 0
+	u.InstancesFailed = 0
+	u.InstancesCurrent = nil
+	u.CompletionTime = ""
+	s.updates[id] = u
+	s.Unlock()
+
+	if err := s.cleanupPreviousUpdatesForJob(
+		ctx, updateInfo.GetJobID()); err != nil {
+		log.WithError(err).
+			WithField("job_id", updateInfo.GetJobID().GetValue()).
+			Info("failed to clean up previous updates")
+	}
+	return nil
+}
+
+// cleanupPreviousUpdatesForJob keeps up to _maxUpdatesPerJob updates and
+// non-updates of a job, and deletes the older ones along with their job
+// configurations.
+func (s *Store) cleanupPreviousUpdatesForJob(
+	ctx context.Context,
+	jobID *peloton.JobID,
+) error {
+	updateIDs, err := s.GetUpdatesForJob(ctx, jobID.GetValue())
+	if err != nil {
+		return err
+	}
+
+	var updateList, nonUpdateList []*models.UpdateModel
+	for _, updateID := range updateIDs {
+		u, err := s.GetUpdate(ctx, updateID)
+		if err != nil {
+			continue
+		}
+		if u.GetType() == models.WorkflowType_UPDATE {
+			updateList = append(updateList, u)
+		} else {
+			nonUpdateList = append(nonUpdateList, u)
+		}
+	}
+
+	for _, list := range [][]*models.UpdateModel{updateList, nonUpdateList} {
+		if len(list) <= _maxUpdatesPerJob {
+			continue
+		}
+		// keep the updates with the latest job configuration versions
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].GetJobConfigVersion() > list[j].GetJobConfigVersion()
+		})
+		for _, u := range list[_maxUpdatesPerJob:] {
+			s.DeleteUpdate(ctx, u.GetUpdateID(), jobID, u.GetJobConfigVersion())
+		}
+	}
+	return nil
+}
+
+// DeleteUpdate deletes the update and all job and task configurations
+// created for the update.
+func (s *Store) DeleteUpdate(
+	ctx context.Context,
+	updateID *peloton.UpdateID,
+	jobID *peloton.JobID,
+	jobConfigVersion uint64,
+) error {
+	s.Lock()
+	delete(s.taskConfigs[jobID.GetValue()], jobConfigVersion)
+	s.Unlock()
+
+	if err := s.jobConfigOps.Delete(ctx, jobID, jobConfigVersion); err != nil {
+		return err
+	}
+	return s.deleteSingleUpdate(ctx, updateID)
+}
+
+// deleteSingleUpdate deletes an update along with its workflow events
+func (s *Store) deleteSingleUpdate(
+	ctx context.Context,
+	id *peloton.UpdateID,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.updates[id.GetValue()]; !ok {
+		return yarpcerrors.NotFoundErrorf("update not found")
+	}
+	delete(s.updates, id.GetValue())
+	delete(s.jobUpdateEvents, id.GetValue())
+	delete(s.podWorkflowEvents, id.GetValue())
+	return nil
+}
+
+// GetUpdate fetches the job update
+func (s *Store) GetUpdate(
+	ctx context.Context,
+	id *peloton.UpdateID,
+) (*models.UpdateModel, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	u, ok := s.updates[id.GetValue()]
+	if !ok {
+		return nil, yarpcerrors.NotFoundErrorf("update not found")
+	}
+	return proto.Clone(u).(*models.UpdateModel), nil
+}
+
+// WriteUpdateProgress writes the progress of the job update.
+// The inputs to this function are the only mutable fields in update.
+func (s *Store) WriteUpdateProgress(
+	ctx context.Context,
+	updateInfo *models.UpdateModel,
+) error {
+	if _, err := time.Parse(
+		time.RFC3339Nano, updateInfo.GetUpdateTime()); err != nil {
+		return errors.Wrap(
+			yarpcerrors.InvalidArgumentErrorf(err.Error()),
+			"fail to parse updateTime")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	u := s.getOrCreateUpdate(updateInfo.GetUpdateID())
+	u.UpdateTime = updateInfo.GetUpdateTime()
+
+	// updateInfo can either have updateTime set only, or
+	// set State, PreState and other fields altogether.
+	if updateInfo.GetState() != update.State_INVALID {
+		u.State = updateInfo.GetState()
+		u.PrevState = updateInfo.GetPrevState()
+		u.InstancesDone = updateInfo.GetInstancesDone()
+		u.InstancesFailed = updateInfo.GetInstancesFailed()
+		u.InstancesCurrent = append(
+			[]uint32(nil), updateInfo.GetInstancesCurrent()...)
+	}
+	if updateInfo.GetOpaqueData() != nil {
+		u.OpaqueData = proto.Clone(
+			updateInfo.GetOpaqueData()).(*peloton.OpaqueData)
+	}
+	if len(updateInfo.GetCompletionTime()) != 0 {
+		u.CompletionTime = updateInfo.GetCompletionTime()
+	}
+	return nil
+}
+
+// ModifyUpdate modify the progress of an update,
+// instances to update/remove/add and the job config version
+func (s *Store) ModifyUpdate(
+	ctx context.Context,
+	updateInfo *models.UpdateModel,
+) error {
+	if _, err := time.Parse(
+		time.RFC3339Nano, updateInfo.GetUpdateTime()); err != nil {
+		return errors.Wrap(
+			yarpcerrors.InvalidArgumentErrorf(err.Error()),
+			"fail to parse updateTime")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	u := s.getOrCreateUpdate(updateInfo.GetUpdateID())
+	u.State = updateInfo.GetState()
+	u.PrevState = updateInfo.GetPrevState()
+	u.InstancesDone = updateInfo.GetInstancesDone()
+	u.InstancesFailed = updateInfo.GetInstancesFailed()
+	u.InstancesCurrent = append(
+		[]uint32(nil), updateInfo.GetInstancesCurrent()...)
+	u.InstancesAdded = append(
+		[]uint32(nil), updateInfo.GetInstancesAdded()...)
+	u.InstancesUpdated = append(
+		[]uint32(nil), updateInfo.GetInstancesUpdated()...)
+	u.InstancesRemoved = append(
+		[]uint32(nil), updateInfo.GetInstancesRemoved()...)
+	u.InstancesTotal = updateInfo.GetInstancesTotal()
+	u.JobConfigVersion = updateInfo.GetJobConfigVersion()
+	u.PrevJobConfigVersion = updateInfo.GetPrevJobConfigVersion()
+	u.UpdateTime = updateInfo.GetUpdateTime()
+	if updateInfo.GetOpaqueData() != nil {
+		u.OpaqueData = proto.Clone(
+			updateInfo.GetOpaqueData()).(*peloton.OpaqueData)
+	}
+	return nil
+}
+
+// getOrCreateUpdate returns the stored update, creating an empty one if it
+// does not exist, as an update statement in Cassandra would.
+// Must be called with the write lock held.
+func (s *Store) getOrCreateUpdate(id *peloton.UpdateID) *models.UpdateModel {
+	u, ok := s.updates[id.GetValue()]
+	if !ok {
+		u = &models.UpdateModel{UpdateID: id}
+		s.updates[id.GetValue()] = u
+	}
+	return u
+}
+
+// GetUpdateProgress fetches the job update progress, which includes the
+// instances already updated, instances being updated and the current
+// state of the update.
+func (s *Store) GetUpdateProgress(
+	ctx context.Context,
+	id *peloton.UpdateID,
+) (*models.UpdateModel, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	u, ok := s.updates[id.GetValue()]
+	if !ok {
+		return nil, yarpcerrors.NotFoundErrorf("update not found")
+	}
+	return &models.UpdateModel{
+		UpdateID:         id,
+		State:            u.GetState(),
+		PrevState:        u.GetPrevState(),
+		InstancesTotal:   u.GetInstancesTotal(),
+		InstancesDone:    u.GetInstancesDone(),
+		InstancesFailed:  u.GetInstancesFailed(),
+		InstancesCurrent: append([]uint32(nil), u.GetInstancesCurrent()...),
+		UpdateTime:       u.GetUpdateTime(),
+		CompletionTime:   u.GetCompletionTime(),
+	}, nil
+}
+
+// GetUpdatesForJob returns the list of job updates created for a given job,
+// latest first.
+func (s *Store) GetUpdatesForJob(
+	ctx context.Context,
+	jobID string,
+) ([]*peloton.UpdateID, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	var updates []*models.UpdateModel
+	for _, u := range s.updates {
+		if u.GetJobID().GetValue() == jobID {
+			updates = append(updates, u)
+		}
+	}
+	sort.Slice(updates, func(i, j int) bool {
+		return parseTime(updates[i].GetCreationTime()).After(
+			parseTime(updates[j].GetCreationTime()))
+	})
+
+	var updateIDs []*peloton.UpdateID
+	for _, u := range updates {
+		updateIDs = append(updateIDs, &peloton.UpdateID{
+			Value: u.GetUpdateID().GetValue(),
+		})
+	}
+	return updateIDs, nil
+}
+
+func parseTime(v string) time.Time {
+	r, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}
+	}
+	return r
+}
+
+// AddJobUpdateEvent adds an update state change event for a job
+func (s *Store) AddJobUpdateEvent(
+	ctx context.Context,
+	updateID *peloton.UpdateID,
+	updateType models.WorkflowType,
+	updateState update.State,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.jobUpdateEvents[updateID.GetValue()] = append(
+		[]*workflowEvent{{
+			workflowType: updateType,
+			state:        updateState,
+			createTime:   time.Now(),
+		}},
+		s.jobUpdateEvents[updateID.GetValue()]...)
+	return nil
+}
+
+// GetJobUpdateEvents gets update state change events for a job
+// in descending create timestamp order
+func (s *Store) GetJobUpdateEvents(
+	ctx context.Context,
+	updateID *peloton.UpdateID,
+) ([]*stateless.WorkflowEvent, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return toWorkflowEvents(s.jobUpdateEvents[updateID.GetValue()]), nil
+}
+
+// AddWorkflowEvent adds workflow events for an update and instance
+// to track the progress
+func (s *Store) AddWorkflowEvent(
+	ctx context.Context,
+	updateID *peloton.UpdateID,
+	instanceID uint32,
+	workflowType models.WorkflowType,
+	workflowState update.State,
+	message string,
+) error {
+	s.Lock()
+	defer s.Unlock()
+
+	instances, ok := s.podWorkflowEvents[updateID.GetValue()]
+	if !ok {
+		instances = make(map[uint32][]*workflowEvent)
+		s.podWorkflowEvents[updateID.GetValue()] = instances
+	}
+	instances[instanceID] = append(
+		[]*workflowEvent{{
+			workflowType: workflowType,
+			state:        workflowState,
+			message:      message,
+			createTime:   time.Now(),
+		}},
+		instances[instanceID]...)
+	return nil
+}
+
+// GetWorkflowEvents gets workflow events for an update and instance,
+// events are sorted in descending create timestamp
+func (s *Store) GetWorkflowEvents(
+	ctx context.Context,
+	updateID *peloton.UpdateID,
+	instanceID uint32,
+	limit uint32,
+) ([]*stateless.WorkflowEvent, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	events := s.podWorkflowEvents[updateID.GetValue()][instanceID]
+	if limit > 0 && uint32(len(events)) > limit {
+		events = events[:limit]
+	}
+	return toWorkflowEvents(events), nil
+}
+
+// toWorkflowEvents converts the stored events into workflow events,
+// dropping consecutive events with the same state and message.
+func toWorkflowEvents(events []*workflowEvent) []*stateless.WorkflowEvent {
+	var workflowEvents []*stateless.WorkflowEvent
+	prev := &stateless.WorkflowEvent{}
+	for _, event := range events {
+		workflowEvent := &stateless.WorkflowEvent{
+			Type:      stateless.WorkflowType(event.workflowType),
+			State:     stateless.WorkflowState(event.state),
+			Timestamp: event.createTime.Format(time.RFC3339),
+			Message:   event.message,
+		}
+		if prev.GetState() == workflowEvent.GetState() &&
+			prev.GetMessage() == workflowEvent.GetMessage() {
+			continue
+		}
+		workflowEvents = append(workflowEvents, workflowEvent)
+		prev = workflowEvent
+	}
+	return workflowEvents
+}
@@ -118,6 +118,40 @@ type OrmJobMetrics struct {
 	SecretInfoUpdateFail tally.Counter
 	SecretInfoDelete     tally.Counter
 	SecretInfoDeleteFail tally.Counter
+	SecretInfoRewrap     tally.Counter
+	SecretInfoRewrapFail tally.Counter
+
+	// cron_jobs
+	CronJobCreate     tally.Counter
+	CronJobCreateFail tally.Counter
+	CronJobGet        tally.Counter
+	CronJobGetFail    tally.Counter
+	CronJobGetAll     tally.Counter
+	CronJobGetAllFail tally.Counter
+	CronJobUpdate     tally.Counter
+	CronJobUpdateFail tally.Counter
+	CronJobDelete     tally.Counter
+	CronJobDeleteFail tally.Counter
+
+	// pipelines
+	PipelineCreate     tally.Counter
+	PipelineCreateFail tally.Counter
+	PipelineGet        tally.Counter
+	PipelineGetFail    tally.Counter
+	PipelineUpdate     tally.Counter
+	PipelineUpdateFail tally.Counter
+
+	// active_pipelines
+	ActivePipelineGetAll     tally.Counter
+	ActivePipelineGetAllFail tally.Counter
+	ActivePipelineDelete     tally.Counter
+	ActivePipelineDeleteFail tally.Counter
+
+	// audit_events
+	AuditEventCreate     tally.Counter
+	AuditEventCreateFail tally.Counter
+	AuditEventQuery      tally.Counter
+	AuditEventQueryFail  tally.Counter
 }
 
 // TaskMetrics is a struct for tracking all the task related counters in the storage layer
@@ -296,6 +330,19 @@ type OrmTaskMetrics struct {
 	PodEventsGetFail tally.Counter
 }
 
+// OrmHostMetrics tracks counters for host related tables
+type OrmHostMetrics struct {
+	// maintenance_windows
+	MaintenanceWindowCreate     tally.Counter
+	MaintenanceWindowCreateFail tally.Counter
+	MaintenanceWindowGet        tally.Counter
+	MaintenanceWindowGetFail    tally.Counter
+	MaintenanceWindowGetAll     tally.Counter
+	MaintenanceWindowGetAllFail tally.Counter
+	MaintenanceWindowUpdate     tally.Counter
+	MaintenanceWindowUpdateFail tally.Counter
+}
+
 // Metrics is a struct for tracking all the general purpose counters that have relevance to the storage
 // layer, i.e. how many jobs and tasks were created/deleted in the storage layer
 type Metrics struct {
@@ -309,6 +356,7 @@ type Metrics struct {
 	WorkflowMetrics       *WorkflowMetrics
 	OrmJobMetrics         *OrmJobMetrics
 	OrmTaskMetrics        *OrmTaskMetrics
+	OrmHostMetrics        *OrmHostMetrics
 }
 
 // NewMetrics returns a new Metrics struct, with all metrics initialized and rooted at the given tally.Scope
@@ -576,6 +624,36 @@ func NewMetrics(scope tally.Scope) *Metrics {
 	secretInfoFailScope := secretInfoScope.Tagged(
 		map[string]string{"result": "fail"})
 
+	cronJobScope := ormScope.SubScope("cron_jobs")
+	cronJobSuccessScope := cronJobScope.Tagged(
+		map[string]string{"result": "success"})
+	cronJobFailScope := cronJobScope.Tagged(
+		map[string]string{"result": "fail"})
+
+	pipelineScope := ormScope.SubScope("pipelines")
+	pipelineSuccessScope := pipelineScope.Tagged(
+		map[string]string{"result": "success"})
+	pipelineFailScope := pipelineScope.Tagged(
+		map[string]string{"result": "fail"})
+
+	activePipelineScope := ormScope.SubScope("active_pipelines")
+	activePipelineSuccessScope := activePipelineScope.Tagged(
+		map[string]string{"result": "success"})
+	activePipelineFailScope := activePipelineScope.Tagged(
+		map[string]string{"result": "fail"})
+
+	auditEventScope := ormScope.SubScope("audit_events")
+	auditEventSuccessScope := auditEventScope.Tagged(
+		map[string]string{"result": "success"})
+	auditEventFailScope := auditEventScope.Tagged(
+		map[string]string{"result": "fail"})
+
+	maintenanceWindowScope := ormScope.SubScope("maintenance_windows")
+	maintenanceWindowSuccessScope := maintenanceWindowScope.Tagged(
+		map[string]string{"result": "success"})
+	maintenanceWindowFailScope := maintenanceWindowScope.Tagged(
+		map[string]string{"result": "fail"})
+
 	ormJobMetrics := &OrmJobMetrics{
 		JobIndexCreate:     jobIndexSuccessScope.Counter("create"),
 		JobIndexCreateFail: jobIndexFailScope.Counter("create"),
@@ -606,6 +684,36 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		SecretInfoUpdateFail: secretInfoFailScope.Counter("update"),
 		SecretInfoDelete:     secretInfoSuccessScope.Counter("delete"),
 		SecretInfoDeleteFail: secretInfoFailScope.Counter("delete"),
+		SecretInfoRewrap:     secretInfoSuccessScope.Counter("rewrap"),
+		SecretInfoRewrapFail: secretInfoFailScope.Counter("rewrap"),
+
+		CronJobCreate:     cronJobSuccessScope.Counter("create"),
+		CronJobCreateFail: cronJobFailScope.Counter("create"),
+		CronJobGet:        cronJobSuccessScope.Counter("get"),
+		CronJobGetFail:    cronJobFailScope.Counter("get"),
+		CronJobGetAll:     cronJobSuccessScope.Counter("get_all"),
+		CronJobGetAllFail: cronJobFailScope.Counter("get_all"),
+		CronJobUpdate:     cronJobSuccessScope.Counter("update"),
+		CronJobUpdateFail: cronJobFailScope.Counter("update"),
+		CronJobDelete:     cronJobSuccessScope.Counter("delete"),
+		CronJobDeleteFail: cronJobFailScope.Counter("delete"),
+
+		PipelineCreate:     pipelineSuccessScope.Counter("create"),
+		PipelineCreateFail: pipelineFailScope.Counter("create"),
+		PipelineGet:        pipelineSuccessScope.Counter("get"),
+		PipelineGetFail:    pipelineFailScope.Counter("get"),
+		PipelineUpdate:     pipelineSuccessScope.Counter("update"),
+		PipelineUpdateFail: pipelineFailScope.Counter("update"),
+
+		ActivePipelineGetAll:     activePipelineSuccessScope.Counter("get_all"),
+		ActivePipelineGetAllFail: activePipelineFailScope.Counter("get_all"),
+		ActivePipelineDelete:     activePipelineSuccessScope.Counter("delete"),
+		ActivePipelineDeleteFail: activePipelineFailScope.Counter("delete"),
+
+		AuditEventCreate:     auditEventSuccessScope.Counter("create"),
+		AuditEventCreateFail: auditEventFailScope.Counter("create"),
+		AuditEventQuery:      auditEventSuccessScope.Counter("query"),
+		AuditEventQueryFail:  auditEventFailScope.Counter("query"),
 	}
 
 	ormTaskMetrics := &OrmTaskMetrics{
@@ -615,6 +723,17 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		PodEventsGetFail: podEventsFailScope.Counter("get"),
 	}
 
+	ormHostMetrics := &OrmHostMetrics{
+		MaintenanceWindowCreate:     maintenanceWindowSuccessScope.Counter("create"),
+		MaintenanceWindowCreateFail: maintenanceWindowFailScope.Counter("create"),
+		MaintenanceWindowGet:        maintenanceWindowSuccessScope.Counter("get"),
+		MaintenanceWindowGetFail:    maintenanceWindowFailScope.Counter("get"),
+		MaintenanceWindowGetAll:     maintenanceWindowSuccessScope.Counter("get_all"),
+		MaintenanceWindowGetAllFail: maintenanceWindowFailScope.Counter("get_all"),
+		MaintenanceWindowUpdate:     maintenanceWindowSuccessScope.Counter("update"),
+		MaintenanceWindowUpdateFail: maintenanceWindowFailScope.Counter("update"),
+	}
+
 	metrics := &Metrics{
 		JobMetrics:            jobMetrics,
 		TaskMetrics:           taskMetrics,
@@ -626,6 +745,7 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		WorkflowMetrics:       workflowMetrics,
 		OrmJobMetrics:         ormJobMetrics,
 		OrmTaskMetrics:        ormTaskMetrics,
+		OrmHostMetrics:        ormHostMetrics,
 	}
 
 	return metrics
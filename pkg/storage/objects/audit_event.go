@@ -0,0 +1,284 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// audit_events table is partitioned by the day of the events,
+	// so that the events of a time range can be read without a scan
+	_auditEventDayFormat = "2006-01-02"
+
+	// the targets of an event are stored as comma separated lists
+	_auditEventTargetSeparator = ","
+)
+
+// init adds an AuditEventObject instance to the global list of storage objects
+func init() {
+	Objs = append(Objs, &AuditEventObject{})
+}
+
+// AuditEventObject corresponds to a row in audit_events table.
+type AuditEventObject struct {
+	// DB specific annotations
+	base.Object `cassandra:"name=audit_events, primaryKey=((day), event_time, event_id)"`
+
+	// Day of the event in UTC, in YYYY-MM-DD format
+	Day string `column:"name=day"`
+	// Time of the event
+	EventTime time.Time `column:"name=event_time"`
+	// EventID of the event
+	EventID string `column:"name=event_id"`
+	// Name of the authenticated user
+	User string `column:"name=user_name"`
+	// Name of the calling service
+	Caller string `column:"name=caller"`
+	// Procedure called
+	Procedure string `column:"name=procedure"`
+	// Comma separated identifiers of the target jobs
+	JobIDs string `column:"name=job_ids"`
+	// Comma separated names of the target pods
+	PodNames string `column:"name=pod_names"`
+	// Comma separated identifiers of the target resource pools
+	RespoolIDs string `column:"name=respool_ids"`
+	// Digest of the encoded request
+	RequestDigest string `column:"name=request_digest"`
+	// Outcome of the call
+	Outcome string `column:"name=outcome"`
+	// Error message of a failed call
+	Message string `column:"name=message"`
+	// Latency of the call in milliseconds
+	LatencyMs int64 `column:"name=latency_ms"`
+}
+
+// AuditEventFilter selects the events returned by AuditEventOps.Query,
+// the empty fields match all the events.
+type AuditEventFilter struct {
+	// User who made the call
+	User string
+	// Procedure matches the events of procedures containing the string
+	Procedure string
+	// JobID matches the events targeting the job or one of its pods
+	JobID string
+	// PodName matches the events targeting the pod
+	PodName string
+	// RespoolID matches the events targeting the resource pool
+	RespoolID string
+	// StartTime and EndTime are the time range of the events
+	StartTime time.Time
+	EndTime   time.Time
+	// Limit is the maximum number of events returned, 0 means no limit
+	Limit int
+}
+
+// AuditEventOps provides methods for manipulating audit_events table.
+type AuditEventOps interface {
+	// Create inserts a row in the table.
+	Create(ctx context.Context, event *models.AuditEvent) error
+
+	// Query retrieves the events which match the filter,
+	// most recent first.
+	Query(
+		ctx context.Context,
+		filter *AuditEventFilter,
+	) ([]*models.AuditEvent, error)
+}
+
+// ensure that default implementation (auditEventOps) satisfies the interface
+var _ AuditEventOps = (*auditEventOps)(nil)
+
+// auditEventOps implements AuditEventOps using a particular Store
+type auditEventOps struct {
+	store *Store
+}
+
+// NewAuditEventOps constructs a AuditEventOps object for provided Store.
+func NewAuditEventOps(s *Store) AuditEventOps {
+	return &auditEventOps{store: s}
+}
+
+// toProto returns the *models.AuditEvent of the row
+func (a *AuditEventObject) toProto() *models.AuditEvent {
+	return &models.AuditEvent{
+		EventId:       a.EventID,
+		Time:          a.EventTime.UTC().Format(time.RFC3339Nano),
+		User:          a.User,
+		Caller:        a.Caller,
+		Procedure:     a.Procedure,
+		JobIds:        splitAuditEventTargets(a.JobIDs),
+		PodNames:      splitAuditEventTargets(a.PodNames),
+		RespoolIds:    splitAuditEventTargets(a.RespoolIDs),
+		RequestDigest: a.RequestDigest,
+		Outcome:       a.Outcome,
+		Message:       a.Message,
+		LatencyMs:     a.LatencyMs,
+	}
+}
+
+// matches returns whether the row is selected by the filter
+func (a *AuditEventObject) matches(filter *AuditEventFilter) bool {
+	if a.EventTime.Before(filter.StartTime) ||
+		a.EventTime.After(filter.EndTime) {
+		return false
+	}
+
+	if len(filter.User) != 0 && a.User != filter.User {
+		return false
+	}
+
+	if len(filter.Procedure) != 0 &&
+		!strings.Contains(a.Procedure, filter.Procedure) {
+		return false
+	}
+
+	if len(filter.JobID) != 0 {
+		found := containsAuditEventTarget(a.JobIDs, filter.JobID)
+		// pod names are made of the job identifier and the instance id
+		for _, podName := range splitAuditEventTargets(a.PodNames) {
+			found = found || strings.HasPrefix(podName, filter.JobID+"-")
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(filter.PodName) != 0 &&
+		!containsAuditEventTarget(a.PodNames, filter.PodName) {
+		return false
+	}
+
+	if len(filter.RespoolID) != 0 &&
+		!containsAuditEventTarget(a.RespoolIDs, filter.RespoolID) {
+		return false
+	}
+
+	return true
+}
+
+// Create creates an AuditEventObject in db
+func (d *auditEventOps) Create(
+	ctx context.Context,
+	event *models.AuditEvent,
+) error {
+	eventTime, err := time.Parse(time.RFC3339Nano, event.GetTime())
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.AuditEventCreateFail.Inc(1)
+		return errors.Wrap(err, "Failed to parse audit event time")
+	}
+	eventTime = eventTime.UTC()
+
+	obj := &AuditEventObject{
+		Day:           eventTime.Format(_auditEventDayFormat),
+		EventTime:     eventTime,
+		EventID:       event.GetEventId(),
+		User:          event.GetUser(),
+		Caller:        event.GetCaller(),
+		Procedure:     event.GetProcedure(),
+		JobIDs:        strings.Join(event.GetJobIds(), _auditEventTargetSeparator),
+		PodNames:      strings.Join(event.GetPodNames(), _auditEventTargetSeparator),
+		RespoolIDs:    strings.Join(event.GetRespoolIds(), _auditEventTargetSeparator),
+		RequestDigest: event.GetRequestDigest(),
+		Outcome:       event.GetOutcome(),
+		Message:       event.GetMessage(),
+		LatencyMs:     event.GetLatencyMs(),
+	}
+
+	if err := d.store.oClient.Create(ctx, obj); err != nil {
+		d.store.metrics.OrmJobMetrics.AuditEventCreateFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.AuditEventCreate.Inc(1)
+	return nil
+}
+
+// Query gets the events which match the filter from db, reading the
+// partitions of the days of the time range from the most recent one
+// until enough events are found
+func (d *auditEventOps) Query(
+	ctx context.Context,
+	filter *AuditEventFilter,
+) ([]*models.AuditEvent, error) {
+	var result []*models.AuditEvent
+
+	startDay := filter.StartTime.UTC().Format(_auditEventDayFormat)
+	for day := filter.EndTime.UTC(); ; day = day.AddDate(0, 0, -1) {
+		dayStr := day.Format(_auditEventDayFormat)
+		if dayStr < startDay {
+			break
+		}
+
+		objs, err := d.store.oClient.GetAll(
+			ctx, &AuditEventObject{Day: dayStr})
+		if err != nil {
+			d.store.metrics.OrmJobMetrics.AuditEventQueryFail.Inc(1)
+			return nil, err
+		}
+
+		// rows are read in clustering order by the cassandra connector,
+		// sort them in case the connector does not keep the order
+		events := make([]*AuditEventObject, 0, len(objs))
+		for _, obj := range objs {
+			events = append(events, obj.(*AuditEventObject))
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].EventTime.After(events[j].EventTime)
+		})
+
+		for _, event := range events {
+			if !event.matches(filter) {
+				continue
+			}
+			result = append(result, event.toProto())
+			if filter.Limit > 0 && len(result) >= filter.Limit {
+				d.store.metrics.OrmJobMetrics.AuditEventQuery.Inc(1)
+				return result, nil
+			}
+		}
+	}
+
+	d.store.metrics.OrmJobMetrics.AuditEventQuery.Inc(1)
+	return result, nil
+}
+
+// splitAuditEventTargets returns the targets of a comma separated list
+func splitAuditEventTargets(targets string) []string {
+	if len(targets) == 0 {
+		return nil
+	}
+	return strings.Split(targets, _auditEventTargetSeparator)
+}
+
+// containsAuditEventTarget returns whether the comma separated
+// list of targets contains the target
+func containsAuditEventTarget(targets string, target string) bool {
+	for _, t := range splitAuditEventTargets(targets) {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/private/models"
+	ormmocks "github.com/uber/peloton/pkg/storage/orm/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditEventObjectTestSuite struct {
+	suite.Suite
+	now    time.Time
+	jobID  string
+	events []*models.AuditEvent
+}
+
+func (s *AuditEventObjectTestSuite) SetupTest() {
+	// the events of each test are isolated by using a distinct day
+	s.now = time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC).
+		AddDate(0, 0, int(time.Now().UnixNano()%10000))
+	s.jobID = uuid.New()
+	s.events = []*models.AuditEvent{
+		{
+			EventId:       uuid.New(),
+			Time:          s.now.Add(-25 * time.Hour).Format(time.RFC3339Nano),
+			User:          "alice",
+			Caller:        "peloton-cli",
+			Procedure:     "peloton.api.v1alpha.job.stateless.svc.JobService::CreateJob",
+			JobIds:        []string{s.jobID},
+			RespoolIds:    []string{"respool1"},
+			RequestDigest: "digest1",
+			Outcome:       "OK",
+			LatencyMs:     10,
+		},
+		{
+			EventId:       uuid.New(),
+			Time:          s.now.Add(-time.Hour).Format(time.RFC3339Nano),
+			User:          "bob",
+			Caller:        "peloton-cli",
+			Procedure:     "peloton.api.v1alpha.pod.svc.PodService::RestartPod",
+			PodNames:      []string{s.jobID + "-1"},
+			RequestDigest: "digest2",
+			Outcome:       "OK",
+			LatencyMs:     20,
+		},
+		{
+			EventId:       uuid.New(),
+			Time:          s.now.Format(time.RFC3339Nano),
+			User:          "alice",
+			Caller:        "peloton-cli",
+			Procedure:     "peloton.api.v1alpha.job.stateless.svc.JobService::StopJob",
+			JobIds:        []string{uuid.New()},
+			RequestDigest: "digest3",
+			Outcome:       "not-found",
+			Message:       "job not found",
+			LatencyMs:     5,
+		},
+	}
+}
+
+func TestAuditEventObjectSuite(t *testing.T) {
+	suite.Run(t, new(AuditEventObjectTestSuite))
+}
+
+// TestAuditEventCreateQuery tests creating and querying audit events in DB
+func (s *AuditEventObjectTestSuite) TestAuditEventCreateQuery() {
+	db := NewAuditEventOps(testStore)
+	ctx := context.Background()
+
+	for _, event := range s.events {
+		s.NoError(db.Create(ctx, event))
+	}
+
+	tests := []struct {
+		msg    string
+		filter *AuditEventFilter
+		result []*models.AuditEvent
+	}{
+		{
+			msg:    "all events, most recent first",
+			filter: &AuditEventFilter{},
+			result: []*models.AuditEvent{s.events[2], s.events[1], s.events[0]},
+		},
+		{
+			msg:    "limit",
+			filter: &AuditEventFilter{Limit: 2},
+			result: []*models.AuditEvent{s.events[2], s.events[1]},
+		},
+		{
+			msg:    "user",
+			filter: &AuditEventFilter{User: "alice"},
+			result: []*models.AuditEvent{s.events[2], s.events[0]},
+		},
+		{
+			msg:    "procedure",
+			filter: &AuditEventFilter{Procedure: "JobService"},
+			result: []*models.AuditEvent{s.events[2], s.events[0]},
+		},
+		{
+			msg:    "job and its pods",
+			filter: &AuditEventFilter{JobID: s.jobID},
+			result: []*models.AuditEvent{s.events[1], s.events[0]},
+		},
+		{
+			msg:    "pod",
+			filter: &AuditEventFilter{PodName: s.jobID + "-1"},
+			result: []*models.AuditEvent{s.events[1]},
+		},
+		{
+			msg:    "resource pool",
+			filter: &AuditEventFilter{RespoolID: "respool1"},
+			result: []*models.AuditEvent{s.events[0]},
+		},
+		{
+			msg: "time range",
+			filter: &AuditEventFilter{
+				StartTime: s.now.Add(-2 * time.Hour),
+				EndTime:   s.now.Add(-time.Minute),
+			},
+			result: []*models.AuditEvent{s.events[1]},
+		},
+	}
+
+	for _, test := range tests {
+		if test.filter.StartTime.IsZero() {
+			test.filter.StartTime = s.now.Add(-48 * time.Hour)
+			test.filter.EndTime = s.now
+		}
+		result, err := db.Query(ctx, test.filter)
+		s.NoError(err, test.msg)
+		s.Equal(test.result, result, test.msg)
+	}
+}
+
+// TestAuditEventOpsFail tests failure cases due to ORM Client errors
+// and invalid events
+func (s *AuditEventObjectTestSuite) TestAuditEventOpsFail() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockClient := ormmocks.NewMockClient(ctrl)
+	mockStore := &Store{oClient: mockClient, metrics: testStore.metrics}
+	db := NewAuditEventOps(mockStore)
+	ctx := context.Background()
+
+	mockClient.EXPECT().Create(gomock.Any(), gomock.Any()).
+		Return(errors.New("create failed"))
+	mockClient.EXPECT().GetAll(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("getall failed"))
+
+	err := db.Create(ctx, s.events[0])
+	s.Equal("create failed", err.Error())
+
+	err = db.Create(ctx, &models.AuditEvent{Time: "yesterday"})
+	s.Error(err)
+
+	_, err = db.Query(ctx, &AuditEventFilter{StartTime: s.now, EndTime: s.now})
+	s.Equal("getall failed", err.Error())
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// cron_jobs table uses a single synthetic partition since the number
+// of cron jobs is expected to be small.
+const cronJobShardID = 0
+
+// init adds a CronJobObject instance to the global list of storage objects
+func init() {
+	Objs = append(Objs, &CronJobObject{})
+}
+
+// CronJobObject corresponds to a row in cron_jobs table.
+type CronJobObject struct {
+	// DB specific annotations
+	base.Object `cassandra:"name=cron_jobs, primaryKey=((shard_id), name)"`
+
+	// Synthetic shard of the cron job
+	ShardID int `column:"name=shard_id"`
+	// Name of the cron job
+	Name string `column:"name=name"`
+	// Marshaled specification of the cron job
+	Spec []byte `column:"name=spec"`
+	// Marshaled status of the cron job
+	Status []byte `column:"name=status"`
+	// Creation time of the cron job
+	CreationTime time.Time `column:"name=creation_time"`
+	// Last time the cron job has been updated
+	UpdateTime time.Time `column:"name=update_time"`
+}
+
+// CronJobOps provides methods for manipulating cron_jobs table.
+type CronJobOps interface {
+	// Create inserts a row in the table if a cron job with the same name
+	// does not exist yet.
+	Create(
+		ctx context.Context,
+		spec *cron.CronJobSpec,
+		status *cron.CronJobStatus,
+	) error
+
+	// Get retrieves a row from the table.
+	Get(
+		ctx context.Context,
+		name string,
+	) (*cron.CronJobInfo, error)
+
+	// GetAll retrieves all the rows from the table.
+	GetAll(ctx context.Context) ([]*cron.CronJobInfo, error)
+
+	// UpdateSpec replaces the specification of a cron job.
+	UpdateSpec(
+		ctx context.Context,
+		spec *cron.CronJobSpec,
+	) error
+
+	// UpdateStatus replaces the status of a cron job.
+	UpdateStatus(
+		ctx context.Context,
+		name string,
+		status *cron.CronJobStatus,
+	) error
+
+	// Delete removes a row from the table.
+	Delete(
+		ctx context.Context,
+		name string,
+	) error
+}
+
+// ensure that default implementation (cronJobOps) satisfies the interface
+var _ CronJobOps = (*cronJobOps)(nil)
+
+// cronJobOps implements CronJobOps using a particular Store
+type cronJobOps struct {
+	store *Store
+}
+
+// NewCronJobOps constructs a CronJobOps object for provided Store.
+func NewCronJobOps(s *Store) CronJobOps {
+	return &cronJobOps{store: s}
+}
+
+// toProto returns the unmarshaled *cron.CronJobInfo
+func (c *CronJobObject) toProto() (*cron.CronJobInfo, error) {
+	spec := &cron.CronJobSpec{}
+	if err := proto.Unmarshal(c.Spec, spec); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal cron job spec")
+	}
+
+	status := &cron.CronJobStatus{}
+	if err := proto.Unmarshal(c.Status, status); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal cron job status")
+	}
+
+	return &cron.CronJobInfo{
+		Spec:   spec,
+		Status: status,
+	}, nil
+}
+
+// Create creates a CronJobObject in db
+func (d *cronJobOps) Create(
+	ctx context.Context,
+	spec *cron.CronJobSpec,
+	status *cron.CronJobStatus,
+) error {
+	specBuffer, err := proto.Marshal(spec)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobCreateFail.Inc(1)
+		return errors.Wrap(err, "Failed to marshal cron job spec")
+	}
+
+	statusBuffer, err := proto.Marshal(status)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobCreateFail.Inc(1)
+		return errors.Wrap(err, "Failed to marshal cron job status")
+	}
+
+	now := time.Now().UTC()
+	obj := &CronJobObject{
+		ShardID:      cronJobShardID,
+		Name:         spec.GetName(),
+		Spec:         specBuffer,
+		Status:       statusBuffer,
+		CreationTime: now,
+		UpdateTime:   now,
+	}
+
+	if err := d.store.oClient.CreateIfNotExists(ctx, obj); err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobCreateFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.CronJobCreate.Inc(1)
+	return nil
+}
+
+// Get gets a cron job from db
+func (d *cronJobOps) Get(
+	ctx context.Context,
+	name string,
+) (*cron.CronJobInfo, error) {
+	obj := &CronJobObject{
+		ShardID: cronJobShardID,
+		Name:    name,
+	}
+
+	if err := d.store.oClient.Get(ctx, obj); err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobGetFail.Inc(1)
+		return nil, err
+	}
+
+	info, err := obj.toProto()
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobGetFail.Inc(1)
+		return nil, err
+	}
+
+	d.store.metrics.OrmJobMetrics.CronJobGet.Inc(1)
+	return info, nil
+}
+
+// GetAll gets all the cron jobs from db
+func (d *cronJobOps) GetAll(
+	ctx context.Context,
+) ([]*cron.CronJobInfo, error) {
+	objs, err := d.store.oClient.GetAll(
+		ctx, &CronJobObject{ShardID: cronJobShardID})
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobGetAllFail.Inc(1)
+		return nil, err
+	}
+
+	var result []*cron.CronJobInfo
+	for _, obj := range objs {
+		info, err := obj.(*CronJobObject).toProto()
+		if err != nil {
+			d.store.metrics.OrmJobMetrics.CronJobGetAllFail.Inc(1)
+			return nil, err
+		}
+		result = append(result, info)
+	}
+
+	d.store.metrics.OrmJobMetrics.CronJobGetAll.Inc(1)
+	return result, nil
+}
+
+// UpdateSpec updates the spec of a cron job in db
+func (d *cronJobOps) UpdateSpec(
+	ctx context.Context,
+	spec *cron.CronJobSpec,
+) error {
+	specBuffer, err := proto.Marshal(spec)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobUpdateFail.Inc(1)
+		return errors.Wrap(err, "Failed to marshal cron job spec")
+	}
+
+	obj := &CronJobObject{
+		ShardID:    cronJobShardID,
+		Name:       spec.GetName(),
+		Spec:       specBuffer,
+		UpdateTime: time.Now().UTC(),
+	}
+
+	if err := d.store.oClient.Update(
+		ctx, obj, "Spec", "UpdateTime"); err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobUpdateFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.CronJobUpdate.Inc(1)
+	return nil
+}
+
+// UpdateStatus updates the status of a cron job in db
+func (d *cronJobOps) UpdateStatus(
+	ctx context.Context,
+	name string,
+	status *cron.CronJobStatus,
+) error {
+	statusBuffer, err := proto.Marshal(status)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobUpdateFail.Inc(1)
+		return errors.Wrap(err, "Failed to marshal cron job status")
+	}
+
+	obj := &CronJobObject{
+		ShardID:    cronJobShardID,
+		Name:       name,
+		Status:     statusBuffer,
+		UpdateTime: time.Now().UTC(),
+	}
+
+	if err := d.store.oClient.Update(
+		ctx, obj, "Status", "UpdateTime"); err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobUpdateFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.CronJobUpdate.Inc(1)
+	return nil
+}
+
+// Delete deletes a cron job from db
+func (d *cronJobOps) Delete(
+	ctx context.Context,
+	name string,
+) error {
+	obj := &CronJobObject{
+		ShardID: cronJobShardID,
+		Name:    name,
+	}
+
+	if err := d.store.oClient.Delete(ctx, obj); err != nil {
+		d.store.metrics.OrmJobMetrics.CronJobDeleteFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.CronJobDelete.Inc(1)
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/cron"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+	ormmocks "github.com/uber/peloton/pkg/storage/orm/mocks"
+
+	"github.com/gocql/gocql"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type CronJobObjectTestSuite struct {
+	suite.Suite
+	spec   *cron.CronJobSpec
+	status *cron.CronJobStatus
+}
+
+func (s *CronJobObjectTestSuite) SetupTest() {
+	s.spec = &cron.CronJobSpec{
+		Name:            "cron-" + uuid.New(),
+		Schedule:        "*/5 * * * *",
+		CollisionPolicy: cron.CollisionPolicy_COLLISION_POLICY_SKIP,
+		Template: &stateless.JobSpec{
+			Name:          "nightly",
+			InstanceCount: 2,
+		},
+	}
+	s.status = &cron.CronJobStatus{
+		NextRunTime: "2019-01-01T00:05:00Z",
+	}
+}
+
+func TestCronJobObjectSuite(t *testing.T) {
+	suite.Run(t, new(CronJobObjectTestSuite))
+}
+
+// TestCronJobCreateGetUpdateDelete tests the full lifecycle of a cron job
+// in DB
+func (s *CronJobObjectTestSuite) TestCronJobCreateGetUpdateDelete() {
+	db := NewCronJobOps(testStore)
+	ctx := context.Background()
+
+	s.NoError(db.Create(ctx, s.spec, s.status))
+
+	// creating a cron job with the same name should fail
+	err := db.Create(ctx, s.spec, s.status)
+	s.True(yarpcerrors.IsAlreadyExists(err))
+
+	info, err := db.Get(ctx, s.spec.GetName())
+	s.NoError(err)
+	s.True(proto.Equal(s.spec, info.GetSpec()))
+	s.True(proto.Equal(s.status, info.GetStatus()))
+
+	all, err := db.GetAll(ctx)
+	s.NoError(err)
+	found := false
+	for _, i := range all {
+		if i.GetSpec().GetName() == s.spec.GetName() {
+			found = true
+		}
+	}
+	s.True(found)
+
+	newSpec := proto.Clone(s.spec).(*cron.CronJobSpec)
+	newSpec.Schedule = "0 * * * *"
+	s.NoError(db.UpdateSpec(ctx, newSpec))
+
+	newStatus := &cron.CronJobStatus{
+		LastRunTime: "2019-01-01T00:05:00Z",
+		NextRunTime: "2019-01-01T01:00:00Z",
+		ActiveRuns:  []*peloton.JobID{{Value: uuid.New()}},
+		RunCount:    1,
+	}
+	s.NoError(db.UpdateStatus(ctx, s.spec.GetName(), newStatus))
+
+	info, err = db.Get(ctx, s.spec.GetName())
+	s.NoError(err)
+	s.True(proto.Equal(newSpec, info.GetSpec()))
+	s.True(proto.Equal(newStatus, info.GetStatus()))
+
+	s.NoError(db.Delete(ctx, s.spec.GetName()))
+
+	_, err = db.Get(ctx, s.spec.GetName())
+	s.Equal(gocql.ErrNotFound, err)
+}
+
+// TestCronJobOpsClientFail tests failure cases due to ORM Client errors
+func (s *CronJobObjectTestSuite) TestCronJobOpsClientFail() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockClient := ormmocks.NewMockClient(ctrl)
+	mockStore := &Store{oClient: mockClient, metrics: testStore.metrics}
+	db := NewCronJobOps(mockStore)
+	ctx := context.Background()
+
+	mockClient.EXPECT().CreateIfNotExists(gomock.Any(), gomock.Any()).
+		Return(errors.New("create failed"))
+	mockClient.EXPECT().Get(gomock.Any(), gomock.Any()).
+		Return(errors.New("get failed"))
+	mockClient.EXPECT().GetAll(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("getall failed"))
+	mockClient.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("update failed")).Times(2)
+	mockClient.EXPECT().Delete(gomock.Any(), gomock.Any()).
+		Return(errors.New("delete failed"))
+
+	err := db.Create(ctx, s.spec, s.status)
+	s.Equal("create failed", err.Error())
+
+	_, err = db.Get(ctx, s.spec.GetName())
+	s.Equal("get failed", err.Error())
+
+	_, err = db.GetAll(ctx)
+	s.Equal("getall failed", err.Error())
+
+	err = db.UpdateSpec(ctx, s.spec)
+	s.Equal("update failed", err.Error())
+
+	err = db.UpdateStatus(ctx, s.spec.GetName(), s.status)
+	s.Equal("update failed", err.Error())
+
+	err = db.Delete(ctx, s.spec.GetName())
+	s.Equal("delete failed", err.Error())
+}
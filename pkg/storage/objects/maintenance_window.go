@@ -0,0 +1,212 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/host"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// maintenance_windows table uses a single synthetic partition since the
+// number of maintenance windows is expected to be small.
+const maintenanceWindowShardID = 0
+
+// init adds a MaintenanceWindowObject instance to the global list of
+// storage objects
+func init() {
+	Objs = append(Objs, &MaintenanceWindowObject{})
+}
+
+// MaintenanceWindowObject corresponds to a row in maintenance_windows table.
+type MaintenanceWindowObject struct {
+	// DB specific annotations
+	base.Object `cassandra:"name=maintenance_windows, primaryKey=((shard_id), window_id)"`
+
+	// Synthetic shard of the maintenance window
+	ShardID int `column:"name=shard_id"`
+	// Identifier of the maintenance window
+	WindowID string `column:"name=window_id"`
+	// Marshaled maintenance window
+	Window []byte `column:"name=window"`
+	// Creation time of the maintenance window
+	CreationTime time.Time `column:"name=creation_time"`
+	// Last time the maintenance window has been updated
+	UpdateTime time.Time `column:"name=update_time"`
+}
+
+// MaintenanceWindowOps provides methods for manipulating
+// maintenance_windows table.
+type MaintenanceWindowOps interface {
+	// Create inserts a row in the table if a maintenance window with the
+	// same id does not exist yet.
+	Create(
+		ctx context.Context,
+		window *host.MaintenanceWindow,
+	) error
+
+	// Get retrieves a row from the table.
+	Get(
+		ctx context.Context,
+		windowID string,
+	) (*host.MaintenanceWindow, error)
+
+	// GetAll retrieves all the rows from the table.
+	GetAll(ctx context.Context) ([]*host.MaintenanceWindow, error)
+
+	// Update replaces a maintenance window.
+	Update(
+		ctx context.Context,
+		window *host.MaintenanceWindow,
+	) error
+}
+
+// ensure that default implementation (maintenanceWindowOps) satisfies
+// the interface
+var _ MaintenanceWindowOps = (*maintenanceWindowOps)(nil)
+
+// maintenanceWindowOps implements MaintenanceWindowOps using a
+// particular Store
+type maintenanceWindowOps struct {
+	store *Store
+}
+
+// NewMaintenanceWindowOps constructs a MaintenanceWindowOps object for
+// provided Store.
+func NewMaintenanceWindowOps(s *Store) MaintenanceWindowOps {
+	return &maintenanceWindowOps{store: s}
+}
+
+// toProto returns the unmarshaled *host.MaintenanceWindow
+func (m *MaintenanceWindowObject) toProto() (*host.MaintenanceWindow, error) {
+	window := &host.MaintenanceWindow{}
+	if err := proto.Unmarshal(m.Window, window); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal maintenance window")
+	}
+	return window, nil
+}
+
+// Create creates a MaintenanceWindowObject in db
+func (d *maintenanceWindowOps) Create(
+	ctx context.Context,
+	window *host.MaintenanceWindow,
+) error {
+	buffer, err := proto.Marshal(window)
+	if err != nil {
+		d.store.metrics.OrmHostMetrics.MaintenanceWindowCreateFail.Inc(1)
+		return errors.Wrap(err, "Failed to marshal maintenance window")
+	}
+
+	now := time.Now().UTC()
+	obj := &MaintenanceWindowObject{
+		ShardID:      maintenanceWindowShardID,
+		WindowID:     window.GetId(),
+		Window:       buffer,
+		CreationTime: now,
+		UpdateTime:   now,
+	}
+
+	if err := d.store.oClient.CreateIfNotExists(ctx, obj); err != nil {
+		d.store.metrics.OrmHostMetrics.MaintenanceWindowCreateFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmHostMetrics.MaintenanceWindowCreate.Inc(1)
+	return nil
+}
+
+// Get gets a maintenance window from db
+func (d *maintenanceWindowOps) Get(
+	ctx context.Context,
+	windowID string,
+) (*host.MaintenanceWindow, error) {
+	obj := &MaintenanceWindowObject{
+		ShardID:  maintenanceWindowShardID,
+		WindowID: windowID,
+	}
+
+	if err := d.store.oClient.Get(ctx, obj); err != nil {
+		d.store.metrics.OrmHostMetrics.MaintenanceWindowGetFail.Inc(1)
+		return nil, err
+	}
+
+	window, err := obj.toProto()
+	if err != nil {
+		d.store.metrics.OrmHostMetrics.MaintenanceWindowGetFail.Inc(1)
+		return nil, err
+	}
+
+	d.store.metrics.OrmHostMetrics.MaintenanceWindowGet.Inc(1)
+	return window, nil
+}
+
+// GetAll gets all the maintenance windows from db
+func (d *maintenanceWindowOps) GetAll(
+	ctx context.Context,
+) ([]*host.MaintenanceWindow, error) {
+	objs, err := d.store.oClient.GetAll(
+		ctx, &MaintenanceWindowObject{ShardID: maintenanceWindowShardID})
+	if err != nil {
+		d.store.metrics.OrmHostMetrics.MaintenanceWindowGetAllFail.Inc(1)
+		return nil, err
+	}
+
+	var result []*host.MaintenanceWindow
+	for _, obj := range objs {
+		window, err := obj.(*MaintenanceWindowObject).toProto()
+		if err != nil {
+			d.store.metrics.OrmHostMetrics.MaintenanceWindowGetAllFail.Inc(1)
+			return nil, err
+		}
+		result = append(result, window)
+	}
+
+	d.store.metrics.OrmHostMetrics.MaintenanceWindowGetAll.Inc(1)
+	return result, nil
+}
+
+// Update updates a maintenance window in db
+func (d *maintenanceWindowOps) Update(
+	ctx context.Context,
+	window *host.MaintenanceWindow,
+) error {
+	buffer, err := proto.Marshal(window)
+	if err != nil {
+		d.store.metrics.OrmHostMetrics.MaintenanceWindowUpdateFail.Inc(1)
+		return errors.Wrap(err, "Failed to marshal maintenance window")
+	}
+
+	obj := &MaintenanceWindowObject{
+		ShardID:    maintenanceWindowShardID,
+		WindowID:   window.GetId(),
+		Window:     buffer,
+		UpdateTime: time.Now().UTC(),
+	}
+
+	if err := d.store.oClient.Update(
+		ctx, obj, "Window", "UpdateTime"); err != nil {
+		d.store.metrics.OrmHostMetrics.MaintenanceWindowUpdateFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmHostMetrics.MaintenanceWindowUpdate.Inc(1)
+	return nil
+}
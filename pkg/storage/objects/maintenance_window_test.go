@@ -0,0 +1,122 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/host"
+	ormmocks "github.com/uber/peloton/pkg/storage/orm/mocks"
+
+	"github.com/gocql/gocql"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type MaintenanceWindowObjectTestSuite struct {
+	suite.Suite
+	window *host.MaintenanceWindow
+}
+
+func (s *MaintenanceWindowObjectTestSuite) SetupTest() {
+	s.window = &host.MaintenanceWindow{
+		Id:        uuid.New(),
+		Hostnames: []string{"host1", "host2"},
+		StartTime: "2019-01-01T02:00:00Z",
+		EndTime:   "2019-01-01T06:00:00Z",
+		Reason:    "kernel upgrade",
+		State:     host.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_SCHEDULED,
+	}
+}
+
+func TestMaintenanceWindowObjectSuite(t *testing.T) {
+	suite.Run(t, new(MaintenanceWindowObjectTestSuite))
+}
+
+// TestMaintenanceWindowCreateGetUpdate tests the lifecycle of a
+// maintenance window in DB
+func (s *MaintenanceWindowObjectTestSuite) TestMaintenanceWindowCreateGetUpdate() {
+	db := NewMaintenanceWindowOps(testStore)
+	ctx := context.Background()
+
+	s.NoError(db.Create(ctx, s.window))
+
+	// creating a window with the same id should fail
+	err := db.Create(ctx, s.window)
+	s.True(yarpcerrors.IsAlreadyExists(err))
+
+	window, err := db.Get(ctx, s.window.GetId())
+	s.NoError(err)
+	s.True(proto.Equal(s.window, window))
+
+	all, err := db.GetAll(ctx)
+	s.NoError(err)
+	found := false
+	for _, w := range all {
+		if w.GetId() == s.window.GetId() {
+			found = true
+		}
+	}
+	s.True(found)
+
+	newWindow := proto.Clone(s.window).(*host.MaintenanceWindow)
+	newWindow.State = host.MaintenanceWindowState_MAINTENANCE_WINDOW_STATE_ACTIVE
+	s.NoError(db.Update(ctx, newWindow))
+
+	window, err = db.Get(ctx, s.window.GetId())
+	s.NoError(err)
+	s.True(proto.Equal(newWindow, window))
+
+	_, err = db.Get(ctx, uuid.New())
+	s.Equal(gocql.ErrNotFound, err)
+}
+
+// TestMaintenanceWindowOpsClientFail tests failure cases due to ORM
+// Client errors
+func (s *MaintenanceWindowObjectTestSuite) TestMaintenanceWindowOpsClientFail() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockClient := ormmocks.NewMockClient(ctrl)
+	mockStore := &Store{oClient: mockClient, metrics: testStore.metrics}
+	db := NewMaintenanceWindowOps(mockStore)
+	ctx := context.Background()
+
+	mockClient.EXPECT().CreateIfNotExists(gomock.Any(), gomock.Any()).
+		Return(errors.New("create failed"))
+	mockClient.EXPECT().Get(gomock.Any(), gomock.Any()).
+		Return(errors.New("get failed"))
+	mockClient.EXPECT().GetAll(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("getall failed"))
+	mockClient.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("update failed"))
+
+	err := db.Create(ctx, s.window)
+	s.Equal("create failed", err.Error())
+
+	_, err = db.Get(ctx, s.window.GetId())
+	s.Equal("get failed", err.Error())
+
+	_, err = db.GetAll(ctx)
+	s.Equal("getall failed", err.Error())
+
+	err = db.Update(ctx, s.window)
+	s.Equal("update failed", err.Error())
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// active_pipelines table uses a single synthetic partition, similar to
+// the active_jobs table.
+const activePipelineShardID = 0
+
+// init adds the pipeline objects to the global list of storage objects
+func init() {
+	Objs = append(Objs, &PipelineObject{}, &ActivePipelineObject{})
+}
+
+// PipelineObject corresponds to a row in pipelines table.
+type PipelineObject struct {
+	// DB specific annotations
+	base.Object `cassandra:"name=pipelines, primaryKey=((pipeline_id))"`
+
+	// PipelineID of the pipeline
+	PipelineID string `column:"name=pipeline_id"`
+	// Name of the pipeline
+	Name string `column:"name=name"`
+	// Marshaled specification of the pipeline
+	Spec []byte `column:"name=spec"`
+	// Marshaled status of the pipeline
+	Status []byte `column:"name=status"`
+	// Current state of the pipeline
+	State string `column:"name=state"`
+	// Creation time of the pipeline
+	CreationTime time.Time `column:"name=creation_time"`
+	// Last time the pipeline has been updated
+	UpdateTime time.Time `column:"name=update_time"`
+}
+
+// ActivePipelineObject corresponds to a row in active_pipelines table.
+type ActivePipelineObject struct {
+	// DB specific annotations
+	base.Object `cassandra:"name=active_pipelines, primaryKey=((shard_id), pipeline_id)"`
+
+	// Synthetic shard of the pipeline
+	ShardID int `column:"name=shard_id"`
+	// PipelineID of the pipeline
+	PipelineID string `column:"name=pipeline_id"`
+}
+
+// PipelineOps provides methods for manipulating pipelines and
+// active_pipelines tables.
+type PipelineOps interface {
+	// Create inserts a row in pipelines table and marks the pipeline
+	// as active.
+	Create(
+		ctx context.Context,
+		id *peloton.PipelineID,
+		spec *pipeline.PipelineSpec,
+		status *pipeline.PipelineStatus,
+	) error
+
+	// Get retrieves a row from pipelines table.
+	Get(
+		ctx context.Context,
+		id *peloton.PipelineID,
+	) (*pipeline.PipelineInfo, error)
+
+	// UpdateStatus replaces the status of a pipeline.
+	UpdateStatus(
+		ctx context.Context,
+		id *peloton.PipelineID,
+		status *pipeline.PipelineStatus,
+	) error
+
+	// GetActive retrieves the identifiers of all the active pipelines.
+	GetActive(ctx context.Context) ([]*peloton.PipelineID, error)
+
+	// DeleteActive removes a pipeline from active_pipelines table.
+	DeleteActive(
+		ctx context.Context,
+		id *peloton.PipelineID,
+	) error
+}
+
+// ensure that default implementation (pipelineOps) satisfies the interface
+var _ PipelineOps = (*pipelineOps)(nil)
+
+// pipelineOps implements PipelineOps using a particular Store
+type pipelineOps struct {
+	store *Store
+}
+
+// NewPipelineOps constructs a PipelineOps object for provided Store.
+func NewPipelineOps(s *Store) PipelineOps {
+	return &pipelineOps{store: s}
+}
+
+// toProto returns the unmarshaled *pipeline.PipelineInfo
+func (p *PipelineObject) toProto() (*pipeline.PipelineInfo, error) {
+	spec := &pipeline.PipelineSpec{}
+	if err := proto.Unmarshal(p.Spec, spec); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal pipeline spec")
+	}
+
+	status := &pipeline.PipelineStatus{}
+	if err := proto.Unmarshal(p.Status, status); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal pipeline status")
+	}
+
+	return &pipeline.PipelineInfo{
+		Id:     &peloton.PipelineID{Value: p.PipelineID},
+		Spec:   spec,
+		Status: status,
+	}, nil
+}
+
+// Create creates a PipelineObject and an ActivePipelineObject in db
+func (d *pipelineOps) Create(
+	ctx context.Context,
+	id *peloton.PipelineID,
+	spec *pipeline.PipelineSpec,
+	status *pipeline.PipelineStatus,
+) error {
+	specBuffer, err := proto.Marshal(spec)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.PipelineCreateFail.Inc(1)
+		return errors.Wrap(err, "Failed to marshal pipeline spec")
+	}
+
+	statusBuffer, err := proto.Marshal(status)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.PipelineCreateFail.Inc(1)
+		return errors.Wrap(err, "Failed to marshal pipeline status")
+	}
+
+	now := time.Now().UTC()
+	obj := &PipelineObject{
+		PipelineID:   id.GetValue(),
+		Name:         spec.GetName(),
+		Spec:         specBuffer,
+		Status:       statusBuffer,
+		State:        status.GetState().String(),
+		CreationTime: now,
+		UpdateTime:   now,
+	}
+
+	if err := d.store.oClient.CreateIfNotExists(ctx, obj); err != nil {
+		d.store.metrics.OrmJobMetrics.PipelineCreateFail.Inc(1)
+		return err
+	}
+
+	// the pipeline is marked as active once it has been persisted, so that
+	// recovery never finds an active pipeline which does not exist
+	if err := d.store.oClient.Create(ctx, &ActivePipelineObject{
+		ShardID:    activePipelineShardID,
+		PipelineID: id.GetValue(),
+	}); err != nil {
+		d.store.metrics.OrmJobMetrics.PipelineCreateFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.PipelineCreate.Inc(1)
+	return nil
+}
+
+// Get gets a pipeline from db
+func (d *pipelineOps) Get(
+	ctx context.Context,
+	id *peloton.PipelineID,
+) (*pipeline.PipelineInfo, error) {
+	obj := &PipelineObject{
+		PipelineID: id.GetValue(),
+	}
+
+	if err := d.store.oClient.Get(ctx, obj); err != nil {
+		d.store.metrics.OrmJobMetrics.PipelineGetFail.Inc(1)
+		return nil, err
+	}
+
+	info, err := obj.toProto()
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.PipelineGetFail.Inc(1)
+		return nil, err
+	}
+
+	d.store.metrics.OrmJobMetrics.PipelineGet.Inc(1)
+	return info, nil
+}
+
+// UpdateStatus updates the status of a pipeline in db
+func (d *pipelineOps) UpdateStatus(
+	ctx context.Context,
+	id *peloton.PipelineID,
+	status *pipeline.PipelineStatus,
+) error {
+	statusBuffer, err := proto.Marshal(status)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.PipelineUpdateFail.Inc(1)
+		return errors.Wrap(err, "Failed to marshal pipeline status")
+	}
+
+	obj := &PipelineObject{
+		PipelineID: id.GetValue(),
+		Status:     statusBuffer,
+		State:      status.GetState().String(),
+		UpdateTime: time.Now().UTC(),
+	}
+
+	if err := d.store.oClient.Update(
+		ctx, obj, "Status", "State", "UpdateTime"); err != nil {
+		d.store.metrics.OrmJobMetrics.PipelineUpdateFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.PipelineUpdate.Inc(1)
+	return nil
+}
+
+// GetActive gets the identifiers of all the active pipelines from db
+func (d *pipelineOps) GetActive(
+	ctx context.Context,
+) ([]*peloton.PipelineID, error) {
+	objs, err := d.store.oClient.GetAll(
+		ctx, &ActivePipelineObject{ShardID: activePipelineShardID})
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.ActivePipelineGetAllFail.Inc(1)
+		return nil, err
+	}
+
+	var result []*peloton.PipelineID
+	for _, obj := range objs {
+		result = append(result, &peloton.PipelineID{
+			Value: obj.(*ActivePipelineObject).PipelineID,
+		})
+	}
+
+	d.store.metrics.OrmJobMetrics.ActivePipelineGetAll.Inc(1)
+	return result, nil
+}
+
+// DeleteActive deletes a pipeline from active_pipelines table
+func (d *pipelineOps) DeleteActive(
+	ctx context.Context,
+	id *peloton.PipelineID,
+) error {
+	obj := &ActivePipelineObject{
+		ShardID:    activePipelineShardID,
+		PipelineID: id.GetValue(),
+	}
+
+	if err := d.store.oClient.Delete(ctx, obj); err != nil {
+		d.store.metrics.OrmJobMetrics.ActivePipelineDeleteFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.ActivePipelineDelete.Inc(1)
+	return nil
+}
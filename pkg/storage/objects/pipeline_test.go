@@ -0,0 +1,162 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/pipeline"
+	ormmocks "github.com/uber/peloton/pkg/storage/orm/mocks"
+
+	"github.com/gocql/gocql"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type PipelineObjectTestSuite struct {
+	suite.Suite
+	id     *peloton.PipelineID
+	spec   *pipeline.PipelineSpec
+	status *pipeline.PipelineStatus
+}
+
+func (s *PipelineObjectTestSuite) SetupTest() {
+	s.id = &peloton.PipelineID{Value: uuid.New()}
+	s.spec = &pipeline.PipelineSpec{
+		Name: "pipeline",
+		Nodes: []*pipeline.Node{
+			{Name: "extract", Config: &job.JobConfig{Type: job.JobType_BATCH}},
+			{Name: "load", Config: &job.JobConfig{Type: job.JobType_BATCH}},
+		},
+		Edges: []*pipeline.Edge{
+			{
+				From:      "extract",
+				To:        "load",
+				Condition: pipeline.EdgeCondition_EDGE_CONDITION_ON_SUCCESS,
+			},
+		},
+	}
+	s.status = &pipeline.PipelineStatus{
+		State:     pipeline.PipelineState_PIPELINE_STATE_RUNNING,
+		GoalState: pipeline.PipelineState_PIPELINE_STATE_SUCCEEDED,
+		Nodes: []*pipeline.NodeStatus{
+			{Name: "extract", State: pipeline.NodeState_NODE_STATE_PENDING},
+			{Name: "load", State: pipeline.NodeState_NODE_STATE_PENDING},
+		},
+	}
+}
+
+func TestPipelineObjectSuite(t *testing.T) {
+	suite.Run(t, new(PipelineObjectTestSuite))
+}
+
+// isActive returns true if the pipeline is in active_pipelines table
+func (s *PipelineObjectTestSuite) isActive(db PipelineOps) bool {
+	ids, err := db.GetActive(context.Background())
+	s.NoError(err)
+	for _, id := range ids {
+		if id.GetValue() == s.id.GetValue() {
+			return true
+		}
+	}
+	return false
+}
+
+// TestPipelineCreateGetUpdate tests the full lifecycle of a pipeline in DB
+func (s *PipelineObjectTestSuite) TestPipelineCreateGetUpdate() {
+	db := NewPipelineOps(testStore)
+	ctx := context.Background()
+
+	s.NoError(db.Create(ctx, s.id, s.spec, s.status))
+	s.True(s.isActive(db))
+
+	info, err := db.Get(ctx, s.id)
+	s.NoError(err)
+	s.Equal(s.id.GetValue(), info.GetId().GetValue())
+	s.True(proto.Equal(s.spec, info.GetSpec()))
+	s.True(proto.Equal(s.status, info.GetStatus()))
+
+	newStatus := proto.Clone(s.status).(*pipeline.PipelineStatus)
+	newStatus.State = pipeline.PipelineState_PIPELINE_STATE_SUCCEEDED
+	newStatus.Nodes[0].State = pipeline.NodeState_NODE_STATE_SUCCEEDED
+	newStatus.Nodes[1].State = pipeline.NodeState_NODE_STATE_SUCCEEDED
+	s.NoError(db.UpdateStatus(ctx, s.id, newStatus))
+
+	info, err = db.Get(ctx, s.id)
+	s.NoError(err)
+	s.True(proto.Equal(newStatus, info.GetStatus()))
+
+	s.NoError(db.DeleteActive(ctx, s.id))
+	s.False(s.isActive(db))
+
+	// the pipeline is kept after it is no longer active
+	_, err = db.Get(ctx, s.id)
+	s.NoError(err)
+
+	_, err = db.Get(ctx, &peloton.PipelineID{Value: uuid.New()})
+	s.Equal(gocql.ErrNotFound, err)
+}
+
+// TestPipelineOpsClientFail tests failure cases due to ORM Client errors
+func (s *PipelineObjectTestSuite) TestPipelineOpsClientFail() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockClient := ormmocks.NewMockClient(ctrl)
+	mockStore := &Store{oClient: mockClient, metrics: testStore.metrics}
+	db := NewPipelineOps(mockStore)
+	ctx := context.Background()
+
+	gomock.InOrder(
+		mockClient.EXPECT().CreateIfNotExists(gomock.Any(), gomock.Any()).
+			Return(errors.New("create failed")),
+		mockClient.EXPECT().CreateIfNotExists(gomock.Any(), gomock.Any()).
+			Return(nil),
+		mockClient.EXPECT().Create(gomock.Any(), gomock.Any()).
+			Return(errors.New("create active failed")),
+	)
+	mockClient.EXPECT().Get(gomock.Any(), gomock.Any()).
+		Return(errors.New("get failed"))
+	mockClient.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("update failed"))
+	mockClient.EXPECT().GetAll(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("getall failed"))
+	mockClient.EXPECT().Delete(gomock.Any(), gomock.Any()).
+		Return(errors.New("delete failed"))
+
+	err := db.Create(ctx, s.id, s.spec, s.status)
+	s.Equal("create failed", err.Error())
+
+	err = db.Create(ctx, s.id, s.spec, s.status)
+	s.Equal("create active failed", err.Error())
+
+	_, err = db.Get(ctx, s.id)
+	s.Equal("get failed", err.Error())
+
+	err = db.UpdateStatus(ctx, s.id, s.status)
+	s.Equal("update failed", err.Error())
+
+	_, err = db.GetActive(ctx)
+	s.Equal("getall failed", err.Error())
+
+	err = db.DeleteActive(ctx, s.id)
+	s.Equal("delete failed", err.Error())
+}
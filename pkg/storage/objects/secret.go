@@ -20,7 +20,9 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/pkg/common/envelope"
 	"github.com/uber/peloton/pkg/storage/objects/base"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 const (
@@ -49,7 +51,8 @@ type SecretInfoObject struct {
 	JobID string `column:"name=job_id"`
 	// Container mount path of this secret
 	Path string `column:"name=path"`
-	// Secret Data (base64 encoded string)
+	// Secret Data (base64 encoded string), envelope encrypted if
+	// encryption is enabled in the store
 	Data string `column:"name=data"`
 	// Creation time of the secret
 	CreationTime time.Time `column:"name=creation_time"`
@@ -69,7 +72,8 @@ type SecretInfoOps interface {
 		secretID, secretString, secretPath string,
 	) error
 
-	// Get retrieves the SecretInfoObject from the table.
+	// Get retrieves the SecretInfoObject from the table, with the secret
+	// data decrypted.
 	GetSecret(
 		ctx context.Context,
 		secretID string,
@@ -86,6 +90,14 @@ type SecretInfoOps interface {
 		ctx context.Context,
 		secretID string,
 	) error
+
+	// RewrapSecret encrypts the secret data if it is stored unencrypted,
+	// or re-wraps its data key with the current key encryption key.
+	// It returns true if the stored secret was changed.
+	RewrapSecret(
+		ctx context.Context,
+		secretID string,
+	) (bool, error)
 }
 
 // secretInfoOps implements SecretInfoOps interface using a particular Store.
@@ -137,7 +149,12 @@ func (s *secretInfoOps) CreateSecret(
 	now time.Time,
 	secretID, secretString, secretPath string,
 ) error {
-	obj, err := newSecretObject(jobID, now, secretID, secretString, secretPath)
+	data, err := s.encrypt(ctx, secretID, secretString)
+	if err != nil {
+		s.store.metrics.OrmJobMetrics.SecretInfoCreateFail.Inc(1)
+		return err
+	}
+	obj, err := newSecretObject(jobID, now, secretID, data, secretPath)
 	if err != nil {
 		s.store.metrics.OrmJobMetrics.SecretInfoCreateFail.Inc(1)
 		return errors.Wrap(err, "Failed to construct SecretInfoObject")
@@ -163,6 +180,12 @@ func (s *secretInfoOps) GetSecret(
 		s.store.metrics.OrmJobMetrics.SecretInfoGetFail.Inc(1)
 		return nil, err
 	}
+	data, err := s.decrypt(ctx, secretID, secretInfoObject.Data)
+	if err != nil {
+		s.store.metrics.OrmJobMetrics.SecretInfoGetFail.Inc(1)
+		return nil, err
+	}
+	secretInfoObject.Data = data
 	s.store.metrics.OrmJobMetrics.SecretInfoGet.Inc(1)
 	return secretInfoObject, nil
 }
@@ -172,10 +195,15 @@ func (s *secretInfoOps) UpdateSecretData(
 	ctx context.Context,
 	secretID, secretString string,
 ) error {
+	data, err := s.encrypt(ctx, secretID, secretString)
+	if err != nil {
+		s.store.metrics.OrmJobMetrics.SecretInfoUpdateFail.Inc(1)
+		return err
+	}
 	secretInfoObject := &SecretInfoObject{
 		SecretID: secretID,
 		Valid:    true,
-		Data:     secretString,
+		Data:     data,
 	}
 	fieldToUpdate := []string{"Data"}
 	if err := s.store.oClient.Update(ctx, secretInfoObject, fieldToUpdate...); err != nil {
@@ -202,3 +230,81 @@ func (s *secretInfoOps) DeleteSecret(
 	s.store.metrics.OrmJobMetrics.SecretInfoDelete.Inc(1)
 	return nil
 }
+
+// RewrapSecret encrypts or re-wraps the data key of a secret in db
+func (s *secretInfoOps) RewrapSecret(
+	ctx context.Context,
+	secretID string,
+) (bool, error) {
+	if s.store.secretKeyProvider == nil {
+		return false, yarpcerrors.FailedPreconditionErrorf(
+			"secret encryption is not enabled")
+	}
+	secretInfoObject := &SecretInfoObject{
+		SecretID: secretID,
+		Valid:    true,
+	}
+	if err := s.store.oClient.Get(ctx, secretInfoObject); err != nil {
+		s.store.metrics.OrmJobMetrics.SecretInfoRewrapFail.Inc(1)
+		return false, err
+	}
+	data, changed, err := envelope.Rewrap(
+		ctx,
+		s.store.secretKeyProvider,
+		secretInfoObject.Data,
+		[]byte(secretID),
+	)
+	if err != nil {
+		s.store.metrics.OrmJobMetrics.SecretInfoRewrapFail.Inc(1)
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+	secretInfoObject.Data = data
+	if err := s.store.oClient.Update(ctx, secretInfoObject, "Data"); err != nil {
+		s.store.metrics.OrmJobMetrics.SecretInfoRewrapFail.Inc(1)
+		return false, err
+	}
+	s.store.metrics.OrmJobMetrics.SecretInfoRewrap.Inc(1)
+	return true, nil
+}
+
+// encrypt encrypts the secret data if encryption is enabled. The secret
+// id is authenticated along with the data, so that the encrypted data
+// cannot be moved to another secret.
+func (s *secretInfoOps) encrypt(
+	ctx context.Context,
+	secretID, secretString string,
+) (string, error) {
+	if s.store.secretKeyProvider == nil {
+		return secretString, nil
+	}
+	data, err := envelope.Encrypt(
+		ctx, s.store.secretKeyProvider, []byte(secretString), []byte(secretID))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encrypt secret")
+	}
+	return data, nil
+}
+
+// decrypt decrypts secret data stored by encrypt. Secrets stored before
+// encryption was enabled are returned as is.
+func (s *secretInfoOps) decrypt(
+	ctx context.Context,
+	secretID, data string,
+) (string, error) {
+	if !envelope.IsEncrypted(data) {
+		return data, nil
+	}
+	if s.store.secretKeyProvider == nil {
+		return "", yarpcerrors.FailedPreconditionErrorf(
+			"secret %s is encrypted but encryption is not enabled", secretID)
+	}
+	plaintext, err := envelope.Decrypt(
+		ctx, s.store.secretKeyProvider, data, []byte(secretID))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decrypt secret")
+	}
+	return string(plaintext), nil
+}
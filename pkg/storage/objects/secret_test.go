@@ -17,12 +17,18 @@ package objects
 import (
 	"context"
 	"encoding/base64"
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/uber/peloton/pkg/common/envelope"
+	"github.com/uber/peloton/pkg/storage/connectors/memory"
+
 	"github.com/gocql/gocql"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 )
 
 type SecretInfoObjectTestSuite struct {
@@ -84,3 +90,78 @@ func (suite *SecretInfoObjectTestSuite) TestSecretInfoOps() {
 	suite.Error(err)
 	suite.Equal(err, gocql.ErrNotFound)
 }
+
+// TestSecretInfoOpsEncrypted tests that secrets are encrypted at rest
+// when the store has a key provider, and decrypted when read.
+func (suite *SecretInfoObjectTestSuite) TestSecretInfoOpsEncrypted() {
+	ctx := context.Background()
+	connector := memory.NewMemoryConnector()
+	store, err := NewStore(connector, tally.NoopScope)
+	suite.NoError(err)
+	db := NewSecretInfoOps(store)
+
+	// store a secret before encryption is enabled
+	plainSecretID := uuid.New()
+	plainSecret := base64.StdEncoding.EncodeToString([]byte("plain"))
+	suite.NoError(db.CreateSecret(
+		ctx, uuid.New(), time.Now(), plainSecretID, plainSecret, "path"))
+	_, err = db.RewrapSecret(ctx, plainSecretID)
+	suite.Error(err)
+
+	keyFile, err := ioutil.TempFile("", "keyfile")
+	suite.NoError(err)
+	defer os.Remove(keyFile.Name())
+	_, err = keyFile.WriteString("current_key: k1\nkeys:\n  k1: " +
+		base64.StdEncoding.EncodeToString(make([]byte, 32)) + "\n")
+	suite.NoError(err)
+	keyFile.Close()
+	provider, err := envelope.NewKeyFileProvider(keyFile.Name())
+	suite.NoError(err)
+	store.SetSecretKeyProvider(provider)
+
+	secretID := uuid.New()
+	secret := base64.StdEncoding.EncodeToString([]byte("secret"))
+	suite.NoError(db.CreateSecret(
+		ctx, uuid.New(), time.Now(), secretID, secret, "path"))
+
+	// the secret is encrypted at rest
+	stored := &SecretInfoObject{SecretID: secretID, Valid: true}
+	suite.NoError(store.oClient.Get(ctx, stored))
+	suite.True(envelope.IsEncrypted(stored.Data))
+	suite.NotEqual(secret, stored.Data)
+
+	secretInfoObj, err := db.GetSecret(ctx, secretID)
+	suite.NoError(err)
+	suite.Equal(secret, secretInfoObj.Data)
+
+	updated := base64.StdEncoding.EncodeToString([]byte("updated"))
+	suite.NoError(db.UpdateSecretData(ctx, secretID, updated))
+	secretInfoObj, err = db.GetSecret(ctx, secretID)
+	suite.NoError(err)
+	suite.Equal(updated, secretInfoObj.Data)
+
+	// secrets stored before encryption are still readable, and get
+	// encrypted when rewrapped
+	secretInfoObj, err = db.GetSecret(ctx, plainSecretID)
+	suite.NoError(err)
+	suite.Equal(plainSecret, secretInfoObj.Data)
+	changed, err := db.RewrapSecret(ctx, plainSecretID)
+	suite.NoError(err)
+	suite.True(changed)
+	stored = &SecretInfoObject{SecretID: plainSecretID, Valid: true}
+	suite.NoError(store.oClient.Get(ctx, stored))
+	suite.True(envelope.IsEncrypted(stored.Data))
+	secretInfoObj, err = db.GetSecret(ctx, plainSecretID)
+	suite.NoError(err)
+	suite.Equal(plainSecret, secretInfoObj.Data)
+
+	// already wrapped by the current key
+	changed, err = db.RewrapSecret(ctx, secretID)
+	suite.NoError(err)
+	suite.False(changed)
+
+	// encrypted secrets can't be read without the key provider
+	store.SetSecretKeyProvider(nil)
+	_, err = db.GetSecret(ctx, secretID)
+	suite.Error(err)
+}
@@ -15,6 +15,7 @@
 package objects
 
 import (
+	"github.com/uber/peloton/pkg/common/envelope"
 	pelotonstore "github.com/uber/peloton/pkg/storage"
 	"github.com/uber/peloton/pkg/storage/cassandra"
 	escassandra "github.com/uber/peloton/pkg/storage/connectors/cassandra"
@@ -33,6 +34,9 @@ var Objs []base.Object
 type Store struct {
 	oClient orm.Client
 	metrics *pelotonstore.Metrics
+	// secretKeyProvider wraps the data keys of encrypted secrets,
+	// secrets are stored unencrypted if it is nil
+	secretKeyProvider envelope.KeyProvider
 }
 
 // NewCassandraStore creates a new Cassandra storage client
@@ -44,6 +48,12 @@ func NewCassandraStore(
 	if err != nil {
 		return nil, err
 	}
+	return NewStore(connector, scope)
+}
+
+// NewStore creates a new storage client which reads and writes the storage
+// objects using the given connector
+func NewStore(connector orm.Connector, scope tally.Scope) (*Store, error) {
 	// TODO: Load up all objects automatically instead of explicitly adding
 	// them here. Might need to add some Go init() magic to do this.
 	oclient, err := orm.NewClient(connector, Objs...)
@@ -55,3 +65,9 @@ func NewCassandraStore(
 		metrics: pelotonstore.NewMetrics(scope),
 	}, nil
 }
+
+// SetSecretKeyProvider enables envelope encryption of the secrets stored
+// through SecretInfoOps, with data keys wrapped by the given provider
+func (s *Store) SetSecretKeyProvider(provider envelope.KeyProvider) {
+	s.secretKeyProvider = provider
+}
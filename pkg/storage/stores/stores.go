@@ -15,17 +15,50 @@
 package stores
 
 import (
+	"sync"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/uber-go/tally"
+	"github.com/uber/peloton/pkg/common/envelope"
 	"github.com/uber/peloton/pkg/storage"
 	"github.com/uber/peloton/pkg/storage/cassandra"
 	storage_config "github.com/uber/peloton/pkg/storage/config"
+	"github.com/uber/peloton/pkg/storage/connectors/memory"
+	"github.com/uber/peloton/pkg/storage/connectors/sql"
+	memory_store "github.com/uber/peloton/pkg/storage/memory"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
+	"github.com/uber/peloton/pkg/storage/orm"
+)
+
+var (
+	memoryConnectorOnce sync.Once
+	memoryConnector     orm.Connector
 )
 
+// getMemoryConnector returns the in-memory connector shared by all
+// stores created in this process, so that the legacy store and the
+// storage objects see the same tables.
+func getMemoryConnector() orm.Connector {
+	memoryConnectorOnce.Do(func() {
+		memoryConnector = memory.NewMemoryConnector()
+	})
+	return memoryConnector
+}
+
 // MustCreateStore creates a generic store that is needed by peloton
-// and exits if store can't be created
+// and exits if store can't be created. The store is backed by Cassandra
+// unless UseMemory is set, UseSQL only applies to MustCreateORMStore.
 func MustCreateStore(
 	cfg *storage_config.Config, rootScope tally.Scope) storage.Store {
+	if cfg.UseMemory {
+		log.Warn("Using in-memory store, data will not be persisted")
+		store, err := memory_store.NewStore(getMemoryConnector(), rootScope)
+		if err != nil {
+			log.Fatalf("Could not create memory store: %+v", err)
+		}
+		return store
+	}
+
 	log.WithFields(log.Fields{
 		"cassandra_connection": cfg.Cassandra.CassandraConn,
 		"cassandra_config":     cfg.Cassandra,
@@ -41,3 +74,53 @@ func MustCreateStore(
 	}
 	return store
 }
+
+// MustCreateORMStore creates the store for the storage objects, backed by
+// the backend selected in the config, and exits if it can't be created
+func MustCreateORMStore(
+	cfg *storage_config.Config, rootScope tally.Scope) *ormobjects.Store {
+	var store *ormobjects.Store
+	var err error
+	switch {
+	case cfg.UseMemory:
+		store, err = ormobjects.NewStore(getMemoryConnector(), rootScope)
+	case cfg.UseSQL:
+		store, err = newSQLStore(cfg, rootScope)
+	default:
+		store, err = ormobjects.NewCassandraStore(&cfg.Cassandra, rootScope)
+	}
+	if err != nil {
+		log.Fatalf("Could not create storage objects store: %+v", err)
+	}
+
+	keyProvider, err := envelope.NewKeyProvider(&cfg.SecretEncryption)
+	if err != nil {
+		log.Fatalf("Could not create secret key provider: %+v", err)
+	}
+	if keyProvider != nil {
+		log.WithField("current_key", keyProvider.CurrentKeyID()).
+			Info("Secret encryption enabled")
+		store.SetSecretKeyProvider(keyProvider)
+	}
+	return store
+}
+
+// newSQLStore creates the store for the storage objects backed by
+// a relational database
+func newSQLStore(
+	cfg *storage_config.Config, rootScope tally.Scope) (*ormobjects.Store, error) {
+	log.WithFields(log.Fields{
+		"driver":     cfg.SQL.Driver,
+		"migrations": cfg.SQL.Migrations,
+	}).Info("SQL Config")
+	if cfg.AutoMigrate {
+		if errs := cfg.SQL.AutoMigrate(); errs != nil {
+			log.Fatalf("Could not migrate database: %+v", errs)
+		}
+	}
+	connector, err := sql.NewSQLConnector(&cfg.SQL, rootScope)
+	if err != nil {
+		return nil, err
+	}
+	return ormobjects.NewStore(connector, rootScope)
+}